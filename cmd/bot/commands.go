@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/user/githubbot/internal/config"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+	"go.yaml.in/yaml/v3"
+)
+
+// databasePath returns the database given with -db, or else the one of the
+// configuration.
+func databasePath(dbPath, configPath string) (string, error) {
+	if dbPath != "" {
+		return dbPath, nil
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Database.Path, nil
+}
+
+// migrate migrates the database schema to the latest version, or reverts it
+// to an older one with -to.
+func migrate(args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to configuration file")
+	dbPath := flags.String("db", "", "Path to the database, instead of the one in the configuration")
+	to := flags.Int("to", -1, "Schema version to revert to, 0 to drop all tables")
+	flags.Parse(args)
+
+	path, err := databasePath(*dbPath, *configPath)
+	if err != nil {
+		return err
+	}
+
+	// Log the migrations applied
+	if err := logger.Init(logger.Options{}); err != nil {
+		return err
+	}
+
+	db, err := storage.OpenDatabase(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if *to >= 0 {
+		err = db.MigrateDown(*to)
+	} else {
+		err = db.MigrateUp()
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database schema version: %d\n", version)
+	return nil
+}
+
+// checkConfig loads and validates the configuration, then prints it with the
+// defaults and environment variables applied and the secrets redacted.
+func checkConfig(args []string) error {
+	flags := flag.NewFlagSet("check-config", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to configuration file")
+	flags.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(cfg.Redacted().Settings())
+	if err != nil {
+		return err
+	}
+	os.Stdout.Write(out)
+	fmt.Fprintln(os.Stderr, "Configuration is valid")
+	return nil
+}
+
+// exportSubscriptions writes the subscriptions of every chat, or of the one
+// given with -chat, as a JSON object keyed by chat ID. Each value can be
+// restored with /import.
+func exportSubscriptions(args []string) error {
+	flags := flag.NewFlagSet("export-subscriptions", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to configuration file")
+	dbPath := flags.String("db", "", "Path to the database, instead of the one in the configuration")
+	chatID := flags.Int64("chat", 0, "Only export this chat")
+	output := flags.String("o", "", "File to write to, instead of standard output")
+	flags.Parse(args)
+
+	path, err := databasePath(*dbPath, *configPath)
+	if err != nil {
+		return err
+	}
+	db, err := storage.NewDatabase(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	store := storage.NewSubscriptionStore(db)
+
+	chatIDs := []int64{*chatID}
+	if *chatID == 0 {
+		if chatIDs, err = store.GetChatIDs(); err != nil {
+			return err
+		}
+	}
+
+	exports := make(map[string]*storage.ChatExport)
+	for _, id := range chatIDs {
+		export, err := store.ExportChat(id)
+		if err != nil {
+			return fmt.Errorf("failed to export chat %d: %w", id, err)
+		}
+		if len(export.Subscriptions) > 0 || len(export.Users) > 0 || len(export.Topics) > 0 {
+			exports[strconv.FormatInt(id, 10)] = export
+		}
+	}
+
+	data, err := json.MarshalIndent(exports, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d chats to %s\n", len(exports), *output)
+	return nil
+}
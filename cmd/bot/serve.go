@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Chat time zones must resolve on hosts without zoneinfo
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/user/githubbot/internal/api"
+	"github.com/user/githubbot/internal/backup"
+	"github.com/user/githubbot/internal/config"
+	"github.com/user/githubbot/internal/debug"
+	"github.com/user/githubbot/internal/errorreport"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/maintenance"
+	"github.com/user/githubbot/internal/metrics"
+	"github.com/user/githubbot/internal/notifier"
+	"github.com/user/githubbot/internal/server"
+	"github.com/user/githubbot/internal/sink"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/internal/telegram"
+	"github.com/user/githubbot/internal/telegraph"
+	"github.com/user/githubbot/internal/tracing"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// serve runs the bot until it receives SIGINT or SIGTERM.
+func serve(args []string) {
+	// Parse command-line flags
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	configPath := flags.String("config", "", "Path to configuration file")
+	gracefulHandoff := flags.Bool("graceful-handoff", false, "Bind with SO_REUSEPORT so a new instance can take over without downtime")
+	flags.Parse(args)
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		// Try to initialize basic logger for error output
+		logger.Init(logger.Options{Debug: true})
+		logger.Fatal().Err(err).Msg("Failed to load configuration")
+	}
+
+	// Initialize logger
+	err = logger.Init(logger.Options{
+		Debug:      cfg.Log.Level == "debug",
+		Format:     cfg.Log.Format,
+		File:       cfg.Log.File,
+		MaxSize:    cfg.Log.MaxSize,
+		MaxBackups: cfg.Log.MaxBackups,
+		MaxAge:     cfg.Log.MaxAge,
+		Compress:   cfg.Log.Compress,
+	})
+	if err != nil {
+		panic("Failed to initialize logger: " + err.Error())
+	}
+
+	if lang, ok := i18n.Parse(cfg.Telegram.Language); ok {
+		i18n.SetDefault(lang)
+	} else {
+		logger.Warn().Str("language", cfg.Telegram.Language).Msg("Unsupported language, using default")
+	}
+
+	logger.Info().Msg("Starting GitHub Telegram Bot")
+	logger.Info().Str("mode", cfg.GitHub.Mode).Msg("GitHub monitoring mode")
+
+	// Report errors to Sentry (if a DSN is configured)
+	if cfg.ErrorReporting.DSN != "" {
+		reporter, err := errorreport.NewSentry(errorreport.Config{
+			DSN:         cfg.ErrorReporting.DSN,
+			Environment: cfg.ErrorReporting.Environment,
+			SampleRate:  cfg.ErrorReporting.SampleRate,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to set up error reporting")
+		}
+		logger.SetReporter(reporter)
+		defer logger.Flush()
+		logger.Info().Msg("Error reporting enabled")
+	}
+
+	// Trace the event pipeline (if enabled)
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err = tracing.Setup(context.Background(), tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			SampleRatio: cfg.Tracing.SampleRatio,
+		})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Failed to set up tracing")
+		}
+		logger.Info().Str("endpoint", cfg.Tracing.Endpoint).Msg("Tracing enabled")
+	}
+
+	// Initialize database
+	db, err := storage.NewDatabase(cfg.Database.Path)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize database")
+	}
+	defer db.Close()
+
+	store := storage.NewSubscriptionStore(db)
+	store.SetQuotas(cfg.Telegram.MaxSubscriptions, cfg.Telegram.MaxRepos)
+	if cfg.Database.EncryptionKey != "" {
+		cipher, err := storage.NewCipher(cfg.Database.EncryptionKey, cfg.Database.PreviousEncryptionKeys...)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid database encryption key")
+		}
+		store.SetCipher(cipher)
+
+		// Seal what a previous key sealed with the current one
+		rotated, err := store.RotateSecrets()
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to rotate stored secrets")
+		}
+		if rotated > 0 {
+			logger.Info().Int("secrets", rotated).Str("key_id", cipher.KeyID()).Msg("Stored secrets rotated to the current encryption key")
+		}
+	}
+	settings := storage.NewSettingsStore(db)
+	logger.Info().Str("path", cfg.Database.Path).Msg("Database initialized")
+
+	backups := backup.New(db, backup.Config{
+		Dir:      cfg.Backup.Dir,
+		Interval: time.Duration(cfg.Backup.Interval) * time.Hour,
+		Keep:     cfg.Backup.Keep,
+	})
+	if s3 := cfg.Backup.S3; s3.Endpoint != "" {
+		client, err := backup.NewS3(s3.Endpoint, s3.Region, s3.Bucket, s3.Prefix, s3.AccessKey, s3.SecretKey)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("Invalid backup bucket")
+		}
+		backups.SetS3(client)
+	}
+	if cfg.Backup.Enabled {
+		backups.Start()
+	}
+
+	maintainer := maintenance.New(db, store, maintenance.Config{
+		Interval:    time.Duration(cfg.Database.Maintenance.Interval) * time.Hour,
+		EventDays:   cfg.Database.Maintenance.EventDays,
+		HistoryDays: cfg.Database.Maintenance.HistoryDays,
+		AuditDays:   cfg.Database.Maintenance.AuditDays,
+		VacuumEvery: time.Duration(cfg.Database.Maintenance.VacuumDays) * 24 * time.Hour,
+	})
+	maintainer.Start()
+
+	// Initialize GitHub client
+	ghClient := github.NewClient(append([]string{cfg.GitHub.Token}, cfg.GitHub.Tokens...)...)
+
+	// Initialize Telegram bot
+	bot, err := telegram.NewBot(cfg.Telegram.Token, cfg.Telegram.Debug, store, ghClient)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to initialize Telegram bot")
+	}
+
+	// Set GitHub client in handlers for repo validation
+	bot.GetAPI() // ensure bot is ready
+	bot.Handlers().SetSettingsStore(settings)
+	bot.Handlers().SetBackuper(backups)
+	bot.Handlers().SetAdmins(cfg.Telegram.Admins)
+	bot.Handlers().SetDefaultPreset(cfg.Telegram.DefaultPreset)
+	bot.Handlers().SetAccess(cfg.Telegram.Access, cfg.Telegram.AllowedUsers, cfg.Telegram.AllowedChats)
+	bot.Handlers().SetPublicURL(cfg.Server.PublicURL)
+	if cfg.GitHub.OAuthClientID != "" {
+		bot.Handlers().SetDeviceFlow(github.NewDeviceFlow(cfg.GitHub.OAuthClientID))
+	}
+
+	// Create event channel for events (from webhook or poller)
+	eventsCh := make(chan *github.WebhookEvent, 100)
+
+	// Create notifier
+	notify := notifier.NewNotifier(bot.GetAPI(), store)
+	notify.SetGitHubClient(ghClient)
+	notify.SetAssetWait(
+		time.Duration(cfg.Notifier.AssetWait)*time.Minute,
+		time.Duration(cfg.Notifier.AssetRecheck)*time.Minute,
+	)
+	notify.SetDigestHour(cfg.Notifier.DigestHour)
+	notify.SetSettingsStore(settings)
+	notify.SetBatchWindow(time.Duration(cfg.Notifier.BatchWindow) * time.Second)
+	notify.SetParseMode(cfg.Telegram.ParseMode)
+	if cfg.Notifier.DryRun {
+		notify.SetDryRun(true)
+		logger.Warn().Msg("Dry run, notifications are logged instead of sent")
+	}
+	if cfg.Telegraph.Enabled {
+		if cfg.Telegraph.AccessToken != "" {
+			notify.SetTelegraph(telegraph.New(cfg.Telegraph.AccessToken, cfg.Telegraph.AuthorName))
+		} else if client, err := telegraph.CreateAccount(context.Background(), "githubbot", cfg.Telegraph.AuthorName); err != nil {
+			logger.Warn().Err(err).Msg("Failed to create Telegraph account, long texts will be truncated")
+		} else {
+			notify.SetTelegraph(client)
+		}
+	}
+
+	// Destinations other than Telegram that /route sends subscriptions to
+	var routes []string
+	addSink := func(kind string, s sink.Sink) {
+		notify.SetSink(kind, s)
+		for _, target := range s.Targets() {
+			routes = append(routes, sink.Route(kind, target))
+		}
+	}
+	if len(cfg.Discord.Webhooks) > 0 {
+		addSink(sink.KindDiscord, sink.NewDiscord(cfg.Discord.Webhooks))
+	}
+	if len(cfg.Slack.Webhooks) > 0 {
+		addSink(sink.KindSlack, sink.NewSlack(cfg.Slack.Webhooks))
+	}
+	if len(cfg.Matrix.Rooms) > 0 {
+		addSink(sink.KindMatrix, sink.NewMatrix(cfg.Matrix.Homeserver, cfg.Matrix.AccessToken, cfg.Matrix.Rooms))
+	}
+	bot.Handlers().SetRoutes(routes)
+	bot.Handlers().SetPreviewer(notify)
+
+	// Start event processing goroutine
+	go func() {
+		defer logger.ReportPanic()
+		for event := range eventsCh {
+			if err := notify.HandleWebhookEvent(event); err != nil {
+				// Queued webhook deliveries are retried later
+				logger.Error().Err(err).Msg("Failed to handle event")
+				event.Retry()
+				continue
+			}
+			event.Done()
+		}
+	}()
+
+	// Start delivering events queued during quiet hours
+	notify.Start()
+
+	// Start poller if enabled (polling or both mode)
+	var poller *github.Poller
+	if cfg.GitHub.Mode == "polling" || cfg.GitHub.Mode == "both" {
+		poller = github.NewPoller(ghClient, store, eventsCh, cfg.GitHub.PollInterval)
+		poller.SetSettingsStore(settings)
+		poller.SetComplianceEvery(cfg.GitHub.ComplianceCheckEvery)
+		poller.SetPollOverrides(cfg.GitHub.PollOverrides)
+		poller.SetPollBranches(cfg.GitHub.PollBranches)
+		poller.SetEventsAPI(cfg.GitHub.EventsAPI)
+		poller.SetGraphQL(cfg.GitHub.GraphQL)
+		poller.SetConcurrency(cfg.GitHub.PollConcurrency)
+		poller.SetActivityPolicy(activityPolicy(cfg.GitHub.Activity))
+		poller.Start()
+		logger.Info().Int("interval_sec", cfg.GitHub.PollInterval).Msg("Poller started - can monitor ANY public repository")
+	}
+
+	// Set up HTTP router for webhooks
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(logger.ReportPanics)
+	r.Use(middleware.Timeout(30 * time.Second))
+
+	// Health check endpoints: /health is kept for existing setups, /healthz
+	// and /readyz report the checks as JSON
+	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+	api.NewHealthHandler(db, bot.GetAPI(), ghClient, poller).Routes(r)
+
+	// Prometheus metrics endpoint (if enabled), behind the admin token
+	if cfg.Server.Metrics {
+		r.Group(func(r chi.Router) {
+			r.Use(api.RequireToken(cfg.Server.AdminToken))
+			r.Handle("/metrics", metrics.Handler())
+		})
+	}
+
+	// Profiling and runtime variables (if enabled), behind the admin token
+	if cfg.Server.Debug {
+		debug.WatchChannel("events", eventsCh)
+		debug.WatchQueue("telegram_sends", func() (int, int) { return notify.SendQueueDepth(), 0 })
+		r.Route("/debug", func(r chi.Router) {
+			r.Use(api.RequireToken(cfg.Server.AdminToken))
+			r.Mount("/", debug.Handler())
+		})
+		logger.Warn().Msg("Debug endpoints enabled at /debug")
+	}
+
+	// GitHub webhook endpoint (if webhook or both mode)
+	var webhookHandler *github.WebhookHandler
+	if cfg.GitHub.Mode == "webhook" || cfg.GitHub.Mode == "both" {
+		webhookHandler = github.NewWebhookHandler(cfg.GitHub.WebhookSecret, store, eventsCh)
+		webhookHandler.SetRepoSecrets(cfg.GitHub.WebhookSecrets)
+		webhookHandler.OnPing(func(ping *github.PingEvent) {
+			bot.Handlers().NotifyAdmins("webhook.ping", ping.Target, ping.HookID, strings.Join(ping.Events, ", "), ping.Zen)
+		})
+		webhookHandler.Start()
+		r.Post("/webhook", webhookHandler.ServeHTTP)
+		r.Post("/webhook/github", webhookHandler.ServeHTTP)
+		logger.Info().Msg("Webhook endpoint enabled at /webhook")
+	}
+
+	// Custom hooks, authenticated by the token in their URL
+	api.NewCustomHookHandler(store, notify).Routes(r)
+
+	// Admin API (if an admin token is configured)
+	if cfg.Server.AdminToken != "" {
+		dashboard := api.NewDashboardHandler(store, ghClient)
+		r.Route("/api", func(r chi.Router) {
+			r.Use(api.RequireToken(cfg.Server.AdminToken))
+			api.NewSettingsHandler(settings).Routes(r)
+			r.Route("/v1", func(r chi.Router) {
+				subscriptions := api.NewSubscriptionsHandler(store)
+				subscriptions.SetDefaultPreset(cfg.Telegram.DefaultPreset)
+				subscriptions.Routes(r)
+				dashboard.Routes(r)
+			})
+		})
+		// The page only holds the UI, its data comes from the API above
+		r.Get("/dashboard", dashboard.ServePage)
+		logger.Info().Msg("Admin API enabled at /api, dashboard at /dashboard")
+	}
+
+	// Start HTTP server
+	listener, source, err := server.Listen(cfg.ServerAddress(), cfg.Server.ReusePort || *gracefulHandoff)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to listen")
+	}
+
+	httpServer := &http.Server{
+		Addr:    cfg.ServerAddress(),
+		Handler: r,
+	}
+
+	go func() {
+		logger.Info().Str("address", listener.Addr().String()).Str("listener", source).Msg("Starting HTTP server")
+		if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Fatal().Err(err).Msg("HTTP server error")
+		}
+	}()
+
+	// Start Telegram bot
+	bot.Start()
+
+	// Wait for shutdown signal, reloading the configuration on SIGHUP
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range sigCh {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reload(*configPath, store, bot.Handlers(), poller)
+	}
+
+	logger.Info().Msg("Shutting down...")
+
+	// Graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Stop poller if running
+	if poller != nil {
+		poller.Stop()
+	}
+
+	// Stop HTTP server: stop accepting and let in-flight webhooks complete
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Error().Err(err).Msg("HTTP server shutdown error")
+	}
+
+	// Stop handing on queued webhooks, the rest is handled after a restart
+	if webhookHandler != nil {
+		webhookHandler.Stop()
+	}
+
+	// Stop Telegram bot
+	bot.Stop()
+
+	// Stop queued event delivery
+	notify.Stop()
+
+	// Stop scheduled backups and maintenance
+	backups.Stop()
+	maintainer.Stop()
+
+	// Close event channel
+	close(eventsCh)
+
+	// Export the spans left
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error().Err(err).Msg("Tracing shutdown error")
+	}
+
+	logger.Info().Msg("Shutdown complete")
+}
+
+// reload applies the tunables of the configuration file again: log level,
+// quotas, administrators, access, default preset and polling schedule.
+// Everything else, such as tokens, the database or the server address, only
+// changes with a restart.
+func reload(configPath string, store *storage.SubscriptionStore, handlers *telegram.Handlers, poller *github.Poller) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to reload configuration, keeping the current one")
+		return
+	}
+
+	logger.SetDebug(cfg.Log.Level == "debug")
+	store.SetQuotas(cfg.Telegram.MaxSubscriptions, cfg.Telegram.MaxRepos)
+	handlers.SetAdmins(cfg.Telegram.Admins)
+	handlers.SetDefaultPreset(cfg.Telegram.DefaultPreset)
+	handlers.SetAccess(cfg.Telegram.Access, cfg.Telegram.AllowedUsers, cfg.Telegram.AllowedChats)
+	if poller != nil {
+		poller.SetInterval(cfg.GitHub.PollInterval)
+		poller.SetPollOverrides(cfg.GitHub.PollOverrides)
+		poller.SetActivityPolicy(activityPolicy(cfg.GitHub.Activity))
+	}
+	logger.Info().Msg("Configuration reloaded")
+}
+
+// activityPolicy converts the activity configuration to the poller's policy.
+func activityPolicy(cfg config.ActivityConfig) github.ActivityPolicy {
+	return github.ActivityPolicy{
+		Enabled:            cfg.Enabled,
+		ActiveThreshold:    cfg.ActiveThreshold,
+		ModerateThreshold:  cfg.ModerateThreshold,
+		ModerateMultiplier: cfg.ModerateMultiplier,
+		DormantMultiplier:  cfg.DormantMultiplier,
+		MinInterval:        time.Duration(cfg.MinInterval) * time.Second,
+		MaxInterval:        time.Duration(cfg.MaxInterval) * time.Second,
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// maxHookPayload caps the size of the JSON posted to a custom hook.
+const maxHookPayload = 64 << 10
+
+// maxHookMessage caps the length of a message rendered from a payload
+// without a template, leaving room for the header.
+const maxHookMessage = 3500
+
+// CustomSender delivers the messages of custom hooks to their chats.
+type CustomSender interface {
+	SendCustom(ctx context.Context, chatID int64, hookName, text string) error
+}
+
+// CustomHookHandler receives JSON from other services, such as uptime
+// monitors or CI systems, at the URLs chats created with /hook, and sends
+// it to the chat rendered with the hook's template. The token in the URL
+// authenticates the sender.
+type CustomHookHandler struct {
+	store  storage.Store
+	sender CustomSender
+}
+
+// NewCustomHookHandler creates a new custom hook handler.
+func NewCustomHookHandler(store storage.Store, sender CustomSender) *CustomHookHandler {
+	return &CustomHookHandler{store: store, sender: sender}
+}
+
+// Routes registers the custom hook endpoint on r.
+func (h *CustomHookHandler) Routes(r chi.Router) {
+	r.Post("/webhook/custom/{token}", h.receive)
+}
+
+// receive renders and delivers a payload posted to a hook.
+func (h *CustomHookHandler) receive(w http.ResponseWriter, r *http.Request) {
+	hook, err := h.store.GetCustomHookByToken(chi.URLParam(r, "token"))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get custom hook")
+		writeError(w, http.StatusInternalServerError, "failed to get hook")
+		return
+	}
+	if hook == nil {
+		writeError(w, http.StatusNotFound, "unknown hook")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHookPayload))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "payload too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "failed to read body")
+		return
+	}
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeError(w, http.StatusBadRequest, "body is not JSON")
+		return
+	}
+
+	text, err := renderHookPayload(hook, payload)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "template failed: "+err.Error())
+		return
+	}
+	if err := h.sender.SendCustom(r.Context(), hook.ChatID, hook.Name, text); err != nil {
+		logger.Error().Err(err).Int64("chat_id", hook.ChatID).Str("hook", hook.Name).Msg("Failed to send custom hook message")
+		writeError(w, http.StatusBadGateway, "failed to send message")
+		return
+	}
+	if err := h.store.MarkCustomHookUsed(hook.ID); err != nil {
+		logger.Warn().Err(err).Int64("hook_id", hook.ID).Msg("Failed to mark custom hook used")
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// renderHookPayload renders a payload with the template of its hook, or as
+// indented JSON if the hook has none.
+func renderHookPayload(hook *storage.CustomHook, payload interface{}) (string, error) {
+	if hook.Template != "" {
+		text, err := github.RenderPayloadTemplate(hook.Template, payload)
+		if err == nil && text == "" {
+			err = errors.New("template rendered an empty message")
+		}
+		return text, err
+	}
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	text := string(data)
+	if len(text) > maxHookMessage {
+		text = strings.ToValidUTF8(text[:maxHookMessage], "") + "\n…"
+	}
+	return text, nil
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// maxFailuresLimit caps how many failed deliveries one request returns.
+const maxFailuresLimit = 100
+
+// dashboardPage is the web UI. It holds no data itself: the page asks for the
+// admin token and reads everything from the admin API with it.
+//
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// DashboardHandler serves the web dashboard and the overview endpoints it
+// uses next to the subscriptions API.
+type DashboardHandler struct {
+	store  storage.Store
+	github *github.Client
+}
+
+// NewDashboardHandler creates a new dashboard handler.
+func NewDashboardHandler(store storage.Store, client *github.Client) *DashboardHandler {
+	return &DashboardHandler{store: store, github: client}
+}
+
+// statsResponse is the JSON representation of the totals over all chats.
+type statsResponse struct {
+	Chats         int         `json:"chats"`
+	InactiveChats int         `json:"inactive_chats"`
+	Subscriptions int         `json:"subscriptions"`
+	Repos         int         `json:"repos"`
+	UserFollows   int         `json:"user_follows"`
+	TopicFollows  int         `json:"topic_follows"`
+	EventsRecent  int         `json:"events_24h"`
+	PendingOutbox int         `json:"pending_deliveries"`
+	QueuedEvents  int         `json:"queued_events"`
+	GitHub        *quotaState `json:"github,omitempty"` // Omitted until GitHub reported a rate limit
+}
+
+// quotaState is the JSON representation of the GitHub rate limit.
+type quotaState struct {
+	Limit       int        `json:"limit"`
+	Remaining   int        `json:"remaining"`
+	Reset       time.Time  `json:"reset"`
+	PausedUntil *time.Time `json:"paused_until,omitempty"`
+}
+
+// failureResponse is the JSON representation of a notification whose
+// delivery was given up.
+type failureResponse struct {
+	ID        int64     `json:"id"`
+	ChatID    int64     `json:"chat_id"`
+	TargetID  int64     `json:"target_id"`
+	Sink      string    `json:"sink,omitempty"`
+	Text      string    `json:"text"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Routes registers the overview endpoints on r.
+func (h *DashboardHandler) Routes(r chi.Router) {
+	r.Get("/stats", h.stats)
+	r.Get("/deliveries/failed", h.listFailures)
+}
+
+// ServePage serves the dashboard page.
+func (h *DashboardHandler) ServePage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Write(dashboardPage)
+}
+
+// stats returns the totals shown by /admin stats, with the GitHub quota.
+func (h *DashboardHandler) stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.store.GetBotStats(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get bot stats")
+		writeError(w, http.StatusInternalServerError, "failed to read stats")
+		return
+	}
+
+	resp := statsResponse{
+		Chats:         stats.Chats,
+		InactiveChats: stats.InactiveChats,
+		Subscriptions: stats.Subscriptions,
+		Repos:         stats.Repos,
+		UserFollows:   stats.UserFollows,
+		TopicFollows:  stats.TopicFollows,
+		EventsRecent:  stats.EventsRecent,
+		PendingOutbox: stats.PendingOutbox,
+		QueuedEvents:  stats.QueuedEvents,
+	}
+	if h.github != nil {
+		if rate := h.github.RateStatus(); rate.Limit > 0 {
+			resp.GitHub = &quotaState{Limit: rate.Limit, Remaining: rate.Remaining, Reset: rate.Reset}
+			if rate.Paused(time.Now()) {
+				resp.GitHub.PausedUntil = &rate.PausedUntil
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// listFailures returns the latest notifications whose delivery was given up,
+// at most ?limit= of them.
+func (h *DashboardHandler) listFailures(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxFailuresLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxFailuresLimit))
+			return
+		}
+		limit = n
+	}
+
+	messages, err := h.store.GetFailedOutbox(limit)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get failed deliveries")
+		writeError(w, http.StatusInternalServerError, "failed to read deliveries")
+		return
+	}
+	resp := make([]failureResponse, 0, len(messages))
+	for _, m := range messages {
+		resp = append(resp, failureResponse{
+			ID:        m.ID,
+			ChatID:    m.ChatID,
+			TargetID:  m.TargetID,
+			Sink:      m.Sink,
+			Text:      m.Text,
+			Error:     m.Error,
+			CreatedAt: m.CreatedAt,
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
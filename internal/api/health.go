@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/storage"
+)
+
+// checkTimeout bounds each dependency check of a readiness probe.
+const checkTimeout = 5 * time.Second
+
+// Check results. A warning is reported without failing the probe.
+const (
+	statusOK   = "ok"
+	statusWarn = "warn"
+	statusFail = "fail"
+)
+
+// HealthHandler serves the liveness and readiness probes.
+//
+// /healthz fails only when the process is alive but stuck, which is when the
+// poller stopped completing rounds; restarting the bot helps then. /readyz
+// also checks the database, Telegram and the GitHub rate limit.
+type HealthHandler struct {
+	db       *storage.Database
+	telegram *tgbotapi.BotAPI
+	github   *github.Client
+	poller   *github.Poller // nil unless polling
+}
+
+// NewHealthHandler creates a new health handler. The poller may be nil.
+func NewHealthHandler(db *storage.Database, telegram *tgbotapi.BotAPI, client *github.Client, poller *github.Poller) *HealthHandler {
+	return &HealthHandler{db: db, telegram: telegram, github: client, poller: poller}
+}
+
+// checkResult is the JSON representation of a single check.
+type checkResult struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Latency int64  `json:"latency_ms,omitempty"`
+
+	Username    string     `json:"username,omitempty"`     // Telegram
+	Limit       int        `json:"limit,omitempty"`        // GitHub
+	Remaining   *int       `json:"remaining,omitempty"`    // GitHub
+	Reset       *time.Time `json:"reset,omitempty"`        // GitHub
+	PausedUntil *time.Time `json:"paused_until,omitempty"` // GitHub
+	Leader      *bool      `json:"leader,omitempty"`       // Poller
+	LastSuccess *time.Time `json:"last_success,omitempty"` // Poller
+}
+
+// healthResponse is the JSON body of a probe.
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+// Routes registers the probe endpoints on r.
+func (h *HealthHandler) Routes(r chi.Router) {
+	r.Get("/healthz", h.liveness)
+	r.Get("/readyz", h.readiness)
+}
+
+// liveness reports whether the process makes progress.
+func (h *HealthHandler) liveness(w http.ResponseWriter, r *http.Request) {
+	checks := make(map[string]checkResult)
+	if h.poller != nil {
+		checks["poller"] = h.checkPoller()
+	}
+	writeHealth(w, checks)
+}
+
+// readiness reports whether the bot and everything it depends on work.
+func (h *HealthHandler) readiness(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]checkResult{
+		"database": h.checkDatabase(r.Context()),
+		"telegram": h.checkTelegram(r.Context()),
+		"github":   h.checkGitHub(),
+	}
+	if h.poller != nil {
+		checks["poller"] = h.checkPoller()
+	}
+	writeHealth(w, checks)
+}
+
+// checkDatabase runs a trivial query.
+func (h *HealthHandler) checkDatabase(ctx context.Context) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	started := time.Now()
+	var one int
+	if err := h.db.GetContext(ctx, &one, `SELECT 1`); err != nil {
+		return checkResult{Status: statusFail, Error: err.Error()}
+	}
+	return checkResult{Status: statusOK, Latency: time.Since(started).Milliseconds()}
+}
+
+// checkTelegram asks Telegram who the bot is, which fails if the token was
+// revoked or the API is unreachable.
+func (h *HealthHandler) checkTelegram(ctx context.Context) checkResult {
+	type getMe struct {
+		user tgbotapi.User
+		err  error
+	}
+	started := time.Now()
+	done := make(chan getMe, 1)
+	go func() {
+		user, err := h.telegram.GetMe()
+		done <- getMe{user, err}
+	}()
+
+	timer := time.NewTimer(checkTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return checkResult{Status: statusFail, Error: res.err.Error()}
+		}
+		return checkResult{Status: statusOK, Username: res.user.UserName, Latency: time.Since(started).Milliseconds()}
+	case <-timer.C:
+		return checkResult{Status: statusFail, Error: "timed out"}
+	case <-ctx.Done():
+		return checkResult{Status: statusFail, Error: ctx.Err().Error()}
+	}
+}
+
+// checkGitHub reports the rate limit last seen. An exhausted quota is only a
+// warning: the bot recovers by itself when the window resets.
+func (h *HealthHandler) checkGitHub() checkResult {
+	now := time.Now()
+	rate := h.github.RateStatus()
+	result := checkResult{Status: statusOK}
+	if rate.Limit > 0 {
+		result.Limit = rate.Limit
+		result.Remaining = &rate.Remaining
+		result.Reset = &rate.Reset
+	}
+	if rate.Paused(now) {
+		result.Status = statusWarn
+		result.Error = "rate limit exceeded"
+		result.PausedUntil = &rate.PausedUntil
+	}
+	return result
+}
+
+// checkPoller fails when the leading poller has not completed a round for
+// too long. A standby instance waiting for the lease is fine.
+func (h *HealthHandler) checkPoller() checkResult {
+	status := h.poller.Status(time.Now())
+	result := checkResult{Status: statusOK, Leader: &status.Leader}
+	if !status.LastSuccess.IsZero() {
+		result.LastSuccess = &status.LastSuccess
+	}
+	if status.Stalled {
+		result.Status = statusFail
+		result.Error = "no polling round completed recently"
+	}
+	return result
+}
+
+// writeHealth writes the checks, with 503 Service Unavailable if any failed.
+func writeHealth(w http.ResponseWriter, checks map[string]checkResult) {
+	resp := healthResponse{Status: statusOK, Checks: checks}
+	code := http.StatusOK
+	for _, check := range checks {
+		if check.Status == statusFail {
+			resp.Status = statusFail
+			code = http.StatusServiceUnavailable
+			break
+		}
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, code, resp)
+}
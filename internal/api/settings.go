@@ -0,0 +1,123 @@
+// Package api provides the HTTP admin API and the health probes.
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// SettingsHandler exposes the allow-listed runtime settings over HTTP.
+type SettingsHandler struct {
+	settings *storage.SettingsStore
+}
+
+// NewSettingsHandler creates a new settings handler.
+func NewSettingsHandler(settings *storage.SettingsStore) *SettingsHandler {
+	return &SettingsHandler{settings: settings}
+}
+
+// settingResponse is the JSON representation of a setting.
+type settingResponse struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Routes registers the settings endpoints on r.
+func (h *SettingsHandler) Routes(r chi.Router) {
+	r.Get("/settings", h.list)
+	r.Get("/settings/{key}", h.get)
+	r.Put("/settings/{key}", h.set)
+}
+
+// list returns all editable settings with their current values.
+func (h *SettingsHandler) list(w http.ResponseWriter, r *http.Request) {
+	keys := storage.EditableSettingKeys()
+	resp := make([]settingResponse, 0, len(keys))
+	for _, key := range keys {
+		value, err := h.settings.GetString(key, "")
+		if err != nil {
+			logger.Error().Err(err).Str("key", key).Msg("Failed to get setting")
+			writeError(w, http.StatusInternalServerError, "failed to read settings")
+			return
+		}
+		resp = append(resp, settingResponse{Key: key, Value: value})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// get returns a single editable setting.
+func (h *SettingsHandler) get(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+	if !storage.IsEditableSetting(key) {
+		writeError(w, http.StatusForbidden, storage.ErrSettingNotEditable.Error())
+		return
+	}
+
+	value, err := h.settings.GetString(key, "")
+	if err != nil {
+		logger.Error().Err(err).Str("key", key).Msg("Failed to get setting")
+		writeError(w, http.StatusInternalServerError, "failed to read setting")
+		return
+	}
+	writeJSON(w, http.StatusOK, settingResponse{Key: key, Value: value})
+}
+
+// set changes a single editable setting.
+func (h *SettingsHandler) set(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "key")
+
+	var req struct {
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.settings.SetEditable(key, req.Value); err != nil {
+		if errors.Is(err, storage.ErrSettingNotEditable) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	logger.Info().Str("key", key).Str("value", req.Value).Msg("Setting changed via API")
+	writeJSON(w, http.StatusOK, settingResponse{Key: key, Value: req.Value})
+}
+
+// RequireToken returns middleware that checks for a matching bearer token.
+func RequireToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Error().Err(err).Msg("Failed to write JSON response")
+	}
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
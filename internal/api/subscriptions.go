@@ -0,0 +1,368 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// maxEventsLimit caps how many events one request to /events returns.
+const maxEventsLimit = 100
+
+// SubscriptionsHandler lets external tooling manage chats and their
+// subscriptions, like the Telegram commands do.
+type SubscriptionsHandler struct {
+	store         storage.Store
+	defaultEvents []storage.EventType // Events of subscriptions created without any
+}
+
+// NewSubscriptionsHandler creates a new subscriptions handler.
+func NewSubscriptionsHandler(store storage.Store) *SubscriptionsHandler {
+	return &SubscriptionsHandler{store: store, defaultEvents: storage.DefaultEvents()}
+}
+
+// SetDefaultPreset sets the preset of subscriptions created without events,
+// like the Telegram handlers' default preset.
+func (h *SubscriptionsHandler) SetDefaultPreset(name string) {
+	if events, ok := storage.PresetEvents(name); ok {
+		h.defaultEvents = events
+	}
+}
+
+// chatResponse is the JSON representation of a chat.
+type chatResponse struct {
+	ChatID    int64     `json:"chat_id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Active    bool      `json:"active"`
+	Paused    bool      `json:"paused"`
+	Language  string    `json:"language,omitempty"`
+	Timezone  string    `json:"timezone,omitempty"`
+	ChannelID int64     `json:"channel_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// subscriptionResponse is the JSON representation of a subscription.
+type subscriptionResponse struct {
+	ID         int64               `json:"id"`
+	ChatID     int64               `json:"chat_id"`
+	Repo       string              `json:"repo"`
+	Events     []storage.EventType `json:"events"`
+	Digest     string              `json:"digest,omitempty"`
+	Sink       string              `json:"sink,omitempty"`
+	MutedUntil *time.Time          `json:"muted_until,omitempty"`
+	Via        string              `json:"via,omitempty"`
+	CreatedAt  time.Time           `json:"created_at"`
+}
+
+// eventResponse is the JSON representation of an event in the history.
+type eventResponse struct {
+	ID         int64           `json:"id"`
+	Repo       string          `json:"repo"`
+	Type       string          `json:"type"`
+	Source     string          `json:"source"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Routes registers the endpoints on r.
+func (h *SubscriptionsHandler) Routes(r chi.Router) {
+	r.Get("/chats", h.listChats)
+	r.Get("/chats/{chatID}", h.getChat)
+	r.Get("/subscriptions", h.listSubscriptions)
+	r.Post("/subscriptions", h.subscribe)
+	r.Delete("/subscriptions", h.unsubscribe)
+	r.Get("/events", h.listEvents)
+}
+
+// listChats returns all chats the bot knows.
+func (h *SubscriptionsHandler) listChats(w http.ResponseWriter, r *http.Request) {
+	chats, err := h.store.GetChats()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get chats")
+		writeError(w, http.StatusInternalServerError, "failed to read chats")
+		return
+	}
+	resp := make([]chatResponse, 0, len(chats))
+	for i := range chats {
+		resp = append(resp, newChatResponse(&chats[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// getChat returns a single chat.
+func (h *SubscriptionsHandler) getChat(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(chi.URLParam(r, "chatID"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chat ID")
+		return
+	}
+	chat, ok := h.chat(w, chatID)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, newChatResponse(chat))
+}
+
+// listSubscriptions returns the subscriptions of the chat given with
+// ?chat_id=, of the repository given with ?repo=owner/name, or all.
+func (h *SubscriptionsHandler) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var subs []storage.Subscription
+	var err error
+	switch {
+	case query.Get("chat_id") != "":
+		chatID, parseErr := strconv.ParseInt(query.Get("chat_id"), 10, 64)
+		if parseErr != nil {
+			writeError(w, http.StatusBadRequest, "invalid chat_id")
+			return
+		}
+		subs, err = h.store.GetSubscriptionsByChat(chatID)
+	case query.Get("repo") != "":
+		owner, repo, ok := parseRepo(query.Get("repo"))
+		if !ok {
+			writeError(w, http.StatusBadRequest, "repo must be owner/name")
+			return
+		}
+		subs, err = h.store.GetSubscriptionsByRepo(owner, repo)
+	default:
+		subs, err = h.allSubscriptions()
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get subscriptions")
+		writeError(w, http.StatusInternalServerError, "failed to read subscriptions")
+		return
+	}
+
+	resp := make([]subscriptionResponse, 0, len(subs))
+	for i := range subs {
+		resp = append(resp, newSubscriptionResponse(&subs[i]))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// subscribe subscribes a chat to a repository, or changes the events of an
+// existing subscription. Without events the default events are used.
+func (h *SubscriptionsHandler) subscribe(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChatID int64    `json:"chat_id"`
+		Repo   string   `json:"repo"`
+		Events []string `json:"events"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	owner, repo, ok := parseRepo(req.Repo)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "repo must be owner/name")
+		return
+	}
+	events := h.defaultEvents
+	if len(req.Events) > 0 {
+		var err error
+		if events, err = storage.ParseEventTypes(strings.Join(req.Events, ",")); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if _, ok := h.chat(w, req.ChatID); !ok {
+		return
+	}
+
+	// A private repository is read with a chat's token or the linked account
+	// of a user, whose access has to be checked by subscribing in Telegram
+	state, err := h.store.GetRepoState(owner, repo)
+	if err != nil {
+		logger.Error().Err(err).Str("repo", req.Repo).Msg("Failed to get repository state")
+		writeError(w, http.StatusInternalServerError, "failed to subscribe")
+		return
+	}
+	if state != nil && (state.CredentialChatID != 0 || state.CredentialUserID != 0) {
+		writeError(w, http.StatusForbidden, "private repositories can only be subscribed to in Telegram")
+		return
+	}
+
+	if err := h.store.Subscribe(req.ChatID, owner, repo, events); err != nil {
+		if errors.Is(err, storage.ErrChatQuotaExceeded) || errors.Is(err, storage.ErrRepoQuotaExceeded) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		logger.Error().Err(err).Int64("chat_id", req.ChatID).Str("repo", req.Repo).Msg("Failed to subscribe")
+		writeError(w, http.StatusInternalServerError, "failed to subscribe")
+		return
+	}
+	sub, err := h.store.GetSubscription(req.ChatID, owner, repo)
+	if err != nil || sub == nil {
+		logger.Error().Err(err).Int64("chat_id", req.ChatID).Str("repo", req.Repo).Msg("Failed to get subscription")
+		writeError(w, http.StatusInternalServerError, "failed to read subscription")
+		return
+	}
+
+	logger.Info().Int64("chat_id", req.ChatID).Str("repo", req.Repo).Msg("Subscribed via API")
+	writeJSON(w, http.StatusCreated, newSubscriptionResponse(sub))
+}
+
+// unsubscribe removes the subscription of the chat given with ?chat_id= to
+// the repository given with ?repo=owner/name.
+func (h *SubscriptionsHandler) unsubscribe(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	chatID, err := strconv.ParseInt(query.Get("chat_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid chat_id")
+		return
+	}
+	owner, repo, ok := parseRepo(query.Get("repo"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "repo must be owner/name")
+		return
+	}
+
+	sub, err := h.store.GetSubscription(chatID, owner, repo)
+	if err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to get subscription")
+		writeError(w, http.StatusInternalServerError, "failed to read subscription")
+		return
+	}
+	if sub == nil {
+		writeError(w, http.StatusNotFound, "subscription not found")
+		return
+	}
+	if err := h.store.Unsubscribe(chatID, owner, repo); err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to unsubscribe")
+		writeError(w, http.StatusInternalServerError, "failed to unsubscribe")
+		return
+	}
+
+	logger.Info().Int64("chat_id", chatID).Str("repo", owner+"/"+repo).Msg("Unsubscribed via API")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listEvents returns the latest events, at most ?limit= of them, of all
+// repositories or of the one given with ?repo=owner/name.
+func (h *SubscriptionsHandler) listEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var owner, repo string
+	if s := query.Get("repo"); s != "" {
+		var ok bool
+		if owner, repo, ok = parseRepo(s); !ok {
+			writeError(w, http.StatusBadRequest, "repo must be owner/name")
+			return
+		}
+	}
+	limit := 20
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxEventsLimit {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxEventsLimit))
+			return
+		}
+		limit = n
+	}
+
+	var entries []storage.HistoryEntry
+	var err error
+	if repo != "" {
+		entries, err = h.store.GetHistory(owner, repo, limit)
+	} else {
+		entries, err = h.store.GetRecentHistory(limit)
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("repo", owner+"/"+repo).Msg("Failed to get event history")
+		writeError(w, http.StatusInternalServerError, "failed to read events")
+		return
+	}
+	resp := make([]eventResponse, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, eventResponse{
+			ID:         e.ID,
+			Repo:       e.RepoOwner + "/" + e.RepoName,
+			Type:       e.EventType,
+			Source:     e.Source,
+			OccurredAt: e.Time(),
+			Payload:    json.RawMessage(e.Payload),
+		})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// allSubscriptions returns the subscriptions of all chats.
+func (h *SubscriptionsHandler) allSubscriptions() ([]storage.Subscription, error) {
+	chatIDs, err := h.store.GetChatIDs()
+	if err != nil {
+		return nil, err
+	}
+	var subs []storage.Subscription
+	for _, chatID := range chatIDs {
+		chatSubs, err := h.store.GetSubscriptionsByChat(chatID)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, chatSubs...)
+	}
+	return subs, nil
+}
+
+// chat looks up a chat, writing an error response if it is unknown or the
+// lookup fails.
+func (h *SubscriptionsHandler) chat(w http.ResponseWriter, chatID int64) (*storage.Chat, bool) {
+	chat, err := h.store.GetChat(chatID)
+	if err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+		writeError(w, http.StatusInternalServerError, "failed to read chat")
+		return nil, false
+	}
+	if chat == nil {
+		writeError(w, http.StatusNotFound, "chat not found")
+		return nil, false
+	}
+	return chat, true
+}
+
+// parseRepo splits an "owner/name" repository.
+func parseRepo(s string) (owner, repo string, ok bool) {
+	owner, repo, ok = strings.Cut(strings.TrimSpace(s), "/")
+	return owner, repo, ok && owner != "" && repo != "" && !strings.Contains(repo, "/")
+}
+
+func newChatResponse(chat *storage.Chat) chatResponse {
+	return chatResponse{
+		ChatID:    chat.ChatID,
+		Type:      chat.ChatType,
+		Title:     chat.Title,
+		Active:    chat.Active,
+		Paused:    chat.Paused,
+		Language:  chat.Language,
+		Timezone:  chat.Timezone,
+		ChannelID: chat.ChannelID,
+		CreatedAt: chat.CreatedAt,
+	}
+}
+
+func newSubscriptionResponse(sub *storage.Subscription) subscriptionResponse {
+	events, err := storage.ParseEvents(sub.Events)
+	if err != nil {
+		logger.Warn().Err(err).Int64("subscription_id", sub.ID).Msg("Failed to parse subscribed events")
+	}
+	resp := subscriptionResponse{
+		ID:        sub.ID,
+		ChatID:    sub.ChatID,
+		Repo:      sub.RepoOwner + "/" + sub.RepoName,
+		Events:    events,
+		Digest:    sub.Digest,
+		Sink:      sub.Sink,
+		Via:       sub.Via,
+		CreatedAt: sub.CreatedAt,
+	}
+	if sub.MutedUntil.Valid {
+		resp.MutedUntil = &sub.MutedUntil.Time
+	}
+	return resp
+}
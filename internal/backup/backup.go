@@ -0,0 +1,253 @@
+// Package backup snapshots the SQLite database on a schedule, keeping the
+// newest snapshots in a directory and, optionally, in an S3-compatible bucket.
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Snapshots are named after the time they were taken, so sorting their
+// names sorts them by age.
+const (
+	namePrefix = "bot-"
+	nameSuffix = ".db"
+	timeLayout = "20060102-150405"
+)
+
+// uploadTimeout bounds how long a snapshot upload may take.
+const uploadTimeout = 10 * time.Minute
+
+// Config holds where and how often snapshots are taken.
+type Config struct {
+	Dir      string        // Directory snapshots are written to
+	Interval time.Duration // Time between scheduled snapshots
+	Keep     int           // Snapshots kept per location, 0 to keep all
+}
+
+// Result describes a snapshot taken.
+type Result struct {
+	Name     string
+	Size     int64
+	Uploaded bool // Whether it was copied to the bucket
+}
+
+// Backuper takes database snapshots.
+type Backuper struct {
+	db     *storage.Database
+	config Config
+	s3     *S3Client
+
+	mu     sync.Mutex // One snapshot at a time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a backuper for a database.
+func New(db *storage.Database, config Config) *Backuper {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Backuper{
+		db:     db,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// SetS3 copies each snapshot to a bucket as well.
+func (b *Backuper) SetS3(client *S3Client) {
+	b.s3 = client
+}
+
+// Start takes snapshots every interval in the background. The first one is
+// due an interval after the newest snapshot in the directory, so restarts do
+// not postpone it.
+func (b *Backuper) Start() {
+	if b.config.Interval <= 0 {
+		return
+	}
+	b.wg.Add(1)
+	go b.run()
+	logger.Info().Str("dir", b.config.Dir).Dur("interval", b.config.Interval).Msg("Database backups scheduled")
+}
+
+// Stop stops the scheduled snapshots, waiting for one in progress.
+func (b *Backuper) Stop() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+// run takes the scheduled snapshots.
+func (b *Backuper) run() {
+	defer b.wg.Done()
+	defer logger.ReportPanic()
+
+	for {
+		wait := time.Duration(0)
+		if last, ok := b.lastSnapshot(); ok {
+			wait = time.Until(last.Add(b.config.Interval))
+		}
+		// An overdue snapshot still leaves the bot a moment to start up
+		if !b.sleep(max(wait, time.Minute)) {
+			return
+		}
+
+		result, err := b.Run(b.ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Scheduled database backup failed")
+			// Try again after an interval rather than right away
+			if !b.sleep(b.config.Interval) {
+				return
+			}
+			continue
+		}
+		logger.Info().Str("name", result.Name).Int64("bytes", result.Size).Bool("uploaded", result.Uploaded).Msg("Database backed up")
+	}
+}
+
+// sleep waits for d, reporting false if the backuper was stopped meanwhile.
+func (b *Backuper) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-b.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Run takes a snapshot now, uploads it if a bucket is set and deletes the
+// snapshots beyond the retention.
+func (b *Backuper) Run(ctx context.Context) (*Result, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.MkdirAll(b.config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := namePrefix + time.Now().UTC().Format(timeLayout) + nameSuffix
+	path := filepath.Join(b.config.Dir, name)
+	if err := b.db.Snapshot(path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Name: name, Size: info.Size()}
+
+	if err := b.pruneDir(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to delete old backups")
+	}
+
+	if b.s3 == nil {
+		return result, nil
+	}
+	uploadCtx, cancel := context.WithTimeout(ctx, uploadTimeout)
+	defer cancel()
+	if err := b.s3.PutFile(uploadCtx, name, path); err != nil {
+		return result, fmt.Errorf("snapshot %s was kept locally but not uploaded: %w", name, err)
+	}
+	result.Uploaded = true
+	if err := b.pruneBucket(uploadCtx); err != nil {
+		logger.Warn().Err(err).Msg("Failed to delete old backups from the bucket")
+	}
+	return result, nil
+}
+
+// lastSnapshot returns when the newest snapshot in the directory was taken.
+func (b *Backuper) lastSnapshot() (time.Time, bool) {
+	names, err := b.localSnapshots()
+	if err != nil || len(names) == 0 {
+		return time.Time{}, false
+	}
+	newest := names[len(names)-1]
+	taken, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(newest, namePrefix), nameSuffix))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return taken, true
+}
+
+// localSnapshots returns the names of the snapshots in the directory, oldest
+// first.
+func (b *Backuper) localSnapshots() ([]string, error) {
+	entries, err := os.ReadDir(b.config.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isSnapshot(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// pruneDir deletes the oldest snapshots in the directory beyond the retention.
+func (b *Backuper) pruneDir() error {
+	if b.config.Keep <= 0 {
+		return nil
+	}
+	names, err := b.localSnapshots()
+	if err != nil {
+		return err
+	}
+	for _, name := range expired(names, b.config.Keep) {
+		if err := os.Remove(filepath.Join(b.config.Dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pruneBucket deletes the oldest snapshots in the bucket beyond the retention.
+func (b *Backuper) pruneBucket(ctx context.Context) error {
+	if b.config.Keep <= 0 {
+		return nil
+	}
+	all, err := b.s3.List(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, name := range all {
+		if isSnapshot(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range expired(names, b.config.Keep) {
+		if err := b.s3.Delete(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSnapshot reports whether a file name is one of a snapshot.
+func isSnapshot(name string) bool {
+	return strings.HasPrefix(name, namePrefix) && strings.HasSuffix(name, nameSuffix)
+}
+
+// expired returns the names beyond the newest keep of a sorted list.
+func expired(names []string, keep int) []string {
+	if len(names) <= keep {
+		return nil
+	}
+	return names[:len(names)-keep]
+}
@@ -3,6 +3,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"reflect"
 	"strings"
 
 	"github.com/spf13/viper"
@@ -10,42 +12,192 @@ import (
 
 // Config represents the application configuration.
 type Config struct {
-	Telegram TelegramConfig `mapstructure:"telegram"`
-	GitHub   GitHubConfig   `mapstructure:"github"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Log      LogConfig      `mapstructure:"log"`
+	Telegram  TelegramConfig  `mapstructure:"telegram"`
+	GitHub    GitHubConfig    `mapstructure:"github"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Log       LogConfig       `mapstructure:"log"`
+	Notifier  NotifierConfig  `mapstructure:"notifier"`
+	Telegraph TelegraphConfig `mapstructure:"telegraph"`
+	Discord   DiscordConfig   `mapstructure:"discord"`
+	Slack     SlackConfig     `mapstructure:"slack"`
+	Matrix    MatrixConfig    `mapstructure:"matrix"`
+	Backup    BackupConfig    `mapstructure:"backup"`
+	Tracing   TracingConfig   `mapstructure:"tracing"`
+
+	ErrorReporting ErrorReportingConfig `mapstructure:"error_reporting"`
 }
 
 // TelegramConfig holds Telegram bot configuration.
 type TelegramConfig struct {
-	Token string `mapstructure:"token"`
-	Debug bool   `mapstructure:"debug"`
+	Token     string  `mapstructure:"token"`
+	TokenFile string  `mapstructure:"token_file"` // File the token is read from instead, e.g. a Docker secret
+	Debug     bool    `mapstructure:"debug"`
+	Admins    []int64 `mapstructure:"admins"`     // Telegram user IDs allowed to run admin commands
+	Language  string  `mapstructure:"language"`   // Reply language of chats without /language: en or zh
+	ParseMode string  `mapstructure:"parse_mode"` // Notification format of chats without /format: markdown or html
+
+	DefaultPreset string `mapstructure:"default_preset"` // Events of /subscribe without a selection: default, releases-only, maintainer or everything
+
+	Access       string  `mapstructure:"access"`        // Who may use the bot: open, allowlist or approval
+	AllowedUsers []int64 `mapstructure:"allowed_users"` // Telegram user IDs that may always use the bot
+	AllowedChats []int64 `mapstructure:"allowed_chats"` // Chat IDs that may always use the bot
+
+	MaxSubscriptions int `mapstructure:"max_subscriptions"` // Repositories a chat may subscribe to, 0 for no limit
+	MaxRepos         int `mapstructure:"max_repos"`         // Repositories watched over all chats, 0 for no limit
 }
 
 // GitHubConfig holds GitHub API configuration.
 type GitHubConfig struct {
-	Token         string `mapstructure:"token"`
-	WebhookSecret string `mapstructure:"webhook_secret"`
-	Mode          string `mapstructure:"mode"`          // webhook, polling, or both
-	PollInterval  int    `mapstructure:"poll_interval"` // Polling interval in seconds
+	Token         string   `mapstructure:"token"`
+	TokenFile     string   `mapstructure:"token_file"` // File the token is read from instead
+	Tokens        []string `mapstructure:"tokens"`     // Further tokens requests take turns with
+	WebhookSecret string   `mapstructure:"webhook_secret"`
+
+	WebhookSecretFile string `mapstructure:"webhook_secret_file"` // File the webhook secret is read from instead
+
+	WebhookSecrets map[string]string `mapstructure:"webhook_secrets"` // Secret per "owner/repo" or "owner", instead of webhook_secret
+
+	Mode         string `mapstructure:"mode"`          // webhook, polling, or both
+	PollInterval int    `mapstructure:"poll_interval"` // Polling interval in seconds
+
+	PollConcurrency int `mapstructure:"poll_concurrency"` // Repositories polled in parallel
+
+	ComplianceCheckEvery int `mapstructure:"compliance_check_every"` // Polls between license/visibility checks
+
+	Activity ActivityConfig `mapstructure:"activity"`
+
+	PollOverrides map[string]int      `mapstructure:"poll_overrides"` // Poll interval in seconds per "owner/repo"
+	PollBranches  map[string][]string `mapstructure:"poll_branches"`  // Branches whose commits are polled per "owner/repo"
+
+	EventsAPI bool `mapstructure:"events_api"` // Poll the repository events API instead of listing each resource
+	GraphQL   bool `mapstructure:"graphql"`    // Fetch repositories in batches with GraphQL, requires a token
+
+	OAuthClientID string `mapstructure:"oauth_client_id"` // OAuth app /login links accounts with, empty to disable
+}
+
+// ActivityConfig holds activity-based poll scheduling configuration.
+type ActivityConfig struct {
+	Enabled            bool    `mapstructure:"enabled"`
+	ActiveThreshold    float64 `mapstructure:"active_threshold"`    // Events per day for "very active"
+	ModerateThreshold  float64 `mapstructure:"moderate_threshold"`  // Events per day for "moderate"
+	ModerateMultiplier float64 `mapstructure:"moderate_multiplier"` // Interval multiplier for moderate repos
+	DormantMultiplier  float64 `mapstructure:"dormant_multiplier"`  // Interval multiplier for dormant repos
+	MinInterval        int     `mapstructure:"min_interval"`        // Floor in seconds
+	MaxInterval        int     `mapstructure:"max_interval"`        // Ceiling in seconds
 }
 
 // DatabaseConfig holds database configuration.
 type DatabaseConfig struct {
-	Path string `mapstructure:"path"`
+	Path        string            `mapstructure:"path"`
+	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
+
+	EncryptionKey     string `mapstructure:"encryption_key"`      // Base64 of the 32 byte key stored tokens are encrypted with
+	EncryptionKeyFile string `mapstructure:"encryption_key_file"` // File the encryption key is read from instead
+
+	PreviousEncryptionKeys []string `mapstructure:"previous_encryption_keys"` // Keys replaced by encryption_key, still read until rotated
+}
+
+// MaintenanceConfig holds the periodic database cleanup configuration.
+type MaintenanceConfig struct {
+	Interval    int `mapstructure:"interval"`     // Hours between cleanups, 0 to disable
+	EventDays   int `mapstructure:"event_days"`   // Days processed event IDs are kept for deduplication
+	HistoryDays int `mapstructure:"history_days"` // Days events stay in /history
+	AuditDays   int `mapstructure:"audit_days"`   // Days commands stay in the audit log
+	VacuumDays  int `mapstructure:"vacuum_days"`  // Days between VACUUMs, 0 to never
 }
 
 // ServerConfig holds HTTP server configuration.
 type ServerConfig struct {
-	Host string `mapstructure:"host"`
-	Port int    `mapstructure:"port"`
+	Host       string `mapstructure:"host"`
+	Port       int    `mapstructure:"port"`
+	PublicURL  string `mapstructure:"public_url"`  // URL the server is reachable at, shown in the URLs of custom hooks
+	AdminToken string `mapstructure:"admin_token"` // Bearer token for the admin API (disabled if empty)
+	ReusePort  bool   `mapstructure:"reuse_port"`  // Bind with SO_REUSEPORT for zero-downtime deploys
+	Metrics    bool   `mapstructure:"metrics"`     // Expose Prometheus metrics at /metrics
+	Debug      bool   `mapstructure:"debug"`       // Expose pprof and runtime variables at /debug
+}
+
+// NotifierConfig holds notification delivery configuration.
+type NotifierConfig struct {
+	AssetWait    int `mapstructure:"asset_wait"`    // Minutes to wait for release assets matching a filter
+	AssetRecheck int `mapstructure:"asset_recheck"` // Minutes between re-checks for late assets
+	DigestHour   int `mapstructure:"digest_hour"`   // Hour of day in each chat's time zone digests are sent
+	BatchWindow  int `mapstructure:"batch_window"`  // Seconds events of a repository are combined into one message, 0 to disable
+
+	DryRun bool `mapstructure:"dry_run"` // Log notifications instead of sending them
+}
+
+// TelegraphConfig holds configuration for publishing long texts to telegra.ph.
+type TelegraphConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	AccessToken string `mapstructure:"access_token"` // Account to publish with, a new one is created if empty
+	AuthorName  string `mapstructure:"author_name"`  // Author shown on published pages
+}
+
+// DiscordConfig holds the Discord channels subscriptions can be routed to.
+type DiscordConfig struct {
+	Webhooks map[string]string `mapstructure:"webhooks"` // Webhook URL of each channel, by the name /route uses
+}
+
+// SlackConfig holds the Slack channels subscriptions can be routed to.
+type SlackConfig struct {
+	Webhooks map[string]string `mapstructure:"webhooks"` // Incoming webhook URL of each channel, by the name /route uses
+}
+
+// MatrixConfig holds the Matrix account notifications are posted with and
+// the rooms subscriptions can be routed to.
+type MatrixConfig struct {
+	Homeserver      string            `mapstructure:"homeserver"`        // Base URL of the homeserver, e.g. https://matrix.org
+	AccessToken     string            `mapstructure:"access_token"`      // Access token of the account, which must have joined the rooms
+	AccessTokenFile string            `mapstructure:"access_token_file"` // File the access token is read from instead
+	Rooms           map[string]string `mapstructure:"rooms"`             // Room ID of each room, by the name /route uses
+}
+
+// BackupConfig holds database backup configuration.
+type BackupConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`  // Take snapshots on a schedule; /admin backup works regardless
+	Dir      string   `mapstructure:"dir"`      // Directory snapshots are written to
+	Interval int      `mapstructure:"interval"` // Hours between scheduled snapshots
+	Keep     int      `mapstructure:"keep"`     // Snapshots kept locally and in the bucket, 0 to keep all
+	S3       S3Config `mapstructure:"s3"`
+}
+
+// S3Config holds the S3-compatible bucket snapshots are copied to.
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"` // e.g. https://s3.us-east-1.amazonaws.com, empty to keep snapshots local only
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	Prefix    string `mapstructure:"prefix"` // Prepended to object keys
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+}
+
+// TracingConfig holds OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP/HTTP URL, e.g. http://localhost:4318; empty for OTEL_EXPORTER_OTLP_ENDPOINT
+	Insecure    bool    `mapstructure:"insecure"`     // Export over plain HTTP
+	SampleRatio float64 `mapstructure:"sample_ratio"` // Fraction of traces recorded, from 0 to 1
+}
+
+// ErrorReportingConfig holds where logged errors and panics are reported to.
+type ErrorReportingConfig struct {
+	DSN         string  `mapstructure:"dsn"`         // Sentry project DSN, empty to disable
+	Environment string  `mapstructure:"environment"` // e.g. production or staging
+	SampleRate  float64 `mapstructure:"sample_rate"` // Fraction of errors reported, from 0 to 1
 }
 
 // LogConfig holds logging configuration.
 type LogConfig struct {
-	Level string `mapstructure:"level"`
-	File  string `mapstructure:"file"`
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"` // Console output: console or json
+	File   string `mapstructure:"file"`
+
+	MaxSize    int  `mapstructure:"max_size"`    // Megabytes the file is rotated at
+	MaxBackups int  `mapstructure:"max_backups"` // Rotated files kept, 0 to keep all
+	MaxAge     int  `mapstructure:"max_age"`     // Days rotated files are kept, 0 to keep regardless of age
+	Compress   bool `mapstructure:"compress"`    // Gzip rotated files
 }
 
 // Load reads configuration from file and environment variables.
@@ -56,10 +208,72 @@ func Load(configPath string) (*Config, error) {
 	v.SetDefault("server.host", "0.0.0.0")
 	v.SetDefault("server.port", 8080)
 	v.SetDefault("database.path", "./data/bot.db")
+	v.SetDefault("database.maintenance.interval", 24)
+	v.SetDefault("database.maintenance.event_days", 30)
+	v.SetDefault("database.maintenance.history_days", 30)
+	v.SetDefault("database.maintenance.audit_days", 90)
+	v.SetDefault("database.maintenance.vacuum_days", 7)
+	v.SetDefault("database.encryption_key", "")
+	v.SetDefault("database.encryption_key_file", "")
+	v.SetDefault("database.previous_encryption_keys", []string{})
 	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "console")
+	v.SetDefault("log.max_size", 100)
+	v.SetDefault("log.max_backups", 5)
+	v.SetDefault("log.max_age", 30)
+	v.SetDefault("log.compress", false)
+	v.SetDefault("telegram.token_file", "")
+	v.SetDefault("github.token_file", "")
+	v.SetDefault("github.webhook_secret_file", "")
+	v.SetDefault("matrix.homeserver", "")
+	v.SetDefault("matrix.access_token", "")
+	v.SetDefault("matrix.access_token_file", "")
 	v.SetDefault("telegram.debug", false)
+	v.SetDefault("telegram.admins", []int64{})
+	v.SetDefault("telegram.language", "zh")
+	v.SetDefault("telegram.parse_mode", "markdown")
+	v.SetDefault("telegram.default_preset", "default")
+	v.SetDefault("telegram.access", "open")
+	v.SetDefault("telegram.allowed_users", []int64{})
+	v.SetDefault("telegram.allowed_chats", []int64{})
+	v.SetDefault("telegram.max_subscriptions", 0)
+	v.SetDefault("telegram.max_repos", 0)
+	v.SetDefault("server.public_url", "")
+	v.SetDefault("server.admin_token", "")
+	v.SetDefault("server.reuse_port", false)
+	v.SetDefault("server.metrics", false)
+	v.SetDefault("server.debug", false)
 	v.SetDefault("github.mode", "polling")    // Default to polling for monitoring any repo
 	v.SetDefault("github.poll_interval", 300) // 5 minutes default
+	v.SetDefault("notifier.asset_wait", 360)
+	v.SetDefault("notifier.asset_recheck", 10)
+	v.SetDefault("notifier.digest_hour", 9)
+	v.SetDefault("notifier.batch_window", 0)
+	v.SetDefault("notifier.dry_run", false)
+	v.SetDefault("telegraph.enabled", false)
+	v.SetDefault("telegraph.author_name", "GitHub Bot")
+	v.SetDefault("backup.enabled", false)
+	v.SetDefault("backup.dir", "./data/backups")
+	v.SetDefault("backup.interval", 24)
+	v.SetDefault("backup.keep", 7)
+	v.SetDefault("backup.s3.region", "us-east-1")
+	v.SetDefault("backup.s3.prefix", "githubbot/")
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+	v.SetDefault("error_reporting.environment", "production")
+	v.SetDefault("error_reporting.sample_rate", 1.0)
+	v.SetDefault("github.compliance_check_every", 12)
+	v.SetDefault("github.events_api", true)
+	v.SetDefault("github.graphql", false)
+	v.SetDefault("github.oauth_client_id", "")
+	v.SetDefault("github.poll_concurrency", 4)
+	v.SetDefault("github.activity.enabled", true)
+	v.SetDefault("github.activity.active_threshold", 5.0)
+	v.SetDefault("github.activity.moderate_threshold", 0.5)
+	v.SetDefault("github.activity.moderate_multiplier", 2.0)
+	v.SetDefault("github.activity.dormant_multiplier", 8.0)
+	v.SetDefault("github.activity.min_interval", 60)
+	v.SetDefault("github.activity.max_interval", 7200)
 
 	// Read config file
 	if configPath != "" {
@@ -87,6 +301,11 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	// Read secrets kept in files, such as Docker or Kubernetes secrets
+	if err := cfg.readSecretFiles(); err != nil {
+		return nil, err
+	}
+
 	// Validate required fields
 	if err := cfg.Validate(); err != nil {
 		return nil, err
@@ -95,11 +314,50 @@ func Load(configPath string) (*Config, error) {
 	return &cfg, nil
 }
 
+// readSecretFiles replaces the secrets whose file is given with the
+// contents of the file.
+func (c *Config) readSecretFiles() error {
+	secrets := []struct {
+		key   string
+		file  string
+		value *string
+	}{
+		{"telegram.token_file", c.Telegram.TokenFile, &c.Telegram.Token},
+		{"github.token_file", c.GitHub.TokenFile, &c.GitHub.Token},
+		{"github.webhook_secret_file", c.GitHub.WebhookSecretFile, &c.GitHub.WebhookSecret},
+		{"matrix.access_token_file", c.Matrix.AccessTokenFile, &c.Matrix.AccessToken},
+		{"database.encryption_key_file", c.Database.EncryptionKeyFile, &c.Database.EncryptionKey},
+	}
+	for _, secret := range secrets {
+		if secret.file == "" {
+			continue
+		}
+		data, err := os.ReadFile(secret.file)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %w", secret.key, err)
+		}
+		*secret.value = strings.TrimSpace(string(data))
+	}
+	return nil
+}
+
 // Validate checks if all required configuration fields are set.
 func (c *Config) Validate() error {
 	if c.Telegram.Token == "" {
 		return fmt.Errorf("telegram token is required")
 	}
+	if len(c.Matrix.Rooms) > 0 && (c.Matrix.Homeserver == "" || c.Matrix.AccessToken == "") {
+		return fmt.Errorf("matrix homeserver and access token are required for matrix rooms")
+	}
+	if c.GitHub.OAuthClientID != "" && c.Database.EncryptionKey == "" {
+		return fmt.Errorf("database encryption key is required for github oauth")
+	}
+	if c.Server.Metrics && c.Server.AdminToken == "" {
+		return fmt.Errorf("server admin token is required for metrics")
+	}
+	if c.Server.Debug && c.Server.AdminToken == "" {
+		return fmt.Errorf("server admin token is required for debug endpoints")
+	}
 	return nil
 }
 
@@ -107,3 +365,75 @@ func (c *Config) Validate() error {
 func (c *Config) ServerAddress() string {
 	return fmt.Sprintf("%s:%d", c.Server.Host, c.Server.Port)
 }
+
+// redacted replaces the secrets printed by Settings.
+const redacted = "REDACTED"
+
+// Redacted returns a copy of the configuration with the secrets replaced,
+// so it can be printed.
+func (c *Config) Redacted() *Config {
+	r := *c
+	for _, secret := range []*string{
+		&r.Telegram.Token, &r.GitHub.Token, &r.GitHub.WebhookSecret, &r.Server.AdminToken,
+		&r.Telegraph.AccessToken, &r.Backup.S3.SecretKey, &r.ErrorReporting.DSN,
+		&r.Matrix.AccessToken, &r.Database.EncryptionKey,
+	} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	r.GitHub.WebhookSecrets = redactedValues(c.GitHub.WebhookSecrets)
+	r.Discord.Webhooks = redactedValues(c.Discord.Webhooks)
+	r.Slack.Webhooks = redactedValues(c.Slack.Webhooks)
+	r.GitHub.Tokens = redactedList(c.GitHub.Tokens)
+	r.Database.PreviousEncryptionKeys = redactedList(c.Database.PreviousEncryptionKeys)
+	return &r
+}
+
+// redactedValues returns a copy of m with every value redacted.
+func redactedValues(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return m
+	}
+	r := make(map[string]string, len(m))
+	for key := range m {
+		r[key] = redacted
+	}
+	return r
+}
+
+// redactedList returns a copy of s with every element redacted.
+func redactedList(s []string) []string {
+	if len(s) == 0 {
+		return s
+	}
+	r := make([]string, len(s))
+	for i := range r {
+		r[i] = redacted
+	}
+	return r
+}
+
+// Settings returns the configuration as nested maps keyed like the
+// configuration file.
+func (c *Config) Settings() map[string]any {
+	return settings(reflect.ValueOf(*c))
+}
+
+// settings converts a configuration struct to a map keyed by the
+// mapstructure tags of its fields.
+func settings(v reflect.Value) map[string]any {
+	m := make(map[string]any)
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("mapstructure")
+		if key == "" {
+			continue
+		}
+		if field := v.Field(i); field.Kind() == reflect.Struct {
+			m[key] = settings(field)
+		} else {
+			m[key] = field.Interface()
+		}
+	}
+	return m
+}
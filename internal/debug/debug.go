@@ -0,0 +1,67 @@
+// Package debug exposes runtime internals for diagnosing long-running
+// deployments: the pprof profiles, and expvar variables with the goroutine
+// count and the depth of the bot's queues.
+package debug
+
+import (
+	"expvar"
+	"net/http"
+	"runtime"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// queueDepth is the fill level of a queue.
+type queueDepth struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity,omitempty"` // 0 if unbounded
+}
+
+var (
+	mu     sync.Mutex
+	queues = make(map[string]func() (length, capacity int))
+)
+
+func init() {
+	expvar.Publish("goroutines", expvar.Func(func() any {
+		return runtime.NumGoroutine()
+	}))
+	expvar.Publish("queues", expvar.Func(queueDepths))
+}
+
+// WatchQueue reports the depth of a queue, such as a channel, under name.
+// depth returns how many items wait and the capacity, 0 if unbounded.
+func WatchQueue(name string, depth func() (length, capacity int)) {
+	mu.Lock()
+	defer mu.Unlock()
+	queues[name] = depth
+}
+
+// WatchChannel reports the depth of a buffered channel under name.
+func WatchChannel[T any](name string, ch chan T) {
+	WatchQueue(name, func() (int, int) { return len(ch), cap(ch) })
+}
+
+// queueDepths returns the current depth of the watched queues.
+func queueDepths() any {
+	mu.Lock()
+	watched := make(map[string]func() (int, int), len(queues))
+	for name, depth := range queues {
+		watched[name] = depth
+	}
+	mu.Unlock()
+
+	depths := make(map[string]queueDepth, len(watched))
+	for name, depth := range watched {
+		length, capacity := depth()
+		depths[name] = queueDepth{Length: length, Capacity: capacity}
+	}
+	return depths
+}
+
+// Handler serves the profiles under /pprof/ and the variables at /vars.
+// Mount it at /debug.
+func Handler() http.Handler {
+	return middleware.Profiler()
+}
@@ -0,0 +1,102 @@
+// Package errorreport sends the errors logged to Sentry, or any service
+// accepting Sentry events such as GlitchTip, so that panics, failed sends and
+// poller errors are aggregated with their stack traces instead of only living
+// in the local logs.
+package errorreport
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/rs/zerolog"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Config holds where errors are reported to.
+type Config struct {
+	DSN         string  // Project DSN
+	Environment string  // e.g. production or staging
+	SampleRate  float64 // Fraction of errors reported, from 0 to 1
+}
+
+// Sentry reports logged errors to Sentry. It implements logger.Reporter.
+type Sentry struct {
+	hub *sentry.Hub
+}
+
+// NewSentry creates a reporter for a Sentry project.
+func NewSentry(config Config) (*Sentry, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         config.DSN,
+		Environment: config.Environment,
+		SampleRate:  config.SampleRate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Sentry client: %w", err)
+	}
+	return &Sentry{hub: sentry.NewHub(client, sentry.NewScope())}, nil
+}
+
+// Report sends an entry as an event. Entries logged by the same line with
+// the same message are grouped into one issue, whatever the error; panics by
+// the function that panicked.
+func (s *Sentry) Report(entry logger.Entry) {
+	event := sentry.NewEvent()
+	event.Timestamp = entry.Time
+	event.Level = level(entry.Level)
+	event.Message = entry.Message
+	event.Logger = "githubbot"
+	event.Fingerprint = []string{entry.Caller, entry.Message}
+	event.Extra = entry.Fields
+	event.Tags = map[string]string{"caller": entry.Caller}
+
+	exception := sentry.Exception{Type: entry.Message, Value: entry.Error, Stacktrace: stacktrace(entry)}
+	if exception.Value == "" {
+		exception.Value = entry.Message
+	}
+	// Panics are all logged by logger.ReportPanic, so they are grouped by
+	// where they happened instead. The stack logged as text is redundant.
+	if recovered, ok := entry.Fields["panic"].(string); ok {
+		event.Level = sentry.LevelFatal
+		exception.Type, exception.Value = "panic", recovered
+		delete(entry.Fields, "stack")
+		if len(entry.Stack) > 0 {
+			top := entry.Stack[0]
+			event.Fingerprint = []string{"panic", top.Function, recovered}
+			event.Tags["caller"] = fmt.Sprintf("%s:%d", top.File, top.Line)
+		}
+	}
+	event.Exception = []sentry.Exception{exception}
+	s.hub.CaptureEvent(event)
+}
+
+// Flush waits up to timeout for the events to be sent.
+func (s *Sentry) Flush(timeout time.Duration) {
+	s.hub.Flush(timeout)
+}
+
+// stacktrace converts the stack of an entry, which starts at the innermost
+// call, to Sentry's order, which ends with it.
+func stacktrace(entry logger.Entry) *sentry.Stacktrace {
+	if len(entry.Stack) == 0 {
+		return nil
+	}
+	frames := make([]sentry.Frame, len(entry.Stack))
+	for i, frame := range entry.Stack {
+		frames[len(frames)-1-i] = sentry.NewFrame(frame)
+	}
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// level returns the Sentry level of a log level.
+func level(l zerolog.Level) sentry.Level {
+	switch l {
+	case zerolog.FatalLevel, zerolog.PanicLevel:
+		return sentry.LevelFatal
+	case zerolog.WarnLevel:
+		return sentry.LevelWarning
+	default:
+		return sentry.LevelError
+	}
+}
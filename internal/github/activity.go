@@ -0,0 +1,145 @@
+package github
+
+import (
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+// Activity bands a repository can be placed in by the poller.
+const (
+	ActivityBandNew      = ""         // Not evaluated yet, polled at the base interval
+	ActivityBandActive   = "active"   // Very active, polled at the base interval
+	ActivityBandModerate = "moderate" // Moderately active
+	ActivityBandDormant  = "dormant"  // Rarely changes
+	ActivityBandOverride = "override" // Interval set manually
+)
+
+// activityWindow is how often activity scores are re-evaluated.
+const activityWindow = 24 * time.Hour
+
+// activitySmoothing weights the latest window against the previous score.
+const activitySmoothing = 0.5
+
+// activityHysteresis is how far below the threshold of its band, as a
+// fraction of it, a score must fall before a repository drops out of the band.
+// It keeps repositories near a threshold from flapping between intervals.
+const activityHysteresis = 0.2
+
+// ActivityPolicy maps repository activity to poll intervals.
+type ActivityPolicy struct {
+	Enabled            bool
+	ActiveThreshold    float64 // Events per day at or above which a repo is very active
+	ModerateThreshold  float64 // Events per day at or above which a repo is moderately active
+	ModerateMultiplier float64
+	DormantMultiplier  float64
+	MinInterval        time.Duration
+	MaxInterval        time.Duration
+}
+
+// DefaultActivityPolicy returns the policy used when none is configured.
+func DefaultActivityPolicy() ActivityPolicy {
+	return ActivityPolicy{
+		Enabled:            true,
+		ActiveThreshold:    5,
+		ModerateThreshold:  0.5,
+		ModerateMultiplier: 2,
+		DormantMultiplier:  8,
+		MinInterval:        60 * time.Second,
+		MaxInterval:        2 * time.Hour,
+	}
+}
+
+// Band returns the activity band for a score of a repository currently in
+// the given band. A repository moves up as soon as its score reaches a
+// threshold, but only moves down once it is clearly below the threshold of
+// its current band.
+func (p ActivityPolicy) Band(current string, score float64) string {
+	band := p.bandFor(score)
+	if bandRank(band) < bandRank(current) && score >= p.threshold(current)*(1-activityHysteresis) {
+		return current
+	}
+	return band
+}
+
+// bandFor returns the activity band a score falls in, ignoring hysteresis.
+func (p ActivityPolicy) bandFor(score float64) string {
+	switch {
+	case score >= p.ActiveThreshold:
+		return ActivityBandActive
+	case score >= p.ModerateThreshold:
+		return ActivityBandModerate
+	default:
+		return ActivityBandDormant
+	}
+}
+
+// threshold returns the lowest score of a band.
+func (p ActivityPolicy) threshold(band string) float64 {
+	switch band {
+	case ActivityBandActive:
+		return p.ActiveThreshold
+	case ActivityBandModerate:
+		return p.ModerateThreshold
+	default:
+		return 0
+	}
+}
+
+// bandRank orders the scored bands from dormant to active, with 0 for
+// repositories not scored yet or overridden.
+func bandRank(band string) int {
+	switch band {
+	case ActivityBandDormant:
+		return 1
+	case ActivityBandModerate:
+		return 2
+	case ActivityBandActive:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Interval returns the poll interval for a repository and the band it was
+// derived from. Manual overrides take precedence over activity scoring.
+func (p ActivityPolicy) Interval(base time.Duration, state *storage.RepoState) (time.Duration, string) {
+	if state != nil && state.PollIntervalOverride > 0 {
+		return time.Duration(state.PollIntervalOverride) * time.Second, ActivityBandOverride
+	}
+	if !p.Enabled || state == nil || state.ActivityBand == ActivityBandNew {
+		return base, ActivityBandNew
+	}
+
+	multiplier := 1.0
+	switch state.ActivityBand {
+	case ActivityBandModerate:
+		multiplier = p.ModerateMultiplier
+	case ActivityBandDormant:
+		multiplier = p.DormantMultiplier
+	}
+
+	interval := time.Duration(float64(base) * multiplier)
+	if p.MinInterval > 0 && interval < p.MinInterval {
+		interval = p.MinInterval
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval, state.ActivityBand
+}
+
+// Evaluate computes the new rolling score for a repository once its scoring
+// window has elapsed. It reports false if the window is still open.
+func (p ActivityPolicy) Evaluate(state *storage.RepoState, now time.Time) (float64, bool) {
+	elapsed := now.Sub(state.WindowStartedAt)
+	if elapsed < activityWindow {
+		return state.ActivityScore, false
+	}
+
+	rate := float64(state.PendingEvents) / (elapsed.Hours() / 24)
+	if state.ActivityBand == ActivityBandNew {
+		return rate, true
+	}
+	return activitySmoothing*rate + (1-activitySmoothing)*state.ActivityScore, true
+}
@@ -0,0 +1,165 @@
+package github
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+func TestActivityBand(t *testing.T) {
+	p := DefaultActivityPolicy() // Active from 5 events a day, moderate from 0.5
+	tests := []struct {
+		name    string
+		current string
+		score   float64
+		want    string
+	}{
+		{"new active", ActivityBandNew, 5, ActivityBandActive},
+		{"new moderate", ActivityBandNew, 0.5, ActivityBandModerate},
+		{"new dormant", ActivityBandNew, 0.49, ActivityBandDormant},
+		{"new quiet", ActivityBandNew, 0, ActivityBandDormant},
+
+		// Moving up happens at the threshold
+		{"dormant to moderate", ActivityBandDormant, 0.5, ActivityBandModerate},
+		{"dormant to active", ActivityBandDormant, 7, ActivityBandActive},
+		{"moderate to active", ActivityBandModerate, 5, ActivityBandActive},
+		{"dormant below threshold", ActivityBandDormant, 0.45, ActivityBandDormant},
+		{"moderate below threshold", ActivityBandModerate, 4.9, ActivityBandModerate},
+
+		// Moving down only once clearly below it
+		{"active just below", ActivityBandActive, 4.5, ActivityBandActive},
+		{"active at margin", ActivityBandActive, 4, ActivityBandActive},
+		{"active to moderate", ActivityBandActive, 3.9, ActivityBandModerate},
+		{"active to dormant", ActivityBandActive, 0.1, ActivityBandDormant},
+		{"moderate just below", ActivityBandModerate, 0.45, ActivityBandModerate},
+		{"moderate at margin", ActivityBandModerate, 0.4, ActivityBandModerate},
+		{"moderate to dormant", ActivityBandModerate, 0.39, ActivityBandDormant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Band(tt.current, tt.score); got != tt.want {
+				t.Errorf("Band(%q, %v) = %q, want %q", tt.current, tt.score, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivityInterval(t *testing.T) {
+	const base = 5 * time.Minute
+	p := DefaultActivityPolicy()
+	disabled := p
+	disabled.Enabled = false
+	clamped := p
+	clamped.MinInterval = 10 * time.Minute
+	clamped.MaxInterval = 30 * time.Minute
+
+	tests := []struct {
+		name     string
+		policy   ActivityPolicy
+		state    *storage.RepoState
+		want     time.Duration
+		wantBand string
+	}{
+		{"unknown repo", p, nil, base, ActivityBandNew},
+		{"new repo", p, &storage.RepoState{ActivityScore: 0.01}, base, ActivityBandNew},
+		{"active", p, &storage.RepoState{ActivityBand: ActivityBandActive}, base, ActivityBandActive},
+		{"moderate", p, &storage.RepoState{ActivityBand: ActivityBandModerate}, 2 * base, ActivityBandModerate},
+		{"dormant", p, &storage.RepoState{ActivityBand: ActivityBandDormant}, 8 * base, ActivityBandDormant},
+		{"disabled", disabled, &storage.RepoState{ActivityBand: ActivityBandDormant}, base, ActivityBandNew},
+		{"floor", clamped, &storage.RepoState{ActivityBand: ActivityBandActive}, 10 * time.Minute, ActivityBandActive},
+		{"ceiling", clamped, &storage.RepoState{ActivityBand: ActivityBandDormant}, 30 * time.Minute, ActivityBandDormant},
+		{
+			"override",
+			clamped,
+			&storage.RepoState{ActivityBand: ActivityBandDormant, PollIntervalOverride: 60},
+			time.Minute,
+			ActivityBandOverride,
+		},
+		{
+			"override while disabled",
+			disabled,
+			&storage.RepoState{PollIntervalOverride: 7200},
+			2 * time.Hour,
+			ActivityBandOverride,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, band := tt.policy.Interval(base, tt.state)
+			if got != tt.want || band != tt.wantBand {
+				t.Errorf("Interval() = %v, %q, want %v, %q", got, band, tt.want, tt.wantBand)
+			}
+		})
+	}
+}
+
+func TestActivityEvaluate(t *testing.T) {
+	p := DefaultActivityPolicy()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	state := &storage.RepoState{ActivityScore: 3, ActivityBand: ActivityBandActive, PendingEvents: 10, WindowStartedAt: now.Add(-23 * time.Hour)}
+	if score, ok := p.Evaluate(state, now); ok || score != 3 {
+		t.Errorf("Evaluate() = %v, %v while the window is open, want the current score", score, ok)
+	}
+
+	// A new repository takes its first rate as is
+	state = &storage.RepoState{ActivityScore: 3, PendingEvents: 10, WindowStartedAt: now.Add(-48 * time.Hour)}
+	if score, ok := p.Evaluate(state, now); !ok || score != 5 {
+		t.Errorf("Evaluate() = %v, %v for a new repository, want 5 events a day", score, ok)
+	}
+
+	// Later ones are smoothed with the previous score
+	state.ActivityBand = ActivityBandActive
+	if score, ok := p.Evaluate(state, now); !ok || score != 4 {
+		t.Errorf("Evaluate() = %v, %v, want the average of 3 and 5", score, ok)
+	}
+}
+
+func TestActivityBandChanges(t *testing.T) {
+	const base = 5 * time.Minute
+	p := DefaultActivityPolicy()
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	state := &storage.RepoState{WindowStartedAt: now}
+
+	steps := []struct {
+		events       int
+		wantBand     string
+		wantInterval time.Duration
+	}{
+		{0, ActivityBandDormant, 40 * time.Minute},  // Score 0
+		{2, ActivityBandModerate, 10 * time.Minute}, // 1, up at the threshold
+		{20, ActivityBandActive, 5 * time.Minute},   // 10.5
+		{2, ActivityBandActive, 5 * time.Minute},    // 6.25
+		{2, ActivityBandActive, 5 * time.Minute},    // 4.125, below 5 but within the margin
+		{2, ActivityBandModerate, 10 * time.Minute}, // 3.06, down
+		{0, ActivityBandModerate, 10 * time.Minute}, // 1.53
+		{0, ActivityBandModerate, 10 * time.Minute}, // 0.77
+		{0, ActivityBandDormant, 40 * time.Minute},  // 0.38, below the margin of 0.4
+		{0, ActivityBandDormant, 40 * time.Minute},  // 0.19
+		{1, ActivityBandModerate, 10 * time.Minute}, // 0.6, straight back up
+		{10, ActivityBandActive, 5 * time.Minute},   // 5.3
+		{0, ActivityBandModerate, 10 * time.Minute}, // 2.65
+		{0, ActivityBandModerate, 10 * time.Minute}, // 1.33
+		{0, ActivityBandModerate, 10 * time.Minute}, // 0.66
+		{0, ActivityBandDormant, 40 * time.Minute},  // 0.33
+	}
+	for day, step := range steps {
+		// A day of polls, then the window closes
+		now = now.Add(24 * time.Hour)
+		state.PendingEvents = step.events
+		score, ok := p.Evaluate(state, now)
+		if !ok {
+			t.Fatalf("day %d: Evaluate() did not close the window", day+1)
+		}
+		state.ActivityScore = score
+		state.ActivityBand = p.Band(state.ActivityBand, score)
+		state.WindowStartedAt = now
+
+		interval, band := p.Interval(base, state)
+		if band != step.wantBand || interval != step.wantInterval {
+			t.Errorf("day %d: score %.3f gives %q every %v, want %q every %v",
+				day+1, score, band, interval, step.wantBand, step.wantInterval)
+		}
+	}
+}
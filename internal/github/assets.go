@@ -0,0 +1,244 @@
+package github
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+)
+
+// maxChecksumSize limits how much of a checksum file is downloaded.
+const maxChecksumSize = 4096
+
+// checksumHosts are the hosts checksum files are downloaded from: release
+// asset URLs on github.com redirect to objects.githubusercontent.com.
+var checksumHosts = map[string]bool{
+	"github.com":                    true,
+	"objects.githubusercontent.com": true,
+}
+
+// checksumClient downloads checksum files, following redirects to the
+// checksum hosts only.
+var checksumClient = &http.Client{
+	Timeout: 15 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return errors.New("too many redirects")
+		}
+		if !isChecksumURL(req.URL) {
+			return fmt.Errorf("redirect to %s not allowed", req.URL.Host)
+		}
+		return nil
+	},
+}
+
+// isChecksumURL reports whether a checksum file may be downloaded from u:
+// an HTTPS release asset URL of github.com, or a checksum host it redirects to.
+func isChecksumURL(u *url.URL) bool {
+	if u.Scheme != "https" || u.User != nil || u.Port() != "" || !checksumHosts[u.Hostname()] {
+		return false
+	}
+	if u.Hostname() == "github.com" {
+		// /<owner>/<repo>/releases/download/<tag>/<name>
+		parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+		return len(parts) >= 6 && parts[2] == "releases" && parts[3] == "download"
+	}
+	return true
+}
+
+// ValidateAssetPattern checks that an asset glob is well-formed.
+func ValidateAssetPattern(pattern string) error {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid asset pattern: %w", err)
+	}
+	return nil
+}
+
+// MatchAssets returns the assets whose names match the glob pattern.
+// Checksum files are never reported as matches themselves.
+func MatchAssets(assets []AssetInfo, pattern string) []AssetInfo {
+	var matched []AssetInfo
+	for _, a := range assets {
+		if isChecksumAsset(a.Name) {
+			continue
+		}
+		if ok, _ := path.Match(pattern, a.Name); ok {
+			matched = append(matched, a)
+		}
+	}
+	return matched
+}
+
+// ChecksumAsset returns the "<name>.sha256" companion of an asset, if present.
+func ChecksumAsset(assets []AssetInfo, asset AssetInfo) (AssetInfo, bool) {
+	for _, a := range assets {
+		if a.Name == asset.Name+".sha256" {
+			return a, true
+		}
+	}
+	return AssetInfo{}, false
+}
+
+// isChecksumAsset reports whether an asset is a SHA-256 checksum file.
+func isChecksumAsset(name string) bool {
+	return strings.HasSuffix(name, ".sha256")
+}
+
+// convertAssets converts API release assets to AssetInfo values.
+func convertAssets(assets []*gh.ReleaseAsset) []AssetInfo {
+	result := make([]AssetInfo, len(assets))
+	for i, a := range assets {
+		result[i] = AssetInfo{
+			Name:        a.GetName(),
+			Size:        a.GetSize(),
+			DownloadURL: a.GetBrowserDownloadURL(),
+		}
+	}
+	return result
+}
+
+// GetReleaseAssets returns the current asset list of a release.
+func (c *Client) GetReleaseAssets(ctx context.Context, owner, repo, tag string) ([]AssetInfo, error) {
+	release, _, err := c.client.Repositories.GetReleaseByTag(ctx, owner, repo, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+	return convertAssets(release.Assets), nil
+}
+
+// ErrReleaseNotFound is returned when a repository has no published release.
+var ErrReleaseNotFound = errors.New("release not found")
+
+// GetLatestRelease returns the most recent published release of a repository,
+// leaving out drafts and pre-releases.
+func (c *Client) GetLatestRelease(ctx context.Context, owner, repo string) (*ReleaseEvent, error) {
+	release, resp, err := c.client.Repositories.GetLatestRelease(ctx, owner, repo)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, ErrReleaseNotFound
+		}
+		return nil, fmt.Errorf("failed to get latest release: %w", err)
+	}
+	return &ReleaseEvent{
+		Action:      "published",
+		TagName:     release.GetTagName(),
+		Name:        release.GetName(),
+		Body:        release.GetBody(),
+		Prerelease:  release.GetPrerelease(),
+		URL:         release.GetHTMLURL(),
+		Author:      UserInfo{Login: release.GetAuthor().GetLogin()},
+		PublishedAt: release.GetPublishedAt().Time,
+		Assets:      convertAssets(release.Assets),
+	}, nil
+}
+
+// FetchChecksum downloads a ".sha256" asset and returns the hex digest it
+// contains. Only release asset URLs of github.com are downloaded.
+func FetchChecksum(ctx context.Context, asset AssetInfo) (string, error) {
+	u, err := url.Parse(asset.DownloadURL)
+	if err != nil || !isChecksumURL(u) {
+		return "", fmt.Errorf("invalid checksum URL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := checksumClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download checksum: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download checksum: status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxChecksumSize {
+		return "", fmt.Errorf("checksum file too large: %d bytes", resp.ContentLength)
+	}
+
+	// Checksum files contain "<digest>" or "<digest>  <filename>"
+	scanner := bufio.NewScanner(io.LimitReader(resp.Body, maxChecksumSize))
+	if scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && isHexDigest(fields[0]) {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found in %s", asset.Name)
+}
+
+// isHexDigest reports whether s looks like a SHA-256 hex digest.
+func isHexDigest(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// maxListedAssets caps how many assets a release notification lists.
+const maxListedAssets = 10
+
+// ParsePlatformList parses a comma-separated list of platform patterns such
+// as "linux_amd64,darwin_arm64".
+func ParsePlatformList(list string) ([]string, error) {
+	var platforms []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.ContainsAny(item, "` ") {
+			return nil, fmt.Errorf("invalid platform %q", item)
+		}
+		platforms = append(platforms, item)
+	}
+	if len(platforms) == 0 {
+		return nil, errors.New("no platforms given")
+	}
+	return platforms, nil
+}
+
+// FilterAssetsByPlatform returns the assets whose names contain one of the
+// platform patterns, case-insensitively.
+func FilterAssetsByPlatform(assets []AssetInfo, platforms []string) []AssetInfo {
+	var filtered []AssetInfo
+	for _, a := range assets {
+		name := strings.ToLower(a.Name)
+		for _, p := range platforms {
+			if strings.Contains(name, strings.ToLower(p)) {
+				filtered = append(filtered, a)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// FormatAssetSize formats an asset size in human-readable units.
+func FormatAssetSize(size int) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := unit, 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
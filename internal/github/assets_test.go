@@ -0,0 +1,229 @@
+package github
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// releaseFixture is the asset list of a release shipping several platforms,
+// some with checksum files.
+var releaseFixture = []AssetInfo{
+	{Name: "app-v1.2.0-linux-amd64.tar.gz", Size: 5 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-v1.2.0-linux-amd64.tar.gz"},
+	{Name: "app-v1.2.0-linux-amd64.tar.gz.sha256", Size: 96, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-v1.2.0-linux-amd64.tar.gz.sha256"},
+	{Name: "app-v1.2.0-linux-arm64.tar.gz", Size: 4 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-v1.2.0-linux-arm64.tar.gz"},
+	{Name: "app-v1.2.0-darwin-arm64.zip", Size: 6 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-v1.2.0-darwin-arm64.zip"},
+	{Name: "app-v1.2.0-darwin-arm64.zip.sha256", Size: 94, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-v1.2.0-darwin-arm64.zip.sha256"},
+	{Name: "app-v1.2.0-windows-amd64.zip", Size: 5 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-v1.2.0-windows-amd64.zip"},
+	{Name: "SHA256SUMS", Size: 400, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/SHA256SUMS"},
+}
+
+// assetNames returns the names of assets.
+func assetNames(assets []AssetInfo) []string {
+	var names []string
+	for _, a := range assets {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func TestMatchAssets(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*linux-amd64*.tar.gz", []string{"app-v1.2.0-linux-amd64.tar.gz"}},
+		// Checksum files match the glob but are never reported themselves
+		{"*linux-amd64*", []string{"app-v1.2.0-linux-amd64.tar.gz"}},
+		{"*linux*", []string{"app-v1.2.0-linux-amd64.tar.gz", "app-v1.2.0-linux-arm64.tar.gz"}},
+		{"*-arm64.*", []string{"app-v1.2.0-linux-arm64.tar.gz", "app-v1.2.0-darwin-arm64.zip"}},
+		{"*.zip", []string{"app-v1.2.0-darwin-arm64.zip", "app-v1.2.0-windows-amd64.zip"}},
+		{"app-v1.2.0-windows-amd64.zip", []string{"app-v1.2.0-windows-amd64.zip"}},
+		{"SHA256SUMS", []string{"SHA256SUMS"}},
+		{"*linux-riscv64*", nil},
+		{"*.sha256", nil},
+		{"[", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := assetNames(MatchAssets(releaseFixture, tt.pattern)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MatchAssets(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAssetPattern(t *testing.T) {
+	for _, pattern := range []string{"*linux-amd64*.tar.gz", "app-?.zip", "*[0-9].deb"} {
+		if err := ValidateAssetPattern(pattern); err != nil {
+			t.Errorf("ValidateAssetPattern(%q) error = %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"[", "*[a-", `\`} {
+		if err := ValidateAssetPattern(pattern); err == nil {
+			t.Errorf("ValidateAssetPattern(%q) accepted an invalid pattern", pattern)
+		}
+	}
+}
+
+func TestChecksumAsset(t *testing.T) {
+	tests := []struct {
+		asset string
+		want  string
+	}{
+		{"app-v1.2.0-linux-amd64.tar.gz", "app-v1.2.0-linux-amd64.tar.gz.sha256"},
+		{"app-v1.2.0-darwin-arm64.zip", "app-v1.2.0-darwin-arm64.zip.sha256"},
+		{"app-v1.2.0-linux-arm64.tar.gz", ""},
+		{"app-v1.2.0-windows-amd64.zip", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.asset, func(t *testing.T) {
+			sum, ok := ChecksumAsset(releaseFixture, AssetInfo{Name: tt.asset})
+			if ok != (tt.want != "") || sum.Name != tt.want {
+				t.Errorf("ChecksumAsset(%q) = %q, %v, want %q", tt.asset, sum.Name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterAssetsByPlatform(t *testing.T) {
+	tests := []struct {
+		platforms []string
+		want      []string
+	}{
+		{[]string{"linux-amd64"}, []string{"app-v1.2.0-linux-amd64.tar.gz", "app-v1.2.0-linux-amd64.tar.gz.sha256"}},
+		{[]string{"DARWIN", "windows"}, []string{"app-v1.2.0-darwin-arm64.zip", "app-v1.2.0-darwin-arm64.zip.sha256", "app-v1.2.0-windows-amd64.zip"}},
+		{[]string{"freebsd"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.platforms, ","), func(t *testing.T) {
+			if got := assetNames(FilterAssetsByPlatform(releaseFixture, tt.platforms)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterAssetsByPlatform(%v) = %v, want %v", tt.platforms, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAssetSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := FormatAssetSize(tt.size); got != tt.want {
+			t.Errorf("FormatAssetSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestIsChecksumURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://github.com/acme/app/releases/download/v1.2.0/app.tar.gz.sha256", true},
+		{"https://objects.githubusercontent.com/github-production-release-asset/1234/abcd", true},
+		{"http://github.com/acme/app/releases/download/v1.2.0/app.tar.gz.sha256", false},
+		{"https://github.com/acme/app/blob/main/app.sha256", false},
+		{"https://github.com/releases/download/v1/app.sha256", false},
+		{"https://github.com.evil.example/acme/app/releases/download/v1/app.sha256", false},
+		{"https://gіthub.com/acme/app/releases/download/v1/app.sha256", false}, // Cyrillic і
+		{"https://user@github.com/acme/app/releases/download/v1/app.sha256", false},
+		{"https://github.com:8443/acme/app/releases/download/v1/app.sha256", false},
+		{"https://127.0.0.1/acme/app/releases/download/v1/app.sha256", false},
+		{"https://169.254.169.254/latest/meta-data/", false},
+		{"file:///etc/passwd", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+			if got := isChecksumURL(u); got != tt.want {
+				t.Errorf("isChecksumURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// redirectTransport sends every request to a test server, whatever its host.
+type redirectTransport struct {
+	server *httptest.Server
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	return t.server.Client().Transport.RoundTrip(req)
+}
+
+func TestFetchChecksum(t *testing.T) {
+	const digest = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/acme/app/releases/download/v1/digest.sha256":
+			w.Write([]byte(digest + "\n"))
+		case "/acme/app/releases/download/v1/named.sha256":
+			w.Write([]byte(strings.ToUpper(digest) + "  app-linux-amd64.tar.gz\n"))
+		case "/acme/app/releases/download/v1/empty.sha256":
+			w.Write([]byte("not a checksum\n"))
+		case "/acme/app/releases/download/v1/large.sha256":
+			w.Write([]byte(strings.Repeat(digest, 100)))
+		case "/acme/app/releases/download/v1/redirect.sha256":
+			http.Redirect(w, r, "https://objects.githubusercontent.com/asset/1", http.StatusFound)
+		case "/acme/app/releases/download/v1/escape.sha256":
+			http.Redirect(w, r, "https://169.254.169.254/latest/meta-data/", http.StatusFound)
+		case "/asset/1":
+			w.Write([]byte(digest))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := *checksumClient
+	client.Transport = redirectTransport{server}
+	prev := checksumClient
+	checksumClient = &client
+	defer func() { checksumClient = prev }()
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{"digest only", "https://github.com/acme/app/releases/download/v1/digest.sha256", digest, false},
+		{"digest and file name", "https://github.com/acme/app/releases/download/v1/named.sha256", digest, false},
+		{"redirect to the asset host", "https://github.com/acme/app/releases/download/v1/redirect.sha256", digest, false},
+		{"no digest", "https://github.com/acme/app/releases/download/v1/empty.sha256", "", true},
+		{"too large", "https://github.com/acme/app/releases/download/v1/large.sha256", "", true},
+		{"not found", "https://github.com/acme/app/releases/download/v1/missing.sha256", "", true},
+		{"redirect to another host", "https://github.com/acme/app/releases/download/v1/escape.sha256", "", true},
+		{"not a release asset", "https://github.com/acme/app/raw/main/digest.sha256", "", true},
+		{"another host", "https://example.com/acme/app/releases/download/v1/digest.sha256", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FetchChecksum(context.Background(), AssetInfo{Name: "app.sha256", DownloadURL: tt.url})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchChecksum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FetchChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,35 +3,36 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/go-github/v57/github"
-	"golang.org/x/oauth2"
 )
 
 // Client wraps the GitHub API client.
 type Client struct {
 	client *github.Client
+	tokens *tokenTransport
 }
 
-// NewClient creates a new GitHub API client.
-// If token is empty, an unauthenticated client is created (with lower rate limits).
-func NewClient(token string) *Client {
-	var client *github.Client
-
-	if token != "" {
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		)
-		tc := oauth2.NewClient(context.Background(), ts)
-		client = github.NewClient(tc)
-	} else {
-		client = github.NewClient(nil)
-	}
+// NewClient creates a new GitHub API client rotating across the given tokens.
+// Without tokens, an unauthenticated client is created (with lower rate limits).
+func NewClient(tokens ...string) *Client {
+	// Requests take turns between the tokens, and repeated requests are
+	// revalidated with their ETag
+	pool := newTokenTransport(http.DefaultTransport, tokens)
+	transport := newETagTransport(pool)
 
-	return &Client{client: client}
+	client := github.NewClient(&http.Client{Transport: transport})
+	return &Client{client: client, tokens: pool}
 }
 
+// ErrRepoNotFound is returned when a repository does not exist or is not accessible.
+var ErrRepoNotFound = errors.New("repository not found")
+
 // RepoInfo contains basic repository information.
 type RepoInfo struct {
 	Owner       string
@@ -41,12 +42,21 @@ type RepoInfo struct {
 	Stars       int
 	Forks       int
 	URL         string
+	License     string // SPDX identifier, empty if none detected
+	Private     bool
+	Archived    bool
+	Language    string    // Primary language, empty if none detected
+	OpenIssues  int       // Open issues including pull requests
+	PushedAt    time.Time // Last push to any branch
 }
 
 // GetRepository retrieves information about a repository.
 func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*RepoInfo, error) {
-	r, _, err := c.client.Repositories.Get(ctx, owner, repo)
+	r, resp, err := c.client.Repositories.Get(ctx, owner, repo)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, ErrRepoNotFound
+		}
 		return nil, fmt.Errorf("failed to get repository: %w", err)
 	}
 
@@ -58,6 +68,12 @@ func (c *Client) GetRepository(ctx context.Context, owner, repo string) (*RepoIn
 		Stars:       r.GetStargazersCount(),
 		Forks:       r.GetForksCount(),
 		URL:         r.GetHTMLURL(),
+		License:     r.GetLicense().GetSPDXID(),
+		Private:     r.GetPrivate(),
+		Archived:    r.GetArchived(),
+		Language:    r.GetLanguage(),
+		OpenIssues:  r.GetOpenIssuesCount(),
+		PushedAt:    r.GetPushedAt().Time,
 	}, nil
 }
 
@@ -74,6 +90,108 @@ func (c *Client) ValidateRepository(ctx context.Context, owner, repo string) (bo
 	return true, nil
 }
 
+// ListRecentCommits returns the latest commits on the default branch of a
+// repository, newest first.
+func (c *Client) ListRecentCommits(ctx context.Context, owner, repo string, count int) ([]CommitInfo, error) {
+	opts := &github.CommitsListOptions{ListOptions: github.ListOptions{PerPage: count}}
+	commits, resp, err := c.client.Repositories.ListCommits(ctx, owner, repo, opts)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, ErrRepoNotFound
+		}
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	result := make([]CommitInfo, len(commits))
+	for i, commit := range commits {
+		result[i] = CommitInfo{
+			SHA:       commit.GetSHA(),
+			Message:   commit.GetCommit().GetMessage(),
+			URL:       commit.GetHTMLURL(),
+			Author:    UserInfo{Login: commit.GetCommit().GetAuthor().GetName()},
+			Timestamp: commit.GetCommit().GetAuthor().GetDate().Time,
+		}
+	}
+	return result, nil
+}
+
+// IssueSummary is an issue or pull request in a listing.
+type IssueSummary struct {
+	Number int
+	Title  string
+	URL    string
+	User   string
+}
+
+// SearchIssues returns a page of the issues or pull requests of a repository
+// in the given state, newest first, and how many there are in total. State is
+// open or closed, or merged for pull requests.
+func (c *Client) SearchIssues(ctx context.Context, owner, repo string, pulls bool, state string, page, perPage int) ([]IssueSummary, int, error) {
+	kind := "issue"
+	if pulls {
+		kind = "pr"
+	}
+	query := fmt.Sprintf("repo:%s/%s is:%s is:%s", owner, repo, kind, state)
+	opts := &github.SearchOptions{
+		Sort:        "created",
+		Order:       "desc",
+		ListOptions: github.ListOptions{Page: page, PerPage: perPage},
+	}
+	result, resp, err := c.client.Search.Issues(ctx, query, opts)
+	if err != nil {
+		// The search API rejects qualifiers naming unknown repositories
+		if resp != nil && resp.StatusCode == http.StatusUnprocessableEntity {
+			return nil, 0, ErrRepoNotFound
+		}
+		return nil, 0, fmt.Errorf("failed to search issues: %w", err)
+	}
+
+	items := make([]IssueSummary, len(result.Issues))
+	for i, issue := range result.Issues {
+		items[i] = IssueSummary{
+			Number: issue.GetNumber(),
+			Title:  issue.GetTitle(),
+			URL:    issue.GetHTMLURL(),
+			User:   issue.GetUser().GetLogin(),
+		}
+	}
+	return items, result.GetTotal(), nil
+}
+
+// TrendingRepos approximates GitHub trending, which has no API: the most
+// starred repositories created since the given time, optionally only those
+// written in a language.
+func (c *Client) TrendingRepos(ctx context.Context, language string, since time.Time, count int) ([]RepoInfo, error) {
+	query := "created:>=" + since.UTC().Format("2006-01-02")
+	if language != "" {
+		query += " language:" + strconv.Quote(language)
+	}
+	opts := &github.SearchOptions{
+		Sort:        "stars",
+		Order:       "desc",
+		ListOptions: github.ListOptions{PerPage: count},
+	}
+	result, _, err := c.client.Search.Repositories(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search repositories: %w", err)
+	}
+
+	repos := make([]RepoInfo, len(result.Repositories))
+	for i, r := range result.Repositories {
+		repos[i] = RepoInfo{
+			Owner:       r.GetOwner().GetLogin(),
+			Name:        r.GetName(),
+			FullName:    r.GetFullName(),
+			Description: r.GetDescription(),
+			Stars:       r.GetStargazersCount(),
+			Forks:       r.GetForksCount(),
+			URL:         r.GetHTMLURL(),
+			Language:    r.GetLanguage(),
+		}
+	}
+	return repos, nil
+}
+
 // GetRateLimit returns the current rate limit status.
 func (c *Client) GetRateLimit(ctx context.Context) (*github.RateLimits, error) {
 	limits, _, err := c.client.RateLimit.Get(ctx)
@@ -0,0 +1,181 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// spdxLicenses lists the SPDX identifiers accepted in compliance filters.
+var spdxLicenses = []string{
+	"0BSD", "AFL-3.0", "AGPL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later",
+	"Apache-1.1", "Apache-2.0", "Artistic-2.0", "BSD-2-Clause", "BSD-3-Clause",
+	"BSD-3-Clause-Clear", "BSD-4-Clause", "BSL-1.0", "CC-BY-4.0", "CC-BY-SA-4.0",
+	"CC0-1.0", "ECL-2.0", "EPL-1.0", "EPL-2.0", "EUPL-1.1", "EUPL-1.2",
+	"GPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0", "GPL-3.0-only",
+	"GPL-3.0-or-later", "ISC", "LGPL-2.1", "LGPL-2.1-only", "LGPL-2.1-or-later",
+	"LGPL-3.0", "LGPL-3.0-only", "LGPL-3.0-or-later", "LPPL-1.3c", "MIT",
+	"MIT-0", "MPL-2.0", "MS-PL", "MS-RL", "MulanPSL-2.0", "NCSA", "ODbL-1.0",
+	"OFL-1.1", "OSL-3.0", "PostgreSQL", "Unlicense", "UPL-1.0", "Vim",
+	"WTFPL", "Zlib",
+}
+
+// ParseLicenseList parses a comma-separated list of SPDX identifiers,
+// returning their canonical spelling.
+func ParseLicenseList(list string) ([]string, error) {
+	var licenses []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, err := canonicalLicense(item)
+		if err != nil {
+			return nil, err
+		}
+		licenses = append(licenses, id)
+	}
+	if len(licenses) == 0 {
+		return nil, errors.New("no licenses given")
+	}
+	return licenses, nil
+}
+
+// canonicalLicense resolves an SPDX identifier case-insensitively and suggests
+// the closest known identifier when it cannot be resolved.
+func canonicalLicense(id string) (string, error) {
+	for _, known := range spdxLicenses {
+		if strings.EqualFold(known, id) {
+			return known, nil
+		}
+	}
+
+	if normalized := normalizeLicense(id); normalized != "" {
+		for _, known := range spdxLicenses {
+			if strings.HasPrefix(normalizeLicense(known), normalized) {
+				return "", fmt.Errorf("unknown SPDX license identifier %q, did you mean %q?", id, known)
+			}
+		}
+	}
+	return "", fmt.Errorf("unknown SPDX license identifier %q (see https://spdx.org/licenses/)", id)
+}
+
+// normalizeLicense strips everything but letters and digits for fuzzy matching.
+func normalizeLicense(id string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(id) {
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// ComplianceEvent reports the license and visibility of a repository.
+type ComplianceEvent struct {
+	License    string // SPDX identifier, empty or NOASSERTION if unknown
+	Private    bool
+	Archived   bool
+	Accessible bool
+	URL        string
+}
+
+// Violations lists the ways the repository breaks a compliance policy that
+// allows the given licenses and requires the repository to stay public.
+func (e *ComplianceEvent) Violations(allowed []string) []string {
+	if !e.Accessible {
+		return []string{"repository is no longer accessible (private or deleted)"}
+	}
+
+	var violations []string
+	licenseOK := false
+	for _, id := range allowed {
+		if strings.EqualFold(id, e.License) {
+			licenseOK = true
+			break
+		}
+	}
+	if !licenseOK {
+		if e.License == "" || e.License == "NOASSERTION" {
+			violations = append(violations, "license is missing or unrecognized")
+		} else {
+			violations = append(violations, fmt.Sprintf("license changed to %s", e.License))
+		}
+	}
+	if e.Private {
+		violations = append(violations, "repository went private")
+	}
+	if e.Archived {
+		violations = append(violations, "repository was archived")
+	}
+	return violations
+}
+
+// SetComplianceEvery sets how many polls of a repository pass between compliance checks.
+func (p *Poller) SetComplianceEvery(cycles int) {
+	if cycles < 1 {
+		cycles = 1
+	}
+	p.complianceEvery = cycles
+}
+
+// checkCompliance emits a compliance event for a repository every few polls,
+// if any of its subscriptions has a compliance filter.
+func (p *Poller) checkCompliance(ctx context.Context, owner, name string) {
+	key := owner + "/" + name
+	p.mu.Lock()
+	cycle := p.complianceCycles[key]
+	p.complianceCycles[key] = cycle + 1
+	p.mu.Unlock()
+	if cycle%p.complianceEvery != 0 {
+		return
+	}
+
+	subs, err := p.store.GetSubscriptionsByRepo(owner, name)
+	if err != nil {
+		logger.Debug().Err(err).Str("repo", key).Msg("Failed to get subscriptions")
+		return
+	}
+	watched := false
+	for _, sub := range subs {
+		if filters, err := storage.ParseFilters(sub.Filters); err == nil && len(filters.Licenses) > 0 {
+			watched = true
+			break
+		}
+	}
+	if !watched {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	event := &ComplianceEvent{
+		Accessible: true,
+		URL:        fmt.Sprintf("https://github.com/%s/%s", owner, name),
+	}
+	info, err := p.client.GetRepository(ctx, owner, name)
+	switch {
+	case errors.Is(err, ErrRepoNotFound):
+		event.Accessible = false
+	case err != nil:
+		p.fetchFailed(err, key, "Failed to check compliance")
+		return
+	default:
+		event.License = info.License
+		event.Private = info.Private
+		event.Archived = info.Archived
+		event.URL = info.URL
+	}
+
+	select {
+	case p.eventsCh <- &WebhookEvent{Type: "compliance", RepoOwner: owner, RepoName: name, Payload: event}:
+	default:
+		logger.Warn().Msg("Event channel full")
+	}
+}
@@ -0,0 +1,91 @@
+package github
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestViolations(t *testing.T) {
+	allowed := []string{"MIT", "Apache-2.0"}
+	tests := []struct {
+		name  string
+		event ComplianceEvent
+		want  []string
+	}{
+		{"compliant", ComplianceEvent{License: "MIT", Accessible: true}, nil},
+		{"license matched case-insensitively", ComplianceEvent{License: "apache-2.0", Accessible: true}, nil},
+		{"license changed", ComplianceEvent{License: "GPL-3.0", Accessible: true}, []string{"license changed to GPL-3.0"}},
+		{"license removed", ComplianceEvent{Accessible: true}, []string{"license is missing or unrecognized"}},
+		{"license unrecognized", ComplianceEvent{License: "NOASSERTION", Accessible: true}, []string{"license is missing or unrecognized"}},
+		{"went private", ComplianceEvent{License: "MIT", Private: true, Accessible: true}, []string{"repository went private"}},
+		{"archived", ComplianceEvent{License: "MIT", Archived: true, Accessible: true}, []string{"repository was archived"}},
+		{
+			"several at once",
+			ComplianceEvent{License: "BUSL-1.1", Private: true, Archived: true, Accessible: true},
+			[]string{"license changed to BUSL-1.1", "repository went private", "repository was archived"},
+		},
+		{
+			// Nothing else is known about a repository that cannot be read
+			"inaccessible",
+			ComplianceEvent{License: "GPL-3.0", Archived: true},
+			[]string{"repository is no longer accessible (private or deleted)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.Violations(allowed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Violations() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViolationsNoLicenseAllowed(t *testing.T) {
+	event := ComplianceEvent{License: "MIT", Accessible: true}
+	if got := event.Violations(nil); !reflect.DeepEqual(got, []string{"license changed to MIT"}) {
+		t.Errorf("Violations(nil) = %q", got)
+	}
+}
+
+func TestParseLicenseList(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr string // Substring of the error, empty for none
+	}{
+		{"MIT", []string{"MIT"}, ""},
+		{"mit, apache-2.0", []string{"MIT", "Apache-2.0"}, ""},
+		{" bsd-3-clause ,, ISC ,", []string{"BSD-3-Clause", "ISC"}, ""},
+		{"gpl-3.0-OR-LATER", []string{"GPL-3.0-or-later"}, ""},
+
+		// Close misspellings suggest the identifier meant
+		{"Apache2", nil, `did you mean "Apache-2.0"?`},
+		{"apache 2.0", nil, `did you mean "Apache-2.0"?`},
+		{"BSD3", nil, `did you mean "BSD-3-Clause"?`},
+		{"MIT, LGPL3", nil, `did you mean "LGPL-3.0"?`},
+
+		// Others point to the list of identifiers
+		{"Proprietary", nil, "see https://spdx.org/licenses/"},
+		{"MIT, ???", nil, "see https://spdx.org/licenses/"},
+		{"", nil, "no licenses given"},
+		{" , ,", nil, "no licenses given"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, err := ParseLicenseList(tt.list)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseLicenseList(%q) error = %v, want %q", tt.list, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLicenseList(%q) error = %v", tt.list, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLicenseList(%q) = %q, want %q", tt.list, got, tt.want)
+			}
+		})
+	}
+}
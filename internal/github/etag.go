@@ -0,0 +1,122 @@
+package github
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// GitHub does not count conditional requests answered with 304 Not Modified
+// against the rate limit. The poller lists the same resources of every
+// repository over and over, so responses are remembered with their ETag and
+// revalidated instead of fetched again.
+
+// maxETagEntries caps how many responses are remembered.
+const maxETagEntries = 1000
+
+// etagEntry is a remembered response.
+type etagEntry struct {
+	etag     string
+	header   http.Header
+	body     []byte
+	lastUsed time.Time
+}
+
+// etagTransport sends If-None-Match for GET requests it has seen before and
+// answers a 304 with the remembered response.
+type etagTransport struct {
+	base http.RoundTripper
+
+	mu      sync.Mutex
+	entries map[string]*etagEntry // Keyed by request URL
+}
+
+func newETagTransport(base http.RoundTripper) *etagTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &etagTransport{base: base, entries: make(map[string]*etagEntry)}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *etagTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	t.mu.Lock()
+	cached := t.entries[key]
+	t.mu.Unlock()
+
+	if cached != nil {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		return t.fromCache(req, resp, key, cached), nil
+	case resp.StatusCode == http.StatusOK && resp.Header.Get("ETag") != "":
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.store(key, &etagEntry{etag: resp.Header.Get("ETag"), header: resp.Header.Clone(), body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// fromCache builds a response from a remembered one, keeping the current
+// rate limit headers of the 304 response.
+func (t *etagTransport) fromCache(req *http.Request, notModified *http.Response, key string, cached *etagEntry) *http.Response {
+	t.mu.Lock()
+	cached.lastUsed = time.Now()
+	t.mu.Unlock()
+
+	header := cached.header.Clone()
+	for name, values := range notModified.Header {
+		header[name] = values
+	}
+	header.Set("X-From-Cache", "1")
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.body)),
+		ContentLength: int64(len(cached.body)),
+		Request:       req,
+	}
+}
+
+// store remembers a response, evicting the least recently used one when full.
+func (t *etagTransport) store(key string, entry *etagEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry.lastUsed = time.Now()
+	if _, ok := t.entries[key]; !ok && len(t.entries) >= maxETagEntries {
+		var oldest string
+		for k, e := range t.entries {
+			if oldest == "" || e.lastUsed.Before(t.entries[oldest].lastUsed) {
+				oldest = k
+			}
+		}
+		delete(t.entries, oldest)
+	}
+	t.entries[key] = entry
+}
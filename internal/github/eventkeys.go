@@ -0,0 +1,117 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The same change can reach the bot through a webhook and through polling.
+// Both sources derive the keys recording an event as processed here, so that
+// whichever arrives second is recognized as a duplicate.
+
+// EventKeys returns the keys recording an event as processed. The event was
+// processed once all of them are recorded. Pushes are keyed by ref and
+// commit, as the poller finds the commits of a push one at a time.
+func EventKeys(event *WebhookEvent) []string {
+	switch e := event.Payload.(type) {
+	case *PushEvent:
+		if len(e.Commits) == 0 {
+			return []string{PushKey(e.Ref, e.After)}
+		}
+		keys := make([]string, len(e.Commits))
+		for i, c := range e.Commits {
+			keys[i] = PushKey(e.Ref, c.SHA)
+		}
+		return keys
+	case *ReleaseEvent:
+		return []string{ReleaseKey(e.TagName)}
+	case *TagEvent:
+		return []string{TagKey(e.Name)}
+	case *IssueEvent:
+		return []string{IssueKey(e.Number, e.Action)}
+	case *PullRequestEvent:
+		return []string{PullRequestKey(e.Number, e.Action, e.Merged)}
+	case *WorkflowRunEvent:
+		return []string{WorkflowRunKey(e.ID, e.Attempt)}
+	case *PackageEvent:
+		return []string{fmt.Sprintf("package-%s-%d-%s", e.Name, e.VersionID, e.Version)}
+	case *PullRequestReviewEvent:
+		return []string{fmt.Sprintf("review-%d", e.ID)}
+	case *PullRequestReviewCommentEvent:
+		return []string{fmt.Sprintf("comment-%d", e.ID)}
+	case *DeploymentEvent:
+		return []string{fmt.Sprintf("deploy-%d-%d", e.ID, e.StatusID)}
+	case *WikiEvent:
+		shas := make([]string, len(e.Pages))
+		for i, page := range e.Pages {
+			shas[i] = page.SHA
+		}
+		return []string{"wiki-" + strings.Join(shas, "-")}
+	case *StarEvent:
+		if e.Milestone > 0 {
+			return []string{fmt.Sprintf("milestone-%d", e.Milestone)}
+		}
+		return []string{fmt.Sprintf("%s-%d", e.User.Login, e.Stars)}
+	case *TopicRepoEvent:
+		return []string{TopicRepoKey(e.ID)}
+	default:
+		return []string{fmt.Sprintf("%s-%v", event.Type, event.Payload)}
+	}
+}
+
+// PushKey returns the processed-event key of a commit pushed to a ref. A
+// commit landing on another branch, as when a feature branch is merged, is a
+// new push.
+func PushKey(ref, sha string) string {
+	return ref + "@" + sha
+}
+
+// PushKeySHA returns the commit of a push key, the key earlier versions
+// recorded pushes under.
+func PushKeySHA(key string) string {
+	return key[strings.LastIndex(key, "@")+1:]
+}
+
+// TopicRepoKey returns the processed-event key of a repository found for a
+// followed topic. It is recorded under the topic, with an empty owner, so
+// that a renamed or transferred repository is not announced again.
+func TopicRepoKey(id int64) string {
+	return fmt.Sprintf("topic-repo-%d", id)
+}
+
+// ReleaseKey returns the processed-event key of a release.
+func ReleaseKey(tagName string) string {
+	return "release-" + tagName
+}
+
+// TagKey returns the processed-event key of a tag.
+func TagKey(name string) string {
+	return "tag-" + name
+}
+
+// IssueKey returns the processed-event key of an issue action. Opening an
+// issue is recorded as its creation.
+func IssueKey(number int, action string) string {
+	if action == "opened" {
+		action = "created"
+	}
+	return fmt.Sprintf("issue-%d-%s", number, action)
+}
+
+// PullRequestKey returns the processed-event key of a pull request action.
+// Webhooks report a merge as a closed action of a merged pull request, while
+// the poller reports it as a merge.
+func PullRequestKey(number int, action string, merged bool) string {
+	switch {
+	case action == "opened":
+		action = "created"
+	case action == "closed" && merged:
+		action = "merged"
+	}
+	return fmt.Sprintf("pr-%d-%s", number, action)
+}
+
+// WorkflowRunKey returns the processed-event key of a workflow run attempt.
+func WorkflowRunKey(id int64, attempt int) string {
+	return fmt.Sprintf("run-%d-%d", id, attempt)
+}
@@ -1,9 +1,15 @@
 package github
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
 )
 
 // Event represents a generic GitHub event.
@@ -48,6 +54,36 @@ type ReleaseEvent struct {
 	URL         string
 	Author      UserInfo
 	PublishedAt time.Time
+	Assets      []AssetInfo
+	FullTextURL string // Full release notes published elsewhere when they are too long
+}
+
+// AssetInfo represents a file attached to a release.
+type AssetInfo struct {
+	Name        string
+	Size        int
+	DownloadURL string
+}
+
+// TagEvent represents a tag being created, with or without a release.
+type TagEvent struct {
+	Name   string
+	SHA    string // Commit the tag points to, empty if unknown
+	URL    string
+	Pusher UserInfo
+}
+
+// PackageEvent represents a package version published to GitHub Packages.
+type PackageEvent struct {
+	Action      string // published
+	Name        string
+	Ecosystem   string // container, npm, maven, etc.
+	Version     string // Version or container tag
+	VersionID   int64
+	PackageURL  string // Pull reference, e.g. ghcr.io/owner/image:tag
+	RegistryURL string
+	URL         string
+	Publisher   UserInfo
 }
 
 // IssueEvent represents an issue event.
@@ -61,6 +97,8 @@ type IssueEvent struct {
 	User     UserInfo
 	Labels   []string
 	Assignee *UserInfo
+
+	FullTextURL string // Full description published elsewhere when it is too long
 }
 
 // PullRequestEvent represents a pull request event.
@@ -74,6 +112,7 @@ type PullRequestEvent struct {
 	User      UserInfo
 	Merged    bool
 	MergedBy  *UserInfo
+	Labels    []string
 	Base      BranchInfo
 	Head      BranchInfo
 	Additions int
@@ -81,6 +120,115 @@ type PullRequestEvent struct {
 	Commits   int
 }
 
+// StarEvent represents a repository being starred, or its star count
+// crossing a milestone.
+type StarEvent struct {
+	Action    string // created, or milestone when detected by the poller
+	User      UserInfo
+	Stars     int // Star count after the event
+	Milestone int // Milestone crossed, 0 if none
+	URL       string
+}
+
+// PullRequestReviewEvent represents a review submitted on a pull request.
+type PullRequestReviewEvent struct {
+	Action   string // submitted, edited, dismissed
+	ID       int64
+	Number   int
+	Title    string // Pull request title
+	State    string // approved, changes_requested, commented
+	Body     string
+	URL      string
+	Reviewer UserInfo
+}
+
+// PullRequestReviewCommentEvent represents a comment on a pull request diff.
+type PullRequestReviewCommentEvent struct {
+	Action string // created, edited, deleted
+	ID     int64
+	Number int
+	Title  string // Pull request title
+	Path   string // File the comment is attached to
+	Body   string
+	URL    string
+	User   UserInfo
+}
+
+// WorkflowRunEvent represents a completed GitHub Actions workflow run.
+type WorkflowRunEvent struct {
+	Action     string // completed
+	ID         int64
+	Attempt    int
+	RunNumber  int
+	Name       string // Workflow name
+	Title      string // Display title, usually the head commit message
+	Branch     string
+	Event      string // Trigger, e.g. push or pull_request
+	Conclusion string // success, failure, cancelled, timed_out, etc.
+	URL        string
+	Actor      UserInfo
+}
+
+// Failed reports whether the run ended in a failure.
+func (e *WorkflowRunEvent) Failed() bool {
+	switch e.Conclusion {
+	case "failure", "timed_out", "startup_failure":
+		return true
+	default:
+		return false
+	}
+}
+
+// DeploymentEvent represents a deployment being created or changing state.
+type DeploymentEvent struct {
+	ID          int64
+	StatusID    int64  // 0 for the deployment itself
+	Environment string // e.g. production, staging
+	State       string // created, queued, in_progress, success, failure, error, inactive
+	Ref         string
+	SHA         string
+	Description string
+	TargetURL   string // Where the deployment can be reached, if known
+	URL         string // Deployments page of the repository
+	Creator     UserInfo
+}
+
+// WikiEvent represents one or more wiki pages being created or edited.
+type WikiEvent struct {
+	Pages  []WikiPage
+	Sender UserInfo
+}
+
+// WikiPage represents a single wiki page change.
+type WikiPage struct {
+	Name    string
+	Title   string
+	Action  string // created, edited
+	SHA     string // Latest revision of the page
+	URL     string
+	DiffURL string
+}
+
+// RepositoryEvent represents a repository being renamed, transferred or archived.
+type RepositoryEvent struct {
+	Action   string // renamed, transferred, archived, unarchived
+	OldOwner string // Owner before a transfer, same as the current owner otherwise
+	OldName  string // Name before a rename, same as the current name otherwise
+	URL      string
+	Sender   UserInfo
+}
+
+// TopicRepoEvent represents a new repository tagged with a followed topic.
+// The repository is the one of the WebhookEvent carrying it.
+type TopicRepoEvent struct {
+	ID          int64 // Repository ID
+	Topic       string
+	Description string
+	Language    string
+	Stars       int
+	URL         string
+}
+
 // BranchInfo represents branch information in a PR.
 type BranchInfo struct {
 	Ref  string
@@ -96,16 +244,15 @@ type UserInfo struct {
 }
 
 // FormatPushMessage formats a push event as a notification message.
-func (e *PushEvent) FormatMessage(repo RepoInfo) string {
+func (e *PushEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
 	branch := extractBranchName(e.Ref)
 	commitCount := len(e.Commits)
-	commitWord := "commit"
-	if commitCount > 1 {
-		commitWord = "commits"
+	title := "push.title"
+	if commitCount == 1 {
+		title = "push.title_one"
 	}
 
-	msg := fmt.Sprintf("🔨 *%s* pushed %d %s to `%s`\n\n",
-		e.Pusher.Login, commitCount, commitWord, branch)
+	msg := i18n.T(lang, title, e.Pusher.Login, branch, commitCount)
 
 	// Show up to 5 commits
 	maxCommits := 5
@@ -114,23 +261,44 @@ func (e *PushEvent) FormatMessage(repo RepoInfo) string {
 	}
 
 	for i := 0; i < maxCommits; i++ {
-		commit := e.Commits[i]
-		shortSHA := commit.SHA[:7]
-		shortMsg := escapeMarkdown(truncateString(commit.Message, 50))
-		msg += fmt.Sprintf("• [`%s`](%s) %s\n", shortSHA, commit.URL, shortMsg)
+		msg += formatCommitLine(e.Commits[i])
 	}
 
 	if len(e.Commits) > 5 {
-		msg += fmt.Sprintf("\n_...and %d more commits_\n", len(e.Commits)-5)
+		msg += i18n.T(lang, "push.more", len(e.Commits)-5)
 	}
 
-	msg += fmt.Sprintf("\n[Compare changes](%s)", e.Compare)
+	msg += "\n" + MarkdownLink(i18n.T(lang, "push.compare"), e.Compare)
 
 	return msg
 }
 
+// formatCommitLine formats a commit as a list entry with its linked short SHA.
+func formatCommitLine(commit CommitInfo) string {
+	shortSHA := commit.SHA[:min(7, len(commit.SHA))]
+	shortMsg := truncateString(commit.Message, 50)
+	return markdown.Sprintf("• %s %s\n", markdown.Raw(MarkdownLink(markdown.Code(shortSHA), commit.URL)), shortMsg)
+}
+
+// FormatCommitHistory lists recent commits of a repository, newest first,
+// like the commits of a push.
+func FormatCommitHistory(repo RepoInfo, commits []CommitInfo, lang i18n.Lang) string {
+	msg := i18n.T(lang, "commits.title", repo.Owner, repo.Name, len(commits))
+	for _, commit := range commits {
+		msg += formatCommitLine(commit)
+	}
+	return msg
+}
+
+// Release notes and issue descriptions longer than these are cut short in
+// notifications.
+const (
+	MaxReleaseBodyLength = 1500
+	MaxIssueBodyLength   = 600
+)
+
 // FormatReleaseMessage formats a release event as a notification message.
-func (e *ReleaseEvent) FormatMessage(repo RepoInfo) string {
+func (e *ReleaseEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
 	emoji := "🎉"
 	if e.Prerelease {
 		emoji = "🧪"
@@ -141,22 +309,87 @@ func (e *ReleaseEvent) FormatMessage(repo RepoInfo) string {
 		name = e.TagName
 	}
 
-	msg := fmt.Sprintf("%s *New Release: %s*\n\n", emoji, name)
-	msg += fmt.Sprintf("📦 Tag: `%s`\n", e.TagName)
-	msg += fmt.Sprintf("👤 Author: %s\n", e.Author.Login)
+	msg := i18n.T(lang, "release.title", emoji, name)
+	msg += i18n.T(lang, "field.tag", e.TagName)
+	msg += i18n.T(lang, "field.author", e.Author.Login)
 
-	if e.Body != "" {
-		body := truncateString(e.Body, 300)
-		msg += fmt.Sprintf("\n%s\n", body)
+	if body := FormatGFM(e.Body, MaxReleaseBodyLength); body != "" {
+		msg += "\n" + body + "\n"
+		if len(e.Body) > MaxReleaseBodyLength && e.FullTextURL != "" {
+			msg += MarkdownLink(i18n.T(lang, "release.full_text"), e.FullTextURL) + "\n"
+		}
 	}
+	msg += formatAssetList(e.Assets, lang)
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "release.view"), e.URL)
+
+	return msg
+}
 
-	msg += fmt.Sprintf("\n[View Release](%s)", e.URL)
+// formatAssetList lists the downloadable assets of a release, leaving out
+// checksum files.
+func formatAssetList(assets []AssetInfo, lang i18n.Lang) string {
+	var listed []AssetInfo
+	for _, a := range assets {
+		if !isChecksumAsset(a.Name) {
+			listed = append(listed, a)
+		}
+	}
+	if len(listed) == 0 {
+		return ""
+	}
+
+	msg := i18n.T(lang, "release.assets")
+	for i, a := range listed {
+		if i == maxListedAssets {
+			msg += i18n.T(lang, "release.assets_more", len(listed)-i)
+			break
+		}
+		link := MarkdownLink(markdown.Escape(a.Name), a.DownloadURL)
+		msg += markdown.Sprintf("• %s (%s)\n", markdown.Raw(link), FormatAssetSize(a.Size))
+	}
+	return msg
+}
+
+// FormatTagMessage formats a tag event as a notification message.
+func (e *TagEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	msg := i18n.T(lang, "tag.title")
+	msg += i18n.T(lang, "field.tag", e.Name)
+	if len(e.SHA) >= 7 {
+		msg += i18n.T(lang, "field.commit", e.SHA[:7])
+	}
+	if e.Pusher.Login != "" {
+		msg += i18n.T(lang, "field.by", e.Pusher.Login)
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "tag.view"), e.URL)
+
+	return msg
+}
+
+// FormatPackageMessage formats a package event as a notification message.
+func (e *PackageEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	msg := i18n.T(lang, "package.title", e.Name)
+	msg += i18n.T(lang, "field.version", e.Version)
+	if e.Ecosystem != "" {
+		msg += i18n.T(lang, "field.type", e.Ecosystem)
+	}
+	if e.PackageURL != "" {
+		msg += markdown.Sprintf("📍 `%s`\n", e.PackageURL)
+	} else if e.RegistryURL != "" {
+		msg += "📍 " + MarkdownLink(i18n.T(lang, "package.registry"), e.RegistryURL) + "\n"
+	}
+	if e.Publisher.Login != "" {
+		msg += i18n.T(lang, "field.by", e.Publisher.Login)
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "package.view"), e.URL)
 
 	return msg
 }
 
 // FormatIssueMessage formats an issue event as a notification message.
-func (e *IssueEvent) FormatMessage(repo RepoInfo) string {
+func (e *IssueEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
 	actionEmoji := map[string]string{
 		"opened":   "📝",
 		"closed":   "✅",
@@ -168,21 +401,30 @@ func (e *IssueEvent) FormatMessage(repo RepoInfo) string {
 		emoji = "📋"
 	}
 
-	msg := fmt.Sprintf("%s *Issue #%d %s*\n\n", emoji, e.Number, e.Action)
-	msg += fmt.Sprintf("📌 %s\n", escapeMarkdown(e.Title))
-	msg += fmt.Sprintf("👤 By: %s\n", escapeMarkdown(e.User.Login))
+	msg := i18n.T(lang, "issue.title", emoji, e.Number, translate(lang, "action", e.Action))
+	msg += markdown.Sprintf("📌 %s\n", e.Title)
+	msg += i18n.T(lang, "field.by", e.User.Login)
 
 	if len(e.Labels) > 0 {
-		msg += fmt.Sprintf("🏷️ Labels: %v\n", e.Labels)
+		msg += i18n.T(lang, "field.labels", e.Labels)
+	}
+
+	if e.Action == "opened" {
+		if body := FormatGFM(e.Body, MaxIssueBodyLength); body != "" {
+			msg += "\n" + body + "\n"
+			if len(e.Body) > MaxIssueBodyLength && e.FullTextURL != "" {
+				msg += MarkdownLink(i18n.T(lang, "issue.full_text"), e.FullTextURL) + "\n"
+			}
+		}
 	}
 
-	msg += fmt.Sprintf("\n[View Issue](%s)", e.URL)
+	msg += "\n" + MarkdownLink(i18n.T(lang, "issue.view"), e.URL)
 
 	return msg
 }
 
 // FormatPRMessage formats a pull request event as a notification message.
-func (e *PullRequestEvent) FormatMessage(repo RepoInfo) string {
+func (e *PullRequestEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
 	actionEmoji := map[string]string{
 		"opened":   "🔀",
 		"closed":   "❌",
@@ -200,22 +442,234 @@ func (e *PullRequestEvent) FormatMessage(repo RepoInfo) string {
 		emoji = "🔀"
 	}
 
-	msg := fmt.Sprintf("%s *PR #%d %s*\n\n", emoji, e.Number, action)
-	msg += fmt.Sprintf("📌 %s\n", escapeMarkdown(e.Title))
-	msg += fmt.Sprintf("👤 By: %s\n", escapeMarkdown(e.User.Login))
-	msg += fmt.Sprintf("🔀 %s → %s\n", escapeMarkdown(e.Head.Ref), escapeMarkdown(e.Base.Ref))
+	msg := i18n.T(lang, "pr.title", emoji, e.Number, translate(lang, "action", action))
+	msg += markdown.Sprintf("📌 %s\n", e.Title)
+	msg += i18n.T(lang, "field.by", e.User.Login)
+	msg += markdown.Sprintf("🔀 %s → %s\n", e.Head.Ref, e.Base.Ref)
+
+	if len(e.Labels) > 0 {
+		msg += i18n.T(lang, "field.labels", e.Labels)
+	}
 
 	if e.Commits > 0 {
-		msg += fmt.Sprintf("📊 %d commits, +%d/-%d lines\n", e.Commits, e.Additions, e.Deletions)
+		msg += i18n.T(lang, "pr.stats", e.Commits, e.Additions, e.Deletions)
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "pr.view"), e.URL)
+
+	return msg
+}
+
+// FormatStarMessage formats a star event as a notification message.
+func (e *StarEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	var msg string
+	if e.Milestone > 0 {
+		msg = i18n.T(lang, "star.milestone", e.Milestone)
+	} else {
+		msg = i18n.T(lang, "star.new")
+		msg += i18n.T(lang, "field.by", e.User.Login)
+	}
+	msg += i18n.T(lang, "star.total", e.Stars)
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "star.view"), e.URL)
+
+	return msg
+}
+
+// FormatReviewMessage formats a pull request review event as a notification message.
+func (e *PullRequestReviewEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	title := "review.commented"
+	switch e.State {
+	case "approved", "changes_requested":
+		title = "review." + e.State
+	}
+
+	msg := i18n.T(lang, title, e.Number) + "\n\n"
+	msg += markdown.Sprintf("📌 %s\n", e.Title)
+	msg += i18n.T(lang, "field.reviewer", e.Reviewer.Login)
+
+	if e.Body != "" {
+		msg += markdown.Sprintf("\n%s\n", truncateString(e.Body, 300))
 	}
 
-	msg += fmt.Sprintf("\n[View PR](%s)", e.URL)
+	msg += "\n" + MarkdownLink(i18n.T(lang, "review.view"), e.URL)
+
+	return msg
+}
+
+// FormatReviewCommentMessage formats a review comment event as a notification message.
+func (e *PullRequestReviewCommentEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	msg := i18n.T(lang, "review_comment.title", e.Number)
+	msg += markdown.Sprintf("📌 %s\n", e.Title)
+	msg += i18n.T(lang, "field.by", e.User.Login)
+	if e.Path != "" {
+		msg += i18n.T(lang, "field.file", e.Path)
+	}
+
+	if e.Body != "" {
+		msg += markdown.Sprintf("\n%s\n", truncateString(e.Body, 300))
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "review_comment.view"), e.URL)
+
+	return msg
+}
+
+// FormatWorkflowRunMessage formats a workflow run event as a notification message.
+func (e *WorkflowRunEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	conclusionEmoji := map[string]string{
+		"success":   "✅",
+		"failure":   "❌",
+		"cancelled": "⚪",
+		"timed_out": "⏱️",
+	}
+
+	emoji := conclusionEmoji[e.Conclusion]
+	if emoji == "" {
+		emoji = "⚠️"
+	}
+
+	conclusion := translate(lang, "conclusion", e.Conclusion)
+	msg := i18n.T(lang, "workflow.title", emoji, conclusion, e.Name)
+	if e.Title != "" {
+		msg += markdown.Sprintf("📌 %s\n", truncateString(firstLine(e.Title), 80))
+	}
+	msg += i18n.T(lang, "field.branch", e.Branch)
+	msg += i18n.T(lang, "workflow.run", e.RunNumber)
+	if e.Attempt > 1 {
+		msg += i18n.T(lang, "workflow.attempt", e.Attempt)
+	}
+	msg += "\n"
+	if e.Actor.Login != "" {
+		msg += i18n.T(lang, "field.by", e.Actor.Login)
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "workflow.view"), e.URL)
+
+	return msg
+}
+
+// FormatDeploymentMessage formats a deployment event as a notification message.
+func (e *DeploymentEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	stateEmoji := map[string]string{
+		"created":     "🚀",
+		"queued":      "⏳",
+		"pending":     "⏳",
+		"in_progress": "🔄",
+		"success":     "✅",
+		"failure":     "❌",
+		"error":       "❌",
+		"inactive":    "💤",
+	}
+
+	emoji := stateEmoji[e.State]
+	if emoji == "" {
+		emoji = "🚀"
+	}
+
+	state := translate(lang, "deployment_state", e.State)
+	msg := i18n.T(lang, "deployment.title", emoji, e.Environment, state)
+	if e.Ref != "" {
+		msg += i18n.T(lang, "field.ref", e.Ref)
+	}
+	if len(e.SHA) >= 7 {
+		msg += i18n.T(lang, "field.commit", e.SHA[:7])
+	}
+	if e.Creator.Login != "" {
+		msg += i18n.T(lang, "field.by", e.Creator.Login)
+	}
+	if e.Description != "" {
+		msg += markdown.Sprintf("\n%s\n", truncateString(e.Description, 200))
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "deployment.view"), e.URL)
+	if SanitizeURL(e.TargetURL) != "" {
+		msg += " • " + MarkdownLink(i18n.T(lang, "deployment.open"), e.TargetURL)
+	}
+
+	return msg
+}
+
+// FormatWikiMessage formats a wiki event as a notification message.
+func (e *WikiEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	msg := i18n.T(lang, "wiki.title", e.Sender.Login)
+
+	// Show up to 5 pages
+	maxPages := 5
+	if len(e.Pages) < maxPages {
+		maxPages = len(e.Pages)
+	}
+
+	for _, page := range e.Pages[:maxPages] {
+		title := page.Title
+		if title == "" {
+			title = page.Name
+		}
+		line := markdown.Sprintf("• %s %s", translate(lang, "action", page.Action), markdown.Raw(MarkdownLink(markdown.Escape(title), page.URL)))
+		if page.Action == "edited" && SanitizeURL(page.DiffURL) != "" {
+			line += markdown.Sprintf(" (%s)", markdown.Raw(MarkdownLink(i18n.T(lang, "wiki.diff"), page.DiffURL)))
+		}
+		msg += line + "\n"
+	}
+
+	if len(e.Pages) > maxPages {
+		msg += i18n.T(lang, "wiki.more", len(e.Pages)-maxPages)
+	}
+
+	return msg
+}
+
+// FormatRepositoryMessage formats a repository lifecycle event as a notification message.
+func (e *RepositoryEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	var msg string
+	switch e.Action {
+	case "renamed", "transferred":
+		msg = i18n.T(lang, "repository.moved", translate(lang, "action", e.Action))
+		msg += markdown.Sprintf("`%s/%s` → `%s/%s`\n", e.OldOwner, e.OldName, repo.Owner, repo.Name)
+		msg += i18n.T(lang, "repository.moved_note")
+	case "archived":
+		msg = i18n.T(lang, "repository.archived")
+	case "unarchived":
+		msg = i18n.T(lang, "repository.unarchived")
+	default:
+		msg = i18n.T(lang, "repository.other", e.Action)
+	}
+	if e.Sender.Login != "" {
+		msg += i18n.T(lang, "field.by", e.Sender.Login)
+	}
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "repository.view"), e.URL)
+
+	return msg
+}
+
+// FormatMessage formats a new repository under a topic as a notification message.
+func (e *TopicRepoEvent) FormatMessage(repo RepoInfo, lang i18n.Lang) string {
+	msg := i18n.T(lang, "topic.new_repo", e.Topic)
+	if e.Description != "" {
+		msg += markdown.Sprintf("%s\n", truncateString(e.Description, 200))
+	}
+	if e.Language != "" {
+		msg += i18n.T(lang, "topic.language", e.Language)
+	}
+	msg += i18n.T(lang, "topic.stars", e.Stars)
+
+	msg += "\n" + MarkdownLink(i18n.T(lang, "repository.view"), e.URL)
 
 	return msg
 }
 
 // Helper functions
 
+// translate returns the translation of a GitHub action or state such as
+// "opened", or the value itself when there is none.
+func translate(lang i18n.Lang, kind, value string) string {
+	if text, ok := i18n.Lookup(lang, kind+"."+value); ok {
+		return text
+	}
+	return value
+}
+
 func extractBranchName(ref string) string {
 	// refs/heads/main -> main
 	if len(ref) > 11 && ref[:11] == "refs/heads/" {
@@ -224,34 +678,185 @@ func extractBranchName(ref string) string {
 	return ref
 }
 
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// truncateString shortens s to at most maxLen bytes, ending it with "...".
+// It cuts before a multi-byte character rather than through it, since
+// Telegram rejects messages that are not valid UTF-8.
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
-}
-
-// escapeMarkdown escapes special Markdown characters to prevent parsing errors.
-func escapeMarkdown(s string) string {
-	replacer := strings.NewReplacer(
-		"_", "\\_",
-		"*", "\\*",
-		"[", "\\[",
-		"]", "\\]",
-		"(", "\\(",
-		")", "\\)",
-		"~", "\\~",
-		"`", "\\`",
-		">", "\\>",
-		"#", "\\#",
-		"+", "\\+",
-		"-", "\\-",
-		"=", "\\=",
-		"|", "\\|",
-		"{", "\\{",
-		"}", "\\}",
-		".", "\\.",
-		"!", "\\!",
-	)
-	return replacer.Replace(s)
+	cut := max(maxLen-3, 0)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
+
+// EventURL returns the GitHub page of an event payload, or an empty string
+// when it has none.
+func EventURL(payload interface{}) string {
+	switch e := payload.(type) {
+	case *PushEvent:
+		return e.Compare
+	case *ReleaseEvent:
+		return e.URL
+	case *TagEvent:
+		return e.URL
+	case *PackageEvent:
+		return e.URL
+	case *IssueEvent:
+		return e.URL
+	case *PullRequestEvent:
+		return e.URL
+	case *StarEvent:
+		return e.URL
+	case *PullRequestReviewEvent:
+		return e.URL
+	case *PullRequestReviewCommentEvent:
+		return e.URL
+	case *WorkflowRunEvent:
+		return e.URL
+	case *DeploymentEvent:
+		return e.URL
+	case *WikiEvent:
+		if len(e.Pages) > 0 {
+			return e.Pages[0].URL
+		}
+	case *RepositoryEvent:
+		return e.URL
+	case *TopicRepoEvent:
+		return e.URL
+	}
+	return ""
+}
+
+// DecodePayload decodes the JSON of an event payload stored in the event
+// history, given the event type.
+func DecodePayload(eventType string, data []byte) (interface{}, error) {
+	var payload interface{}
+	switch eventType {
+	case "push":
+		payload = &PushEvent{}
+	case "release":
+		payload = &ReleaseEvent{}
+	case "tag":
+		payload = &TagEvent{}
+	case "package":
+		payload = &PackageEvent{}
+	case "issues":
+		payload = &IssueEvent{}
+	case "pull_request":
+		payload = &PullRequestEvent{}
+	case "star":
+		payload = &StarEvent{}
+	case "pull_request_review":
+		payload = &PullRequestReviewEvent{}
+	case "pull_request_review_comment":
+		payload = &PullRequestReviewCommentEvent{}
+	case "workflow_run":
+		payload = &WorkflowRunEvent{}
+	case "deployment":
+		payload = &DeploymentEvent{}
+	case "wiki":
+		payload = &WikiEvent{}
+	default:
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+	if err := json.Unmarshal(data, payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", eventType, err)
+	}
+	return payload, nil
+}
+
+// openGraphURL is GitHub's service rendering the preview cards of
+// repositories, commits, issues, pull requests and releases.
+const openGraphURL = "https://opengraph.githubassets.com"
+
+// PreviewImageURL returns the preview card GitHub renders for an event, or for
+// the repository when the event has no card of its own. Cards are requested
+// with the current day as cache key, so they refresh daily.
+func PreviewImageURL(repoOwner, repoName string, payload interface{}) string {
+	path := url.PathEscape(repoOwner) + "/" + url.PathEscape(repoName)
+	switch e := payload.(type) {
+	case *PushEvent:
+		if e.After != "" && strings.Trim(e.After, "0") != "" {
+			path += "/commit/" + url.PathEscape(e.After)
+		}
+	case *ReleaseEvent:
+		path += "/releases/tag/" + url.PathEscape(e.TagName)
+	case *IssueEvent:
+		path += fmt.Sprintf("/issues/%d", e.Number)
+	case *PullRequestEvent:
+		path += fmt.Sprintf("/pull/%d", e.Number)
+	case *PullRequestReviewEvent:
+		path += fmt.Sprintf("/pull/%d", e.Number)
+	case *PullRequestReviewCommentEvent:
+		path += fmt.Sprintf("/pull/%d", e.Number)
+	}
+	return openGraphURL + "/" + time.Now().UTC().Format("20060102") + "/" + path
+}
+
+// maxURLLength caps the length of a link target embedded in a message.
+const maxURLLength = 2048
+
+// urlTargetReplacer percent-encodes characters that could terminate or
+// confuse a Markdown link target.
+var urlTargetReplacer = strings.NewReplacer(
+	"(", "%28",
+	")", "%29",
+	"[", "%5B",
+	"]", "%5D",
+	"`", "%60",
+	"*", "%2A",
+	"_", "%5F",
+	"\\", "%5C",
+	"<", "%3C",
+	">", "%3E",
+	"\"", "%22",
+	" ", "%20",
+)
+
+// SanitizeURL makes a URL safe to use as a Markdown link target.
+// Only absolute http and https URLs are accepted; credentials are stripped,
+// non-ASCII hosts are percent-encoded and Markdown-significant characters are
+// escaped. An empty string is returned when the URL cannot be used.
+func SanitizeURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || len(raw) > maxURLLength {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if (scheme != "http" && scheme != "https") || u.Host == "" {
+		return ""
+	}
+	u.Scheme = scheme
+	u.User = nil
+
+	safe := urlTargetReplacer.Replace(u.String())
+	if len(safe) > maxURLLength {
+		return ""
+	}
+	return safe
+}
+
+// MarkdownLink renders a MarkdownV2 link around text, which must already be
+// MarkdownV2, falling back to the text alone when the URL does not pass
+// SanitizeURL.
+func MarkdownLink(text, rawURL string) string {
+	target := SanitizeURL(rawURL)
+	if target == "" {
+		return text
+	}
+	return markdown.Link(text, target)
 }
@@ -0,0 +1,292 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+// maxPatternLength caps the length of user-supplied regular expressions.
+const maxPatternLength = 200
+
+// ParseBranchList parses a comma-separated list of branch names or glob
+// patterns such as "main,release/*".
+func ParseBranchList(list string) ([]string, error) {
+	var branches []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.ContainsAny(item, "`") {
+			return nil, fmt.Errorf("invalid branch pattern %q", item)
+		}
+		if _, err := path.Match(item, ""); err != nil {
+			return nil, fmt.Errorf("invalid branch pattern %q: %w", item, err)
+		}
+		branches = append(branches, item)
+	}
+	if len(branches) == 0 {
+		return nil, errors.New("no branches given")
+	}
+	return branches, nil
+}
+
+// MatchBranch reports whether a branch matches any of the patterns.
+// A "*" does not match across "/", so "release/*" matches "release/1.0".
+func MatchBranch(patterns []string, branch string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLabelList parses a comma-separated list of labels into required and
+// excluded labels. Labels prefixed with "-" are excluded, e.g. "bug,-dependencies".
+func ParseLabelList(list string) (required, excluded []string, err error) {
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if label, ok := strings.CutPrefix(item, "-"); ok {
+			if label = strings.TrimSpace(label); label != "" {
+				excluded = append(excluded, label)
+			}
+			continue
+		}
+		if item != "" {
+			required = append(required, item)
+		}
+	}
+	if len(required) == 0 && len(excluded) == 0 {
+		return nil, nil, errors.New("no labels given")
+	}
+	return required, excluded, nil
+}
+
+// MatchLabels reports whether a set of labels has at least one of the required
+// labels, if any are given, and none of the excluded ones. Labels are compared
+// case-insensitively.
+func MatchLabels(labels, required, excluded []string) bool {
+	has := func(want string) bool {
+		for _, l := range labels {
+			if strings.EqualFold(l, want) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, l := range excluded {
+		if has(l) {
+			return false
+		}
+	}
+	if len(required) == 0 {
+		return true
+	}
+	for _, l := range required {
+		if has(l) {
+			return true
+		}
+	}
+	return false
+}
+
+// BotAuthors are the accounts suggested for the ignore list, also used when
+// the list is given as "bots".
+var BotAuthors = []string{"dependabot[bot]", "renovate[bot]"}
+
+// ParseAuthorList parses a comma-separated list of GitHub logins. The word
+// "bots" expands to BotAuthors.
+func ParseAuthorList(list string) ([]string, error) {
+	var authors []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		switch {
+		case item == "":
+			continue
+		case strings.EqualFold(item, "bots"):
+			authors = append(authors, BotAuthors...)
+		case strings.ContainsAny(item, "` "):
+			return nil, fmt.Errorf("invalid login %q", item)
+		default:
+			authors = append(authors, item)
+		}
+	}
+	if len(authors) == 0 {
+		return nil, errors.New("no logins given")
+	}
+	return authors, nil
+}
+
+// IsIgnoredAuthor reports whether a login is on the ignore list, case-insensitively.
+func IsIgnoredAuthor(ignored []string, login string) bool {
+	for _, l := range ignored {
+		if strings.EqualFold(l, login) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredPush reports whether a push was made by an ignored account, either as
+// pusher or as author of every commit.
+func ignoredPush(ignored []string, e *PushEvent) bool {
+	if IsIgnoredAuthor(ignored, e.Pusher.Login) {
+		return true
+	}
+	if len(e.Commits) == 0 {
+		return false
+	}
+	for _, c := range e.Commits {
+		if !IsIgnoredAuthor(ignored, c.Author.Login) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsIgnoredEvent reports whether a push, issue or pull request event was
+// authored by an account on the ignore list.
+func IsIgnoredEvent(ignored []string, payload interface{}) bool {
+	if len(ignored) == 0 {
+		return false
+	}
+	switch e := payload.(type) {
+	case *PushEvent:
+		return ignoredPush(ignored, e)
+	case *IssueEvent:
+		return IsIgnoredAuthor(ignored, e.User.Login)
+	case *PullRequestEvent:
+		return IsIgnoredAuthor(ignored, e.User.Login)
+	default:
+		return false
+	}
+}
+
+// ValidateTextPattern checks that a title/message filter is a valid regular expression.
+func ValidateTextPattern(pattern string) error {
+	if len(pattern) > maxPatternLength {
+		return fmt.Errorf("pattern longer than %d characters", maxPatternLength)
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	return nil
+}
+
+// ValidateImport checks the patterns and templates of a subscription read
+// from an export as the commands setting them would.
+func ValidateImport(sub storage.ExportedSubscription) error {
+	if f := sub.Filters; f != nil {
+		for _, pattern := range []string{f.Include, f.Exclude, f.PrereleaseTags} {
+			if pattern == "" {
+				continue
+			}
+			if err := ValidateTextPattern(pattern); err != nil {
+				return err
+			}
+		}
+	}
+	for event, text := range sub.Templates {
+		if _, err := storage.ParseEventType(string(event)); err != nil {
+			return err
+		}
+		if err := ValidateTemplate(event, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventTexts returns the texts keyword filters apply to: issue and PR titles
+// and commit messages. It returns nil for other events.
+func eventTexts(payload interface{}) []string {
+	switch e := payload.(type) {
+	case *PushEvent:
+		texts := make([]string, len(e.Commits))
+		for i, c := range e.Commits {
+			texts[i] = c.Message
+		}
+		return texts
+	case *IssueEvent:
+		return []string{e.Title}
+	case *PullRequestEvent:
+		return []string{e.Title}
+	default:
+		return nil
+	}
+}
+
+// MatchText applies include and exclude regular expressions to an event. An
+// event passes if any of its texts matches include and not all of them match
+// exclude; a push thus passes as long as one commit is not excluded. Events
+// without texts and invalid patterns always pass.
+func MatchText(include, exclude string, payload interface{}) bool {
+	texts := eventTexts(payload)
+	if len(texts) == 0 {
+		return true
+	}
+
+	if include != "" {
+		if re, err := regexp.Compile(include); err == nil && !anyMatch(re, texts) {
+			return false
+		}
+	}
+	if exclude != "" {
+		if re, err := regexp.Compile(exclude); err == nil && allMatch(re, texts) {
+			return false
+		}
+	}
+	return true
+}
+
+func anyMatch(re *regexp.Regexp, texts []string) bool {
+	for _, t := range texts {
+		if re.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func allMatch(re *regexp.Regexp, texts []string) bool {
+	for _, t := range texts {
+		if !re.MatchString(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Branch returns the branch a push went to, or "" for tag pushes.
+func (e *PushEvent) Branch() string {
+	if !strings.HasPrefix(e.Ref, "refs/heads/") {
+		return ""
+	}
+	return strings.TrimPrefix(e.Ref, "refs/heads/")
+}
+
+// DefaultPrereleaseTags matches the tags of nightly builds, release
+// candidates and other pre-releases not marked as such on GitHub, like
+// "v2.0.0-rc.1" or "nightly-2024-05-01".
+const DefaultPrereleaseTags = `(?i)(^|[-._+\d])(nightly|alpha|beta|rc|pre|preview|canary|snapshot|dev)\d*([-._+]|$)`
+
+// IsPrerelease reports whether a release is a pre-release: marked as one on
+// GitHub, or tagged with a name matching the pattern, DefaultPrereleaseTags
+// if empty.
+func IsPrerelease(release *ReleaseEvent, pattern string) bool {
+	if release.Prerelease {
+		return true
+	}
+	if pattern == "" {
+		pattern = DefaultPrereleaseTags
+	}
+	re, err := regexp.Compile(pattern)
+	return err == nil && re.MatchString(release.TagName)
+}
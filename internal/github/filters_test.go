@@ -0,0 +1,53 @@
+package github
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+func TestImportValidation(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	defer db.Close()
+	store := storage.NewSubscriptionStore(db)
+	if err := store.CreateOrUpdateChat(1, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+
+	events := []storage.EventType{storage.EventTypePush, storage.EventTypeRelease}
+	sub := func(repo string, filters *storage.SubscriptionFilters, templates map[storage.EventType]string) storage.ExportedSubscription {
+		return storage.ExportedSubscription{Repo: "acme/" + repo, Events: events, Filters: filters, Templates: templates}
+	}
+	export := &storage.ChatExport{
+		Version: storage.ExportVersion,
+		Subscriptions: []storage.ExportedSubscription{
+			sub("valid", &storage.SubscriptionFilters{Include: "^feat", PrereleaseTags: "-rc"},
+				map[storage.EventType]string{storage.EventTypeRelease: "{{.Tag}} is out"}),
+			sub("include", &storage.SubscriptionFilters{Include: "(unclosed"}, nil),
+			sub("exclude", &storage.SubscriptionFilters{Exclude: strings.Repeat("a", maxPatternLength+1)}, nil),
+			sub("prerelease", &storage.SubscriptionFilters{SkipPrereleases: true, PrereleaseTags: "[z-a]"}, nil),
+			sub("event", nil, map[storage.EventType]string{"bogus": "{{.Repo}}"}),
+			sub("field", nil, map[storage.EventType]string{storage.EventTypePush: "{{.Tag}}"}),
+			sub("nested", nil, map[storage.EventType]string{
+				storage.EventTypePush: "{{range .Messages}}{{range $.Messages}}{{range $.Messages}}{{end}}{{end}}{{end}}",
+			}),
+		},
+	}
+
+	result, err := store.ImportChat(1, export, ValidateImport)
+	if err != nil {
+		t.Fatalf("ImportChat() error = %v", err)
+	}
+	if result.Subscriptions != 1 || result.Skipped != 6 {
+		t.Errorf("ImportChat() = %+v, want 1 subscription imported and 6 skipped", result)
+	}
+	subs, err := store.GetSubscriptionsByChat(1)
+	if err != nil || len(subs) != 1 || subs[0].RepoName != "valid" {
+		t.Errorf("GetSubscriptionsByChat() = %+v, %v, want the valid subscription only", subs, err)
+	}
+}
@@ -0,0 +1,331 @@
+package github
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// GitHub-flavored markdown found in release notes is converted to MarkdownV2
+// line by line. Inline formatting is matched within a single line so that a
+// truncated body can never leave an entity open; anything that does not pair
+// up is shown literally.
+
+var (
+	gfmComment   = regexp.MustCompile(`(?s)<!--.*?-->`)
+	gfmHTMLTag   = regexp.MustCompile(`</?[a-zA-Z][a-zA-Z0-9-]*(\s[^<>]*)?/?>`)
+	gfmFence     = regexp.MustCompile("^\\s{0,3}(```+|~~~+)\\s*([\\w+#.-]*)")
+	gfmHeading   = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(\s+#+)?\s*$`)
+	gfmBullet    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	gfmOrdered   = regexp.MustCompile(`^(\s*)(\d{1,9})[.)]\s+(.*)$`)
+	gfmQuote     = regexp.MustCompile(`^\s{0,3}>\s?(.*)$`)
+	gfmRule      = regexp.MustCompile(`^\s{0,3}((-\s*){3,}|(\*\s*){3,}|(_\s*){3,})$`)
+	gfmSetext    = regexp.MustCompile(`^\s{0,3}=+\s*$`)
+	gfmTableRule = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// FormatGFM converts GitHub-flavored markdown to MarkdownV2, keeping at most
+// about maxLen characters of the source. Longer text is cut at a line break
+// and marked with an ellipsis.
+func FormatGFM(source string, maxLen int) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = gfmComment.ReplaceAllString(source, "")
+
+	var out []string
+	var code []string
+	fence, language := "", ""
+	used, blank := 0, false
+	truncated := false
+
+	for _, line := range strings.Split(source, "\n") {
+		if used+len(line) > maxLen && used > 0 {
+			truncated = true
+			break
+		}
+		used += len(line) + 1
+
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				out = appendCode(out, code, language)
+				fence, code = "", nil
+				continue
+			}
+			code = append(code, line)
+			continue
+		}
+
+		if m := gfmFence.FindStringSubmatch(line); m != nil {
+			fence, language = m[1], m[2]
+			continue
+		}
+
+		converted, ok := convertGFMLine(line)
+		if !ok {
+			continue
+		}
+		if converted == "" {
+			if !blank && len(out) > 0 {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, converted)
+		blank = false
+	}
+
+	if fence != "" {
+		out = appendCode(out, code, language)
+	}
+	for len(out) > 0 && out[len(out)-1] == "" {
+		out = out[:len(out)-1]
+	}
+	if truncated {
+		out = append(out, markdown.Escape("…"))
+	}
+	return strings.Join(out, "\n")
+}
+
+// appendCode adds a fenced code block to the output unless it is empty.
+func appendCode(out, code []string, language string) []string {
+	if len(code) == 0 {
+		return out
+	}
+	return append(out, markdown.Pre(strings.Join(code, "\n"), language))
+}
+
+// convertGFMLine converts a line outside of code blocks. It reports false for
+// lines that are dropped, such as table separators.
+func convertGFMLine(line string) (string, bool) {
+	line = gfmHTMLTag.ReplaceAllString(line, "")
+	if strings.TrimSpace(line) == "" {
+		return "", true
+	}
+
+	switch {
+	case gfmSetext.MatchString(line), gfmTableRule.MatchString(line) && strings.Contains(line, "|"):
+		return "", false
+	case gfmRule.MatchString(line):
+		return markdown.Escape("———"), true
+	}
+
+	if m := gfmHeading.FindStringSubmatch(line); m != nil {
+		return "*" + convertInline(m[1], entitySet{bold: true}) + "*", true
+	}
+	if m := gfmBullet.FindStringSubmatch(line); m != nil {
+		item := m[2]
+		bullet := "•"
+		switch {
+		case strings.HasPrefix(item, "[ ] "):
+			bullet, item = "☐", item[4:]
+		case strings.HasPrefix(item, "[x] "), strings.HasPrefix(item, "[X] "):
+			bullet, item = "☑", item[4:]
+		}
+		return listIndent(m[1]) + bullet + " " + convertInline(item, entitySet{}), true
+	}
+	if m := gfmOrdered.FindStringSubmatch(line); m != nil {
+		return listIndent(m[1]) + markdown.Escape(m[2]+".") + " " + convertInline(m[3], entitySet{}), true
+	}
+	if m := gfmQuote.FindStringSubmatch(line); m != nil {
+		return "┃ " + convertInline(m[1], entitySet{}), true
+	}
+	return convertInline(strings.TrimSpace(line), entitySet{}), true
+}
+
+// listIndent converts the indentation of a nested list item.
+func listIndent(indent string) string {
+	indent = strings.ReplaceAll(indent, "\t", "    ")
+	return strings.Repeat("  ", len(indent)/2)
+}
+
+// entitySet records the entities enclosing inline text, which must not be
+// opened again inside themselves.
+type entitySet struct {
+	bold, italic, strike, link bool
+}
+
+// convertInline converts the inline formatting of a single line.
+func convertInline(s string, open entitySet) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isASCIIPunct(s[i+1]):
+			b.WriteString(markdown.Escape(s[i+1 : i+2]))
+			i += 2
+			continue
+
+		case c == '`':
+			run := delimiterRun(s[i:], '`')
+			if end := strings.Index(s[i+run:], s[i:i+run]); end >= 0 {
+				b.WriteString(markdown.Code(strings.TrimSpace(s[i+run : i+run+end])))
+				i += run + end + run
+				continue
+			}
+
+		case (c == '!' && strings.HasPrefix(s[i:], "![")) || c == '[':
+			start := i
+			if c == '!' {
+				start++
+			}
+			if text, url, n, ok := parseGFMLink(s[start:]); ok && !open.link {
+				if c == '!' && text == "" {
+					text = "image"
+				}
+				inner := open
+				inner.link = true
+				label := convertInline(text, inner)
+				if target := SanitizeURL(url); target != "" && label != "" {
+					b.WriteString(markdown.Link(label, target))
+				} else {
+					b.WriteString(label)
+				}
+				i = start + n
+				continue
+			}
+
+		case c == '<':
+			if end := strings.IndexByte(s[i:], '>'); end > 0 && !open.link {
+				url := s[i+1 : i+end]
+				if target := SanitizeURL(url); target != "" && !strings.ContainsAny(url, " ") {
+					b.WriteString(markdown.Link(markdown.Escape(url), target))
+					i += end + 1
+					continue
+				}
+			}
+
+		case c == '~' && strings.HasPrefix(s[i:], "~~") && !open.strike:
+			if inner, n, ok := emphasis(s[i:], "~~"); ok {
+				nested := open
+				nested.strike = true
+				b.WriteString("~" + convertInline(inner, nested) + "~")
+				i += n
+				continue
+			}
+
+		case (c == '*' || c == '_') && delimiterRun(s[i:], c) >= 2 && !open.bold && canOpen(s, i, c):
+			if inner, n, ok := emphasis(s[i:], strings.Repeat(string(c), 2)); ok {
+				nested := open
+				nested.bold = true
+				b.WriteString("*" + convertInline(inner, nested) + "*")
+				i += n
+				continue
+			}
+
+		case (c == '*' || c == '_') && delimiterRun(s[i:], c) == 1 && !open.italic && canOpen(s, i, c):
+			if inner, n, ok := emphasis(s[i:], string(c)); ok {
+				nested := open
+				nested.italic = true
+				b.WriteString("_" + convertInline(inner, nested) + "_")
+				i += n
+				continue
+			}
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(markdown.Escape(s[i : i+size]))
+		i += size
+	}
+	return b.String()
+}
+
+// emphasis finds the text enclosed by delim at the start of s and returns it
+// with the length of the whole span.
+func emphasis(s, delim string) (string, int, bool) {
+	rest := s[len(delim):]
+	if rest == "" || unicode.IsSpace(rune(rest[0])) {
+		return "", 0, false
+	}
+	for from := 0; from < len(rest); {
+		end := strings.Index(rest[from:], delim)
+		if end < 0 {
+			return "", 0, false
+		}
+		end += from
+		closeOK := end > 0 && !unicode.IsSpace(rune(rest[end-1]))
+		after := end + len(delim)
+		if delim[0] == '_' && after < len(rest) && isWordByte(rest[after]) {
+			closeOK = false
+		}
+		if closeOK && (delim[0] == '~' || delimiterRun(rest[end:], delim[0]) == len(delim)) {
+			return rest[:end], len(delim) + after, true
+		}
+		from = end + delimiterRun(rest[end:], delim[0])
+	}
+	return "", 0, false
+}
+
+// canOpen reports whether an emphasis marker at s[i] may open emphasis.
+// Underscores inside words, as in snake_case, never do.
+func canOpen(s string, i int, c byte) bool {
+	return c != '_' || i == 0 || !isWordByte(s[i-1])
+}
+
+// parseGFMLink parses a [text](url) link at the start of s.
+func parseGFMLink(s string) (text, url string, n int, ok bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if !strings.HasPrefix(s[i+1:], "(") {
+				return "", "", 0, false
+			}
+			end := closingParen(s[i+2:])
+			if end < 0 {
+				return "", "", 0, false
+			}
+			target := strings.TrimSpace(s[i+2 : i+2+end])
+			if space := strings.IndexByte(target, ' '); space >= 0 {
+				target = target[:space] // Drop a link title
+			}
+			return s[1:i], strings.Trim(target, "<>"), i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// closingParen returns the index of the parenthesis closing a link target,
+// allowing balanced parentheses inside it, or -1.
+func closingParen(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// delimiterRun returns how many times c repeats at the start of s.
+func delimiterRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
@@ -0,0 +1,408 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// With many subscribed repositories, polling each one takes several REST
+// requests. The GraphQL API returns the recent commits, releases, issues and
+// pull requests of a whole batch of repositories in a single query instead.
+// Its results are converted to the REST types, so the events are built the
+// same way either way.
+
+// graphQLBatchSize is how many repositories one query covers.
+const graphQLBatchSize = 20
+
+// repoSnapshot is the recent activity of a repository fetched with GraphQL.
+type repoSnapshot struct {
+	polledAt time.Time
+	since    map[string]time.Time // Watermark the query started from, by kind
+	branch   string               // Default branch, empty for an empty repository
+	commits  []*gh.RepositoryCommit
+	releases []*gh.RepositoryRelease
+	issues   []*gh.Issue
+	prs      []*gh.PullRequest
+}
+
+// SetGraphQL selects whether the commits, releases, issues and pull requests
+// of due repositories are fetched in batches with GraphQL. The GraphQL API
+// requires a token.
+func (p *Poller) SetGraphQL(enabled bool) {
+	if enabled && !p.client.Authenticated() {
+		logger.Warn().Msg("GraphQL polling requires a GitHub token, polling with REST")
+		enabled = false
+	}
+	p.useGraphQL = enabled
+}
+
+// Authenticated reports whether requests are sent with a token.
+func (c *Client) Authenticated() bool {
+	return c.tokens != nil && c.tokens.tokens[0].token != ""
+}
+
+// graphQL sends a query and decodes the data of its response. Errors for
+// single fields, like a repository that no longer exists, leave those fields
+// null and are only logged.
+func (c *Client) graphQL(ctx context.Context, query string, data interface{}) error {
+	req, err := c.client.NewRequest("POST", "graphql", map[string]string{"query": query})
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if _, err := c.client.Do(ctx, req, &resp); err != nil {
+		return err
+	}
+	if len(resp.Data) == 0 || string(resp.Data) == "null" {
+		if len(resp.Errors) > 0 {
+			return errors.New(resp.Errors[0].Message)
+		}
+		return errors.New("empty GraphQL response")
+	}
+	for _, e := range resp.Errors {
+		logger.Debug().Str("error", e.Message).Msg("GraphQL query partially failed")
+	}
+	return json.Unmarshal(resp.Data, data)
+}
+
+// fetchSnapshots fetches the recent activity of repositories in batches.
+// Repositories missing from the result, because a query failed or they are
+// not accessible, are polled with REST.
+func (p *Poller) fetchSnapshots(repos [][2]string) map[string]*repoSnapshot {
+	snapshots := make(map[string]*repoSnapshot)
+	for start := 0; start < len(repos); start += graphQLBatchSize {
+		batch := repos[start:min(start+graphQLBatchSize, len(repos))]
+		if err := p.fetchSnapshotBatch(batch, snapshots); err != nil {
+			p.fetchFailed(err, fmt.Sprintf("%d repositories", len(batch)), "Failed to fetch repositories with GraphQL")
+		}
+		if p.ctx.Err() != nil {
+			break
+		}
+	}
+	return snapshots
+}
+
+// fetchSnapshotBatch fetches the recent activity of one batch of repositories.
+func (p *Poller) fetchSnapshotBatch(repos [][2]string, snapshots map[string]*repoSnapshot) error {
+	ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
+	defer cancel()
+
+	polledAt := time.Now()
+	since := make([]map[string]time.Time, len(repos))
+	var query strings.Builder
+	query.WriteString("query {\n")
+	for i, repo := range repos {
+		since[i] = map[string]time.Time{
+			"push":         p.since(repo[0], repo[1], "push"),
+			"release":      p.since(repo[0], repo[1], "release"),
+			"issues":       p.since(repo[0], repo[1], "issues"),
+			"pull_request": p.since(repo[0], repo[1], "pull_request"),
+		}
+		fmt.Fprintf(&query, graphQLRepoQuery, i, graphQLString(repo[0]), graphQLString(repo[1]),
+			graphQLString(since[i]["push"].UTC().Format(time.RFC3339)),
+			graphQLString(since[i]["issues"].UTC().Format(time.RFC3339)))
+	}
+	query.WriteString("}")
+
+	var data map[string]*graphQLRepo
+	if err := p.client.graphQL(ctx, query.String(), &data); err != nil {
+		return err
+	}
+
+	for i, repo := range repos {
+		r := data[fmt.Sprintf("r%d", i)]
+		if r == nil {
+			continue
+		}
+		snap := r.snapshot()
+		snap.polledAt = polledAt
+		snap.since = since[i]
+		snapshots[repo[0]+"/"+repo[1]] = snap
+	}
+	return nil
+}
+
+// applySnapshot sends the events found in a repository's snapshot and
+// advances its watermarks. Tags are not part of the query and are checked
+// with REST.
+func (p *Poller) applySnapshot(ctx context.Context, owner, name string, snap *repoSnapshot) int {
+	ref := ""
+	if snap.branch != "" {
+		ref = "refs/heads/" + snap.branch
+	}
+
+	total := 0
+	for _, kind := range []string{"push", "release", "issues", "pull_request"} {
+		var count int
+		var complete bool
+		switch kind {
+		case "push":
+			count, complete = p.notifyCommits(ctx, owner, name, ref, snap.commits)
+		case "release":
+			count, complete = p.notifyReleases(owner, name, snap.since[kind], snap.releases)
+		case "issues":
+			count, complete = p.notifyIssues(owner, name, snap.since[kind], snap.issues)
+		case "pull_request":
+			count, complete = p.notifyPullRequests(owner, name, snap.since[kind], snap.prs)
+		}
+		if complete {
+			p.advance(owner, name, kind, snap.polledAt, "")
+		}
+		total += count
+	}
+	return total + p.pollTags(ctx, owner, name)
+}
+
+// graphQLString quotes a string for a GraphQL query.
+func graphQLString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
+
+// graphQLRepoQuery selects the recent activity of one repository, given its
+// index, owner, name, and the times to list commits and issues from.
+const graphQLRepoQuery = `r%d: repository(owner: %s, name: %s) {
+  defaultBranchRef {
+    name
+    target {
+      ... on Commit {
+        history(first: 10, since: %s) {
+          nodes { oid message url committedDate author { name user { login } } }
+        }
+      }
+    }
+  }
+  releases(first: 5, orderBy: {field: CREATED_AT, direction: DESC}) {
+    nodes {
+      tagName name description isDraft isPrerelease url publishedAt
+      author { login }
+      releaseAssets(first: 50) { nodes { name size downloadUrl } }
+    }
+  }
+  issues(first: 10, orderBy: {field: UPDATED_AT, direction: DESC}, filterBy: {since: %s}) {
+    nodes {
+      number title body state url createdAt closedAt
+      author { login }
+      labels(first: 10) { nodes { name } }
+    }
+  }
+  pullRequests(first: 10, orderBy: {field: UPDATED_AT, direction: DESC}) {
+    nodes {
+      number title body state url createdAt closedAt merged additions deletions
+      baseRefName headRefName
+      author { login }
+      mergedBy { login }
+      commits { totalCount }
+      labels(first: 10) { nodes { name } }
+    }
+  }
+}
+`
+
+// graphQLLogin is an actor as returned by GraphQL, null for deleted users.
+type graphQLLogin struct {
+	Login string `json:"login"`
+}
+
+type graphQLLabels struct {
+	Nodes []struct {
+		Name string `json:"name"`
+	} `json:"nodes"`
+}
+
+// graphQLRepo is the result of graphQLRepoQuery.
+type graphQLRepo struct {
+	DefaultBranchRef *struct {
+		Name   string `json:"name"`
+		Target struct {
+			History struct {
+				Nodes []struct {
+					OID           string    `json:"oid"`
+					Message       string    `json:"message"`
+					URL           string    `json:"url"`
+					CommittedDate time.Time `json:"committedDate"`
+					Author        struct {
+						Name string        `json:"name"`
+						User *graphQLLogin `json:"user"`
+					} `json:"author"`
+				} `json:"nodes"`
+			} `json:"history"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+	Releases struct {
+		Nodes []struct {
+			TagName       string        `json:"tagName"`
+			Name          string        `json:"name"`
+			Description   string        `json:"description"`
+			IsDraft       bool          `json:"isDraft"`
+			IsPrerelease  bool          `json:"isPrerelease"`
+			URL           string        `json:"url"`
+			PublishedAt   *time.Time    `json:"publishedAt"`
+			Author        *graphQLLogin `json:"author"`
+			ReleaseAssets struct {
+				Nodes []struct {
+					Name        string `json:"name"`
+					Size        int    `json:"size"`
+					DownloadURL string `json:"downloadUrl"`
+				} `json:"nodes"`
+			} `json:"releaseAssets"`
+		} `json:"nodes"`
+	} `json:"releases"`
+	Issues struct {
+		Nodes []struct {
+			Number    int           `json:"number"`
+			Title     string        `json:"title"`
+			Body      string        `json:"body"`
+			State     string        `json:"state"`
+			URL       string        `json:"url"`
+			CreatedAt time.Time     `json:"createdAt"`
+			ClosedAt  *time.Time    `json:"closedAt"`
+			Author    *graphQLLogin `json:"author"`
+			Labels    graphQLLabels `json:"labels"`
+		} `json:"nodes"`
+	} `json:"issues"`
+	PullRequests struct {
+		Nodes []struct {
+			Number      int           `json:"number"`
+			Title       string        `json:"title"`
+			Body        string        `json:"body"`
+			State       string        `json:"state"`
+			URL         string        `json:"url"`
+			CreatedAt   time.Time     `json:"createdAt"`
+			ClosedAt    *time.Time    `json:"closedAt"`
+			Merged      bool          `json:"merged"`
+			Additions   int           `json:"additions"`
+			Deletions   int           `json:"deletions"`
+			BaseRefName string        `json:"baseRefName"`
+			HeadRefName string        `json:"headRefName"`
+			Author      *graphQLLogin `json:"author"`
+			MergedBy    *graphQLLogin `json:"mergedBy"`
+			Commits     struct {
+				TotalCount int `json:"totalCount"`
+			} `json:"commits"`
+			Labels graphQLLabels `json:"labels"`
+		} `json:"nodes"`
+	} `json:"pullRequests"`
+}
+
+// snapshot converts the result to the REST types.
+func (r *graphQLRepo) snapshot() *repoSnapshot {
+	snap := &repoSnapshot{}
+
+	if r.DefaultBranchRef != nil {
+		snap.branch = r.DefaultBranchRef.Name
+		for _, c := range r.DefaultBranchRef.Target.History.Nodes {
+			commit := &gh.RepositoryCommit{
+				SHA:     gh.String(c.OID),
+				HTMLURL: gh.String(c.URL),
+				Commit: &gh.Commit{
+					Message:   gh.String(c.Message),
+					Author:    &gh.CommitAuthor{Name: gh.String(c.Author.Name)},
+					Committer: &gh.CommitAuthor{Date: &gh.Timestamp{Time: c.CommittedDate}},
+				},
+			}
+			if c.Author.User != nil {
+				commit.Author = &gh.User{Login: gh.String(c.Author.User.Login)}
+			}
+			snap.commits = append(snap.commits, commit)
+		}
+	}
+
+	for _, rel := range r.Releases.Nodes {
+		release := &gh.RepositoryRelease{
+			TagName:    gh.String(rel.TagName),
+			Name:       gh.String(rel.Name),
+			Body:       gh.String(rel.Description),
+			Draft:      gh.Bool(rel.IsDraft),
+			Prerelease: gh.Bool(rel.IsPrerelease),
+			HTMLURL:    gh.String(rel.URL),
+			Author:     graphQLUser(rel.Author),
+		}
+		if rel.PublishedAt != nil {
+			release.PublishedAt = &gh.Timestamp{Time: *rel.PublishedAt}
+		}
+		for _, a := range rel.ReleaseAssets.Nodes {
+			release.Assets = append(release.Assets, &gh.ReleaseAsset{
+				Name:               gh.String(a.Name),
+				Size:               gh.Int(a.Size),
+				BrowserDownloadURL: gh.String(a.DownloadURL),
+			})
+		}
+		snap.releases = append(snap.releases, release)
+	}
+
+	for _, is := range r.Issues.Nodes {
+		snap.issues = append(snap.issues, &gh.Issue{
+			Number:    gh.Int(is.Number),
+			Title:     gh.String(is.Title),
+			Body:      gh.String(is.Body),
+			State:     gh.String(strings.ToLower(is.State)),
+			HTMLURL:   gh.String(is.URL),
+			CreatedAt: &gh.Timestamp{Time: is.CreatedAt},
+			ClosedAt:  graphQLTimestamp(is.ClosedAt),
+			User:      graphQLUser(is.Author),
+			Labels:    is.Labels.labels(),
+		})
+	}
+
+	for _, pr := range r.PullRequests.Nodes {
+		state := strings.ToLower(pr.State)
+		if pr.Merged {
+			state = "closed" // REST reports merged pull requests as closed
+		}
+		snap.prs = append(snap.prs, &gh.PullRequest{
+			Number:    gh.Int(pr.Number),
+			Title:     gh.String(pr.Title),
+			Body:      gh.String(pr.Body),
+			State:     gh.String(state),
+			HTMLURL:   gh.String(pr.URL),
+			CreatedAt: &gh.Timestamp{Time: pr.CreatedAt},
+			ClosedAt:  graphQLTimestamp(pr.ClosedAt),
+			Merged:    gh.Bool(pr.Merged),
+			MergedBy:  graphQLUser(pr.MergedBy),
+			User:      graphQLUser(pr.Author),
+			Additions: gh.Int(pr.Additions),
+			Deletions: gh.Int(pr.Deletions),
+			Commits:   gh.Int(pr.Commits.TotalCount),
+			Base:      &gh.PullRequestBranch{Ref: gh.String(pr.BaseRefName)},
+			Head:      &gh.PullRequestBranch{Ref: gh.String(pr.HeadRefName)},
+			Labels:    pr.Labels.labels(),
+		})
+	}
+	return snap
+}
+
+func (l graphQLLabels) labels() []*gh.Label {
+	labels := make([]*gh.Label, len(l.Nodes))
+	for i, n := range l.Nodes {
+		labels[i] = &gh.Label{Name: gh.String(n.Name)}
+	}
+	return labels
+}
+
+func graphQLUser(l *graphQLLogin) *gh.User {
+	if l == nil {
+		return nil
+	}
+	return &gh.User{Login: gh.String(l.Login)}
+}
+
+func graphQLTimestamp(t *time.Time) *gh.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return &gh.Timestamp{Time: *t}
+}
@@ -0,0 +1,155 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/internal/storage"
+)
+
+// ErrNoEvent is returned by LatestEvent when a repository has no event of
+// the requested type yet.
+var ErrNoEvent = errors.New("no event found")
+
+// LatestEvent returns the most recent event of a type, as the poller would
+// have detected it: the latest commit on the default branch for push, the
+// latest release for release and the latest issue for issues. It lets chats
+// preview notifications with real data.
+func (c *Client) LatestEvent(ctx context.Context, owner, repo string, eventType storage.EventType) (*WebhookEvent, error) {
+	event := &WebhookEvent{
+		Type:       string(eventType),
+		RepoOwner:  owner,
+		RepoName:   repo,
+		Source:     "poller",
+		DetectedAt: time.Now(),
+	}
+
+	var resp *gh.Response
+	var err error
+	switch eventType {
+	case storage.EventTypePush:
+		resp, err = c.latestCommit(ctx, event)
+	case storage.EventTypeRelease:
+		resp, err = c.latestRelease(ctx, event)
+	case storage.EventTypeIssue:
+		resp, err = c.latestIssue(ctx, event)
+	default:
+		return nil, fmt.Errorf("unsupported event type %q", eventType)
+	}
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, ErrRepoNotFound
+		}
+		return nil, err
+	}
+	if event.Payload == nil {
+		return nil, ErrNoEvent
+	}
+	return event, nil
+}
+
+// latestCommit sets the latest commit on the default branch as the payload
+// of an event.
+func (c *Client) latestCommit(ctx context.Context, event *WebhookEvent) (*gh.Response, error) {
+	r, resp, err := c.client.Repositories.Get(ctx, event.RepoOwner, event.RepoName)
+	if err != nil {
+		return resp, fmt.Errorf("failed to get repository: %w", err)
+	}
+	commits, resp, err := c.client.Repositories.ListCommits(ctx, event.RepoOwner, event.RepoName, &gh.CommitsListOptions{
+		SHA:         r.GetDefaultBranch(),
+		ListOptions: gh.ListOptions{PerPage: 1},
+	})
+	if err != nil {
+		// An empty repository has no commits to list
+		if resp != nil && resp.StatusCode == http.StatusConflict {
+			return nil, nil
+		}
+		return resp, fmt.Errorf("failed to list commits: %w", err)
+	}
+	if len(commits) == 0 {
+		return nil, nil
+	}
+
+	commit := commits[0]
+	event.OccurredAt = commit.GetCommit().GetCommitter().GetDate().Time
+	event.Payload = &PushEvent{
+		Ref:    "refs/heads/" + r.GetDefaultBranch(),
+		After:  commit.GetSHA(),
+		Pusher: UserInfo{Login: commit.GetAuthor().GetLogin()},
+		Commits: []CommitInfo{{
+			SHA:     commit.GetSHA(),
+			Message: commit.GetCommit().GetMessage(),
+			URL:     commit.GetHTMLURL(),
+			Author:  UserInfo{Login: commit.GetCommit().GetAuthor().GetName()},
+		}},
+		Compare: commit.GetHTMLURL(),
+	}
+	return nil, nil
+}
+
+// latestRelease sets the latest published release, pre-releases included,
+// as the payload of an event.
+func (c *Client) latestRelease(ctx context.Context, event *WebhookEvent) (*gh.Response, error) {
+	releases, resp, err := c.client.Repositories.ListReleases(ctx, event.RepoOwner, event.RepoName, &gh.ListOptions{PerPage: 5})
+	if err != nil {
+		return resp, fmt.Errorf("failed to list releases: %w", err)
+	}
+	for _, release := range releases {
+		if release.GetDraft() {
+			continue
+		}
+		event.OccurredAt = release.GetPublishedAt().Time
+		event.Payload = &ReleaseEvent{
+			Action:      "published",
+			TagName:     release.GetTagName(),
+			Name:        release.GetName(),
+			Body:        release.GetBody(),
+			Prerelease:  release.GetPrerelease(),
+			URL:         release.GetHTMLURL(),
+			Author:      UserInfo{Login: release.GetAuthor().GetLogin()},
+			PublishedAt: release.GetPublishedAt().Time,
+			Assets:      convertAssets(release.Assets),
+		}
+		break
+	}
+	return nil, nil
+}
+
+// latestIssue sets the latest opened issue as the payload of an event.
+func (c *Client) latestIssue(ctx context.Context, event *WebhookEvent) (*gh.Response, error) {
+	issues, resp, err := c.client.Issues.ListByRepo(ctx, event.RepoOwner, event.RepoName, &gh.IssueListByRepoOptions{
+		State:       "all",
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: gh.ListOptions{PerPage: 10},
+	})
+	if err != nil {
+		return resp, fmt.Errorf("failed to list issues: %w", err)
+	}
+	for _, issue := range issues {
+		if issue.IsPullRequest() {
+			continue
+		}
+		labels := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			labels[i] = l.GetName()
+		}
+		event.OccurredAt = issue.GetCreatedAt().Time
+		event.Payload = &IssueEvent{
+			Action: "opened",
+			Number: issue.GetNumber(),
+			Title:  issue.GetTitle(),
+			Body:   issue.GetBody(),
+			State:  issue.GetState(),
+			URL:    issue.GetHTMLURL(),
+			User:   UserInfo{Login: issue.GetUser().GetLogin()},
+			Labels: labels,
+		}
+		break
+	}
+	return nil, nil
+}
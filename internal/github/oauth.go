@@ -0,0 +1,180 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Users link their GitHub account with the OAuth device flow: the bot asks
+// GitHub for a code, the user enters it on github.com, and meanwhile the bot
+// polls until GitHub hands out the token. It needs the client ID of an OAuth
+// app with the device flow enabled, but no client secret and no callback URL.
+
+// oauthScopes are the scopes requested, reading private repositories needs
+// "repo".
+const oauthScopes = "repo"
+
+// deviceGrantType is the grant type of the token requests.
+const deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// Device flows end with one of these errors when no token was handed out.
+var (
+	ErrLoginDenied  = errors.New("authorization denied")
+	ErrLoginExpired = errors.New("device code expired")
+)
+
+// ErrInvalidToken is returned when GitHub does not accept a token.
+var ErrInvalidToken = errors.New("invalid token")
+
+// DeviceFlow links GitHub accounts with the OAuth device flow.
+type DeviceFlow struct {
+	clientID string
+	baseURL  string // https://github.com
+	http     *http.Client
+}
+
+// NewDeviceFlow creates a device flow for the OAuth app with the given
+// client ID.
+func NewDeviceFlow(clientID string) *DeviceFlow {
+	return &DeviceFlow{
+		clientID: clientID,
+		baseURL:  "https://github.com",
+		http:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// DeviceCode is a pending login: the user enters UserCode at
+// VerificationURI before ExpiresAt.
+type DeviceCode struct {
+	DeviceCode      string
+	UserCode        string
+	VerificationURI string
+	ExpiresAt       time.Time
+	Interval        time.Duration // How often GitHub may be asked for the token
+}
+
+// DeviceToken is the token handed out for a device code.
+type DeviceToken struct {
+	AccessToken string
+	Scopes      string // Granted scopes, comma separated
+}
+
+// Start requests a new device code.
+func (f *DeviceFlow) Start(ctx context.Context) (*DeviceCode, error) {
+	var reply struct {
+		DeviceCode      string `json:"device_code"`
+		UserCode        string `json:"user_code"`
+		VerificationURI string `json:"verification_uri"`
+		ExpiresIn       int    `json:"expires_in"`
+		Interval        int    `json:"interval"`
+		Error           string `json:"error"`
+		Description     string `json:"error_description"`
+	}
+	err := f.post(ctx, "/login/device/code", url.Values{
+		"client_id": {f.clientID},
+		"scope":     {oauthScopes},
+	}, &reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Error != "" {
+		return nil, fmt.Errorf("failed to request device code: %s: %s", reply.Error, reply.Description)
+	}
+
+	return &DeviceCode{
+		DeviceCode:      reply.DeviceCode,
+		UserCode:        reply.UserCode,
+		VerificationURI: reply.VerificationURI,
+		ExpiresAt:       time.Now().Add(time.Duration(reply.ExpiresIn) * time.Second),
+		Interval:        time.Duration(max(reply.Interval, 5)) * time.Second,
+	}, nil
+}
+
+// Wait polls for the token of a device code until the user authorized the
+// app, returning ErrLoginDenied if they refused and ErrLoginExpired if the
+// code expired first.
+func (f *DeviceFlow) Wait(ctx context.Context, code *DeviceCode) (*DeviceToken, error) {
+	interval := code.Interval
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		if time.Now().After(code.ExpiresAt) {
+			return nil, ErrLoginExpired
+		}
+
+		var reply struct {
+			AccessToken string `json:"access_token"`
+			Scope       string `json:"scope"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+			Interval    int    `json:"interval"`
+		}
+		err := f.post(ctx, "/login/oauth/access_token", url.Values{
+			"client_id":   {f.clientID},
+			"device_code": {code.DeviceCode},
+			"grant_type":  {deviceGrantType},
+		}, &reply)
+		if err != nil {
+			return nil, err
+		}
+
+		switch reply.Error {
+		case "":
+			return &DeviceToken{AccessToken: reply.AccessToken, Scopes: reply.Scope}, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval = time.Duration(reply.Interval) * time.Second
+			if interval <= code.Interval {
+				interval = code.Interval + 5*time.Second
+			}
+		case "expired_token":
+			return nil, ErrLoginExpired
+		case "access_denied":
+			return nil, ErrLoginDenied
+		default:
+			return nil, fmt.Errorf("failed to get access token: %s: %s", reply.Error, reply.Description)
+		}
+	}
+}
+
+// post sends a form to GitHub's OAuth endpoints and decodes the JSON reply.
+// Errors of the flow come back with status 200 in the reply's error field.
+func (f *DeviceFlow) post(ctx context.Context, path string, form url.Values, reply interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.baseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+// GetAuthenticatedLogin returns the login of the account a token belongs to.
+func (c *Client) GetAuthenticatedLogin(ctx context.Context, token string) (string, error) {
+	user, resp, err := c.client.Users.Get(WithToken(ctx, token), "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return "", ErrInvalidToken
+		}
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.GetLogin(), nil
+}
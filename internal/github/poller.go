@@ -3,21 +3,60 @@ package github
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/internal/metrics"
 	"github.com/user/githubbot/internal/storage"
 	"github.com/user/githubbot/pkg/logger"
 )
 
+// MinPollInterval is the shortest poll interval in seconds, to respect rate
+// limits.
+const MinPollInterval = 60
+
+// DefaultPollConcurrency is how many repositories are polled in parallel
+// unless configured otherwise.
+const DefaultPollConcurrency = 4
+
 // Poller periodically checks GitHub repositories for updates.
 type Poller struct {
 	client    *Client
-	store     *storage.SubscriptionStore
+	store     storage.Store
+	settings  *storage.SettingsStore
 	eventsCh  chan<- *WebhookEvent
-	interval  time.Duration
-	startTime time.Time // 记录启动时间，只推送启动后的新事件
+	startTime time.Time // 记录启动时间，未轮询过的仓库只推送启动后的新事件
+	leaderID  string    // Identifies this instance in the leader lease
+	rateState string    // Last logged rate limit throttling state
+
+	concurrency  int  // Repositories polled in parallel
+	useEventsAPI bool // Poll the repository events API instead of listing each resource
+	useGraphQL   bool // Fetch due repositories in batches with GraphQL
+
+	complianceEvery int                 // Polls between compliance checks of a repo
+	pollBranches    map[string][]string // Branches whose commits are polled, keyed by lowercase owner/name
+
+	// Tunables a configuration reload replaces, guarded by configMu
+	configMu sync.RWMutex
+	interval time.Duration
+	activity ActivityPolicy
+
+	// Per-repo state, keyed by owner/name. Repositories are polled by
+	// several workers, so access goes through mu.
+	mu               sync.Mutex
+	nextPoll         map[string]time.Time // Schedule
+	complianceCycles map[string]int       // Polls since start
+	defaultBranches  map[string]string    // Default branch
+
+	// Progress reported to health checks, guarded by statusMu
+	statusMu     sync.Mutex
+	leading      bool      // Holds the poller lease
+	leadingSince time.Time // When it last acquired the lease
+	lastSuccess  time.Time // When the last polling round completed
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -25,12 +64,12 @@ type Poller struct {
 }
 
 // NewPoller creates a new repository poller.
-func NewPoller(client *Client, store *storage.SubscriptionStore, eventsCh chan<- *WebhookEvent, intervalSeconds int) *Poller {
+func NewPoller(client *Client, store storage.Store, eventsCh chan<- *WebhookEvent, intervalSeconds int) *Poller {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	interval := time.Duration(intervalSeconds) * time.Second
-	if interval < 60*time.Second {
-		interval = 60 * time.Second // Minimum 1 minute to respect rate limits
+	if interval < MinPollInterval*time.Second {
+		interval = MinPollInterval * time.Second // Respect rate limits
 	}
 
 	return &Poller{
@@ -38,17 +77,155 @@ func NewPoller(client *Client, store *storage.SubscriptionStore, eventsCh chan<-
 		store:     store,
 		eventsCh:  eventsCh,
 		interval:  interval,
+		activity:  DefaultActivityPolicy(),
 		startTime: time.Now(), // 记录启动时间
-		ctx:       ctx,
-		cancel:    cancel,
+		leaderID:  instanceID(),
+
+		concurrency:  DefaultPollConcurrency,
+		useEventsAPI: true,
+
+		complianceEvery: 12,
+
+		nextPoll:         make(map[string]time.Time),
+		complianceCycles: make(map[string]int),
+		defaultBranches:  make(map[string]string),
+
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// SetSettingsStore sets the store used for runtime setting overrides.
+func (p *Poller) SetSettingsStore(settings *storage.SettingsStore) {
+	p.settings = settings
+}
+
+// SetConcurrency sets how many repositories are polled in parallel.
+func (p *Poller) SetConcurrency(workers int) {
+	if workers < 1 {
+		workers = 1
 	}
+	p.concurrency = workers
+}
+
+// SetActivityPolicy sets the policy used to adapt poll intervals to repository activity.
+func (p *Poller) SetActivityPolicy(policy ActivityPolicy) {
+	p.configMu.Lock()
+	defer p.configMu.Unlock()
+	p.activity = policy
+}
+
+// activityPolicy returns the policy set with SetActivityPolicy.
+func (p *Poller) activityPolicy() ActivityPolicy {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	return p.activity
+}
+
+// SetInterval changes the poll interval while polling. Repositories are
+// rescheduled from their last poll with the new interval.
+func (p *Poller) SetInterval(intervalSeconds int) {
+	interval := time.Duration(intervalSeconds) * time.Second
+	if interval < MinPollInterval*time.Second {
+		interval = MinPollInterval * time.Second
+	}
+
+	p.configMu.Lock()
+	changed := interval != p.interval
+	p.interval = interval
+	p.configMu.Unlock()
+	if !changed {
+		return
+	}
+
+	p.mu.Lock()
+	p.nextPoll = make(map[string]time.Time)
+	p.mu.Unlock()
+	logger.Info().Dur("interval", interval).Msg("Poll interval changed")
+}
+
+// currentInterval returns the poll interval, honoring the runtime override.
+func (p *Poller) currentInterval() time.Duration {
+	p.configMu.RLock()
+	interval := p.interval
+	p.configMu.RUnlock()
+
+	if p.settings == nil {
+		return interval
+	}
+	seconds, err := p.settings.GetInt(storage.SettingPollInterval, 0)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to read poll interval setting")
+		return interval
+	}
+	if seconds < 60 {
+		return interval
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // Start begins the polling loop.
 func (p *Poller) Start() {
 	p.wg.Add(1)
 	go p.pollLoop()
-	logger.Info().Dur("interval", p.interval).Msg("Poller started")
+	logger.Info().Dur("interval", p.currentInterval()).Msg("Poller started")
+}
+
+// SetPollOverrides sets the poll intervals of repositories given as
+// "owner/repo" in the configuration, replacing those set with /pollinterval.
+// Intervals an earlier configuration set for repositories no longer listed
+// are removed. The configuration lowercases its keys, so they match
+// subscribed repositories regardless of case.
+func (p *Poller) SetPollOverrides(overrides map[string]int) {
+	if cleared, err := p.store.ClearConfigPollOverrides(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to clear poll interval overrides of the configuration")
+	} else if cleared > 0 {
+		logger.Debug().Int64("repos", cleared).Msg("Cleared poll interval overrides of the configuration")
+	}
+	if len(overrides) == 0 {
+		return
+	}
+	subscribed, err := p.store.GetAllSubscribedRepos()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to get subscribed repos")
+	}
+
+	for repo, seconds := range overrides {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok || owner == "" || name == "" {
+			logger.Warn().Str("repo", repo).Msg("Invalid repository in poll interval overrides")
+			continue
+		}
+		if seconds < MinPollInterval {
+			logger.Warn().Str("repo", repo).Int("seconds", seconds).Msg("Poll interval override too short, using the minimum")
+			seconds = MinPollInterval
+		}
+
+		var targets [][2]string
+		for _, r := range subscribed {
+			if strings.EqualFold(r[0], owner) && strings.EqualFold(r[1], name) {
+				targets = append(targets, r)
+			}
+		}
+		if len(targets) == 0 {
+			targets = append(targets, [2]string{owner, name})
+		}
+		for _, t := range targets {
+			if err := p.store.SetConfigPollOverride(t[0], t[1], seconds); err != nil {
+				logger.Warn().Err(err).Str("repo", repo).Msg("Failed to set poll interval override")
+			}
+		}
+	}
+}
+
+// SetPollBranches sets the branches whose commits are polled for
+// repositories given as "owner/repo" in the configuration, instead of only
+// the default branch. The events API reports pushes to every branch anyway.
+func (p *Poller) SetPollBranches(branches map[string][]string) {
+	p.pollBranches = make(map[string][]string, len(branches))
+	for repo, list := range branches {
+		p.pollBranches[strings.ToLower(repo)] = list
+	}
 }
 
 // Stop gracefully stops the poller.
@@ -56,16 +233,52 @@ func (p *Poller) Stop() {
 	logger.Info().Msg("Stopping poller")
 	p.cancel()
 	p.wg.Wait()
+
+	// Let a newly deployed instance take over right away
+	if p.settings != nil {
+		if err := p.settings.ReleaseLease(storage.SettingPollerLeader, p.leaderID); err != nil {
+			logger.Warn().Err(err).Msg("Failed to release poller lease")
+		}
+	}
 }
 
+// pollTick is how often the poller checks which repositories are due.
+const pollTick = time.Minute
+
+// pollStallAfter is how long the leading poller may go without completing a
+// polling round before health checks consider it stalled.
+const pollStallAfter = 15 * pollTick
+
+// PollerStatus describes the progress of the poller for health checks.
+type PollerStatus struct {
+	Leader      bool      // Holds the poller lease, false on a standby instance
+	LastSuccess time.Time // When the last polling round completed, zero if none has
+	Stalled     bool      // Leading, but no round completed for too long
+}
+
+// leaderTTL is how long the poller lease lasts without renewal.
+const leaderTTL = 3 * pollTick
+
 // pollLoop is the main polling loop.
 func (p *Poller) pollLoop() {
 	defer p.wg.Done()
+	defer logger.ReportPanic()
+
+	// Only one instance may poll at a time, e.g. while a deploy overlaps
+	for !p.lead() {
+		logger.Info().Msg("Another instance is polling, waiting for the poller lease")
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-time.After(pollTick):
+		}
+	}
 
 	// 首次轮询：只记录当前状态，不推送通知（静默初始化）
 	p.initializeRepos()
+	p.markPolled()
 
-	ticker := time.NewTicker(p.interval)
+	ticker := time.NewTicker(pollTick)
 	defer ticker.Stop()
 
 	for {
@@ -73,9 +286,75 @@ func (p *Poller) pollLoop() {
 		case <-p.ctx.Done():
 			return
 		case <-ticker.C:
-			p.pollAllRepos()
+			if !p.lead() {
+				logger.Warn().Msg("Poller lease lost, skipping poll")
+				continue
+			}
+			p.syncUsers()
+			p.checkTopics()
+			if p.pollAllRepos() {
+				p.markPolled()
+			}
+		}
+	}
+}
+
+// isLeader acquires or renews the poller lease. Without a settings store the
+// poller always considers itself the leader.
+func (p *Poller) isLeader() bool {
+	if p.settings == nil {
+		return true
+	}
+	ok, err := p.settings.AcquireLease(storage.SettingPollerLeader, p.leaderID, leaderTTL)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to acquire poller lease")
+		return false
+	}
+	return ok
+}
+
+// lead renews the poller lease like isLeader, recording the outcome for
+// health checks.
+func (p *Poller) lead() bool {
+	leader := p.isLeader()
+	p.statusMu.Lock()
+	if leader && !p.leading {
+		p.leadingSince = time.Now()
+	}
+	p.leading = leader
+	p.statusMu.Unlock()
+	return leader
+}
+
+// markPolled records that a polling round completed.
+func (p *Poller) markPolled() {
+	p.statusMu.Lock()
+	p.lastSuccess = time.Now()
+	p.statusMu.Unlock()
+}
+
+// Status reports how the poller is doing at the given time.
+func (p *Poller) Status(now time.Time) PollerStatus {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+
+	status := PollerStatus{Leader: p.leading, LastSuccess: p.lastSuccess}
+	if p.leading {
+		// Measured from taking over the lease, so a standby instance that
+		// becomes the leader is given time for its first round
+		since := p.lastSuccess
+		if p.leadingSince.After(since) {
+			since = p.leadingSince
 		}
+		status.Stalled = now.Sub(since) > pollStallAfter
 	}
+	return status
+}
+
+// instanceID returns an identifier unique to this process.
+func instanceID() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d-%d", host, os.Getpid(), time.Now().UnixNano())
 }
 
 // initializeRepos 首次运行时记录已有事件，避免推送历史数据
@@ -97,6 +376,10 @@ func (p *Poller) initializeRepos() {
 		case <-p.ctx.Done():
 			return
 		default:
+			// Repositories polled before resume from their watermarks
+			if seen, err := p.store.HasWatermarks(repo[0], repo[1]); err == nil && seen {
+				continue
+			}
 			p.recordExistingEvents(repo[0], repo[1])
 		}
 	}
@@ -108,16 +391,20 @@ func (p *Poller) initializeRepos() {
 func (p *Poller) recordExistingEvents(owner, name string) {
 	ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
 	defer cancel()
+	if state, err := p.store.GetRepoState(owner, name); err == nil {
+		ctx, _ = p.withCredential(ctx, owner, name, state)
+	}
 
 	// 记录现有 commits
 	commits, _, err := p.client.client.Repositories.ListCommits(ctx, owner, name, &gh.CommitsListOptions{
 		ListOptions: gh.ListOptions{PerPage: 10},
 	})
 	if err == nil {
+		ref := p.defaultRef(ctx, owner, name)
 		for _, commit := range commits {
 			sha := commit.GetSHA()
 			if sha != "" {
-				p.store.RecordEvent(owner, name, "push", sha)
+				p.store.RecordEvent(owner, name, "push", PushKey(ref, sha))
 			}
 		}
 	}
@@ -127,7 +414,7 @@ func (p *Poller) recordExistingEvents(owner, name string) {
 	if err == nil {
 		for _, release := range releases {
 			if !release.GetDraft() {
-				eventID := fmt.Sprintf("release-%s", release.GetTagName())
+				eventID := ReleaseKey(release.GetTagName())
 				p.store.RecordEvent(owner, name, "release", eventID)
 			}
 		}
@@ -144,11 +431,11 @@ func (p *Poller) recordExistingEvents(owner, name string) {
 		for _, issue := range issues {
 			if !issue.IsPullRequest() {
 				// 使用 "issue-创建" 作为唯一标识，只通知新创建的 issue
-				eventID := fmt.Sprintf("issue-%d-created", issue.GetNumber())
+				eventID := IssueKey(issue.GetNumber(), "opened")
 				p.store.RecordEvent(owner, name, "issues", eventID)
 				// 同时记录关闭事件（如果已关闭）
 				if issue.GetState() == "closed" {
-					eventID = fmt.Sprintf("issue-%d-closed", issue.GetNumber())
+					eventID = IssueKey(issue.GetNumber(), "closed")
 					p.store.RecordEvent(owner, name, "issues", eventID)
 				}
 			}
@@ -165,15 +452,11 @@ func (p *Poller) recordExistingEvents(owner, name string) {
 	if err == nil {
 		for _, pr := range prs {
 			// 使用 "pr-创建" 作为唯一标识
-			eventID := fmt.Sprintf("pr-%d-created", pr.GetNumber())
+			eventID := PullRequestKey(pr.GetNumber(), "opened", false)
 			p.store.RecordEvent(owner, name, "pull_request", eventID)
 			// 记录合并/关闭事件（如果已完成）
 			if pr.GetState() == "closed" {
-				if pr.GetMerged() {
-					eventID = fmt.Sprintf("pr-%d-merged", pr.GetNumber())
-				} else {
-					eventID = fmt.Sprintf("pr-%d-closed", pr.GetNumber())
-				}
+				eventID = PullRequestKey(pr.GetNumber(), "closed", pr.GetMerged())
 				p.store.RecordEvent(owner, name, "pull_request", eventID)
 			}
 		}
@@ -182,78 +465,360 @@ func (p *Poller) recordExistingEvents(owner, name string) {
 	logger.Debug().Str("repo", owner+"/"+name).Msg("Recorded existing events")
 }
 
-// pollAllRepos checks all subscribed repositories for updates.
-func (p *Poller) pollAllRepos() {
+// pollAllRepos checks all subscribed repositories for updates, reporting
+// whether the round could run.
+func (p *Poller) pollAllRepos() bool {
 	repos, err := p.store.GetAllSubscribedRepos()
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get subscribed repos")
-		return
+		return false
 	}
 
 	if len(repos) == 0 {
-		return
+		return true
 	}
 
-	logger.Debug().Int("count", len(repos)).Msg("Polling repositories")
+	now := time.Now()
+	base, ok := p.throttle(p.currentInterval(), now)
+	if !ok {
+		return true
+	}
 
+	type dueRepo struct {
+		owner, name string
+		state       *storage.RepoState
+		snapshot    *repoSnapshot // Fetched with GraphQL, nil to poll with REST
+	}
+	var due []dueRepo
 	for _, repo := range repos {
+		state, err := p.repoState(repo[0], repo[1])
+		if err != nil {
+			logger.Warn().Err(err).Str("repo", repo[0]+"/"+repo[1]).Msg("Failed to load repo state")
+		}
+		if p.isDue(repo[0], repo[1], state, base, now) {
+			due = append(due, dueRepo{owner: repo[0], name: repo[1], state: state})
+		}
+	}
+	if len(due) == 0 {
+		return true
+	}
+
+	// Fetch the repositories in batches; those with configured branches list
+	// the commits of each branch on their own, and those read with a user's
+	// account are fetched with that account
+	if p.useGraphQL {
+		var batched [][2]string
+		for _, r := range due {
+			if len(p.pollBranches[strings.ToLower(r.owner+"/"+r.name)]) == 0 && !hasCredential(r.state) {
+				batched = append(batched, [2]string{r.owner, r.name})
+			}
+		}
+		snapshots := p.fetchSnapshots(batched)
+		for i := range due {
+			due[i].snapshot = snapshots[due[i].owner+"/"+due[i].name]
+		}
+	}
+
+	jobs := make(chan dueRepo)
+	var wg sync.WaitGroup
+	for i := 0; i < min(p.concurrency, len(due)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer logger.ReportPanic()
+			for r := range jobs {
+				p.pollRepo(r.owner, r.name, r.state, base, r.snapshot)
+			}
+		}()
+	}
+
+	polled := 0
+	renewed := now
+dispatch:
+	for _, r := range due {
+		// Keep the lease alive during long polling rounds
+		if time.Since(renewed) > pollTick {
+			if !p.lead() {
+				logger.Warn().Msg("Poller lease lost, stopping polling round")
+				break
+			}
+			renewed = time.Now()
+		}
+		if p.client.RateStatus().Paused(time.Now()) {
+			logger.Warn().Msg("GitHub rate limit exceeded, stopping polling round")
+			break
+		}
 		select {
 		case <-p.ctx.Done():
-			return
-		default:
-			p.pollRepo(repo[0], repo[1])
+			break dispatch
+		case jobs <- r:
+			polled++
 		}
 	}
+	close(jobs)
+	wg.Wait()
+
+	logger.Debug().
+		Int("count", len(repos)).
+		Int("polled", polled).
+		Dur("took", time.Since(now)).
+		Msg("Polled repositories")
+	return true
+}
+
+// repoState loads the polling state of a repository, creating it if needed.
+func (p *Poller) repoState(owner, name string) (*storage.RepoState, error) {
+	if err := p.store.EnsureRepoState(owner, name); err != nil {
+		return nil, err
+	}
+	return p.store.GetRepoState(owner, name)
+}
+
+// hasCredential reports whether a repository is read with a chat's token or
+// a user's linked account.
+func hasCredential(state *storage.RepoState) bool {
+	return state != nil && (state.CredentialChatID != 0 || state.CredentialUserID != 0)
 }
 
-// pollRepo checks a single repository for updates.
-func (p *Poller) pollRepo(owner, name string) {
+// withCredential returns ctx authenticated with the chat token or linked
+// account a private repository is read with, if any. It reports false if the
+// token ran out of quota, so the repository should not be polled now.
+func (p *Poller) withCredential(ctx context.Context, owner, name string, state *storage.RepoState) (context.Context, bool) {
+	if !hasCredential(state) {
+		return ctx, true
+	}
+	token, err := p.credentialToken(owner, name, state)
+	if err != nil {
+		logger.Warn().Err(err).Str("repo", owner+"/"+name).Msg("Failed to read repository credential")
+		return ctx, true
+	}
+	if token == "" {
+		return ctx, true // Removed since, try the configured tokens
+	}
+	if p.client.UserRateStatus(token).Paused(time.Now()) {
+		return ctx, false
+	}
+	return WithToken(ctx, token), true
+}
+
+// credentialToken returns the token a repository is read with: that of the
+// chat it was subscribed with, as long as the chat is still subscribed to
+// it, or else that of the linked account. It is empty if neither is left.
+func (p *Poller) credentialToken(owner, name string, state *storage.RepoState) (string, error) {
+	if state.CredentialChatID != 0 {
+		sub, err := p.store.GetSubscription(state.CredentialChatID, owner, name)
+		if err != nil {
+			return "", err
+		}
+		if sub != nil {
+			token, err := p.store.GetChatGitHubToken(state.CredentialChatID)
+			if err != nil || token != "" {
+				return token, err
+			}
+		}
+		// The chat's token only reads the chat's own subscriptions
+		if err := p.store.SetRepoChatCredential(owner, name, 0); err != nil {
+			return "", err
+		}
+	}
+	if state.CredentialUserID == 0 {
+		return "", nil
+	}
+	return p.store.GetGitHubToken(state.CredentialUserID)
+}
+
+// isDue reports whether a repository should be polled now. A manual
+// override applies from the last poll on, so changing it takes effect
+// without waiting for the previously scheduled check.
+func (p *Poller) isDue(owner, name string, state *storage.RepoState, base time.Duration, now time.Time) bool {
+	p.mu.Lock()
+	next, ok := p.nextPoll[owner+"/"+name]
+	p.mu.Unlock()
+	if state != nil && state.PollIntervalOverride > 0 && state.LastPolledAt.Valid {
+		next, ok = state.LastPolledAt.Time.Add(time.Duration(state.PollIntervalOverride)*time.Second), true
+	}
+	if !ok {
+		// Not polled by this process yet: continue the persisted schedule
+		if state == nil || !state.LastPolledAt.Valid {
+			return true
+		}
+		interval, _ := p.activityPolicy().Interval(base, state)
+		next = state.LastPolledAt.Time.Add(interval)
+	}
+	return !now.Before(next)
+}
+
+// pollRepo checks a single repository for updates and schedules its next check.
+func (p *Poller) pollRepo(owner, name string, state *storage.RepoState, base time.Duration, snapshot *repoSnapshot) {
+	start := time.Now()
+	defer func() { metrics.ObservePoll(owner+"/"+name, time.Since(start)) }()
+
 	ctx, cancel := context.WithTimeout(p.ctx, 30*time.Second)
 	defer cancel()
+	ctx, ok := p.withCredential(ctx, owner, name, state)
+	if !ok {
+		p.schedule(owner, name, state, base, 0)
+		return
+	}
+
+	count := 0
+	polled := false
+
+	// Use the batch fetched with GraphQL or check the event stream, falling
+	// back to listing each resource
+	switch {
+	case snapshot != nil:
+		count, polled = p.applySnapshot(ctx, owner, name, snapshot), true
+	case p.useEventsAPI:
+		count, polled = p.pollEvents(ctx, owner, name)
+	}
+	if p.client.RateStatus().Paused(time.Now()) {
+		// Further requests would be refused until the rate limit resets
+		p.schedule(owner, name, state, base, count)
+		return
+	}
+	if !polled {
+		count += p.pollCommits(ctx, owner, name)
+		count += p.pollReleases(ctx, owner, name)
+		count += p.pollTags(ctx, owner, name)
+		count += p.pollIssues(ctx, owner, name)
+		count += p.pollPullRequests(ctx, owner, name)
+	}
+
+	// Check for finished workflow runs
+	count += p.pollWorkflowRuns(ctx, owner, name)
+
+	// Check for star milestones
+	count += p.pollStars(ctx, owner, name, state)
+
+	// Check license and visibility for compliance-watched repos
+	p.checkCompliance(ctx, owner, name)
+
+	p.schedule(owner, name, state, base, count)
+}
+
+// schedule updates the activity score of a repository and picks its next poll time.
+func (p *Poller) schedule(owner, name string, state *storage.RepoState, base time.Duration, events int) {
+	now := time.Now()
+
+	policy := p.activityPolicy()
+	var score float64
+	evaluated := false
+	if state != nil && policy.Enabled {
+		state.PendingEvents += events
+		score, evaluated = policy.Evaluate(state, now)
+		if evaluated {
+			state.ActivityScore = score
+			state.ActivityBand = policy.Band(state.ActivityBand, score)
+		}
+	}
+
+	interval, band := policy.Interval(base, state)
+	p.mu.Lock()
+	p.nextPoll[owner+"/"+name] = now.Add(interval)
+	p.mu.Unlock()
 
-	// Check for new commits
-	p.pollCommits(ctx, owner, name)
+	if state == nil {
+		return
+	}
+
+	if err := p.store.RecordRepoPoll(owner, name, events, int(interval.Seconds())); err != nil {
+		logger.Warn().Err(err).Str("repo", owner+"/"+name).Msg("Failed to record poll")
+	}
+	if evaluated {
+		if err := p.store.UpdateRepoActivity(owner, name, score, state.ActivityBand); err != nil {
+			logger.Warn().Err(err).Str("repo", owner+"/"+name).Msg("Failed to update activity score")
+		}
+		logger.Info().
+			Str("repo", owner+"/"+name).
+			Float64("score", score).
+			Str("band", band).
+			Dur("interval", interval).
+			Msg("Repository activity re-evaluated")
+	}
+}
 
-	// Check for new releases
-	p.pollReleases(ctx, owner, name)
+// pollCommits checks for new commits on the default branch, or on the
+// branches configured for the repository.
+func (p *Poller) pollCommits(ctx context.Context, owner, name string) int {
+	since := p.since(owner, name, "push")
+	polledAt := time.Now()
 
-	// Check for new issues
-	p.pollIssues(ctx, owner, name)
+	branches := p.pollBranches[strings.ToLower(owner+"/"+name)]
+	if len(branches) == 0 {
+		branches = []string{""} // The default branch
+	}
 
-	// Check for new pull requests
-	p.pollPullRequests(ctx, owner, name)
+	count := 0
+	complete := true
+	for _, branch := range branches {
+		n, ok := p.pollBranchCommits(ctx, owner, name, branch, since)
+		count += n
+		complete = complete && ok
+	}
+	if complete {
+		p.advance(owner, name, "push", polledAt, "")
+	}
+	return count
 }
 
-// pollCommits checks for new commits.
-func (p *Poller) pollCommits(ctx context.Context, owner, name string) {
+// pollBranchCommits checks for new commits on a branch, "" for the default
+// branch. It reports false if the commits could not all be checked. A commit
+// on several branches is notified for the first one.
+func (p *Poller) pollBranchCommits(ctx context.Context, owner, name, branch string, since time.Time) (int, bool) {
 	commits, _, err := p.client.client.Repositories.ListCommits(ctx, owner, name, &gh.CommitsListOptions{
-		Since:       p.startTime, // 只获取启动后的 commits
+		SHA:         branch,
+		Since:       since, // 只获取上次轮询后的 commits
 		ListOptions: gh.ListOptions{PerPage: 10},
 	})
 	if err != nil {
-		logger.Debug().Err(err).Str("repo", owner+"/"+name).Msg("Failed to fetch commits")
-		return
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch commits")
+		return 0, false
 	}
 
+	ref := ""
+	if branch != "" {
+		ref = "refs/heads/" + branch
+	}
+	return p.notifyCommits(ctx, owner, name, ref, commits)
+}
+
+// notifyCommits sends events for the commits not processed yet, pushed to
+// the given ref, "" for the default branch. It reports false if an event
+// could not be queued.
+func (p *Poller) notifyCommits(ctx context.Context, owner, name, ref string, commits []*gh.RepositoryCommit) (int, bool) {
+	count := 0
+	complete := true
 	for _, commit := range commits {
 		sha := commit.GetSHA()
 		if sha == "" {
 			continue
 		}
 
-		// Check if already processed
-		processed, _ := p.store.IsEventProcessed(owner, name, "push", sha)
+		if ref == "" {
+			ref = p.defaultRef(ctx, owner, name)
+		}
+
+		// Check if already processed, or recorded by commit alone by earlier
+		// versions
+		processed, _ := p.store.IsEventProcessed(owner, name, "push", PushKey(ref, sha))
+		if !processed {
+			processed, _ = p.store.IsEventProcessed(owner, name, "push", sha)
+		}
 		if processed {
 			continue
 		}
 
 		// Create push event
 		event := &WebhookEvent{
-			Type:      "push",
-			RepoOwner: owner,
-			RepoName:  name,
+			Type:       "push",
+			RepoOwner:  owner,
+			RepoName:   name,
+			Source:     "poller",
+			OccurredAt: commit.GetCommit().GetCommitter().GetDate().Time,
+			DetectedAt: time.Now(),
 			Payload: &PushEvent{
-				Ref:    "refs/heads/main",
+				Ref:    ref,
 				After:  sha,
 				Pusher: UserInfo{Login: commit.GetAuthor().GetLogin()},
 				Commits: []CommitInfo{{
@@ -268,33 +833,74 @@ func (p *Poller) pollCommits(ctx context.Context, owner, name string) {
 
 		select {
 		case p.eventsCh <- event:
-			logger.Debug().Str("repo", owner+"/"+name).Str("sha", sha[:7]).Msg("New commit detected")
+			count++
+			logger.Debug().Str("repo", owner+"/"+name).Str("ref", ref).Str("sha", sha[:7]).Msg("New commit detected")
 		default:
 			logger.Warn().Msg("Event channel full")
+			complete = false
+		}
+	}
+	return count, complete
+}
+
+// defaultRef returns the ref of a repository's default branch, or "HEAD" if it
+// cannot be determined.
+func (p *Poller) defaultRef(ctx context.Context, owner, name string) string {
+	key := owner + "/" + name
+	p.mu.Lock()
+	branch, ok := p.defaultBranches[key]
+	p.mu.Unlock()
+	if !ok {
+		repo, _, err := p.client.client.Repositories.Get(ctx, owner, name)
+		if err != nil {
+			p.fetchFailed(err, key, "Failed to fetch default branch")
+			return "HEAD"
 		}
+		branch = repo.GetDefaultBranch()
+		p.mu.Lock()
+		p.defaultBranches[key] = branch
+		p.mu.Unlock()
 	}
+	if branch == "" {
+		return "HEAD"
+	}
+	return "refs/heads/" + branch
 }
 
 // pollReleases checks for new releases.
-func (p *Poller) pollReleases(ctx context.Context, owner, name string) {
+func (p *Poller) pollReleases(ctx context.Context, owner, name string) int {
+	since := p.since(owner, name, "release")
+	polledAt := time.Now()
 	releases, _, err := p.client.client.Repositories.ListReleases(ctx, owner, name, &gh.ListOptions{PerPage: 5})
 	if err != nil {
-		logger.Debug().Err(err).Str("repo", owner+"/"+name).Msg("Failed to fetch releases")
-		return
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch releases")
+		return 0
 	}
 
+	count, complete := p.notifyReleases(owner, name, since, releases)
+	if complete {
+		p.advance(owner, name, "release", polledAt, "")
+	}
+	return count
+}
+
+// notifyReleases sends events for the releases published since the given
+// time. It reports false if an event could not be queued.
+func (p *Poller) notifyReleases(owner, name string, since time.Time, releases []*gh.RepositoryRelease) (int, bool) {
+	count := 0
+	dropped := false
 	for _, release := range releases {
 		if release.GetDraft() {
 			continue
 		}
 
-		// 只推送启动后发布的 release
-		if release.GetPublishedAt().Time.Before(p.startTime) {
+		// 只推送上次轮询后发布的 release
+		if release.GetPublishedAt().Time.Before(since) {
 			continue
 		}
 
 		tagName := release.GetTagName()
-		eventID := fmt.Sprintf("release-%s", tagName)
+		eventID := ReleaseKey(tagName)
 
 		processed, _ := p.store.IsEventProcessed(owner, name, "release", eventID)
 		if processed {
@@ -302,9 +908,12 @@ func (p *Poller) pollReleases(ctx context.Context, owner, name string) {
 		}
 
 		event := &WebhookEvent{
-			Type:      "release",
-			RepoOwner: owner,
-			RepoName:  name,
+			Type:       "release",
+			RepoOwner:  owner,
+			RepoName:   name,
+			Source:     "poller",
+			OccurredAt: release.GetPublishedAt().Time,
+			DetectedAt: time.Now(),
 			Payload: &ReleaseEvent{
 				Action:     "published",
 				TagName:    tagName,
@@ -313,52 +922,132 @@ func (p *Poller) pollReleases(ctx context.Context, owner, name string) {
 				Prerelease: release.GetPrerelease(),
 				URL:        release.GetHTMLURL(),
 				Author:     UserInfo{Login: release.GetAuthor().GetLogin()},
+				Assets:     convertAssets(release.Assets),
 			},
 		}
 
 		select {
 		case p.eventsCh <- event:
+			count++
 			logger.Debug().Str("repo", owner+"/"+name).Str("tag", tagName).Msg("New release detected")
 		default:
+			dropped = true
+		}
+	}
+	return count, !dropped
+}
+
+// pollTags checks for new tags, if any subscriber wants tag events. Tags carry
+// no timestamp, so the tags present at the first check are recorded silently.
+func (p *Poller) pollTags(ctx context.Context, owner, name string) int {
+	if !p.wantsEvent(owner, name, storage.EventTypeTag) {
+		return 0
+	}
+
+	polledAt := time.Now()
+	tags, _, err := p.client.client.Repositories.ListTags(ctx, owner, name, &gh.ListOptions{PerPage: 10})
+	if err != nil {
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch tags")
+		return 0
+	}
+
+	key := owner + "/" + name
+	seeded := p.watermark(owner, name, "tag") != nil
+
+	count := 0
+	dropped := false
+	for _, tag := range tags {
+		tagName := tag.GetName()
+		eventID := TagKey(tagName)
+
+		processed, _ := p.store.IsEventProcessed(owner, name, "tag", eventID)
+		if processed {
+			continue
+		}
+		if !seeded {
+			p.store.RecordEvent(owner, name, "tag", eventID)
+			continue
+		}
+
+		event := &WebhookEvent{
+			Type:       "tag",
+			RepoOwner:  owner,
+			RepoName:   name,
+			Source:     "poller",
+			DetectedAt: time.Now(),
+			Payload: &TagEvent{
+				Name: tagName,
+				SHA:  tag.GetCommit().GetSHA(),
+				URL:  fmt.Sprintf("https://github.com/%s/%s/tree/%s", owner, name, url.PathEscape(tagName)),
+			},
+		}
+
+		select {
+		case p.eventsCh <- event:
+			count++
+			logger.Debug().Str("repo", key).Str("tag", tagName).Msg("New tag detected")
+		default:
+			dropped = true
 		}
 	}
+	if !dropped {
+		p.advance(owner, name, "tag", polledAt, "")
+	}
+	return count
 }
 
 // pollIssues checks for NEW issues (created after bot start).
-func (p *Poller) pollIssues(ctx context.Context, owner, name string) {
+func (p *Poller) pollIssues(ctx context.Context, owner, name string) int {
+	since := p.since(owner, name, "issues")
+	polledAt := time.Now()
+
 	// 只获取最近创建的 issues
 	issues, _, err := p.client.client.Issues.ListByRepo(ctx, owner, name, &gh.IssueListByRepoOptions{
 		State:       "all",
 		Sort:        "created", // 按创建时间排序
 		Direction:   "desc",
-		Since:       p.startTime, // 只获取启动后的
+		Since:       since, // 只获取上次轮询后更新的
 		ListOptions: gh.ListOptions{PerPage: 10},
 	})
 	if err != nil {
-		logger.Debug().Err(err).Str("repo", owner+"/"+name).Msg("Failed to fetch issues")
-		return
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch issues")
+		return 0
 	}
 
+	count, complete := p.notifyIssues(owner, name, since, issues)
+	if complete {
+		p.advance(owner, name, "issues", polledAt, "")
+	}
+	return count
+}
+
+// notifyIssues sends events for the issues opened or closed since the given
+// time. It reports false if an event could not be queued.
+func (p *Poller) notifyIssues(owner, name string, since time.Time, issues []*gh.Issue) (int, bool) {
+	count := 0
+	dropped := false
 	for _, issue := range issues {
 		// Skip pull requests
 		if issue.IsPullRequest() {
 			continue
 		}
 
-		// 只推送启动后创建的 issue
-		if issue.GetCreatedAt().Time.Before(p.startTime) {
-			// 但如果是关闭事件且在启动后关闭，也推送
+		// 只推送上次轮询后创建的 issue
+		if issue.GetCreatedAt().Time.Before(since) {
+			// 但如果是关闭事件且在上次轮询后关闭，也推送
 			if issue.GetState() == "closed" {
 				closedAt := issue.GetClosedAt()
-				if !closedAt.IsZero() && closedAt.Time.After(p.startTime) {
-					p.notifyIssueClosed(owner, name, issue)
+				if !closedAt.IsZero() && closedAt.Time.After(since) {
+					if p.notifyIssueClosed(owner, name, issue) {
+						count++
+					}
 				}
 			}
 			continue
 		}
 
 		number := issue.GetNumber()
-		eventID := fmt.Sprintf("issue-%d-created", number)
+		eventID := IssueKey(number, "opened")
 
 		processed, _ := p.store.IsEventProcessed(owner, name, "issues", eventID)
 		if processed {
@@ -371,9 +1060,12 @@ func (p *Poller) pollIssues(ctx context.Context, owner, name string) {
 		}
 
 		event := &WebhookEvent{
-			Type:      "issues",
-			RepoOwner: owner,
-			RepoName:  name,
+			Type:       "issues",
+			RepoOwner:  owner,
+			RepoName:   name,
+			Source:     "poller",
+			OccurredAt: issue.GetCreatedAt().Time,
+			DetectedAt: time.Now(),
 			Payload: &IssueEvent{
 				Action: "opened",
 				Number: number,
@@ -388,20 +1080,23 @@ func (p *Poller) pollIssues(ctx context.Context, owner, name string) {
 
 		select {
 		case p.eventsCh <- event:
+			count++
 			logger.Debug().Str("repo", owner+"/"+name).Int("issue", number).Msg("New issue detected")
 		default:
+			dropped = true
 		}
 	}
+	return count, !dropped
 }
 
 // notifyIssueClosed 通知 issue 关闭
-func (p *Poller) notifyIssueClosed(owner, name string, issue *gh.Issue) {
+func (p *Poller) notifyIssueClosed(owner, name string, issue *gh.Issue) bool {
 	number := issue.GetNumber()
-	eventID := fmt.Sprintf("issue-%d-closed", number)
+	eventID := IssueKey(number, "closed")
 
 	processed, _ := p.store.IsEventProcessed(owner, name, "issues", eventID)
 	if processed {
-		return
+		return false
 	}
 
 	labels := make([]string, len(issue.Labels))
@@ -410,9 +1105,12 @@ func (p *Poller) notifyIssueClosed(owner, name string, issue *gh.Issue) {
 	}
 
 	event := &WebhookEvent{
-		Type:      "issues",
-		RepoOwner: owner,
-		RepoName:  name,
+		Type:       "issues",
+		RepoOwner:  owner,
+		RepoName:   name,
+		Source:     "poller",
+		OccurredAt: issue.GetClosedAt().Time,
+		DetectedAt: time.Now(),
 		Payload: &IssueEvent{
 			Action: "closed",
 			Number: number,
@@ -428,12 +1126,16 @@ func (p *Poller) notifyIssueClosed(owner, name string, issue *gh.Issue) {
 	select {
 	case p.eventsCh <- event:
 		logger.Debug().Str("repo", owner+"/"+name).Int("issue", number).Msg("Issue closed detected")
+		return true
 	default:
 	}
+	return false
 }
 
 // pollPullRequests checks for NEW pull requests.
-func (p *Poller) pollPullRequests(ctx context.Context, owner, name string) {
+func (p *Poller) pollPullRequests(ctx context.Context, owner, name string) int {
+	since := p.since(owner, name, "pull_request")
+	polledAt := time.Now()
 	prs, _, err := p.client.client.PullRequests.List(ctx, owner, name, &gh.PullRequestListOptions{
 		State:       "all",
 		Sort:        "created",
@@ -441,25 +1143,40 @@ func (p *Poller) pollPullRequests(ctx context.Context, owner, name string) {
 		ListOptions: gh.ListOptions{PerPage: 10},
 	})
 	if err != nil {
-		logger.Debug().Err(err).Str("repo", owner+"/"+name).Msg("Failed to fetch PRs")
-		return
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch PRs")
+		return 0
+	}
+
+	count, complete := p.notifyPullRequests(owner, name, since, prs)
+	if complete {
+		p.advance(owner, name, "pull_request", polledAt, "")
 	}
+	return count
+}
 
+// notifyPullRequests sends events for the pull requests opened, closed or
+// merged since the given time. It reports false if an event could not be
+// queued.
+func (p *Poller) notifyPullRequests(owner, name string, since time.Time, prs []*gh.PullRequest) (int, bool) {
+	count := 0
+	dropped := false
 	for _, pr := range prs {
-		// 只推送启动后创建的 PR
-		if pr.GetCreatedAt().Time.Before(p.startTime) {
-			// 但如果是合并/关闭事件且在启动后发生，也推送
+		// 只推送上次轮询后创建的 PR
+		if pr.GetCreatedAt().Time.Before(since) {
+			// 但如果是合并/关闭事件且在上次轮询后发生，也推送
 			if pr.GetState() == "closed" {
 				closedAt := pr.GetClosedAt()
-				if !closedAt.IsZero() && closedAt.Time.After(p.startTime) {
-					p.notifyPRClosed(owner, name, pr)
+				if !closedAt.IsZero() && closedAt.Time.After(since) {
+					if p.notifyPRClosed(owner, name, pr) {
+						count++
+					}
 				}
 			}
 			continue
 		}
 
 		number := pr.GetNumber()
-		eventID := fmt.Sprintf("pr-%d-created", number)
+		eventID := PullRequestKey(number, "opened", false)
 
 		processed, _ := p.store.IsEventProcessed(owner, name, "pull_request", eventID)
 		if processed {
@@ -467,9 +1184,12 @@ func (p *Poller) pollPullRequests(ctx context.Context, owner, name string) {
 		}
 
 		event := &WebhookEvent{
-			Type:      "pull_request",
-			RepoOwner: owner,
-			RepoName:  name,
+			Type:       "pull_request",
+			RepoOwner:  owner,
+			RepoName:   name,
+			Source:     "poller",
+			OccurredAt: pr.GetCreatedAt().Time,
+			DetectedAt: time.Now(),
 			Payload: &PullRequestEvent{
 				Action:    "opened",
 				Number:    number,
@@ -479,6 +1199,7 @@ func (p *Poller) pollPullRequests(ctx context.Context, owner, name string) {
 				URL:       pr.GetHTMLURL(),
 				Merged:    pr.GetMerged(),
 				User:      UserInfo{Login: pr.GetUser().GetLogin()},
+				Labels:    prLabels(pr),
 				Additions: pr.GetAdditions(),
 				Deletions: pr.GetDeletions(),
 				Commits:   pr.GetCommits(),
@@ -489,36 +1210,103 @@ func (p *Poller) pollPullRequests(ctx context.Context, owner, name string) {
 
 		select {
 		case p.eventsCh <- event:
+			count++
 			logger.Debug().Str("repo", owner+"/"+name).Int("pr", number).Msg("New PR detected")
 		default:
+			dropped = true
+		}
+	}
+	return count, !dropped
+}
+
+// pollWorkflowRuns checks for workflow runs completed after bot start, if any
+// subscriber wants workflow run events.
+func (p *Poller) pollWorkflowRuns(ctx context.Context, owner, name string) int {
+	if !p.wantsEvent(owner, name, storage.EventTypeWorkflowRun) {
+		return 0
+	}
+
+	since := p.since(owner, name, "workflow_run")
+	polledAt := time.Now()
+	runs, _, err := p.client.client.Actions.ListRepositoryWorkflowRuns(ctx, owner, name, &gh.ListWorkflowRunsOptions{
+		Status:      "completed",
+		Created:     ">=" + since.UTC().Format(time.RFC3339),
+		ListOptions: gh.ListOptions{PerPage: 10},
+	})
+	if err != nil {
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch workflow runs")
+		return 0
+	}
+
+	count := 0
+	dropped := false
+	for _, run := range runs.WorkflowRuns {
+		eventID := WorkflowRunKey(run.GetID(), run.GetRunAttempt())
+
+		processed, _ := p.store.IsEventProcessed(owner, name, "workflow_run", eventID)
+		if processed {
+			continue
+		}
+
+		event := &WebhookEvent{
+			Type:       "workflow_run",
+			RepoOwner:  owner,
+			RepoName:   name,
+			Source:     "poller",
+			OccurredAt: run.GetUpdatedAt().Time,
+			DetectedAt: time.Now(),
+			Payload: &WorkflowRunEvent{
+				Action:     "completed",
+				ID:         run.GetID(),
+				Attempt:    run.GetRunAttempt(),
+				RunNumber:  run.GetRunNumber(),
+				Name:       run.GetName(),
+				Title:      run.GetDisplayTitle(),
+				Branch:     run.GetHeadBranch(),
+				Event:      run.GetEvent(),
+				Conclusion: run.GetConclusion(),
+				URL:        run.GetHTMLURL(),
+				Actor:      UserInfo{Login: run.GetActor().GetLogin()},
+			},
+		}
+
+		select {
+		case p.eventsCh <- event:
+			count++
+			logger.Debug().Str("repo", owner+"/"+name).Int64("run", run.GetID()).Msg("Workflow run completed")
+		default:
+			dropped = true
 		}
 	}
+	if !dropped {
+		p.advance(owner, name, "workflow_run", polledAt, "")
+	}
+	return count
 }
 
 // notifyPRClosed 通知 PR 关闭/合并
-func (p *Poller) notifyPRClosed(owner, name string, pr *gh.PullRequest) {
+func (p *Poller) notifyPRClosed(owner, name string, pr *gh.PullRequest) bool {
 	number := pr.GetNumber()
 	merged := pr.GetMerged()
 
-	var eventID string
-	var action string
+	action := "closed"
 	if merged {
-		eventID = fmt.Sprintf("pr-%d-merged", number)
 		action = "merged"
-	} else {
-		eventID = fmt.Sprintf("pr-%d-closed", number)
-		action = "closed"
 	}
+	eventID := PullRequestKey(number, "closed", merged)
 
 	processed, _ := p.store.IsEventProcessed(owner, name, "pull_request", eventID)
 	if processed {
-		return
+		return false
 	}
 
 	event := &WebhookEvent{
-		Type:      "pull_request",
-		RepoOwner: owner,
-		RepoName:  name,
+		Type:       "pull_request",
+		RepoOwner:  owner,
+		RepoName:   name,
+		Source:     "poller",
+		OccurredAt: pr.GetClosedAt().Time,
+		DetectedAt: time.Now(),
 		Payload: &PullRequestEvent{
 			Action:    action,
 			Number:    number,
@@ -528,6 +1316,7 @@ func (p *Poller) notifyPRClosed(owner, name string, pr *gh.PullRequest) {
 			URL:       pr.GetHTMLURL(),
 			Merged:    merged,
 			User:      UserInfo{Login: pr.GetUser().GetLogin()},
+			Labels:    prLabels(pr),
 			Additions: pr.GetAdditions(),
 			Deletions: pr.GetDeletions(),
 			Commits:   pr.GetCommits(),
@@ -539,6 +1328,17 @@ func (p *Poller) notifyPRClosed(owner, name string, pr *gh.PullRequest) {
 	select {
 	case p.eventsCh <- event:
 		logger.Debug().Str("repo", owner+"/"+name).Int("pr", number).Str("action", action).Msg("PR closed/merged detected")
+		return true
 	default:
 	}
+	return false
+}
+
+// prLabels returns the label names of a pull request.
+func prLabels(pr *gh.PullRequest) []string {
+	labels := make([]string, len(pr.Labels))
+	for i, l := range pr.Labels {
+		labels[i] = l.GetName()
+	}
+	return labels
 }
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+// newLeasePoller returns a poller of one process sharing the lease in settings.
+func newLeasePoller(settings *storage.SettingsStore, id string) *Poller {
+	p := NewPoller(nil, nil, nil, MinPollInterval)
+	p.SetSettingsStore(settings)
+	p.leaderID = id
+	return p
+}
+
+func TestPollerLeaseHandoff(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	settings := storage.NewSettingsStore(db)
+
+	leading := func(p *Poller) bool {
+		t.Helper()
+		lead := p.lead()
+		if status := p.Status(time.Now()); status.Leader != lead {
+			t.Errorf("Status().Leader = %v after lead() = %v", status.Leader, lead)
+		}
+		return lead
+	}
+
+	// During a deploy the new process waits for the old one
+	old, next := newLeasePoller(settings, "old"), newLeasePoller(settings, "new")
+	if !leading(old) {
+		t.Fatal("first process did not get the lease")
+	}
+	if leading(next) {
+		t.Fatal("both processes lead during the overlap")
+	}
+	if !leading(old) || leading(next) {
+		t.Fatal("lease moved while the old process renews it")
+	}
+
+	// Stopping the old one hands the lease over without waiting for it to expire
+	old.Stop()
+	if !leading(next) {
+		t.Fatal("new process did not take over the released lease")
+	}
+	if status := next.Status(time.Now()); status.Stalled {
+		t.Error("new leader reported stalled before its first round")
+	}
+
+	// A process that dies without releasing it is replaced once it expires
+	if ok, err := settings.AcquireLease(storage.SettingPollerLeader, "new", -2*time.Second); err != nil || !ok {
+		t.Fatalf("AcquireLease() = %v, %v", ok, err)
+	}
+	replacement := newLeasePoller(settings, "replacement")
+	if !leading(replacement) {
+		t.Error("expired lease not taken over")
+	}
+	if leading(next) {
+		t.Error("expired holder kept leading after the takeover")
+	}
+}
+
+func TestSetPollOverrides(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := storage.NewSubscriptionStore(db)
+	p := NewPoller(nil, store, nil, MinPollInterval)
+
+	overrides := func() map[string]int {
+		t.Helper()
+		got := make(map[string]int)
+		for _, name := range []string{"app", "lib", "manual"} {
+			state, err := store.GetRepoState("acme", name)
+			if err != nil {
+				t.Fatalf("GetRepoState() error = %v", err)
+			}
+			if state != nil && state.PollIntervalOverride > 0 {
+				got[name] = state.PollIntervalOverride
+			}
+		}
+		return got
+	}
+
+	// Set with /pollinterval
+	if err := store.SetRepoPollOverride("acme", "manual", 600); err != nil {
+		t.Fatalf("SetRepoPollOverride() error = %v", err)
+	}
+
+	steps := []struct {
+		name   string
+		config map[string]int
+		want   map[string]int
+	}{
+		{"configured", map[string]int{"acme/app": 120, "acme/lib": 30}, map[string]int{"app": 120, "lib": MinPollInterval, "manual": 600}},
+		{"one removed", map[string]int{"acme/lib": 300}, map[string]int{"lib": 300, "manual": 600}},
+		{"all removed", nil, map[string]int{"manual": 600}},
+	}
+	for _, step := range steps {
+		p.SetPollOverrides(step.config)
+		if got := overrides(); fmt.Sprint(got) != fmt.Sprint(step.want) {
+			t.Errorf("%s: overrides = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
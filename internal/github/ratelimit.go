@@ -0,0 +1,190 @@
+package github
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Every API response reports how much of the hourly quota of its token is
+// left. The client remembers the latest figures, and when GitHub refuses
+// requests for exceeding the primary or secondary rate limit, until when to
+// hold off, so that the poller can slow down before the quota runs out.
+
+// secondaryLimitWait is how long to hold off after a secondary rate limit
+// response without Retry-After, as GitHub recommends.
+const secondaryLimitWait = time.Minute
+
+// RateStatus is the rate limit state last reported by GitHub.
+type RateStatus struct {
+	Limit       int       // Requests per hour, 0 if not known yet
+	Remaining   int       // Requests left in the current window
+	Reset       time.Time // When the window resets
+	PausedUntil time.Time // Requests are refused until then, zero if not limited
+}
+
+// Paused reports whether requests are refused at the given time.
+func (s RateStatus) Paused(now time.Time) bool {
+	return now.Before(s.PausedUntil)
+}
+
+// record updates the rate limit state from a response.
+func (s *RateStatus) record(resp *http.Response, now time.Time) {
+	// Search and GraphQL have quotas of their own
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return
+	}
+
+	limit, errLimit := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	reset, errReset := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if errLimit == nil && errRemaining == nil && errReset == nil {
+		s.Limit = limit
+		s.Remaining = remaining
+		s.Reset = time.Unix(reset, 0)
+	}
+
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	switch {
+	case resp.Header.Get("Retry-After") != "":
+		seconds, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		s.pause(now.Add(time.Duration(seconds) * time.Second))
+	case errRemaining == nil && remaining == 0:
+		s.pause(s.Reset)
+	case resp.StatusCode == http.StatusTooManyRequests:
+		s.pause(now.Add(secondaryLimitWait))
+	}
+	// Otherwise forbidden for other reasons, e.g. a private repository
+}
+
+// pause holds off requests until the given time.
+func (s *RateStatus) pause(until time.Time) {
+	if until.After(s.PausedUntil) {
+		s.PausedUntil = until
+	}
+}
+
+// RateStatus returns the rate limit state last reported by GitHub, combined
+// over all tokens.
+func (c *Client) RateStatus() RateStatus {
+	if c.tokens == nil {
+		return RateStatus{}
+	}
+	return c.tokens.combined(time.Now())
+}
+
+// UserRateStatus returns the rate limit state last reported for a token
+// requests were made with WithToken.
+func (c *Client) UserRateStatus(token string) RateStatus {
+	if c.tokens == nil {
+		return RateStatus{}
+	}
+	return c.tokens.userStatus(token)
+}
+
+// noteRateLimit holds off requests with the token of a secondary rate limit
+// error whose response carried no Retry-After.
+func (c *Client) noteRateLimit(err error) {
+	var secondary *github.AbuseRateLimitError
+	if c.tokens == nil || !errors.As(err, &secondary) || secondary.RetryAfter != nil || secondary.Response == nil {
+		return
+	}
+	c.tokens.pause(secondary.Response.Request, time.Now().Add(secondaryLimitWait))
+}
+
+// isRateLimitError reports whether an API call failed for exceeding a rate
+// limit.
+func isRateLimitError(err error) bool {
+	var primary *github.RateLimitError
+	var secondary *github.AbuseRateLimitError
+	return errors.As(err, &primary) || errors.As(err, &secondary)
+}
+
+// Below rateLowShare of the hourly quota polling slows down, and at
+// rateReserveShare it pauses until the quota resets, keeping the rest for
+// commands.
+const (
+	rateLowShare     = 0.2
+	rateReserveShare = 0.05
+	maxRateStretch   = 8
+)
+
+// rateThrottle returns how much to stretch poll intervals given the rate
+// limit state, or until when to pause polling.
+func rateThrottle(s RateStatus, now time.Time) (float64, time.Time) {
+	if s.Paused(now) {
+		return 1, s.PausedUntil
+	}
+	if s.Limit == 0 || !now.Before(s.Reset) {
+		return 1, time.Time{} // Unknown, or a fresh window has started
+	}
+
+	limit := float64(s.Limit)
+	remaining := float64(s.Remaining)
+	switch {
+	case remaining <= rateReserveShare*limit:
+		return 1, s.Reset
+	case remaining < rateLowShare*limit:
+		return math.Min(rateLowShare*limit/remaining, maxRateStretch), time.Time{}
+	default:
+		return 1, time.Time{}
+	}
+}
+
+// throttle stretches the base poll interval while the API quota runs low and
+// reports false while polling is paused. Changes are logged once.
+func (p *Poller) throttle(base time.Duration, now time.Time) (time.Duration, bool) {
+	status := p.client.RateStatus()
+	stretch, pausedUntil := rateThrottle(status, now)
+
+	state := "normal"
+	switch {
+	case !pausedUntil.IsZero():
+		state = "paused"
+	case stretch > 1:
+		state = "stretched"
+	}
+	if state != p.rateState {
+		p.rateState = state
+		switch state {
+		case "paused":
+			logger.Warn().
+				Int("remaining", status.Remaining).
+				Int("limit", status.Limit).
+				Time("until", pausedUntil).
+				Msg("GitHub rate limit exhausted, pausing polling")
+		case "stretched":
+			logger.Warn().
+				Int("remaining", status.Remaining).
+				Int("limit", status.Limit).
+				Time("reset", status.Reset).
+				Float64("stretch", stretch).
+				Msg("GitHub rate limit running low, polling less often")
+		default:
+			logger.Info().Int("remaining", status.Remaining).Int("limit", status.Limit).Msg("GitHub rate limit recovered, polling normally")
+		}
+	}
+
+	if state == "paused" {
+		return base, false
+	}
+	return time.Duration(float64(base) * stretch), true
+}
+
+// fetchFailed logs a failed API call. Rate limit errors are warnings, as
+// they hold back every repository.
+func (p *Poller) fetchFailed(err error, repo, msg string) {
+	if !isRateLimitError(err) {
+		logger.Debug().Err(err).Str("repo", repo).Msg(msg)
+		return
+	}
+	p.client.noteRateLimit(err)
+	logger.Warn().Err(err).Str("repo", repo).Msg(msg)
+}
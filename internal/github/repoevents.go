@@ -0,0 +1,296 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// The repository events API reports pushes, issues, pull requests, releases,
+// tags, reviews and wiki edits in a single list, so one request per poll
+// replaces listing each kind of resource. Workflow runs, star milestones and
+// compliance are not part of it and are still checked on their own.
+
+// repoEventsPerPage is how many events are fetched per poll. GitHub keeps at
+// most 300 events of the last 90 days per repository.
+const repoEventsPerPage = 100
+
+// SetEventsAPI selects whether repositories are polled through the events API
+// or by listing commits, releases, tags, issues and pull requests.
+func (p *Poller) SetEventsAPI(enabled bool) {
+	p.useEventsAPI = enabled
+}
+
+// pollEvents translates the events of a repository created after bot start
+// and not seen in earlier polls. It reports false if the events could not be
+// fetched.
+func (p *Poller) pollEvents(ctx context.Context, owner, name string) (int, bool) {
+	key := owner + "/" + name
+	polledAt := time.Now()
+	events, _, err := p.client.client.Activity.ListRepositoryEvents(ctx, owner, name, &gh.ListOptions{PerPage: repoEventsPerPage})
+	if err != nil {
+		p.fetchFailed(err, key, "Failed to fetch repository events")
+		return 0, false
+	}
+
+	// Events are listed newest first and have increasing IDs. Without a
+	// watermark, only events after bot start are new.
+	var lastID int64
+	since := p.startTime
+	if w := p.watermark(owner, name, "events"); w != nil {
+		lastID, _ = strconv.ParseInt(w.LastID, 10, 64)
+		since = time.Time{}
+	}
+
+	count := 0
+	newest := lastID
+	for i := len(events) - 1; i >= 0; i-- {
+		ev := events[i]
+		id, _ := strconv.ParseInt(ev.GetID(), 10, 64)
+		if id <= lastID {
+			continue
+		}
+		if ev.GetCreatedAt().Time.Before(since) {
+			newest = max(newest, id)
+			continue
+		}
+
+		event := p.translateEvent(owner, name, ev)
+		if event != nil && !p.isProcessed(event) {
+			select {
+			case p.eventsCh <- event:
+				count++
+				logger.Debug().Str("repo", key).Str("type", event.Type).Str("event_id", ev.GetID()).Msg("New repository event detected")
+			default:
+				// Stop here so the event is picked up again next time
+				logger.Warn().Msg("Event channel full")
+				p.advance(owner, name, "events", polledAt, strconv.FormatInt(newest, 10))
+				return count, true
+			}
+		}
+		newest = max(newest, id)
+	}
+	p.advance(owner, name, "events", polledAt, strconv.FormatInt(newest, 10))
+	return count, true
+}
+
+// isProcessed reports whether all keys of an event were recorded as processed.
+func (p *Poller) isProcessed(event *WebhookEvent) bool {
+	_, push := event.Payload.(*PushEvent)
+	for _, key := range EventKeys(event) {
+		processed, _ := p.store.IsEventProcessed(event.RepoOwner, event.RepoName, event.Type, key)
+		if !processed && push {
+			processed, _ = p.store.IsEventProcessed(event.RepoOwner, event.RepoName, event.Type, PushKeySHA(key))
+		}
+		if !processed {
+			return false
+		}
+	}
+	return true
+}
+
+// translateEvent converts an entry of the events API to the event a webhook
+// would have delivered, or nil for events that are not notified.
+func (p *Poller) translateEvent(owner, name string, ev *gh.Event) *WebhookEvent {
+	raw, err := ev.ParsePayload()
+	if err != nil {
+		logger.Debug().Err(err).Str("type", ev.GetType()).Msg("Failed to parse repository event")
+		return nil
+	}
+
+	repoURL := fmt.Sprintf("https://github.com/%s/%s", owner, name)
+	actor := UserInfo{
+		Login:     ev.GetActor().GetLogin(),
+		AvatarURL: ev.GetActor().GetAvatarURL(),
+		URL:       "https://github.com/" + ev.GetActor().GetLogin(),
+	}
+
+	var eventType string
+	var payload interface{}
+	switch e := raw.(type) {
+	case *gh.PushEvent:
+		if len(e.Commits) == 0 {
+			return nil
+		}
+		commits := make([]CommitInfo, len(e.Commits))
+		for i, c := range e.Commits {
+			commits[i] = CommitInfo{
+				SHA:     c.GetSHA(),
+				Message: c.GetMessage(),
+				URL:     repoURL + "/commit/" + c.GetSHA(),
+				Author:  UserInfo{Login: c.GetAuthor().GetName()},
+			}
+		}
+		eventType = "push"
+		payload = &PushEvent{
+			Ref:     e.GetRef(),
+			Before:  e.GetBefore(),
+			After:   e.GetHead(),
+			Compare: fmt.Sprintf("%s/compare/%s...%s", repoURL, shortSHA(e.GetBefore()), shortSHA(e.GetHead())),
+			Pusher:  actor,
+			Commits: commits,
+		}
+
+	case *gh.IssuesEvent:
+		switch e.GetAction() {
+		case "opened", "closed", "reopened":
+		default:
+			return nil
+		}
+		issue := e.GetIssue()
+		labels := make([]string, len(issue.Labels))
+		for i, l := range issue.Labels {
+			labels[i] = l.GetName()
+		}
+		eventType = "issues"
+		payload = &IssueEvent{
+			Action: e.GetAction(),
+			Number: issue.GetNumber(),
+			Title:  issue.GetTitle(),
+			Body:   issue.GetBody(),
+			State:  issue.GetState(),
+			URL:    issue.GetHTMLURL(),
+			User:   UserInfo{Login: issue.GetUser().GetLogin(), AvatarURL: issue.GetUser().GetAvatarURL(), URL: issue.GetUser().GetHTMLURL()},
+			Labels: labels,
+		}
+
+	case *gh.PullRequestEvent:
+		switch e.GetAction() {
+		case "opened", "closed", "reopened":
+		default:
+			return nil
+		}
+		pr := e.GetPullRequest()
+		var mergedBy *UserInfo
+		if pr.GetMerged() {
+			mergedBy = &UserInfo{Login: pr.GetMergedBy().GetLogin()}
+		}
+		eventType = "pull_request"
+		payload = &PullRequestEvent{
+			Action:    e.GetAction(),
+			Number:    pr.GetNumber(),
+			Title:     pr.GetTitle(),
+			Body:      pr.GetBody(),
+			State:     pr.GetState(),
+			URL:       pr.GetHTMLURL(),
+			User:      UserInfo{Login: pr.GetUser().GetLogin(), AvatarURL: pr.GetUser().GetAvatarURL(), URL: pr.GetUser().GetHTMLURL()},
+			Merged:    pr.GetMerged(),
+			MergedBy:  mergedBy,
+			Labels:    prLabels(pr),
+			Additions: pr.GetAdditions(),
+			Deletions: pr.GetDeletions(),
+			Commits:   pr.GetCommits(),
+			Base:      BranchInfo{Ref: pr.GetBase().GetRef(), SHA: pr.GetBase().GetSHA()},
+			Head:      BranchInfo{Ref: pr.GetHead().GetRef(), SHA: pr.GetHead().GetSHA()},
+		}
+
+	case *gh.ReleaseEvent:
+		release := e.GetRelease()
+		if e.GetAction() != "published" || release.GetDraft() {
+			return nil
+		}
+		eventType = "release"
+		payload = &ReleaseEvent{
+			Action:      "published",
+			TagName:     release.GetTagName(),
+			Name:        release.GetName(),
+			Body:        release.GetBody(),
+			Prerelease:  release.GetPrerelease(),
+			URL:         release.GetHTMLURL(),
+			Author:      UserInfo{Login: release.GetAuthor().GetLogin()},
+			PublishedAt: release.GetPublishedAt().Time,
+			Assets:      convertAssets(release.Assets),
+		}
+
+	case *gh.CreateEvent:
+		// Only tags are notified, branch creation is ignored
+		if e.GetRefType() != "tag" {
+			return nil
+		}
+		eventType = "tag"
+		payload = &TagEvent{
+			Name:   e.GetRef(),
+			URL:    repoURL + "/tree/" + url.PathEscape(e.GetRef()),
+			Pusher: actor,
+		}
+
+	case *gh.PullRequestReviewEvent:
+		if e.GetAction() != "submitted" && e.GetAction() != "created" {
+			return nil
+		}
+		review := e.GetReview()
+		eventType = "pull_request_review"
+		payload = &PullRequestReviewEvent{
+			Action:   "submitted",
+			ID:       review.GetID(),
+			Number:   e.GetPullRequest().GetNumber(),
+			Title:    e.GetPullRequest().GetTitle(),
+			State:    strings.ToLower(review.GetState()),
+			Body:     review.GetBody(),
+			URL:      review.GetHTMLURL(),
+			Reviewer: actor,
+		}
+
+	case *gh.PullRequestReviewCommentEvent:
+		if e.GetAction() != "created" {
+			return nil
+		}
+		comment := e.GetComment()
+		eventType = "pull_request_review_comment"
+		payload = &PullRequestReviewCommentEvent{
+			Action: "created",
+			ID:     comment.GetID(),
+			Number: e.GetPullRequest().GetNumber(),
+			Title:  e.GetPullRequest().GetTitle(),
+			Path:   comment.GetPath(),
+			Body:   comment.GetBody(),
+			URL:    comment.GetHTMLURL(),
+			User:   actor,
+		}
+
+	case *gh.GollumEvent:
+		if len(e.Pages) == 0 {
+			return nil
+		}
+		pages := make([]WikiPage, len(e.Pages))
+		for i, page := range e.Pages {
+			pages[i] = WikiPage{
+				Name:    page.GetPageName(),
+				Title:   page.GetTitle(),
+				Action:  page.GetAction(),
+				SHA:     page.GetSHA(),
+				URL:     page.GetHTMLURL(),
+				DiffURL: page.GetHTMLURL() + "/_compare/" + page.GetSHA(),
+			}
+		}
+		eventType = "wiki"
+		payload = &WikiEvent{Pages: pages, Sender: actor}
+
+	default:
+		return nil
+	}
+
+	return &WebhookEvent{
+		Type:       eventType,
+		RepoOwner:  owner,
+		RepoName:   name,
+		Source:     "poller",
+		OccurredAt: ev.GetCreatedAt().Time,
+		DetectedAt: time.Now(),
+		Payload:    payload,
+	}
+}
+
+// shortSHA abbreviates a commit SHA for compare URLs.
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
@@ -0,0 +1,106 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// IsStarMilestone reports whether a star count is a milestone worth announcing:
+// 10, 25, 50, 100, 250, 500, 1000 and so on.
+func IsStarMilestone(stars int) bool {
+	return stars > 0 && starMilestone(stars-1, stars) == stars
+}
+
+// starMilestone returns the highest milestone in (prev, cur], or 0 if the star
+// count did not cross one.
+func starMilestone(prev, cur int) int {
+	crossed := 0
+	for base := 10; base <= cur; base *= 10 {
+		for _, m := range []int{base, base * 5 / 2, base * 5} {
+			if m > prev && m <= cur {
+				crossed = m
+			}
+		}
+	}
+	return crossed
+}
+
+// pollStars checks the star count of a repository if any subscriber wants star
+// events, and emits an event when it crosses a milestone.
+func (p *Poller) pollStars(ctx context.Context, owner, name string, state *storage.RepoState) int {
+	if state == nil || !p.wantsEvent(owner, name, storage.EventTypeStar) {
+		return 0
+	}
+
+	info, err := p.client.GetRepository(ctx, owner, name)
+	if err != nil {
+		p.fetchFailed(err, owner+"/"+name, "Failed to fetch star count")
+		return 0
+	}
+
+	stars := info.Stars
+	if stars == state.Stargazers {
+		return 0
+	}
+	if err := p.store.SetRepoStargazers(owner, name, stars); err != nil {
+		logger.Warn().Err(err).Str("repo", owner+"/"+name).Msg("Failed to record star count")
+	}
+
+	// The first check only records the current count
+	if state.Stargazers < 0 {
+		return 0
+	}
+
+	milestone := starMilestone(state.Stargazers, stars)
+	if milestone == 0 {
+		return 0
+	}
+
+	event := &WebhookEvent{
+		Type:       "star",
+		RepoOwner:  owner,
+		RepoName:   name,
+		Source:     "poller",
+		DetectedAt: time.Now(),
+		Payload: &StarEvent{
+			Action:    "milestone",
+			Stars:     stars,
+			Milestone: milestone,
+			URL:       fmt.Sprintf("%s/stargazers", info.URL),
+		},
+	}
+
+	select {
+	case p.eventsCh <- event:
+		logger.Debug().Str("repo", owner+"/"+name).Int("milestone", milestone).Msg("Star milestone detected")
+		return 1
+	default:
+		logger.Warn().Msg("Event channel full")
+	}
+	return 0
+}
+
+// wantsEvent reports whether any subscription of a repository has an event type enabled.
+func (p *Poller) wantsEvent(owner, name string, eventType storage.EventType) bool {
+	subs, err := p.store.GetSubscriptionsByRepo(owner, name)
+	if err != nil {
+		logger.Debug().Err(err).Str("repo", owner+"/"+name).Msg("Failed to get subscriptions")
+		return false
+	}
+	for _, sub := range subs {
+		events, err := storage.ParseEvents(sub.Events)
+		if err != nil {
+			continue
+		}
+		for _, e := range events {
+			if e == eventType {
+				return true
+			}
+		}
+	}
+	return false
+}
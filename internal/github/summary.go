@@ -0,0 +1,73 @@
+package github
+
+import (
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// Summarize returns a one-line MarkdownV2 summary of an event payload, used when
+// several events are delivered together.
+func Summarize(payload interface{}, lang i18n.Lang) string {
+	switch e := payload.(type) {
+	case *PushEvent:
+		key := "summary.push"
+		if len(e.Commits) == 1 {
+			key = "summary.push_one"
+		}
+		text := i18n.T(lang, key, len(e.Commits), extractBranchName(e.Ref))
+		if len(e.Commits) > 0 {
+			text += ": " + markdown.Escape(truncateString(firstLine(e.Commits[len(e.Commits)-1].Message), 50))
+		}
+		return linkSummary(text, e.Compare)
+	case *ReleaseEvent:
+		name := e.Name
+		if name == "" {
+			name = e.TagName
+		}
+		return linkSummary(i18n.T(lang, "summary.release", name), e.URL)
+	case *TagEvent:
+		return linkSummary(i18n.T(lang, "summary.tag", e.Name), e.URL)
+	case *PackageEvent:
+		return linkSummary(markdown.Sprintf("%s `%s`", e.Name, e.Version), e.URL)
+	case *IssueEvent:
+		action := translate(lang, "action", e.Action)
+		return linkSummary(markdown.Sprintf("#%d %s: %s", e.Number, action, truncateString(e.Title, 60)), e.URL)
+	case *PullRequestEvent:
+		action := e.Action
+		if action == "closed" && e.Merged {
+			action = "merged"
+		}
+		action = translate(lang, "action", action)
+		return linkSummary(markdown.Sprintf("#%d %s: %s", e.Number, action, truncateString(e.Title, 60)), e.URL)
+	case *PullRequestReviewEvent:
+		state := translate(lang, "action", e.State)
+		return linkSummary(i18n.T(lang, "summary.review", e.Number, e.Reviewer.Login, state), e.URL)
+	case *PullRequestReviewCommentEvent:
+		return linkSummary(i18n.T(lang, "summary.review_comment", e.Number, e.User.Login), e.URL)
+	case *WorkflowRunEvent:
+		conclusion := translate(lang, "conclusion", e.Conclusion)
+		return linkSummary(i18n.T(lang, "summary.workflow", e.Name, conclusion, e.Branch), e.URL)
+	case *DeploymentEvent:
+		state := translate(lang, "deployment_state", e.State)
+		return linkSummary(markdown.Sprintf("%s: %s", e.Environment, state), e.URL)
+	case *WikiEvent:
+		return i18n.T(lang, "summary.wiki", len(e.Pages), e.Sender.Login)
+	case *StarEvent:
+		if e.Milestone > 0 {
+			return i18n.T(lang, "summary.milestone", e.Milestone)
+		}
+		return i18n.T(lang, "summary.star", e.User.Login, e.Stars)
+	case *RepositoryEvent:
+		return linkSummary(i18n.T(lang, "summary.repository", translate(lang, "action", e.Action)), e.URL)
+	default:
+		return i18n.T(lang, "summary.event")
+	}
+}
+
+// linkSummary appends a link to a summary line when the URL is usable.
+func linkSummary(text, rawURL string) string {
+	if SanitizeURL(rawURL) == "" {
+		return text
+	}
+	return text + " " + MarkdownLink("→", rawURL)
+}
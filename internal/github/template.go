@@ -0,0 +1,290 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+// MaxTemplateLength caps the size of a custom message template.
+const MaxTemplateLength = 1000
+
+const (
+	// maxTemplateOutput caps the text a template renders, a Telegram message
+	// being at most 4096 characters.
+	maxTemplateOutput = 4096
+	// maxRangeDepth caps how deeply range actions nest, as each level
+	// multiplies the work of rendering.
+	maxRangeDepth = 2
+)
+
+// errTemplateOutput aborts rendering a template whose output grew too long.
+var errTemplateOutput = fmt.Errorf("template output is longer than %d bytes", maxTemplateOutput)
+
+// limitedWriter collects rendered text, failing once it exceeds
+// maxTemplateOutput, which stops the template being executed.
+type limitedWriter struct {
+	strings.Builder
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > maxTemplateOutput {
+		return 0, errTemplateOutput
+	}
+	return w.Builder.Write(p)
+}
+
+// templateFuncs are the functions available in custom message templates.
+var templateFuncs = template.FuncMap{
+	"truncate":  func(n int, s string) string { return truncateString(s, n) },
+	"firstLine": firstLine,
+	"join":      func(sep string, list []string) string { return strings.Join(list, sep) },
+	"upper":     strings.ToUpper,
+	"lower":     strings.ToLower,
+}
+
+// samplePayloads holds an empty payload per event type, used to check that
+// a template only refers to fields the event type provides.
+var samplePayloads = map[storage.EventType]interface{}{
+	storage.EventTypePush:                     &PushEvent{},
+	storage.EventTypeRelease:                  &ReleaseEvent{},
+	storage.EventTypeTag:                      &TagEvent{},
+	storage.EventTypePackage:                  &PackageEvent{},
+	storage.EventTypeIssue:                    &IssueEvent{},
+	storage.EventTypePullRequest:              &PullRequestEvent{},
+	storage.EventTypeStar:                     &StarEvent{},
+	storage.EventTypeWorkflowRun:              &WorkflowRunEvent{},
+	storage.EventTypeDeployment:               &DeploymentEvent{},
+	storage.EventTypeWiki:                     &WikiEvent{},
+	storage.EventTypePullRequestReview:        &PullRequestReviewEvent{},
+	storage.EventTypePullRequestReviewComment: &PullRequestReviewCommentEvent{},
+}
+
+// TemplateData returns the fields a custom message template can use for an
+// event payload. Every event provides Repo and URL.
+func TemplateData(repoOwner, repoName string, payload interface{}) map[string]interface{} {
+	data := map[string]interface{}{
+		"Repo": repoOwner + "/" + repoName,
+		"URL":  EventURL(payload),
+	}
+
+	switch e := payload.(type) {
+	case *PushEvent:
+		messages := make([]string, len(e.Commits))
+		for i, c := range e.Commits {
+			messages[i] = firstLine(c.Message)
+		}
+		data["Pusher"] = e.Pusher.Login
+		data["Branch"] = extractBranchName(e.Ref)
+		data["Commits"] = len(e.Commits)
+		data["Messages"] = messages
+	case *ReleaseEvent:
+		data["Tag"] = e.TagName
+		data["Name"] = e.Name
+		data["Body"] = e.Body
+		data["Author"] = e.Author.Login
+		data["Prerelease"] = e.Prerelease
+	case *TagEvent:
+		data["Tag"] = e.Name
+		data["SHA"] = e.SHA
+		data["Pusher"] = e.Pusher.Login
+	case *PackageEvent:
+		data["Name"] = e.Name
+		data["Version"] = e.Version
+		data["Ecosystem"] = e.Ecosystem
+		data["Publisher"] = e.Publisher.Login
+	case *IssueEvent:
+		data["Number"] = e.Number
+		data["Action"] = e.Action
+		data["Title"] = e.Title
+		data["Author"] = e.User.Login
+		data["Labels"] = e.Labels
+	case *PullRequestEvent:
+		data["Number"] = e.Number
+		data["Action"] = e.Action
+		data["Title"] = e.Title
+		data["Author"] = e.User.Login
+		data["Labels"] = e.Labels
+		data["Head"] = e.Head.Ref
+		data["Base"] = e.Base.Ref
+		data["Merged"] = e.Merged
+	case *StarEvent:
+		data["User"] = e.User.Login
+		data["Stars"] = e.Stars
+		data["Milestone"] = e.Milestone
+	case *WorkflowRunEvent:
+		data["Name"] = e.Name
+		data["Title"] = e.Title
+		data["Branch"] = e.Branch
+		data["Conclusion"] = e.Conclusion
+		data["Run"] = e.RunNumber
+		data["Actor"] = e.Actor.Login
+	case *DeploymentEvent:
+		data["Environment"] = e.Environment
+		data["State"] = e.State
+		data["Ref"] = e.Ref
+		data["Creator"] = e.Creator.Login
+		data["Description"] = e.Description
+	case *WikiEvent:
+		titles := make([]string, len(e.Pages))
+		for i, page := range e.Pages {
+			titles[i] = page.Title
+		}
+		data["Sender"] = e.Sender.Login
+		data["Pages"] = titles
+	case *PullRequestReviewEvent:
+		data["Number"] = e.Number
+		data["Title"] = e.Title
+		data["State"] = e.State
+		data["Reviewer"] = e.Reviewer.Login
+		data["Body"] = e.Body
+	case *PullRequestReviewCommentEvent:
+		data["Number"] = e.Number
+		data["Title"] = e.Title
+		data["Author"] = e.User.Login
+		data["Path"] = e.Path
+		data["Body"] = e.Body
+	}
+	return data
+}
+
+// TemplateFields lists the fields available in templates for an event type.
+func TemplateFields(event storage.EventType) []string {
+	var fields []string
+	for name := range TemplateData("", "", samplePayloads[event]) {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// ValidateTemplate checks that a custom message template parses and only
+// uses fields available for the event type.
+func ValidateTemplate(event storage.EventType, text string) error {
+	sample, ok := samplePayloads[event]
+	if !ok {
+		return fmt.Errorf("templates are not supported for %s events", event)
+	}
+	tmpl, err := parseTemplate(event, text)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(io.Discard, TemplateData("", "", sample))
+}
+
+// RenderTemplate renders a custom message template for an event payload as
+// plain text.
+func RenderTemplate(event storage.EventType, text, repoOwner, repoName string, payload interface{}) (string, error) {
+	tmpl, err := parseTemplate(event, text)
+	if err != nil {
+		return "", err
+	}
+	var b limitedWriter
+	if err := tmpl.Execute(&b, TemplateData(repoOwner, repoName, payload)); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// parseTemplate parses a custom message template, rejecting unknown fields.
+func parseTemplate(event storage.EventType, text string) (*template.Template, error) {
+	if len(text) > MaxTemplateLength {
+		return nil, fmt.Errorf("template is longer than %d characters", MaxTemplateLength)
+	}
+	tmpl, err := template.New(string(event)).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, checkTemplate(tmpl)
+}
+
+// ValidatePayloadTemplate checks that a custom hook template parses. Its
+// fields depend on the JSON posted, so they are only checked when rendering.
+func ValidatePayloadTemplate(text string) error {
+	_, err := parsePayloadTemplate(text)
+	return err
+}
+
+// RenderPayloadTemplate renders a custom hook template for a JSON payload as
+// plain text. Objects are accessed by key, e.g. {{.monitor.name}}.
+func RenderPayloadTemplate(text string, payload interface{}) (string, error) {
+	tmpl, err := parsePayloadTemplate(text)
+	if err != nil {
+		return "", err
+	}
+	var b limitedWriter
+	if err := tmpl.Execute(&b, payload); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// parsePayloadTemplate parses a custom hook template.
+func parsePayloadTemplate(text string) (*template.Template, error) {
+	if len(text) > MaxTemplateLength {
+		return nil, fmt.Errorf("template is longer than %d characters", MaxTemplateLength)
+	}
+	tmpl, err := template.New("hook").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, checkTemplate(tmpl)
+}
+
+// checkTemplate rejects templates whose rendering could take unbounded
+// work: ranges nested more than maxRangeDepth deep, ranges over a number and
+// calls to other templates, which could recurse.
+func checkTemplate(tmpl *template.Template) error {
+	if len(tmpl.Templates()) > 1 {
+		return errors.New("templates cannot define other templates")
+	}
+	return checkNode(tmpl.Tree.Root, 0)
+}
+
+// checkNode applies checkTemplate to a node of a template, inside depth
+// range actions.
+func checkNode(node parse.Node, depth int) error {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := checkNode(child, depth); err != nil {
+				return err
+			}
+		}
+	case *parse.RangeNode:
+		if depth == maxRangeDepth {
+			return fmt.Errorf("range actions cannot be nested more than %d deep", maxRangeDepth)
+		}
+		if cmds := n.Pipe.Cmds; len(cmds) == 1 && len(cmds[0].Args) == 1 {
+			if _, ok := cmds[0].Args[0].(*parse.NumberNode); ok {
+				return errors.New("range over a number is not supported")
+			}
+		}
+		if err := checkNode(n.List, depth+1); err != nil {
+			return err
+		}
+		return checkNode(n.ElseList, depth)
+	case *parse.IfNode:
+		if err := checkNode(n.List, depth); err != nil {
+			return err
+		}
+		return checkNode(n.ElseList, depth)
+	case *parse.WithNode:
+		if err := checkNode(n.List, depth); err != nil {
+			return err
+		}
+		return checkNode(n.ElseList, depth)
+	case *parse.TemplateNode:
+		return errors.New("templates cannot include other templates")
+	}
+	return nil
+}
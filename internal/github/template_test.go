@@ -0,0 +1,68 @@
+package github
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	valid := []string{
+		"",
+		"{{.Repo}}: {{.Title}}",
+		"{{range .Labels}}{{.}} {{end}}",
+		"{{range .Labels}}{{range $.Labels}}{{.}}{{end}}{{end}}",
+		"{{if .Merged}}{{range .Labels}}{{.}}{{end}}{{else}}{{range .Labels}}{{.}}{{end}}{{end}}",
+	}
+	for _, text := range valid {
+		if err := ValidateTemplate(storage.EventTypePullRequest, text); err != nil {
+			t.Errorf("ValidateTemplate(%q) = %v", text, err)
+		}
+	}
+
+	// Checked for custom hook templates as well
+	unbounded := []string{
+		"{{range .Labels}}{{range .Labels}}{{range .Labels}}x{{end}}{{end}}{{end}}",
+		"{{range .Labels}}{{with .}}{{range $.Labels}}{{if .}}{{range $.Labels}}x{{end}}{{end}}{{end}}{{end}}{{end}}",
+		"{{range 1000000000}}{{end}}",
+		`{{define "x"}}{{template "x" .}}{{end}}{{template "x" .}}`,
+		`{{block "x" .}}{{.Title}}{{end}}`,
+		strings.Repeat("x", MaxTemplateLength+1),
+	}
+	for _, text := range unbounded {
+		if err := ValidateTemplate(storage.EventTypePullRequest, text); err == nil {
+			t.Errorf("ValidateTemplate(%q) accepted", text)
+		}
+		if err := ValidatePayloadTemplate(text); err == nil {
+			t.Errorf("ValidatePayloadTemplate(%q) accepted", text)
+		}
+	}
+	if err := ValidateTemplate(storage.EventTypePullRequest, "{{.Missing}}"); err == nil {
+		t.Error("ValidateTemplate() accepted an unknown field")
+	}
+}
+
+func TestRenderTemplateOutputLimit(t *testing.T) {
+	labels := make([]string, 100)
+	for i := range labels {
+		labels[i] = strings.Repeat("l", 50)
+	}
+	payload := &PullRequestEvent{Number: 1, Title: "Add feature", Labels: labels}
+
+	// Within the nesting limit, the output still grows with the square of
+	// the list
+	text := "{{range .Labels}}{{range $.Labels}}{{.}}{{end}}{{end}}"
+	if _, err := RenderTemplate(storage.EventTypePullRequest, text, "acme", "app", payload); !errors.Is(err, errTemplateOutput) {
+		t.Errorf("RenderTemplate() error = %v, want %v", err, errTemplateOutput)
+	}
+	if _, err := RenderPayloadTemplate(text, map[string]interface{}{"Labels": labels}); !errors.Is(err, errTemplateOutput) {
+		t.Errorf("RenderPayloadTemplate() error = %v, want %v", err, errTemplateOutput)
+	}
+
+	got, err := RenderTemplate(storage.EventTypePullRequest, "{{.Repo}}#{{.Number}} {{.Title}}", "acme", "app", payload)
+	if err != nil || got != "acme/app#1 Add feature" {
+		t.Errorf("RenderTemplate() = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,305 @@
+package github
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Each token has a quota of its own. Requests take turns between the
+// configured tokens, skipping those that ran out until their quota resets, so
+// that several tokens multiply the requests available per hour.
+
+// Requests for a repository only a user's own account can read carry that
+// user's token in their context instead. Such a token is not part of the
+// pool: it is used for nothing else, and its quota is tracked apart.
+
+// contextTokenKey is the context key of a user's token.
+type contextTokenKey struct{}
+
+// WithToken returns a context whose API requests are authenticated with the
+// given token instead of the configured ones.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, contextTokenKey{}, token)
+}
+
+// contextToken returns the token set with WithToken, if any.
+func contextToken(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(contextTokenKey{}).(string)
+	return token, ok && token != ""
+}
+
+// tokenState is a token and the rate limit state last reported for it.
+type tokenState struct {
+	token  string // Empty for unauthenticated requests
+	status RateStatus
+}
+
+// available reports whether the token may be used at the given time.
+func (t *tokenState) available(now time.Time) bool {
+	if t.status.Paused(now) {
+		return false
+	}
+	return t.status.Limit == 0 || t.status.Remaining > 0 || !now.Before(t.status.Reset)
+}
+
+// tokenTransport authenticates requests with a pool of tokens and records
+// the rate limit state of each.
+type tokenTransport struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	tokens []*tokenState
+	next   int                    // Index of the token whose turn is next
+	users  map[string]*tokenState // Tokens of requests made WithToken
+}
+
+// newTokenTransport creates a transport rotating across the given tokens.
+// Empty and repeated tokens are ignored; without any, requests are sent
+// unauthenticated.
+func newTokenTransport(base http.RoundTripper, tokens []string) *tokenTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &tokenTransport{base: base}
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if token == "" || seen[token] {
+			continue
+		}
+		seen[token] = true
+		t.tokens = append(t.tokens, &tokenState{token: token})
+	}
+	if len(t.tokens) == 0 {
+		t.tokens = []*tokenState{{}}
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper. A request refused for the rate
+// limit of its token is sent again with the next token that has quota left.
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if token, ok := contextToken(req.Context()); ok {
+		return t.roundTripUser(req, token)
+	}
+	for attempt := 1; ; attempt++ {
+		state := t.pick(time.Now())
+		out := req
+		if state.token != "" {
+			out = req.Clone(req.Context())
+			out.Header.Set("Authorization", "Bearer "+state.token)
+		}
+		if attempt > 1 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			out.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(out)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		t.mu.Lock()
+		state.status.record(resp, now)
+		limited := !state.available(now)
+		t.mu.Unlock()
+
+		retry := limited && attempt < len(t.tokens) && t.anyAvailable(now) &&
+			(req.Body == nil || req.GetBody != nil) &&
+			(resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests)
+		if !retry {
+			t.reportCombined(resp, now)
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// roundTripUser sends a request with a user's token, which has nothing to
+// rotate to. The rate limit headers of the response are replaced with the
+// quota of the pool, as go-github would otherwise refuse requests made with
+// the configured tokens once the user's quota runs out.
+func (t *tokenTransport) roundTripUser(req *http.Request, token string) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.Header.Set("Authorization", "Bearer "+token)
+	resp, err := t.base.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t.mu.Lock()
+	state := t.userState(token)
+	state.status.record(resp, now)
+	t.mu.Unlock()
+
+	// A pause the user's token was given is recorded above and must not hold
+	// off the whole client either
+	resp.Header.Del("Retry-After")
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return resp, nil
+	}
+	for _, key := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"} {
+		resp.Header.Del(key)
+	}
+	if total := t.combined(now); total.Limit > 0 {
+		resp.Header.Set("X-RateLimit-Limit", strconv.Itoa(total.Limit))
+		resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(total.Remaining))
+		if !total.Reset.IsZero() {
+			resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(total.Reset.Unix(), 10))
+		}
+	}
+	return resp, nil
+}
+
+// userState returns the state of a user's token, creating it on first use.
+// The caller must hold mu.
+func (t *tokenTransport) userState(token string) *tokenState {
+	if t.users == nil {
+		t.users = make(map[string]*tokenState)
+	}
+	state, ok := t.users[token]
+	if !ok {
+		state = &tokenState{token: token}
+		t.users[token] = state
+	}
+	return state
+}
+
+// userStatus returns the rate limit state last reported for a user's token.
+func (t *tokenTransport) userStatus(token string) RateStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state, ok := t.users[token]; ok {
+		return state.status
+	}
+	return RateStatus{}
+}
+
+// anyAvailable reports whether some token has quota left.
+func (t *tokenTransport) anyAvailable(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range t.tokens {
+		if state.available(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportCombined replaces the rate limit headers of a response with the
+// quota of all tokens together. go-github refuses further requests on its own
+// once a response reports no quota left, which should only happen when no
+// token has any.
+func (t *tokenTransport) reportCombined(resp *http.Response, now time.Time) {
+	if len(t.tokens) < 2 || resp.Header.Get("X-RateLimit-Limit") == "" {
+		return
+	}
+	if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" && resource != "core" {
+		return
+	}
+	total := t.combined(now)
+	if total.Limit == 0 {
+		return
+	}
+	resp.Header.Set("X-RateLimit-Limit", strconv.Itoa(total.Limit))
+	resp.Header.Set("X-RateLimit-Remaining", strconv.Itoa(total.Remaining))
+	if !total.Reset.IsZero() {
+		resp.Header.Set("X-RateLimit-Reset", strconv.FormatInt(total.Reset.Unix(), 10))
+	}
+}
+
+// pick returns the next token in turn that has quota left. If all ran out,
+// it returns the one available again first.
+func (t *tokenTransport) pick(now time.Time) *tokenState {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := range t.tokens {
+		index := (t.next + i) % len(t.tokens)
+		if t.tokens[index].available(now) {
+			t.next = index + 1
+			return t.tokens[index]
+		}
+	}
+
+	first := t.tokens[0]
+	for _, state := range t.tokens[1:] {
+		if state.availableAt().Before(first.availableAt()) {
+			first = state
+		}
+	}
+	return first
+}
+
+// availableAt returns when an exhausted token may be used again.
+func (t *tokenState) availableAt() time.Time {
+	if t.status.PausedUntil.After(t.status.Reset) {
+		return t.status.PausedUntil
+	}
+	return t.status.Reset
+}
+
+// pause holds off the token a request was sent with.
+func (t *tokenTransport) pause(req *http.Request, until time.Time) {
+	if req == nil {
+		return
+	}
+	auth := req.Header.Get("Authorization")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, state := range t.tokens {
+		if state.token == "" && auth == "" || state.token != "" && auth == "Bearer "+state.token {
+			state.status.pause(until)
+		}
+	}
+	if token, ok := strings.CutPrefix(auth, "Bearer "); ok && t.users[token] != nil {
+		t.users[token].status.pause(until)
+	}
+}
+
+// combined returns the rate limit state of all tokens together: their
+// summed quota, the earliest time one resets, and a pause only while no
+// token is available.
+func (t *tokenTransport) combined(now time.Time) RateStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var total RateStatus
+	paused := true
+	for _, state := range t.tokens {
+		s := state.status
+		if s.Limit > 0 {
+			total.Limit += s.Limit
+			if now.Before(s.Reset) {
+				total.Remaining += s.Remaining
+				if total.Reset.IsZero() || s.Reset.Before(total.Reset) {
+					total.Reset = s.Reset
+				}
+			} else {
+				total.Remaining += s.Limit // A fresh window has started
+			}
+		}
+
+		if !s.Paused(now) {
+			paused = false
+		} else if total.PausedUntil.IsZero() || s.PausedUntil.Before(total.PausedUntil) {
+			total.PausedUntil = s.PausedUntil
+		}
+	}
+	if !paused {
+		total.PausedUntil = time.Time{}
+	}
+	return total
+}
@@ -0,0 +1,163 @@
+package github
+
+import (
+	"fmt"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Followed topics are checked with the search API for repositories updated
+// since the last check, which finds both new repositories and existing ones
+// that just added the topic. Their watermarks are kept with the repository
+// ones, under an empty owner, which no repository has. The repositories found
+// are recorded under the topic by ID, so each is announced once, even if it
+// is renamed or updated again.
+//
+// A topic checked for the first time only records the repositories found, so
+// existing ones are not announced. So does the first check of a topic
+// followed before repositories were recorded by ID, except for repositories
+// created since its last check.
+
+// topicCheckInterval is how often each followed topic is searched.
+const topicCheckInterval = time.Hour
+
+// topicOverlap is how far before its watermark a topic is searched again.
+// The search index lags behind, so repositories can show up hours after they
+// changed. Repositories seen twice are recognized by their processed-event
+// keys.
+const topicOverlap = 24 * time.Hour
+
+// topicResultsPerPage is how many repositories are fetched per page, and
+// topicMaxPages how many pages at most, the search API returning at most
+// 1000 results.
+const (
+	topicResultsPerPage = 100
+	topicMaxPages       = 10
+)
+
+// topicSeenByID marks the watermarks of topics whose repositories are
+// recorded by ID, see TopicRepoKey.
+const topicSeenByID = "repo-ids"
+
+// checkTopics searches the followed topics that are due for updated
+// repositories.
+func (p *Poller) checkTopics() {
+	topics, err := p.store.GetSubscribedTopics()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to get subscribed topics")
+		return
+	}
+
+	now := time.Now()
+	for _, topic := range topics {
+		w := p.watermark("", topic, "topic")
+		if w != nil && w.LastID == topicSeenByID && now.Sub(w.SeenAt) < topicCheckInterval {
+			continue
+		}
+		if p.ctx.Err() != nil {
+			return
+		}
+
+		// Checked for the first time, only record what is there
+		since, quietBefore := now.Add(-topicOverlap), now
+		if w != nil {
+			since, quietBefore = w.SeenAt.Add(-topicOverlap), time.Time{}
+			if w.LastID != topicSeenByID {
+				quietBefore = since
+			}
+		}
+		if !p.checkTopic(topic, since, quietBefore) {
+			return
+		}
+	}
+}
+
+// checkTopic notifies the repositories of a topic updated since the given
+// time that were not announced yet. Those created before quietBefore are
+// only recorded. It reports false if the search failed for exceeding the
+// rate limit.
+func (p *Poller) checkTopic(topic string, since, quietBefore time.Time) bool {
+	polledAt := time.Now()
+	query := fmt.Sprintf("topic:%s fork:false", topic)
+	opts := &gh.SearchOptions{Sort: "updated", Order: "desc", ListOptions: gh.ListOptions{PerPage: topicResultsPerPage}}
+
+	count := 0
+	for page := 0; page < topicMaxPages; page++ {
+		result, resp, err := p.client.client.Search.Repositories(p.ctx, query, opts)
+		if err != nil {
+			p.fetchFailed(err, "topic:"+topic, "Failed to search topic repositories")
+			return !isRateLimitError(err)
+		}
+
+		for _, r := range result.Repositories {
+			if r.GetUpdatedAt().Before(since) {
+				return p.checkedTopic(topic, polledAt, count)
+			}
+			if r.GetFork() || r.GetPrivate() {
+				continue
+			}
+			key := TopicRepoKey(r.GetID())
+			if p.topicRepoSeen(topic, key, r) {
+				continue
+			}
+			if r.GetCreatedAt().Before(quietBefore) {
+				if err := p.store.RecordEvent("", topic, "topic_repo", key); err != nil {
+					logger.Warn().Err(err).Msg("Failed to record event")
+				}
+				continue
+			}
+
+			event := &WebhookEvent{
+				Type:       "topic_repo",
+				RepoOwner:  r.GetOwner().GetLogin(),
+				RepoName:   r.GetName(),
+				Source:     "poller",
+				OccurredAt: r.GetUpdatedAt().Time,
+				DetectedAt: time.Now(),
+				Payload: &TopicRepoEvent{
+					ID:          r.GetID(),
+					Topic:       topic,
+					Description: r.GetDescription(),
+					Language:    r.GetLanguage(),
+					Stars:       r.GetStargazersCount(),
+					URL:         r.GetHTMLURL(),
+				},
+			}
+			select {
+			case p.eventsCh <- event:
+				count++
+			default:
+				// Leave the watermark so the rest is found next time
+				logger.Warn().Msg("Event channel full")
+				return true
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return p.checkedTopic(topic, polledAt, count)
+}
+
+// checkedTopic advances the watermark of a topic checked at polledAt, in
+// which count repositories were found, and reports true.
+func (p *Poller) checkedTopic(topic string, polledAt time.Time, count int) bool {
+	if count > 0 {
+		logger.Info().Str("topic", topic).Int("repos", count).Msg("New topic repositories detected")
+	}
+	p.advance("", topic, "topic", polledAt, topicSeenByID)
+	return true
+}
+
+// topicRepoSeen reports whether a repository of a topic was recorded, by ID
+// or by name as earlier versions did.
+func (p *Poller) topicRepoSeen(topic, key string, r *gh.Repository) bool {
+	if processed, _ := p.store.IsEventProcessed("", topic, "topic_repo", key); processed {
+		return true
+	}
+	processed, _ := p.store.IsEventProcessed(r.GetOwner().GetLogin(), r.GetName(), "topic_repo", "topic-"+topic)
+	return processed
+}
@@ -0,0 +1,160 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/internal/storage"
+)
+
+// topicSearch is a search API serving the repositories of topics, two per
+// page, most recently updated first.
+type topicSearch struct {
+	server *httptest.Server
+
+	mu    sync.Mutex
+	repos map[string][]*gh.Repository // By topic
+}
+
+func newTopicSearch(t *testing.T) *topicSearch {
+	t.Helper()
+	s := &topicSearch{repos: make(map[string][]*gh.Repository)}
+	s.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/repositories" || r.URL.Query().Get("sort") != "updated" {
+			http.NotFound(w, r)
+			return
+		}
+		topic, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Query().Get("q"), "topic:"), " ")
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+
+		s.mu.Lock()
+		repos := append([]*gh.Repository(nil), s.repos[topic]...)
+		s.mu.Unlock()
+		sort.Slice(repos, func(i, j int) bool { return repos[i].GetUpdatedAt().After(repos[j].GetUpdatedAt().Time) })
+		start, end := min(2*(page-1), len(repos)), min(2*page, len(repos))
+		if end < len(repos) {
+			w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/search/repositories?page=%d>; rel="next"`, page+1))
+		}
+		json.NewEncoder(w).Encode(gh.RepositoriesSearchResult{Total: gh.Int(len(repos)), Repositories: repos[start:end]})
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// add lists a repository under a topic, replacing the one of the same ID.
+func (s *topicSearch) add(topic string, id int64, fullName string, created, updated time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	owner, name, _ := strings.Cut(fullName, "/")
+	repo := &gh.Repository{
+		ID:        gh.Int64(id),
+		Name:      gh.String(name),
+		Owner:     &gh.User{Login: gh.String(owner)},
+		CreatedAt: &gh.Timestamp{Time: created},
+		UpdatedAt: &gh.Timestamp{Time: updated},
+	}
+	for i, r := range s.repos[topic] {
+		if r.GetID() == id {
+			s.repos[topic][i] = repo
+			return
+		}
+	}
+	s.repos[topic] = append(s.repos[topic], repo)
+}
+
+// announced runs a topic check and returns the repositories announced,
+// recorded as the notifier does.
+func announced(t *testing.T, p *Poller, events chan *WebhookEvent) []string {
+	t.Helper()
+	p.checkTopics()
+	var repos []string
+	for {
+		select {
+		case event := <-events:
+			topic := event.Payload.(*TopicRepoEvent)
+			if err := p.store.RecordEvent("", topic.Topic, event.Type, TopicRepoKey(topic.ID)); err != nil {
+				t.Fatalf("RecordEvent() error = %v", err)
+			}
+			repos = append(repos, event.RepoOwner+"/"+event.RepoName)
+		default:
+			sort.Strings(repos)
+			return repos
+		}
+	}
+}
+
+func TestCheckTopics(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := storage.NewSubscriptionStore(db)
+	if err := store.CreateOrUpdateChat(1, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+	for _, topic := range []string{"cli", "go"} {
+		if err := store.SubscribeTopic(1, topic); err != nil {
+			t.Fatalf("SubscribeTopic() error = %v", err)
+		}
+	}
+
+	search := newTopicSearch(t)
+	events := make(chan *WebhookEvent, 10)
+	p := NewPoller(&Client{client: gh.NewClient(&http.Client{Transport: redirectTransport{search.server}})}, store, events, MinPollInterval)
+	defer p.Stop()
+
+	now := time.Now()
+	year := 365 * 24 * time.Hour
+	search.add("cli", 1, "acme/new", now.Add(-time.Hour), now.Add(-time.Hour))
+	search.add("cli", 2, "acme/old", now.Add(-year), now.Add(-2*time.Hour))
+	search.add("cli", 3, "acme/stale", now.Add(-year), now.Add(-30*24*time.Hour))
+
+	// Followed by an earlier version, which recorded repositories by name
+	if err := store.SetWatermark("", "go", "topic", now.Add(-2*time.Hour), ""); err != nil {
+		t.Fatalf("SetWatermark() error = %v", err)
+	}
+	if err := store.RecordEvent("acme", "legacy", "topic_repo", "topic-go"); err != nil {
+		t.Fatalf("RecordEvent() error = %v", err)
+	}
+	search.add("go", 10, "acme/legacy", now.Add(-3*time.Hour), now.Add(-3*time.Hour))
+	search.add("go", 11, "acme/fresh", now.Add(-30*time.Minute), now.Add(-30*time.Minute))
+	search.add("go", 12, "acme/ancient", now.Add(-year), now.Add(-time.Minute))
+
+	// The first check of cli only records its repositories, go announces those
+	// created since it was last checked
+	if got := announced(t, p, events); len(got) != 1 || got[0] != "acme/fresh" {
+		t.Fatalf("first check announced %q, want acme/fresh", got)
+	}
+
+	// A repository adding the topic is found by its update, a renamed one is
+	// recognized by its ID
+	for _, topic := range []string{"cli", "go"} {
+		if err := store.SetWatermark("", topic, "topic", now.Add(-2*time.Hour), topicSeenByID); err != nil {
+			t.Fatalf("SetWatermark() error = %v", err)
+		}
+	}
+	search.add("cli", 1, "acme/renamed", now.Add(-time.Hour), now)
+	search.add("cli", 4, "acme/added", now.Add(-2*year), now.Add(-10*time.Minute))
+	search.add("go", 12, "acme/ancient", now.Add(-year), now)
+	if got := announced(t, p, events); len(got) != 1 || got[0] != "acme/added" {
+		t.Errorf("second check announced %q, want acme/added", got)
+	}
+
+	// Checked again only once the interval passed
+	search.add("cli", 5, "acme/later", now, now)
+	if got := announced(t, p, events); len(got) != 0 {
+		t.Errorf("check within the interval announced %q", got)
+	}
+}
@@ -0,0 +1,150 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// A user subscription follows all public repositories of a GitHub user. The
+// first synchronization subscribes to every repository the user owns, later
+// ones only to repositories created since, so repositories the chat
+// unsubscribed from on purpose are not added back.
+
+// userSyncInterval is how often the repository lists of followed users are
+// synchronized.
+const userSyncInterval = 6 * time.Hour
+
+// maxUserRepos caps how many repositories a single user subscription adds.
+const maxUserRepos = 300
+
+// ErrUserNotFound is returned when a GitHub user does not exist.
+var ErrUserNotFound = errors.New("user not found")
+
+// UserRepo is a public repository owned by a GitHub user.
+type UserRepo struct {
+	Owner     string
+	Name      string
+	CreatedAt time.Time
+}
+
+// GetUserLogin returns the login of a GitHub user or organization with its
+// canonical capitalization.
+func (c *Client) GetUserLogin(ctx context.Context, login string) (string, error) {
+	user, resp, err := c.client.Users.Get(ctx, login)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return "", ErrUserNotFound
+		}
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.GetLogin(), nil
+}
+
+// ListUserRepos returns the public repositories a user owns, leaving out
+// forks and archived repositories.
+func (c *Client) ListUserRepos(ctx context.Context, login string) ([]UserRepo, error) {
+	opts := &gh.RepositoryListByUserOptions{
+		Type:        "owner",
+		Sort:        "created",
+		Direction:   "desc",
+		ListOptions: gh.ListOptions{PerPage: 100},
+	}
+
+	var repos []UserRepo
+	for {
+		page, resp, err := c.client.Repositories.ListByUser(ctx, login, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories: %w", err)
+		}
+		for _, r := range page {
+			if r.GetFork() || r.GetArchived() || r.GetPrivate() {
+				continue
+			}
+			repos = append(repos, UserRepo{
+				Owner:     r.GetOwner().GetLogin(),
+				Name:      r.GetName(),
+				CreatedAt: r.GetCreatedAt().Time,
+			})
+			if len(repos) == maxUserRepos {
+				return repos, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return repos, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// SyncUserRepos subscribes the chat of a user subscription to the user's
+// repositories not synchronized yet and returns how many were added.
+// Repositories beyond the subscription quotas are left out.
+func SyncUserRepos(ctx context.Context, client *Client, store storage.Store, sub storage.UserSubscription) (int, error) {
+	// Taken before listing, so repositories created meanwhile are found next
+	// time even if the listing missed them
+	syncedAt := time.Now()
+	repos, err := client.ListUserRepos(ctx, sub.Login)
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, r := range repos {
+		if sub.SyncedAt.Valid && r.CreatedAt.Before(sub.SyncedAt.Time) {
+			continue
+		}
+		ok, err := store.SubscribeVia(sub.ChatID, r.Owner, r.Name, sub.Events, storage.UserVia(sub.Login))
+		if errors.Is(err, storage.ErrChatQuotaExceeded) {
+			logger.Warn().Int64("chat_id", sub.ChatID).Str("user", sub.Login).Msg("Subscription quota reached, not following further repositories")
+			break
+		}
+		if errors.Is(err, storage.ErrRepoQuotaExceeded) {
+			continue
+		}
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, store.MarkUserSynced(sub.ID, syncedAt)
+}
+
+// syncUsers synchronizes the repository lists of followed users that are
+// due.
+func (p *Poller) syncUsers() {
+	subs, err := p.store.GetUserSubscriptions()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to get user subscriptions")
+		return
+	}
+
+	now := time.Now()
+	for _, sub := range subs {
+		if sub.SyncedAt.Valid && now.Sub(sub.SyncedAt.Time) < userSyncInterval {
+			continue
+		}
+		if p.ctx.Err() != nil {
+			return
+		}
+		added, err := SyncUserRepos(p.ctx, p.client, p.store, sub)
+		if err != nil {
+			p.fetchFailed(err, sub.Login, "Failed to sync user repositories")
+			if isRateLimitError(err) {
+				return
+			}
+			continue
+		}
+		if added > 0 {
+			logger.Info().Str("user", sub.Login).Int64("chat_id", sub.ChatID).Int("added", added).Msg("Subscribed to new repositories of user")
+		}
+	}
+}
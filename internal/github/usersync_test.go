@@ -0,0 +1,69 @@
+package github
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	gh "github.com/google/go-github/v57/github"
+	"github.com/user/githubbot/internal/storage"
+)
+
+func TestSyncUserReposCreatedWhileListing(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := storage.NewSubscriptionStore(db)
+	if err := store.CreateOrUpdateChat(1, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+	if _, err := store.SubscribeUser(1, "octocat", storage.DefaultEvents()); err != nil {
+		t.Fatalf("SubscribeUser() error = %v", err)
+	}
+
+	// The first listing misses a repository created while it runs
+	var mu sync.Mutex
+	var repos []*gh.Repository
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/octocat/repos" {
+			http.NotFound(w, r)
+			return
+		}
+		mu.Lock()
+		listed := append([]*gh.Repository(nil), repos...)
+		repos = append(repos, &gh.Repository{
+			Name:      gh.String("late"),
+			Owner:     &gh.User{Login: gh.String("octocat")},
+			CreatedAt: &gh.Timestamp{Time: time.Now()},
+		})
+		mu.Unlock()
+		json.NewEncoder(w).Encode(listed)
+	}))
+	defer server.Close()
+	client := &Client{client: gh.NewClient(&http.Client{Transport: redirectTransport{server}})}
+
+	syncRepos := func() int {
+		t.Helper()
+		subs, err := store.GetUserSubscriptions()
+		if err != nil || len(subs) != 1 {
+			t.Fatalf("GetUserSubscriptions() = %v, %v", subs, err)
+		}
+		added, err := SyncUserRepos(t.Context(), client, store, subs[0])
+		if err != nil {
+			t.Fatalf("SyncUserRepos() error = %v", err)
+		}
+		return added
+	}
+	if added := syncRepos(); added != 0 {
+		t.Fatalf("first sync added %d repositories, want 0", added)
+	}
+	if added := syncRepos(); added != 1 {
+		t.Errorf("second sync added %d repositories, want the one created during the first", added)
+	}
+}
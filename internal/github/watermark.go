@@ -0,0 +1,46 @@
+package github
+
+import (
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// The poller records per repository and kind of event up to when it has
+// checked for new events. After a restart it resumes from there, so events
+// during downtime are still notified. Only repositories never polled before
+// start from the bot start.
+
+// watermarkOverlap is how far before its watermark a repository is checked
+// again, to catch events GitHub lists with a delay. Events seen twice are
+// recognized by their processed-event keys.
+const watermarkOverlap = 10 * time.Minute
+
+// watermark returns the watermark of a repository and kind of event, or nil
+// if it was never checked.
+func (p *Poller) watermark(owner, name, kind string) *storage.Watermark {
+	w, err := p.store.GetWatermark(owner, name, kind)
+	if err != nil {
+		logger.Warn().Err(err).Str("repo", owner+"/"+name).Str("kind", kind).Msg("Failed to load watermark")
+		return nil
+	}
+	return w
+}
+
+// since returns from when to check a kind of event of a repository.
+func (p *Poller) since(owner, name, kind string) time.Time {
+	w := p.watermark(owner, name, kind)
+	if w == nil {
+		return p.startTime
+	}
+	return w.SeenAt.Add(-watermarkOverlap)
+}
+
+// advance records that a kind of event of a repository was checked up to the
+// given time.
+func (p *Poller) advance(owner, name, kind string, seenAt time.Time, lastID string) {
+	if err := p.store.SetWatermark(owner, name, kind, seenAt, lastID); err != nil {
+		logger.Warn().Err(err).Str("repo", owner+"/"+name).Str("kind", kind).Msg("Failed to save watermark")
+	}
+}
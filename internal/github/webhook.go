@@ -1,6 +1,7 @@
 package github
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -8,31 +9,204 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/user/githubbot/internal/storage"
 	"github.com/user/githubbot/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/user/githubbot/internal/github")
+
+// dispatchBatch is how many queued webhook deliveries are read at a time.
+const dispatchBatch = 50
+
+// dispatchPoll is the longest the dispatcher waits before reading the queue
+// again, should a wake-up be missed or reading fail. Claims are renewed as
+// often.
+const dispatchPoll = 10 * time.Second
+
+// dispatchClaim is how long a delivery stays claimed without renewal, after
+// which another instance hands it out, e.g. when this one crashed.
+const dispatchClaim = 2 * time.Minute
+
+// maxDeliveryAttempts is how often handling a delivery may fail before it is
+// dropped.
+const maxDeliveryAttempts = 8
+
 // WebhookHandler handles incoming GitHub webhooks.
+//
+// Deliveries are persisted before they are acknowledged, and a dispatcher
+// claims them and hands them on to the events channel in order. They are
+// deleted once the consumer calls Done, so a full channel holds webhooks back
+// instead of dropping them, and those not handled before a crash are handled
+// after the restart. The claim keeps instances sharing the database, e.g.
+// while a deploy overlaps, from handing out the same delivery twice.
 type WebhookHandler struct {
-	secret   string
-	eventsCh chan<- *WebhookEvent
+	secret      string
+	holder      string            // Identifies this instance in delivery claims
+	repoSecrets map[string]string // Keyed by lowercase "owner/repo" or "owner", see SetRepoSecrets
+	store       storage.Store
+	eventsCh    chan<- *WebhookEvent
+	wake        chan struct{} // Signals the dispatcher that a delivery was queued
+	onPing      func(ping *PingEvent)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // WebhookEvent represents a parsed webhook event.
 type WebhookEvent struct {
-	Type      string // push, release, issues, pull_request
+	Type      string // push, release, issues, pull_request, pull_request_review, star, etc.
 	RepoOwner string
 	RepoName  string
 	Payload   interface{} // PushEvent, ReleaseEvent, etc.
+
+	Source     string    // webhook or poller
+	DeliveryID string    // X-GitHub-Delivery of webhook events, unique per delivery
+	OccurredAt time.Time // When the event happened on GitHub, zero if unknown
+	DetectedAt time.Time // When the bot received or detected the event
+
+	ctx   context.Context // Carries the trace the event is handled in
+	done  func()          // Removes the webhook delivery from the queue, nil if not queued
+	retry func()          // Hands the webhook delivery out again later, nil if not queued
+}
+
+// Done tells that the event was handled. Consumers of the events channel
+// call it or Retry for every event, which removes webhook deliveries from the
+// queue.
+func (e *WebhookEvent) Done() {
+	if e.done != nil {
+		e.done()
+	}
+}
+
+// Retry tells that handling the event failed. Webhook deliveries stay queued
+// and are handed out again after a backoff, growing with every failure, until
+// they failed too often.
+func (e *WebhookEvent) Retry() {
+	if e.retry != nil {
+		e.retry()
+	}
+}
+
+// Context returns the context carrying the trace of the event, a background
+// context if it is not traced.
+func (e *WebhookEvent) Context() context.Context {
+	if e.ctx == nil {
+		return context.Background()
+	}
+	return e.ctx
+}
+
+// SetTraceContext makes the handling of the event continue the trace of the
+// span in ctx. Only the trace is kept, not the cancellation of ctx, which
+// usually ends long before the event is handled.
+func (e *WebhookEvent) SetTraceContext(ctx context.Context) {
+	e.ctx = trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
 }
 
-// NewWebhookHandler creates a new webhook handler.
-func NewWebhookHandler(secret string, eventsCh chan<- *WebhookEvent) *WebhookHandler {
+// NewWebhookHandler creates a new webhook handler queuing deliveries in the
+// store.
+func NewWebhookHandler(secret string, store storage.Store, eventsCh chan<- *WebhookEvent) *WebhookHandler {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &WebhookHandler{
 		secret:   secret,
+		holder:   instanceID(),
+		store:    store,
 		eventsCh: eventsCh,
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// PingEvent is sent by GitHub when a webhook is created, to test it.
+type PingEvent struct {
+	HookID      int64
+	Target      string // "owner/repo", or the organization of organization hooks
+	URL         string // Where the hook delivers to
+	ContentType string // json or form
+	Events      []string
+	Zen         string // A random GitHub design philosophy
+}
+
+// OnPing sets a function called in the background with the ping of every
+// webhook set up, e.g. to tell the administrators.
+func (h *WebhookHandler) OnPing(fn func(ping *PingEvent)) {
+	h.onPing = fn
+}
+
+// Start hands the queued deliveries on to the events channel in the
+// background, beginning with those left from before a restart.
+func (h *WebhookHandler) Start() {
+	h.wg.Add(1)
+	go h.dispatch()
+}
+
+// Stop stops handing deliveries on. Those not handled yet stay queued.
+func (h *WebhookHandler) Stop() {
+	h.cancel()
+	h.wg.Wait()
+}
+
+// SetRepoSecrets sets the secrets webhooks of some repositories are signed
+// with instead of the default secret, keyed by "owner/repo", or by "owner"
+// for all repositories of a user or organization. Keys are case-insensitive
+// and a repository's own secret comes before its owner's.
+func (h *WebhookHandler) SetRepoSecrets(secrets map[string]string) {
+	h.repoSecrets = make(map[string]string, len(secrets))
+	for key, secret := range secrets {
+		h.repoSecrets[strings.ToLower(key)] = secret
+	}
+}
+
+// secretFor returns the secret a delivery must be signed with, the default
+// secret unless the repository or its owner has its own. The repository is
+// looked up by the same fields parseEvent attributes the event to, so that a
+// delivery signed with one repository's secret cannot pass for another's.
+func (h *WebhookHandler) secretFor(body []byte) string {
+	if len(h.repoSecrets) == 0 {
+		return h.secret
+	}
+	var delivery struct {
+		Repository struct {
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+			Name string `json:"name"`
+		} `json:"repository"`
+		Organization struct {
+			Login string `json:"login"`
+		} `json:"organization"`
+	}
+	if err := json.Unmarshal(body, &delivery); err != nil {
+		return h.secret
+	}
+
+	owner, name := delivery.Repository.Owner.Login, delivery.Repository.Name
+	keys := []string{owner}
+	switch {
+	case owner != "" && name != "":
+		keys = []string{owner + "/" + name, owner}
+	case owner == "" && name == "":
+		// Deliveries of organization hooks without a repository
+		keys = []string{delivery.Organization.Login}
+	}
+	for _, key := range keys {
+		if secret, ok := h.repoSecrets[strings.ToLower(key)]; ok && key != "" {
+			return secret
+		}
 	}
+	return h.secret
 }
 
 // ServeHTTP handles incoming webhook requests.
@@ -42,6 +216,10 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, "webhook.receive", trace.WithSpanKind(trace.SpanKindServer))
+	defer span.End()
+
 	// Read body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -51,11 +229,20 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	defer r.Body.Close()
 
-	// Verify signature if secret is set
-	if h.secret != "" {
+	// Verify signature if secret is set. Once some repositories have a secret
+	// of their own, deliveries of the others must be signed as well
+	secret := h.secretFor(body)
+	if secret == "" && len(h.repoSecrets) > 0 {
+		logger.Warn().Msg("Webhook delivery without a configured secret")
+		span.SetStatus(codes.Error, "no secret")
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	if secret != "" {
 		signature := r.Header.Get("X-Hub-Signature-256")
-		if !h.verifySignature(body, signature) {
+		if !verifySignature(secret, body, signature) {
 			logger.Warn().Msg("Invalid webhook signature")
+			span.SetStatus(codes.Error, "invalid signature")
 			http.Error(w, "Invalid signature", http.StatusUnauthorized)
 			return
 		}
@@ -68,33 +255,231 @@ func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	span.SetAttributes(
+		attribute.String("github.event", eventType),
+		attribute.String("github.delivery", r.Header.Get("X-GitHub-Delivery")),
+	)
+
+	// A new webhook is tested with a ping, which is answered right away
+	if eventType == "ping" {
+		h.handlePing(w, body)
+		return
+	}
+
 	// Parse and handle event
+	_, parseSpan := tracer.Start(ctx, "webhook.parse")
 	event, err := h.parseEvent(eventType, body)
+	if err != nil {
+		parseSpan.RecordError(err)
+		parseSpan.SetStatus(codes.Error, "parse failed")
+	}
+	parseSpan.End()
 	if err != nil {
 		logger.Error().Err(err).Str("event_type", eventType).Msg("Failed to parse event")
+		span.SetStatus(codes.Error, "parse failed")
 		http.Error(w, "Failed to parse event", http.StatusBadRequest)
 		return
 	}
 
 	if event != nil {
-		// Send event to channel for processing
+		span.SetAttributes(attribute.String("github.repo", event.RepoOwner+"/"+event.RepoName))
+
+		// Queue the delivery for the dispatcher, failing so GitHub reports it
+		// as undelivered if it cannot be kept
+		carrier := propagation.MapCarrier{}
+		otel.GetTextMapPropagator().Inject(ctx, carrier)
+		_, err := h.store.AddInboundEvent(&storage.InboundEvent{
+			EventType:   eventType,
+			DeliveryID:  r.Header.Get("X-GitHub-Delivery"),
+			Payload:     string(body),
+			TraceParent: carrier.Get("traceparent"),
+			ReceivedAt:  time.Now(),
+		})
+		if err != nil {
+			logger.Error().Err(err).Str("event_type", eventType).Msg("Failed to queue webhook event")
+			span.SetStatus(codes.Error, "queue failed")
+			http.Error(w, "Failed to queue event", http.StatusInternalServerError)
+			return
+		}
 		select {
-		case h.eventsCh <- event:
-			logger.Info().
-				Str("type", event.Type).
-				Str("repo", fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName)).
-				Msg("Webhook event received")
+		case h.wake <- struct{}{}:
 		default:
-			logger.Warn().Msg("Event channel full, dropping event")
 		}
+		logger.Info().
+			Str("type", event.Type).
+			Str("repo", fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName)).
+			Msg("Webhook event received")
 	}
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("OK"))
 }
 
+// handlePing answers the ping of a webhook set up, telling whether its
+// deliveries will be understood.
+func (h *WebhookHandler) handlePing(w http.ResponseWriter, body []byte) {
+	var payload struct {
+		Zen    string `json:"zen"`
+		HookID int64  `json:"hook_id"`
+		Hook   struct {
+			Type   string   `json:"type"`
+			Events []string `json:"events"`
+			Config struct {
+				ContentType string `json:"content_type"`
+				URL         string `json:"url"`
+			} `json:"config"`
+		} `json:"hook"`
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+		Organization struct {
+			Login string `json:"login"`
+		} `json:"organization"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		logger.Warn().Err(err).Msg("Webhook ping is not JSON")
+		http.Error(w, "Set the content type of the webhook to application/json", http.StatusBadRequest)
+		return
+	}
+
+	ping := &PingEvent{
+		HookID:      payload.HookID,
+		Target:      payload.Repository.FullName,
+		URL:         payload.Hook.Config.URL,
+		ContentType: payload.Hook.Config.ContentType,
+		Events:      payload.Hook.Events,
+		Zen:         payload.Zen,
+	}
+	if ping.Target == "" {
+		ping.Target = payload.Organization.Login
+	}
+	logger.Info().
+		Int64("hook_id", ping.HookID).
+		Str("hook_type", payload.Hook.Type).
+		Str("target", ping.Target).
+		Str("url", ping.URL).
+		Str("content_type", ping.ContentType).
+		Strs("events", ping.Events).
+		Msg("Webhook ping received")
+
+	if h.onPing != nil {
+		go func() {
+			defer logger.ReportPanic()
+			h.onPing(ping)
+		}()
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "Pong! Webhook %d for %s is set up.\n", ping.HookID, ping.Target)
+}
+
+// dispatch claims the queued deliveries and hands them on to the events
+// channel, waiting while it is full.
+func (h *WebhookHandler) dispatch() {
+	defer h.wg.Done()
+	defer logger.ReportPanic()
+
+	renew := time.NewTicker(dispatchPoll)
+	defer renew.Stop()
+	for {
+		queued, err := h.store.ClaimInboundEvents(h.holder, dispatchClaim, dispatchBatch)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to claim queued webhook events")
+		}
+		for _, inbound := range queued {
+			event := h.restore(inbound)
+			if event == nil {
+				continue
+			}
+			if !h.send(event, renew.C) {
+				return
+			}
+		}
+		if len(queued) == dispatchBatch {
+			continue
+		}
+
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-h.wake:
+		case <-renew.C:
+			h.renewClaims()
+		}
+	}
+}
+
+// send hands an event on to the events channel, renewing the claims of the
+// deliveries handed out while it waits. It reports false if the handler stopped.
+func (h *WebhookHandler) send(event *WebhookEvent, renew <-chan time.Time) bool {
+	for {
+		select {
+		case h.eventsCh <- event:
+			return true
+		case <-renew:
+			h.renewClaims()
+		case <-h.ctx.Done():
+			return false
+		}
+	}
+}
+
+// renewClaims keeps the deliveries this instance handed out but that were not
+// handled yet claimed.
+func (h *WebhookHandler) renewClaims() {
+	if err := h.store.RenewInboundClaims(h.holder, dispatchClaim); err != nil {
+		logger.Warn().Err(err).Msg("Failed to renew webhook event claims")
+	}
+}
+
+// restore parses a queued delivery back into its event, or drops it and
+// returns nil if that fails.
+func (h *WebhookHandler) restore(inbound storage.InboundEvent) *WebhookEvent {
+	id := inbound.ID
+	remove := func() {
+		if err := h.store.DeleteInboundEvent(id); err != nil {
+			logger.Warn().Err(err).Int64("id", id).Msg("Failed to delete queued webhook event")
+		}
+	}
+
+	event, err := h.parseEvent(inbound.EventType, []byte(inbound.Payload))
+	if err != nil || event == nil {
+		logger.Warn().Err(err).Str("event_type", inbound.EventType).Msg("Dropping unparsable queued webhook event")
+		remove()
+		return nil
+	}
+	event.Source = "webhook"
+	event.DeliveryID = inbound.DeliveryID
+	event.DetectedAt = inbound.ReceivedAt
+	event.SetTraceContext(otel.GetTextMapPropagator().Extract(context.Background(),
+		propagation.MapCarrier{"traceparent": inbound.TraceParent}))
+	event.done = remove
+	event.retry = func() {
+		attempts := inbound.Attempts + 1
+		if attempts >= maxDeliveryAttempts {
+			logger.Error().Int64("id", id).Str("delivery", inbound.DeliveryID).Int("attempts", attempts).
+				Msg("Dropping webhook event that failed too often")
+			remove()
+			return
+		}
+		if err := h.store.RetryInboundEvent(id, retryBackoff(attempts)); err != nil {
+			logger.Warn().Err(err).Int64("id", id).Msg("Failed to requeue webhook event")
+		}
+	}
+	return event
+}
+
+// retryBackoff returns how long a delivery that failed attempts times waits
+// before it is handed out again.
+func retryBackoff(attempts int) time.Duration {
+	backoff := 30 * time.Second << (attempts - 1)
+	if backoff > time.Hour {
+		backoff = time.Hour
+	}
+	return backoff
+}
+
 // verifySignature verifies the GitHub webhook signature.
-func (h *WebhookHandler) verifySignature(body []byte, signature string) bool {
+func verifySignature(secret string, body []byte, signature string) bool {
 	if !strings.HasPrefix(signature, "sha256=") {
 		return false
 	}
@@ -104,7 +489,7 @@ func (h *WebhookHandler) verifySignature(body []byte, signature string) bool {
 		return false
 	}
 
-	mac := hmac.New(sha256.New, []byte(h.secret))
+	mac := hmac.New(sha256.New, []byte(secret))
 	mac.Write(body)
 	expected := mac.Sum(nil)
 
@@ -131,6 +516,7 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 	repoName := baseEvent.Repository.Name
 
 	var payload interface{}
+	var occurredAt time.Time
 
 	switch eventType {
 	case "push":
@@ -184,6 +570,10 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 			}
 		}
 
+		if pushPayload.HeadCommit != nil {
+			occurredAt = parseTimestamp(pushPayload.HeadCommit.Timestamp)
+		}
+
 		payload = &PushEvent{
 			Ref:     pushPayload.Ref,
 			Before:  pushPayload.Before,
@@ -209,6 +599,11 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 					AvatarURL string `json:"avatar_url"`
 					HTMLURL   string `json:"html_url"`
 				} `json:"author"`
+				Assets []struct {
+					Name               string `json:"name"`
+					Size               int    `json:"size"`
+					BrowserDownloadURL string `json:"browser_download_url"`
+				} `json:"assets"`
 			} `json:"release"`
 		}
 
@@ -221,6 +616,13 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 			return nil, nil
 		}
 
+		assets := make([]AssetInfo, len(releasePayload.Release.Assets))
+		for i, a := range releasePayload.Release.Assets {
+			assets[i] = AssetInfo{Name: a.Name, Size: a.Size, DownloadURL: a.BrowserDownloadURL}
+		}
+
+		occurredAt = parseTimestamp(releasePayload.Release.PublishedAt)
+
 		payload = &ReleaseEvent{
 			Action:     releasePayload.Action,
 			TagName:    releasePayload.Release.TagName,
@@ -234,18 +636,22 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 				AvatarURL: releasePayload.Release.Author.AvatarURL,
 				URL:       releasePayload.Release.Author.HTMLURL,
 			},
+			Assets: assets,
 		}
 
 	case "issues":
 		var issuePayload struct {
 			Action string `json:"action"`
 			Issue  struct {
-				Number  int    `json:"number"`
-				Title   string `json:"title"`
-				Body    string `json:"body"`
-				State   string `json:"state"`
-				HTMLURL string `json:"html_url"`
-				User    struct {
+				Number    int    `json:"number"`
+				Title     string `json:"title"`
+				Body      string `json:"body"`
+				State     string `json:"state"`
+				HTMLURL   string `json:"html_url"`
+				CreatedAt string `json:"created_at"`
+				UpdatedAt string `json:"updated_at"`
+				ClosedAt  string `json:"closed_at"`
+				User      struct {
 					Login     string `json:"login"`
 					AvatarURL string `json:"avatar_url"`
 					HTMLURL   string `json:"html_url"`
@@ -284,6 +690,9 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 			}
 		}
 
+		occurredAt = actionTimestamp(issuePayload.Action,
+			issuePayload.Issue.CreatedAt, issuePayload.Issue.ClosedAt, issuePayload.Issue.UpdatedAt)
+
 		payload = &IssueEvent{
 			Action: issuePayload.Action,
 			Number: issuePayload.Issue.Number,
@@ -313,6 +722,9 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 				Additions int    `json:"additions"`
 				Deletions int    `json:"deletions"`
 				Commits   int    `json:"commits"`
+				CreatedAt string `json:"created_at"`
+				UpdatedAt string `json:"updated_at"`
+				ClosedAt  string `json:"closed_at"`
 				User      struct {
 					Login     string `json:"login"`
 					AvatarURL string `json:"avatar_url"`
@@ -331,6 +743,9 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 					Ref string `json:"ref"`
 					SHA string `json:"sha"`
 				} `json:"head"`
+				Labels []struct {
+					Name string `json:"name"`
+				} `json:"labels"`
 			} `json:"pull_request"`
 		}
 
@@ -352,6 +767,14 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 			}
 		}
 
+		prLabels := make([]string, len(prPayload.PullRequest.Labels))
+		for i, l := range prPayload.PullRequest.Labels {
+			prLabels[i] = l.Name
+		}
+
+		occurredAt = actionTimestamp(prPayload.Action,
+			prPayload.PullRequest.CreatedAt, prPayload.PullRequest.ClosedAt, prPayload.PullRequest.UpdatedAt)
+
 		payload = &PullRequestEvent{
 			Action:    prPayload.Action,
 			Number:    prPayload.PullRequest.Number,
@@ -361,6 +784,7 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 			URL:       prPayload.PullRequest.HTMLURL,
 			Merged:    prPayload.PullRequest.Merged,
 			MergedBy:  mergedBy,
+			Labels:    prLabels,
 			Additions: prPayload.PullRequest.Additions,
 			Deletions: prPayload.PullRequest.Deletions,
 			Commits:   prPayload.PullRequest.Commits,
@@ -373,6 +797,498 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 			Head: BranchInfo{Ref: prPayload.PullRequest.Head.Ref, SHA: prPayload.PullRequest.Head.SHA},
 		}
 
+	case "star":
+		var starPayload struct {
+			Action    string `json:"action"`
+			StarredAt string `json:"starred_at"`
+			Sender    struct {
+				Login     string `json:"login"`
+				AvatarURL string `json:"avatar_url"`
+				HTMLURL   string `json:"html_url"`
+			} `json:"sender"`
+			Repository struct {
+				HTMLURL         string `json:"html_url"`
+				StargazersCount int    `json:"stargazers_count"`
+			} `json:"repository"`
+		}
+
+		if err := json.Unmarshal(body, &starPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse star event: %w", err)
+		}
+
+		// Only notify for new stars
+		if starPayload.Action != "created" {
+			return nil, nil
+		}
+
+		occurredAt = parseTimestamp(starPayload.StarredAt)
+
+		stars := starPayload.Repository.StargazersCount
+		milestone := 0
+		if IsStarMilestone(stars) {
+			milestone = stars
+		}
+
+		payload = &StarEvent{
+			Action: starPayload.Action,
+			User: UserInfo{
+				Login:     starPayload.Sender.Login,
+				AvatarURL: starPayload.Sender.AvatarURL,
+				URL:       starPayload.Sender.HTMLURL,
+			},
+			Stars:     stars,
+			Milestone: milestone,
+			URL:       starPayload.Repository.HTMLURL + "/stargazers",
+		}
+
+	case "create":
+		var createPayload struct {
+			Ref        string `json:"ref"`
+			RefType    string `json:"ref_type"`
+			Repository struct {
+				HTMLURL string `json:"html_url"`
+			} `json:"repository"`
+			Sender struct {
+				Login     string `json:"login"`
+				AvatarURL string `json:"avatar_url"`
+				HTMLURL   string `json:"html_url"`
+			} `json:"sender"`
+		}
+
+		if err := json.Unmarshal(body, &createPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse create event: %w", err)
+		}
+
+		// Only tags are notified, branch creation is ignored
+		if createPayload.RefType != "tag" {
+			return nil, nil
+		}
+
+		eventType = "tag"
+		payload = &TagEvent{
+			Name: createPayload.Ref,
+			URL:  createPayload.Repository.HTMLURL + "/tree/" + url.PathEscape(createPayload.Ref),
+			Pusher: UserInfo{
+				Login:     createPayload.Sender.Login,
+				AvatarURL: createPayload.Sender.AvatarURL,
+				URL:       createPayload.Sender.HTMLURL,
+			},
+		}
+
+	case "package", "registry_package":
+		type packagePayload struct {
+			Name           string `json:"name"`
+			PackageType    string `json:"package_type"`
+			Ecosystem      string `json:"ecosystem"`
+			HTMLURL        string `json:"html_url"`
+			PackageVersion struct {
+				ID                int64  `json:"id"`
+				Version           string `json:"version"`
+				HTMLURL           string `json:"html_url"`
+				PackageURL        string `json:"package_url"`
+				CreatedAt         string `json:"created_at"`
+				ContainerMetadata *struct {
+					Tag struct {
+						Name string `json:"name"`
+					} `json:"tag"`
+				} `json:"container_metadata"`
+			} `json:"package_version"`
+			Registry *struct {
+				URL string `json:"url"`
+			} `json:"registry"`
+		}
+		var pkgEvent struct {
+			Action          string         `json:"action"`
+			Package         packagePayload `json:"package"`
+			RegistryPackage packagePayload `json:"registry_package"`
+			Sender          struct {
+				Login     string `json:"login"`
+				AvatarURL string `json:"avatar_url"`
+				HTMLURL   string `json:"html_url"`
+			} `json:"sender"`
+		}
+
+		if err := json.Unmarshal(body, &pkgEvent); err != nil {
+			return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+		}
+
+		// Only notify for newly published versions
+		if pkgEvent.Action != "published" {
+			return nil, nil
+		}
+
+		pkg := pkgEvent.Package
+		if eventType == "registry_package" {
+			pkg = pkgEvent.RegistryPackage
+		}
+
+		version := pkg.PackageVersion.Version
+		if meta := pkg.PackageVersion.ContainerMetadata; meta != nil && meta.Tag.Name != "" {
+			version = meta.Tag.Name
+		}
+		ecosystem := pkg.PackageType
+		if ecosystem == "" {
+			ecosystem = pkg.Ecosystem
+		}
+		pkgURL := pkg.PackageVersion.HTMLURL
+		if pkgURL == "" {
+			pkgURL = pkg.HTMLURL
+		}
+
+		occurredAt = parseTimestamp(pkg.PackageVersion.CreatedAt)
+
+		p := &PackageEvent{
+			Action:     pkgEvent.Action,
+			Name:       pkg.Name,
+			Ecosystem:  strings.ToLower(ecosystem),
+			Version:    version,
+			VersionID:  pkg.PackageVersion.ID,
+			PackageURL: pkg.PackageVersion.PackageURL,
+			URL:        pkgURL,
+			Publisher: UserInfo{
+				Login:     pkgEvent.Sender.Login,
+				AvatarURL: pkgEvent.Sender.AvatarURL,
+				URL:       pkgEvent.Sender.HTMLURL,
+			},
+		}
+		if pkg.Registry != nil {
+			p.RegistryURL = pkg.Registry.URL
+		}
+
+		eventType = "package"
+		payload = p
+
+	case "repository":
+		var repoPayload struct {
+			Action     string `json:"action"`
+			Repository struct {
+				HTMLURL   string `json:"html_url"`
+				UpdatedAt string `json:"updated_at"`
+			} `json:"repository"`
+			Changes struct {
+				Repository struct {
+					Name struct {
+						From string `json:"from"`
+					} `json:"name"`
+				} `json:"repository"`
+				Owner struct {
+					From struct {
+						User *struct {
+							Login string `json:"login"`
+						} `json:"user"`
+						Organization *struct {
+							Login string `json:"login"`
+						} `json:"organization"`
+					} `json:"from"`
+				} `json:"owner"`
+			} `json:"changes"`
+			Sender struct {
+				Login     string `json:"login"`
+				AvatarURL string `json:"avatar_url"`
+				HTMLURL   string `json:"html_url"`
+			} `json:"sender"`
+		}
+
+		if err := json.Unmarshal(body, &repoPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse repository event: %w", err)
+		}
+
+		switch repoPayload.Action {
+		case "renamed", "transferred", "archived", "unarchived":
+		default:
+			return nil, nil
+		}
+
+		lifecycle := &RepositoryEvent{
+			Action:   repoPayload.Action,
+			OldOwner: repoOwner,
+			OldName:  repoName,
+			URL:      repoPayload.Repository.HTMLURL,
+			Sender: UserInfo{
+				Login:     repoPayload.Sender.Login,
+				AvatarURL: repoPayload.Sender.AvatarURL,
+				URL:       repoPayload.Sender.HTMLURL,
+			},
+		}
+		if from := repoPayload.Changes.Repository.Name.From; from != "" {
+			lifecycle.OldName = from
+		}
+		if from := repoPayload.Changes.Owner.From; from.User != nil {
+			lifecycle.OldOwner = from.User.Login
+		} else if from.Organization != nil {
+			lifecycle.OldOwner = from.Organization.Login
+		}
+
+		occurredAt = parseTimestamp(repoPayload.Repository.UpdatedAt)
+		payload = lifecycle
+
+	case "pull_request_review":
+		var reviewPayload struct {
+			Action string `json:"action"`
+			Review struct {
+				ID          int64  `json:"id"`
+				State       string `json:"state"`
+				Body        string `json:"body"`
+				HTMLURL     string `json:"html_url"`
+				SubmittedAt string `json:"submitted_at"`
+				User        struct {
+					Login     string `json:"login"`
+					AvatarURL string `json:"avatar_url"`
+					HTMLURL   string `json:"html_url"`
+				} `json:"user"`
+			} `json:"review"`
+			PullRequest struct {
+				Number int    `json:"number"`
+				Title  string `json:"title"`
+			} `json:"pull_request"`
+		}
+
+		if err := json.Unmarshal(body, &reviewPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse pull request review event: %w", err)
+		}
+
+		// Only notify for newly submitted reviews
+		if reviewPayload.Action != "submitted" {
+			return nil, nil
+		}
+
+		review := reviewPayload.Review
+		occurredAt = parseTimestamp(review.SubmittedAt)
+
+		payload = &PullRequestReviewEvent{
+			Action: reviewPayload.Action,
+			ID:     review.ID,
+			Number: reviewPayload.PullRequest.Number,
+			Title:  reviewPayload.PullRequest.Title,
+			State:  strings.ToLower(review.State),
+			Body:   review.Body,
+			URL:    review.HTMLURL,
+			Reviewer: UserInfo{
+				Login:     review.User.Login,
+				AvatarURL: review.User.AvatarURL,
+				URL:       review.User.HTMLURL,
+			},
+		}
+
+	case "pull_request_review_comment":
+		var commentPayload struct {
+			Action  string `json:"action"`
+			Comment struct {
+				ID        int64  `json:"id"`
+				Path      string `json:"path"`
+				Body      string `json:"body"`
+				HTMLURL   string `json:"html_url"`
+				CreatedAt string `json:"created_at"`
+				User      struct {
+					Login     string `json:"login"`
+					AvatarURL string `json:"avatar_url"`
+					HTMLURL   string `json:"html_url"`
+				} `json:"user"`
+			} `json:"comment"`
+			PullRequest struct {
+				Number int    `json:"number"`
+				Title  string `json:"title"`
+			} `json:"pull_request"`
+		}
+
+		if err := json.Unmarshal(body, &commentPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse pull request review comment event: %w", err)
+		}
+
+		// Only notify for new comments
+		if commentPayload.Action != "created" {
+			return nil, nil
+		}
+
+		comment := commentPayload.Comment
+		occurredAt = parseTimestamp(comment.CreatedAt)
+
+		payload = &PullRequestReviewCommentEvent{
+			Action: commentPayload.Action,
+			ID:     comment.ID,
+			Number: commentPayload.PullRequest.Number,
+			Title:  commentPayload.PullRequest.Title,
+			Path:   comment.Path,
+			Body:   comment.Body,
+			URL:    comment.HTMLURL,
+			User: UserInfo{
+				Login:     comment.User.Login,
+				AvatarURL: comment.User.AvatarURL,
+				URL:       comment.User.HTMLURL,
+			},
+		}
+
+	case "deployment", "deployment_status":
+		var deployPayload struct {
+			Action     string `json:"action"`
+			Deployment struct {
+				ID          int64  `json:"id"`
+				Environment string `json:"environment"`
+				Ref         string `json:"ref"`
+				SHA         string `json:"sha"`
+				Description string `json:"description"`
+				CreatedAt   string `json:"created_at"`
+				Creator     struct {
+					Login     string `json:"login"`
+					AvatarURL string `json:"avatar_url"`
+					HTMLURL   string `json:"html_url"`
+				} `json:"creator"`
+			} `json:"deployment"`
+			DeploymentStatus *struct {
+				ID             int64  `json:"id"`
+				State          string `json:"state"`
+				Description    string `json:"description"`
+				EnvironmentURL string `json:"environment_url"`
+				TargetURL      string `json:"target_url"`
+				CreatedAt      string `json:"created_at"`
+			} `json:"deployment_status"`
+			Repository struct {
+				HTMLURL string `json:"html_url"`
+			} `json:"repository"`
+		}
+
+		if err := json.Unmarshal(body, &deployPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse %s event: %w", eventType, err)
+		}
+
+		if deployPayload.Action != "created" {
+			return nil, nil
+		}
+
+		d := deployPayload.Deployment
+		deployment := &DeploymentEvent{
+			ID:          d.ID,
+			Environment: d.Environment,
+			State:       "created",
+			Ref:         d.Ref,
+			SHA:         d.SHA,
+			Description: d.Description,
+			URL:         deployPayload.Repository.HTMLURL + "/deployments",
+			Creator: UserInfo{
+				Login:     d.Creator.Login,
+				AvatarURL: d.Creator.AvatarURL,
+				URL:       d.Creator.HTMLURL,
+			},
+		}
+		occurredAt = parseTimestamp(d.CreatedAt)
+
+		if status := deployPayload.DeploymentStatus; status != nil {
+			deployment.StatusID = status.ID
+			deployment.State = status.State
+			if status.Description != "" {
+				deployment.Description = status.Description
+			}
+			deployment.TargetURL = status.EnvironmentURL
+			if deployment.TargetURL == "" {
+				deployment.TargetURL = status.TargetURL
+			}
+			occurredAt = parseTimestamp(status.CreatedAt)
+		}
+
+		eventType = "deployment"
+		payload = deployment
+
+	case "gollum":
+		var wikiPayload struct {
+			Pages []struct {
+				PageName string `json:"page_name"`
+				Title    string `json:"title"`
+				Action   string `json:"action"`
+				SHA      string `json:"sha"`
+				HTMLURL  string `json:"html_url"`
+			} `json:"pages"`
+			Repository struct {
+				HTMLURL string `json:"html_url"`
+			} `json:"repository"`
+			Sender struct {
+				Login     string `json:"login"`
+				AvatarURL string `json:"avatar_url"`
+				HTMLURL   string `json:"html_url"`
+			} `json:"sender"`
+		}
+
+		if err := json.Unmarshal(body, &wikiPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse gollum event: %w", err)
+		}
+
+		if len(wikiPayload.Pages) == 0 {
+			return nil, nil
+		}
+
+		pages := make([]WikiPage, len(wikiPayload.Pages))
+		for i, p := range wikiPayload.Pages {
+			pages[i] = WikiPage{
+				Name:    p.PageName,
+				Title:   p.Title,
+				Action:  p.Action,
+				SHA:     p.SHA,
+				URL:     p.HTMLURL,
+				DiffURL: p.HTMLURL + "/_compare/" + p.SHA,
+			}
+		}
+
+		eventType = "wiki"
+		payload = &WikiEvent{
+			Pages: pages,
+			Sender: UserInfo{
+				Login:     wikiPayload.Sender.Login,
+				AvatarURL: wikiPayload.Sender.AvatarURL,
+				URL:       wikiPayload.Sender.HTMLURL,
+			},
+		}
+
+	case "workflow_run":
+		var runPayload struct {
+			Action      string `json:"action"`
+			WorkflowRun struct {
+				ID           int64  `json:"id"`
+				Name         string `json:"name"`
+				DisplayTitle string `json:"display_title"`
+				HeadBranch   string `json:"head_branch"`
+				Event        string `json:"event"`
+				Conclusion   string `json:"conclusion"`
+				RunNumber    int    `json:"run_number"`
+				RunAttempt   int    `json:"run_attempt"`
+				HTMLURL      string `json:"html_url"`
+				UpdatedAt    string `json:"updated_at"`
+				Actor        struct {
+					Login     string `json:"login"`
+					AvatarURL string `json:"avatar_url"`
+					HTMLURL   string `json:"html_url"`
+				} `json:"actor"`
+			} `json:"workflow_run"`
+		}
+
+		if err := json.Unmarshal(body, &runPayload); err != nil {
+			return nil, fmt.Errorf("failed to parse workflow run event: %w", err)
+		}
+
+		// Only notify for finished runs
+		if runPayload.Action != "completed" {
+			return nil, nil
+		}
+
+		run := runPayload.WorkflowRun
+		occurredAt = parseTimestamp(run.UpdatedAt)
+
+		payload = &WorkflowRunEvent{
+			Action:     runPayload.Action,
+			ID:         run.ID,
+			Attempt:    run.RunAttempt,
+			RunNumber:  run.RunNumber,
+			Name:       run.Name,
+			Title:      run.DisplayTitle,
+			Branch:     run.HeadBranch,
+			Event:      run.Event,
+			Conclusion: run.Conclusion,
+			URL:        run.HTMLURL,
+			Actor: UserInfo{
+				Login:     run.Actor.Login,
+				AvatarURL: run.Actor.AvatarURL,
+				URL:       run.Actor.HTMLURL,
+			},
+		}
+
 	default:
 		// Ignore unsupported event types
 		logger.Debug().Str("event_type", eventType).Msg("Ignoring unsupported event type")
@@ -380,9 +1296,32 @@ func (h *WebhookHandler) parseEvent(eventType string, body []byte) (*WebhookEven
 	}
 
 	return &WebhookEvent{
-		Type:      eventType,
-		RepoOwner: repoOwner,
-		RepoName:  repoName,
-		Payload:   payload,
+		Type:       eventType,
+		RepoOwner:  repoOwner,
+		RepoName:   repoName,
+		Payload:    payload,
+		OccurredAt: occurredAt,
 	}, nil
 }
+
+// parseTimestamp parses an RFC 3339 payload timestamp, returning the zero
+// time if it is missing or malformed.
+func parseTimestamp(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// actionTimestamp picks the payload timestamp matching an issue or PR action.
+func actionTimestamp(action, createdAt, closedAt, updatedAt string) time.Time {
+	switch action {
+	case "opened":
+		return parseTimestamp(createdAt)
+	case "closed":
+		return parseTimestamp(closedAt)
+	default:
+		return parseTimestamp(updatedAt)
+	}
+}
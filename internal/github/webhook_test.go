@@ -0,0 +1,181 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSecretFor(t *testing.T) {
+	h := NewWebhookHandler("default", nil, nil)
+	h.SetRepoSecrets(map[string]string{
+		"Acme/Rocket": "rocket",
+		"acme":        "acme",
+		"evil/repo":   "evil",
+	})
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"repository secret", `{"repository":{"name":"rocket","owner":{"login":"acme"}}}`, "rocket"},
+		{"owner secret", `{"repository":{"name":"other","owner":{"login":"ACME"}}}`, "acme"},
+		{"default secret", `{"repository":{"name":"x","owner":{"login":"someone"}}}`, "default"},
+		{"organization hook", `{"organization":{"login":"acme"}}`, "acme"},
+		{
+			// full_name claims the signer's repository, the event is attributed to owner/name
+			"spoofed full_name",
+			`{"repository":{"full_name":"evil/repo","name":"rocket","owner":{"login":"acme"}}}`,
+			"rocket",
+		},
+		{
+			"organization does not override the repository owner",
+			`{"repository":{"name":"x","owner":{"login":"someone"}},"organization":{"login":"acme"}}`,
+			"default",
+		},
+		{"invalid JSON", `{`, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.secretFor([]byte(tt.body)); got != tt.want {
+				t.Errorf("secretFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServeHTTPRejectsForgedDeliveries(t *testing.T) {
+	h := NewWebhookHandler("", nil, nil)
+	h.SetRepoSecrets(map[string]string{"evil/repo": "evil", "acme/rocket": "rocket"})
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+	}{
+		{
+			"signed with another repository's secret",
+			`{"repository":{"full_name":"evil/repo","name":"rocket","owner":{"login":"acme"}}}`,
+			sign("evil", `{"repository":{"full_name":"evil/repo","name":"rocket","owner":{"login":"acme"}}}`),
+		},
+		{"unsigned delivery of a repository without a secret", `{"repository":{"name":"x","owner":{"login":"someone"}}}`, ""},
+		{
+			"signed with an empty secret",
+			`{"repository":{"name":"x","owner":{"login":"someone"}}}`,
+			sign("", `{"repository":{"name":"x","owner":{"login":"someone"}}}`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			req.Header.Set("X-GitHub-Event", "push")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"zen":"Keep it logically awesome."}`)
+	tests := []struct {
+		name      string
+		signature string
+		want      bool
+	}{
+		{"valid", sign("secret", string(body)), true},
+		{"wrong secret", sign("other", string(body)), false},
+		{"missing prefix", strings.TrimPrefix(sign("secret", string(body)), "sha256="), false},
+		{"not hex", "sha256=zz", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifySignature("secret", body, tt.signature); got != tt.want {
+				t.Errorf("verifySignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatchClaimsDeliveries(t *testing.T) {
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store := storage.NewSubscriptionStore(db)
+
+	const deliveries = 20
+	for i := 0; i < deliveries; i++ {
+		_, err := store.AddInboundEvent(&storage.InboundEvent{
+			EventType:  "push",
+			DeliveryID: fmt.Sprintf("delivery-%d", i),
+			Payload:    `{"ref":"refs/heads/main","repository":{"name":"app","owner":{"login":"acme"}}}`,
+			ReceivedAt: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("AddInboundEvent() error = %v", err)
+		}
+	}
+
+	// Two instances overlapping during a deploy share the queue
+	events := make(chan *WebhookEvent, 2*deliveries)
+	for i := 0; i < 2; i++ {
+		h := NewWebhookHandler("secret", store, events)
+		h.Start()
+		t.Cleanup(h.Stop)
+	}
+
+	seen := map[string]bool{}
+	var failed *WebhookEvent
+	for len(seen) < deliveries {
+		select {
+		case event := <-events:
+			if seen[event.DeliveryID] {
+				t.Fatalf("delivery %s handed out twice", event.DeliveryID)
+			}
+			seen[event.DeliveryID] = true
+			if failed == nil {
+				failed = event
+				continue
+			}
+			event.Done()
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of %d deliveries", len(seen), deliveries)
+		}
+	}
+
+	// A failed delivery stays queued but is not handed out again right away
+	failed.Retry()
+	select {
+	case event := <-events:
+		t.Fatalf("delivery %s handed out again during its backoff", event.DeliveryID)
+	case <-time.After(100 * time.Millisecond):
+	}
+	queued, err := store.ClaimInboundEvents("test", time.Minute, deliveries)
+	if err != nil || len(queued) != 0 {
+		t.Errorf("ClaimInboundEvents() = %d deliveries, %v, want the failed one waiting for its backoff", len(queued), err)
+	}
+}
@@ -0,0 +1,587 @@
+package i18n
+
+// english holds the English messages. It is the fallback for keys missing
+// from other catalogs.
+var english = map[string]string{
+	// Common replies
+	"common.unknown_command":     "Unknown command. Use /help to see the available commands.",
+	"common.repo_format":         "❌ Invalid repository, use: `owner/repo`",
+	"common.sub_not_found":       "❌ No subscription found for `%s/%s`",
+	"common.sub_not_found_short": "❌ Subscription not found",
+	"common.failed":              "❌ Something went wrong, please try again later",
+	"common.settings_failed":     "❌ Failed to load settings",
+	"common.unknown":             "unknown",
+
+	// /start and /help
+	"start.text": "🤖 *Welcome to the GitHub monitor bot!*\n\n" +
+		"I can watch *any public GitHub repository* for changes, including:\n" +
+		"• 📨 New commits (Push)\n" +
+		"• 🎉 Releases\n" +
+		"• 📝 Issues\n" +
+		"• 🔀 Pull requests\n\n" +
+		"*Getting started:*\n" +
+		"Just use `/subscribe owner/repo` to subscribe to a repository!\n\n" +
+		"*Examples:*\n" +
+		"`/subscribe torvalds/linux`\n" +
+		"`/subscribe microsoft/vscode`\n\n" +
+		"Use /help to see all commands.",
+	"help.text": "📚 *Commands*\n\n" +
+		"*Subscriptions:*\n" +
+		"• `/subscribe <owner/repo> [events]` - Subscribe to a repository, optional events: push, releases, tags, packages, issues, prs, reviews, review\\_comments, stars, ci, deployments, wiki, or preset=releases-only|maintainer|everything\n" +
+		"• `/unsubscribe <owner/repo>` - Unsubscribe\n" +
+		"• `/subscribe user:<login> [events]` - Follow all public repositories of a GitHub user, including new ones\n" +
+		"• `/subscribe topic:<topic>` - Get notified about new repositories tagged with a topic\n" +
+		"• `/list` - Show current subscriptions\n" +
+		"• `/stats` - Show how many notifications each subscription sent this week\n" +
+		"• `/export` / `/import` - Save the subscriptions to a file, or recreate them from one\n" +
+		"• `/info <owner/repo>` - Show repository details\n" +
+		"• `/test <owner/repo> [push|release|issue]` - Preview the notification of the latest commit, release or issue in this chat\n" +
+		"• `/latest <owner/repo>` - Show the latest release\n" +
+		"• `/commits <owner/repo> [n]` - List the latest commits of the default branch\n" +
+		"• `/history <owner/repo> [n]` - List the latest events notified for a subscribed repository\n" +
+		"• `/trending [language] [daily|weekly]` - Show the most starred new repositories\n" +
+		"• `/issues <owner/repo> [open|closed]` / `/prs <owner/repo> [open|closed|merged]` - Browse issues or pull requests\n" +
+		"• `/settings <owner/repo>` - Toggle event types\n" +
+		"• `/mute <owner/repo> [2h]` - Mute a subscription for a while, `/unmute` to resume\n" +
+		"• `/pause` / `/resume` - Pause or resume all notifications in this chat\n" +
+		"• `/quiet 23:00-08:00` - Set quiet hours, notifications are summarized when they end\n" +
+		"• `/digest <owner/repo> daily|weekly|off` - Deliver a subscription as a daily or weekly digest\n" +
+		"• `/silent <owner/repo> push,stars|off` - Deliver some event types without a notification sound\n" +
+		"• `/timezone Europe/Berlin` - Set the time zone of this chat\n" +
+		"• `/language en|zh` - Set the language of this chat\n" +
+		"• `/format markdown|html` - Set how notifications in this chat are rendered\n" +
+		"• `/photos on|off` - Send notifications as preview images with the text as caption\n" +
+		"• `/dryrun on|off` - Only log this chat's notifications instead of sending them\n" +
+		"• `/template <owner/repo> [event] [template]` - Customize the notification text of a subscription\n" +
+		"• `/login` - Link your GitHub account to subscribe to private repositories, `/logout` to unlink it\n" +
+		"• `/settoken <token>` - Set a personal access token for this chat's private repositories instead, in a private chat\n" +
+		"• `/hook new <name>` - Create a URL other services can post JSON to, `/hook` lists them\n" +
+		"• `/bindchannel @channel` - Post this chat's notifications to a channel, `/unbindchannel` to stop\n" +
+		"• `/filter <owner/repo> assets:<glob>` - Only notify about releases with a matching asset\n\n" +
+		"*Shortcuts:*\n" +
+		"• `/sub` - Short for subscribe\n" +
+		"• `/unsub` - Short for unsubscribe\n\n" +
+		"*Examples:*\n" +
+		"```\n" +
+		"/subscribe torvalds/linux\n" +
+		"/subscribe microsoft/vscode\n" +
+		"/sub golang/go releases,issues\n" +
+		"/list\n" +
+		"/unsub torvalds/linux\n" +
+		"```\n\n" +
+		"💡 Once subscribed, you are notified about new commits, releases, issues and PRs automatically.",
+
+	// /subscribe and /unsubscribe
+	"subscribe.usage":             "❌ Please specify a repository: `/subscribe owner/repo [events]`",
+	"subscribe.invalid_events":    "❌ Invalid event type, choose from: `push`, `releases`, `tags`, `packages`, `issues`, `prs`, `reviews`, `review_comments`, `stars`, `ci`, `deployments`, `wiki`, `all`, or a preset: `preset=releases-only`, `preset=maintainer`, `preset=everything`",
+	"subscribe.validate_error":    "⚠️ Failed to validate the repository, please try again later",
+	"subscribe.repo_not_found":    "❌ Repository `%s/%s` does not exist or is not accessible",
+	"subscribe.private_hint":      "\nIf it is private, link your GitHub account with /login or /settoken first",
+	"subscribe.failed":            "❌ Failed to subscribe, please try again later",
+	"subscribe.chat_quota":        "⚠️ This chat has reached its limit of %d subscriptions. Unsubscribe from a repository with /list to make room",
+	"subscribe.repo_quota":        "⚠️ This bot is watching as many repositories as it can right now. You can still subscribe to repositories other chats follow already",
+	"subscribe.success":           "✅ *Subscribed to %s/%s*\n\nEvents:\n",
+	"subscribe.success_footer":    "\nYou will be notified about new activity automatically!",
+	"subscribe.user_usage":        "❌ Please specify a GitHub user: `/subscribe user:login [events]`",
+	"subscribe.user_not_found":    "❌ GitHub user `%s` does not exist",
+	"subscribe.user_success":      "✅ *Following %s*, subscribed to %d repositories\n\nEvents:\n",
+	"subscribe.user_footer":       "\nNew public repositories of the user are subscribed automatically!",
+	"subscribe.topic_usage":       "❌ Please specify a topic of lowercase letters, digits and hyphens: `/subscribe topic:telegram-bot`",
+	"subscribe.topic_success":     "✅ Following topic `%s`, new repositories tagged with it will be announced here",
+	"unsubscribe.usage":           "❌ Please specify a repository: `/unsubscribe owner/repo`",
+	"unsubscribe.failed":          "❌ Failed to unsubscribe, please try again later",
+	"unsubscribe.failed_short":    "❌ Failed to unsubscribe",
+	"unsubscribe.user_not_found":  "❌ Not following GitHub user `%s`",
+	"unsubscribe.user_success":    "✅ Stopped following `%s`, unsubscribed from %d repositories",
+	"unsubscribe.topic_not_found": "❌ Not following topic `%s`",
+	"unsubscribe.topic_success":   "✅ Stopped following topic `%s`",
+	"unsubscribe.success":         "✅ Unsubscribed from `%s/%s`",
+
+	// Event type labels
+	"event.push":                        "📨 Pushes",
+	"event.release":                     "🎉 Releases",
+	"event.tag":                         "🏷️ Tags",
+	"event.package":                     "📦 Packages",
+	"event.issues":                      "📝 Issues",
+	"event.pull_request":                "🔀 Pull Requests",
+	"event.star":                        "⭐ Stars",
+	"event.workflow_run":                "⚙️ CI (Actions)",
+	"event.deployment":                  "🚀 Deployments",
+	"event.wiki":                        "📖 Wiki",
+	"event.pull_request_review":         "👀 PR Reviews",
+	"event.pull_request_review_comment": "💬 Review Comments",
+
+	// /pause, /resume, /quiet, /timezone, /language
+	"pause.paused":     "⏸ All notifications in this chat are paused, subscriptions are kept. Use /resume to resume",
+	"pause.resumed":    "▶️ Notifications in this chat resumed",
+	"quiet.none":       "🌙 No quiet hours set. Use `/quiet 23:00-08:00` to set them",
+	"quiet.current":    "🌙 Quiet hours: `%s`\nUse `/quiet off` to disable them",
+	"quiet.invalid":    "❌ Invalid time range, e.g. `/quiet 23:00-08:00`",
+	"quiet.disabled":   "🔔 Quiet hours disabled, held back notifications will be summarized shortly",
+	"quiet.set":        "🌙 Quiet hours set to `%s` (%s), notifications are summarized when they end",
+	"timezone.current": "🕒 Time zone: `%s` (%s)\nUse `/timezone Europe/Berlin` to change it, `/timezone off` for server time",
+	"timezone.invalid": "❌ Unknown time zone, use an IANA name such as `Europe/Berlin` or `Asia/Shanghai`",
+	"timezone.reset":   "🕒 Using the server time zone `%s` again",
+	"timezone.set":     "🕒 Time zone set to `%s`, quiet hours and digests follow it",
+	"language.current": "🌐 Language: English\nUse `/language en` or `/language zh` to switch",
+	"language.invalid": "❌ Unsupported language, choose from: `en`, `zh`",
+	"language.set":     "🌐 Switched to English",
+	"format.current":   "📝 Notification format: %s\nUse `/format html` or `/format markdown` to switch, `/format default` for the bot's default",
+	"format.default":   "bot default",
+	"format.invalid":   "❌ The format must be `markdown`, `html` or `default`",
+	"format.set":       "📝 Notifications in this chat will use %s",
+	"format.reset":     "📝 Notifications in this chat will use the bot's default format",
+	"photos.on":        "🖼 Notifications are sent as GitHub preview images\nUse `/photos off` to send plain text",
+	"photos.off":       "🖼 Notifications are sent as plain text\nUse `/photos on` to attach GitHub preview images",
+	"photos.invalid":   "❌ Use `/photos on` or `/photos off`",
+	"dryrun.on":        "🧪 Dry run: notifications are logged and kept in /history, but not sent\nUse `/dryrun off` to send them again",
+	"dryrun.off":       "🧪 Notifications are sent\nUse `/dryrun on` to only log them while trying filters and templates",
+	"dryrun.invalid":   "❌ Use `/dryrun on` or `/dryrun off`",
+
+	// /mute, /unmute, /digest
+	"mute.usage":            "❌ Please specify a repository: `/mute owner/repo 2h`",
+	"mute.invalid_duration": "❌ Invalid duration, e.g. `30m`, `2h`, `1d` (at most 30 days)",
+	"mute.failed":           "❌ Failed to mute, please try again later",
+	"mute.success":          "🔇 Muted `%s/%s` for %s, use `/unmute %s/%s` to resume earlier",
+	"unmute.usage":          "❌ Please specify a repository: `/unmute owner/repo`",
+	"unmute.failed":         "❌ Failed to unmute, please try again later",
+	"unmute.success":        "🔔 Notifications for `%s/%s` resumed",
+	"digest.usage":          "❌ Usage: `/digest owner/repo daily|weekly|off`",
+	"digest.invalid_mode":   "❌ The mode must be `daily`, `weekly` or `off`",
+	"digest.failed":         "❌ Failed to save, please try again later",
+	"digest.daily":          "📰 Notifications for `%s/%s` will be sent as a daily digest",
+	"digest.weekly":         "📰 Notifications for `%s/%s` will be sent as a weekly digest (Mondays)",
+	"digest.off":            "🔔 Real-time notifications for `%s/%s` resumed",
+
+	// Silent notifications
+	"silent.usage":   "❌ Usage: `/silent owner/repo [events|all|off]`, e.g. `/silent owner/repo push,stars`",
+	"silent.none":    "🔔 All notifications for `%s/%s` make a sound",
+	"silent.current": "🔕 Delivered without a sound for `%s/%s`: %s",
+	"silent.set":     "🔕 These notifications for `%s/%s` will arrive without a sound: %s",
+	"silent.off":     "🔔 All notifications for `%s/%s` will make a sound again",
+	"silent.failed":  "❌ Failed to save, please try again later",
+
+	// Custom message templates
+	"template.usage":         "❌ Usage: `/template owner/repo [event] [template|off]`",
+	"template.none":          "📝 `%s/%s` uses the built-in messages\nSet a template with `/template %s/%s push \"{{.Pusher}} pushed to {{.Branch}}\"`",
+	"template.list":          "📝 *Templates for %s/%s:*\n",
+	"template.builtin":       "📝 `%s/%s` uses the built-in message for %s events\n",
+	"template.current":       "📝 %s template of `%s/%s`:\n```\n%s\n```\n",
+	"template.fields":        "Available fields: %s\nFunctions: truncate, firstLine, join, upper, lower\nUse `off` to restore the built-in message",
+	"template.invalid_event": "❌ Unknown event type `%s`",
+	"template.invalid":       "❌ Invalid template: %s",
+	"template.failed":        "❌ Failed to save, please try again later",
+	"template.set":           "✅ Custom %s template saved for `%s/%s`",
+	"template.reset":         "🔔 `%s/%s` uses the built-in %s message again",
+
+	// Custom hooks
+	"hook.usage":            "❌ Usage: `/hook [new|template|delete] <name>`\nNames may contain letters, digits, - and _",
+	"hook.failed":           "❌ Failed to update the hooks, please try again later",
+	"hook.none":             "🪝 This chat has no custom hooks\nCreate one with `/hook new <name>` to forward the webhooks of other services here",
+	"hook.list":             "🪝 *Custom hooks of this chat:*\n\n",
+	"hook.url_hidden":       "URL shown only when the hook was created",
+	"hook.limit":            "❌ A chat can have at most %d custom hooks",
+	"hook.exists":           "❌ A hook named `%s` already exists",
+	"hook.not_found":        "❌ No hook named `%s`",
+	"hook.created":          "✅ Hook *%s* created, POST JSON to:\n`%s`\n\nThe payload is shown as it is, or set a template with `/hook template %s \"{{.status}}: {{.message}}\"`",
+	"hook.deleted":          "🗑 Hook `%s` deleted, its URL no longer works",
+	"hook.template_none":    "📝 Hook `%s` shows the payload as it is",
+	"hook.template_current": "📝 Template of hook `%s`:\n```\n%s\n```\nUse `off` to show the payload as it is",
+	"hook.template_set":     "✅ Template saved for hook `%s`",
+	"hook.template_reset":   "🔔 Hook `%s` shows the payload as it is again",
+
+	// Routing to sinks
+	"route.usage":           "❌ Usage: `/route owner/repo [destination|chat]`",
+	"route.admin_only":      "⛔ Only bot administrators can route subscriptions",
+	"route.chat":            "➡️ Notifications for `%s/%s` are sent to this chat",
+	"route.current":         "➡️ Notifications for `%s/%s` are sent to `%s`\nUse `chat` to receive them here again",
+	"route.unknown":         "❌ Unknown destination `%s`",
+	"route.available":       "\nDestinations: %s",
+	"route.none_configured": "\nNo destinations are configured, add Discord or Slack webhooks or Matrix rooms under `discord.webhooks`, `slack.webhooks` or `matrix.rooms` in the configuration",
+	"route.set":             "✅ Notifications for `%s/%s` will be sent to `%s`",
+	"route.reset":           "🔔 Notifications for `%s/%s` will be sent to this chat again",
+	"route.failed":          "❌ Failed to save, please try again later",
+
+	// Linked GitHub accounts
+	"login.disabled":     "❌ Linking GitHub accounts is not enabled on this bot",
+	"login.private_only": "🔒 Please send /login in a private chat with the bot",
+	"login.linked":       "🔗 Your GitHub account *%s* is linked\nUse /logout to unlink it",
+	"login.pending":      "⏳ A login is already waiting for its code, enter it on GitHub or send /logout to cancel it",
+	"login.code":         "🔗 *Link your GitHub account*\n\n1. Open %s\n2. Enter the code `%s`\n\nThe code expires in %d minutes. Once linked, you can subscribe to the private repositories your account can read",
+	"login.success":      "✅ GitHub account *%s* linked\nYou can now subscribe to the private repositories it can read",
+	"login.denied":       "❌ The login was refused on GitHub",
+	"login.expired":      "⌛ The login code expired, send /login for a new one",
+	"login.failed":       "❌ Failed to link your GitHub account, please try again later",
+	"logout.done":        "🔓 GitHub account *%s* unlinked\nPrivate repositories you subscribed to are no longer read with it",
+	"logout.none":        "ℹ️ No GitHub account is linked",
+	"logout.failed":      "❌ Failed to unlink your GitHub account, please try again later",
+
+	"settoken.disabled":      "❌ Storing GitHub tokens is not enabled on this bot",
+	"settoken.private_only":  "🔒 Please send /settoken in a private chat with the bot\nIf you posted a token here, revoke it on GitHub now",
+	"settoken.none":          "🔑 This chat has no GitHub token\nSend `/settoken <token>` with a personal access token to subscribe this chat to the private repositories it can read",
+	"settoken.current":       "🔑 This chat uses the token of GitHub account *%s*\nQuota: %s\nUse `/settoken off` to remove it",
+	"settoken.invalid":       "❌ GitHub did not accept this token",
+	"settoken.saved":         "✅ Token of GitHub account *%s* saved for this chat\nThis chat can now subscribe to the private repositories it can read",
+	"settoken.removed":       "✅ Token of GitHub account *%s* removed from this chat",
+	"settoken.remove_failed": "❌ Failed to remove the token, please try again later",
+	"settoken.delete_failed": "⚠️ Your message with the token could not be deleted, please delete it yourself",
+	"settoken.failed":        "❌ Failed to save the token, please try again later",
+
+	// Channel posting
+	"channel.none":           "📢 Notifications are sent to this chat\nTo post them to a channel, add the bot as an administrator of the channel, then use `/bindchannel @channel` or forward a post from the channel to the bot",
+	"channel.current":        "📢 Notifications of this chat are posted to *%s*\nUse `/unbindchannel` to receive them here again",
+	"channel.bound":          "📢 Notifications of this chat will be posted to *%s*",
+	"channel.unbound":        "🔔 Notifications will be sent to this chat again",
+	"channel.not_found":      "❌ Channel `%s` not found, the bot must be a member of it",
+	"channel.not_channel":    "❌ This chat is not a channel",
+	"channel.bot_not_admin":  "❌ The bot must be an administrator of *%s* allowed to post messages",
+	"channel.user_not_admin": "❌ Only administrators of *%s* can bind it",
+	"channel.failed":         "❌ Failed to save, please try again later",
+	"channel.lost":           "⚠️ The bot can no longer post to *%s*, notifications will be sent to this chat again",
+
+	// Compliance acknowledgement
+	"ack.failed":  "❌ Failed to acknowledge",
+	"ack.success": "✅ Compliance warning for `%s/%s` acknowledged",
+
+	// /list
+	"info.usage":                "❌ Please specify a repository: `/info owner/repo`",
+	"info.failed":               "❌ Failed to load the repository, please try again later",
+	"info.stars":                "⭐ Stars: %d · 🍴 Forks: %d\n",
+	"info.open_issues":          "📝 Open issues and PRs: %d\n",
+	"info.language":             "💻 Language: %s\n",
+	"info.license":              "📜 License: %s\n",
+	"info.pushed_at":            "🕒 Last push: %s\n",
+	"info.archived":             "📦 Archived, read-only\n",
+	"info.subscribe_button":     "➕ Subscribe",
+	"history.usage":             "❌ Please specify a repository: `/history owner/repo [n]`",
+	"history.invalid_count":     "❌ The number of events must be between 1 and %d",
+	"history.not_subscribed":    "❌ This chat is not subscribed to `%s/%s`",
+	"history.failed":            "❌ Failed to load the event history, please try again later",
+	"history.none":              "📭 No events of `%s/%s` were notified yet",
+	"history.title":             "🕘 *Latest %[3]d events of %[1]s/%[2]s*\n\n",
+	"commits.usage":             "❌ Please specify a repository: `/commits owner/repo [n]`",
+	"commits.invalid_count":     "❌ The number of commits must be between 1 and %d",
+	"commits.failed":            "❌ Failed to load commits, please try again later",
+	"commits.none":              "📭 `%s/%s` has no commits yet",
+	"issues.usage":              "❌ Please specify a repository: `/issues owner/repo [open|closed]`",
+	"prs.usage":                 "❌ Please specify a repository: `/prs owner/repo [open|closed|merged]`",
+	"issues.failed":             "❌ Failed to search, please try again later",
+	"issues.none":               "📭 Nothing found",
+	"issues.title_open":         "📝 *Open issues of %s/%s* (%d)\n",
+	"issues.title_closed":       "📝 *Closed issues of %s/%s* (%d)\n",
+	"prs.title_open":            "🔀 *Open pull requests of %s/%s* (%d)\n",
+	"prs.title_closed":          "🔀 *Closed pull requests of %s/%s* (%d)\n",
+	"prs.title_merged":          "🔀 *Merged pull requests of %s/%s* (%d)\n",
+	"trending.failed":           "❌ Failed to load trending repositories, please try again later",
+	"trending.none":             "📭 No trending repositories found",
+	"trending.title_daily":      "🔥 *Trending today*",
+	"trending.title_weekly":     "🔥 *Trending this week*",
+	"trending.language":         " · %s",
+	"trending.subscribe_button": "➕ %s",
+	"stats.failed":              "❌ Failed to load statistics",
+	"stats.none":                "📭 No notifications in the last 7 days",
+	"stats.title":               "📊 *Notifications in the last 7 days*\n\n",
+	"stats.total":               "%d notifications across %d repositories\n\n",
+	"stats.by_event":            "*By event:*\n",
+	"stats.by_repo":             "\n*Noisiest repositories:*\n",
+	"access.denied":             "⛔ This bot is not available in this chat",
+	"access.pending":            "⏳ This chat is still waiting for an administrator's approval",
+	"access.requested":          "⏳ An administrator needs to approve this chat before the bot can be used here. You will be notified once it is decided",
+	"access.granted":            "✅ An administrator approved this chat, the bot is ready to use. Send /help to get started",
+	"access.failed":             "❌ Failed to update the chat's access, please try again later",
+	"access.request":            "🔐 *Approval requested*\n\n💬 %s (%s, `%d`)\n👤 %s",
+	"access.approve_button":     "✅ Approve",
+	"access.deny_button":        "⛔ Deny",
+	"access.decided_approved":   "✅ %s (`%d`) approved by %s",
+	"access.decided_denied":     "⛔ %s (`%d`) denied by %s",
+	"export.failed":             "❌ Failed to export the subscriptions, please try again later",
+	"export.caption":            "📦 %d subscriptions, %d followed users and %d topics\n\nSend this file with the caption /import to another chat or bot to recreate them",
+	"import.usage":              "❌ Send an exported file with the caption /import, or reply to it with /import",
+	"import.invalid":            "❌ This is not a subscription export this bot can read",
+	"import.failed":             "❌ Failed to import the subscriptions, please try again later",
+	"import.success":            "✅ Imported %d subscriptions, %d followed users and %d topics\n",
+	"import.skipped":            "⚠️ %d invalid entries were skipped\n",
+	"import.denied":             "⚠️ Left out repositories that do not exist or you cannot read: %s\n",
+	"import.quota":              "⚠️ Some subscriptions were left out because the subscription limit was reached\n",
+	"admin.usage":               "❌ Usage: `/admin stats|backup|audit`",
+	"admin.backup_disabled":     "❌ Database backups are not configured",
+	"admin.backup_failed":       "❌ The backup failed, see the logs for details",
+	"admin.backup_done":         "💾 Saved `%s` (%.1f MB)",
+	"admin.backup_uploaded":     "💾 Saved and uploaded `%s` (%.1f MB)",
+	"admin.quota":               "%d/%d left, resets at %s",
+	"audit.usage":               "❌ Usage: `/admin audit [chat:<id>] [user:<id>] [cmd:<command>] [n]`",
+	"audit.invalid_count":       "❌ The number of entries must be between 1 and %d",
+	"audit.failed":              "❌ Failed to load the audit log, please try again later",
+	"audit.none":                "📭 No matching commands were recorded",
+	"audit.title":               "🧾 *Latest %d commands*\n\n",
+	"audit.handled":             "✅",
+	"audit.denied":              "⛔ denied",
+	"audit.unknown":             "❓ unknown",
+	"webhook.ping":              "🔔 *Webhook set up*\n\n📦 %s\n🆔 Hook `%d`\n⚡ %s\n\n_%s_",
+	"admin.stats": "📊 *Bot statistics*\n\n" +
+		"💬 Chats: %d (%d inactive)\n" +
+		"📋 Subscriptions: %d\n" +
+		"📦 Repositories: %d\n" +
+		"👤 Followed users: %d\n" +
+		"🏷 Followed topics: %d\n" +
+		"⚡ Events processed in 24h: %d\n" +
+		"📤 Pending deliveries: %d\n" +
+		"🕒 Queued for digests and quiet hours: %d\n" +
+		"🔑 GitHub quota: %s\n",
+	"test.usage":          "❌ Please specify a repository: `/test owner/repo [push|release|issue]`",
+	"test.invalid_event":  "❌ Invalid event type, choose from: `push`, `release`, `issue`",
+	"test.failed":         "❌ Failed to send the preview, please try again later",
+	"test.not_found":      "❌ Repository `%s/%s` does not exist or is not accessible",
+	"test.none":           "📭 `%s/%s` has no %s event to preview yet",
+	"test.not_subscribed": "🧪 This is a preview. This chat is not subscribed to `%s/%s`, so it shows the default format",
+	"test.filtered":       "🧪 This is a preview. The subscription to `%s/%s` would not notify this event, because of its event types, filters or mute",
+	"test.delivered":      "🧪 This is a preview. The subscription would notify this event as shown",
+	"latest.usage":        "❌ Please specify a repository: `/latest owner/repo`",
+	"latest.failed":       "❌ Failed to load the latest release, please try again later",
+	"latest.none":         "📭 `%s/%s` has no published release",
+	"latest.published":    "📅 Published %s\n",
+	"list.failed":         "❌ Failed to load subscriptions",
+	"list.empty":          "📭 No subscriptions yet\n\nUse `/subscribe owner/repo` to subscribe to a repository",
+	"list.title":          "📋 *Subscriptions (%d)*\n\n",
+	"list.paused":         "⏸ Notifications are paused, use /resume to resume\n\n",
+	"list.dry_run":        "🧪 Dry run: notifications are logged but not sent, use `/dryrun off` to send them\n\n",
+	"list.muted_until":    "   🔇 Muted until %s\n",
+	"list.digest_daily":   "   📰 Daily digest\n",
+	"list.digest_weekly":  "   📰 Weekly digest\n",
+	"list.routed":         "   ➡️ Sent to `%s`\n",
+	"list.compliance":     "   ⚠️ Compliance warning: %s\n",
+	"list.ack_button":     "✅ Acknowledge %s/%s",
+	"list.unsub_button":   "✖ %s/%s",
+	"list.page":           "\nPage %d of %d\n",
+	"list.prev":           "◀ Previous",
+	"list.next":           "Next ▶",
+	"list.footer":         "\nUse `/unsubscribe owner/repo` to unsubscribe",
+
+	// /status
+	"status.rate_limit": "%d/%d (resets in %s, %s)",
+	"status.text": "📊 *Bot status*\n\n" +
+		"⏱️ *Uptime:* %s\n" +
+		"🕒 *Current time:* %s (%s)\n" +
+		"📡 *Mode:* Polling\n\n" +
+		"📦 *Overall:*\n" +
+		"• Monitored repositories: %d\n" +
+		"• Unreachable chats (blocked or removed): %d\n\n" +
+		"👤 *Your subscriptions:*\n" +
+		"• Subscriptions: %d\n\n" +
+		"🔗 *GitHub API:*\n" +
+		"• Quota: %s\n",
+	"status.lag_title":  "\n🚚 *Delivery lag (median):*\n",
+	"status.lag_detail": " (detection %s + delivery %s)\n",
+	"status.poll_title": "\n⏲️ *Polling:*\n",
+	"status.more_repos": "• _...and %d more repositories_\n",
+
+	// /setting
+	"setting.admin_only":    "⛔ This command is for administrators only",
+	"setting.unavailable":   "❌ Settings store is not available",
+	"setting.usage":         "Usage:\n`/setting get <key>`\n`/setting set <key> <value>`\n\nAvailable keys:\n",
+	"setting.forbidden":     "❌ Setting `%s` is not accessible",
+	"setting.read_failed":   "❌ Failed to read the setting",
+	"setting.unset":         "⚙️ `%s` is not set (using the default)",
+	"setting.set_usage":     "❌ Please specify a value: `/setting set <key> <value>`",
+	"setting.invalid_value": "❌ Invalid value: %s",
+	"setting.saved":         "✅ Set `%s` = `%s`",
+
+	// /settings
+	"settings.usage":         "❌ Please specify a repository: `/settings owner/repo`",
+	"settings.load_failed":   "❌ Failed to load the subscription",
+	"settings.title":         "⚙️ *%s/%s notification settings*\n\nTap a button to turn an event type on or off:",
+	"settings.keep_one":      "⚠️ At least one event type must stay enabled, use `/unsubscribe` to stop notifications",
+	"settings.update_failed": "❌ Failed to update the settings",
+
+	// /filter
+	"filter.usage":                "❌ Please specify a repository: `/filter owner/repo assets:<glob>`",
+	"filter.load_failed":          "❌ Failed to load the filters",
+	"filter.invalid":              "❌ Invalid filter `%s`, use: `type:value`",
+	"filter.invalid_assets":       "❌ Invalid asset pattern",
+	"filter.invalid_platforms":    "❌ Invalid platform list, use: `platforms:linux_amd64,darwin_arm64`",
+	"filter.compliance_format":    "❌ Usage: `compliance:license=MIT,Apache-2.0`",
+	"filter.invalid_licenses":     "❌ Invalid license list: %s",
+	"filter.invalid_branches":     "❌ Invalid branch list, use: `branch:main,release/*`",
+	"filter.invalid_labels":       "❌ Invalid label list, use: `labels:bug,-dependencies`",
+	"filter.invalid_ignore":       "❌ Invalid user list, use: `ignore:dependabot[bot],renovate[bot]`",
+	"filter.invalid_regex":        "❌ Invalid regular expression (spaces are not allowed, use `\\s` instead)",
+	"filter.ci_format":            "❌ Usage: `ci:failures` or `ci:failures+recovery`",
+	"filter.unknown":              "❌ Unknown filter type `%s`",
+	"filter.save_failed":          "❌ Failed to save the filters",
+	"filter.updated":              "✅ Filters updated\n\n",
+	"filters.title":               "🔍 *Filters of %s/%s*\n\n",
+	"filters.assets":              "• Assets: %s\n",
+	"filters.platforms":           "• Listed assets: %s\n",
+	"filters.licenses":            "• Compliance licenses: %s\n",
+	"filters.branches":            "• Branches: %s\n",
+	"filters.labels":              "• Labels: %s\n",
+	"filters.required_labels":     "• Required labels: %s\n",
+	"filters.excluded_labels":     "• Excluded labels: %s\n",
+	"filters.ignore":              "• Ignored users: %s\n",
+	"filters.include":             "• Include: %s\n",
+	"filters.exclude":             "• Exclude: %s\n",
+	"filters.prereleases":         "• Pre-releases: %s\n",
+	"filters.prereleases_skipped": "• Pre-releases: skipped, with tags matching %s\n",
+	"filters.prerelease_default":  "nightly, rc, beta and the like",
+	"filters.ci":                  "• CI: %s\n",
+	"filters.none":                "none",
+	"filters.all":                 "all",
+	"filters.ci_failures":         "failures only",
+	"filters.ci_recovery":         "failures and first recovery",
+	"filters.help": "\nHow to set them:\n" +
+		"`/filter owner/repo assets:<glob>`\n" +
+		"`/filter owner/repo platforms:linux_amd64,darwin_arm64`\n" +
+		"`/filter owner/repo compliance:license=MIT,Apache-2.0`\n" +
+		"`/filter owner/repo ci:failures` or `ci:failures+recovery`\n" +
+		"`/filter owner/repo branch:main,release/*`\n" +
+		"`/filter owner/repo labels:bug,-dependencies`\n" +
+		"`/filter owner/repo include:(?i)panic exclude:^chore:`\n" +
+		"`/filter owner/repo prereleases:skip` or `prereleases:<regex of tags>`\n" +
+		"`/filter owner/repo ignore:bots` (ignores `%s`)\n" +
+		"An empty value (like `assets:`) clears a filter",
+
+	// /diagnose and poll schedules
+	"diagnose.usage":        "❌ Please specify a repository: `/diagnose owner/repo`",
+	"diagnose.load_failed":  "❌ Failed to load the subscription",
+	"diagnose.state_failed": "❌ Failed to load the polling state",
+	"diagnose.title":        "🩺 *Diagnostics: %s/%s*\n\n",
+	"diagnose.schedule":     "⏲️ Polling: %s\n",
+	"diagnose.activity":     "📈 Activity: about %.1f events/day\n",
+	"diagnose.last_polled":  "🕒 Last checked: %s ago\n",
+	"poll.never":            "not polled yet",
+	"pollinterval.usage":    "❌ Usage: `/pollinterval owner/repo [2m|1h|auto]`",
+	"pollinterval.invalid":  "❌ Invalid interval, use e.g. `2m`, `1h` or `auto` (between 1 minute and 1 day)",
+	"pollinterval.current":  "⏲️ `%s/%s` is %s",
+	"pollinterval.set":      "⏲️ `%s/%s` will be checked every %s",
+	"pollinterval.reset":    "⏲️ `%s/%s` will be checked based on its activity again",
+	"poll.schedule":         "checked about every %s (%s)",
+	"poll.reason_override":  "set manually",
+	"poll.reason_active":    "very active repository",
+	"poll.reason_moderate":  "moderately active repository",
+	"poll.reason_dormant":   "quiet repository",
+	"poll.reason_new":       "new subscription, using the base interval",
+	"duration.days":         "%dd %dh %dm",
+	"duration.hours":        "%dh %dm",
+	"duration.minutes":      "%dm %ds",
+	"duration.seconds":      "%ds",
+
+	// Group onboarding and /setupcheck
+	"onboarding.welcome_back": "👋 Welcome back! The %d subscriptions of this group are still active and notifications have resumed.",
+	"onboarding.text": "👋 *Hi everyone! I'm the GitHub monitor bot*\n\n" +
+		"I can post activity of *any public GitHub repository* to this group:\n" +
+		"• 📨 New commits (Push)\n" +
+		"• 🎉 Releases\n" +
+		"• 📝 Issues\n" +
+		"• 🔀 Pull requests\n\n" +
+		"Tap the button below to subscribe to a first repository, or use `/subscribe owner/repo`.\n\n" +
+		"🔧 Whoever added me can run /setupcheck to check permissions and configuration.",
+	"onboarding.button":         "➕ Subscribe to a repository",
+	"wizard.prompt":             "📝 Reply with the repository to subscribe to",
+	"wizard.format":             ", as owner/repo",
+	"setupcheck.title":          "🔧 *Setup check*\n\n",
+	"setupcheck.no_permissions": "⚠️ Could not get the bot's permissions in this group\n",
+	"setupcheck.muted":          "❌ The bot is not allowed to send messages here\n",
+	"setupcheck.group_ok":       "✅ Bot status: %s, can send messages\n",
+	"setupcheck.private_ok":     "✅ Notifications work in private chats\n",
+	"setupcheck.no_subs":        "⚠️ No subscriptions yet, use `/subscribe owner/repo` to start\n",
+	"setupcheck.subs":           "✅ Subscribed to %d repositories\n",
+	"setupcheck.no_client":      "⚠️ No GitHub client configured, repositories cannot be validated\n",
+	"setupcheck.client":         "✅ GitHub client configured\n",
+
+	// Notification fields
+	"field.tag":      "📦 Tag: `%s`\n",
+	"field.commit":   "🔗 Commit: `%s`\n",
+	"field.by":       "👤 By: %s\n",
+	"field.author":   "👤 Author: %s\n",
+	"field.reviewer": "👤 Reviewer: %s\n",
+	"field.labels":   "🏷️ Labels: %v\n",
+	"field.branch":   "🌿 Branch: `%s`\n",
+	"field.ref":      "🌿 Ref: `%s`\n",
+	"field.file":     "📄 File: %s\n",
+	"field.version":  "🏷️ Version: `%s`\n",
+	"field.type":     "🧰 Type: %s\n",
+	"field.filter":   "🔍 Filter: `%s`\n",
+	"field.license":  "📜 License: `%s`\n",
+
+	// Notifications
+	"push.title":               "🔨 *%[1]s* pushed %[3]d commits to `%[2]s`\n\n",
+	"push.title_one":           "🔨 *%[1]s* pushed %[3]d commit to `%[2]s`\n\n",
+	"push.more":                "\n_...and %d more commits_\n",
+	"commits.title":            "📜 *Latest %[3]d commits of %[1]s/%[2]s*\n\n",
+	"push.compare":             "Compare changes",
+	"release.title":            "%s *New Release: %s*\n\n",
+	"release.view":             "View Release",
+	"release.full_text":        "📄 Read the full release notes",
+	"release.assets":           "\n📦 *Assets:*\n",
+	"release.assets_more":      "_...and %d more_\n",
+	"tag.title":                "🏷️ *New Tag*\n\n",
+	"tag.view":                 "View Tag",
+	"package.title":            "📦 *New Package Version: %s*\n\n",
+	"package.registry":         "Registry",
+	"package.view":             "View Package",
+	"issue.title":              "%s *Issue #%d %s*\n\n",
+	"issue.view":               "View Issue",
+	"issue.full_text":          "📄 Read the full description",
+	"pr.title":                 "%s *PR #%d %s*\n\n",
+	"pr.stats":                 "📊 %d commits, +%d/-%d lines\n",
+	"pr.view":                  "View PR",
+	"star.milestone":           "🌟 *Crossed %d stars!*\n\n",
+	"star.new":                 "⭐ *New star*\n\n",
+	"star.total":               "📈 Total: %d stars\n",
+	"star.view":                "View Stargazers",
+	"review.approved":          "✅ *PR #%d approved*",
+	"review.changes_requested": "🛠 *PR #%d changes requested*",
+	"review.commented":         "👀 *PR #%d reviewed*",
+	"review.view":              "View Review",
+	"review_comment.title":     "💬 *New review comment on PR #%d*\n\n",
+	"review_comment.view":      "View Comment",
+	"workflow.title":           "%s *Workflow %s: %s*\n\n",
+	"workflow.run":             "🔢 Run #%d",
+	"workflow.attempt":         " (attempt %d)",
+	"workflow.view":            "View Run",
+	"deployment.title":         "%s *Deployment to %s: %s*\n\n",
+	"deployment.view":          "View Deployments",
+	"deployment.open":          "Open Environment",
+	"wiki.title":               "📖 *Wiki updated by %s*\n\n",
+	"wiki.diff":                "diff",
+	"wiki.more":                "\n_...and %d more pages_\n",
+	"repository.moved":         "✏️ *Repository %s*\n\n",
+	"repository.moved_note":    "Subscriptions were moved to the new name automatically.\n",
+	"repository.archived":      "📦 *Repository archived*\n\nIt is now read-only and will not receive new activity.\n",
+	"repository.unarchived":    "📂 *Repository unarchived*\n",
+	"repository.other":         "📋 *Repository %s*\n",
+	"topic.new_repo":           "🏷 *New repository in topic %s*\n\n",
+	"topic.language":           "💻 Language: %s\n",
+	"topic.stars":              "⭐ Stars: %d\n",
+	"repository.view":          "View Repository",
+	"assets.title":             "\n\n📦 *Matching assets:*\n",
+	"assets.missing_title":     "⚠️ *Released without a matching asset*\n\n",
+	"assets.missing_note":      "\nNo matching asset appeared within the wait window.\n",
+	"compliance.alert":         "⚠️ *COMPLIANCE DRIFT DETECTED* ⚠️\n\n",
+	"compliance.allowed":       "\n📜 Allowed licenses: `%s`\n",
+	"compliance.note":          "\nThis subscription stays flagged in /list until acknowledged.\n",
+	"compliance.resolved":      "✅ *Back in compliance*\n\n",
+	"compliance.ack_button":    "✅ Acknowledge",
+	"notify.open_button":       "🔗 Open on GitHub",
+	"notify.mute_button":       "🔇 Mute 1h",
+	"notify.unsub_button":      "❌ Unsubscribe",
+
+	// Summaries of queued events
+	"summary.more":           "  _...and %d more_\n",
+	"summary.quiet_title":    "🌙 *%d notifications during quiet hours*",
+	"summary.batch_title":    "🔔 *%d new events*",
+	"summary.daily_title":    "📰 *Daily digest: %d events*",
+	"summary.weekly_title":   "📰 *Weekly digest: %d events*",
+	"summary.push":           "%d commits to `%s`",
+	"summary.push_one":       "%d commit to `%s`",
+	"summary.release":        "Release %s",
+	"summary.tag":            "Tag `%s`",
+	"summary.review":         "#%[1]d %[3]s by %[2]s",
+	"summary.review_comment": "#%d comment by %s",
+	"summary.workflow":       "%s %s on `%s`",
+	"summary.wiki":           "%d wiki page(s) changed by %s",
+	"summary.milestone":      "Crossed %d stars",
+	"summary.star":           "Starred by %s (%d stars)",
+	"summary.repository":     "Repository %s",
+	"summary.event":          "Event",
+}
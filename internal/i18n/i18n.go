@@ -0,0 +1,110 @@
+// Package i18n provides the translated texts shown to Telegram users.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// Lang identifies a supported language.
+type Lang string
+
+// Supported languages.
+const (
+	English Lang = "en"
+	Chinese Lang = "zh"
+)
+
+// catalogs maps each language to its messages by key.
+var catalogs = map[Lang]map[string]string{
+	English: english,
+	Chinese: chinese,
+}
+
+// defaultLang is used for chats that did not choose a language.
+var defaultLang = Chinese
+
+// SetDefault sets the language of chats without a language setting.
+func SetDefault(lang Lang) {
+	if _, ok := catalogs[lang]; ok {
+		defaultLang = lang
+	}
+}
+
+// Default returns the language of chats without a language setting.
+func Default() Lang {
+	return defaultLang
+}
+
+// Languages returns all supported languages.
+func Languages() []Lang {
+	return []Lang{English, Chinese}
+}
+
+// Parse resolves a language code such as "en" or "zh-CN".
+func Parse(code string) (Lang, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	base, _, _ := strings.Cut(strings.ReplaceAll(code, "_", "-"), "-")
+	lang := Lang(base)
+	if _, ok := catalogs[lang]; !ok {
+		return "", false
+	}
+	return lang, true
+}
+
+// Resolve returns the language for a stored language code, falling back to
+// the default language when it is empty or unsupported.
+func Resolve(code string) Lang {
+	if lang, ok := Parse(code); ok {
+		return lang
+	}
+	return defaultLang
+}
+
+// T returns the message for key in lang as MarkdownV2, formatted with args.
+// Arguments are escaped unless they are markdown.Raw. Missing translations
+// fall back to English, then to the key itself.
+func T(lang Lang, key string, args ...interface{}) string {
+	return markdown.Sprintf(message(lang, key), args...)
+}
+
+// Plain returns the message for key in lang formatted with args, without any
+// markup processing, for texts such as button labels that are not parsed.
+func Plain(lang Lang, key string, args ...interface{}) string {
+	text := message(lang, key)
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// message returns the unformatted message for key, or the key itself.
+func message(lang Lang, key string) string {
+	if text, ok := Lookup(lang, key); ok {
+		return text
+	}
+	return key
+}
+
+// Lookup returns the unformatted message for key in lang, falling back to
+// English, and reports whether it exists.
+func Lookup(lang Lang, key string) (string, bool) {
+	if text, ok := catalogs[lang][key]; ok {
+		return text, true
+	}
+	text, ok := catalogs[English][key]
+	return text, ok
+}
+
+// All returns the message for key in every supported language.
+func All(key string) []string {
+	var texts []string
+	for _, lang := range Languages() {
+		if text, ok := catalogs[lang][key]; ok {
+			texts = append(texts, text)
+		}
+	}
+	return texts
+}
@@ -0,0 +1,611 @@
+package i18n
+
+// chinese holds the Simplified Chinese messages.
+var chinese = map[string]string{
+	// Common replies
+	"common.unknown_command":     "未知命令。使用 /help 查看可用命令。",
+	"common.repo_format":         "❌ 仓库格式错误，请使用: `owner/repo`",
+	"common.sub_not_found":       "❌ 未找到 `%s/%s` 的订阅",
+	"common.sub_not_found_short": "❌ 未找到该订阅",
+	"common.failed":              "❌ 操作失败，请稍后重试",
+	"common.settings_failed":     "❌ 获取设置失败",
+	"common.unknown":             "未知",
+
+	// /start and /help
+	"start.text": "🤖 *欢迎使用 GitHub 监控机器人！*\n\n" +
+		"我可以帮助你监控 *任意 GitHub 公有仓库* 的变动，包括：\n" +
+		"• 📨 新的提交 (Push)\n" +
+		"• 🎉 版本发布 (Release)\n" +
+		"• 📝 Issue 变动\n" +
+		"• 🔀 Pull Request 变动\n\n" +
+		"*快速开始：*\n" +
+		"使用 `/subscribe owner/repo` 订阅仓库即可！\n\n" +
+		"*示例：*\n" +
+		"`/subscribe torvalds/linux`\n" +
+		"`/subscribe microsoft/vscode`\n\n" +
+		"使用 /help 查看所有命令。",
+	"help.text": "📚 *命令帮助*\n\n" +
+		"*订阅管理：*\n" +
+		"• `/subscribe <owner/repo> [events]` - 订阅仓库，可选事件: push, releases, tags, packages, issues, prs, reviews, review\\_comments, stars, ci, deployments, wiki，或预设 preset=releases-only|maintainer|everything\n" +
+		"• `/unsubscribe <owner/repo>` - 取消订阅\n" +
+		"• `/subscribe user:<login> [events]` - 关注 GitHub 用户的所有公开仓库，包括之后新建的仓库\n" +
+		"• `/subscribe topic:<topic>` - 有新仓库加入该主题时收到通知\n" +
+		"• `/list` - 查看当前订阅\n" +
+		"• `/stats` - 查看本周各订阅发送的通知数量\n" +
+		"• `/export` / `/import` - 将订阅导出为文件，或从文件恢复订阅\n" +
+		"• `/info <owner/repo>` - 查看仓库详情\n" +
+		"• `/test <owner/repo> [push|release|issue]` - 在本聊天中预览最新提交、Release 或 Issue 的通知\n" +
+		"• `/latest <owner/repo>` - 查看最新发布的版本\n" +
+		"• `/commits <owner/repo> [n]` - 列出默认分支最近的提交\n" +
+		"• `/history <owner/repo> [n]` - 列出已订阅仓库最近通知过的事件\n" +
+		"• `/trending [language] [daily|weekly]` - 查看 Star 最多的新仓库\n" +
+		"• `/issues <owner/repo> [open|closed]` / `/prs <owner/repo> [open|closed|merged]` - 浏览 Issue 或 PR\n" +
+		"• `/settings <owner/repo>` - 开关各类事件通知\n" +
+		"• `/mute <owner/repo> [2h]` - 暂时静音订阅，`/unmute` 恢复\n" +
+		"• `/pause` / `/resume` - 暂停或恢复本聊天的所有通知\n" +
+		"• `/quiet 23:00-08:00` - 设置免打扰时段，期间的通知将在结束后汇总发送\n" +
+		"• `/digest <owner/repo> daily|weekly|off` - 将订阅改为每日/每周摘要\n" +
+		"• `/silent <owner/repo> push,stars|off` - 静默推送部分事件类型（无提示音）\n" +
+		"• `/timezone Europe/Berlin` - 设置本聊天的时区\n" +
+		"• `/language en|zh` - 设置本聊天的语言\n" +
+		"• `/format markdown|html` - 设置本聊天通知的渲染格式\n" +
+		"• `/photos on|off` - 以 GitHub 预览图发送通知，文字作为图片说明\n" +
+		"• `/dryrun on|off` - 本聊天的通知只记录不发送 (演练模式)\n" +
+		"• `/template <owner/repo> [event] [template]` - 自定义订阅的通知文本\n" +
+		"• `/login` - 关联 GitHub 账号以订阅私有仓库，`/logout` 取消关联\n" +
+		"• `/settoken <token>` - 为此聊天的私有仓库改用个人访问令牌 (需在私聊中发送)\n" +
+		"• `/hook new <name>` - 创建一个可供其他服务 POST JSON 的 URL，`/hook` 列出所有钩子\n" +
+		"• `/bindchannel @channel` - 将本聊天的通知发布到频道，`/unbindchannel` 取消\n" +
+		"• `/filter <owner/repo> assets:<glob>` - 仅在 Release 包含匹配资源时通知\n\n" +
+		"*快捷命令：*\n" +
+		"• `/sub` - 订阅仓库的简写\n" +
+		"• `/unsub` - 取消订阅的简写\n\n" +
+		"*示例：*\n" +
+		"```\n" +
+		"/subscribe torvalds/linux\n" +
+		"/subscribe microsoft/vscode\n" +
+		"/sub golang/go releases,issues\n" +
+		"/list\n" +
+		"/unsub torvalds/linux\n" +
+		"```\n\n" +
+		"💡 订阅后，当仓库有新的 commit、release、issue 或 PR 时，你将自动收到通知。",
+
+	// /subscribe and /unsubscribe
+	"subscribe.user_usage":        "❌ 请指定 GitHub 用户，格式: `/subscribe user:login [events]`",
+	"subscribe.user_not_found":    "❌ GitHub 用户 `%s` 不存在",
+	"subscribe.user_success":      "✅ *已关注 %s*，订阅了 %d 个仓库\n\n监控事件：\n",
+	"subscribe.user_footer":       "\n该用户新建的公开仓库将被自动订阅！",
+	"subscribe.topic_usage":       "❌ 请指定由小写字母、数字和连字符组成的主题，如: `/subscribe topic:telegram-bot`",
+	"subscribe.topic_success":     "✅ 已关注主题 `%s`，带有该主题的新仓库将在此通知",
+	"subscribe.usage":             "❌ 请指定仓库，格式: `/subscribe owner/repo [events]`",
+	"subscribe.invalid_events":    "❌ 事件类型错误，可选: `push`, `releases`, `tags`, `packages`, `issues`, `prs`, `reviews`, `review_comments`, `stars`, `ci`, `deployments`, `wiki`, `all`，或预设: `preset=releases-only`, `preset=maintainer`, `preset=everything`",
+	"subscribe.validate_error":    "⚠️ 验证仓库时出错，请稍后重试",
+	"subscribe.repo_not_found":    "❌ 仓库 `%s/%s` 不存在或不可访问",
+	"subscribe.private_hint":      "\n如果是私有仓库，请先使用 /login 或 /settoken 关联你的 GitHub 账号",
+	"subscribe.failed":            "❌ 订阅失败，请稍后重试",
+	"subscribe.chat_quota":        "⚠️ 此聊天的订阅数已达上限 %d 个，请先通过 /list 取消订阅部分仓库",
+	"subscribe.repo_quota":        "⚠️ 本 Bot 监控的仓库数已达上限，目前只能订阅其他聊天已在关注的仓库",
+	"subscribe.success":           "✅ *成功订阅 %s/%s*\n\n监控事件：\n",
+	"subscribe.success_footer":    "\n当仓库有新动态时，你将自动收到通知！",
+	"unsubscribe.usage":           "❌ 请指定仓库，格式: `/unsubscribe owner/repo`",
+	"unsubscribe.failed":          "❌ 取消订阅失败，请稍后重试",
+	"unsubscribe.failed_short":    "❌ 取消订阅失败",
+	"unsubscribe.user_not_found":  "❌ 未关注 GitHub 用户 `%s`",
+	"unsubscribe.user_success":    "✅ 已取消关注 `%s`，取消订阅了 %d 个仓库",
+	"unsubscribe.topic_not_found": "❌ 未关注主题 `%s`",
+	"unsubscribe.topic_success":   "✅ 已取消关注主题 `%s`",
+	"unsubscribe.success":         "✅ 已取消订阅 `%s/%s`",
+
+	// Event type labels
+	"event.push":                        "📨 Push (提交)",
+	"event.release":                     "🎉 Release (发布)",
+	"event.tag":                         "🏷️ Tags",
+	"event.package":                     "📦 Packages",
+	"event.issues":                      "📝 Issues",
+	"event.pull_request":                "🔀 Pull Requests",
+	"event.star":                        "⭐ Stars",
+	"event.workflow_run":                "⚙️ CI (Actions)",
+	"event.deployment":                  "🚀 Deployments",
+	"event.wiki":                        "📖 Wiki",
+	"event.pull_request_review":         "👀 PR Reviews",
+	"event.pull_request_review_comment": "💬 Review Comments",
+
+	// /pause, /resume, /quiet, /timezone, /language
+	"pause.paused":     "⏸ 已暂停本聊天的所有通知，订阅列表保持不变。使用 /resume 恢复",
+	"pause.resumed":    "▶️ 已恢复本聊天的通知",
+	"quiet.none":       "🌙 未设置免打扰时段。使用 `/quiet 23:00-08:00` 设置",
+	"quiet.current":    "🌙 免打扰时段: `%s`\n使用 `/quiet off` 关闭",
+	"quiet.invalid":    "❌ 时段格式错误，例如: `/quiet 23:00-08:00`",
+	"quiet.disabled":   "🔔 已关闭免打扰时段，积压的通知将很快汇总发送",
+	"quiet.set":        "🌙 已设置免打扰时段 `%s`（%s），期间的通知将在结束后汇总发送",
+	"timezone.current": "🕒 当前时区: `%s`（%s）\n使用 `/timezone Europe/Berlin` 设置，`/timezone off` 恢复服务器时区",
+	"timezone.invalid": "❌ 未知时区，请使用 IANA 时区名，例如: `Europe/Berlin`、`Asia/Shanghai`",
+	"timezone.reset":   "🕒 已恢复服务器时区 `%s`",
+	"timezone.set":     "🕒 时区已设置为 `%s`，免打扰时段和摘要将按此时区计算",
+	"language.current": "🌐 当前语言: 中文\n使用 `/language en` 或 `/language zh` 切换",
+	"language.invalid": "❌ 不支持的语言，可选: `en`, `zh`",
+	"language.set":     "🌐 已切换为中文",
+	"format.current":   "📝 通知格式: %s\n使用 `/format html` 或 `/format markdown` 切换，`/format default` 恢复默认",
+	"format.default":   "默认",
+	"format.invalid":   "❌ 格式必须是 `markdown`、`html` 或 `default`",
+	"format.set":       "📝 本聊天的通知将使用 %s",
+	"format.reset":     "📝 本聊天的通知将使用默认格式",
+	"photos.on":        "🖼 通知将以 GitHub 预览图发送\n使用 `/photos off` 改为纯文字",
+	"photos.off":       "🖼 通知将以纯文字发送\n使用 `/photos on` 附带 GitHub 预览图",
+	"photos.invalid":   "❌ 请使用 `/photos on` 或 `/photos off`",
+	"dryrun.on":        "🧪 演练模式: 通知会被记录并保存在 /history 中，但不会发送\n使用 `/dryrun off` 恢复发送",
+	"dryrun.off":       "🧪 通知正常发送\n使用 `/dryrun on` 在调试过滤器和模板时只记录不发送",
+	"dryrun.invalid":   "❌ 请使用 `/dryrun on` 或 `/dryrun off`",
+
+	// /mute, /unmute, /digest
+	"mute.usage":            "❌ 请指定仓库，格式: `/mute owner/repo 2h`",
+	"mute.invalid_duration": "❌ 时长格式错误，例如: `30m`, `2h`, `1d`（最长 30 天）",
+	"mute.failed":           "❌ 静音失败，请稍后重试",
+	"mute.success":          "🔇 已静音 `%s/%s` %s，可用 `/unmute %s/%s` 提前恢复",
+	"unmute.usage":          "❌ 请指定仓库，格式: `/unmute owner/repo`",
+	"unmute.failed":         "❌ 取消静音失败，请稍后重试",
+	"unmute.success":        "🔔 已恢复 `%s/%s` 的通知",
+	"digest.usage":          "❌ 格式: `/digest owner/repo daily|weekly|off`",
+	"digest.invalid_mode":   "❌ 模式只能是 `daily`、`weekly` 或 `off`",
+	"digest.failed":         "❌ 设置失败，请稍后重试",
+	"digest.daily":          "📰 `%s/%s` 的通知将汇总为每日摘要发送",
+	"digest.weekly":         "📰 `%s/%s` 的通知将汇总为每周摘要（周一）发送",
+	"digest.off":            "🔔 `%s/%s` 已恢复实时通知",
+
+	// Silent notifications
+	"silent.usage":   "❌ 格式: `/silent owner/repo [events|all|off]`，例如 `/silent owner/repo push,stars`",
+	"silent.none":    "🔔 `%s/%s` 的所有通知都会响铃",
+	"silent.current": "🔕 `%s/%s` 的静默通知: %s",
+	"silent.set":     "🔕 `%s/%s` 的以下通知将静默送达: %s",
+	"silent.off":     "🔔 `%s/%s` 的所有通知将恢复响铃",
+	"silent.failed":  "❌ 设置失败，请稍后重试",
+
+	// Custom message templates
+	"template.usage":         "❌ 格式: `/template owner/repo [event] [template|off]`",
+	"template.none":          "📝 `%s/%s` 使用内置消息\n设置模板: `/template %s/%s push \"{{.Pusher}} pushed to {{.Branch}}\"`",
+	"template.list":          "📝 *%s/%s 的消息模板:*\n",
+	"template.builtin":       "📝 `%s/%s` 的 %s 事件使用内置消息\n",
+	"template.current":       "📝 %s 模板 (`%s/%s`):\n```\n%s\n```\n",
+	"template.fields":        "可用字段: %s\n函数: truncate, firstLine, join, upper, lower\n使用 `off` 恢复内置消息",
+	"template.invalid_event": "❌ 未知的事件类型 `%s`",
+	"template.invalid":       "❌ 模板无效: %s",
+	"template.failed":        "❌ 设置失败，请稍后重试",
+	"template.set":           "✅ 已保存 `%[2]s/%[3]s` 的 %[1]s 模板",
+	"template.reset":         "🔔 `%s/%s` 的 %s 事件已恢复内置消息",
+
+	// Custom hooks
+	"hook.usage":            "❌ 格式: `/hook [new|template|delete] <name>`\n名称只能包含字母、数字、- 和 _",
+	"hook.failed":           "❌ 更新自定义钩子失败，请稍后重试",
+	"hook.none":             "🪝 本聊天没有自定义钩子\n使用 `/hook new <name>` 创建一个，即可将其他服务的 webhook 转发到这里",
+	"hook.list":             "🪝 *本聊天的自定义钩子:*\n\n",
+	"hook.url_hidden":       "URL 仅在创建时显示",
+	"hook.limit":            "❌ 每个聊天最多可以创建 %d 个自定义钩子",
+	"hook.exists":           "❌ 名为 `%s` 的钩子已存在",
+	"hook.not_found":        "❌ 没有名为 `%s` 的钩子",
+	"hook.created":          "✅ 已创建钩子 *%s*，请将 JSON POST 到:\n`%s`\n\n默认原样显示内容，也可以设置模板: `/hook template %s \"{{.status}}: {{.message}}\"`",
+	"hook.deleted":          "🗑 已删除钩子 `%s`，其 URL 已失效",
+	"hook.template_none":    "📝 钩子 `%s` 原样显示内容",
+	"hook.template_current": "📝 钩子 `%s` 的模板:\n```\n%s\n```\n使用 `off` 恢复原样显示",
+	"hook.template_set":     "✅ 已保存钩子 `%s` 的模板",
+	"hook.template_reset":   "🔔 钩子 `%s` 已恢复原样显示内容",
+
+	// Routing to sinks
+	"route.usage":           "❌ 格式: `/route owner/repo [destination|chat]`",
+	"route.admin_only":      "⛔ 只有机器人管理员可以设置订阅路由",
+	"route.chat":            "➡️ `%s/%s` 的通知发送到本聊天",
+	"route.current":         "➡️ `%s/%s` 的通知发送到 `%s`\n使用 `chat` 恢复发送到本聊天",
+	"route.unknown":         "❌ 未知的目标 `%s`",
+	"route.available":       "\n可用目标: %s",
+	"route.none_configured": "\n尚未配置任何目标，请在配置文件的 `discord.webhooks`、`slack.webhooks` 或 `matrix.rooms` 中添加 Discord、Slack Webhook 或 Matrix 房间",
+	"route.set":             "✅ `%s/%s` 的通知将发送到 `%s`",
+	"route.reset":           "🔔 `%s/%s` 的通知将重新发送到本聊天",
+	"route.failed":          "❌ 保存失败，请稍后重试",
+
+	// Linked GitHub accounts
+	"login.disabled":     "❌ 此机器人未启用 GitHub 账号关联",
+	"login.private_only": "🔒 请在与机器人的私聊中发送 /login",
+	"login.linked":       "🔗 已关联 GitHub 账号 *%s*\n使用 /logout 取消关联",
+	"login.pending":      "⏳ 已有一个登录在等待验证码，请在 GitHub 上输入，或发送 /logout 取消",
+	"login.code":         "🔗 *关联 GitHub 账号*\n\n1. 打开 %s\n2. 输入验证码 `%s`\n\n验证码 %d 分钟后过期。关联后即可订阅该账号可访问的私有仓库",
+	"login.success":      "✅ 已关联 GitHub 账号 *%s*\n现在可以订阅该账号可访问的私有仓库了",
+	"login.denied":       "❌ 登录已在 GitHub 上被拒绝",
+	"login.expired":      "⌛ 验证码已过期，请重新发送 /login",
+	"login.failed":       "❌ 关联 GitHub 账号失败，请稍后重试",
+	"logout.done":        "🔓 已取消关联 GitHub 账号 *%s*\n你订阅的私有仓库将不再使用该账号读取",
+	"logout.none":        "ℹ️ 尚未关联 GitHub 账号",
+	"logout.failed":      "❌ 取消关联 GitHub 账号失败，请稍后重试",
+
+	"settoken.disabled":      "❌ 此机器人未启用 GitHub Token 存储",
+	"settoken.private_only":  "🔒 请在与机器人的私聊中发送 /settoken\n如果你已在此处发送了 Token，请立即在 GitHub 上撤销它",
+	"settoken.none":          "🔑 此聊天尚未设置 GitHub Token\n发送 `/settoken <token>` 并附上个人访问令牌，即可为此聊天订阅它可访问的私有仓库",
+	"settoken.current":       "🔑 此聊天使用 GitHub 账号 *%s* 的 Token\n配额: %s\n使用 `/settoken off` 移除",
+	"settoken.invalid":       "❌ GitHub 不接受此 Token",
+	"settoken.saved":         "✅ 已为此聊天保存 GitHub 账号 *%s* 的 Token\n此聊天现在可以订阅它可访问的私有仓库了",
+	"settoken.removed":       "✅ 已从此聊天移除 GitHub 账号 *%s* 的 Token",
+	"settoken.remove_failed": "❌ 移除 Token 失败，请稍后重试",
+	"settoken.delete_failed": "⚠️ 无法删除包含 Token 的消息，请手动删除",
+	"settoken.failed":        "❌ 保存 Token 失败，请稍后重试",
+
+	// Channel posting
+	"channel.none":           "📢 通知发送到本聊天\n如需发布到频道，请先将机器人设为频道管理员，然后使用 `/bindchannel @channel` 或将频道中的消息转发给机器人",
+	"channel.current":        "📢 本聊天的通知发布到 *%s*\n使用 `/unbindchannel` 恢复发送到本聊天",
+	"channel.bound":          "📢 本聊天的通知将发布到 *%s*",
+	"channel.unbound":        "🔔 通知将重新发送到本聊天",
+	"channel.not_found":      "❌ 找不到频道 `%s`，机器人必须是该频道的成员",
+	"channel.not_channel":    "❌ 该聊天不是频道",
+	"channel.bot_not_admin":  "❌ 机器人必须是 *%s* 的管理员并拥有发布消息权限",
+	"channel.user_not_admin": "❌ 只有 *%s* 的管理员才能绑定该频道",
+	"channel.failed":         "❌ 设置失败，请稍后重试",
+	"channel.lost":           "⚠️ 机器人已无法向 *%s* 发布消息，通知将重新发送到本聊天",
+
+	// Compliance acknowledgement
+	"ack.failed":  "❌ 确认失败",
+	"ack.success": "✅ 已确认 `%s/%s` 的合规警告",
+
+	// /list
+	"info.usage":                "❌ 请指定仓库，格式: `/info owner/repo`",
+	"info.failed":               "❌ 获取仓库信息失败，请稍后重试",
+	"info.stars":                "⭐ Star: %d · 🍴 Fork: %d\n",
+	"info.open_issues":          "📝 未关闭的 Issue 和 PR: %d\n",
+	"info.language":             "💻 语言: %s\n",
+	"info.license":              "📜 许可证: %s\n",
+	"info.pushed_at":            "🕒 最近推送: %s\n",
+	"info.archived":             "📦 已归档，只读\n",
+	"info.subscribe_button":     "➕ 订阅",
+	"history.usage":             "❌ 请指定仓库，格式: `/history owner/repo [n]`",
+	"history.invalid_count":     "❌ 事件数量必须在 1 到 %d 之间",
+	"history.not_subscribed":    "❌ 此聊天未订阅 `%s/%s`",
+	"history.failed":            "❌ 加载事件历史失败，请稍后重试",
+	"history.none":              "📭 `%s/%s` 还没有通知过任何事件",
+	"history.title":             "🕘 *%[1]s/%[2]s 最近的 %[3]d 个事件*\n\n",
+	"commits.usage":             "❌ 请指定仓库，格式: `/commits owner/repo [n]`",
+	"commits.invalid_count":     "❌ 提交数量必须在 1 到 %d 之间",
+	"commits.failed":            "❌ 获取提交记录失败，请稍后重试",
+	"commits.none":              "📭 `%s/%s` 还没有任何提交",
+	"issues.usage":              "❌ 请指定仓库，格式: `/issues owner/repo [open|closed]`",
+	"prs.usage":                 "❌ 请指定仓库，格式: `/prs owner/repo [open|closed|merged]`",
+	"issues.failed":             "❌ 搜索失败，请稍后重试",
+	"issues.none":               "📭 没有找到任何结果",
+	"issues.title_open":         "📝 *%s/%s 未关闭的 Issue* (%d)\n",
+	"issues.title_closed":       "📝 *%s/%s 已关闭的 Issue* (%d)\n",
+	"prs.title_open":            "🔀 *%s/%s 未关闭的 PR* (%d)\n",
+	"prs.title_closed":          "🔀 *%s/%s 已关闭的 PR* (%d)\n",
+	"prs.title_merged":          "🔀 *%s/%s 已合并的 PR* (%d)\n",
+	"trending.failed":           "❌ 获取热门仓库失败，请稍后重试",
+	"trending.none":             "📭 没有找到热门仓库",
+	"trending.title_daily":      "🔥 *今日热门*",
+	"trending.title_weekly":     "🔥 *本周热门*",
+	"trending.language":         " · %s",
+	"trending.subscribe_button": "➕ %s",
+	"stats.failed":              "❌ 获取统计数据失败",
+	"stats.none":                "📭 最近 7 天没有任何通知",
+	"stats.title":               "📊 *最近 7 天的通知*\n\n",
+	"stats.total":               "共 %d 条通知，来自 %d 个仓库\n\n",
+	"stats.by_event":            "*按事件：*\n",
+	"stats.by_repo":             "\n*通知最多的仓库：*\n",
+	"access.denied":             "⛔ 此聊天无法使用本 Bot",
+	"access.pending":            "⏳ 此聊天仍在等待管理员批准",
+	"access.requested":          "⏳ 需要管理员批准后才能在此聊天中使用本 Bot，结果出来后会通知您",
+	"access.granted":            "✅ 管理员已批准此聊天，现在可以使用本 Bot 了。发送 /help 开始使用",
+	"access.failed":             "❌ 更新聊天的访问权限失败，请稍后重试",
+	"access.request":            "🔐 *新的使用申请*\n\n💬 %s (%s, `%d`)\n👤 %s",
+	"access.approve_button":     "✅ 批准",
+	"access.deny_button":        "⛔ 拒绝",
+	"access.decided_approved":   "✅ %s (`%d`) 已由 %s 批准",
+	"access.decided_denied":     "⛔ %s (`%d`) 已被 %s 拒绝",
+	"export.failed":             "❌ 导出订阅失败，请稍后重试",
+	"export.caption":            "📦 %d 个订阅，%d 个关注的用户，%d 个话题\n\n将此文件以 /import 为说明文字发送到其他聊天或 Bot 即可恢复",
+	"import.usage":              "❌ 请发送导出的文件并以 /import 作为说明文字，或回复该文件 /import",
+	"import.invalid":            "❌ 这不是本 Bot 可识别的订阅导出文件",
+	"import.failed":             "❌ 导入订阅失败，请稍后重试",
+	"import.success":            "✅ 已导入 %d 个订阅，%d 个关注的用户，%d 个话题\n",
+	"import.skipped":            "⚠️ 已跳过 %d 个无效条目\n",
+	"import.denied":             "⚠️ 以下仓库不存在或你无权访问，未导入: %s\n",
+	"import.quota":              "⚠️ 已达订阅数上限，部分订阅未导入\n",
+	"admin.usage":               "❌ 用法: `/admin stats|backup|audit`",
+	"admin.backup_disabled":     "❌ 未配置数据库备份",
+	"admin.backup_failed":       "❌ 备份失败，详情请查看日志",
+	"admin.backup_done":         "💾 已保存 `%s` (%.1f MB)",
+	"admin.backup_uploaded":     "💾 已保存并上传 `%s` (%.1f MB)",
+	"admin.quota":               "剩余 %d/%d，%s 重置",
+	"audit.usage":               "❌ 用法: `/admin audit [chat:<id>] [user:<id>] [cmd:<命令>] [n]`",
+	"audit.invalid_count":       "❌ 条数必须在 1 到 %d 之间",
+	"audit.failed":              "❌ 加载审计日志失败，请稍后重试",
+	"audit.none":                "📭 没有符合条件的命令记录",
+	"audit.title":               "🧾 *最近 %d 条命令*\n\n",
+	"audit.handled":             "✅",
+	"audit.denied":              "⛔ 已拒绝",
+	"audit.unknown":             "❓ 未知命令",
+	"webhook.ping":              "🔔 *Webhook 已设置*\n\n📦 %s\n🆔 Hook `%d`\n⚡ %s\n\n_%s_",
+	"admin.stats": "📊 *机器人统计*\n\n" +
+		"💬 聊天: %d 个 (%d 个不可用)\n" +
+		"📋 订阅: %d 个\n" +
+		"📦 仓库: %d 个\n" +
+		"👤 关注的用户: %d 个\n" +
+		"🏷 关注的主题: %d 个\n" +
+		"⚡ 24 小时内处理的事件: %d 个\n" +
+		"📤 待投递: %d 条\n" +
+		"🕒 等待摘要和免打扰结束: %d 条\n" +
+		"🔑 GitHub 配额: %s\n",
+	"test.usage":          "❌ 请指定仓库: `/test owner/repo [push|release|issue]`",
+	"test.invalid_event":  "❌ 无效的事件类型，可选: `push`、`release`、`issue`",
+	"test.failed":         "❌ 发送预览失败，请稍后重试",
+	"test.not_found":      "❌ 仓库 `%s/%s` 不存在或无法访问",
+	"test.none":           "📭 `%s/%s` 暂无可预览的 %s 事件",
+	"test.not_subscribed": "🧪 以上为预览。本聊天未订阅 `%s/%s`，显示的是默认格式",
+	"test.filtered":       "🧪 以上为预览。由于事件类型、过滤器或静音设置，`%s/%s` 的订阅不会推送此事件",
+	"test.delivered":      "🧪 以上为预览。订阅会按此格式推送该事件",
+	"latest.usage":        "❌ 请指定仓库，格式: `/latest owner/repo`",
+	"latest.failed":       "❌ 获取最新版本失败，请稍后重试",
+	"latest.none":         "📭 `%s/%s` 还没有发布任何版本",
+	"latest.published":    "📅 发布于 %s\n",
+	"list.failed":         "❌ 获取订阅列表失败",
+	"list.empty":          "📭 当前没有任何订阅\n\n使用 `/subscribe owner/repo` 来订阅仓库",
+	"list.title":          "📋 *当前订阅 (%d 个)*\n\n",
+	"list.paused":         "⏸ 通知已暂停，使用 /resume 恢复\n\n",
+	"list.dry_run":        "🧪 演练模式: 通知只记录不发送，使用 `/dryrun off` 恢复发送\n\n",
+	"list.muted_until":    "   🔇 静音至 %s\n",
+	"list.digest_daily":   "   📰 每日摘要\n",
+	"list.digest_weekly":  "   📰 每周摘要\n",
+	"list.routed":         "   ➡️ 发送到 `%s`\n",
+	"list.compliance":     "   ⚠️ 合规警告: %s\n",
+	"list.ack_button":     "✅ 确认 %s/%s",
+	"list.unsub_button":   "✖ %s/%s",
+	"list.page":           "\n第 %d/%d 页\n",
+	"list.prev":           "◀ 上一页",
+	"list.next":           "下一页 ▶",
+	"list.footer":         "\n使用 `/unsubscribe owner/repo` 取消订阅",
+
+	// /status
+	"status.rate_limit": "%d/%d (%s 后重置，%s)",
+	"status.text": "📊 *Bot 状态*\n\n" +
+		"⏱️ *运行时间:* %s\n" +
+		"🕒 *当前时间:* %s (%s)\n" +
+		"📡 *监控模式:* Polling\n\n" +
+		"📦 *全局统计:*\n" +
+		"• 监控仓库数: %d\n" +
+		"• 无法送达的聊天 (已屏蔽或移除 Bot): %d\n\n" +
+		"👤 *你的订阅:*\n" +
+		"• 订阅数: %d\n\n" +
+		"🔗 *GitHub API:*\n" +
+		"• 配额: %s\n",
+	"status.lag_title":  "\n🚚 *投递延迟 (中位数):*\n",
+	"status.lag_detail": " (发现 %s + 发送 %s)\n",
+	"status.poll_title": "\n⏲️ *轮询频率:*\n",
+	"status.more_repos": "• _...以及另外 %d 个仓库_\n",
+
+	// /setting
+	"setting.admin_only":    "⛔ 此命令仅限管理员使用",
+	"setting.unavailable":   "❌ 设置存储不可用",
+	"setting.usage":         "用法：\n`/setting get <key>`\n`/setting set <key> <value>`\n\n可用的键：\n",
+	"setting.forbidden":     "❌ 不允许访问设置 `%s`",
+	"setting.read_failed":   "❌ 读取设置失败",
+	"setting.unset":         "⚙️ `%s` 未设置（使用默认值）",
+	"setting.set_usage":     "❌ 请指定值，格式: `/setting set <key> <value>`",
+	"setting.invalid_value": "❌ 无效的值: %s",
+	"setting.saved":         "✅ 已设置 `%s` = `%s`",
+
+	// /settings
+	"settings.usage":         "❌ 请指定仓库，格式: `/settings owner/repo`",
+	"settings.load_failed":   "❌ 获取订阅失败",
+	"settings.title":         "⚙️ *%s/%s 通知设置*\n\n点击按钮开启或关闭对应事件：",
+	"settings.keep_one":      "⚠️ 至少需要保留一种事件，如需停止通知请使用 `/unsubscribe`",
+	"settings.update_failed": "❌ 更新设置失败",
+
+	// /filter
+	"filter.usage":                "❌ 请指定仓库，格式: `/filter owner/repo assets:<glob>`",
+	"filter.load_failed":          "❌ 获取过滤条件失败",
+	"filter.invalid":              "❌ 无效的过滤条件 `%s`，格式: `类型:值`",
+	"filter.invalid_platforms":    "❌ 平台列表无效，格式: `platforms:linux_amd64,darwin_arm64`",
+	"filter.invalid_assets":       "❌ 无效的资源匹配模式",
+	"filter.compliance_format":    "❌ 格式: `compliance:license=MIT,Apache-2.0`",
+	"filter.invalid_licenses":     "❌ 无效的许可证列表: %s",
+	"filter.invalid_branches":     "❌ 无效的分支列表，格式: `branch:main,release/*`",
+	"filter.invalid_labels":       "❌ 无效的标签列表，格式: `labels:bug,-dependencies`",
+	"filter.invalid_ignore":       "❌ 无效的用户列表，格式: `ignore:dependabot[bot],renovate[bot]`",
+	"filter.invalid_regex":        "❌ 无效的正则表达式（不能包含空格，可用 `\\s` 代替）",
+	"filter.ci_format":            "❌ 格式: `ci:failures` 或 `ci:failures+recovery`",
+	"filter.unknown":              "❌ 未知的过滤类型 `%s`",
+	"filter.save_failed":          "❌ 保存过滤条件失败",
+	"filter.updated":              "✅ 已更新过滤条件\n\n",
+	"filters.title":               "🔍 *%s/%s 的过滤条件*\n\n",
+	"filters.assets":              "• 资源: %s\n",
+	"filters.platforms":           "• 列出的资源: %s\n",
+	"filters.licenses":            "• 合规许可证: %s\n",
+	"filters.branches":            "• 分支: %s\n",
+	"filters.labels":              "• 标签: %s\n",
+	"filters.required_labels":     "• 需要标签: %s\n",
+	"filters.excluded_labels":     "• 排除标签: %s\n",
+	"filters.ignore":              "• 忽略用户: %s\n",
+	"filters.include":             "• 包含: %s\n",
+	"filters.exclude":             "• 排除: %s\n",
+	"filters.prereleases":         "• 预发布版本: %s\n",
+	"filters.prereleases_skipped": "• 预发布版本: 跳过，包括标签匹配 %s 的版本\n",
+	"filters.prerelease_default":  "nightly、rc、beta 等",
+	"filters.ci":                  "• CI: %s\n",
+	"filters.none":                "无",
+	"filters.all":                 "全部",
+	"filters.ci_failures":         "仅失败",
+	"filters.ci_recovery":         "失败及首次恢复",
+	"filters.help": "\n设置方式：\n" +
+		"`/filter owner/repo assets:<glob>`\n" +
+		"`/filter owner/repo platforms:linux_amd64,darwin_arm64`\n" +
+		"`/filter owner/repo compliance:license=MIT,Apache-2.0`\n" +
+		"`/filter owner/repo ci:failures` 或 `ci:failures+recovery`\n" +
+		"`/filter owner/repo branch:main,release/*`\n" +
+		"`/filter owner/repo labels:bug,-dependencies`\n" +
+		"`/filter owner/repo include:(?i)panic exclude:^chore:`\n" +
+		"`/filter owner/repo prereleases:skip` 或 `prereleases:<标签正则>`\n" +
+		"`/filter owner/repo ignore:bots` (忽略 `%s`)\n" +
+		"值留空（如 `assets:`）则清除",
+
+	// /diagnose and poll schedules
+	"diagnose.usage":        "❌ 请指定仓库，格式: `/diagnose owner/repo`",
+	"diagnose.load_failed":  "❌ 获取订阅信息失败",
+	"diagnose.state_failed": "❌ 获取轮询状态失败",
+	"diagnose.title":        "🩺 *诊断: %s/%s*\n\n",
+	"diagnose.schedule":     "⏲️ 轮询: %s\n",
+	"diagnose.activity":     "📈 活跃度: 约 %.1f 个事件/天\n",
+	"diagnose.last_polled":  "🕒 上次检查: %s前\n",
+	"poll.never":            "尚未轮询",
+	"pollinterval.usage":    "❌ 用法: `/pollinterval owner/repo [2m|1h|auto]`",
+	"pollinterval.invalid":  "❌ 无效的间隔，例如 `2m`、`1h` 或 `auto` (1 分钟到 1 天之间)",
+	"pollinterval.current":  "⏲️ `%s/%s`: %s",
+	"pollinterval.set":      "⏲️ `%s/%s` 将每 %s检查一次",
+	"pollinterval.reset":    "⏲️ `%s/%s` 将重新按活跃度调整检查频率",
+	"poll.schedule":         "约每 %s检查一次（%s）",
+	"poll.reason_override":  "手动设置",
+	"poll.reason_active":    "仓库非常活跃",
+	"poll.reason_moderate":  "仓库活跃度一般",
+	"poll.reason_dormant":   "仓库活跃度较低",
+	"poll.reason_new":       "新订阅，使用基础间隔",
+	"duration.days":         "%d天 %d小时 %d分钟",
+	"duration.hours":        "%d小时 %d分钟",
+	"duration.minutes":      "%d分钟 %d秒",
+	"duration.seconds":      "%d秒",
+
+	// Group onboarding and /setupcheck
+	"onboarding.welcome_back": "👋 欢迎回来！本群的 %d 个订阅仍然有效，通知现已恢复。",
+	"onboarding.text": "👋 *大家好！我是 GitHub 监控机器人*\n\n" +
+		"我可以在本群推送 *任意 GitHub 公有仓库* 的动态：\n" +
+		"• 📨 新的提交 (Push)\n" +
+		"• 🎉 版本发布 (Release)\n" +
+		"• 📝 Issue 变动\n" +
+		"• 🔀 Pull Request 变动\n\n" +
+		"点击下方按钮订阅第一个仓库，或使用 `/subscribe owner/repo`。\n\n" +
+		"🔧 把我拉进群的成员可以运行 /setupcheck 检查权限和配置。",
+	"onboarding.button":         "➕ 订阅仓库",
+	"wizard.prompt":             "📝 请回复要订阅的仓库",
+	"wizard.format":             "，格式: owner/repo",
+	"setupcheck.title":          "🔧 *设置检查*\n\n",
+	"setupcheck.no_permissions": "⚠️ 无法获取机器人在本群的权限\n",
+	"setupcheck.muted":          "❌ 机器人被禁止发言，无法推送通知\n",
+	"setupcheck.group_ok":       "✅ 机器人身份: %s，可以发送消息\n",
+	"setupcheck.private_ok":     "✅ 私聊中可以正常推送通知\n",
+	"setupcheck.no_subs":        "⚠️ 尚未订阅任何仓库，使用 `/subscribe owner/repo` 开始\n",
+	"setupcheck.subs":           "✅ 已订阅 %d 个仓库\n",
+	"setupcheck.no_client":      "⚠️ 未配置 GitHub 客户端，无法验证仓库\n",
+	"setupcheck.client":         "✅ GitHub 客户端已配置\n",
+
+	// Notification fields
+	"field.tag":      "📦 标签: `%s`\n",
+	"field.commit":   "🔗 提交: `%s`\n",
+	"field.by":       "👤 作者: %s\n",
+	"field.author":   "👤 作者: %s\n",
+	"field.reviewer": "👤 审查者: %s\n",
+	"field.labels":   "🏷️ 标签: %v\n",
+	"field.branch":   "🌿 分支: `%s`\n",
+	"field.ref":      "🌿 引用: `%s`\n",
+	"field.file":     "📄 文件: %s\n",
+	"field.version":  "🏷️ 版本: `%s`\n",
+	"field.type":     "🧰 类型: %s\n",
+	"field.filter":   "🔍 过滤: `%s`\n",
+	"field.license":  "📜 许可证: `%s`\n",
+
+	// Actions and states shown in notifications
+	"action.opened":                "已创建",
+	"action.closed":                "已关闭",
+	"action.reopened":              "已重新打开",
+	"action.merged":                "已合并",
+	"action.edited":                "已编辑",
+	"action.created":               "已创建",
+	"action.renamed":               "已重命名",
+	"action.transferred":           "已转移",
+	"action.approved":              "已批准",
+	"action.changes_requested":     "要求修改",
+	"action.commented":             "已评论",
+	"conclusion.success":           "成功",
+	"conclusion.failure":           "失败",
+	"conclusion.cancelled":         "已取消",
+	"conclusion.timed_out":         "超时",
+	"deployment_state.created":     "已创建",
+	"deployment_state.queued":      "排队中",
+	"deployment_state.pending":     "等待中",
+	"deployment_state.in_progress": "进行中",
+	"deployment_state.success":     "成功",
+	"deployment_state.failure":     "失败",
+	"deployment_state.error":       "出错",
+	"deployment_state.inactive":    "已停用",
+
+	// Notifications
+	"push.title":               "🔨 *%[1]s* 向 `%[2]s` 推送了 %[3]d 个提交\n\n",
+	"push.title_one":           "🔨 *%[1]s* 向 `%[2]s` 推送了 %[3]d 个提交\n\n",
+	"push.more":                "\n_...以及另外 %d 个提交_\n",
+	"commits.title":            "📜 *%[1]s/%[2]s 最近的 %[3]d 个提交*\n\n",
+	"push.compare":             "查看变更",
+	"release.title":            "%s *新版本: %s*\n\n",
+	"release.view":             "查看 Release",
+	"release.full_text":        "📄 阅读完整发布说明",
+	"release.assets":           "\n📦 *资源:*\n",
+	"release.assets_more":      "_...以及另外 %d 个_\n",
+	"tag.title":                "🏷️ *新标签*\n\n",
+	"tag.view":                 "查看标签",
+	"package.title":            "📦 *新的包版本: %s*\n\n",
+	"package.registry":         "仓库地址",
+	"package.view":             "查看包",
+	"issue.title":              "%s *Issue #%d %s*\n\n",
+	"issue.view":               "查看 Issue",
+	"issue.full_text":          "📄 阅读完整描述",
+	"pr.title":                 "%s *PR #%d %s*\n\n",
+	"pr.stats":                 "📊 %d 个提交，+%d/-%d 行\n",
+	"pr.view":                  "查看 PR",
+	"star.milestone":           "🌟 *Star 数突破 %d！*\n\n",
+	"star.new":                 "⭐ *新的 Star*\n\n",
+	"star.total":               "📈 总计: %d 个 Star\n",
+	"star.view":                "查看 Stargazers",
+	"review.approved":          "✅ *PR #%d 已批准*",
+	"review.changes_requested": "🛠 *PR #%d 要求修改*",
+	"review.commented":         "👀 *PR #%d 已审查*",
+	"review.view":              "查看审查",
+	"review_comment.title":     "💬 *PR #%d 有新的审查评论*\n\n",
+	"review_comment.view":      "查看评论",
+	"workflow.title":           "%[1]s *工作流 %[3]s: %[2]s*\n\n",
+	"workflow.run":             "🔢 运行 #%d",
+	"workflow.attempt":         "（第 %d 次尝试）",
+	"workflow.view":            "查看运行",
+	"deployment.title":         "%s *部署到 %s: %s*\n\n",
+	"deployment.view":          "查看部署",
+	"deployment.open":          "打开环境",
+	"wiki.title":               "📖 *%s 更新了 Wiki*\n\n",
+	"wiki.diff":                "差异",
+	"wiki.more":                "\n_...以及另外 %d 个页面_\n",
+	"repository.moved":         "✏️ *仓库%s*\n\n",
+	"repository.moved_note":    "订阅已自动迁移到新名称。\n",
+	"repository.archived":      "📦 *仓库已归档*\n\n仓库现为只读，不会再有新的动态。\n",
+	"repository.unarchived":    "📂 *仓库已取消归档*\n",
+	"repository.other":         "📋 *仓库 %s*\n",
+	"topic.new_repo":           "🏷 *主题 %s 有新仓库*\n\n",
+	"topic.language":           "💻 语言: %s\n",
+	"topic.stars":              "⭐ Star 数: %d\n",
+	"repository.view":          "查看仓库",
+	"assets.title":             "\n\n📦 *匹配的资源:*\n",
+	"assets.missing_title":     "⚠️ *发布的 Release 中没有匹配的资源*\n\n",
+	"assets.missing_note":      "\n等待期内没有出现匹配的资源。\n",
+	"compliance.alert":         "⚠️ *检测到合规偏差* ⚠️\n\n",
+	"compliance.allowed":       "\n📜 允许的许可证: `%s`\n",
+	"compliance.note":          "\n在确认之前，该订阅会在 /list 中保持标记。\n",
+	"compliance.resolved":      "✅ *已恢复合规*\n\n",
+	"compliance.ack_button":    "✅ 确认",
+	"notify.open_button":       "🔗 在 GitHub 上查看",
+	"notify.mute_button":       "🔇 静音 1 小时",
+	"notify.unsub_button":      "❌ 取消订阅",
+
+	// Summaries of queued events
+	"summary.more":           "  _...以及另外 %d 个_\n",
+	"summary.batch_title":    "🔔 *%d 个新事件*",
+	"summary.quiet_title":    "🌙 *免打扰期间的 %d 条通知*",
+	"summary.daily_title":    "📰 *每日摘要: %d 个事件*",
+	"summary.weekly_title":   "📰 *每周摘要: %d 个事件*",
+	"summary.push":           "%d 个提交到 `%s`",
+	"summary.push_one":       "%d 个提交到 `%s`",
+	"summary.release":        "Release %s",
+	"summary.tag":            "标签 `%s`",
+	"summary.review":         "#%[1]d 被 %[2]s %[3]s",
+	"summary.review_comment": "#%d %s 的评论",
+	"summary.workflow":       "%[1]s 在 `%[3]s` 上%[2]s",
+	"summary.wiki":           "%d 个 Wiki 页面被 %s 修改",
+	"summary.milestone":      "Star 数突破 %d",
+	"summary.star":           "%s 点了 Star（共 %d 个）",
+	"summary.repository":     "仓库%s",
+	"summary.event":          "事件",
+}
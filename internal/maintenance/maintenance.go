@@ -0,0 +1,131 @@
+// Package maintenance keeps the database from growing forever: it deletes
+// old processed-event records, history and audit entries on a schedule, and
+// lets SQLite reclaim the space and refresh its query planner statistics.
+package maintenance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// startDelay is how long after the start the first cleanup runs, leaving the
+// bot a moment to start up.
+const startDelay = time.Minute
+
+// Config holds what is kept and how often the database is maintained.
+type Config struct {
+	Interval    time.Duration // Time between cleanups
+	EventDays   int           // Days processed event IDs are kept for deduplication
+	HistoryDays int           // Days events stay in the history shown by /history
+	AuditDays   int           // Days commands stay in the audit log
+	VacuumEvery time.Duration // Time between VACUUMs, 0 to never
+}
+
+// Maintainer runs the periodic database maintenance.
+type Maintainer struct {
+	db     *storage.Database
+	store  storage.Store
+	config Config
+
+	lastVacuum time.Time
+	ctx        context.Context
+	cancel     context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+// New creates a maintainer for a database and the store on top of it.
+func New(db *storage.Database, store storage.Store, config Config) *Maintainer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Maintainer{
+		db:     db,
+		store:  store,
+		config: config,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start runs the maintenance in the background, shortly after the start and
+// then every interval.
+func (m *Maintainer) Start() {
+	if m.config.Interval <= 0 {
+		return
+	}
+	m.lastVacuum = time.Now()
+	m.wg.Add(1)
+	go m.run()
+}
+
+// Stop stops the maintenance, waiting for a run in progress.
+func (m *Maintainer) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// run runs the maintenance on schedule.
+func (m *Maintainer) run() {
+	defer m.wg.Done()
+	defer logger.ReportPanic()
+
+	timer := time.NewTimer(startDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-timer.C:
+			m.Run()
+			timer.Reset(m.config.Interval)
+		}
+	}
+}
+
+// Run deletes old records, then analyzes the database and, when due,
+// vacuums it.
+func (m *Maintainer) Run() {
+	started := time.Now()
+
+	var events, history, audit int64
+	var err error
+	if m.config.EventDays > 0 {
+		if events, err = m.store.CleanupOldEvents(m.config.EventDays); err != nil {
+			logger.Warn().Err(err).Msg("Failed to clean up event records")
+		}
+	}
+	if m.config.HistoryDays > 0 {
+		if history, err = m.store.CleanupHistory(m.config.HistoryDays); err != nil {
+			logger.Warn().Err(err).Msg("Failed to clean up event history")
+		}
+	}
+	if m.config.AuditDays > 0 {
+		if audit, err = m.store.CleanupAuditLog(m.config.AuditDays); err != nil {
+			logger.Warn().Err(err).Msg("Failed to clean up audit log")
+		}
+	}
+
+	if err := m.db.Analyze(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to analyze database")
+	}
+
+	vacuumed := false
+	if m.config.VacuumEvery > 0 && time.Since(m.lastVacuum) >= m.config.VacuumEvery {
+		if err := m.db.Vacuum(); err != nil {
+			logger.Warn().Err(err).Msg("Failed to vacuum database")
+		} else {
+			vacuumed = true
+		}
+		m.lastVacuum = time.Now()
+	}
+
+	logger.Info().
+		Int64("event_records", events).
+		Int64("history", history).
+		Int64("audit", audit).
+		Bool("vacuumed", vacuumed).
+		Dur("took", time.Since(started)).
+		Msg("Database maintenance done")
+}
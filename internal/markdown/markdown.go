@@ -0,0 +1,394 @@
+// Package markdown renders text for Telegram's MarkdownV2 parse mode.
+//
+// Messages are written as format strings using the usual markup: *bold*,
+// _italic_, `code`, ```pre``` blocks and [text](url) links. Sprintf escapes the
+// literal text of the format and every argument, so that GitHub content such
+// as commit messages and issue titles can never break the markup. Arguments
+// that already are MarkdownV2, like links or translated messages, are wrapped
+// in Raw to be inserted as they are.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Raw is text that is already valid MarkdownV2 and is inserted unescaped.
+type Raw string
+
+// special lists the characters MarkdownV2 requires to be escaped outside of
+// entities.
+const special = "\\_*[]()~`>#+-=|{}.!"
+
+// escaper escapes every special character of plain text.
+var escaper = func() *strings.Replacer {
+	var pairs []string
+	for _, c := range special {
+		pairs = append(pairs, string(c), "\\"+string(c))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+// codeEscaper escapes the characters that are special inside code and pre entities.
+var codeEscaper = strings.NewReplacer("\\", "\\\\", "`", "\\`")
+
+// urlEscaper escapes the characters that are special inside a link target.
+var urlEscaper = strings.NewReplacer("\\", "\\\\", ")", "\\)")
+
+// Escape escapes s so it is shown literally.
+func Escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// Code renders s as inline code.
+func Code(s string) string {
+	return "`" + codeEscaper.Replace(s) + "`"
+}
+
+// Pre renders s as a pre-formatted code block, optionally tagged with the
+// language of the code.
+func Pre(s, language string) string {
+	return "```" + language + "\n" + codeEscaper.Replace(s) + "\n```"
+}
+
+// Link renders a link. The text must already be MarkdownV2, the URL is used
+// as given apart from escaping.
+func Link(text, url string) string {
+	return "[" + text + "](" + urlEscaper.Replace(url) + ")"
+}
+
+// Sprintf formats a message. The format is escaped with Template and every
+// argument that is not Raw is escaped after formatting with its verb.
+func Sprintf(format string, args ...interface{}) string {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		if raw, ok := arg.(Raw); ok {
+			escaped[i] = string(raw)
+		} else {
+			escaped[i] = escapedArg{arg}
+		}
+	}
+	return fmt.Sprintf(Template(format), escaped...)
+}
+
+// escapedArg formats its value like fmt would and escapes the result.
+type escapedArg struct {
+	value interface{}
+}
+
+// Format implements fmt.Formatter.
+func (a escapedArg) Format(f fmt.State, verb rune) {
+	io.WriteString(f, Escape(fmt.Sprintf(fmt.FormatString(f, verb), a.value)))
+}
+
+// Template escapes the literal text of a format string while keeping its
+// markup, fmt verbs and existing backslash escapes intact. Entity markers
+// (*, _, ~) are kept as they are, code and pre entities only have backslashes
+// escaped, and [text](url) links keep their brackets.
+func Template(format string) string {
+	var b strings.Builder
+	b.Grow(len(format) + len(format)/8)
+
+	inCode, inPre, inURL := false, false, false
+	linkDepth := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		switch {
+		case c == '%':
+			end := verbEnd(format, i)
+			b.WriteString(format[i:end])
+			i = end - 1
+		case strings.HasPrefix(format[i:], "```") && !inCode:
+			inPre = !inPre
+			b.WriteString("```")
+			i += 2
+		case c == '`' && !inPre:
+			inCode = !inCode
+			b.WriteByte(c)
+		case inCode || inPre:
+			if c == '\\' {
+				b.WriteString("\\\\")
+			} else {
+				b.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(format):
+			b.WriteString(format[i : i+2])
+			i++
+		case inURL:
+			if c == ')' {
+				inURL = false
+			}
+			b.WriteByte(c)
+		case c == '[' && startsLink(format[i:]):
+			linkDepth++
+			b.WriteByte(c)
+		case c == ']' && linkDepth > 0 && strings.HasPrefix(format[i:], "]("):
+			linkDepth--
+			inURL = true
+			b.WriteString("](")
+			i++
+		case c == '*' || c == '_' || c == '~':
+			b.WriteByte(c)
+		case strings.IndexByte(special, c) >= 0:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// verbEnd returns the index just past the fmt verb starting at format[start].
+func verbEnd(format string, start int) int {
+	for i := start + 1; i < len(format); i++ {
+		switch c := format[i]; {
+		case strings.IndexByte("+-# 0123456789.*[]", c) >= 0:
+			continue
+		default:
+			return i + 1
+		}
+	}
+	return len(format)
+}
+
+// startsLink reports whether s begins a [text](url) link on a single line.
+func startsLink(s string) bool {
+	end := strings.Index(s, "](")
+	if end < 0 {
+		return false
+	}
+	return !strings.Contains(s[:end], "\n")
+}
+
+// ToHTML converts MarkdownV2 produced by this package to Telegram HTML. It is
+// tolerant of malformed input: stray markers are closed at the end, unknown
+// escapes are kept as plain text and an escape cut off at the end is dropped.
+func ToHTML(s string) string {
+	return convert(s, htmlDialect)
+}
+
+// ToDiscord converts MarkdownV2 produced by this package to the markdown of
+// Discord messages, as tolerantly as ToHTML.
+func ToDiscord(s string) string {
+	return convert(s, discordDialect)
+}
+
+// ToSlack converts MarkdownV2 produced by this package to Slack's mrkdwn,
+// as tolerantly as ToHTML. Slack has no underline, underlined text is kept
+// plain.
+func ToSlack(s string) string {
+	return convert(s, slackDialect)
+}
+
+// ToPlain converts MarkdownV2 produced by this package to plain text, with
+// link targets in parentheses after their text.
+func ToPlain(s string) string {
+	return convert(s, plainDialect)
+}
+
+// Truncate shortens text converted from MarkdownV2, or plain text, to at
+// most n runes, ending it with an ellipsis if it was cut.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// dialect describes how another markup renders the entities of MarkdownV2.
+type dialect struct {
+	escape func(text string) string           // Escapes plain text
+	styles map[byte][2]string                 // Opening and closing markup of bold, italic, underline and strikethrough, by marker
+	code   func(code string) string           // Renders inline code
+	pre    func(code, language string) string // Renders a code block, language may be empty
+	link   func(text, url string) string      // Renders a link around already converted text
+}
+
+// Style markers of MarkdownV2. Underline is written __ and keyed by 'u'.
+const (
+	styleBold      = '*'
+	styleItalic    = '_'
+	styleUnderline = 'u'
+	styleStrike    = '~'
+)
+
+var htmlDialect = dialect{
+	escape: html.EscapeString,
+	styles: map[byte][2]string{
+		styleBold:      {"<b>", "</b>"},
+		styleItalic:    {"<i>", "</i>"},
+		styleUnderline: {"<u>", "</u>"},
+		styleStrike:    {"<s>", "</s>"},
+	},
+	code: func(code string) string {
+		return "<code>" + html.EscapeString(code) + "</code>"
+	},
+	pre: func(code, language string) string {
+		if language == "" {
+			return "<pre>" + html.EscapeString(code) + "</pre>"
+		}
+		return `<pre><code class="language-` + html.EscapeString(language) + `">` + html.EscapeString(code) + "</code></pre>"
+	},
+	link: func(text, url string) string {
+		return `<a href="` + html.EscapeString(url) + `">` + text + "</a>"
+	},
+}
+
+// discordEscaper escapes the characters Discord's markdown treats as markup.
+var discordEscaper = strings.NewReplacer(
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "~", "\\~", "`", "\\`",
+	"|", "\\|", ">", "\\>", "#", "\\#", "[", "\\[", "]", "\\]",
+)
+
+var discordDialect = dialect{
+	escape: discordEscaper.Replace,
+	styles: map[byte][2]string{
+		styleBold:      {"**", "**"},
+		styleItalic:    {"*", "*"},
+		styleUnderline: {"__", "__"},
+		styleStrike:    {"~~", "~~"},
+	},
+	code: func(code string) string {
+		if strings.Contains(code, "`") {
+			return "`` " + code + " ``"
+		}
+		return "`" + code + "`"
+	},
+	pre: func(code, language string) string {
+		code = strings.TrimSuffix(strings.ReplaceAll(code, "```", "`\u200b``"), "\n")
+		return "```" + language + "\n" + code + "\n```"
+	},
+	link: func(text, url string) string {
+		return "[" + text + "](<" + url + ">)"
+	},
+}
+
+// slackEscaper escapes the characters Slack's mrkdwn requires to be encoded.
+// Its markers cannot be escaped, they only take effect around words.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var slackDialect = dialect{
+	escape: slackEscaper.Replace,
+	styles: map[byte][2]string{
+		styleBold:      {"*", "*"},
+		styleItalic:    {"_", "_"},
+		styleUnderline: {"", ""},
+		styleStrike:    {"~", "~"},
+	},
+	code: func(code string) string {
+		return "`" + slackEscaper.Replace(code) + "`"
+	},
+	pre: func(code, language string) string {
+		return "```" + slackEscaper.Replace(strings.TrimSuffix(code, "\n")) + "```"
+	},
+	link: func(text, url string) string {
+		return "<" + slackEscaper.Replace(url) + "|" + strings.ReplaceAll(text, "|", "¦") + ">"
+	},
+}
+
+var plainDialect = dialect{
+	escape: func(text string) string { return text },
+	styles: map[byte][2]string{},
+	code:   func(code string) string { return code },
+	pre: func(code, language string) string {
+		return strings.TrimSuffix(code, "\n")
+	},
+	link: func(text, url string) string {
+		if text == url {
+			return text
+		}
+		return text + " (" + url + ")"
+	},
+}
+
+// convert converts MarkdownV2 produced by this package to another markup.
+func convert(s string, d dialect) string {
+	var out []byte
+	var open []byte // Open style markers, innermost last
+	var links []int // Output offsets of open link texts
+
+	toggle := func(marker byte) {
+		if n := len(open); n > 0 && open[n-1] == marker {
+			open = open[:n-1]
+			out = append(out, d.styles[marker][1]...)
+			return
+		}
+		open = append(open, marker)
+		out = append(out, d.styles[marker][0]...)
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			// A backslash ending the text is left of an escape cut off by
+			// truncation, there is nothing to show
+			if i+1 < len(s) {
+				i++
+				out = append(out, d.escape(s[i:i+1])...)
+			}
+		case strings.HasPrefix(s[i:], "```"):
+			body, end := scanEntity(s, i+3, "```")
+			language, code, ok := strings.Cut(body, "\n")
+			if !ok || strings.ContainsAny(language, " \t") {
+				language, code = "", strings.TrimPrefix(body, "\n")
+			}
+			out = append(out, d.pre(code, language)...)
+			i = end - 1
+		case c == '`':
+			body, end := scanEntity(s, i+1, "`")
+			out = append(out, d.code(body)...)
+			i = end - 1
+		case strings.HasPrefix(s[i:], "__"):
+			toggle(styleUnderline)
+			i++
+		case c == styleBold || c == styleItalic || c == styleStrike:
+			toggle(c)
+		case c == '[':
+			links = append(links, len(out))
+		case c == ']' && len(links) > 0 && strings.HasPrefix(s[i:], "]("):
+			url, end := scanEntity(s, i+2, ")")
+			start := links[len(links)-1]
+			links = links[:len(links)-1]
+			text := string(out[start:])
+			out = append(out[:start], d.link(text, url)...)
+			i = end - 1
+		default:
+			// Multi-byte characters pass through, only ASCII can be markup
+			j := i + 1
+			for j < len(s) && s[j] >= 0x80 {
+				j++
+			}
+			out = append(out, d.escape(s[i:j])...)
+			i = j - 1
+		}
+	}
+	for n := len(open) - 1; n >= 0; n-- {
+		out = append(out, d.styles[open[n]][1]...)
+	}
+	return string(out)
+}
+
+// scanEntity returns the unescaped content of an entity starting at s[start]
+// and closed by delim, and the index just past the delimiter.
+func scanEntity(s string, start int, delim string) (string, int) {
+	var b strings.Builder
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case strings.HasPrefix(s[i:], delim):
+			return b.String(), i + len(delim)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), len(s)
+}
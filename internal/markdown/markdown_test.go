@@ -0,0 +1,310 @@
+package markdown
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// checkMarkdownV2 reports the first reason Telegram would reject s as
+// MarkdownV2: a reserved character that is neither escaped nor markup, an
+// entity left open, entities overlapping instead of nesting, or a dangling
+// backslash.
+func checkMarkdownV2(s string) error {
+	var open []string // Open entities, innermost last
+
+	toggle := func(marker string, at int) error {
+		if n := len(open); n > 0 && open[n-1] == marker {
+			open = open[:n-1]
+			return nil
+		}
+		for _, m := range open {
+			if m == marker {
+				return fmt.Errorf("%q at %d closes an entity that is not the innermost", marker, at)
+			}
+		}
+		open = append(open, marker)
+		return nil
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(s) {
+				return fmt.Errorf("dangling backslash at %d", i)
+			}
+			if s[i+1] < 1 || s[i+1] > 126 {
+				return fmt.Errorf("non-ASCII character escaped at %d", i)
+			}
+			i++
+		case strings.HasPrefix(s[i:], "```"):
+			end, err := entityEnd(s, i+3, "```")
+			if err != nil {
+				return err
+			}
+			i = end - 1
+		case c == '`':
+			end, err := entityEnd(s, i+1, "`")
+			if err != nil {
+				return err
+			}
+			i = end - 1
+		case strings.HasPrefix(s[i:], "__"):
+			if err := toggle("__", i); err != nil {
+				return err
+			}
+			i++
+		case c == '*' || c == '_' || c == '~':
+			if err := toggle(string(c), i); err != nil {
+				return err
+			}
+		case c == '[':
+			open = append(open, "[")
+		case c == ']':
+			if n := len(open); n == 0 || open[n-1] != "[" || !strings.HasPrefix(s[i:], "](") {
+				return fmt.Errorf("unescaped ] at %d", i)
+			}
+			open = open[:len(open)-1]
+			end, err := entityEnd(s, i+2, ")")
+			if err != nil {
+				return err
+			}
+			i = end - 1
+		case strings.IndexByte(special, c) >= 0:
+			return fmt.Errorf("unescaped %q at %d", c, i)
+		}
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("entities %q left open", open)
+	}
+	return nil
+}
+
+// entityEnd returns the index just past the delimiter closing the code, pre
+// or link target starting at s[start].
+func entityEnd(s string, start int, delim string) (int, error) {
+	for i := start; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			if i+1 == len(s) {
+				return 0, fmt.Errorf("dangling backslash at %d", i)
+			}
+			i++
+		case strings.HasPrefix(s[i:], delim):
+			return i + len(delim), nil
+		}
+	}
+	return 0, fmt.Errorf("%q opened before %d is not closed", delim, start)
+}
+
+func TestCheckMarkdownV2(t *testing.T) {
+	valid := []string{"", "plain", `a\.b`, "*bold _italic_*", "`a.b`", "```\na.b\n```", "[a\\_b](https://x.com/a\\)b)", "___a_\r__"}
+	for _, s := range valid {
+		if err := checkMarkdownV2(s); err != nil {
+			t.Errorf("checkMarkdownV2(%q) = %v", s, err)
+		}
+	}
+	invalid := []string{"a.b", "*bold", "*a _b* c_", `a\`, "`code", "[text", "a]b", "[a](b", "x ||spoiler||", "é\\é"}
+	for _, s := range invalid {
+		if err := checkMarkdownV2(s); err == nil {
+			t.Errorf("checkMarkdownV2(%q) accepted", s)
+		}
+	}
+}
+
+// hostile are commit messages and issue titles that break naive escaping.
+var hostile = []string{
+	"fix(parser): handle a_b and *ptr",
+	"[WIP] feat!: add {config} #123",
+	`Revert "Merge pull request #42 from user/feature_branch"`,
+	"use `rm -rf ./build` > /dev/null | tee log.txt",
+	`C:\Users\me\file_name.txt`,
+	`ends with a backslash \`,
+	"**bold** __underline__ ~~strike~~ ||spoiler||",
+	"```go\nfmt.Println(\"hi\")\n```",
+	"[click me](javascript:alert(1))",
+	"1 + 1 = 2. Done!",
+	"> quoted\n# heading\n- item",
+	"emoji 🚀 and ünïcödé ✓",
+	`\_already\_escaped\_`,
+	special,
+}
+
+func TestEscapeReservedCharacters(t *testing.T) {
+	for _, c := range special {
+		if got, want := Escape(string(c)), `\`+string(c); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", c, got, want)
+		}
+	}
+
+	all := Escape(special)
+	if err := checkMarkdownV2(all); err != nil {
+		t.Errorf("Escape(%q) = %q: %v", special, all, err)
+	}
+	if got := ToPlain(all); got != special {
+		t.Errorf("ToPlain(Escape(%q)) = %q", special, got)
+	}
+
+	for _, s := range []string{"letters 0123456789", `@$%&'",;:?/^`, "ünïcödé 🚀 日本語", "tab\tnew\nline"} {
+		if got := Escape(s); got != s {
+			t.Errorf("Escape(%q) = %q, want it unchanged", s, got)
+		}
+	}
+}
+
+func TestSprintfHostile(t *testing.T) {
+	formats := []string{
+		"%s",
+		"📌 %s",
+		"*%s*",
+		"_%s_",
+		"__%s__",
+		"~%s~",
+		"*bold _italic %s_*",
+		"`%s`",
+		"```\n%s\n```",
+		"[%s](https://github.com/acme/app)",
+		"(%s) - done.",
+	}
+	for _, format := range formats {
+		for _, arg := range hostile {
+			got := Sprintf(format, arg)
+			if err := checkMarkdownV2(got); err != nil {
+				t.Errorf("Sprintf(%q, %q) = %q: %v", format, arg, got, err)
+				continue
+			}
+			if plain := ToPlain(got); !strings.Contains(plain, arg) {
+				t.Errorf("Sprintf(%q, %q) shows as %q, want the argument kept", format, arg, plain)
+			}
+		}
+	}
+}
+
+func TestSprintfVerbs(t *testing.T) {
+	got := Sprintf("#%d %s: %.1f%% of %q done!", 42, "a_b", 99.5, "x.y")
+	want := `\#42 a\_b: 99\.5% of "x\.y" done\!`
+	if got != want {
+		t.Errorf("Sprintf() = %q, want %q", got, want)
+	}
+
+	link := Link(Escape("feature_branch"), "https://example.com/a)b")
+	got = Sprintf("see %s or %s", Raw(link), "c_d")
+	want = `see [feature\_branch](https://example.com/a\)b) or c\_d`
+	if got != want {
+		t.Errorf("Sprintf() = %q, want %q", got, want)
+	}
+	if err := checkMarkdownV2(got); err != nil {
+		t.Errorf("Sprintf() = %q: %v", got, err)
+	}
+}
+
+func TestNestedEntities(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		html string
+	}{
+		{
+			"styles",
+			Template("*bold _italic __underline ~strike~__ italic_ bold*"),
+			"<b>bold <i>italic <u>underline <s>strike</s></u> italic</i> bold</b>",
+		},
+		{
+			"italic underline",
+			Template("___both_\r__"),
+			"<u><i>both</i>\r</u>",
+		},
+		{
+			"styled link text",
+			Sprintf("*%s [_%s_](%s)*", "a*b", "c_d", Raw("https://github.com/acme/app")),
+			`<b>a*b <a href="https://github.com/acme/app"><i>c_d</i></a></b>`,
+		},
+		{
+			"code in bold",
+			Sprintf("*see %s now*", Raw(Code("a*b_c`d"))),
+			"<b>see <code>a*b_c`d</code> now</b>",
+		},
+		{
+			"markers as arguments",
+			Sprintf("~%s~ *%s* _%s_", "~x~", "*y*", "_z_"),
+			"<s>~x~</s> <b>*y*</b> <i>_z_</i>",
+		},
+		{
+			"pre in italic",
+			Template("_run:_\n```sh\nmake test\n```"),
+			`<i>run:</i>` + "\n" + `<pre><code class="language-sh">make test` + "\n</code></pre>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkMarkdownV2(tt.text); err != nil {
+				t.Fatalf("%q: %v", tt.text, err)
+			}
+			if got := ToHTML(tt.text); got != tt.html {
+				t.Errorf("ToHTML(%q) = %q, want %q", tt.text, got, tt.html)
+			}
+		})
+	}
+}
+
+func TestTruncatedEscapes(t *testing.T) {
+	raw := special + ` C:\dir\file_name.txt 🚀`
+	escaped := Escape(raw)
+
+	for n := 0; n <= len(escaped); n++ {
+		if n < len(escaped) && !utf8.RuneStart(escaped[n]) {
+			continue
+		}
+		prefix := escaped[:n]
+		// A cut right after a backslash leaves half an escape
+		if got := ToPlain(prefix); !strings.HasPrefix(raw, got) {
+			t.Errorf("ToPlain(%q) = %q, want a prefix of %q", prefix, got, raw)
+		}
+	}
+}
+
+func TestTruncatedEntities(t *testing.T) {
+	message := Sprintf("*%s* _%s_ %s `%s` [%s](%s)",
+		"bold.", "it_al", "a\\b", "co`de\\", "li]nk", "https://github.com/acme/a_(b)")
+	if err := checkMarkdownV2(message); err != nil {
+		t.Fatalf("%q: %v", message, err)
+	}
+
+	// Cut anywhere, the HTML stays well formed
+	for n := 0; n <= len(message); n++ {
+		html := ToHTML(message[:n])
+		d := xml.NewDecoder(strings.NewReader("<root>" + html + "</root>"))
+		for {
+			_, err := d.Token()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("ToHTML(%q) = %q: %v", message[:n], html, err)
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly", 7, "exactly"},
+		{"too long", 5, "too …"},
+		{"ünïcödé 🚀 text", 9, "ünïcödé …"},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.n)
+		if got != tt.want || utf8.RuneCountInString(got) > tt.n {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package metrics provides Prometheus instrumentation for the event pipeline.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Event sources.
+const (
+	SourceWebhook = "webhook"
+	SourcePoller  = "poller"
+)
+
+// Lag stages measured for each delivered event.
+const (
+	StageDetection = "detection" // Source timestamp until the bot saw the event
+	StageDelivery  = "delivery"  // Detection until the notification was sent
+	StageTotal     = "total"     // Source timestamp until the notification was sent
+)
+
+// lagBuckets spans webhook-fast deliveries up to slow polling.
+var lagBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200}
+
+var eventLag = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "githubbot",
+	Name:      "event_lag_seconds",
+	Help:      "Time from the GitHub event until its notification was delivered, by stage.",
+	Buckets:   lagBuckets,
+}, []string{"repo", "event_type", "source", "stage"})
+
+var pollDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "githubbot",
+	Name:      "poll_duration_seconds",
+	Help:      "Time taken to poll a repository.",
+	Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+}, []string{"repo"})
+
+func init() {
+	prometheus.MustRegister(eventLag, pollDuration)
+}
+
+// ObservePoll records how long polling a repository took.
+func ObservePoll(repo string, took time.Duration) {
+	pollDuration.WithLabelValues(repo).Observe(took.Seconds())
+}
+
+// Handler returns the HTTP handler serving the metrics endpoint.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// recentLagSize is how many recent samples are kept per source and stage.
+const recentLagSize = 200
+
+var (
+	recentMu  sync.Mutex
+	recentLag = make(map[string][]time.Duration) // keyed by source/stage
+)
+
+// ObserveLag records the lag of a delivered event. Zero timestamps are
+// ignored, so events without a known source time only record delivery lag.
+func ObserveLag(repo, eventType, source string, occurredAt, detectedAt, sentAt time.Time) {
+	if !occurredAt.IsZero() && !detectedAt.IsZero() {
+		observe(repo, eventType, source, StageDetection, detectedAt.Sub(occurredAt))
+	}
+	if !detectedAt.IsZero() {
+		observe(repo, eventType, source, StageDelivery, sentAt.Sub(detectedAt))
+	}
+	if !occurredAt.IsZero() {
+		observe(repo, eventType, source, StageTotal, sentAt.Sub(occurredAt))
+	}
+}
+
+// observe records one lag sample.
+func observe(repo, eventType, source, stage string, lag time.Duration) {
+	if lag < 0 {
+		lag = 0 // Clock skew between GitHub and us
+	}
+	eventLag.WithLabelValues(repo, eventType, source, stage).Observe(lag.Seconds())
+
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	key := source + "/" + stage
+	samples := append(recentLag[key], lag)
+	if len(samples) > recentLagSize {
+		samples = samples[len(samples)-recentLagSize:]
+	}
+	recentLag[key] = samples
+}
+
+// MedianLag returns the median of the recent lag samples for a source and
+// stage. It reports false if no samples were recorded.
+func MedianLag(source, stage string) (time.Duration, bool) {
+	recentMu.Lock()
+	samples := append([]time.Duration(nil), recentLag[source+"/"+stage]...)
+	recentMu.Unlock()
+
+	if len(samples) == 0 {
+		return 0, false
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2], true
+}
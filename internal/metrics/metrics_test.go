@@ -0,0 +1,188 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// lagHistogram returns the recorded lag histogram of a repository's stage.
+func lagHistogram(t *testing.T, repo, source, stage string) *dto.Histogram {
+	t.Helper()
+	var m dto.Metric
+	if err := eventLag.WithLabelValues(repo, "release", source, stage).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	return m.GetHistogram()
+}
+
+// bucketCount returns the cumulative count of the bucket with an upper bound.
+func bucketCount(t *testing.T, h *dto.Histogram, upperBound float64) uint64 {
+	t.Helper()
+	for _, b := range h.GetBucket() {
+		if b.GetUpperBound() == upperBound {
+			return b.GetCumulativeCount()
+		}
+	}
+	t.Fatalf("no bucket with upper bound %v", upperBound)
+	return 0
+}
+
+// resetRecentLag clears the recent samples MedianLag reports.
+func resetRecentLag(t *testing.T) {
+	t.Helper()
+	recentMu.Lock()
+	recentLag = make(map[string][]time.Duration)
+	recentMu.Unlock()
+	t.Cleanup(func() {
+		recentMu.Lock()
+		recentLag = make(map[string][]time.Duration)
+		recentMu.Unlock()
+	})
+}
+
+func TestObserveLagBuckets(t *testing.T) {
+	resetRecentLag(t)
+	occurred := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ObserveLag("acme/buckets", "release", SourcePoller, occurred, occurred.Add(10*time.Second), occurred.Add(70*time.Second))
+
+	tests := []struct {
+		stage      string
+		sum        float64
+		below      float64 // Largest bucket the sample is not in
+		upperBound float64 // Smallest bucket the sample is in
+	}{
+		{StageDetection, 10, 5, 15},
+		{StageDelivery, 60, 30, 60},
+		{StageTotal, 70, 60, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stage, func(t *testing.T) {
+			h := lagHistogram(t, "acme/buckets", SourcePoller, tt.stage)
+			if h.GetSampleCount() != 1 {
+				t.Fatalf("sample count = %d, want 1", h.GetSampleCount())
+			}
+			if h.GetSampleSum() != tt.sum {
+				t.Errorf("sample sum = %v, want %v", h.GetSampleSum(), tt.sum)
+			}
+			if got := bucketCount(t, h, tt.below); got != 0 {
+				t.Errorf("bucket le=%v count = %d, want 0", tt.below, got)
+			}
+			if got := bucketCount(t, h, tt.upperBound); got != 1 {
+				t.Errorf("bucket le=%v count = %d, want 1", tt.upperBound, got)
+			}
+		})
+	}
+}
+
+func TestObserveLagZeroTimestamps(t *testing.T) {
+	resetRecentLag(t)
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		repo       string
+		occurredAt time.Time
+		detectedAt time.Time
+		want       map[string]uint64 // Sample count by stage
+	}{
+		{
+			"unknown source time",
+			"acme/no-occurred",
+			time.Time{},
+			at,
+			map[string]uint64{StageDetection: 0, StageDelivery: 1, StageTotal: 0},
+		},
+		{
+			"unknown detection time",
+			"acme/no-detected",
+			at,
+			time.Time{},
+			map[string]uint64{StageDetection: 0, StageDelivery: 0, StageTotal: 1},
+		},
+		{
+			"no timestamps",
+			"acme/none",
+			time.Time{},
+			time.Time{},
+			map[string]uint64{StageDetection: 0, StageDelivery: 0, StageTotal: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ObserveLag(tt.repo, "release", SourceWebhook, tt.occurredAt, tt.detectedAt, at.Add(time.Second))
+			for stage, want := range tt.want {
+				if got := lagHistogram(t, tt.repo, SourceWebhook, stage).GetSampleCount(); got != want {
+					t.Errorf("%s sample count = %d, want %d", stage, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestObserveLagNegative(t *testing.T) {
+	resetRecentLag(t)
+	// GitHub's clock is ahead of ours: the event seems to happen after it
+	// was detected and sent
+	sent := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ObserveLag("acme/skew", "release", SourceWebhook, sent.Add(30*time.Second), sent.Add(time.Second), sent)
+
+	for _, stage := range []string{StageDetection, StageDelivery, StageTotal} {
+		h := lagHistogram(t, "acme/skew", SourceWebhook, stage)
+		if h.GetSampleCount() != 1 || h.GetSampleSum() != 0 {
+			t.Errorf("%s: count = %d, sum = %v, want 1 sample of 0", stage, h.GetSampleCount(), h.GetSampleSum())
+		}
+		if got := bucketCount(t, h, 1); got != 1 {
+			t.Errorf("%s: bucket le=1 count = %d, want 1", stage, got)
+		}
+	}
+	if median, ok := MedianLag(SourceWebhook, StageTotal); !ok || median != 0 {
+		t.Errorf("MedianLag() = %v, %v, want 0, true", median, ok)
+	}
+}
+
+func TestMedianLag(t *testing.T) {
+	resetRecentLag(t)
+	if _, ok := MedianLag(SourcePoller, StageTotal); ok {
+		t.Fatal("MedianLag() reported a median without samples")
+	}
+
+	sent := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, lag := range []time.Duration{90 * time.Second, 10 * time.Second, 30 * time.Second} {
+		ObserveLag("acme/median", "release", SourcePoller, sent.Add(-lag), time.Time{}, sent)
+	}
+	if median, ok := MedianLag(SourcePoller, StageTotal); !ok || median != 30*time.Second {
+		t.Errorf("MedianLag() = %v, %v, want 30s, true", median, ok)
+	}
+	// Samples are kept per source and stage
+	if _, ok := MedianLag(SourceWebhook, StageTotal); ok {
+		t.Error("MedianLag() reported poller samples for webhook")
+	}
+	if _, ok := MedianLag(SourcePoller, StageDetection); ok {
+		t.Error("MedianLag() reported total samples for detection")
+	}
+}
+
+func TestMedianLagKeepsRecentSamples(t *testing.T) {
+	resetRecentLag(t)
+	sent := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	// Slow samples followed by a full window of fast ones
+	for i := 0; i < recentLagSize; i++ {
+		ObserveLag("acme/recent", "release", SourcePoller, sent.Add(-time.Hour), time.Time{}, sent)
+	}
+	for i := 0; i < recentLagSize; i++ {
+		ObserveLag("acme/recent", "release", SourcePoller, sent.Add(-time.Second), time.Time{}, sent)
+	}
+
+	recentMu.Lock()
+	kept := len(recentLag[SourcePoller+"/"+StageTotal])
+	recentMu.Unlock()
+	if kept != recentLagSize {
+		t.Errorf("kept %d samples, want %d", kept, recentLagSize)
+	}
+	if median, _ := MedianLag(SourcePoller, StageTotal); median != time.Second {
+		t.Errorf("MedianLag() = %v, want 1s", median)
+	}
+}
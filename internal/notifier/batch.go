@@ -0,0 +1,159 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/metrics"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Events of one repository arriving for a chat within the batch window are
+// combined into a single summary instead of a burst of messages. The window
+// starts with the first event; an event that stays alone is sent as usual.
+
+// batchKey identifies the events of one repository buffered for a chat.
+type batchKey struct {
+	chatID int64
+	repo   string
+}
+
+// batch holds the notifications buffered for a chat and repository.
+type batch struct {
+	items []batchItem
+	lang  i18n.Lang
+	loc   *time.Location
+}
+
+type batchItem struct {
+	event *github.WebhookEvent
+	text  string
+	opts  sendOptions
+}
+
+// batcher buffers notifications during the batch window.
+type batcher struct {
+	mu      sync.Mutex
+	window  time.Duration // 0 disables batching
+	batches map[batchKey]*batch
+}
+
+// SetBatchWindow configures how long events of a repository are collected
+// before being sent as one message, 0 to send each event right away.
+func (n *Notifier) SetBatchWindow(window time.Duration) {
+	if window < 0 {
+		window = 0
+	}
+	n.batches.mu.Lock()
+	n.batches.window = window
+	n.batches.mu.Unlock()
+}
+
+// batchEvent buffers a notification for the chat and reports whether it did
+// so. The first buffered event of a repository starts the window.
+func (n *Notifier) batchEvent(chat *storage.Chat, event *github.WebhookEvent, text string, opts sendOptions, lang i18n.Lang) bool {
+	if chat == nil {
+		return false
+	}
+
+	n.batches.mu.Lock()
+	defer n.batches.mu.Unlock()
+
+	if n.batches.window == 0 {
+		return false
+	}
+	if n.batches.batches == nil {
+		n.batches.batches = make(map[batchKey]*batch)
+	}
+
+	key := batchKey{chatID: chat.ChatID, repo: event.RepoOwner + "/" + event.RepoName}
+	b, ok := n.batches.batches[key]
+	if !ok {
+		b = &batch{lang: lang, loc: chat.Location()}
+		n.batches.batches[key] = b
+		time.AfterFunc(n.batches.window, func() { n.flushBatch(key) })
+	}
+	b.items = append(b.items, batchItem{event: event, text: text, opts: opts})
+	return true
+}
+
+// flushBatch sends the notifications buffered for a chat and repository.
+func (n *Notifier) flushBatch(key batchKey) {
+	n.batches.mu.Lock()
+	b, ok := n.batches.batches[key]
+	delete(n.batches.batches, key)
+	n.batches.mu.Unlock()
+
+	if ok {
+		n.sendBatch(key.chatID, b)
+	}
+}
+
+// flushBatches sends every buffered notification, used when stopping.
+func (n *Notifier) flushBatches() {
+	n.batches.mu.Lock()
+	pending := n.batches.batches
+	n.batches.batches = nil
+	n.batches.mu.Unlock()
+
+	for key, b := range pending {
+		n.sendBatch(key.chatID, b)
+	}
+}
+
+// sendBatch sends a single buffered notification as it is, and several as a
+// summary grouped by event type. The summary is only silent if all of its
+// events are.
+func (n *Notifier) sendBatch(chatID int64, b *batch) {
+	if len(b.items) == 1 {
+		item := b.items[0]
+		if err := n.sendNotificationWithOptions(item.event.Context(), chatID, item.text, item.opts); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send notification")
+			return
+		}
+		observeLag(item.event)
+		return
+	}
+
+	events := make([]storage.QueuedEvent, 0, len(b.items))
+	silent := true
+	for _, item := range b.items {
+		occurred := item.event.OccurredAt
+		if occurred.IsZero() {
+			occurred = item.event.DetectedAt
+		}
+		events = append(events, storage.QueuedEvent{
+			ChatID:    chatID,
+			RepoOwner: item.event.RepoOwner,
+			RepoName:  item.event.RepoName,
+			EventType: item.event.Type,
+			Summary:   github.Summarize(item.event.Payload, b.lang),
+			CreatedAt: occurred,
+		})
+		silent = silent && item.opts.silent
+	}
+
+	title := i18n.T(b.lang, "summary.batch_title", len(events))
+	for _, message := range n.msgBuilder.BuildQueuedSummary(title, events, b.loc, "15:04", b.lang) {
+		if err := n.sendNotificationWithOptions(context.Background(), chatID, message, sendOptions{silent: silent}); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send batched notifications")
+			return
+		}
+	}
+	for _, item := range b.items {
+		observeLag(item.event)
+	}
+}
+
+// observeLag records how long an event took from happening to being delivered.
+func observeLag(event *github.WebhookEvent) {
+	metrics.ObserveLag(
+		fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName),
+		event.Type, event.Source, event.OccurredAt, event.DetectedAt, time.Now(),
+	)
+}
@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"context"
+
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// SendCustom delivers a message received by a custom hook of a chat, like a
+// notification: through the outbox and the send queue, to the channel the
+// chat is bound to and in its format. Chats that paused notifications or
+// removed the bot get nothing.
+func (n *Notifier) SendCustom(ctx context.Context, chatID int64, hookName, text string) error {
+	chat, err := n.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	if chat != nil && (!chat.Active || chat.Paused) {
+		return nil
+	}
+
+	message := markdown.Sprintf("🪝 *%s*\n\n%s", hookName, text)
+	return n.sendNotification(ctx, chatID, message)
+}
@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// matchesFilters applies the per-subscription filters to an event. Events the
+// filters do not concern always pass.
+func (n *Notifier) matchesFilters(sub storage.Subscription, event *github.WebhookEvent) bool {
+	filters, err := storage.ParseFilters(sub.Filters)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to parse subscription filters")
+		return true
+	}
+
+	if github.IsIgnoredEvent(filters.IgnoreAuthors, event.Payload) {
+		return false
+	}
+	if !github.MatchText(filters.Include, filters.Exclude, event.Payload) {
+		return false
+	}
+
+	switch e := event.Payload.(type) {
+	case *github.PushEvent:
+		if len(filters.Branches) > 0 && !github.MatchBranch(filters.Branches, e.Branch()) {
+			return false
+		}
+	case *github.IssueEvent:
+		if !github.MatchLabels(e.Labels, filters.Labels, filters.ExcludeLabels) {
+			return false
+		}
+	case *github.PullRequestEvent:
+		if !github.MatchLabels(e.Labels, filters.Labels, filters.ExcludeLabels) {
+			return false
+		}
+	case *github.ReleaseEvent:
+		if filters.SkipPrereleases && github.IsPrerelease(e, filters.PrereleaseTags) {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseForSubscription returns a copy of a release with the assets a
+// subscription wants listed, and whether they differ from the event's. An
+// asset filter leaves the list out, since matching assets are appended to
+// the notification separately.
+func (n *Notifier) releaseForSubscription(sub storage.Subscription, release *github.ReleaseEvent) (*github.ReleaseEvent, bool) {
+	filters, err := storage.ParseFilters(sub.Filters)
+	if err != nil {
+		return nil, false
+	}
+
+	listed := *release
+	switch {
+	case filters.Assets != "":
+		listed.Assets = nil
+	case len(filters.Platforms) > 0:
+		listed.Assets = github.FilterAssetsByPlatform(release.Assets, filters.Platforms)
+	default:
+		return nil, false
+	}
+	return &listed, true
+}
@@ -0,0 +1,65 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/telegraph"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// maxFullTextLength caps how much of a text is published, keeping pages
+// within Telegraph's content size limit.
+const maxFullTextLength = 32 * 1024
+
+// SetTelegraph enables publishing release notes and issue descriptions that
+// are too long for a notification to Telegraph.
+func (n *Notifier) SetTelegraph(client *telegraph.Client) {
+	n.telegraph = client
+}
+
+// publishFullText publishes the body of a release or new issue that is cut
+// short in its notification, and links the page from the event.
+func (n *Notifier) publishFullText(event *github.WebhookEvent) {
+	if n.telegraph == nil {
+		return
+	}
+
+	repo := fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName)
+	var title, body, url string
+	var target *string
+	switch e := event.Payload.(type) {
+	case *github.ReleaseEvent:
+		if len(e.Body) <= github.MaxReleaseBodyLength || e.FullTextURL != "" {
+			return
+		}
+		name := e.Name
+		if name == "" {
+			name = e.TagName
+		}
+		title, body, url, target = repo+" "+name, e.Body, e.URL, &e.FullTextURL
+	case *github.IssueEvent:
+		if e.Action != "opened" || len(e.Body) <= github.MaxIssueBodyLength || e.FullTextURL != "" {
+			return
+		}
+		title = fmt.Sprintf("%s#%d: %s", repo, e.Number, e.Title)
+		body, url, target = e.Body, e.URL, &e.FullTextURL
+	default:
+		return
+	}
+
+	content := telegraph.FromHTML(markdown.ToHTML(github.FormatGFM(body, maxFullTextLength)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	page, err := n.telegraph.CreatePage(ctx, title, url, content)
+	if err != nil {
+		logger.Warn().Err(err).Str("repo", repo).Msg("Failed to publish full text to Telegraph")
+		return
+	}
+	*target = page
+}
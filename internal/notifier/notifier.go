@@ -2,36 +2,218 @@
 package notifier
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/sink"
 	"github.com/user/githubbot/internal/storage"
 	"github.com/user/githubbot/internal/telegram"
+	"github.com/user/githubbot/internal/telegraph"
 	"github.com/user/githubbot/pkg/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("github.com/user/githubbot/internal/notifier")
+
 // Notifier sends notifications to Telegram chats.
 type Notifier struct {
 	bot        *tgbotapi.BotAPI
-	store      *storage.SubscriptionStore
+	store      storage.Store
+	ghClient   *github.Client
 	msgBuilder *telegram.MessageBuilder
+	sender     *sendQueue // Paces all outgoing notifications
+	resumeID   int64      // Newest outbox message left from before this start
+	batches    batcher    // Notifications collected during the batch window
+
+	assetWait    time.Duration // How long to wait for a matching release asset
+	assetRecheck time.Duration // How often to re-check a release for late assets
+
+	// Last failure state per repo, workflow and branch, used to detect
+	// recoveries. It starts empty after a restart.
+	ciFailed map[string]bool
+
+	digestHour int                    // Hour of day digests are sent
+	lastDigest time.Time              // When digests were last checked for delivery
+	settings   *storage.SettingsStore // Keeps lastDigest across restarts, nil to keep it in memory
+
+	parseMode string // Parse mode of chats without their own setting
+	dryRun    bool   // Notifications of all chats logged instead of sent
+
+	telegraph *telegraph.Client // Publishes long texts, nil if disabled
+
+	sinks map[string]sink.Sink // Sinks subscriptions can be routed to, by kind
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // NewNotifier creates a new notifier instance.
-func NewNotifier(bot *tgbotapi.BotAPI, store *storage.SubscriptionStore) *Notifier {
-	return &Notifier{
-		bot:        bot,
-		store:      store,
-		msgBuilder: telegram.NewMessageBuilder(),
+func NewNotifier(bot *tgbotapi.BotAPI, store storage.Store) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		bot:          bot,
+		store:        store,
+		msgBuilder:   telegram.NewMessageBuilder(),
+		sender:       newSendQueue(bot),
+		assetWait:    6 * time.Hour,
+		assetRecheck: 10 * time.Minute,
+		ciFailed:     make(map[string]bool),
+		digestHour:   9,
+		parseMode:    storage.ParseModeMarkdown,
+		sinks:        make(map[string]sink.Sink),
+		ctx:          ctx,
+		cancel:       cancel,
 	}
+
+	// Notifications written before now are resumed once the notifier starts
+	if id, err := store.GetMaxOutboxID(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to inspect the outbox")
+	} else {
+		n.resumeID = id
+	}
+
+	// Messages are sent from the start, even before queued events are delivered
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer logger.ReportPanic()
+		n.sender.run(ctx)
+	}()
+	return n
+}
+
+// SetGitHubClient sets the GitHub client used to fetch release assets.
+func (n *Notifier) SetGitHubClient(client *github.Client) {
+	n.ghClient = client
 }
 
-// HandleWebhookEvent processes a webhook event and sends notifications.
+// SetSettingsStore sets the store keeping when digests were last checked,
+// so that digests due while the bot was down are still sent.
+func (n *Notifier) SetSettingsStore(settings *storage.SettingsStore) {
+	n.settings = settings
+}
+
+// SetAssetWait configures how long release notifications wait for assets
+// matching a subscription's asset filter, and how often they re-check.
+func (n *Notifier) SetAssetWait(wait, recheck time.Duration) {
+	if recheck < time.Minute {
+		recheck = time.Minute
+	}
+	n.assetWait = wait
+	n.assetRecheck = recheck
+}
+
+// SetParseMode configures the parse mode of chats without their own setting.
+func (n *Notifier) SetParseMode(mode string) {
+	switch mode {
+	case storage.ParseModeMarkdown, storage.ParseModeHTML:
+		n.parseMode = mode
+	default:
+		logger.Warn().Str("parse_mode", mode).Msg("Invalid parse mode, using markdown")
+		n.parseMode = storage.ParseModeMarkdown
+	}
+}
+
+// SetDryRun configures whether the notifications of all chats are only
+// logged instead of sent. Chats can also be set to dry run on their own.
+func (n *Notifier) SetDryRun(enabled bool) {
+	n.dryRun = enabled
+}
+
+// SendQueueDepth returns how many notifications wait for their turn to be
+// sent.
+func (n *Notifier) SendQueueDepth() int {
+	return n.sender.depth()
+}
+
+// HandleWebhookEvent processes a webhook event and sends notifications. The
+// handling is traced as part of the event's trace, which deliveries sent
+// later, batched or waiting for release assets, continue as well.
 func (n *Notifier) HandleWebhookEvent(event *github.WebhookEvent) error {
+	ctx, span := tracer.Start(event.Context(), "notifier.handle", trace.WithAttributes(
+		attribute.String("github.event", event.Type),
+		attribute.String("github.repo", event.RepoOwner+"/"+event.RepoName),
+		attribute.String("event.source", event.Source),
+	))
+	defer span.End()
+	event.SetTraceContext(ctx)
+
+	// Redeliveries of a webhook are recognized by their delivery ID, whatever
+	// their payload
+	if n.isDelivered(event) {
+		logger.Debug().Str("delivery", event.DeliveryID).Msg("Webhook delivery already processed, skipping")
+		span.SetAttributes(attribute.Bool("github.redelivery", true))
+		return nil
+	}
+
+	err := n.handleEvent(event)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "handling failed")
+		return err
+	}
+	n.recordDelivery(event)
+	return nil
+}
+
+// deliveryEventType is the event type webhook delivery IDs are recorded
+// under, alongside the keys of the events.
+const deliveryEventType = "delivery"
+
+// isDelivered reports whether the webhook delivery of an event was
+// processed already.
+func (n *Notifier) isDelivered(event *github.WebhookEvent) bool {
+	if event.DeliveryID == "" {
+		return false
+	}
+	done, err := n.store.IsEventProcessed(event.RepoOwner, event.RepoName, deliveryEventType, event.DeliveryID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check webhook delivery")
+	}
+	return done
+}
+
+// recordDelivery records the webhook delivery of an event as processed.
+func (n *Notifier) recordDelivery(event *github.WebhookEvent) {
+	if event.DeliveryID == "" {
+		return
+	}
+	if err := n.store.RecordEvent(event.RepoOwner, event.RepoName, deliveryEventType, event.DeliveryID); err != nil {
+		logger.Warn().Err(err).Msg("Failed to record webhook delivery")
+	}
+}
+
+// handleEvent sends the notifications of an event.
+func (n *Notifier) handleEvent(event *github.WebhookEvent) error {
+	// Compliance checks are evaluated per subscription, without deduplication
+	if compliance, ok := event.Payload.(*github.ComplianceEvent); ok {
+		return n.handleCompliance(event, compliance)
+	}
+
+	// Lifecycle changes concern every subscriber, whatever their event selection
+	if lifecycle, ok := event.Payload.(*github.RepositoryEvent); ok {
+		return n.handleRepositoryLifecycle(event, lifecycle)
+	}
+
+	// New repositories of a topic go to the topic's subscribers instead
+	if topic, ok := event.Payload.(*github.TopicRepoEvent); ok {
+		return n.handleTopicRepo(event, topic)
+	}
+
 	// Get all subscribers for this repo
-	subs, err := n.store.GetSubscriptionsByRepo(event.RepoOwner, event.RepoName)
+	subs, err := n.store.GetActiveSubscriptionsByRepo(event.RepoOwner, event.RepoName)
 	if err != nil {
 		return fmt.Errorf("failed to get subscribers: %w", err)
 	}
@@ -43,80 +225,260 @@ func (n *Notifier) HandleWebhookEvent(event *github.WebhookEvent) error {
 		return nil
 	}
 
-	// Generate event ID for deduplication
-	eventID := n.generateEventID(event)
+	// Check if event was already processed, by the webhook or the poller
+	eventKeys := github.EventKeys(event)
+	if n.isProcessed(event, eventKeys) {
+		logger.Debug().Strs("event_keys", eventKeys).Msg("Event already processed, skipping")
+		return nil
+	}
+
+	// Long release notes and issue descriptions are linked in full
+	n.publishFullText(event)
 
-	// Check if event was already processed
-	processed, err := n.store.IsEventProcessed(event.RepoOwner, event.RepoName, event.Type, eventID)
-	if err != nil {
-		logger.Warn().Err(err).Msg("Failed to check event processing status")
+	// Build the notification message once per language in use
+	messages := make(map[i18n.Lang]string)
+	message := func(lang i18n.Lang) string {
+		if m, ok := messages[lang]; ok {
+			return m
+		}
+		m := n.buildMessage(event, lang)
+		messages[lang] = m
+		return m
 	}
-	if processed {
-		logger.Debug().Str("event_id", eventID).Msg("Event already processed, skipping")
+	if message(i18n.Default()) == "" {
 		return nil
 	}
+	n.recordHistory(event)
 
-	// Build the notification message
-	message := n.buildMessage(event)
-	if message == "" {
-		return nil
+	// Remember whether this run fixed a previously failing workflow
+	run, isRun := event.Payload.(*github.WorkflowRunEvent)
+	recovered := false
+	if isRun {
+		recovered = n.trackWorkflowRun(event, run)
 	}
 
 	// Send to all subscribers who want this event type
 	eventType := storage.EventType(event.Type)
+	now := time.Now()
+	accepted := false
 	for _, sub := range subs {
+		if sub.IsMuted(now) {
+			continue
+		}
 		if n.isEventEnabled(sub, eventType) {
-			if err := n.sendNotification(sub.ChatID, message); err != nil {
+			if isRun && !n.wantsWorkflowRun(sub, run, recovered) {
+				continue
+			}
+			if !n.matchesFilters(sub, event) {
+				continue
+			}
+			accepted = true
+			// Counted whether delivered right away or later in a summary
+			if err := n.store.RecordNotification(sub.ChatID, event.RepoOwner, event.RepoName, event.Type, now); err != nil {
+				logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to record notification stats")
+			}
+			chat, err := n.store.GetChat(sub.ChatID)
+			if err != nil {
+				logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to get chat")
+			}
+			lang := chatLanguage(chat)
+			// Digests, quiet hours and batching only concern notifications to the chat
+			routed := sub.Sink != ""
+			if !routed && sub.Digest != storage.DigestOff && n.queueEvent(sub.ChatID, event, sub.Digest, lang) {
+				continue
+			}
+			text := message(lang)
+			if release, ok := event.Payload.(*github.ReleaseEvent); ok {
+				if listed, ok := n.releaseForSubscription(sub, release); ok {
+					text = n.msgBuilder.BuildReleaseMessage(event.RepoOwner, event.RepoName, listed, lang)
+				}
+			}
+			if custom := n.customMessage(sub, event); custom != "" {
+				text = custom
+			}
+			opts := sendOptions{
+				markup: actionKeyboard(sub, event, lang),
+				silent: sub.IsSilent(eventType),
+				sink:   sub.Sink,
+			}
+			if chat != nil && chat.Photos {
+				opts.photo = github.PreviewImageURL(event.RepoOwner, event.RepoName, event.Payload)
+			}
+			if release, ok := event.Payload.(*github.ReleaseEvent); ok {
+				if pattern := n.assetFilter(sub); pattern != "" {
+					n.notifyReleaseAssets(sub.ChatID, event, release, text, opts, pattern, time.Now().Add(n.assetWait), lang)
+					continue
+				}
+			}
+			if !routed && n.queueIfQuiet(chat, event, now, lang) {
+				continue
+			}
+			if !routed && n.batchEvent(chat, event, text, opts, lang) {
+				continue
+			}
+			if err := n.sendNotificationWithOptions(event.Context(), sub.ChatID, text, opts); err != nil {
 				logger.Error().
 					Err(err).
 					Int64("chat_id", sub.ChatID).
 					Msg("Failed to send notification")
 				// Continue sending to other subscribers
+				continue
 			}
+			observeLag(event)
 		}
 	}
 
-	// Record the event as processed
-	if err := n.store.RecordEvent(event.RepoOwner, event.RepoName, event.Type, eventID); err != nil {
-		logger.Warn().Err(err).Msg("Failed to record event")
+	// Record the event as processed, unless every subscription filtered it
+	// out: the same commits pushed to another branch may still be wanted
+	if !accepted {
+		return nil
+	}
+	for _, key := range eventKeys {
+		if err := n.store.RecordEvent(event.RepoOwner, event.RepoName, event.Type, key); err != nil {
+			logger.Warn().Err(err).Msg("Failed to record event")
+		}
 	}
 
 	return nil
 }
 
-// generateEventID creates a unique ID for an event.
-func (n *Notifier) generateEventID(event *github.WebhookEvent) string {
+// recordHistory adds an event to the history of its repository shown by
+// /history.
+func (n *Notifier) recordHistory(event *github.WebhookEvent) {
+	payload, err := json.Marshal(event.Payload)
+	if err == nil {
+		err = n.store.RecordHistory(event.RepoOwner, event.RepoName, event.Type, string(payload), event.Source, event.OccurredAt)
+	}
+	if err != nil {
+		logger.Warn().Err(err).Str("repo", event.RepoOwner+"/"+event.RepoName).Msg("Failed to record event history")
+	}
+}
+
+// isProcessed reports whether all keys of an event were recorded as
+// processed, or the event was recorded under the IDs used by earlier versions:
+// the commits alone for a push.
+func (n *Notifier) isProcessed(event *github.WebhookEvent, keys []string) bool {
+	_, push := event.Payload.(*github.PushEvent)
+	processed := true
+	for _, key := range keys {
+		done, err := n.store.IsEventProcessed(event.RepoOwner, event.RepoName, event.Type, key)
+		if err == nil && !done && push {
+			done, err = n.store.IsEventProcessed(event.RepoOwner, event.RepoName, event.Type, github.PushKeySHA(key))
+		}
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to check event processing status")
+		}
+		if !done {
+			processed = false
+			break
+		}
+	}
+	if processed {
+		return true
+	}
+
+	if id := legacyEventID(event); id != "" {
+		done, err := n.store.IsEventProcessed(event.RepoOwner, event.RepoName, event.Type, id)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to check event processing status")
+		}
+		return done
+	}
+	return false
+}
+
+// legacyEventID returns the ID earlier versions recorded events under, for
+// the kinds of events whose key changed, or "".
+func legacyEventID(event *github.WebhookEvent) string {
 	switch e := event.Payload.(type) {
 	case *github.PushEvent:
-		return e.After // Use the commit SHA
+		return e.After
 	case *github.ReleaseEvent:
 		return e.TagName
 	case *github.IssueEvent:
 		return fmt.Sprintf("%d-%s", e.Number, e.Action)
 	case *github.PullRequestEvent:
 		return fmt.Sprintf("%d-%s", e.Number, e.Action)
-	default:
-		return fmt.Sprintf("%s-%v", event.Type, event.Payload)
 	}
+	return ""
 }
 
 // buildMessage creates the notification message for an event.
-func (n *Notifier) buildMessage(event *github.WebhookEvent) string {
+func (n *Notifier) buildMessage(event *github.WebhookEvent, lang i18n.Lang) string {
 	switch e := event.Payload.(type) {
 	case *github.PushEvent:
-		return n.msgBuilder.BuildPushMessage(event.RepoOwner, event.RepoName, e)
+		return n.msgBuilder.BuildPushMessage(event.RepoOwner, event.RepoName, e, lang)
 	case *github.ReleaseEvent:
-		return n.msgBuilder.BuildReleaseMessage(event.RepoOwner, event.RepoName, e)
+		return n.msgBuilder.BuildReleaseMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.TagEvent:
+		return n.msgBuilder.BuildTagMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.PackageEvent:
+		return n.msgBuilder.BuildPackageMessage(event.RepoOwner, event.RepoName, e, lang)
 	case *github.IssueEvent:
-		return n.msgBuilder.BuildIssueMessage(event.RepoOwner, event.RepoName, e)
+		return n.msgBuilder.BuildIssueMessage(event.RepoOwner, event.RepoName, e, lang)
 	case *github.PullRequestEvent:
-		return n.msgBuilder.BuildPRMessage(event.RepoOwner, event.RepoName, e)
+		return n.msgBuilder.BuildPRMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.PullRequestReviewEvent:
+		return n.msgBuilder.BuildReviewMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.PullRequestReviewCommentEvent:
+		return n.msgBuilder.BuildReviewCommentMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.WorkflowRunEvent:
+		return n.msgBuilder.BuildWorkflowRunMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.DeploymentEvent:
+		return n.msgBuilder.BuildDeploymentMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.WikiEvent:
+		return n.msgBuilder.BuildWikiMessage(event.RepoOwner, event.RepoName, e, lang)
+	case *github.StarEvent:
+		return n.msgBuilder.BuildStarMessage(event.RepoOwner, event.RepoName, e, lang)
 	default:
 		logger.Warn().Str("type", event.Type).Msg("Unknown event type")
 		return ""
 	}
 }
 
+// customMessage renders a subscription's custom template for an event. It
+// returns an empty string when there is no template or it fails to render, in
+// which case the built-in message is used.
+func (n *Notifier) customMessage(sub storage.Subscription, event *github.WebhookEvent) string {
+	templates, err := storage.ParseTemplates(sub.Templates)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to parse message templates")
+		return ""
+	}
+	eventType := storage.EventType(event.Type)
+	tmpl, ok := templates[eventType]
+	if !ok {
+		return ""
+	}
+	message, err := n.msgBuilder.BuildCustomMessage(event.RepoOwner, event.RepoName, eventType, tmpl, event.Payload)
+	if err != nil {
+		logger.Warn().
+			Err(err).
+			Int64("chat_id", sub.ChatID).
+			Str("event", event.Type).
+			Msg("Failed to render message template, using default message")
+		return ""
+	}
+	return message
+}
+
+// chatLanguage returns the language notifications to a chat are written in.
+func chatLanguage(chat *storage.Chat) i18n.Lang {
+	if chat == nil {
+		return i18n.Default()
+	}
+	return i18n.Resolve(chat.Language)
+}
+
+// languageFor looks up the language notifications to a chat are written in.
+func (n *Notifier) languageFor(chatID int64) i18n.Lang {
+	chat, err := n.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	return chatLanguage(chat)
+}
+
 // isEventEnabled checks if a subscriber wants this type of event.
 func (n *Notifier) isEventEnabled(sub storage.Subscription, eventType storage.EventType) bool {
 	var events []storage.EventType
@@ -133,12 +495,405 @@ func (n *Notifier) isEventEnabled(sub storage.Subscription, eventType storage.Ev
 	return false
 }
 
-// sendNotification sends a message to a chat.
-func (n *Notifier) sendNotification(chatID int64, message string) error {
-	msg := tgbotapi.NewMessage(chatID, message)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	msg.DisableWebPagePreview = true
+// trackWorkflowRun records the outcome of a workflow run and reports whether it
+// is the first success after a failure on the same workflow and branch.
+func (n *Notifier) trackWorkflowRun(event *github.WebhookEvent, run *github.WorkflowRunEvent) bool {
+	key := fmt.Sprintf("%s/%s:%s:%s", event.RepoOwner, event.RepoName, run.Name, run.Branch)
+	wasFailing := n.ciFailed[key]
+	n.ciFailed[key] = run.Failed()
+	return wasFailing && run.Conclusion == "success"
+}
+
+// wantsWorkflowRun applies the CI filter of a subscription to a workflow run.
+func (n *Notifier) wantsWorkflowRun(sub storage.Subscription, run *github.WorkflowRunEvent, recovered bool) bool {
+	filters, err := storage.ParseFilters(sub.Filters)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to parse subscription filters")
+		return true
+	}
+
+	switch filters.CI {
+	case storage.CIFilterFailures:
+		return run.Failed()
+	case storage.CIFilterFailuresRecovery:
+		return run.Failed() || recovered
+	default:
+		return true
+	}
+}
+
+// handleCompliance compares a repository's license and visibility against the
+// compliance filter of each subscription and notifies on state transitions only.
+func (n *Notifier) handleCompliance(event *github.WebhookEvent, compliance *github.ComplianceEvent) error {
+	subs, err := n.store.GetActiveSubscriptionsByRepo(event.RepoOwner, event.RepoName)
+	if err != nil {
+		return fmt.Errorf("failed to get subscribers: %w", err)
+	}
 
-	_, err := n.bot.Send(msg)
-	return err
+	for _, sub := range subs {
+		filters, err := storage.ParseFilters(sub.Filters)
+		if err != nil {
+			logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to parse subscription filters")
+			continue
+		}
+
+		var violations []string
+		if len(filters.Licenses) > 0 {
+			violations = compliance.Violations(filters.Licenses)
+		}
+		current := strings.Join(violations, "; ")
+		if current == sub.ComplianceViolation {
+			continue
+		}
+
+		if err := n.store.SetComplianceViolation(sub.ID, current); err != nil {
+			logger.Error().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to record compliance state")
+			continue
+		}
+
+		lang := n.languageFor(sub.ChatID)
+		var message string
+		var markup interface{}
+		switch {
+		case current != "":
+			message = n.msgBuilder.BuildComplianceAlert(event.RepoOwner, event.RepoName, compliance, violations, filters.Licenses, lang)
+			markup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "compliance.ack_button"), fmt.Sprintf("ack:%d", sub.ID)),
+			))
+		case len(filters.Licenses) > 0:
+			message = n.msgBuilder.BuildComplianceResolved(event.RepoOwner, event.RepoName, compliance, lang)
+		default:
+			continue // Filter removed, nothing to announce
+		}
+
+		logger.Info().
+			Str("repo", fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName)).
+			Int64("chat_id", sub.ChatID).
+			Str("violation", current).
+			Msg("Compliance state changed")
+
+		if err := n.sendNotificationWithOptions(event.Context(), sub.ChatID, message, sendOptions{markup: markup}); err != nil {
+			logger.Error().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to send notification")
+		}
+	}
+	return nil
+}
+
+// handleRepositoryLifecycle moves subscriptions of a renamed or transferred
+// repository to its new name and notifies all of its subscribers.
+func (n *Notifier) handleRepositoryLifecycle(event *github.WebhookEvent, lifecycle *github.RepositoryEvent) error {
+	if lifecycle.OldOwner != event.RepoOwner || lifecycle.OldName != event.RepoName {
+		if err := n.store.RenameRepository(lifecycle.OldOwner, lifecycle.OldName, event.RepoOwner, event.RepoName); err != nil {
+			return fmt.Errorf("failed to rename subscriptions: %w", err)
+		}
+		logger.Info().
+			Str("from", fmt.Sprintf("%s/%s", lifecycle.OldOwner, lifecycle.OldName)).
+			Str("to", fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName)).
+			Msg("Repository renamed, subscriptions updated")
+	}
+
+	subs, err := n.store.GetActiveSubscriptionsByRepo(event.RepoOwner, event.RepoName)
+	if err != nil {
+		return fmt.Errorf("failed to get subscribers: %w", err)
+	}
+
+	for _, sub := range subs {
+		message := n.msgBuilder.BuildRepositoryMessage(event.RepoOwner, event.RepoName, lifecycle, n.languageFor(sub.ChatID))
+		if err := n.sendNotification(event.Context(), sub.ChatID, message); err != nil {
+			logger.Error().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to send notification")
+		}
+	}
+	return nil
+}
+
+// handleTopicRepo announces a new repository to the chats following one of
+// its topics.
+func (n *Notifier) handleTopicRepo(event *github.WebhookEvent, topic *github.TopicRepoEvent) error {
+	// Recorded under the topic, see TopicRepoKey
+	key := github.TopicRepoKey(topic.ID)
+	done, err := n.store.IsEventProcessed("", topic.Topic, event.Type, key)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check event processing status")
+	}
+	if done {
+		return nil
+	}
+
+	chatIDs, err := n.store.GetActiveTopicSubscribers(topic.Topic)
+	if err != nil {
+		return fmt.Errorf("failed to get topic subscribers: %w", err)
+	}
+	for _, chatID := range chatIDs {
+		message := n.msgBuilder.BuildTopicRepoMessage(event.RepoOwner, event.RepoName, topic, n.languageFor(chatID))
+		if err := n.sendNotification(event.Context(), chatID, message); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send notification")
+		}
+	}
+
+	if err := n.store.RecordEvent("", topic.Topic, event.Type, key); err != nil {
+		logger.Warn().Err(err).Msg("Failed to record event")
+	}
+	return nil
+}
+
+// assetFilter returns the asset glob configured for a subscription, if any.
+func (n *Notifier) assetFilter(sub storage.Subscription) string {
+	filters, err := storage.ParseFilters(sub.Filters)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", sub.ChatID).Msg("Failed to parse subscription filters")
+		return ""
+	}
+	return filters.Assets
+}
+
+// notifyReleaseAssets sends a release notification message once the release
+// has an asset matching pattern. Until the deadline passes it re-checks periodically,
+// after which a notice about the missing asset is sent instead. Re-checks
+// still pending when the notifier stops leave the notification without its
+// assets in the outbox, to be sent on the next start.
+func (n *Notifier) notifyReleaseAssets(chatID int64, event *github.WebhookEvent, release *github.ReleaseEvent, message string, opts sendOptions, pattern string, deadline time.Time, lang i18n.Lang) {
+	if n.checkReleaseAssets(chatID, event, release, message, opts, pattern, deadline, lang) {
+		return
+	}
+
+	logger.Debug().
+		Str("repo", fmt.Sprintf("%s/%s", event.RepoOwner, event.RepoName)).
+		Str("tag", release.TagName).
+		Str("pattern", pattern).
+		Msg("No matching release asset yet, re-checking later")
+
+	if n.ctx.Err() != nil {
+		n.keepNotification(chatID, message, opts)
+		return
+	}
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		defer logger.ReportPanic()
+
+		ticker := time.NewTicker(n.assetRecheck)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-n.ctx.Done():
+				n.keepNotification(chatID, message, opts)
+				return
+			case <-ticker.C:
+				if n.checkReleaseAssets(chatID, event, release, message, opts, pattern, deadline, lang) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// checkReleaseAssets sends a release notification message with the assets
+// matching pattern, or the missing asset notice once the deadline passed. It
+// reports false if neither was sent and the release should be re-checked.
+func (n *Notifier) checkReleaseAssets(chatID int64, event *github.WebhookEvent, release *github.ReleaseEvent, message string, opts sendOptions, pattern string, deadline time.Time, lang i18n.Lang) bool {
+	assets := n.releaseAssets(event, release)
+
+	matched := github.MatchAssets(assets, pattern)
+	if len(matched) > 0 {
+		message += n.msgBuilder.BuildAssetSection(matched, n.checksums(assets, matched), lang)
+		if err := n.sendNotificationWithOptions(event.Context(), chatID, message, opts); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send notification")
+		}
+		return true
+	}
+
+	if !time.Now().Before(deadline) {
+		notice := n.msgBuilder.BuildMissingAssetMessage(event.RepoOwner, event.RepoName, release, pattern, lang)
+		if err := n.sendNotification(event.Context(), chatID, notice); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to send notification")
+		}
+		return true
+	}
+	return false
+}
+
+// releaseAssets returns the current assets of a release, falling back to the
+// assets included in the event when they cannot be fetched.
+func (n *Notifier) releaseAssets(event *github.WebhookEvent, release *github.ReleaseEvent) []github.AssetInfo {
+	if n.ghClient == nil {
+		return release.Assets
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assets, err := n.ghClient.GetReleaseAssets(ctx, event.RepoOwner, event.RepoName, release.TagName)
+	if err != nil {
+		logger.Warn().Err(err).Str("tag", release.TagName).Msg("Failed to fetch release assets")
+		return release.Assets
+	}
+	return assets
+}
+
+// checksums fetches the SHA-256 checksums published alongside matched assets.
+func (n *Notifier) checksums(assets, matched []github.AssetInfo) map[string]string {
+	sums := make(map[string]string)
+	for _, a := range matched {
+		sumAsset, ok := github.ChecksumAsset(assets, a)
+		if !ok {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		sum, err := github.FetchChecksum(ctx, sumAsset)
+		cancel()
+		if err != nil {
+			logger.Debug().Err(err).Str("asset", a.Name).Msg("Failed to fetch checksum")
+			continue
+		}
+		sums[a.Name] = sum
+	}
+	return sums
+}
+
+// actionKeyboard returns the inline buttons attached to an event notification:
+// a link to the event on GitHub and shortcuts to mute or drop the subscription.
+func actionKeyboard(sub storage.Subscription, event *github.WebhookEvent, lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	if url := github.EventURL(event.Payload); github.SanitizeURL(url) != "" {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonURL(i18n.Plain(lang, "notify.open_button"), url))
+	}
+	row = append(row,
+		tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "notify.mute_button"), fmt.Sprintf("mute:%d", sub.ID)),
+		tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "notify.unsub_button"), fmt.Sprintf("unsub:%d", sub.ID)),
+	)
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// sendNotification sends a message to a chat. ctx carries the trace of the
+// event notified.
+func (n *Notifier) sendNotification(ctx context.Context, chatID int64, message string) error {
+	return n.sendNotificationWithOptions(ctx, chatID, message, sendOptions{})
+}
+
+// sendOptions are the per-message delivery options of a notification.
+type sendOptions struct {
+	markup interface{} // Reply markup, nil for none
+	silent bool        // Deliver without a notification sound
+	photo  string      // Image to send the message as caption of, empty for none
+	sink   string      // Route to a sink to deliver to instead of the chat, empty for none
+}
+
+// maxCaptionLength is the longest caption Telegram accepts, in UTF-16 code
+// units. The formatting markup is counted as well, erring on the safe side.
+const maxCaptionLength = 1024
+
+// sendNotificationWithOptions sends a message with the given options,
+// converting it to HTML for chats that prefer it. Notifications of a chat
+// bound to a channel are posted to the channel without the markup, whose
+// buttons would let any reader change the subscription. Messages routed to a
+// sink are kept in MarkdownV2 and without markup, the sink converts them. The
+// message is kept in the outbox until it was delivered. In dry run it is
+// logged instead.
+func (n *Notifier) sendNotificationWithOptions(ctx context.Context, chatID int64, message string, opts sendOptions) error {
+	if n.isDryRun(chatID) {
+		logger.Info().
+			Int64("chat_id", chatID).
+			Str("sink", opts.sink).
+			Str("text", message).
+			Msg("Dry run, notification not sent")
+		return nil
+	}
+
+	m, err := n.outboxMessage(chatID, message, opts)
+	if err != nil {
+		return err
+	}
+	id, err := n.store.AddOutboxMessage(&m)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to persist notification, sending anyway")
+	}
+	m.ID = id
+	return n.deliver(ctx, m)
+}
+
+// keepNotification leaves a notification in the outbox without sending it,
+// so that it is sent when the notifier next starts.
+func (n *Notifier) keepNotification(chatID int64, message string, opts sendOptions) {
+	if n.isDryRun(chatID) {
+		logger.Info().Int64("chat_id", chatID).Str("text", message).Msg("Dry run, notification not sent")
+		return
+	}
+	m, err := n.outboxMessage(chatID, message, opts)
+	if err == nil {
+		_, err = n.store.AddOutboxMessage(&m)
+	}
+	if err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to keep notification for the next start")
+	}
+}
+
+// outboxMessage returns the outbox message delivering a notification with
+// the given options to a chat, its channel or a sink.
+func (n *Notifier) outboxMessage(chatID int64, message string, opts sendOptions) (storage.OutboxMessage, error) {
+	m := storage.OutboxMessage{
+		ChatID:    chatID,
+		TargetID:  chatID,
+		Text:      message,
+		ParseMode: tgbotapi.ModeMarkdownV2,
+		Photo:     opts.photo,
+		Silent:    opts.silent,
+	}
+	if opts.sink != "" {
+		m.Sink = opts.sink
+		opts.markup = nil
+	} else {
+		if channelID := n.chatChannel(chatID); channelID != 0 {
+			m.TargetID = channelID
+			opts.markup = nil
+		}
+		if n.chatParseMode(chatID) == storage.ParseModeHTML {
+			m.Text, m.ParseMode = markdown.ToHTML(message), tgbotapi.ModeHTML
+		}
+	}
+	if opts.markup != nil {
+		markup, err := json.Marshal(opts.markup)
+		if err != nil {
+			return m, fmt.Errorf("failed to encode reply markup: %w", err)
+		}
+		m.Markup = string(markup)
+	}
+	return m, nil
+}
+
+// isDryRun reports whether notifications to a chat are only logged.
+func (n *Notifier) isDryRun(chatID int64) bool {
+	if n.dryRun {
+		return true
+	}
+	chat, err := n.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	return chat != nil && chat.DryRun
+}
+
+// chatChannel returns the channel a chat's notifications are posted to, or 0.
+func (n *Notifier) chatChannel(chatID int64) int64 {
+	chat, err := n.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	if chat == nil {
+		return 0
+	}
+	return chat.ChannelID
+}
+
+// chatParseMode returns the parse mode notifications to a chat are sent with.
+func (n *Notifier) chatParseMode(chatID int64) string {
+	chat, err := n.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	if chat == nil || chat.ParseMode == storage.ParseModeDefault {
+		return n.parseMode
+	}
+	return chat.ParseMode
 }
@@ -0,0 +1,339 @@
+package notifier
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/internal/telegramtest"
+)
+
+// newTestNotifier returns a notifier sending to a fake Telegram server, with
+// its store in a temporary database.
+func newTestNotifier(t *testing.T) (*Notifier, *storage.SubscriptionStore, *telegramtest.Server) {
+	t.Helper()
+	store := telegramtest.NewStore(t)
+	telegram := telegramtest.NewServer(t)
+	return NewNotifier(telegram.Bot(t), store), store, telegram
+}
+
+// assetRelease returns a release event whose release ships assets.
+func assetRelease(assets []github.AssetInfo) (*github.WebhookEvent, *github.ReleaseEvent) {
+	release := &github.ReleaseEvent{
+		Action:  "published",
+		TagName: "v1.2.0",
+		URL:     "https://github.com/acme/app/releases/tag/v1.2.0",
+		Assets:  assets,
+	}
+	return &github.WebhookEvent{Type: "release", RepoOwner: "acme", RepoName: "app", Payload: release}, release
+}
+
+// multiPlatformAssets are the assets of a release shipping several platforms,
+// some with checksum files.
+var multiPlatformAssets = []github.AssetInfo{
+	{Name: "app-linux-amd64.tar.gz", Size: 5 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-linux-amd64.tar.gz"},
+	{Name: "app-linux-amd64.tar.gz.sha256", Size: 96, DownloadURL: "https://example.com/app-linux-amd64.tar.gz.sha256"},
+	{Name: "app-linux-arm64.tar.gz", Size: 4 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-linux-arm64.tar.gz"},
+	{Name: "app-darwin-arm64.zip", Size: 6 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-darwin-arm64.zip"},
+	{Name: "app-windows-amd64.zip", Size: 5 << 20, DownloadURL: "https://github.com/acme/app/releases/download/v1.2.0/app-windows-amd64.zip"},
+}
+
+func TestNotifyReleaseAssetsMatching(t *testing.T) {
+	tests := []struct {
+		pattern string
+		listed  []string
+		missing []string
+	}{
+		{"*linux-amd64*", []string{"app-linux-amd64.tar.gz"}, []string{"app-linux-arm64.tar.gz", "app-linux-amd64.tar.gz.sha256"}},
+		{"*linux*", []string{"app-linux-amd64.tar.gz", "app-linux-arm64.tar.gz"}, []string{"app-darwin-arm64.zip"}},
+		{"*.zip", []string{"app-darwin-arm64.zip", "app-windows-amd64.zip"}, []string{"app-linux-amd64.tar.gz"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			n, _, telegram := newTestNotifier(t)
+			defer n.Stop()
+
+			event, release := assetRelease(multiPlatformAssets)
+			n.notifyReleaseAssets(42, event, release, "release message", sendOptions{}, tt.pattern, time.Now().Add(time.Hour), i18n.English)
+
+			sent := telegram.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			if !strings.HasPrefix(sent[0], "release message") {
+				t.Errorf("message %q does not start with the release message", sent[0])
+			}
+			for _, name := range tt.listed {
+				if !strings.Contains(sent[0], markdown.Escape(name)) {
+					t.Errorf("message %q does not list %s", sent[0], name)
+				}
+			}
+			for _, name := range tt.missing {
+				if strings.Contains(sent[0], markdown.Escape(name)+"]") {
+					t.Errorf("message %q lists %s", sent[0], name)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyReleaseAssetsMissing(t *testing.T) {
+	n, _, telegram := newTestNotifier(t)
+	defer n.Stop()
+
+	event, release := assetRelease(multiPlatformAssets)
+	n.notifyReleaseAssets(42, event, release, "release message", sendOptions{}, "*linux-riscv64*", time.Now(), i18n.English)
+
+	sent := telegram.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	if want := i18n.T(i18n.English, "assets.missing_title"); !strings.Contains(sent[0], want) {
+		t.Errorf("message %q is not the missing asset notice", sent[0])
+	}
+	if strings.Contains(sent[0], "release message") {
+		t.Errorf("missing asset notice %q includes the release message", sent[0])
+	}
+}
+
+func TestNotifyReleaseAssetsRecheck(t *testing.T) {
+	n, _, telegram := newTestNotifier(t)
+	n.assetRecheck = 10 * time.Millisecond
+
+	event, release := assetRelease(nil)
+	n.notifyReleaseAssets(42, event, release, "release message", sendOptions{}, "*linux-amd64*", time.Now().Add(100*time.Millisecond), i18n.English)
+	if sent := telegram.Messages(); len(sent) != 0 {
+		t.Fatalf("sent %v before the asset wait ended", sent)
+	}
+
+	// The re-checks send the missing asset notice once the wait ends
+	telegram.WaitForMessages(1)
+	n.Stop()
+
+	sent := telegram.Messages()
+	if len(sent) != 1 || !strings.Contains(sent[0], i18n.T(i18n.English, "assets.missing_title")) {
+		t.Errorf("sent %q, want the missing asset notice only", sent)
+	}
+}
+
+func TestNotifyReleaseAssetsKeptOnStop(t *testing.T) {
+	n, store, telegram := newTestNotifier(t)
+
+	event, release := assetRelease(nil)
+	n.notifyReleaseAssets(42, event, release, "release message", sendOptions{}, "*linux-amd64*", time.Now().Add(time.Hour), i18n.English)
+	n.Stop()
+
+	if sent := telegram.Messages(); len(sent) != 0 {
+		t.Errorf("sent %v while waiting for assets", sent)
+	}
+	pending, err := store.GetPendingOutbox(1 << 62)
+	if err != nil {
+		t.Fatalf("GetPendingOutbox() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].ChatID != 42 || pending[0].Text != "release message" {
+		t.Fatalf("pending outbox = %+v, want the release message for the next start", pending)
+	}
+
+	// The next start sends it
+	next, _, nextTelegram := newTestNotifier(t)
+	next.store = store
+	next.resumeID = pending[0].ID
+	next.Start()
+	sent := nextTelegram.WaitForMessages(1)
+	next.Stop()
+	if len(sent) != 1 || sent[0] != "release message" {
+		t.Errorf("next start sent %q, want the kept release message", sent)
+	}
+}
+
+func TestBuildAssetSectionChecksums(t *testing.T) {
+	n, _, _ := newTestNotifier(t)
+	defer n.Stop()
+
+	matched := github.MatchAssets(multiPlatformAssets, "*linux*")
+	sum := strings.Repeat("ab", 32)
+	section := n.msgBuilder.BuildAssetSection(matched, map[string]string{"app-linux-amd64.tar.gz": sum}, i18n.English)
+
+	want := fmt.Sprintf("• [%s](%s) %s\n  `sha256:%s`\n",
+		markdown.Escape("app-linux-amd64.tar.gz"), multiPlatformAssets[0].DownloadURL, markdown.Escape("(5.0 MiB)"), sum)
+	if !strings.Contains(section, want) {
+		t.Errorf("section %q does not contain %q", section, want)
+	}
+	if strings.Count(section, "sha256:") != 1 {
+		t.Errorf("section %q lists a checksum for an asset without one", section)
+	}
+	if !strings.Contains(section, markdown.Escape("app-linux-arm64.tar.gz")) {
+		t.Errorf("section %q does not list the arm64 asset", section)
+	}
+}
+
+func TestHandleComplianceTransitions(t *testing.T) {
+	n, store, telegram := newTestNotifier(t)
+	defer n.Stop()
+
+	// Chat 1 watches the license, chat 2 is subscribed without a policy
+	for _, chatID := range []int64{1, 2} {
+		if err := store.CreateOrUpdateChat(chatID, "private", ""); err != nil {
+			t.Fatalf("CreateOrUpdateChat() error = %v", err)
+		}
+		if err := store.SetChatLanguage(chatID, string(i18n.English)); err != nil {
+			t.Fatalf("SetChatLanguage() error = %v", err)
+		}
+		if err := store.Subscribe(chatID, "acme", "app", []storage.EventType{storage.EventTypeRelease}); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	}
+	if err := store.SetSubscriptionFilters(1, "acme", "app", storage.SubscriptionFilters{Licenses: []string{"MIT"}}); err != nil {
+		t.Fatalf("SetSubscriptionFilters() error = %v", err)
+	}
+
+	alert := i18n.T(i18n.English, "compliance.alert")
+	resolved := i18n.T(i18n.English, "compliance.resolved")
+	steps := []struct {
+		name  string
+		event github.ComplianceEvent
+		want  string // Heading of the message sent, empty for none
+	}{
+		{"compliant", github.ComplianceEvent{License: "MIT", Accessible: true}, ""},
+		{"license changed", github.ComplianceEvent{License: "GPL-3.0", Accessible: true}, alert},
+		{"same drift again", github.ComplianceEvent{License: "GPL-3.0", Accessible: true}, ""},
+		{"archived as well", github.ComplianceEvent{License: "GPL-3.0", Archived: true, Accessible: true}, alert},
+		{"back in compliance", github.ComplianceEvent{License: "MIT", Accessible: true}, resolved},
+		{"still compliant", github.ComplianceEvent{License: "MIT", Accessible: true}, ""},
+		{"went private", github.ComplianceEvent{License: "MIT", Private: true, Accessible: true}, alert},
+		{"inaccessible", github.ComplianceEvent{}, alert},
+	}
+	for _, step := range steps {
+		before := len(telegram.Messages())
+		event := step.event
+		webhook := &github.WebhookEvent{Type: "compliance", RepoOwner: "acme", RepoName: "app", Payload: &event}
+		if err := n.handleCompliance(webhook, &event); err != nil {
+			t.Fatalf("%s: handleCompliance() error = %v", step.name, err)
+		}
+
+		sent := telegram.Messages()[before:]
+		switch {
+		case step.want == "" && len(sent) != 0:
+			t.Errorf("%s: sent %q, want nothing", step.name, sent)
+		case step.want != "" && (len(sent) != 1 || !strings.Contains(sent[0], step.want)):
+			t.Errorf("%s: sent %q, want one message with %q", step.name, sent, step.want)
+		}
+	}
+
+	// The subscription without a policy never records a drift
+	sub, err := store.GetSubscription(2, "acme", "app")
+	if err != nil || sub == nil || sub.ComplianceViolation != "" {
+		t.Errorf("GetSubscription() = %+v, %v, want no drift recorded without a license filter", sub, err)
+	}
+}
+
+func TestHandlePushPerBranch(t *testing.T) {
+	n, store, telegram := newTestNotifier(t)
+	defer n.Stop()
+
+	if err := store.CreateOrUpdateChat(1, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+	if err := store.Subscribe(1, "acme", "app", []storage.EventType{storage.EventTypePush}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	if err := store.SetSubscriptionFilters(1, "acme", "app", storage.SubscriptionFilters{Branches: []string{"main"}}); err != nil {
+		t.Fatalf("SetSubscriptionFilters() error = %v", err)
+	}
+	// Recorded by commit alone by an earlier version
+	if err := store.RecordEvent("acme", "app", "push", "c0ffee"); err != nil {
+		t.Fatalf("RecordEvent() error = %v", err)
+	}
+
+	push := func(ref, sha string) *github.WebhookEvent {
+		return &github.WebhookEvent{Type: "push", RepoOwner: "acme", RepoName: "app", Payload: &github.PushEvent{
+			Ref:     ref,
+			After:   sha,
+			Commits: []github.CommitInfo{{SHA: sha, Message: "Add feature"}},
+		}}
+	}
+	steps := []struct {
+		name  string
+		event *github.WebhookEvent
+		sent  bool
+	}{
+		{"feature branch", push("refs/heads/feature", "abc123"), false},
+		{"merged to main", push("refs/heads/main", "abc123"), true},
+		{"delivered again", push("refs/heads/main", "abc123"), false},
+		{"recorded by commit", push("refs/heads/main", "c0ffee"), false},
+	}
+	for _, step := range steps {
+		before := len(telegram.Messages())
+		if err := n.handleEvent(step.event); err != nil {
+			t.Fatalf("%s: handleEvent() error = %v", step.name, err)
+		}
+		if sent := telegram.Messages()[before:]; (len(sent) == 1) != step.sent || len(sent) > 1 {
+			t.Errorf("%s: sent %q, want a notification %v", step.name, sent, step.sent)
+		}
+	}
+
+	// Only the push that was notified is recorded
+	for _, key := range []string{"refs/heads/feature@abc123", "refs/heads/main@abc123"} {
+		processed, err := store.IsEventProcessed("acme", "app", "push", key)
+		if err != nil {
+			t.Fatalf("IsEventProcessed() error = %v", err)
+		}
+		if want := strings.HasPrefix(key, "refs/heads/main"); processed != want {
+			t.Errorf("IsEventProcessed(%q) = %v, want %v", key, processed, want)
+		}
+	}
+}
+
+func TestDigestsDueWhileDown(t *testing.T) {
+	n, store, telegram := newTestNotifier(t)
+	defer n.Stop()
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "settings.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	settings := storage.NewSettingsStore(db)
+	n.SetSettingsStore(settings)
+
+	if err := store.CreateOrUpdateChat(1, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+	queue := func() {
+		t.Helper()
+		if err := store.QueueEvent(1, "acme", "app", "push", "1 commit", storage.QueueReasonDailyDigest); err != nil {
+			t.Fatalf("QueueEvent() error = %v", err)
+		}
+	}
+
+	// The bot was down over the last digest hour
+	now := time.Now()
+	if err := settings.SetTime(storage.SettingLastDigest, now.Add(-48*time.Hour)); err != nil {
+		t.Fatalf("SetTime() error = %v", err)
+	}
+	queue()
+	n.Start()
+	n.flushDigests(now)
+	if sent := telegram.Messages(); len(sent) != 1 {
+		t.Fatalf("sent %q after the restart, want the missed digest", sent)
+	}
+	if last, err := settings.GetTime(storage.SettingLastDigest, time.Time{}); err != nil || !last.Equal(now) {
+		t.Errorf("GetTime() = %v, %v, want the check saved", last, err)
+	}
+
+	// Restarting right after does not send the next one early
+	restarted := NewNotifier(n.bot, store)
+	defer restarted.Stop()
+	restarted.SetSettingsStore(settings)
+	restarted.Start()
+	queue()
+	restarted.flushDigests(now)
+	if sent := telegram.Messages(); len(sent) != 1 {
+		t.Errorf("sent %q after restarting again, want no digest before the next digest hour", sent)
+	}
+}
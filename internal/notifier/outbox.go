@@ -0,0 +1,167 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// errChatInactive is returned for notifications to a chat the bot can no
+// longer post to.
+var errChatInactive = errors.New("chat is inactive")
+
+// outboxRetention is how many days delivered notifications stay in the outbox.
+const outboxRetention = 7
+
+// resumeOutbox delivers the notifications left pending when the bot last
+// stopped, up to and including maxID.
+func (n *Notifier) resumeOutbox(maxID int64) {
+	defer n.wg.Done()
+	defer logger.ReportPanic()
+
+	messages, err := n.store.GetPendingOutbox(maxID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to load pending notifications")
+		return
+	}
+	if len(messages) > 0 {
+		logger.Info().Int("count", len(messages)).Msg("Resuming delivery of pending notifications")
+	}
+	for _, m := range messages {
+		if n.ctx.Err() != nil {
+			return
+		}
+		if err := n.deliver(context.Background(), m); err != nil {
+			logger.Error().Err(err).Int64("chat_id", m.ChatID).Msg("Failed to send notification")
+		}
+	}
+}
+
+// deliver sends an outbox message and records the outcome. A message with a
+// photo is sent as its caption when it fits, and as text if the photo fails.
+// Messages not sent because the bot stopped stay pending. ctx carries the
+// trace of the event notified.
+func (n *Notifier) deliver(ctx context.Context, m storage.OutboxMessage) (err error) {
+	ctx, span := tracer.Start(ctx, "notifier.deliver", trace.WithAttributes(
+		attribute.Int64("telegram.chat_id", m.TargetID),
+		attribute.Int64("outbox.id", m.ID),
+	))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "delivery failed")
+		}
+		span.End()
+	}()
+
+	if m.Sink != "" {
+		span.SetAttributes(attribute.String("sink.route", m.Sink))
+		return n.deliverToSink(ctx, m)
+	}
+
+	if chat, err := n.store.GetChat(m.TargetID); err == nil && chat != nil && !chat.Active {
+		n.markOutbox(m.ID, errChatInactive)
+		return errChatInactive
+	}
+
+	var markup interface{}
+	if m.Markup != "" {
+		var keyboard tgbotapi.InlineKeyboardMarkup
+		if err := json.Unmarshal([]byte(m.Markup), &keyboard); err != nil {
+			logger.Warn().Err(err).Int64("outbox_id", m.ID).Msg("Invalid reply markup in outbox, sending without it")
+		} else {
+			markup = keyboard
+		}
+	}
+
+	if m.Photo != "" {
+		if err = n.sendPhoto(ctx, m, markup); err != nil {
+			logger.Debug().Err(err).Int64("chat_id", m.ChatID).Msg("Failed to send photo notification, sending text")
+		}
+	}
+	if m.Photo == "" || err != nil {
+		msg := tgbotapi.NewMessage(m.TargetID, m.Text)
+		msg.ParseMode = m.ParseMode
+		msg.DisableWebPagePreview = true
+		msg.DisableNotification = m.Silent
+		if markup != nil {
+			msg.ReplyMarkup = markup
+		}
+		_, err = n.sender.Send(ctx, m.TargetID, msg)
+	}
+
+	if m.ID == 0 || errors.Is(err, errSendQueueStopped) {
+		return err
+	}
+	if err != nil && unreachable(err) {
+		n.disableTarget(m)
+	}
+	n.markOutbox(m.ID, err)
+	return err
+}
+
+// markOutbox records the outcome of delivering an outbox message.
+func (n *Notifier) markOutbox(id int64, err error) {
+	var markErr error
+	if err != nil {
+		markErr = n.store.MarkOutboxFailed(id, err.Error())
+	} else {
+		markErr = n.store.MarkOutboxSent(id)
+	}
+	if markErr != nil {
+		logger.Warn().Err(markErr).Int64("outbox_id", id).Msg("Failed to update outbox")
+	}
+}
+
+// unreachable reports whether a send failed because the bot can no longer
+// post to the chat: it was blocked, removed, or the chat no longer exists.
+func unreachable(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == http.StatusForbidden ||
+		apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "chat not found")
+}
+
+// disableTarget stops delivery to a chat the bot can no longer post to. A
+// bound channel is unbound, so its chat gets its notifications again.
+func (n *Notifier) disableTarget(m storage.OutboxMessage) {
+	if err := n.store.SetChatActive(m.TargetID, false); err != nil {
+		logger.Error().Err(err).Int64("chat_id", m.TargetID).Msg("Failed to deactivate chat")
+		return
+	}
+	if m.TargetID != m.ChatID {
+		if _, err := n.store.UnbindChannel(m.TargetID); err != nil {
+			logger.Error().Err(err).Int64("channel_id", m.TargetID).Msg("Failed to unbind channel")
+		}
+	}
+	logger.Info().Int64("chat_id", m.TargetID).Msg("Chat unreachable, delivery disabled")
+}
+
+// sendPhoto sends a message with its photo, unless the text is too long for
+// a caption.
+func (n *Notifier) sendPhoto(ctx context.Context, m storage.OutboxMessage, markup interface{}) error {
+	if len(utf16.Encode([]rune(m.Text))) > maxCaptionLength {
+		return errors.New("text too long for a caption")
+	}
+	photo := tgbotapi.NewPhoto(m.TargetID, tgbotapi.FileURL(m.Photo))
+	photo.Caption = m.Text
+	photo.ParseMode = m.ParseMode
+	photo.DisableNotification = m.Silent
+	if markup != nil {
+		photo.ReplyMarkup = markup
+	}
+	_, err := n.sender.Send(ctx, m.TargetID, photo)
+	return err
+}
@@ -0,0 +1,59 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/storage"
+)
+
+// Preview sends a chat the notification of an event as its subscription to
+// the repository would, with the chat's language, parse mode and photos and
+// the subscription's templates and asset selection. It is sent to the chat
+// only, not to its channel or route, and without buttons, and nothing is
+// recorded. It reports whether the subscription would have notified the
+// event, false without one.
+func (n *Notifier) Preview(ctx context.Context, chatID int64, event *github.WebhookEvent) (bool, error) {
+	sub, err := n.store.GetSubscription(chatID, event.RepoOwner, event.RepoName)
+	if err != nil {
+		return false, err
+	}
+	chat, err := n.store.GetChat(chatID)
+	if err != nil {
+		return false, err
+	}
+	lang := chatLanguage(chat)
+
+	text := n.buildMessage(event, lang)
+	wanted := false
+	if sub != nil {
+		wanted = !sub.IsMuted(time.Now()) &&
+			n.isEventEnabled(*sub, storage.EventType(event.Type)) &&
+			n.matchesFilters(*sub, event)
+		if release, ok := event.Payload.(*github.ReleaseEvent); ok {
+			if listed, ok := n.releaseForSubscription(*sub, release); ok {
+				text = n.msgBuilder.BuildReleaseMessage(event.RepoOwner, event.RepoName, listed, lang)
+			}
+		}
+		if custom := n.customMessage(*sub, event); custom != "" {
+			text = custom
+		}
+	}
+
+	m := storage.OutboxMessage{
+		ChatID:    chatID,
+		TargetID:  chatID,
+		Text:      text,
+		ParseMode: tgbotapi.ModeMarkdownV2,
+	}
+	if n.chatParseMode(chatID) == storage.ParseModeHTML {
+		m.Text, m.ParseMode = markdown.ToHTML(text), tgbotapi.ModeHTML
+	}
+	if chat != nil && chat.Photos {
+		m.Photo = github.PreviewImageURL(event.RepoOwner, event.RepoName, event.Payload)
+	}
+	return wanted, n.deliver(ctx, m)
+}
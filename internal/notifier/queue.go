@@ -0,0 +1,205 @@
+package notifier
+
+import (
+	"context"
+	"time"
+
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// queueFlushInterval is how often queued events are checked for delivery.
+const queueFlushInterval = time.Minute
+
+// SetDigestHour configures the hour of day (0-23) digests are sent.
+func (n *Notifier) SetDigestHour(hour int) {
+	if hour < 0 || hour > 23 {
+		logger.Warn().Int("hour", hour).Msg("Invalid digest hour, using 9")
+		hour = 9
+	}
+	n.digestHour = hour
+}
+
+// Start begins delivering queued events, and notifications left pending when
+// the bot last stopped, in the background.
+func (n *Notifier) Start() {
+	n.lastDigest = time.Now()
+	if n.settings != nil {
+		last, err := n.settings.GetTime(storage.SettingLastDigest, n.lastDigest)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to load when digests were last sent")
+		}
+		n.lastDigest = last
+	}
+	n.wg.Add(2)
+	go n.runQueue()
+	go n.resumeOutbox(n.resumeID)
+}
+
+// Stop stops the background delivery of queued events after sending the
+// batched ones. Notifications still waiting in the send queue, or for a
+// release asset, stay in the outbox for the next start.
+func (n *Notifier) Stop() {
+	n.flushBatches()
+	n.cancel()
+	n.wg.Wait()
+}
+
+// runQueue periodically delivers queued events: those of chats that left
+// their quiet hours, and digests once they are due.
+func (n *Notifier) runQueue() {
+	defer n.wg.Done()
+	defer logger.ReportPanic()
+
+	ticker := time.NewTicker(queueFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			n.flushQuietHours(now)
+			n.flushDigests(now)
+			if _, err := n.store.CleanupOutbox(outboxRetention); err != nil {
+				logger.Warn().Err(err).Msg("Failed to clean up the outbox")
+			}
+		}
+	}
+}
+
+// quietHours returns the quiet hours of a chat, if it has any configured.
+func (n *Notifier) quietHours(chat *storage.Chat) (storage.QuietHours, bool) {
+	if chat == nil || chat.QuietHours == "" {
+		return storage.QuietHours{}, false
+	}
+	quiet, err := storage.ParseQuietHours(chat.QuietHours)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chat.ChatID).Msg("Invalid quiet hours")
+		return storage.QuietHours{}, false
+	}
+	return quiet, true
+}
+
+// queueEvent stores a summary of an event for later delivery and reports
+// whether it did so.
+func (n *Notifier) queueEvent(chatID int64, event *github.WebhookEvent, reason string, lang i18n.Lang) bool {
+	summary := github.Summarize(event.Payload, lang)
+	if err := n.store.QueueEvent(chatID, event.RepoOwner, event.RepoName, event.Type, summary, reason); err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Str("reason", reason).Msg("Failed to queue event, sending now")
+		return false
+	}
+	return true
+}
+
+// queueIfQuiet stores an event for later delivery when the chat is inside its
+// quiet hours, and reports whether it did so.
+func (n *Notifier) queueIfQuiet(chat *storage.Chat, event *github.WebhookEvent, now time.Time, lang i18n.Lang) bool {
+	quiet, ok := n.quietHours(chat)
+	if !ok || !quiet.Contains(now.In(chat.Location())) {
+		return false
+	}
+	return n.queueEvent(chat.ChatID, event, storage.QueueReasonQuietHours, lang)
+}
+
+// flushQuietHours sends a summary of the events queued for each chat whose
+// quiet hours have ended.
+func (n *Notifier) flushQuietHours(now time.Time) {
+	n.flushQueue(storage.QueueReasonQuietHours, "summary.quiet_title", "15:04", func(chat *storage.Chat) bool {
+		quiet, ok := n.quietHours(chat)
+		return !ok || !quiet.Contains(now.In(chat.Location()))
+	})
+}
+
+// flushDigests sends the daily digests of each chat once the digest hour has
+// passed in the chat's time zone, and the weekly digests once it passed on a
+// Monday, including those that came due while the bot was down.
+func (n *Notifier) flushDigests(now time.Time) {
+	prev := n.lastDigest
+	n.lastDigest = now
+
+	n.flushQueue(storage.QueueReasonDailyDigest, "summary.daily_title", "15:04", func(chat *storage.Chat) bool {
+		return n.lastDigestTime(now, chat.Location()).After(prev)
+	})
+	n.flushQueue(storage.QueueReasonWeeklyDigest, "summary.weekly_title", "Mon 15:04", func(chat *storage.Chat) bool {
+		due := n.lastDigestTime(now, chat.Location())
+		for due.Weekday() != time.Monday {
+			due = due.AddDate(0, 0, -1)
+		}
+		return due.After(prev)
+	})
+
+	if n.settings != nil {
+		if err := n.settings.SetTime(storage.SettingLastDigest, now); err != nil {
+			logger.Warn().Err(err).Msg("Failed to save when digests were last sent")
+		}
+	}
+}
+
+// lastDigestTime returns the most recent digest hour at or before now in loc.
+func (n *Notifier) lastDigestTime(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	due := time.Date(local.Year(), local.Month(), local.Day(), n.digestHour, 0, 0, 0, loc)
+	if local.Before(due) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return due
+}
+
+// flushQueue sends a summary of the events queued for a reason to every chat
+// for which ready reports true, then removes them from the queue. Event times
+// are shown in the chat's time zone using layout, and titleKey names the
+// catalog entry of the summary title.
+func (n *Notifier) flushQueue(reason, titleKey, layout string, ready func(chat *storage.Chat) bool) {
+	chatIDs, err := n.store.GetQueuedChats(reason)
+	if err != nil {
+		logger.Error().Err(err).Str("reason", reason).Msg("Failed to get chats with queued events")
+		return
+	}
+
+	for _, chatID := range chatIDs {
+		chat, err := n.store.GetChat(chatID)
+		if err != nil {
+			logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+			continue
+		}
+		if !ready(chat) {
+			continue
+		}
+
+		events, err := n.store.GetQueuedEvents(chatID, reason)
+		if err != nil || len(events) == 0 {
+			continue
+		}
+		lastID := events[len(events)-1].ID
+
+		// Drop the backlog of chats that left or paused in the meantime
+		if chat == nil || !chat.Active || chat.Paused {
+			if err := n.store.DeleteQueuedEvents(chatID, reason, lastID); err != nil {
+				logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to delete queued events")
+			}
+			continue
+		}
+
+		lang := chatLanguage(chat)
+		title := i18n.T(lang, titleKey, len(events))
+		sent := true
+		for _, message := range n.msgBuilder.BuildQueuedSummary(title, events, chat.Location(), layout, lang) {
+			if err := n.sendNotification(context.Background(), chatID, message); err != nil {
+				logger.Error().Err(err).Int64("chat_id", chatID).Str("reason", reason).Msg("Failed to send queued summary")
+				sent = false
+				break
+			}
+		}
+		if !sent {
+			continue // Retry on the next tick
+		}
+
+		if err := n.store.DeleteQueuedEvents(chatID, reason, lastID); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to delete queued events")
+		}
+	}
+}
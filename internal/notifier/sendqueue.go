@@ -0,0 +1,288 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/pkg/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Telegram rejects bots sending more than about 30 messages per second
+// overall, one per second to a private chat or 20 per minute to a group or
+// channel. All notifications go through a send queue pacing them below these
+// limits.
+const (
+	globalSendInterval  = time.Second / 30
+	privateSendInterval = time.Second
+	groupSendInterval   = 3 * time.Second
+)
+
+// Sends failing with a transient error, like flood control or a server error,
+// are retried with exponential backoff, or after the delay Telegram asks for.
+const (
+	maxSendAttempts = 5
+	sendRetryBase   = time.Second
+	sendRetryMax    = time.Minute
+	maxRetryAfter   = 10 * time.Minute // Longer flood waits give up instead
+)
+
+// errSendQueueStopped is returned for messages that were not sent before the
+// notifier stopped.
+var errSendQueueStopped = errors.New("send queue stopped")
+
+// sendQueue delivers messages one at a time, taking turns between chats so
+// that a burst to one chat does not hold back the others.
+type sendQueue struct {
+	bot  *tgbotapi.BotAPI
+	wake chan struct{}
+
+	mu      sync.Mutex
+	chats   map[int64]*chatSends
+	ready   []int64 // Chats with pending messages, in the order they take turns
+	stopped bool
+}
+
+// chatSends holds the messages waiting for one chat.
+type chatSends struct {
+	pending []*pendingSend
+	next    time.Time // Earliest time the chat may receive another message
+}
+
+// pendingSend is a queued message and where to report its outcome.
+type pendingSend struct {
+	ctx      context.Context // Trace the send belongs to
+	msg      tgbotapi.Chattable
+	result   chan sendResult
+	attempts int // Failed attempts so far
+}
+
+type sendResult struct {
+	msg tgbotapi.Message
+	err error
+}
+
+func newSendQueue(bot *tgbotapi.BotAPI) *sendQueue {
+	return &sendQueue{
+		bot:   bot,
+		wake:  make(chan struct{}, 1),
+		chats: make(map[int64]*chatSends),
+	}
+}
+
+// Send queues a message to a chat and waits until it was sent. ctx carries
+// the trace the send is recorded in.
+func (q *sendQueue) Send(ctx context.Context, chatID int64, msg tgbotapi.Chattable) (tgbotapi.Message, error) {
+	p := &pendingSend{ctx: ctx, msg: msg, result: make(chan sendResult, 1)}
+
+	q.mu.Lock()
+	if q.stopped {
+		q.mu.Unlock()
+		return tgbotapi.Message{}, errSendQueueStopped
+	}
+	c := q.chats[chatID]
+	if c == nil {
+		c = &chatSends{}
+		q.chats[chatID] = c
+	}
+	if len(c.pending) == 0 {
+		q.ready = append(q.ready, chatID)
+	}
+	c.pending = append(c.pending, p)
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	r := <-p.result
+	return r.msg, r.err
+}
+
+// run sends queued messages until ctx is done, then fails those left.
+func (q *sendQueue) run(ctx context.Context) {
+	defer q.stop()
+
+	var last time.Time
+	for {
+		if wait := globalSendInterval - time.Since(last); wait > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+		}
+
+		p, chatID, wait := q.next(time.Now())
+		if p == nil {
+			var timeout <-chan time.Time
+			if wait > 0 {
+				timeout = time.After(wait)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			case <-timeout:
+			}
+			continue
+		}
+
+		_, span := tracer.Start(p.ctx, "telegram.send", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.Int64("telegram.chat_id", chatID),
+			attribute.Int("telegram.attempt", p.attempts+1),
+		))
+		msg, err := q.bot.Send(p.msg)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "send failed")
+		}
+		span.End()
+		last = time.Now()
+		if err != nil {
+			p.attempts++
+			if delay, ok := retryDelay(err, p.attempts); ok {
+				logger.Warn().
+					Err(err).
+					Int64("chat_id", chatID).
+					Int("attempt", p.attempts).
+					Dur("retry_in", delay).
+					Msg("Failed to send message, retrying")
+				q.retry(chatID, p, last.Add(delay))
+				continue
+			}
+		}
+		p.result <- sendResult{msg: msg, err: err}
+	}
+}
+
+// next takes the message of the first chat in turn that may receive one now.
+// Otherwise it returns how long until a chat may, or 0 if none is waiting.
+func (q *sendQueue) next(now time.Time) (*pendingSend, int64, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var wait time.Duration
+	for i, chatID := range q.ready {
+		c := q.chats[chatID]
+		if until := c.next.Sub(now); until > 0 {
+			if wait == 0 || until < wait {
+				wait = until
+			}
+			continue
+		}
+
+		p := c.pending[0]
+		c.pending = c.pending[1:]
+		c.next = now.Add(chatSendInterval(chatID))
+		q.ready = append(q.ready[:i], q.ready[i+1:]...)
+		if len(c.pending) > 0 {
+			q.ready = append(q.ready, chatID)
+		}
+		q.forgetIdle(now)
+		return p, chatID, 0
+	}
+	return nil, 0, wait
+}
+
+// retry puts a message back at the front of its chat's queue, holding the
+// chat until the given time.
+func (q *sendQueue) retry(chatID int64, p *pendingSend, at time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		p.result <- sendResult{err: errSendQueueStopped}
+		return
+	}
+	c := q.chats[chatID]
+	if c == nil {
+		c = &chatSends{}
+		q.chats[chatID] = c
+	}
+	if len(c.pending) == 0 {
+		q.ready = append(q.ready, chatID)
+	}
+	c.pending = append([]*pendingSend{p}, c.pending...)
+	if at.After(c.next) {
+		c.next = at
+	}
+}
+
+// forgetIdle drops chats without pending messages whose interval has passed.
+func (q *sendQueue) forgetIdle(now time.Time) {
+	for chatID, c := range q.chats {
+		if len(c.pending) == 0 && !c.next.After(now) {
+			delete(q.chats, chatID)
+		}
+	}
+}
+
+// depth returns how many messages wait to be sent.
+func (q *sendQueue) depth() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := 0
+	for _, c := range q.chats {
+		n += len(c.pending)
+	}
+	return n
+}
+
+// stop fails the messages still queued and rejects new ones.
+func (q *sendQueue) stop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.stopped = true
+	for _, chatID := range q.ready {
+		for _, p := range q.chats[chatID].pending {
+			p.result <- sendResult{err: errSendQueueStopped}
+		}
+	}
+	q.ready = nil
+	q.chats = make(map[int64]*chatSends)
+}
+
+// retryDelay returns how long to wait before sending again after an error,
+// or false if the error is permanent or the attempts are used up.
+func retryDelay(err error, attempts int) (time.Duration, bool) {
+	if attempts >= maxSendAttempts || errors.Is(err, errSendQueueStopped) {
+		return 0, false
+	}
+
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.RetryAfter > 0:
+			delay := time.Duration(apiErr.RetryAfter) * time.Second
+			return delay, delay <= maxRetryAfter
+		case apiErr.Code != http.StatusTooManyRequests && apiErr.Code < http.StatusInternalServerError:
+			return 0, false
+		}
+	}
+
+	// Flood control without a delay, server and network errors
+	delay := sendRetryBase << (attempts - 1)
+	if delay > sendRetryMax {
+		delay = sendRetryMax
+	}
+	return delay, true
+}
+
+// chatSendInterval returns the minimum time between messages to a chat.
+// Groups and channels have negative IDs.
+func chatSendInterval(chatID int64) time.Duration {
+	if chatID < 0 {
+		return groupSendInterval
+	}
+	return privateSendInterval
+}
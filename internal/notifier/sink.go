@@ -0,0 +1,37 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/user/githubbot/internal/sink"
+	"github.com/user/githubbot/internal/storage"
+)
+
+// SetSink sets the sink that subscriptions routed to its kind of destination
+// are delivered with.
+func (n *Notifier) SetSink(kind string, s sink.Sink) {
+	n.sinks[kind] = s
+}
+
+// deliverToSink sends an outbox message to the sink it is routed to and
+// records the outcome.
+func (n *Notifier) deliverToSink(ctx context.Context, m storage.OutboxMessage) error {
+	kind, target, ok := sink.ParseRoute(m.Sink)
+	s := n.sinks[kind]
+
+	var err error
+	switch {
+	case !ok:
+		err = fmt.Errorf("invalid route %q", m.Sink)
+	case s == nil:
+		err = fmt.Errorf("no %s sink is configured", kind)
+	default:
+		err = s.Send(ctx, target, sink.Message{Text: m.Text, Photo: m.Photo, Silent: m.Silent})
+	}
+
+	if m.ID != 0 {
+		n.markOutbox(m.ID, err)
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+// Package server provides HTTP listener setup for graceful deploys.
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// systemdFirstFD is the first file descriptor passed by systemd socket activation.
+const systemdFirstFD = 3
+
+// ListenFDEnv names the environment variable holding an inherited listener FD.
+const ListenFDEnv = "GHBOT_LISTEN_FD"
+
+// Listen returns the listener the HTTP server should serve on. An inherited
+// listener (GHBOT_LISTEN_FD or systemd socket activation) is preferred; otherwise
+// a new socket is bound to addr, with SO_REUSEPORT set when reusePort is true so
+// a new instance can bind alongside the old one during a deploy.
+func Listen(addr string, reusePort bool) (net.Listener, string, error) {
+	ln, source, err := inheritedListener()
+	if err != nil {
+		return nil, "", err
+	}
+	if ln != nil {
+		return ln, source, nil
+	}
+
+	if !reusePort {
+		ln, err := net.Listen("tcp", addr)
+		return ln, "bind", err
+	}
+
+	if !reusePortSupported {
+		return nil, "", fmt.Errorf("SO_REUSEPORT is not supported on this platform")
+	}
+
+	lc := net.ListenConfig{Control: setReusePort}
+	ln, err = lc.Listen(context.Background(), "tcp", addr)
+	return ln, "reuseport", err
+}
+
+// inheritedListener returns a listener passed in by the parent process, if any.
+func inheritedListener() (net.Listener, string, error) {
+	if value := os.Getenv(ListenFDEnv); value != "" {
+		fd, err := strconv.Atoi(value)
+		if err != nil || fd < systemdFirstFD {
+			return nil, "", fmt.Errorf("invalid %s: %q", ListenFDEnv, value)
+		}
+		ln, err := fileListener(fd)
+		return ln, "inherited", err
+	}
+
+	// systemd socket activation: LISTEN_PID must match us
+	if pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID")); pid == os.Getpid() {
+		if n, _ := strconv.Atoi(os.Getenv("LISTEN_FDS")); n > 0 {
+			ln, err := fileListener(systemdFirstFD)
+			return ln, "systemd", err
+		}
+	}
+
+	return nil, "", nil
+}
+
+// fileListener wraps an inherited file descriptor in a net.Listener.
+func fileListener(fd int) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), "listener")
+	if f == nil {
+		return nil, fmt.Errorf("invalid listener fd %d", fd)
+	}
+	defer f.Close()
+
+	ln, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use inherited listener: %w", err)
+	}
+	return ln, nil
+}
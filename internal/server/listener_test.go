@@ -0,0 +1,178 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serve serves on ln, answering with name so tests can tell instances apart.
+// Requests to /slow block until release is closed. It returns the server,
+// which is shut down when the test ends if it still runs.
+func serve(t *testing.T, ln net.Listener, name string, started chan<- struct{}, release <-chan struct{}) *http.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		fmt.Fprint(w, name)
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return srv
+}
+
+// get requests url on a new connection and returns the body.
+func get(url string) (string, error) {
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %s", resp.Status)
+	}
+	return string(body), err
+}
+
+func TestListenHandoff(t *testing.T) {
+	if !reusePortSupported {
+		t.Skip("SO_REUSEPORT is not supported on this platform")
+	}
+
+	oldLn, source, err := Listen("127.0.0.1:0", true)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	if source != "reuseport" {
+		t.Errorf("Listen() source = %q, want reuseport", source)
+	}
+	addr := oldLn.Addr().String()
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	oldSrv := serve(t, oldLn, "old", started, release)
+
+	// A request is in flight on the old instance when the new one starts
+	inFlight := make(chan string, 1)
+	go func() {
+		body, err := get("http://" + addr + "/slow")
+		if err != nil {
+			body = err.Error()
+		}
+		inFlight <- body
+	}()
+	<-started
+
+	newLn, _, err := Listen(addr, true)
+	if err != nil {
+		t.Fatalf("Listen() of the new instance error = %v while the old one listens", err)
+	}
+	serve(t, newLn, "new", make(chan struct{}, 1), nil)
+
+	// Both listen during the overlap
+	served := map[string]bool{}
+	for i := 0; i < 50 && len(served) < 2; i++ {
+		body, err := get("http://" + addr + "/")
+		if err != nil {
+			t.Fatalf("request during the overlap error = %v", err)
+		}
+		served[body] = true
+	}
+	if !served["new"] {
+		t.Errorf("new instance served no requests during the overlap, got %v", served)
+	}
+
+	// The old instance drains: it stops accepting but finishes the request
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- oldSrv.Shutdown(ctx)
+	}()
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown() returned %v with a request in flight", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	if body := <-inFlight; body != "old" {
+		t.Errorf("in-flight request got %q, want it completed by the old instance", body)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("Shutdown() error = %v", err)
+	}
+
+	// The new instance takes every request from now on
+	for i := 0; i < 10; i++ {
+		if body, err := get("http://" + addr + "/"); err != nil || body != "new" {
+			t.Fatalf("request after the handoff = %q, %v, want the new instance", body, err)
+		}
+	}
+}
+
+func TestListenWithoutReusePort(t *testing.T) {
+	ln, source, err := Listen("127.0.0.1:0", false)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+	if source != "bind" {
+		t.Errorf("Listen() source = %q, want bind", source)
+	}
+
+	// A second instance cannot bind until the first one is gone
+	if second, _, err := Listen(ln.Addr().String(), false); err == nil {
+		second.Close()
+		t.Error("Listen() bound an address in use without SO_REUSEPORT")
+	}
+}
+
+func TestListenInherited(t *testing.T) {
+	parent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer parent.Close()
+	f, err := parent.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("File() error = %v", err)
+	}
+	t.Setenv(ListenFDEnv, strconv.Itoa(int(f.Fd())))
+
+	// The address is ignored in favour of the inherited socket
+	ln, source, err := Listen("127.0.0.1:1", false)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+	if source != "inherited" || ln.Addr().String() != parent.Addr().String() {
+		t.Errorf("Listen() = %v, %q, want the inherited listener on %v", ln.Addr(), source, parent.Addr())
+	}
+
+	// The child keeps serving once the parent closed its copy of the socket
+	parent.Close()
+	serve(t, ln, "child", nil, nil)
+	if body, err := get("http://" + ln.Addr().String() + "/"); err != nil || body != "child" {
+		t.Errorf("request = %q, %v, want the child to serve it", body, err)
+	}
+}
+
+func TestListenInvalidInherited(t *testing.T) {
+	for _, value := range []string{"abc", "0", "2"} {
+		t.Setenv(ListenFDEnv, value)
+		if ln, _, err := Listen("127.0.0.1:0", false); err == nil {
+			ln.Close()
+			t.Errorf("Listen() accepted %s=%q", ListenFDEnv, value)
+		}
+	}
+}
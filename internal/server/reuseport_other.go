@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package server
+
+import "syscall"
+
+const reusePortSupported = false
+
+// setReusePort is a no-op on platforms without SO_REUSEPORT.
+func setReusePort(network, address string, c syscall.RawConn) error {
+	return nil
+}
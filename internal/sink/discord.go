@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// KindDiscord is the kind of the Discord sink in routes.
+const KindDiscord = "discord"
+
+// maxDiscordText is the longest embed description Discord accepts, in
+// characters.
+const maxDiscordText = 4096
+
+// discordSuppressNotifications is the message flag that skips the
+// notification sound.
+const discordSuppressNotifications = 1 << 12
+
+// Discord posts notifications to Discord channels through their webhooks.
+type Discord struct {
+	webhooks map[string]string // Webhook URLs by name
+	http     *http.Client
+}
+
+// NewDiscord creates a Discord sink posting to the given webhook URLs, keyed
+// by the name subscriptions are routed with.
+func NewDiscord(webhooks map[string]string) *Discord {
+	return &Discord{
+		webhooks: webhooks,
+		http:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Targets implements Sink.
+func (d *Discord) Targets() []string {
+	return slices.Sorted(maps.Keys(d.webhooks))
+}
+
+// discordEmbed is the part of a Discord embed notifications use.
+type discordEmbed struct {
+	Description string        `json:"description"`
+	Image       *discordImage `json:"image,omitempty"`
+}
+
+type discordImage struct {
+	URL string `json:"url"`
+}
+
+// Send implements Sink. The message is posted as the description of an
+// embed, which fits longer texts than a plain message, with mentions
+// disabled so that GitHub content cannot ping the channel.
+func (d *Discord) Send(ctx context.Context, target string, m Message) error {
+	webhook, ok := d.webhooks[target]
+	if !ok {
+		return fmt.Errorf("unknown discord webhook %q", target)
+	}
+
+	embed := discordEmbed{Description: markdown.Truncate(markdown.ToDiscord(m.Text), maxDiscordText)}
+	if m.Photo != "" {
+		embed.Image = &discordImage{URL: m.Photo}
+	}
+	payload := map[string]interface{}{
+		"embeds":           []discordEmbed{embed},
+		"allowed_mentions": map[string][]string{"parse": {}},
+	}
+	if m.Silent {
+		payload["flags"] = discordSuppressNotifications
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode discord message: %w", err)
+	}
+
+	if err := postJSON(ctx, d.http, webhook, body); err != nil {
+		return fmt.Errorf("discord webhook %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// KindMatrix is the kind of the Matrix sink in routes.
+const KindMatrix = "matrix"
+
+// Matrix posts notifications to Matrix rooms with the account of an access
+// token, through the client-server API.
+type Matrix struct {
+	homeserver string
+	token      string
+	rooms      map[string]string // Room IDs by name
+	http       *http.Client
+
+	txnPrefix string       // Makes transaction IDs unique across restarts
+	txnCount  atomic.Int64 // Makes transaction IDs unique within this run
+}
+
+// NewMatrix creates a Matrix sink posting with the access token of an
+// account on homeserver to the given rooms, keyed by the name subscriptions
+// are routed with. The account must have joined the rooms.
+func NewMatrix(homeserver, token string, rooms map[string]string) *Matrix {
+	return &Matrix{
+		homeserver: strings.TrimSuffix(homeserver, "/"),
+		token:      token,
+		rooms:      rooms,
+		http:       &http.Client{Timeout: 15 * time.Second},
+		txnPrefix:  fmt.Sprintf("githubbot-%d", time.Now().UnixNano()),
+	}
+}
+
+// Targets implements Sink.
+func (mx *Matrix) Targets() []string {
+	return slices.Sorted(maps.Keys(mx.rooms))
+}
+
+// Send implements Sink. The message is sent as HTML with a plain text
+// fallback. Silent messages are sent as notices, which clients usually do
+// not notify about. Photos are not uploaded to the homeserver and are left
+// out.
+func (mx *Matrix) Send(ctx context.Context, target string, m Message) error {
+	room, ok := mx.rooms[target]
+	if !ok {
+		return fmt.Errorf("unknown matrix room %q", target)
+	}
+
+	msgtype := "m.text"
+	if m.Silent {
+		msgtype = "m.notice"
+	}
+	body, err := json.Marshal(map[string]string{
+		"msgtype":        msgtype,
+		"body":           markdown.ToPlain(m.Text),
+		"format":         "org.matrix.custom.html",
+		"formatted_body": strings.ReplaceAll(markdown.ToHTML(m.Text), "\n", "<br>"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode matrix message: %w", err)
+	}
+
+	// Retries reuse the transaction ID, so the homeserver sends the message once
+	txnID := fmt.Sprintf("%s-%d", mx.txnPrefix, mx.txnCount.Add(1))
+	endpoint := mx.homeserver + "/_matrix/client/v3/rooms/" + url.PathEscape(room) +
+		"/send/m.room.message/" + url.PathEscape(txnID)
+	header := http.Header{"Authorization": {"Bearer " + mx.token}}
+	if err := sendJSON(ctx, mx.http, http.MethodPut, endpoint, header, body); err != nil {
+		return fmt.Errorf("matrix room %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Package sink delivers notifications to services other than Telegram, so
+// that subscriptions can be routed to, for example, a Discord or Slack
+// channel or a Matrix room.
+//
+// A subscription's route names a sink and one of its configured
+// destinations, like "discord:releases". Notifications keep going through
+// the notifier's pipeline and outbox, only the final delivery differs.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Services rate limit their webhooks. Requests they reject with 429, or that
+// fail with a server error, are retried after the delay they ask for.
+const (
+	maxPostAttempts = 3
+	maxPostWait     = time.Minute // Longer waits give up instead
+)
+
+// Message is a notification to deliver.
+type Message struct {
+	Text   string // Written in Telegram MarkdownV2, as produced by the markdown package
+	Photo  string // Image to attach, empty for none
+	Silent bool   // Deliver without a notification sound, where supported
+}
+
+// Sink delivers notifications to the destinations of one service.
+type Sink interface {
+	// Targets returns the names of the configured destinations, sorted.
+	Targets() []string
+	// Send delivers a message to the destination with the given name.
+	Send(ctx context.Context, target string, m Message) error
+}
+
+// Route returns the route of a subscription to a destination of a sink.
+func Route(kind, target string) string {
+	return kind + ":" + target
+}
+
+// ParseRoute splits a route into the kind of its sink and its destination.
+func ParseRoute(route string) (kind, target string, ok bool) {
+	kind, target, ok = strings.Cut(route, ":")
+	return kind, target, ok && kind != "" && target != ""
+}
+
+// postJSON posts a JSON payload to a webhook, retrying as described above.
+func postJSON(ctx context.Context, client *http.Client, url string, body []byte) error {
+	return sendJSON(ctx, client, http.MethodPost, url, nil, body)
+}
+
+// sendJSON sends a JSON payload with the given method and extra headers,
+// retrying as described above.
+func sendJSON(ctx context.Context, client *http.Client, method, url string, header http.Header, body []byte) error {
+	for attempt := 1; ; attempt++ {
+		wait, err := send(ctx, client, method, url, header, body)
+		if wait == 0 || attempt == maxPostAttempts || wait > maxPostWait {
+			return err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// send sends a payload once. A failure that is worth retrying returns how
+// long to wait first, from the Retry-After header or the retry_after or
+// retry_after_ms field of the reply.
+func send(ctx context.Context, client *http.Client, method, url string, header http.Header, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Second, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		return 0, nil
+	}
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(reply))
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if seconds, parseErr := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); parseErr == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second)), err
+		}
+		var limit struct {
+			RetryAfter   float64 `json:"retry_after"`
+			RetryAfterMs int64   `json:"retry_after_ms"`
+		}
+		if json.Unmarshal(reply, &limit) == nil {
+			switch {
+			case limit.RetryAfter > 0:
+				return time.Duration(limit.RetryAfter * float64(time.Second)), err
+			case limit.RetryAfterMs > 0:
+				return time.Duration(limit.RetryAfterMs) * time.Millisecond, err
+			}
+		}
+		return time.Second, err
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return time.Second, err
+	}
+	return 0, err
+}
@@ -0,0 +1,81 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// KindSlack is the kind of the Slack sink in routes.
+const KindSlack = "slack"
+
+// maxSlackText is the longest text of a Slack section block, in characters.
+const maxSlackText = 3000
+
+// Slack posts notifications to Slack channels through incoming webhooks.
+type Slack struct {
+	webhooks map[string]string // Incoming webhook URLs by name
+	http     *http.Client
+}
+
+// NewSlack creates a Slack sink posting to the given incoming webhook URLs,
+// keyed by the name subscriptions are routed with.
+func NewSlack(webhooks map[string]string) *Slack {
+	return &Slack{
+		webhooks: webhooks,
+		http:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Targets implements Sink.
+func (s *Slack) Targets() []string {
+	return slices.Sorted(maps.Keys(s.webhooks))
+}
+
+// slackBlock is the part of a Slack Block Kit block notifications use.
+type slackBlock struct {
+	Type     string     `json:"type"`
+	Text     *slackText `json:"text,omitempty"`
+	ImageURL string     `json:"image_url,omitempty"`
+	AltText  string     `json:"alt_text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Send implements Sink. The message is posted as a section block in mrkdwn,
+// followed by an image block for its photo. The text is repeated as the
+// fallback shown in notifications. Slack has no silent messages, m.Silent is
+// ignored.
+func (s *Slack) Send(ctx context.Context, target string, m Message) error {
+	webhook, ok := s.webhooks[target]
+	if !ok {
+		return fmt.Errorf("unknown slack webhook %q", target)
+	}
+
+	text := markdown.Truncate(markdown.ToSlack(m.Text), maxSlackText)
+	blocks := []slackBlock{{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}}
+	if m.Photo != "" {
+		blocks = append(blocks, slackBlock{Type: "image", ImageURL: m.Photo, AltText: "preview"})
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"text":   text,
+		"blocks": blocks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+
+	if err := postJSON(ctx, s.http, webhook, body); err != nil {
+		return fmt.Errorf("slack webhook %s: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// The command audit log keeps every command and button press received, so
+// operators of shared instances can find out who did what, e.g. after abuse
+// or an accidental unsubscribe.
+
+// Outcomes of audited commands.
+const (
+	AuditHandled = "handled" // Passed on to the command's handler
+	AuditDenied  = "denied"  // Refused by the access control
+	AuditUnknown = "unknown" // Not a known command
+)
+
+// AuditEntry is a command received.
+type AuditEntry struct {
+	ID        int64     `db:"id"`
+	ChatID    int64     `db:"chat_id"`
+	UserID    int64     `db:"user_id"`
+	Username  string    `db:"username"`
+	Command   string    `db:"command"` // Without the slash; button presses as "button:<action>"
+	Args      string    `db:"args"`
+	Outcome   string    `db:"outcome"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AuditFilter selects audit entries. Zero fields match everything.
+type AuditFilter struct {
+	ChatID  int64
+	UserID  int64
+	Command string
+}
+
+// RecordAudit adds a command to the audit log.
+func (s *SubscriptionStore) RecordAudit(entry *AuditEntry) error {
+	query := `
+		INSERT INTO command_audit (chat_id, user_id, username, command, args, outcome)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, entry.ChatID, entry.UserID, entry.Username, entry.Command, entry.Args, entry.Outcome)
+	return err
+}
+
+// GetAuditLog returns the latest audit entries matching a filter, newest
+// first.
+func (s *SubscriptionStore) GetAuditLog(filter AuditFilter, limit int) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.ChatID != 0 {
+		conditions = append(conditions, "chat_id = ?")
+		args = append(args, filter.ChatID)
+	}
+	if filter.UserID != 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.Command != "" {
+		conditions = append(conditions, "command = ? COLLATE NOCASE")
+		args = append(args, filter.Command)
+	}
+
+	query := `SELECT * FROM command_audit`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	var entries []AuditEntry
+	err := s.db.Select(&entries, query, args...)
+	return entries, err
+}
+
+// CleanupAuditLog removes audit entries older than the given number of days.
+func (s *SubscriptionStore) CleanupAuditLog(daysToKeep int) (int64, error) {
+	query := `DELETE FROM command_audit WHERE created_at < datetime('now', '-' || ? || ' days')`
+	result, err := s.db.Exec(query, daysToKeep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
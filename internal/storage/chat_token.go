@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Chats can set a GitHub token of their own with /settoken. It is used for
+// the private repositories the chat subscribes to, and its quota is tracked
+// apart from the configured tokens and from the other chats' tokens.
+
+// ChatToken is the GitHub token a chat set.
+type ChatToken struct {
+	ChatID    int64     `db:"chat_id"`
+	Login     string    `db:"login"` // GitHub account the token belongs to
+	Token     string    `db:"token"` // Encrypted, see GetChatGitHubToken
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// SaveChatToken sets the GitHub token of a chat, replacing the one set
+// before. The token is encrypted with the store's cipher.
+func (s *SubscriptionStore) SaveChatToken(chatID int64, login, token string) error {
+	encrypted, err := s.encrypt(token)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO chat_tokens (chat_id, login, token)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_id) DO UPDATE SET
+			login = excluded.login,
+			token = excluded.token,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err = s.db.Exec(query, chatID, login, encrypted)
+	return err
+}
+
+// GetChatToken returns the token a chat set, nil if none.
+func (s *SubscriptionStore) GetChatToken(chatID int64) (*ChatToken, error) {
+	var token ChatToken
+	err := s.db.Get(&token, `SELECT * FROM chat_tokens WHERE chat_id = ?`, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetChatGitHubToken returns the decrypted token a chat set, empty if none.
+func (s *SubscriptionStore) GetChatGitHubToken(chatID int64) (string, error) {
+	token, err := s.GetChatToken(chatID)
+	if err != nil || token == nil {
+		return "", err
+	}
+	return s.decrypt(token.Token)
+}
+
+// DeleteChatToken removes the token of a chat, reporting whether there was
+// one. Repositories read with it fall back to the configured tokens.
+func (s *SubscriptionStore) DeleteChatToken(chatID int64) (bool, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM chat_tokens WHERE chat_id = ?`, chatID)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE repo_state SET credential_chat_id = 0 WHERE credential_chat_id = ?`, chatID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// SetRepoChatCredential sets the chat whose token reads a repository, 0 for
+// the configured tokens. A repository already read with another chat's token
+// or a linked account keeps it, unless chatID is 0.
+func (s *SubscriptionStore) SetRepoChatCredential(repoOwner, repoName string, chatID int64) error {
+	if err := s.EnsureRepoState(repoOwner, repoName); err != nil {
+		return err
+	}
+	query := `
+		UPDATE repo_state SET credential_chat_id = ?
+		WHERE repo_owner = ? AND repo_name = ?
+			AND (? = 0 OR (credential_chat_id = 0 AND credential_user_id = 0))
+	`
+	_, err := s.db.Exec(query, chatID, repoOwner, repoName, chatID)
+	return err
+}
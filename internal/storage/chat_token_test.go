@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChatToken(t *testing.T) {
+	store := newTestStore(t)
+
+	if token, err := store.GetChatGitHubToken(1); err != nil || token != "" {
+		t.Fatalf("GetChatGitHubToken() = %q, %v, want no token", token, err)
+	}
+	if err := store.SaveChatToken(1, "octocat", "ghp_first"); err != nil {
+		t.Fatalf("SaveChatToken() error = %v", err)
+	}
+	if err := store.SaveChatToken(1, "hubot", "ghp_second"); err != nil {
+		t.Fatalf("SaveChatToken() error = %v", err)
+	}
+	if err := store.SaveChatToken(2, "monalisa", "ghp_other"); err != nil {
+		t.Fatalf("SaveChatToken() error = %v", err)
+	}
+
+	chatToken, err := store.GetChatToken(1)
+	if err != nil || chatToken == nil {
+		t.Fatalf("GetChatToken() = %v, %v", chatToken, err)
+	}
+	if chatToken.Login != "hubot" || strings.Contains(chatToken.Token, "ghp_") {
+		t.Errorf("GetChatToken() = %+v, want the replacing login with an encrypted token", chatToken)
+	}
+	for chatID, want := range map[int64]string{1: "ghp_second", 2: "ghp_other"} {
+		if token, err := store.GetChatGitHubToken(chatID); err != nil || token != want {
+			t.Errorf("GetChatGitHubToken(%d) = %q, %v, want %q", chatID, token, err, want)
+		}
+	}
+}
+
+func TestSaveChatTokenWithoutCipher(t *testing.T) {
+	store := NewSubscriptionStore(newTestDatabase(t))
+	if err := store.SaveChatToken(1, "octocat", "ghp_token"); err != ErrNoEncryptionKey {
+		t.Errorf("SaveChatToken() error = %v, want %v", err, ErrNoEncryptionKey)
+	}
+}
+
+func TestRepoChatCredential(t *testing.T) {
+	store := newTestStore(t)
+	for chatID, token := range map[int64]string{1: "ghp_one", 2: "ghp_two"} {
+		if err := store.SaveChatToken(chatID, "octocat", token); err != nil {
+			t.Fatalf("SaveChatToken() error = %v", err)
+		}
+	}
+
+	credential := func(owner, name string) (int64, int64) {
+		t.Helper()
+		state, err := store.GetRepoState(owner, name)
+		if err != nil || state == nil {
+			t.Fatalf("GetRepoState() = %v, %v", state, err)
+		}
+		return state.CredentialChatID, state.CredentialUserID
+	}
+
+	if err := store.SetRepoChatCredential("acme", "private", 1); err != nil {
+		t.Fatalf("SetRepoChatCredential() error = %v", err)
+	}
+	// Another chat subscribing does not take over the repository
+	if err := store.SetRepoChatCredential("acme", "private", 2); err != nil {
+		t.Fatalf("SetRepoChatCredential() error = %v", err)
+	}
+	if chatID, _ := credential("acme", "private"); chatID != 1 {
+		t.Errorf("credential chat = %d, want 1", chatID)
+	}
+
+	// Nor does it take over one read with a linked account
+	if err := store.SetRepoCredential("acme", "linked", 42); err != nil {
+		t.Fatalf("SetRepoCredential() error = %v", err)
+	}
+	if err := store.SetRepoChatCredential("acme", "linked", 2); err != nil {
+		t.Fatalf("SetRepoChatCredential() error = %v", err)
+	}
+	if chatID, userID := credential("acme", "linked"); chatID != 0 || userID != 42 {
+		t.Errorf("credential = chat %d, user %d, want user 42", chatID, userID)
+	}
+
+	// Removing the token falls back to the configured tokens
+	if removed, err := store.DeleteChatToken(1); err != nil || !removed {
+		t.Fatalf("DeleteChatToken() = %v, %v", removed, err)
+	}
+	if chatID, _ := credential("acme", "private"); chatID != 0 {
+		t.Errorf("credential chat = %d after removing its token, want 0", chatID)
+	}
+	if removed, err := store.DeleteChatToken(1); err != nil || removed {
+		t.Errorf("DeleteChatToken() = %v, %v for a removed token", removed, err)
+	}
+	if token, _ := store.GetChatGitHubToken(2); token != "ghp_two" {
+		t.Errorf("token of chat 2 = %q, want it kept", token)
+	}
+}
+
+func TestRotateSecretsChatTokens(t *testing.T) {
+	oldKey, newKey := testKey(1), testKey(2)
+	store := newTestStore(t, oldKey)
+	if err := store.SaveChatToken(1, "octocat", "ghp_chat"); err != nil {
+		t.Fatalf("SaveChatToken() error = %v", err)
+	}
+	if err := store.SaveGitHubAccount(7, "hubot", "gho_account", ""); err != nil {
+		t.Fatalf("SaveGitHubAccount() error = %v", err)
+	}
+
+	cipher, err := NewCipher(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	store.SetCipher(cipher)
+	if rotated, err := store.RotateSecrets(); err != nil || rotated != 2 {
+		t.Fatalf("RotateSecrets() = %d, %v, want 2", rotated, err)
+	}
+	if rotated, err := store.RotateSecrets(); err != nil || rotated != 0 {
+		t.Errorf("RotateSecrets() = %d, %v again, want 0", rotated, err)
+	}
+
+	// Only the new key is needed from now on
+	cipher, err = NewCipher(newKey)
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	store.SetCipher(cipher)
+	if token, err := store.GetChatGitHubToken(1); err != nil || token != "ghp_chat" {
+		t.Errorf("GetChatGitHubToken() = %q, %v", token, err)
+	}
+	if token, err := store.GetGitHubToken(7); err != nil || token != "gho_account" {
+		t.Errorf("GetGitHubToken() = %q, %v", token, err)
+	}
+}
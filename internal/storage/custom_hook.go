@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Custom hooks let any service post to a chat: JSON sent to the hook's URL
+// is rendered with the hook's template and delivered like a notification.
+//
+// The secret token in the URL is looked up by its SHA-256 and kept sealed
+// with the store's cipher, so that the URLs can be shown again. Without a
+// cipher only the hash is kept, and a hook's URL is shown once when it is
+// created. Hooks of earlier versions keep their token in plain text until a
+// cipher seals them, see RotateSecrets.
+
+// ErrCustomHookExists is returned when a chat already has a hook of a name.
+var ErrCustomHookExists = errors.New("custom hook already exists")
+
+// CustomHook is a webhook URL of a chat for other services.
+type CustomHook struct {
+	ID         int64        `db:"id"`
+	ChatID     int64        `db:"chat_id"`
+	Name       string       `db:"name"`
+	Token      string       `db:"token"`      // Secret part of the URL, see GetCustomHookToken
+	TokenHash  string       `db:"token_hash"` // SHA-256 of the token, empty for plain text tokens
+	Template   string       `db:"template"`   // Empty to show the payload as is
+	CreatedAt  time.Time    `db:"created_at"`
+	LastUsedAt sql.NullTime `db:"last_used_at"`
+}
+
+// CreateCustomHook adds a hook of a chat with a new random token, which it
+// returns along with the hook.
+func (s *SubscriptionStore) CreateCustomHook(chatID int64, name string) (*CustomHook, string, error) {
+	existing, err := s.GetCustomHook(chatID, name)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return nil, "", ErrCustomHookExists
+	}
+
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(secret)
+	hash := hashHookToken(token)
+	stored := hash
+	if s.cipher != nil {
+		if stored, err = s.cipher.Encrypt(token); err != nil {
+			return nil, "", err
+		}
+	}
+	query := `INSERT INTO custom_hooks (chat_id, name, token, token_hash) VALUES (?, ?, ?, ?)`
+	if _, err := s.db.Exec(query, chatID, name, stored, hash); err != nil {
+		return nil, "", err
+	}
+	hook, err := s.GetCustomHook(chatID, name)
+	return hook, token, err
+}
+
+// GetCustomHookToken returns the token of a hook, empty if only its hash is
+// kept.
+func (s *SubscriptionStore) GetCustomHookToken(hook *CustomHook) (string, error) {
+	switch hook.TokenHash {
+	case "":
+		return hook.Token, nil
+	case hook.Token:
+		return "", nil
+	}
+	return s.decrypt(hook.Token)
+}
+
+// hashHookToken returns the hash a hook is looked up by.
+func hashHookToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetCustomHook returns a hook of a chat by name, nil if there is none.
+func (s *SubscriptionStore) GetCustomHook(chatID int64, name string) (*CustomHook, error) {
+	var hook CustomHook
+	err := s.db.Get(&hook, `SELECT * FROM custom_hooks WHERE chat_id = ? AND name = ?`, chatID, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &hook, err
+}
+
+// GetCustomHookByToken returns the hook of a token, nil if there is none.
+func (s *SubscriptionStore) GetCustomHookByToken(token string) (*CustomHook, error) {
+	var hook CustomHook
+	query := `SELECT * FROM custom_hooks WHERE token_hash = ? OR (token_hash = '' AND token = ?)`
+	err := s.db.Get(&hook, query, hashHookToken(token), token)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &hook, err
+}
+
+// GetCustomHooksByChat returns the hooks of a chat by name.
+func (s *SubscriptionStore) GetCustomHooksByChat(chatID int64) ([]CustomHook, error) {
+	var hooks []CustomHook
+	err := s.db.Select(&hooks, `SELECT * FROM custom_hooks WHERE chat_id = ? ORDER BY name`, chatID)
+	return hooks, err
+}
+
+// SetCustomHookTemplate sets the template of a hook, empty to show payloads
+// as they are.
+func (s *SubscriptionStore) SetCustomHookTemplate(chatID int64, name, template string) error {
+	_, err := s.db.Exec(`UPDATE custom_hooks SET template = ? WHERE chat_id = ? AND name = ?`, template, chatID, name)
+	return err
+}
+
+// MarkCustomHookUsed records that a hook received a payload.
+func (s *SubscriptionStore) MarkCustomHookUsed(id int64) error {
+	_, err := s.db.Exec(`UPDATE custom_hooks SET last_used_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
+
+// DeleteCustomHook removes a hook of a chat, reporting whether it existed.
+func (s *SubscriptionStore) DeleteCustomHook(chatID int64, name string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM custom_hooks WHERE chat_id = ? AND name = ?`, chatID, name)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// sealCustomHookTokens hashes and seals the hook tokens earlier versions kept
+// in plain text, returning how many it sealed.
+func (s *SubscriptionStore) sealCustomHookTokens() (int, error) {
+	var hooks []CustomHook
+	if err := s.db.Select(&hooks, `SELECT * FROM custom_hooks WHERE token_hash = ''`); err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	for _, hook := range hooks {
+		encrypted, err := s.cipher.Encrypt(hook.Token)
+		if err != nil {
+			return sealed, err
+		}
+		query := `UPDATE custom_hooks SET token = ?, token_hash = ? WHERE id = ? AND token_hash = ''`
+		if _, err := s.db.Exec(query, encrypted, hashHookToken(hook.Token), hook.ID); err != nil {
+			return sealed, err
+		}
+		sealed++
+	}
+	return sealed, nil
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+// createHook creates a hook of chat 1, created if needed, and returns it
+// with its token.
+func createHook(t *testing.T, store *SubscriptionStore, name string) (*CustomHook, string) {
+	t.Helper()
+	if err := store.CreateOrUpdateChat(1, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+	hook, token, err := store.CreateCustomHook(1, name)
+	if err != nil || hook == nil || token == "" {
+		t.Fatalf("CreateCustomHook() = %v, %q, %v", hook, token, err)
+	}
+	return hook, token
+}
+
+// hookByToken returns the name of the hook a token opens, empty for none.
+func hookByToken(t *testing.T, store *SubscriptionStore, token string) string {
+	t.Helper()
+	hook, err := store.GetCustomHookByToken(token)
+	if err != nil {
+		t.Fatalf("GetCustomHookByToken() error = %v", err)
+	}
+	if hook == nil {
+		return ""
+	}
+	return hook.Name
+}
+
+func TestCustomHookToken(t *testing.T) {
+	store := newTestStore(t)
+	hook, token := createHook(t, store, "uptime")
+
+	if strings.Contains(hook.Token, token) || strings.Contains(hook.TokenHash, token) {
+		t.Errorf("CreateCustomHook() stored %+v, want the token sealed and hashed", hook)
+	}
+	if got, err := store.GetCustomHookToken(hook); err != nil || got != token {
+		t.Errorf("GetCustomHookToken() = %q, %v, want %q", got, err, token)
+	}
+	if name := hookByToken(t, store, token); name != "uptime" {
+		t.Errorf("GetCustomHookByToken() = %q, want the hook", name)
+	}
+	for _, guess := range []string{"", hook.Token, hook.TokenHash} {
+		if name := hookByToken(t, store, guess); name != "" {
+			t.Errorf("GetCustomHookByToken(%q) = %q, want no hook", guess, name)
+		}
+	}
+}
+
+func TestCustomHookTokenWithoutCipher(t *testing.T) {
+	store := NewSubscriptionStore(newTestDatabase(t))
+	hook, token := createHook(t, store, "uptime")
+
+	// Only the hash is kept, the URL works but cannot be shown again
+	if got, err := store.GetCustomHookToken(hook); err != nil || got != "" {
+		t.Errorf("GetCustomHookToken() = %q, %v, want no token", got, err)
+	}
+	if name := hookByToken(t, store, token); name != "uptime" {
+		t.Errorf("GetCustomHookByToken() = %q, want the hook", name)
+	}
+	createHook(t, store, "ci")
+}
+
+func TestRotateSecretsCustomHooks(t *testing.T) {
+	oldKey, newKey := testKey(1), testKey(2)
+	store := newTestStore(t, oldKey)
+	_, sealed := createHook(t, store, "sealed")
+
+	// Kept in plain text by an earlier version
+	if _, err := store.db.Exec(`INSERT INTO custom_hooks (chat_id, name, token) VALUES (1, 'legacy', 'plaintoken')`); err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+	if name := hookByToken(t, store, "plaintoken"); name != "legacy" {
+		t.Fatalf("GetCustomHookByToken() = %q, want the legacy hook", name)
+	}
+
+	// Without a cipher only the hash is kept, there is nothing to rotate
+	store.SetCipher(nil)
+	_, hashed := createHook(t, store, "hashed")
+
+	cipher, err := NewCipher(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	store.SetCipher(cipher)
+	if rotated, err := store.RotateSecrets(); err != nil || rotated != 2 {
+		t.Fatalf("RotateSecrets() = %d, %v, want the legacy hook sealed and the other rotated", rotated, err)
+	}
+	if rotated, err := store.RotateSecrets(); err != nil || rotated != 0 {
+		t.Errorf("RotateSecrets() = %d, %v again, want 0", rotated, err)
+	}
+
+	legacy, err := store.GetCustomHook(1, "legacy")
+	if err != nil || legacy == nil || legacy.Token == "plaintoken" {
+		t.Fatalf("GetCustomHook() = %+v, %v, want the token sealed", legacy, err)
+	}
+	if token, err := store.GetCustomHookToken(legacy); err != nil || token != "plaintoken" {
+		t.Errorf("GetCustomHookToken() = %q, %v", token, err)
+	}
+	for token, want := range map[string]string{"plaintoken": "legacy", sealed: "sealed", hashed: "hashed"} {
+		if name := hookByToken(t, store, token); name != want {
+			t.Errorf("GetCustomHookByToken() = %q, want %q", name, want)
+		}
+	}
+}
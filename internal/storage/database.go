@@ -14,44 +14,23 @@ type Database struct {
 	*sqlx.DB
 }
 
-// schema defines the database tables.
-const schema = `
-CREATE TABLE IF NOT EXISTS chats (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    chat_id INTEGER UNIQUE NOT NULL,
-    chat_type TEXT NOT NULL,
-    title TEXT,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS subscriptions (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    chat_id INTEGER NOT NULL,
-    repo_owner TEXT NOT NULL,
-    repo_name TEXT NOT NULL,
-    events TEXT NOT NULL DEFAULT '["push","release","issues","pull_request"]',
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(chat_id, repo_owner, repo_name),
-    FOREIGN KEY (chat_id) REFERENCES chats(chat_id)
-);
-
-CREATE TABLE IF NOT EXISTS event_records (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    repo_owner TEXT NOT NULL,
-    repo_name TEXT NOT NULL,
-    event_type TEXT NOT NULL,
-    event_id TEXT NOT NULL,
-    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-    UNIQUE(repo_owner, repo_name, event_type, event_id)
-);
-
-CREATE INDEX IF NOT EXISTS idx_subscriptions_chat_id ON subscriptions(chat_id);
-CREATE INDEX IF NOT EXISTS idx_subscriptions_repo ON subscriptions(repo_owner, repo_name);
-CREATE INDEX IF NOT EXISTS idx_event_records_repo ON event_records(repo_owner, repo_name);
-`
-
-// NewDatabase creates a new database connection and initializes the schema.
+// NewDatabase creates a new database connection and migrates the schema to
+// the latest version.
 func NewDatabase(dbPath string) (*Database, error) {
+	d, err := OpenDatabase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.MigrateUp(); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// OpenDatabase creates a new database connection, leaving the schema as it
+// is.
+func OpenDatabase(dbPath string) (*Database, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -68,12 +47,29 @@ func NewDatabase(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	// Initialize schema
-	if _, err := db.Exec(schema); err != nil {
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+	return &Database{DB: db}, nil
+}
+
+// Snapshot writes a consistent copy of the database to path, which must not
+// exist yet.
+func (d *Database) Snapshot(path string) error {
+	if _, err := d.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
 	}
+	return nil
+}
 
-	return &Database{DB: db}, nil
+// Vacuum rebuilds the database file, returning the space of deleted rows to
+// the file system.
+func (d *Database) Vacuum() error {
+	_, err := d.Exec(`VACUUM`)
+	return err
+}
+
+// Analyze refreshes the statistics the query planner chooses indexes by.
+func (d *Database) Analyze() error {
+	_, err := d.Exec(`ANALYZE`)
+	return err
 }
 
 // Close closes the database connection.
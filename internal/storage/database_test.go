@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// newTestDatabase returns a migrated in-memory database. It keeps a single
+// connection, as every connection to ":memory:" opens a database of its own.
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := OpenDatabase(":memory:")
+	if err != nil {
+		t.Fatalf("OpenDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+	if err := db.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	return db
+}
+
+// testKey returns an encryption key made of one repeated byte.
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+// newTestStore returns a store with a cipher sealing secrets with the first
+// of keys, or a test key without any.
+func newTestStore(t *testing.T, keys ...string) *SubscriptionStore {
+	t.Helper()
+	store := NewSubscriptionStore(newTestDatabase(t))
+	if len(keys) == 0 {
+		keys = []string{testKey(1)}
+	}
+	cipher, err := NewCipher(keys[0], keys[1:]...)
+	if err != nil {
+		t.Fatalf("NewCipher() error = %v", err)
+	}
+	store.SetCipher(cipher)
+	return store
+}
+
+func TestMigrateDownUp(t *testing.T) {
+	db := newTestDatabase(t)
+	latest, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion() error = %v", err)
+	}
+
+	if err := db.MigrateDown(0); err != nil {
+		t.Fatalf("MigrateDown(0) error = %v", err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != 0 {
+		t.Fatalf("SchemaVersion() = %d, %v after migrating down, want 0", version, err)
+	}
+	if err := db.MigrateUp(); err != nil {
+		t.Fatalf("MigrateUp() error = %v", err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != latest {
+		t.Errorf("SchemaVersion() = %d, %v after migrating up again, want %d", version, err, latest)
+	}
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportVersion is the version of the format written by ExportChat. Imports
+// of newer versions are refused.
+const ExportVersion = 1
+
+// ErrUnsupportedExport is returned when importing a document of an unknown
+// format version.
+var ErrUnsupportedExport = errors.New("unsupported export version")
+
+// ChatExport is the portable form of a chat's subscriptions, used to move
+// them to another chat or bot instance. Repositories subscribed through a
+// followed user are left out, the user subscription adds them again.
+type ChatExport struct {
+	Version       int                    `json:"version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Subscriptions []ExportedSubscription `json:"subscriptions"`
+	Users         []ExportedUser         `json:"users,omitempty"`
+	Topics        []string               `json:"topics,omitempty"`
+}
+
+// ExportedSubscription is a repository subscription in a ChatExport.
+type ExportedSubscription struct {
+	Repo      string               `json:"repo"` // "owner/name"
+	Events    []EventType          `json:"events"`
+	Filters   *SubscriptionFilters `json:"filters,omitempty"`
+	Digest    string               `json:"digest,omitempty"`
+	Silent    []EventType          `json:"silent,omitempty"`
+	Templates map[EventType]string `json:"templates,omitempty"`
+}
+
+// ExportedUser is a followed GitHub user in a ChatExport.
+type ExportedUser struct {
+	Login  string      `json:"login"`
+	Events []EventType `json:"events"`
+}
+
+// ImportResult tells what ImportChat recreated.
+type ImportResult struct {
+	Subscriptions int
+	Users         int
+	Topics        int
+	Skipped       int  // Invalid entries
+	QuotaReached  bool // Subscriptions were left out for the quotas
+}
+
+// ExportChat returns the subscriptions, followed users and topics of a chat.
+func (s *SubscriptionStore) ExportChat(chatID int64) (*ChatExport, error) {
+	export := &ChatExport{
+		Version:       ExportVersion,
+		ExportedAt:    time.Now().UTC(),
+		Subscriptions: []ExportedSubscription{},
+	}
+
+	subs, err := s.GetSubscriptionsByChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range subs {
+		if sub.Via != "" {
+			continue
+		}
+		exported := ExportedSubscription{
+			Repo:   sub.RepoOwner + "/" + sub.RepoName,
+			Digest: sub.Digest,
+		}
+		if exported.Events, err = ParseEvents(sub.Events); err != nil {
+			return nil, err
+		}
+		if sub.Filters != "" && sub.Filters != "{}" {
+			filters, err := ParseFilters(sub.Filters)
+			if err != nil {
+				return nil, err
+			}
+			exported.Filters = &filters
+		}
+		if sub.Silent != "" {
+			if exported.Silent, err = ParseEvents(sub.Silent); err != nil {
+				return nil, err
+			}
+		}
+		templates, err := ParseTemplates(sub.Templates)
+		if err != nil {
+			return nil, err
+		}
+		if len(templates) > 0 {
+			exported.Templates = templates
+		}
+		export.Subscriptions = append(export.Subscriptions, exported)
+	}
+
+	var users []UserSubscription
+	if err := s.db.Select(&users, `SELECT * FROM user_subscriptions WHERE chat_id = ? ORDER BY login`, chatID); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		events, err := ParseEvents(user.Events)
+		if err != nil {
+			return nil, err
+		}
+		export.Users = append(export.Users, ExportedUser{Login: user.Login, Events: events})
+	}
+
+	topics, err := s.GetTopicSubscriptionsByChat(chatID)
+	if err != nil {
+		return nil, err
+	}
+	for _, topic := range topics {
+		export.Topics = append(export.Topics, topic.Topic)
+	}
+	return export, nil
+}
+
+// ImportChat recreates the subscriptions of an export in a chat. Existing
+// subscriptions to the same repositories are overwritten, others are kept.
+// validate checks the filter patterns and templates of each subscription,
+// which the storage package cannot parse; subscriptions failing it are
+// skipped.
+func (s *SubscriptionStore) ImportChat(chatID int64, export *ChatExport, validate func(ExportedSubscription) error) (ImportResult, error) {
+	var result ImportResult
+	if export.Version < 1 || export.Version > ExportVersion {
+		return result, ErrUnsupportedExport
+	}
+
+	for _, sub := range export.Subscriptions {
+		owner, name, ok := strings.Cut(sub.Repo, "/")
+		events, eventsErr := canonicalEvents(sub.Events)
+		silent, silentErr := canonicalEvents(sub.Silent)
+		if !ok || owner == "" || name == "" || strings.Contains(name, "/") ||
+			eventsErr != nil || len(events) == 0 || silentErr != nil || !isDigestMode(sub.Digest) ||
+			validate(sub) != nil {
+			result.Skipped++
+			continue
+		}
+
+		err := s.Subscribe(chatID, owner, name, events)
+		if errors.Is(err, ErrChatQuotaExceeded) || errors.Is(err, ErrRepoQuotaExceeded) {
+			result.QuotaReached = true
+			continue
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to import %s: %w", sub.Repo, err)
+		}
+
+		filters := SubscriptionFilters{}
+		if sub.Filters != nil {
+			filters = *sub.Filters
+		}
+		if err := s.SetSubscriptionFilters(chatID, owner, name, filters); err != nil {
+			return result, err
+		}
+		if err := s.SetSubscriptionDigest(chatID, owner, name, sub.Digest); err != nil {
+			return result, err
+		}
+		if err := s.SetSubscriptionSilent(chatID, owner, name, silent); err != nil {
+			return result, err
+		}
+		for event, text := range sub.Templates {
+			if err := s.SetSubscriptionTemplate(chatID, owner, name, event, text); err != nil {
+				return result, err
+			}
+		}
+		result.Subscriptions++
+	}
+
+	for _, user := range export.Users {
+		events, err := canonicalEvents(user.Events)
+		if err != nil || len(events) == 0 || user.Login == "" {
+			result.Skipped++
+			continue
+		}
+		// The poller synchronizes the user's repositories on its next run
+		if _, err := s.SubscribeUser(chatID, user.Login, events); err != nil {
+			return result, fmt.Errorf("failed to import user %s: %w", user.Login, err)
+		}
+		result.Users++
+	}
+
+	for _, topic := range export.Topics {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic == "" {
+			result.Skipped++
+			continue
+		}
+		if err := s.SubscribeTopic(chatID, topic); err != nil {
+			return result, fmt.Errorf("failed to import topic %s: %w", topic, err)
+		}
+		result.Topics++
+	}
+	return result, nil
+}
+
+// canonicalEvents checks event types read from an export.
+func canonicalEvents(events []EventType) ([]EventType, error) {
+	canonical := make([]EventType, 0, len(events))
+	for _, event := range events {
+		parsed, err := ParseEventType(string(event))
+		if err != nil {
+			return nil, err
+		}
+		canonical = append(canonical, parsed)
+	}
+	return canonical, nil
+}
+
+// isDigestMode reports whether digest is one of the Digest constants.
+func isDigestMode(digest string) bool {
+	return digest == DigestOff || digest == DigestDaily || digest == DigestWeekly
+}
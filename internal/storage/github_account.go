@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Users can link their GitHub account to subscribe to private repositories.
+// The poller reads such a repository with the token of the user who
+// subscribed to it, recorded as the credential of the repository.
+
+// GitHubAccount is the GitHub account a Telegram user linked.
+type GitHubAccount struct {
+	UserID    int64     `db:"user_id"` // Telegram user
+	Login     string    `db:"login"`
+	Token     string    `db:"token"`  // Encrypted, see GetGitHubToken
+	Scopes    string    `db:"scopes"` // OAuth scopes granted, comma separated
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+// SaveGitHubAccount links a GitHub account to a Telegram user, replacing the
+// one linked before. The token is encrypted with the store's cipher.
+func (s *SubscriptionStore) SaveGitHubAccount(userID int64, login, token, scopes string) error {
+	encrypted, err := s.encrypt(token)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO github_accounts (user_id, login, token, scopes)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET
+			login = excluded.login,
+			token = excluded.token,
+			scopes = excluded.scopes,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err = s.db.Exec(query, userID, login, encrypted, scopes)
+	return err
+}
+
+// GetGitHubAccount returns the account a user linked, nil if none.
+func (s *SubscriptionStore) GetGitHubAccount(userID int64) (*GitHubAccount, error) {
+	var account GitHubAccount
+	err := s.db.Get(&account, `SELECT * FROM github_accounts WHERE user_id = ?`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetGitHubToken returns the decrypted token of the account a user linked,
+// empty if none.
+func (s *SubscriptionStore) GetGitHubToken(userID int64) (string, error) {
+	account, err := s.GetGitHubAccount(userID)
+	if err != nil || account == nil {
+		return "", err
+	}
+	return s.decrypt(account.Token)
+}
+
+// DeleteGitHubAccount unlinks the account of a user, reporting whether there
+// was one. Repositories read with it fall back to the configured tokens.
+func (s *SubscriptionStore) DeleteGitHubAccount(userID int64) (bool, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM github_accounts WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	if _, err := tx.Exec(`UPDATE repo_state SET credential_user_id = 0 WHERE credential_user_id = ?`, userID); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// SetRepoCredential sets the user whose linked account reads a repository,
+// 0 for the configured tokens.
+func (s *SubscriptionStore) SetRepoCredential(repoOwner, repoName string, userID int64) error {
+	if err := s.EnsureRepoState(repoOwner, repoName); err != nil {
+		return err
+	}
+	query := `UPDATE repo_state SET credential_user_id = ? WHERE repo_owner = ? AND repo_name = ?`
+	_, err := s.db.Exec(query, userID, repoOwner, repoName)
+	return err
+}
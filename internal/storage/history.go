@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// The event history keeps every event notified for a repository with its
+// full payload, so chats can look back at what they missed.
+
+// HistoryEntry is an event notified for a repository.
+type HistoryEntry struct {
+	ID         int64        `db:"id"`
+	RepoOwner  string       `db:"repo_owner"`
+	RepoName   string       `db:"repo_name"`
+	EventType  string       `db:"event_type"`
+	Payload    string       `db:"payload"` // JSON of the event payload
+	Source     string       `db:"source"`  // webhook or poller
+	OccurredAt sql.NullTime `db:"occurred_at"`
+	CreatedAt  time.Time    `db:"created_at"`
+}
+
+// Time returns when the event happened, or when it was recorded if unknown.
+func (e *HistoryEntry) Time() time.Time {
+	if e.OccurredAt.Valid {
+		return e.OccurredAt.Time
+	}
+	return e.CreatedAt
+}
+
+// RecordHistory adds an event to the history of a repository.
+func (s *SubscriptionStore) RecordHistory(repoOwner, repoName, eventType, payload, source string, occurredAt time.Time) error {
+	var occurred sql.NullTime
+	if !occurredAt.IsZero() {
+		occurred = sql.NullTime{Time: occurredAt.UTC(), Valid: true}
+	}
+	query := `
+		INSERT INTO event_history (repo_owner, repo_name, event_type, payload, source, occurred_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, repoOwner, repoName, eventType, payload, source, occurred)
+	return err
+}
+
+// GetHistory returns the latest events of a repository, newest first.
+func (s *SubscriptionStore) GetHistory(repoOwner, repoName string, limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	query := `
+		SELECT * FROM event_history
+		WHERE repo_owner = ? COLLATE NOCASE AND repo_name = ? COLLATE NOCASE
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	err := s.db.Select(&entries, query, repoOwner, repoName, limit)
+	return entries, err
+}
+
+// GetRecentHistory returns the latest events of all repositories, newest
+// first.
+func (s *SubscriptionStore) GetRecentHistory(limit int) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	err := s.db.Select(&entries, `SELECT * FROM event_history ORDER BY id DESC LIMIT ?`, limit)
+	return entries, err
+}
+
+// CleanupHistory removes events older than the given number of days from the
+// history.
+func (s *SubscriptionStore) CleanupHistory(daysToKeep int) (int64, error) {
+	query := `DELETE FROM event_history WHERE created_at < datetime('now', '-' || ? || ' days')`
+	result, err := s.db.Exec(query, daysToKeep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"sort"
+	"time"
+)
+
+// InboundEvent is a webhook delivery persisted until it was handled, so a
+// crash or a backlog does not lose it.
+type InboundEvent struct {
+	ID          int64     `db:"id"`
+	EventType   string    `db:"event_type"`   // X-GitHub-Event
+	DeliveryID  string    `db:"delivery_id"`  // X-GitHub-Delivery
+	Payload     string    `db:"payload"`      // Raw JSON body
+	TraceParent string    `db:"trace_parent"` // W3C traceparent of the request, empty if not traced
+	ReceivedAt  time.Time `db:"received_at"`
+
+	ClaimedBy    string `db:"claimed_by"`    // Instance handling the delivery, empty if none
+	ClaimedUntil int64  `db:"claimed_until"` // Unix time the claim or retry backoff ends
+	Attempts     int    `db:"attempts"`      // Failed attempts at handling it
+}
+
+// AddInboundEvent persists a received webhook delivery and returns its ID.
+func (s *SubscriptionStore) AddInboundEvent(e *InboundEvent) (int64, error) {
+	query := `
+		INSERT INTO inbound_events (event_type, delivery_id, payload, trace_parent, received_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, e.EventType, e.DeliveryID, e.Payload, e.TraceParent, e.ReceivedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// ClaimInboundEvents claims up to limit webhook deliveries for holder until
+// now+ttl and returns them, oldest first. Only deliveries nobody claimed, or
+// whose claim or retry backoff ended, are claimed, so instances sharing the
+// database never hand out the same delivery at the same time.
+func (s *SubscriptionStore) ClaimInboundEvents(holder string, ttl time.Duration, limit int) ([]InboundEvent, error) {
+	now := time.Now()
+	var events []InboundEvent
+	query := `
+		UPDATE inbound_events SET claimed_by = ?, claimed_until = ?
+		WHERE id IN (
+			SELECT id FROM inbound_events WHERE claimed_until < ? ORDER BY id LIMIT ?
+		)
+		RETURNING *
+	`
+	if err := s.db.Select(&events, query, holder, now.Add(ttl).Unix(), now.Unix(), limit); err != nil {
+		return nil, err
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+	return events, nil
+}
+
+// RenewInboundClaims extends the claims of holder on the webhook deliveries it
+// has not handled yet until now+ttl.
+func (s *SubscriptionStore) RenewInboundClaims(holder string, ttl time.Duration) error {
+	query := `UPDATE inbound_events SET claimed_until = ? WHERE claimed_by = ?`
+	_, err := s.db.Exec(query, time.Now().Add(ttl).Unix(), holder)
+	return err
+}
+
+// RetryInboundEvent releases the claim on a webhook delivery that could not
+// be handled, so it is claimed again once backoff has passed.
+func (s *SubscriptionStore) RetryInboundEvent(id int64, backoff time.Duration) error {
+	query := `
+		UPDATE inbound_events SET claimed_by = '', claimed_until = ?, attempts = attempts + 1
+		WHERE id = ?
+	`
+	_, err := s.db.Exec(query, time.Now().Add(backoff).Unix(), id)
+	return err
+}
+
+// DeleteInboundEvent removes a webhook delivery once it was handled.
+func (s *SubscriptionStore) DeleteInboundEvent(id int64) error {
+	_, err := s.db.Exec(`DELETE FROM inbound_events WHERE id = ?`, id)
+	return err
+}
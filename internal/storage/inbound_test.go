@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClaimInboundEvents(t *testing.T) {
+	store := newTestStore(t)
+	for _, delivery := range []string{"a", "b", "c"} {
+		if _, err := store.AddInboundEvent(&InboundEvent{EventType: "push", DeliveryID: delivery, Payload: "{}", ReceivedAt: time.Now()}); err != nil {
+			t.Fatalf("AddInboundEvent() error = %v", err)
+		}
+	}
+	claim := func(holder string, ttl time.Duration, limit int) []string {
+		t.Helper()
+		events, err := store.ClaimInboundEvents(holder, ttl, limit)
+		if err != nil {
+			t.Fatalf("ClaimInboundEvents() error = %v", err)
+		}
+		var deliveries []string
+		for _, e := range events {
+			if e.ClaimedBy != holder {
+				t.Errorf("delivery %s claimed by %q, want %q", e.DeliveryID, e.ClaimedBy, holder)
+			}
+			deliveries = append(deliveries, e.DeliveryID)
+		}
+		return deliveries
+	}
+
+	// Two instances never get the same delivery
+	if got := claim("old", time.Minute, 2); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("old claimed %v, want a and b in order", got)
+	}
+	if got := claim("new", time.Minute, 10); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("new claimed %v, want c only", got)
+	}
+	if got := claim("new", time.Minute, 10); len(got) != 0 {
+		t.Fatalf("new claimed %v again", got)
+	}
+
+	// Claims that are not renewed run out
+	if err := store.RenewInboundClaims("old", -2*time.Second); err != nil {
+		t.Fatalf("RenewInboundClaims() error = %v", err)
+	}
+	if got := claim("new", time.Minute, 10); len(got) != 2 {
+		t.Fatalf("new claimed %v, want the expired claims of old", got)
+	}
+
+	// A failed delivery waits for its backoff
+	events, err := store.ClaimInboundEvents("other", time.Minute, 10)
+	if err != nil || len(events) != 0 {
+		t.Fatalf("ClaimInboundEvents() = %v, %v, want nothing left", events, err)
+	}
+	if err := store.RetryInboundEvent(1, time.Hour); err != nil {
+		t.Fatalf("RetryInboundEvent() error = %v", err)
+	}
+	if got := claim("other", time.Minute, 10); len(got) != 0 {
+		t.Errorf("claimed %v during the backoff", got)
+	}
+	if err := store.RetryInboundEvent(1, -2*time.Second); err != nil {
+		t.Fatalf("RetryInboundEvent() error = %v", err)
+	}
+	events, err = store.ClaimInboundEvents("other", time.Minute, 10)
+	if err != nil || len(events) != 1 || events[0].ID != 1 || events[0].Attempts != 2 {
+		t.Errorf("ClaimInboundEvents() = %+v, %v, want the retried delivery after two failures", events, err)
+	}
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// The schema is built by the numbered migrations in the migrations
+// directory, each a "NNNN_name.up.sql" file with a matching ".down.sql" file
+// that reverts it. Applied versions are recorded in schema_migrations. A
+// schema change is made by adding the next migration, never by editing an
+// applied one.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one step of the schema.
+type migration struct {
+	version int
+	name    string
+	up      string
+	down    string
+}
+
+// migrationsTable records the applied migrations.
+const migrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+)`
+
+// legacyColumns lists the columns added to existing tables before versioned
+// migrations. Databases of those versions get the missing ones along with
+// the first migration.
+var legacyColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"chats", "active", "INTEGER NOT NULL DEFAULT 1"},
+	{"chats", "onboarding_state", "TEXT NOT NULL DEFAULT ''"},
+	{"subscriptions", "filters", "TEXT NOT NULL DEFAULT '{}'"},
+	{"subscriptions", "compliance_violation", "TEXT NOT NULL DEFAULT ''"},
+	{"subscriptions", "compliance_acked", "INTEGER NOT NULL DEFAULT 0"},
+	{"repo_state", "stargazers", "INTEGER NOT NULL DEFAULT -1"},
+	{"subscriptions", "muted_until", "DATETIME"},
+	{"chats", "paused", "INTEGER NOT NULL DEFAULT 0"},
+	{"chats", "quiet_hours", "TEXT NOT NULL DEFAULT ''"},
+	{"subscriptions", "digest", "TEXT NOT NULL DEFAULT ''"},
+	{"chats", "timezone", "TEXT NOT NULL DEFAULT ''"},
+	{"chats", "language", "TEXT NOT NULL DEFAULT ''"},
+	{"chats", "parse_mode", "TEXT NOT NULL DEFAULT ''"},
+	{"subscriptions", "templates", "TEXT NOT NULL DEFAULT '{}'"},
+	{"chats", "channel_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"subscriptions", "silent", "TEXT NOT NULL DEFAULT '[]'"},
+	{"chats", "photos", "INTEGER NOT NULL DEFAULT 0"},
+	{"subscriptions", "via", "TEXT NOT NULL DEFAULT ''"},
+	{"chats", "access", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// loadMigrations reads the embedded migrations ordered by version.
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		base, ok := strings.CutSuffix(entry.Name(), ".sql")
+		if !ok {
+			continue
+		}
+		base, direction, ok := cutLast(base, ".")
+		if !ok || direction != "up" && direction != "down" {
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql", entry.Name())
+		}
+		number, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+
+		data, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		m := byVersion[version]
+		if m == nil {
+			m = &migration{version: version, name: name}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.up = string(data)
+		} else {
+			m.down = string(data)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d: missing up or down file", m.version)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i, m := range migrations {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// SchemaVersion returns the version of the latest applied migration, 0 for
+// an empty database.
+func (d *Database) SchemaVersion() (int, error) {
+	if _, err := d.Exec(migrationsTable); err != nil {
+		return 0, fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	var version int
+	err := d.Get(&version, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`)
+	return version, err
+}
+
+// MigrateUp applies the migrations not applied yet.
+func (d *Database) MigrateUp() error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, len(migrations))
+	}
+
+	// Databases from before versioned migrations already have tables
+	legacy := false
+	if current == 0 {
+		var tables int
+		query := `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'chats'`
+		if err := d.Get(&tables, query); err != nil {
+			return err
+		}
+		legacy = tables > 0
+	}
+
+	for _, m := range migrations[current:] {
+		err := d.inTx(func(tx *sqlx.Tx) error {
+			if _, err := tx.Exec(m.up); err != nil {
+				return err
+			}
+			if m.version == 1 && legacy {
+				if err := addLegacyColumns(tx); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec(`INSERT INTO schema_migrations (version, name) VALUES (?, ?)`, m.version, m.name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d %s: %w", m.version, m.name, err)
+		}
+		logger.Info().Int("version", m.version).Str("name", m.name).Msg("Applied database migration")
+	}
+	return nil
+}
+
+// MigrateDown reverts the applied migrations newer than target, newest
+// first. A target of 0 drops all tables.
+func (d *Database) MigrateDown(target int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	current, err := d.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d)", current, len(migrations))
+	}
+	if target < 0 {
+		target = 0
+	}
+
+	for version := current; version > target; version-- {
+		m := migrations[version-1]
+		err := d.inTx(func(tx *sqlx.Tx) error {
+			if _, err := tx.Exec(m.down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to revert migration %d %s: %w", m.version, m.name, err)
+		}
+		logger.Info().Int("version", m.version).Str("name", m.name).Msg("Reverted database migration")
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction, committing if it succeeds.
+func (d *Database) inTx(fn func(tx *sqlx.Tx) error) error {
+	tx, err := d.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addLegacyColumns adds the legacyColumns a database is missing.
+func addLegacyColumns(tx *sqlx.Tx) error {
+	for _, c := range legacyColumns {
+		var count int
+		query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`
+		if err := tx.Get(&count, query, c.table, c.column); err != nil {
+			return fmt.Errorf("failed to inspect table %s: %w", c.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.column, c.definition)
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS queued_events;
+DROP TABLE IF EXISTS notification_stats;
+DROP TABLE IF EXISTS repo_watermarks;
+DROP TABLE IF EXISTS repo_state;
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS event_records;
+DROP TABLE IF EXISTS topic_subscriptions;
+DROP TABLE IF EXISTS user_subscriptions;
+DROP TABLE IF EXISTS subscriptions;
+DROP TABLE IF EXISTS chats;
@@ -0,0 +1,140 @@
+-- Tables as of the introduction of versioned migrations. Databases created
+-- before then may lack some columns, see legacyColumns.
+
+CREATE TABLE IF NOT EXISTS chats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER UNIQUE NOT NULL,
+    chat_type TEXT NOT NULL,
+    title TEXT,
+    active INTEGER NOT NULL DEFAULT 1,
+    onboarding_state TEXT NOT NULL DEFAULT '',
+    paused INTEGER NOT NULL DEFAULT 0,
+    quiet_hours TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT '',
+    language TEXT NOT NULL DEFAULT '',
+    parse_mode TEXT NOT NULL DEFAULT '',
+    channel_id INTEGER NOT NULL DEFAULT 0,
+    photos INTEGER NOT NULL DEFAULT 0,
+    access TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT '["push","release","issues","pull_request"]',
+    filters TEXT NOT NULL DEFAULT '{}',
+    compliance_violation TEXT NOT NULL DEFAULT '',
+    compliance_acked INTEGER NOT NULL DEFAULT 0,
+    muted_until DATETIME,
+    digest TEXT NOT NULL DEFAULT '',
+    templates TEXT NOT NULL DEFAULT '{}',
+    silent TEXT NOT NULL DEFAULT '[]',
+    via TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chat_id, repo_owner, repo_name),
+    FOREIGN KEY (chat_id) REFERENCES chats(chat_id)
+);
+
+CREATE TABLE IF NOT EXISTS user_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    login TEXT NOT NULL,
+    events TEXT NOT NULL DEFAULT '["push","release","issues","pull_request"]',
+    synced_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chat_id, login),
+    FOREIGN KEY (chat_id) REFERENCES chats(chat_id)
+);
+
+CREATE TABLE IF NOT EXISTS topic_subscriptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    topic TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(chat_id, topic),
+    FOREIGN KEY (chat_id) REFERENCES chats(chat_id)
+);
+
+CREATE TABLE IF NOT EXISTS event_records (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(repo_owner, repo_name, event_type, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS repo_state (
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    activity_score REAL NOT NULL DEFAULT 0,
+    pending_events INTEGER NOT NULL DEFAULT 0,
+    window_started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    activity_band TEXT NOT NULL DEFAULT '',
+    poll_interval INTEGER NOT NULL DEFAULT 0,
+    poll_interval_override INTEGER NOT NULL DEFAULT 0,
+    last_polled_at DATETIME,
+    stargazers INTEGER NOT NULL DEFAULT -1,
+    PRIMARY KEY (repo_owner, repo_name)
+);
+
+CREATE TABLE IF NOT EXISTS repo_watermarks (
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    seen_at DATETIME NOT NULL,
+    last_id TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (repo_owner, repo_name, kind)
+);
+
+CREATE TABLE IF NOT EXISTS notification_stats (
+    chat_id INTEGER NOT NULL,
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    day DATE NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (chat_id, repo_owner, repo_name, event_type, day)
+);
+
+CREATE TABLE IF NOT EXISTS queued_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    summary TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    text TEXT NOT NULL,
+    parse_mode TEXT NOT NULL,
+    photo TEXT NOT NULL DEFAULT '',
+    markup TEXT NOT NULL DEFAULT '',
+    silent INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    sent_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox(status);
+CREATE INDEX IF NOT EXISTS idx_queued_events_chat ON queued_events(chat_id, reason);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_chat_id ON subscriptions(chat_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_repo ON subscriptions(repo_owner, repo_name);
+CREATE INDEX IF NOT EXISTS idx_event_records_repo ON event_records(repo_owner, repo_name);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS event_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    repo_owner TEXT NOT NULL,
+    repo_name TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    occurred_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_history_repo ON event_history(repo_owner COLLATE NOCASE, repo_name COLLATE NOCASE, id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS command_audit (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL DEFAULT 0,
+    username TEXT NOT NULL DEFAULT '',
+    command TEXT NOT NULL,
+    args TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_command_audit_chat ON command_audit(chat_id, id);
+CREATE INDEX IF NOT EXISTS idx_command_audit_user ON command_audit(user_id, id);
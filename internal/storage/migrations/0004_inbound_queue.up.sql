@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS inbound_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL,
+    delivery_id TEXT NOT NULL DEFAULT '',
+    payload TEXT NOT NULL,
+    trace_parent TEXT NOT NULL DEFAULT '',
+    received_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS custom_hooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    template TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    last_used_at DATETIME,
+    FOREIGN KEY (chat_id) REFERENCES chats(chat_id),
+    UNIQUE(chat_id, name)
+);
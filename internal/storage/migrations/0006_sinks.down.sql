@@ -0,0 +1,2 @@
+ALTER TABLE outbox DROP COLUMN sink;
+ALTER TABLE subscriptions DROP COLUMN sink;
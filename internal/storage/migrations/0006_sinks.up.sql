@@ -0,0 +1,2 @@
+ALTER TABLE subscriptions ADD COLUMN sink TEXT NOT NULL DEFAULT '';
+ALTER TABLE outbox ADD COLUMN sink TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE repo_state DROP COLUMN credential_user_id;
+DROP TABLE IF EXISTS github_accounts;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS github_accounts (
+    user_id INTEGER PRIMARY KEY,
+    login TEXT NOT NULL,
+    token TEXT NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE repo_state ADD COLUMN credential_user_id INTEGER NOT NULL DEFAULT 0;
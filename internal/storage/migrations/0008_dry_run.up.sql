@@ -0,0 +1 @@
+ALTER TABLE chats ADD COLUMN dry_run INTEGER NOT NULL DEFAULT 0;
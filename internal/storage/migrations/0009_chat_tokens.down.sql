@@ -0,0 +1,2 @@
+ALTER TABLE repo_state DROP COLUMN credential_chat_id;
+DROP TABLE IF EXISTS chat_tokens;
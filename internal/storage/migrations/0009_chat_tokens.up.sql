@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS chat_tokens (
+    chat_id INTEGER PRIMARY KEY,
+    login TEXT NOT NULL,
+    token TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE repo_state ADD COLUMN credential_chat_id INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE inbound_events DROP COLUMN attempts;
+ALTER TABLE inbound_events DROP COLUMN claimed_until;
+ALTER TABLE inbound_events DROP COLUMN claimed_by;
@@ -0,0 +1,3 @@
+ALTER TABLE inbound_events ADD COLUMN claimed_by TEXT NOT NULL DEFAULT '';
+ALTER TABLE inbound_events ADD COLUMN claimed_until INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE inbound_events ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
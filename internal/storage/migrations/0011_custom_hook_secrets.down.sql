@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_custom_hooks_token_hash;
+ALTER TABLE custom_hooks DROP COLUMN token_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE custom_hooks ADD COLUMN token_hash TEXT NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_custom_hooks_token_hash ON custom_hooks(token_hash) WHERE token_hash != '';
@@ -0,0 +1 @@
+ALTER TABLE repo_state DROP COLUMN poll_override_config;
@@ -0,0 +1 @@
+ALTER TABLE repo_state ADD COLUMN poll_override_config INTEGER NOT NULL DEFAULT 0;
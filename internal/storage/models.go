@@ -1,7 +1,12 @@
 // Package storage provides database operations and data models.
 package storage
 
-import "time"
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Subscription represents a repository subscription.
 type Subscription struct {
@@ -9,10 +14,74 @@ type Subscription struct {
 	ChatID    int64     `db:"chat_id"`
 	RepoOwner string    `db:"repo_owner"`
 	RepoName  string    `db:"repo_name"`
-	Events    string    `db:"events"` // JSON array of event types
+	Events    string    `db:"events"`  // JSON array of event types
+	Filters   string    `db:"filters"` // JSON object of SubscriptionFilters
 	CreatedAt time.Time `db:"created_at"`
+
+	ComplianceViolation string `db:"compliance_violation"` // Current compliance drift, empty if compliant
+	ComplianceAcked     bool   `db:"compliance_acked"`     // Whether the drift was acknowledged
+
+	MutedUntil sql.NullTime `db:"muted_until"` // Notifications are suppressed until then
+	Digest     string       `db:"digest"`      // See Digest constants, empty for real-time delivery
+	Templates  string       `db:"templates"`   // JSON object of custom message templates by event type
+	Silent     string       `db:"silent"`      // JSON array of event types delivered without a notification sound
+	Via        string       `db:"via"`         // "user:<login>" if added by a user subscription, empty if subscribed directly
+	Sink       string       `db:"sink"`        // Route to a sink like "discord:releases", empty to notify the chat
+}
+
+// Digest modes collect a subscription's events into a periodic summary.
+const (
+	DigestOff    = ""
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// IsMuted reports whether the subscription is muted at the given time.
+func (s *Subscription) IsMuted(now time.Time) bool {
+	return s.MutedUntil.Valid && now.Before(s.MutedUntil.Time)
 }
 
+// IsSilent reports whether events of the given type are delivered without a
+// notification sound.
+func (s *Subscription) IsSilent(event EventType) bool {
+	events, err := ParseEvents(s.Silent)
+	if err != nil {
+		return false
+	}
+	for _, e := range events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// SubscriptionFilters narrows down which events a subscription is notified about.
+type SubscriptionFilters struct {
+	Assets    string   `json:"assets,omitempty"`    // Glob a release asset must match, e.g. "*linux-amd64*"
+	Platforms []string `json:"platforms,omitempty"` // Release assets listed in notifications must contain one of these
+	Licenses  []string `json:"licenses,omitempty"`  // Allowed SPDX licenses for the compliance watch
+	CI        string   `json:"ci,omitempty"`        // Which workflow runs to notify about, see CIFilter constants
+	Branches  []string `json:"branches,omitempty"`  // Branch names or globs push events must match
+
+	Labels        []string `json:"labels,omitempty"`         // Issues and PRs must have one of these labels
+	ExcludeLabels []string `json:"exclude_labels,omitempty"` // Issues and PRs with any of these labels are skipped
+	IgnoreAuthors []string `json:"ignore_authors,omitempty"` // Logins whose pushes, issues and PRs are skipped
+
+	Include string `json:"include,omitempty"` // Regex titles or commit messages must match
+	Exclude string `json:"exclude,omitempty"` // Regex of titles or commit messages to skip
+
+	SkipPrereleases bool   `json:"skip_prereleases,omitempty"` // Only notify about stable releases
+	PrereleaseTags  string `json:"prerelease_tags,omitempty"`  // Regex of tags skipped as pre-releases, empty for the default
+}
+
+// CI filter modes of a subscription.
+const (
+	CIFilterAll              = ""                  // Every completed run
+	CIFilterFailures         = "failures"          // Failed runs only
+	CIFilterFailuresRecovery = "failures+recovery" // Failed runs and the first success after a failure
+)
+
 // EventRecord stores processed events for deduplication.
 type EventRecord struct {
 	ID        int64     `db:"id"`
@@ -30,8 +99,55 @@ type Chat struct {
 	ChatType  string    `db:"chat_type"` // private, group, supergroup, channel
 	Title     string    `db:"title"`
 	CreatedAt time.Time `db:"created_at"`
+
+	Active          bool   `db:"active"`           // False while the bot is removed from the chat
+	OnboardingState string `db:"onboarding_state"` // See OnboardingState constants
+	Paused          bool   `db:"paused"`           // Notifications paused by the chat's users
+	QuietHours      string `db:"quiet_hours"`      // Daily window like "23:00-08:00", empty if none
+	Timezone        string `db:"timezone"`         // IANA name like "Europe/Berlin", empty for server time
+	Language        string `db:"language"`         // Language code like "en", empty for the default
+	ParseMode       string `db:"parse_mode"`       // See ParseMode constants
+	ChannelID       int64  `db:"channel_id"`       // Channel notifications are posted to instead, 0 if none
+	Photos          bool   `db:"photos"`           // Notifications sent as preview images with a caption
+	Access          string `db:"access"`           // See ChatAccess constants
+	DryRun          bool   `db:"dry_run"`          // Notifications logged instead of sent
 }
 
+// Location returns the chat's time zone, falling back to the server's.
+func (c *Chat) Location() *time.Location {
+	if c == nil || c.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// Approval states of a chat when new chats need an administrator's approval.
+const (
+	ChatAccessNone     = ""         // Not requested yet
+	ChatAccessPending  = "pending"  // Waiting for an administrator
+	ChatAccessApproved = "approved" // May use the bot
+	ChatAccessDenied   = "denied"   // Refused by an administrator
+)
+
+// Onboarding states of a group chat.
+const (
+	OnboardingNone    = ""        // Onboarding message not attempted yet
+	OnboardingPending = "pending" // Sending failed, retry once permissions change
+	OnboardingSent    = "sent"    // Onboarding message delivered
+	OnboardingFailed  = "failed"  // Retry failed as well, give up
+)
+
+// Parse modes notifications to a chat are rendered with.
+const (
+	ParseModeDefault  = ""         // Use the configured default
+	ParseModeMarkdown = "markdown" // Telegram MarkdownV2
+	ParseModeHTML     = "html"     // Telegram HTML
+)
+
 // EventType represents the type of GitHub event.
 type EventType string
 
@@ -42,6 +158,14 @@ const (
 	EventTypePullRequest EventType = "pull_request"
 	EventTypeStar        EventType = "star"
 	EventTypeFork        EventType = "fork"
+	EventTypeWorkflowRun EventType = "workflow_run"
+	EventTypeTag         EventType = "tag"
+	EventTypeDeployment  EventType = "deployment"
+	EventTypeWiki        EventType = "wiki"
+	EventTypePackage     EventType = "package"
+
+	EventTypePullRequestReview        EventType = "pull_request_review"
+	EventTypePullRequestReviewComment EventType = "pull_request_review_comment"
 )
 
 // AllEventTypes returns all supported event types.
@@ -49,8 +173,16 @@ func AllEventTypes() []EventType {
 	return []EventType{
 		EventTypePush,
 		EventTypeRelease,
+		EventTypeTag,
+		EventTypePackage,
 		EventTypeIssue,
 		EventTypePullRequest,
+		EventTypeStar,
+		EventTypeWorkflowRun,
+		EventTypeDeployment,
+		EventTypeWiki,
+		EventTypePullRequestReview,
+		EventTypePullRequestReviewComment,
 	}
 }
 
@@ -63,3 +195,119 @@ func DefaultEvents() []EventType {
 		EventTypePullRequest,
 	}
 }
+
+// Presets are named event selections, chosen with "preset=<name>" wherever
+// event types are listed.
+const (
+	PresetDefault      = "default"
+	PresetReleasesOnly = "releases-only"
+	PresetMaintainer   = "maintainer"
+	PresetEverything   = "everything"
+)
+
+// PresetNames lists the available presets.
+var PresetNames = []string{PresetDefault, PresetReleasesOnly, PresetMaintainer, PresetEverything}
+
+// PresetEvents returns the event types of a preset.
+func PresetEvents(name string) ([]EventType, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case PresetDefault:
+		return DefaultEvents(), true
+	case PresetReleasesOnly:
+		return []EventType{EventTypeRelease}, true
+	case PresetMaintainer:
+		return []EventType{EventTypeIssue, EventTypePullRequest, EventTypeWorkflowRun}, true
+	case PresetEverything:
+		return AllEventTypes(), true
+	}
+	return nil, false
+}
+
+// eventAliases maps the names users may type to event types.
+var eventAliases = map[string]EventType{
+	"push":          EventTypePush,
+	"pushes":        EventTypePush,
+	"commits":       EventTypePush,
+	"release":       EventTypeRelease,
+	"releases":      EventTypeRelease,
+	"tag":           EventTypeTag,
+	"tags":          EventTypeTag,
+	"package":       EventTypePackage,
+	"packages":      EventTypePackage,
+	"issue":         EventTypeIssue,
+	"issues":        EventTypeIssue,
+	"pr":            EventTypePullRequest,
+	"prs":           EventTypePullRequest,
+	"pull_request":  EventTypePullRequest,
+	"pull_requests": EventTypePullRequest,
+	"star":          EventTypeStar,
+	"stars":         EventTypeStar,
+	"ci":            EventTypeWorkflowRun,
+	"actions":       EventTypeWorkflowRun,
+	"workflows":     EventTypeWorkflowRun,
+	"workflow_run":  EventTypeWorkflowRun,
+	"deploy":        EventTypeDeployment,
+	"deploys":       EventTypeDeployment,
+	"deployment":    EventTypeDeployment,
+	"deployments":   EventTypeDeployment,
+	"wiki":          EventTypeWiki,
+	"gollum":        EventTypeWiki,
+
+	"review":                      EventTypePullRequestReview,
+	"reviews":                     EventTypePullRequestReview,
+	"pull_request_review":         EventTypePullRequestReview,
+	"review_comment":              EventTypePullRequestReviewComment,
+	"review_comments":             EventTypePullRequestReviewComment,
+	"pull_request_review_comment": EventTypePullRequestReviewComment,
+}
+
+// ParseEventType parses a single event name such as "prs".
+func ParseEventType(name string) (EventType, error) {
+	event, ok := eventAliases[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("unknown event type %q", name)
+	}
+	return event, nil
+}
+
+// ParseEventTypes parses a comma-separated list of event names such as
+// "releases,issues", which may include presets such as "preset=maintainer".
+// Duplicates are dropped and the order follows AllEventTypes.
+func ParseEventTypes(list string) ([]EventType, error) {
+	selected := make(map[EventType]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return AllEventTypes(), nil
+		}
+		if preset, ok := strings.CutPrefix(name, "preset="); ok {
+			events, ok := PresetEvents(preset)
+			if !ok {
+				return nil, fmt.Errorf("unknown preset %q", preset)
+			}
+			for _, event := range events {
+				selected[event] = true
+			}
+			continue
+		}
+		event, ok := eventAliases[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event type %q", name)
+		}
+		selected[event] = true
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no event types given")
+	}
+
+	var events []EventType
+	for _, event := range AllEventTypes() {
+		if selected[event] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Delivery states of an outbox message.
+const (
+	OutboxPending = "pending" // Not delivered yet, resumed after a restart
+	OutboxSent    = "sent"    // Delivered to Telegram
+	OutboxFailed  = "failed"  // Gave up after a permanent error
+)
+
+// OutboxMessage is a notification persisted until it was delivered.
+type OutboxMessage struct {
+	ID        int64        `db:"id"`
+	ChatID    int64        `db:"chat_id"`    // Chat the notification belongs to
+	TargetID  int64        `db:"target_id"`  // Chat or bound channel it is posted to
+	Text      string       `db:"text"`       // Message text, already in its parse mode
+	ParseMode string       `db:"parse_mode"` // Telegram parse mode like "MarkdownV2"
+	Photo     string       `db:"photo"`      // Image to send the text as caption of, empty for none
+	Markup    string       `db:"markup"`     // JSON reply markup, empty for none
+	Silent    bool         `db:"silent"`     // Deliver without a notification sound
+	Sink      string       `db:"sink"`       // Route to deliver to instead of TargetID, empty for Telegram
+	Status    string       `db:"status"`     // See Outbox constants
+	Error     string       `db:"error"`      // Last delivery error
+	CreatedAt time.Time    `db:"created_at"`
+	SentAt    sql.NullTime `db:"sent_at"`
+}
+
+// AddOutboxMessage persists a pending notification and returns its ID.
+func (s *SubscriptionStore) AddOutboxMessage(m *OutboxMessage) (int64, error) {
+	query := `
+		INSERT INTO outbox (chat_id, target_id, text, parse_mode, photo, markup, silent, sink)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, m.ChatID, m.TargetID, m.Text, m.ParseMode, m.Photo, m.Markup, m.Silent, m.Sink)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetPendingOutbox returns the undelivered notifications up to and including
+// maxID, oldest first.
+func (s *SubscriptionStore) GetPendingOutbox(maxID int64) ([]OutboxMessage, error) {
+	var messages []OutboxMessage
+	query := `SELECT * FROM outbox WHERE status = ? AND id <= ? ORDER BY id`
+	err := s.db.Select(&messages, query, OutboxPending, maxID)
+	return messages, err
+}
+
+// GetMaxOutboxID returns the ID of the newest outbox message, 0 if none.
+func (s *SubscriptionStore) GetMaxOutboxID() (int64, error) {
+	var id int64
+	err := s.db.Get(&id, `SELECT COALESCE(MAX(id), 0) FROM outbox`)
+	return id, err
+}
+
+// MarkOutboxSent records that a notification was delivered.
+func (s *SubscriptionStore) MarkOutboxSent(id int64) error {
+	query := `UPDATE outbox SET status = ?, error = '', sent_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := s.db.Exec(query, OutboxSent, id)
+	return err
+}
+
+// MarkOutboxFailed records that delivering a notification was given up.
+func (s *SubscriptionStore) MarkOutboxFailed(id int64, reason string) error {
+	_, err := s.db.Exec(`UPDATE outbox SET status = ?, error = ? WHERE id = ?`, OutboxFailed, reason, id)
+	return err
+}
+
+// GetFailedOutbox returns the latest notifications whose delivery was given
+// up, newest first.
+func (s *SubscriptionStore) GetFailedOutbox(limit int) ([]OutboxMessage, error) {
+	var messages []OutboxMessage
+	query := `SELECT * FROM outbox WHERE status = ? ORDER BY id DESC LIMIT ?`
+	err := s.db.Select(&messages, query, OutboxFailed, limit)
+	return messages, err
+}
+
+// CleanupOutbox removes delivered and failed notifications older than the
+// given number of days.
+func (s *SubscriptionStore) CleanupOutbox(daysToKeep int) (int64, error) {
+	query := `DELETE FROM outbox WHERE status != ? AND created_at < datetime('now', '-' || ? || ' days')`
+	result, err := s.db.Exec(query, OutboxPending, daysToKeep)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
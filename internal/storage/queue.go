@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reasons an event is queued instead of being delivered right away.
+const (
+	QueueReasonQuietHours   = "quiet"
+	QueueReasonDailyDigest  = DigestDaily
+	QueueReasonWeeklyDigest = DigestWeekly
+)
+
+// QueuedEvent is a notification held back for later, summarized delivery.
+type QueuedEvent struct {
+	ID        int64     `db:"id"`
+	ChatID    int64     `db:"chat_id"`
+	RepoOwner string    `db:"repo_owner"`
+	RepoName  string    `db:"repo_name"`
+	EventType string    `db:"event_type"`
+	Summary   string    `db:"summary"` // One-line Markdown summary of the event
+	Reason    string    `db:"reason"`  // See QueueReason constants
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// QueueEvent stores a notification for later delivery.
+func (s *SubscriptionStore) QueueEvent(chatID int64, repoOwner, repoName, eventType, summary, reason string) error {
+	query := `
+		INSERT INTO queued_events (chat_id, repo_owner, repo_name, event_type, summary, reason)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, chatID, repoOwner, repoName, eventType, summary, reason)
+	return err
+}
+
+// GetQueuedChats returns the chats with events queued for a reason.
+func (s *SubscriptionStore) GetQueuedChats(reason string) ([]int64, error) {
+	var chats []int64
+	err := s.db.Select(&chats, `SELECT DISTINCT chat_id FROM queued_events WHERE reason = ?`, reason)
+	return chats, err
+}
+
+// GetQueuedEvents returns the events queued for a chat, oldest first.
+func (s *SubscriptionStore) GetQueuedEvents(chatID int64, reason string) ([]QueuedEvent, error) {
+	var events []QueuedEvent
+	query := `SELECT * FROM queued_events WHERE chat_id = ? AND reason = ? ORDER BY id`
+	err := s.db.Select(&events, query, chatID, reason)
+	return events, err
+}
+
+// DeleteQueuedEvents removes queued events up to and including maxID, so events
+// queued while a summary was being sent are kept.
+func (s *SubscriptionStore) DeleteQueuedEvents(chatID int64, reason string, maxID int64) error {
+	query := `DELETE FROM queued_events WHERE chat_id = ? AND reason = ? AND id <= ?`
+	_, err := s.db.Exec(query, chatID, reason, maxID)
+	return err
+}
+
+// SetQuietHours sets the quiet hours of a chat, empty to disable them.
+func (s *SubscriptionStore) SetQuietHours(chatID int64, quietHours string) error {
+	_, err := s.db.Exec(`UPDATE chats SET quiet_hours = ? WHERE chat_id = ?`, quietHours, chatID)
+	return err
+}
+
+// QuietHours is a daily window, in minutes after midnight, during which
+// notifications are held back. The window may wrap around midnight.
+type QuietHours struct {
+	Start int
+	End   int
+}
+
+// ParseQuietHours parses a window such as "23:00-08:00".
+func ParseQuietHours(s string) (QuietHours, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("quiet hours must look like 23:00-08:00")
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	if start == end {
+		return QuietHours{}, fmt.Errorf("quiet hours must not be empty")
+	}
+	return QuietHours{Start: start, End: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("invalid hour in %q", s)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid minute in %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// Contains reports whether t falls within the quiet hours.
+func (q QuietHours) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if q.Start < q.End {
+		return minute >= q.Start && minute < q.End
+	}
+	return minute >= q.Start || minute < q.End
+}
+
+// String formats the quiet hours as "HH:MM-HH:MM".
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
@@ -0,0 +1,68 @@
+package storage
+
+import "errors"
+
+// ErrChatQuotaExceeded is returned when a chat already has as many repository
+// subscriptions as allowed.
+var ErrChatQuotaExceeded = errors.New("chat subscription limit reached")
+
+// ErrRepoQuotaExceeded is returned when subscribing would make the bot watch
+// more repositories than allowed.
+var ErrRepoQuotaExceeded = errors.New("repository limit reached")
+
+// SetQuotas limits how many repositories a chat may subscribe to and how many
+// repositories are watched in total. Zero means no limit.
+func (s *SubscriptionStore) SetQuotas(perChat, repos int) {
+	s.quotaMu.Lock()
+	defer s.quotaMu.Unlock()
+	s.maxPerChat = perChat
+	s.maxRepos = repos
+}
+
+// Quotas returns the limits set with SetQuotas.
+func (s *SubscriptionStore) Quotas() (perChat, repos int) {
+	s.quotaMu.RLock()
+	defer s.quotaMu.RUnlock()
+	return s.maxPerChat, s.maxRepos
+}
+
+// checkQuotas reports whether a new subscription of a chat to a repository
+// stays within the quotas. watched tells whether any chat is subscribed to
+// the repository already. Updating an existing subscription always does.
+func (s *SubscriptionStore) checkQuotas(chatID int64, repoOwner, repoName string, watched bool) error {
+	maxPerChat, maxRepos := s.Quotas()
+	if maxPerChat == 0 && (maxRepos == 0 || watched) {
+		return nil
+	}
+
+	var existing int
+	query := `SELECT COUNT(*) FROM subscriptions WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	if err := s.db.Get(&existing, query, chatID, repoOwner, repoName); err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+
+	if maxPerChat > 0 {
+		var count int
+		if err := s.db.Get(&count, `SELECT COUNT(*) FROM subscriptions WHERE chat_id = ?`, chatID); err != nil {
+			return err
+		}
+		if count >= maxPerChat {
+			return ErrChatQuotaExceeded
+		}
+	}
+
+	if maxRepos > 0 && !watched {
+		var count int
+		query := `SELECT COUNT(*) FROM (SELECT DISTINCT repo_owner, repo_name FROM subscriptions)`
+		if err := s.db.Get(&count, query); err != nil {
+			return err
+		}
+		if count >= maxRepos {
+			return ErrRepoQuotaExceeded
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RepoState holds per-repository polling state maintained by the poller.
+type RepoState struct {
+	RepoOwner            string       `db:"repo_owner"`
+	RepoName             string       `db:"repo_name"`
+	ActivityScore        float64      `db:"activity_score"`         // Rolling events per day
+	PendingEvents        int          `db:"pending_events"`         // Events seen since the window started
+	WindowStartedAt      time.Time    `db:"window_started_at"`      // Start of the current scoring window
+	ActivityBand         string       `db:"activity_band"`          // Empty until the first evaluation
+	PollInterval         int          `db:"poll_interval"`          // Effective interval in seconds
+	PollIntervalOverride int          `db:"poll_interval_override"` // Manual override in seconds, 0 = none
+	PollOverrideConfig   bool         `db:"poll_override_config"`   // Override set by the configuration rather than /pollinterval
+	LastPolledAt         sql.NullTime `db:"last_polled_at"`
+	Stargazers           int          `db:"stargazers"`         // Last seen star count, -1 if unknown
+	CredentialUserID     int64        `db:"credential_user_id"` // User whose linked GitHub account reads the repo, 0 for the configured tokens
+	CredentialChatID     int64        `db:"credential_chat_id"` // Chat whose token reads the repo, 0 for none
+}
+
+// GetRepoState returns the polling state of a repository, or nil if none exists.
+func (s *SubscriptionStore) GetRepoState(repoOwner, repoName string) (*RepoState, error) {
+	var state RepoState
+	query := `SELECT * FROM repo_state WHERE repo_owner = ? AND repo_name = ?`
+	err := s.db.Get(&state, query, repoOwner, repoName)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// EnsureRepoState creates the polling state of a repository if it does not exist.
+func (s *SubscriptionStore) EnsureRepoState(repoOwner, repoName string) error {
+	query := `
+		INSERT OR IGNORE INTO repo_state (repo_owner, repo_name, window_started_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+	`
+	_, err := s.db.Exec(query, repoOwner, repoName)
+	return err
+}
+
+// RecordRepoPoll records the outcome of a poll: the number of new events seen
+// and the interval the poller scheduled for the next check.
+func (s *SubscriptionStore) RecordRepoPoll(repoOwner, repoName string, events, intervalSeconds int) error {
+	query := `
+		UPDATE repo_state SET
+			pending_events = pending_events + ?,
+			poll_interval = ?,
+			last_polled_at = CURRENT_TIMESTAMP
+		WHERE repo_owner = ? AND repo_name = ?
+	`
+	_, err := s.db.Exec(query, events, intervalSeconds, repoOwner, repoName)
+	return err
+}
+
+// SetRepoPollOverride sets the poll interval of a repository in seconds,
+// 0 to derive it from the repository's activity again.
+func (s *SubscriptionStore) SetRepoPollOverride(repoOwner, repoName string, seconds int) error {
+	return s.setRepoPollOverride(repoOwner, repoName, seconds, false)
+}
+
+// SetConfigPollOverride sets the poll interval of a repository in seconds as
+// given in the configuration, see ClearConfigPollOverrides.
+func (s *SubscriptionStore) SetConfigPollOverride(repoOwner, repoName string, seconds int) error {
+	return s.setRepoPollOverride(repoOwner, repoName, seconds, true)
+}
+
+// setRepoPollOverride sets the poll interval of a repository and whether the
+// configuration set it.
+func (s *SubscriptionStore) setRepoPollOverride(repoOwner, repoName string, seconds int, config bool) error {
+	if err := s.EnsureRepoState(repoOwner, repoName); err != nil {
+		return err
+	}
+	query := `UPDATE repo_state SET poll_interval_override = ?, poll_override_config = ? WHERE repo_owner = ? AND repo_name = ?`
+	_, err := s.db.Exec(query, seconds, config, repoOwner, repoName)
+	return err
+}
+
+// ClearConfigPollOverrides removes the poll intervals set by the
+// configuration, keeping those set with /pollinterval, and returns how many
+// it removed.
+func (s *SubscriptionStore) ClearConfigPollOverrides() (int64, error) {
+	result, err := s.db.Exec(`UPDATE repo_state SET poll_interval_override = 0, poll_override_config = 0 WHERE poll_override_config = 1`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// SetRepoStargazers records the last seen star count of a repository.
+func (s *SubscriptionStore) SetRepoStargazers(repoOwner, repoName string, stars int) error {
+	query := `UPDATE repo_state SET stargazers = ? WHERE repo_owner = ? AND repo_name = ?`
+	_, err := s.db.Exec(query, stars, repoOwner, repoName)
+	return err
+}
+
+// UpdateRepoActivity stores a newly evaluated activity score and band, and
+// starts a new scoring window.
+func (s *SubscriptionStore) UpdateRepoActivity(repoOwner, repoName string, score float64, band string) error {
+	query := `
+		UPDATE repo_state SET
+			activity_score = ?,
+			activity_band = ?,
+			pending_events = 0,
+			window_started_at = CURRENT_TIMESTAMP
+		WHERE repo_owner = ? AND repo_name = ?
+	`
+	_, err := s.db.Exec(query, score, band, repoOwner, repoName)
+	return err
+}
+
+// ResetRepoActivity discards the activity history of a repository so it is
+// polled at the base interval again. Manual overrides are kept.
+func (s *SubscriptionStore) ResetRepoActivity(repoOwner, repoName string) error {
+	query := `
+		UPDATE repo_state SET
+			activity_score = 0,
+			activity_band = '',
+			pending_events = 0,
+			window_started_at = CURRENT_TIMESTAMP
+		WHERE repo_owner = ? AND repo_name = ?
+	`
+	_, err := s.db.Exec(query, repoOwner, repoName)
+	return err
+}
+
+// Watermark records up to when the poller has checked one kind of event of a
+// repository, so that polling resumes there after a restart.
+type Watermark struct {
+	RepoOwner string    `db:"repo_owner"`
+	RepoName  string    `db:"repo_name"`
+	Kind      string    `db:"kind"`    // Event type, or "events" for the events API
+	SeenAt    time.Time `db:"seen_at"` // Time up to which events were checked
+	LastID    string    `db:"last_id"` // Newest event ID seen, if the source has ordered IDs
+}
+
+// GetWatermark returns the watermark of a repository and kind of event, or
+// nil if the poller has not checked it yet.
+func (s *SubscriptionStore) GetWatermark(repoOwner, repoName, kind string) (*Watermark, error) {
+	var w Watermark
+	query := `SELECT * FROM repo_watermarks WHERE repo_owner = ? AND repo_name = ? AND kind = ?`
+	err := s.db.Get(&w, query, repoOwner, repoName, kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// HasWatermarks reports whether the poller has checked a repository before.
+func (s *SubscriptionStore) HasWatermarks(repoOwner, repoName string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM repo_watermarks WHERE repo_owner = ? AND repo_name = ?`
+	err := s.db.Get(&count, query, repoOwner, repoName)
+	return count > 0, err
+}
+
+// SetWatermark records up to when a kind of event of a repository was checked.
+func (s *SubscriptionStore) SetWatermark(repoOwner, repoName, kind string, seenAt time.Time, lastID string) error {
+	query := `
+		INSERT INTO repo_watermarks (repo_owner, repo_name, kind, seen_at, last_id)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(repo_owner, repo_name, kind) DO UPDATE SET
+			seen_at = excluded.seen_at,
+			last_id = excluded.last_id
+	`
+	_, err := s.db.Exec(query, repoOwner, repoName, kind, seenAt.UTC(), lastID)
+	return err
+}
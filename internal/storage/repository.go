@@ -0,0 +1,177 @@
+package storage
+
+import "time"
+
+// The poller, notifier and bot handlers only depend on the Store interface,
+// so another backend or an in-memory double can stand in for the SQLite
+// implementation, SubscriptionStore.
+
+// ChatRepository keeps the chats the bot talks to and their settings.
+type ChatRepository interface {
+	CreateOrUpdateChat(chatID int64, chatType, title string) error
+	GetChat(chatID int64) (*Chat, error)
+	GetChatIDs() ([]int64, error)
+	GetChats() ([]Chat, error)
+	CountInactiveChats() (int, error)
+
+	SetChatActive(chatID int64, active bool) error
+	SetChatAccess(chatID int64, access string) error
+	SetChatPaused(chatID int64, paused bool) error
+	SetChatTimezone(chatID int64, timezone string) error
+	SetChatLanguage(chatID int64, language string) error
+	SetChatParseMode(chatID int64, mode string) error
+	SetChatPhotos(chatID int64, enabled bool) error
+	SetChatDryRun(chatID int64, enabled bool) error
+	SetChatChannel(chatID, channelID int64) error
+	SetQuietHours(chatID int64, quietHours string) error
+	SetOnboardingState(chatID int64, state string) error
+	UnbindChannel(channelID int64) ([]int64, error)
+}
+
+// SubscriptionRepository keeps what chats are subscribed to: repositories,
+// GitHub users and topics.
+type SubscriptionRepository interface {
+	Subscribe(chatID int64, repoOwner, repoName string, events []EventType) error
+	Unsubscribe(chatID int64, repoOwner, repoName string) error
+	RenameRepository(oldOwner, oldName, newOwner, newName string) error
+	Quotas() (perChat, repos int)
+
+	GetSubscription(chatID int64, repoOwner, repoName string) (*Subscription, error)
+	GetSubscriptionByID(chatID, id int64) (*Subscription, error)
+	GetSubscriptionsByChat(chatID int64) ([]Subscription, error)
+	GetSubscriptionsByRepo(repoOwner, repoName string) ([]Subscription, error)
+	GetActiveSubscriptionsByRepo(repoOwner, repoName string) ([]Subscription, error)
+	GetAllSubscribedRepos() ([][2]string, error)
+
+	GetSubscribedEvents(chatID int64, repoOwner, repoName string) ([]EventType, error)
+	SetSubscribedEvents(id int64, events []EventType) error
+	GetSubscriptionFilters(chatID int64, repoOwner, repoName string) (*SubscriptionFilters, error)
+	SetSubscriptionFilters(chatID int64, repoOwner, repoName string, filters SubscriptionFilters) error
+	SetSubscriptionTemplate(chatID int64, repoOwner, repoName string, event EventType, text string) error
+	SetSubscriptionDigest(chatID int64, repoOwner, repoName, digest string) error
+	SetSubscriptionSilent(chatID int64, repoOwner, repoName string, events []EventType) error
+	SetSubscriptionSink(chatID int64, repoOwner, repoName, sink string) error
+	MuteSubscription(chatID int64, repoOwner, repoName string, until time.Time) error
+	SetComplianceViolation(id int64, violation string) error
+	AcknowledgeCompliance(chatID, id int64) (*Subscription, error)
+
+	SubscribeUser(chatID int64, login string, events []EventType) (*UserSubscription, error)
+	UnsubscribeUser(chatID int64, login string) (int64, error)
+	GetUserSubscriptions() ([]UserSubscription, error)
+	SubscribeVia(chatID int64, repoOwner, repoName, events, via string) (bool, error)
+	MarkUserSynced(id int64, at time.Time) error
+
+	SubscribeTopic(chatID int64, topic string) error
+	UnsubscribeTopic(chatID int64, topic string) error
+	GetTopicSubscriptionsByChat(chatID int64) ([]TopicSubscription, error)
+	GetSubscribedTopics() ([]string, error)
+	GetActiveTopicSubscribers(topic string) ([]int64, error)
+
+	ExportChat(chatID int64) (*ChatExport, error)
+	ImportChat(chatID int64, export *ChatExport, validate func(ExportedSubscription) error) (ImportResult, error)
+}
+
+// EventRepository keeps what happened to events: the webhook deliveries
+// waiting to be handled, which were processed, how far each repository was
+// polled, and the notifications waiting for delivery.
+type EventRepository interface {
+	RecordEvent(repoOwner, repoName, eventType, eventID string) error
+	IsEventProcessed(repoOwner, repoName, eventType, eventID string) (bool, error)
+	CleanupOldEvents(daysToKeep int) (int64, error)
+
+	RecordHistory(repoOwner, repoName, eventType, payload, source string, occurredAt time.Time) error
+	GetHistory(repoOwner, repoName string, limit int) ([]HistoryEntry, error)
+	GetRecentHistory(limit int) ([]HistoryEntry, error)
+	CleanupHistory(daysToKeep int) (int64, error)
+
+	GetWatermark(repoOwner, repoName, kind string) (*Watermark, error)
+	HasWatermarks(repoOwner, repoName string) (bool, error)
+	SetWatermark(repoOwner, repoName, kind string, seenAt time.Time, lastID string) error
+
+	GetRepoState(repoOwner, repoName string) (*RepoState, error)
+	EnsureRepoState(repoOwner, repoName string) error
+	RecordRepoPoll(repoOwner, repoName string, events, intervalSeconds int) error
+	SetRepoPollOverride(repoOwner, repoName string, seconds int) error
+	SetConfigPollOverride(repoOwner, repoName string, seconds int) error
+	ClearConfigPollOverrides() (int64, error)
+	SetRepoStargazers(repoOwner, repoName string, stars int) error
+	UpdateRepoActivity(repoOwner, repoName string, score float64, band string) error
+	ResetRepoActivity(repoOwner, repoName string) error
+
+	AddOutboxMessage(m *OutboxMessage) (int64, error)
+	GetPendingOutbox(maxID int64) ([]OutboxMessage, error)
+	GetMaxOutboxID() (int64, error)
+	MarkOutboxSent(id int64) error
+	MarkOutboxFailed(id int64, reason string) error
+	GetFailedOutbox(limit int) ([]OutboxMessage, error)
+	CleanupOutbox(daysToKeep int) (int64, error)
+
+	AddInboundEvent(e *InboundEvent) (int64, error)
+	ClaimInboundEvents(holder string, ttl time.Duration, limit int) ([]InboundEvent, error)
+	RenewInboundClaims(holder string, ttl time.Duration) error
+	RetryInboundEvent(id int64, backoff time.Duration) error
+	DeleteInboundEvent(id int64) error
+
+	QueueEvent(chatID int64, repoOwner, repoName, eventType, summary, reason string) error
+	GetQueuedChats(reason string) ([]int64, error)
+	GetQueuedEvents(chatID int64, reason string) ([]QueuedEvent, error)
+	DeleteQueuedEvents(chatID int64, reason string, maxID int64) error
+
+	RecordNotification(chatID int64, repoOwner, repoName, eventType string, at time.Time) error
+	GetNotificationCounts(chatID int64, since time.Time) ([]NotificationCount, error)
+}
+
+// AuditRepository keeps the commands received.
+type AuditRepository interface {
+	RecordAudit(entry *AuditEntry) error
+	GetAuditLog(filter AuditFilter, limit int) ([]AuditEntry, error)
+	CleanupAuditLog(daysToKeep int) (int64, error)
+}
+
+// CustomHookRepository keeps the webhook URLs chats created for other
+// services.
+type CustomHookRepository interface {
+	CreateCustomHook(chatID int64, name string) (*CustomHook, string, error)
+	GetCustomHook(chatID int64, name string) (*CustomHook, error)
+	GetCustomHookToken(hook *CustomHook) (string, error)
+	GetCustomHookByToken(token string) (*CustomHook, error)
+	GetCustomHooksByChat(chatID int64) ([]CustomHook, error)
+	SetCustomHookTemplate(chatID int64, name, template string) error
+	MarkCustomHookUsed(id int64) error
+	DeleteCustomHook(chatID int64, name string) (bool, error)
+}
+
+// GitHubAccountRepository keeps the GitHub accounts users linked and which
+// repositories are read with them.
+type GitHubAccountRepository interface {
+	SaveGitHubAccount(userID int64, login, token, scopes string) error
+	GetGitHubAccount(userID int64) (*GitHubAccount, error)
+	GetGitHubToken(userID int64) (string, error)
+	DeleteGitHubAccount(userID int64) (bool, error)
+	SetRepoCredential(repoOwner, repoName string, userID int64) error
+}
+
+// ChatTokenRepository keeps the GitHub tokens chats set and which
+// repositories are read with them.
+type ChatTokenRepository interface {
+	SaveChatToken(chatID int64, login, token string) error
+	GetChatToken(chatID int64) (*ChatToken, error)
+	GetChatGitHubToken(chatID int64) (string, error)
+	DeleteChatToken(chatID int64) (bool, error)
+	SetRepoChatCredential(repoOwner, repoName string, chatID int64) error
+}
+
+// Store is everything the bot keeps.
+type Store interface {
+	ChatRepository
+	SubscriptionRepository
+	EventRepository
+	AuditRepository
+	CustomHookRepository
+	GitHubAccountRepository
+	ChatTokenRepository
+
+	GetBotStats(since time.Time) (*BotStats, error)
+}
+
+var _ Store = (*SubscriptionStore)(nil)
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Secrets such as the GitHub tokens of linked accounts are stored encrypted
+// with AES-GCM, so that a copy of the database or one of its backups does not
+// give them away without the key from the configuration.
+//
+// To rotate the key, the new one is configured and the old one kept among
+// the previous keys: secrets are sealed with the new key and tagged with its
+// ID, while the old one still opens those sealed before. RotateSecrets seals
+// them again with the new key, after which the old one can be dropped.
+
+// ErrNoEncryptionKey is returned when storing or reading a secret without an
+// encryption key configured.
+var ErrNoEncryptionKey = errors.New("no encryption key configured")
+
+// Sealed secrets start with their format: "v2:<key ID>:" followed by the
+// base64 of the nonce and the sealed text. Secrets of the first format,
+// "v1:", carry no key ID and are tried with every key.
+const (
+	secretPrefixV1 = "v1:"
+	secretPrefixV2 = "v2:"
+)
+
+// Cipher encrypts secrets at rest with the current key, and decrypts them
+// with whichever of its keys they were sealed with.
+type Cipher struct {
+	current *secretKey
+	keys    []*secretKey // The current key first
+}
+
+// secretKey is an encryption key and its ID.
+type secretKey struct {
+	id   string // First bytes of the key's SHA-256, in hex
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a base64 encoded 32 byte key, as created by
+// "openssl rand -base64 32". Secrets sealed with one of the previous keys can
+// still be decrypted.
+func NewCipher(key string, previous ...string) (*Cipher, error) {
+	c := &Cipher{}
+	for i, k := range append([]string{key}, previous...) {
+		parsed, err := newSecretKey(k)
+		if err != nil {
+			if i > 0 {
+				return nil, fmt.Errorf("previous encryption key %d: %w", i, err)
+			}
+			return nil, err
+		}
+		c.keys = append(c.keys, parsed)
+	}
+	c.current = c.keys[0]
+	return c, nil
+}
+
+// newSecretKey parses a base64 encoded 32 byte key.
+func newSecretKey(key string) (*secretKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("invalid encryption key: got %d bytes, want 32", len(raw))
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(raw)
+	return &secretKey{id: hex.EncodeToString(sum[:4]), aead: aead}, nil
+}
+
+// KeyID returns the ID of the current key, which tags the secrets it seals.
+func (c *Cipher) KeyID() string {
+	return c.current.id
+}
+
+// Encrypt seals a secret with the current key and a random nonce.
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	aead := c.current.aead
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return secretPrefixV2 + c.current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a secret sealed by Encrypt with any of the cipher's keys.
+func (c *Cipher) Decrypt(ciphertext string) (string, error) {
+	if encoded, ok := strings.CutPrefix(ciphertext, secretPrefixV1); ok {
+		var err error
+		for _, key := range c.keys {
+			var plaintext string
+			if plaintext, err = key.open(encoded); err == nil {
+				return plaintext, nil
+			}
+		}
+		return "", err
+	}
+
+	rest, ok := strings.CutPrefix(ciphertext, secretPrefixV2)
+	if !ok {
+		return "", errors.New("unknown secret format")
+	}
+	id, encoded, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", errors.New("invalid secret: no key ID")
+	}
+	for _, key := range c.keys {
+		if key.id == id {
+			return key.open(encoded)
+		}
+	}
+	return "", fmt.Errorf("secret sealed with unknown key %s", id)
+}
+
+// Current reports whether a secret is sealed with the current key, so it
+// needs no rotation.
+func (c *Cipher) Current(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, secretPrefixV2+c.current.id+":")
+}
+
+// open opens the base64 of a nonce and the text sealed with the key.
+func (k *secretKey) open(encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+	size := k.aead.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("invalid secret: too short")
+	}
+	plaintext, err := k.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// SetCipher sets the cipher secrets are stored with. Without one, linking
+// GitHub accounts and setting chat tokens fail with ErrNoEncryptionKey.
+func (s *SubscriptionStore) SetCipher(c *Cipher) {
+	s.cipher = c
+}
+
+// encrypt seals a secret with the store's cipher.
+func (s *SubscriptionStore) encrypt(plaintext string) (string, error) {
+	if s.cipher == nil {
+		return "", ErrNoEncryptionKey
+	}
+	return s.cipher.Encrypt(plaintext)
+}
+
+// decrypt opens a secret sealed with the store's cipher.
+func (s *SubscriptionStore) decrypt(ciphertext string) (string, error) {
+	if s.cipher == nil {
+		return "", ErrNoEncryptionKey
+	}
+	return s.cipher.Decrypt(ciphertext)
+}
+
+// RotateSecrets seals the stored secrets again with the current key, when
+// they were sealed with a previous one: the tokens of linked GitHub accounts,
+// those chats set and those of custom hooks. Hook tokens earlier versions
+// kept in plain text are sealed as well. It returns how many were rotated; a
+// secret none of the keys opens is left as it is and reported in the error,
+// while the others are still rotated.
+func (s *SubscriptionStore) RotateSecrets() (int, error) {
+	if s.cipher == nil {
+		return 0, ErrNoEncryptionKey
+	}
+
+	rotated, err := s.sealCustomHookTokens()
+	if err != nil {
+		return rotated, err
+	}
+	var errs []error
+	for _, t := range []struct{ table, key, what string }{
+		{"github_accounts", "user_id", "github account of user"},
+		{"chat_tokens", "chat_id", "github token of chat"},
+		{"custom_hooks", "id", "custom hook"},
+	} {
+		n, err := s.rotateTokens(t.table, t.key, t.what)
+		rotated += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return rotated, errors.Join(errs...)
+}
+
+// rotateTokens seals the token column of a table again with the current
+// key, see RotateSecrets. Rows are identified by their key column and
+// described as what in errors.
+func (s *SubscriptionStore) rotateTokens(table, key, what string) (int, error) {
+	var rows []struct {
+		ID    int64  `db:"id"`
+		Token string `db:"token"`
+	}
+	query := fmt.Sprintf(`SELECT %s AS id, token FROM %s`, key, table)
+	if table == "custom_hooks" {
+		// Hooks kept as a hash only have no secret to seal
+		query += ` WHERE token != token_hash`
+	}
+	if err := s.db.Select(&rows, query); err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	var errs []error
+	for _, row := range rows {
+		if s.cipher.Current(row.Token) {
+			continue
+		}
+		token, err := s.cipher.Decrypt(row.Token)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %d: %w", what, row.ID, err))
+			continue
+		}
+		sealed, err := s.cipher.Encrypt(token)
+		if err != nil {
+			return rotated, err
+		}
+		// Unless the token was replaced meanwhile
+		query := fmt.Sprintf(`UPDATE %s SET token = ? WHERE %s = ? AND token = ?`, table, key)
+		result, err := s.db.Exec(query, sealed, row.ID, row.Token)
+		if err != nil {
+			return rotated, err
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			rotated++
+		}
+	}
+	return rotated, errors.Join(errs...)
+}
@@ -0,0 +1,255 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Setting keys that can be tweaked at runtime by administrators.
+const (
+	SettingPollInterval = "poll_interval" // Poll interval override in seconds
+)
+
+// Internal setting keys, never editable from chat or the API.
+const (
+	SettingPollerLeader = "poller.leader"        // Lease held by the instance running the poller
+	SettingLastDigest   = "notifier.last_digest" // When digests were last checked for delivery
+)
+
+// editableSettings is the allow-list of keys that may be changed from chat or
+// the admin API, together with a validator for their values.
+var editableSettings = map[string]func(value string) error{
+	SettingPollInterval: func(value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be an integer number of seconds")
+		}
+		if n != 0 && n < 60 {
+			return fmt.Errorf("must be 0 (use config) or at least 60 seconds")
+		}
+		return nil
+	},
+}
+
+// ErrSettingNotEditable is returned when a key is not on the allow-list.
+var ErrSettingNotEditable = errors.New("setting is not editable")
+
+// EditableSettingKeys returns the sorted list of runtime-editable setting keys.
+func EditableSettingKeys() []string {
+	keys := make([]string, 0, len(editableSettings))
+	for k := range editableSettings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// IsEditableSetting reports whether a key may be changed at runtime.
+func IsEditableSetting(key string) bool {
+	_, ok := editableSettings[key]
+	return ok
+}
+
+// ValidateSetting checks that a key is editable and its value is acceptable.
+func ValidateSetting(key, value string) error {
+	validate, ok := editableSettings[key]
+	if !ok {
+		return ErrSettingNotEditable
+	}
+	return validate(value)
+}
+
+// SettingsStore provides typed access to the bot-wide key/value settings.
+type SettingsStore struct {
+	db *Database
+}
+
+// NewSettingsStore creates a new settings store.
+func NewSettingsStore(db *Database) *SettingsStore {
+	return &SettingsStore{db: db}
+}
+
+// get returns the raw value for a key and whether it exists.
+func (s *SettingsStore) get(key string) (string, bool, error) {
+	var value string
+	err := s.db.Get(&value, `SELECT value FROM settings WHERE key = ?`, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get setting %q: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// set stores the raw value for a key.
+func (s *SettingsStore) set(key, value string) error {
+	query := `
+		INSERT INTO settings (key, value, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET
+			value = excluded.value,
+			updated_at = excluded.updated_at
+	`
+	if _, err := s.db.Exec(query, key, value); err != nil {
+		return fmt.Errorf("failed to set setting %q: %w", key, err)
+	}
+	return nil
+}
+
+// Delete removes a setting.
+func (s *SettingsStore) Delete(key string) error {
+	if _, err := s.db.Exec(`DELETE FROM settings WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete setting %q: %w", key, err)
+	}
+	return nil
+}
+
+// GetString returns a string setting, or def if it is not set.
+func (s *SettingsStore) GetString(key, def string) (string, error) {
+	value, ok, err := s.get(key)
+	if err != nil || !ok {
+		return def, err
+	}
+	return value, nil
+}
+
+// SetString stores a string setting.
+func (s *SettingsStore) SetString(key, value string) error {
+	return s.set(key, value)
+}
+
+// GetInt returns an integer setting, or def if it is not set.
+func (s *SettingsStore) GetInt(key string, def int) (int, error) {
+	value, ok, err := s.get(key)
+	if err != nil || !ok {
+		return def, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return def, fmt.Errorf("setting %q is not an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+// SetInt stores an integer setting.
+func (s *SettingsStore) SetInt(key string, value int) error {
+	return s.set(key, strconv.Itoa(value))
+}
+
+// GetBool returns a boolean setting, or def if it is not set.
+func (s *SettingsStore) GetBool(key string, def bool) (bool, error) {
+	value, ok, err := s.get(key)
+	if err != nil || !ok {
+		return def, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return def, fmt.Errorf("setting %q is not a boolean: %w", key, err)
+	}
+	return b, nil
+}
+
+// SetBool stores a boolean setting.
+func (s *SettingsStore) SetBool(key string, value bool) error {
+	return s.set(key, strconv.FormatBool(value))
+}
+
+// GetTime returns a time setting, or def if it is not set.
+func (s *SettingsStore) GetTime(key string, def time.Time) (time.Time, error) {
+	value, ok, err := s.get(key)
+	if err != nil || !ok {
+		return def, err
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return def, fmt.Errorf("setting %q is not a timestamp: %w", key, err)
+	}
+	return t, nil
+}
+
+// SetTime stores a time setting.
+func (s *SettingsStore) SetTime(key string, value time.Time) error {
+	return s.set(key, value.UTC().Format(time.RFC3339Nano))
+}
+
+// GetJSON decodes a JSON setting into v. It reports false if the key is not set,
+// in which case v is left untouched.
+func (s *SettingsStore) GetJSON(key string, v interface{}) (bool, error) {
+	value, ok, err := s.get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, fmt.Errorf("failed to unmarshal setting %q: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetJSON stores v as a JSON setting.
+func (s *SettingsStore) SetJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal setting %q: %w", key, err)
+	}
+	return s.set(key, string(data))
+}
+
+// SetEditable validates and stores an allow-listed setting.
+func (s *SettingsStore) SetEditable(key, value string) error {
+	if err := ValidateSetting(key, value); err != nil {
+		return err
+	}
+	return s.set(key, value)
+}
+
+// AcquireLease claims or renews a named lease for holder until now+ttl. The
+// lease is granted only if it is free, expired or already held by holder, which
+// lets several instances share a database while only one acts as leader.
+func (s *SettingsStore) AcquireLease(key, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	value, err := json.Marshal(lease{Holder: holder, ExpiresAt: now.Add(ttl).Unix()})
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal lease %q: %w", key, err)
+	}
+
+	query := `
+		INSERT INTO settings (key, value, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(key) DO UPDATE SET
+			value = excluded.value,
+			updated_at = excluded.updated_at
+		WHERE json_extract(settings.value, '$.holder') = ?
+			OR json_extract(settings.value, '$.expires_at') < ?
+	`
+	result, err := s.db.Exec(query, key, string(value), holder, now.Unix())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %q: %w", key, err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %q: %w", key, err)
+	}
+	return rows > 0, nil
+}
+
+// ReleaseLease gives up a lease if it is held by holder.
+func (s *SettingsStore) ReleaseLease(key, holder string) error {
+	query := `DELETE FROM settings WHERE key = ? AND json_extract(value, '$.holder') = ?`
+	if _, err := s.db.Exec(query, key, holder); err != nil {
+		return fmt.Errorf("failed to release lease %q: %w", key, err)
+	}
+	return nil
+}
+
+// lease is the JSON value stored for a lease.
+type lease struct {
+	Holder    string `json:"holder"`
+	ExpiresAt int64  `json:"expires_at"`
+}
@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSettingsRoundTrip(t *testing.T) {
+	s := NewSettingsStore(newTestDatabase(t))
+
+	t.Run("string", func(t *testing.T) {
+		if got, err := s.GetString("name", "default"); err != nil || got != "default" {
+			t.Fatalf("GetString() = %q, %v before it was set, want the default", got, err)
+		}
+		for _, value := range []string{"octocat", "", "ünïcödé 🚀", `quotes " and ' and \`} {
+			if err := s.SetString("name", value); err != nil {
+				t.Fatalf("SetString(%q) error = %v", value, err)
+			}
+			if got, err := s.GetString("name", "default"); err != nil || got != value {
+				t.Errorf("GetString() = %q, %v, want %q", got, err, value)
+			}
+		}
+	})
+
+	t.Run("int", func(t *testing.T) {
+		if got, err := s.GetInt("count", 7); err != nil || got != 7 {
+			t.Fatalf("GetInt() = %d, %v before it was set, want the default", got, err)
+		}
+		for _, value := range []int{0, -1, 300, 1 << 40} {
+			if err := s.SetInt("count", value); err != nil {
+				t.Fatalf("SetInt(%d) error = %v", value, err)
+			}
+			if got, err := s.GetInt("count", 7); err != nil || got != value {
+				t.Errorf("GetInt() = %d, %v, want %d", got, err, value)
+			}
+		}
+	})
+
+	t.Run("bool", func(t *testing.T) {
+		if got, err := s.GetBool("flag", true); err != nil || !got {
+			t.Fatalf("GetBool() = %v, %v before it was set, want the default", got, err)
+		}
+		for _, value := range []bool{false, true} {
+			if err := s.SetBool("flag", value); err != nil {
+				t.Fatalf("SetBool(%v) error = %v", value, err)
+			}
+			if got, err := s.GetBool("flag", !value); err != nil || got != value {
+				t.Errorf("GetBool() = %v, %v, want %v", got, err, value)
+			}
+		}
+	})
+
+	t.Run("time", func(t *testing.T) {
+		def := time.Unix(0, 0)
+		if got, err := s.GetTime("at", def); err != nil || !got.Equal(def) {
+			t.Fatalf("GetTime() = %v, %v before it was set, want the default", got, err)
+		}
+		shanghai := time.FixedZone("CST", 8*60*60)
+		value := time.Date(2024, 2, 29, 23, 59, 59, 123456789, shanghai)
+		if err := s.SetTime("at", value); err != nil {
+			t.Fatalf("SetTime() error = %v", err)
+		}
+		got, err := s.GetTime("at", def)
+		if err != nil || !got.Equal(value) {
+			t.Errorf("GetTime() = %v, %v, want %v", got, err, value)
+		}
+		if got.Location() != time.UTC {
+			t.Errorf("GetTime() location = %v, want UTC", got.Location())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		type config struct {
+			Repos   []string          `json:"repos"`
+			Labels  map[string]string `json:"labels"`
+			Enabled bool              `json:"enabled"`
+		}
+		var missing config
+		if ok, err := s.GetJSON("config", &missing); err != nil || ok {
+			t.Fatalf("GetJSON() = %v, %v before it was set, want false", ok, err)
+		}
+		value := config{Repos: []string{"acme/app", "acme/lib"}, Labels: map[string]string{"bug": "🐛"}, Enabled: true}
+		if err := s.SetJSON("config", value); err != nil {
+			t.Fatalf("SetJSON() error = %v", err)
+		}
+		var got config
+		if ok, err := s.GetJSON("config", &got); err != nil || !ok || !reflect.DeepEqual(got, value) {
+			t.Errorf("GetJSON() = %+v, %v, %v, want %+v", got, ok, err, value)
+		}
+		if err := s.SetJSON("config", func() {}); err == nil {
+			t.Error("SetJSON() accepted a value JSON cannot encode")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := s.SetString("gone", "value"); err != nil {
+			t.Fatalf("SetString() error = %v", err)
+		}
+		if err := s.Delete("gone"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if got, err := s.GetString("gone", "default"); err != nil || got != "default" {
+			t.Errorf("GetString() = %q, %v after Delete, want the default", got, err)
+		}
+		if err := s.Delete("never-set"); err != nil {
+			t.Errorf("Delete() of a missing key error = %v", err)
+		}
+	})
+}
+
+func TestSettingsWrongType(t *testing.T) {
+	s := NewSettingsStore(newTestDatabase(t))
+	if err := s.SetString("value", "not a number"); err != nil {
+		t.Fatalf("SetString() error = %v", err)
+	}
+
+	if got, err := s.GetInt("value", 5); err == nil || got != 5 {
+		t.Errorf("GetInt() = %d, %v, want the default and an error", got, err)
+	}
+	if got, err := s.GetBool("value", true); err == nil || !got {
+		t.Errorf("GetBool() = %v, %v, want the default and an error", got, err)
+	}
+	def := time.Unix(1, 0)
+	if got, err := s.GetTime("value", def); err == nil || !got.Equal(def) {
+		t.Errorf("GetTime() = %v, %v, want the default and an error", got, err)
+	}
+	var v map[string]int
+	if ok, err := s.GetJSON("value", &v); err == nil || ok {
+		t.Errorf("GetJSON() = %v, %v, want false and an error", ok, err)
+	}
+}
+
+func TestSettingsConcurrentUpserts(t *testing.T) {
+	s := NewSettingsStore(newTestDatabase(t))
+
+	const writers = 20
+	const writes = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*writes*2)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				// Everyone writes the shared key, and each its own
+				if err := s.SetInt("shared", w*writes+i); err != nil {
+					errs <- err
+				}
+				if err := s.SetInt(fmt.Sprintf("own.%d", w), i); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("concurrent set error = %v", err)
+	}
+
+	shared, err := s.GetInt("shared", -1)
+	if err != nil || shared < 0 || shared >= writers*writes {
+		t.Errorf("GetInt(shared) = %d, %v, want one of the written values", shared, err)
+	}
+	for w := 0; w < writers; w++ {
+		if got, err := s.GetInt(fmt.Sprintf("own.%d", w), -1); err != nil || got != writes-1 {
+			t.Errorf("GetInt(own.%d) = %d, %v, want %d", w, got, err, writes-1)
+		}
+	}
+
+	var rows int
+	if err := s.db.Get(&rows, `SELECT COUNT(*) FROM settings WHERE key = 'shared'`); err != nil || rows != 1 {
+		t.Errorf("shared key stored in %d rows, %v, want 1", rows, err)
+	}
+}
+
+func TestSetEditable(t *testing.T) {
+	s := NewSettingsStore(newTestDatabase(t))
+
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		wantErr error // nil for any error when invalid is set
+		invalid bool
+	}{
+		{"valid interval", SettingPollInterval, "300", nil, false},
+		{"reset to config", SettingPollInterval, "0", nil, false},
+		{"interval too short", SettingPollInterval, "30", nil, true},
+		{"negative interval", SettingPollInterval, "-60", nil, true},
+		{"interval not a number", SettingPollInterval, "5m", nil, true},
+		{"unknown key", "telegram.token", "123:abc", ErrSettingNotEditable, true},
+		{"internal key", SettingPollerLeader, `{"holder":"me","expires_at":9999999999}`, ErrSettingNotEditable, true},
+		{"empty key", "", "1", ErrSettingNotEditable, true},
+		{"key differing in case", "POLL_INTERVAL", "300", ErrSettingNotEditable, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _, err := s.get(tt.key)
+			if err != nil {
+				t.Fatalf("get() error = %v", err)
+			}
+
+			err = s.SetEditable(tt.key, tt.value)
+			if !tt.invalid {
+				if err != nil {
+					t.Fatalf("SetEditable() error = %v", err)
+				}
+				if got, _ := s.GetString(tt.key, ""); got != tt.value {
+					t.Errorf("stored %q, want %q", got, tt.value)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("SetEditable(%q, %q) accepted", tt.key, tt.value)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetEditable() error = %v, want %v", err, tt.wantErr)
+			}
+			if after, _, _ := s.get(tt.key); after != before {
+				t.Errorf("rejected value changed the setting from %q to %q", before, after)
+			}
+		})
+	}
+}
+
+func TestEditableSettingKeys(t *testing.T) {
+	keys := EditableSettingKeys()
+	if !sort.StringsAreSorted(keys) || !slices.Contains(keys, SettingPollInterval) {
+		t.Errorf("EditableSettingKeys() = %v, want the sorted keys with %q", keys, SettingPollInterval)
+	}
+	for _, key := range keys {
+		if !IsEditableSetting(key) {
+			t.Errorf("IsEditableSetting(%q) = false", key)
+		}
+	}
+	if IsEditableSetting(SettingPollerLeader) {
+		t.Errorf("IsEditableSetting(%q) = true for an internal key", SettingPollerLeader)
+	}
+}
+
+func TestLease(t *testing.T) {
+	s := NewSettingsStore(newTestDatabase(t))
+	acquire := func(holder string, ttl time.Duration) bool {
+		t.Helper()
+		ok, err := s.AcquireLease(SettingPollerLeader, holder, ttl)
+		if err != nil {
+			t.Fatalf("AcquireLease(%q) error = %v", holder, err)
+		}
+		return ok
+	}
+
+	if !acquire("old", time.Minute) {
+		t.Fatal("free lease not granted")
+	}
+	if acquire("new", time.Minute) {
+		t.Fatal("lease granted while another holder has it")
+	}
+	if !acquire("old", time.Minute) {
+		t.Fatal("holder could not renew its lease")
+	}
+
+	// Releasing hands it over right away, and only the holder can release it
+	if err := s.ReleaseLease(SettingPollerLeader, "new"); err != nil {
+		t.Fatalf("ReleaseLease() error = %v", err)
+	}
+	if acquire("new", time.Minute) {
+		t.Fatal("lease released by someone not holding it")
+	}
+	if err := s.ReleaseLease(SettingPollerLeader, "old"); err != nil {
+		t.Fatalf("ReleaseLease() error = %v", err)
+	}
+	if !acquire("new", time.Minute) {
+		t.Fatal("released lease not granted")
+	}
+	if acquire("old", time.Minute) {
+		t.Fatal("previous holder took the lease back")
+	}
+
+	// A holder that stops renewing loses it once it expires
+	if !acquire("new", -2*time.Second) {
+		t.Fatal("holder could not renew its lease")
+	}
+	if !acquire("old", time.Minute) {
+		t.Error("expired lease not granted")
+	}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import "time"
+
+// Notifications are counted per chat, repository, event type and day, so
+// that chats can see which of their subscriptions are the noisiest.
+
+// NotificationCount is how many notifications of one event type a chat got
+// for a repository.
+type NotificationCount struct {
+	RepoOwner string `db:"repo_owner"`
+	RepoName  string `db:"repo_name"`
+	EventType string `db:"event_type"`
+	Count     int    `db:"count"`
+}
+
+// RecordNotification counts a notification delivered to a chat.
+func (s *SubscriptionStore) RecordNotification(chatID int64, repoOwner, repoName, eventType string, at time.Time) error {
+	query := `
+		INSERT INTO notification_stats (chat_id, repo_owner, repo_name, event_type, day, count)
+		VALUES (?, ?, ?, ?, ?, 1)
+		ON CONFLICT(chat_id, repo_owner, repo_name, event_type, day) DO UPDATE SET
+			count = count + 1
+	`
+	_, err := s.db.Exec(query, chatID, repoOwner, repoName, eventType, at.UTC().Format("2006-01-02"))
+	return err
+}
+
+// GetNotificationCounts returns the notifications a chat got since the given
+// day, per repository and event type, the largest counts first.
+func (s *SubscriptionStore) GetNotificationCounts(chatID int64, since time.Time) ([]NotificationCount, error) {
+	var counts []NotificationCount
+	query := `
+		SELECT repo_owner, repo_name, event_type, SUM(count) AS count
+		FROM notification_stats
+		WHERE chat_id = ? AND day >= ?
+		GROUP BY repo_owner, repo_name, event_type
+		ORDER BY count DESC, repo_owner, repo_name
+	`
+	err := s.db.Select(&counts, query, chatID, since.UTC().Format("2006-01-02"))
+	return counts, err
+}
+
+// BotStats are totals over all chats for administrators.
+type BotStats struct {
+	Chats         int `db:"chats"`
+	InactiveChats int `db:"inactive_chats"`
+	Subscriptions int `db:"subscriptions"`
+	Repos         int `db:"repos"`
+	EventsRecent  int `db:"events_recent"`  // Events processed since the given time
+	PendingOutbox int `db:"pending_outbox"` // Notifications waiting for delivery
+	QueuedEvents  int `db:"queued_events"`  // Notifications held back for quiet hours and digests
+	UserFollows   int `db:"user_follows"`   // Subscriptions to GitHub users
+	TopicFollows  int `db:"topic_follows"`  // Subscriptions to GitHub topics
+}
+
+// GetBotStats returns totals over all chats, counting processed events since
+// the given time.
+func (s *SubscriptionStore) GetBotStats(since time.Time) (*BotStats, error) {
+	var stats BotStats
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM chats) AS chats,
+			(SELECT COUNT(*) FROM chats WHERE active = 0) AS inactive_chats,
+			(SELECT COUNT(*) FROM subscriptions) AS subscriptions,
+			(SELECT COUNT(*) FROM (SELECT DISTINCT repo_owner, repo_name FROM subscriptions)) AS repos,
+			(SELECT COUNT(*) FROM event_records WHERE created_at >= ?) AS events_recent,
+			(SELECT COUNT(*) FROM outbox WHERE status = ?) AS pending_outbox,
+			(SELECT COUNT(*) FROM queued_events) AS queued_events,
+			(SELECT COUNT(*) FROM user_subscriptions) AS user_follows,
+			(SELECT COUNT(*) FROM topic_subscriptions) AS topic_follows
+	`
+	err := s.db.Get(&stats, query, since.UTC().Format("2006-01-02 15:04:05"), OutboxPending)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
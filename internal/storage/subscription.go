@@ -5,11 +5,19 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 )
 
-// SubscriptionStore handles subscription-related database operations.
+// SubscriptionStore implements Store on the SQLite database.
 type SubscriptionStore struct {
 	db *Database
+
+	quotaMu    sync.RWMutex
+	maxPerChat int // See SetQuotas
+	maxRepos   int
+
+	cipher *Cipher // See SetCipher
 }
 
 // NewSubscriptionStore creates a new subscription store.
@@ -30,6 +38,115 @@ func (s *SubscriptionStore) CreateOrUpdateChat(chatID int64, chatType, title str
 	return err
 }
 
+// GetChat returns a chat record, or nil if the chat is unknown.
+func (s *SubscriptionStore) GetChat(chatID int64) (*Chat, error) {
+	var chat Chat
+	err := s.db.Get(&chat, `SELECT * FROM chats WHERE chat_id = ?`, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// GetChatIDs returns the IDs of all chats.
+func (s *SubscriptionStore) GetChatIDs() ([]int64, error) {
+	var chatIDs []int64
+	err := s.db.Select(&chatIDs, `SELECT chat_id FROM chats ORDER BY chat_id`)
+	return chatIDs, err
+}
+
+// GetChats returns all chats, ordered by ID.
+func (s *SubscriptionStore) GetChats() ([]Chat, error) {
+	var chats []Chat
+	err := s.db.Select(&chats, `SELECT * FROM chats ORDER BY chat_id`)
+	return chats, err
+}
+
+// SetChatActive marks whether notifications should be delivered to a chat.
+func (s *SubscriptionStore) SetChatActive(chatID int64, active bool) error {
+	_, err := s.db.Exec(`UPDATE chats SET active = ? WHERE chat_id = ?`, active, chatID)
+	return err
+}
+
+// CountInactiveChats returns how many chats the bot can no longer deliver to.
+func (s *SubscriptionStore) CountInactiveChats() (int, error) {
+	var count int
+	err := s.db.Get(&count, `SELECT COUNT(*) FROM chats WHERE active = 0`)
+	return count, err
+}
+
+// SetChatAccess records whether a chat was approved to use the bot.
+func (s *SubscriptionStore) SetChatAccess(chatID int64, access string) error {
+	_, err := s.db.Exec(`UPDATE chats SET access = ? WHERE chat_id = ?`, access, chatID)
+	return err
+}
+
+// SetChatPaused pauses or resumes all notifications to a chat.
+func (s *SubscriptionStore) SetChatPaused(chatID int64, paused bool) error {
+	_, err := s.db.Exec(`UPDATE chats SET paused = ? WHERE chat_id = ?`, paused, chatID)
+	return err
+}
+
+// SetChatTimezone sets the time zone of a chat, empty for server time.
+func (s *SubscriptionStore) SetChatTimezone(chatID int64, timezone string) error {
+	_, err := s.db.Exec(`UPDATE chats SET timezone = ? WHERE chat_id = ?`, timezone, chatID)
+	return err
+}
+
+// SetChatLanguage sets the language of a chat, empty for the default.
+func (s *SubscriptionStore) SetChatLanguage(chatID int64, language string) error {
+	_, err := s.db.Exec(`UPDATE chats SET language = ? WHERE chat_id = ?`, language, chatID)
+	return err
+}
+
+// SetChatParseMode sets the parse mode of a chat's notifications, empty for
+// the default.
+func (s *SubscriptionStore) SetChatParseMode(chatID int64, mode string) error {
+	_, err := s.db.Exec(`UPDATE chats SET parse_mode = ? WHERE chat_id = ?`, mode, chatID)
+	return err
+}
+
+// SetChatPhotos sets whether a chat's notifications are sent as preview
+// images with the message as caption.
+func (s *SubscriptionStore) SetChatPhotos(chatID int64, enabled bool) error {
+	_, err := s.db.Exec(`UPDATE chats SET photos = ? WHERE chat_id = ?`, enabled, chatID)
+	return err
+}
+
+// SetChatDryRun sets whether a chat's notifications are only logged instead
+// of sent.
+func (s *SubscriptionStore) SetChatDryRun(chatID int64, enabled bool) error {
+	_, err := s.db.Exec(`UPDATE chats SET dry_run = ? WHERE chat_id = ?`, enabled, chatID)
+	return err
+}
+
+// SetChatChannel sets the channel a chat's notifications are posted to, 0 to
+// deliver them to the chat itself.
+func (s *SubscriptionStore) SetChatChannel(chatID, channelID int64) error {
+	_, err := s.db.Exec(`UPDATE chats SET channel_id = ? WHERE chat_id = ?`, channelID, chatID)
+	return err
+}
+
+// UnbindChannel detaches a channel from every chat posting to it and returns
+// the IDs of those chats.
+func (s *SubscriptionStore) UnbindChannel(channelID int64) ([]int64, error) {
+	var chatIDs []int64
+	if err := s.db.Select(&chatIDs, `SELECT chat_id FROM chats WHERE channel_id = ?`, channelID); err != nil {
+		return nil, err
+	}
+	_, err := s.db.Exec(`UPDATE chats SET channel_id = 0 WHERE channel_id = ?`, channelID)
+	return chatIDs, err
+}
+
+// SetOnboardingState records the onboarding progress of a chat.
+func (s *SubscriptionStore) SetOnboardingState(chatID int64, state string) error {
+	_, err := s.db.Exec(`UPDATE chats SET onboarding_state = ? WHERE chat_id = ?`, state, chatID)
+	return err
+}
+
 // Subscribe creates a new subscription for a chat.
 func (s *SubscriptionStore) Subscribe(chatID int64, repoOwner, repoName string, events []EventType) error {
 	eventsJSON, err := json.Marshal(events)
@@ -37,14 +154,31 @@ func (s *SubscriptionStore) Subscribe(chatID int64, repoOwner, repoName string,
 		return fmt.Errorf("failed to marshal events: %w", err)
 	}
 
+	var existing int
+	countQuery := `SELECT COUNT(*) FROM subscriptions WHERE repo_owner = ? AND repo_name = ?`
+	if err := s.db.Get(&existing, countQuery, repoOwner, repoName); err != nil {
+		return err
+	}
+	if err := s.checkQuotas(chatID, repoOwner, repoName, existing > 0); err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO subscriptions (chat_id, repo_owner, repo_name, events)
 		VALUES (?, ?, ?, ?)
 		ON CONFLICT(chat_id, repo_owner, repo_name) DO UPDATE SET
-			events = excluded.events
+			events = excluded.events,
+			via = ''
 	`
-	_, err = s.db.Exec(query, chatID, repoOwner, repoName, string(eventsJSON))
-	return err
+	if _, err = s.db.Exec(query, chatID, repoOwner, repoName, string(eventsJSON)); err != nil {
+		return err
+	}
+
+	// A repository nobody was watching starts over at the base poll interval
+	if existing == 0 {
+		return s.ResetRepoActivity(repoOwner, repoName)
+	}
+	return nil
 }
 
 // Unsubscribe removes a subscription.
@@ -65,6 +199,30 @@ func (s *SubscriptionStore) Unsubscribe(chatID int64, repoOwner, repoName string
 	return nil
 }
 
+// RenameRepository moves the subscriptions, polling state and event history of
+// a repository to its new owner and name. Chats already subscribed under the
+// new name keep their existing subscription.
+func (s *SubscriptionStore) RenameRepository(oldOwner, oldName, newOwner, newName string) error {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"subscriptions", "repo_state", "repo_watermarks", "event_records"} {
+		update := fmt.Sprintf(`UPDATE OR IGNORE %s SET repo_owner = ?, repo_name = ? WHERE repo_owner = ? AND repo_name = ?`, table)
+		if _, err := tx.Exec(update, newOwner, newName, oldOwner, oldName); err != nil {
+			return fmt.Errorf("failed to rename repository in %s: %w", table, err)
+		}
+		remove := fmt.Sprintf(`DELETE FROM %s WHERE repo_owner = ? AND repo_name = ?`, table)
+		if _, err := tx.Exec(remove, oldOwner, oldName); err != nil {
+			return fmt.Errorf("failed to clean up %s: %w", table, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
 // GetSubscriptionsByChat returns all subscriptions for a chat.
 func (s *SubscriptionStore) GetSubscriptionsByChat(chatID int64) ([]Subscription, error) {
 	var subs []Subscription
@@ -81,6 +239,21 @@ func (s *SubscriptionStore) GetSubscriptionsByRepo(repoOwner, repoName string) (
 	return subs, err
 }
 
+// GetActiveSubscriptionsByRepo returns the subscriptions for a repository
+// whose chats currently accept notifications, i.e. the bot is still in the
+// chat and it has not paused notifications.
+func (s *SubscriptionStore) GetActiveSubscriptionsByRepo(repoOwner, repoName string) ([]Subscription, error) {
+	var subs []Subscription
+	query := `
+		SELECT s.* FROM subscriptions s
+		LEFT JOIN chats c ON c.chat_id = s.chat_id
+		WHERE s.repo_owner = ? AND s.repo_name = ?
+			AND COALESCE(c.active, 1) = 1 AND COALESCE(c.paused, 0) = 0
+	`
+	err := s.db.Select(&subs, query, repoOwner, repoName)
+	return subs, err
+}
+
 // GetSubscription returns a specific subscription.
 func (s *SubscriptionStore) GetSubscription(chatID int64, repoOwner, repoName string) (*Subscription, error) {
 	var sub Subscription
@@ -152,9 +325,239 @@ func (s *SubscriptionStore) GetSubscribedEvents(chatID int64, repoOwner, repoNam
 		return nil, nil
 	}
 
+	return ParseEvents(sub.Events)
+}
+
+// ParseEvents decodes the JSON events column of a subscription.
+func ParseEvents(raw string) ([]EventType, error) {
 	var events []EventType
-	if err := json.Unmarshal([]byte(sub.Events), &events); err != nil {
+	if err := json.Unmarshal([]byte(raw), &events); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal events: %w", err)
 	}
 	return events, nil
 }
+
+// GetSubscriptionByID returns a chat's subscription by ID, or nil if it does
+// not belong to the chat.
+func (s *SubscriptionStore) GetSubscriptionByID(chatID, id int64) (*Subscription, error) {
+	var sub Subscription
+	err := s.db.Get(&sub, `SELECT * FROM subscriptions WHERE id = ? AND chat_id = ?`, id, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// SetSubscribedEvents replaces the event types of a subscription.
+func (s *SubscriptionStore) SetSubscribedEvents(id int64, events []EventType) error {
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal events: %w", err)
+	}
+	_, err = s.db.Exec(`UPDATE subscriptions SET events = ? WHERE id = ?`, string(eventsJSON), id)
+	return err
+}
+
+// ParseFilters decodes the JSON filters column of a subscription.
+func ParseFilters(raw string) (SubscriptionFilters, error) {
+	var filters SubscriptionFilters
+	if raw == "" {
+		return filters, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &filters); err != nil {
+		return filters, fmt.Errorf("failed to unmarshal filters: %w", err)
+	}
+	return filters, nil
+}
+
+// GetSubscriptionFilters returns the filters of a subscription, or nil if the
+// subscription does not exist.
+func (s *SubscriptionStore) GetSubscriptionFilters(chatID int64, repoOwner, repoName string) (*SubscriptionFilters, error) {
+	sub, err := s.GetSubscription(chatID, repoOwner, repoName)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, nil
+	}
+
+	filters, err := ParseFilters(sub.Filters)
+	if err != nil {
+		return nil, err
+	}
+	return &filters, nil
+}
+
+// SetSubscriptionFilters replaces the filters of a subscription.
+func (s *SubscriptionStore) SetSubscriptionFilters(chatID int64, repoOwner, repoName string, filters SubscriptionFilters) error {
+	filtersJSON, err := json.Marshal(filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal filters: %w", err)
+	}
+
+	query := `UPDATE subscriptions SET filters = ? WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	result, err := s.db.Exec(query, string(filtersJSON), chatID, repoOwner, repoName)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// ParseTemplates decodes the custom message templates of a subscription.
+func ParseTemplates(raw string) (map[EventType]string, error) {
+	templates := make(map[EventType]string)
+	if raw == "" {
+		return templates, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &templates); err != nil {
+		return templates, fmt.Errorf("failed to unmarshal templates: %w", err)
+	}
+	return templates, nil
+}
+
+// SetSubscriptionTemplate sets the custom message template of a subscription
+// for one event type. An empty template restores the built-in message.
+func (s *SubscriptionStore) SetSubscriptionTemplate(chatID int64, repoOwner, repoName string, event EventType, text string) error {
+	sub, err := s.GetSubscription(chatID, repoOwner, repoName)
+	if err != nil {
+		return err
+	}
+	if sub == nil {
+		return errors.New("subscription not found")
+	}
+
+	templates, err := ParseTemplates(sub.Templates)
+	if err != nil {
+		return err
+	}
+	if text == "" {
+		delete(templates, event)
+	} else {
+		templates[event] = text
+	}
+
+	templatesJSON, err := json.Marshal(templates)
+	if err != nil {
+		return fmt.Errorf("failed to marshal templates: %w", err)
+	}
+	_, err = s.db.Exec(`UPDATE subscriptions SET templates = ? WHERE id = ?`, string(templatesJSON), sub.ID)
+	return err
+}
+
+// MuteSubscription suppresses notifications of a subscription until the given time.
+// A zero time unmutes it.
+func (s *SubscriptionStore) MuteSubscription(chatID int64, repoOwner, repoName string, until time.Time) error {
+	var mutedUntil sql.NullTime
+	if !until.IsZero() {
+		mutedUntil = sql.NullTime{Time: until.UTC(), Valid: true}
+	}
+
+	query := `UPDATE subscriptions SET muted_until = ? WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	result, err := s.db.Exec(query, mutedUntil, chatID, repoOwner, repoName)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// SetSubscriptionDigest switches a subscription between real-time delivery and
+// one of the Digest modes.
+func (s *SubscriptionStore) SetSubscriptionDigest(chatID int64, repoOwner, repoName, digest string) error {
+	query := `UPDATE subscriptions SET digest = ? WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	result, err := s.db.Exec(query, digest, chatID, repoOwner, repoName)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// SetSubscriptionSilent sets the event types of a subscription that are
+// delivered without a notification sound.
+func (s *SubscriptionStore) SetSubscriptionSilent(chatID int64, repoOwner, repoName string, events []EventType) error {
+	if events == nil {
+		events = []EventType{}
+	}
+	silentJSON, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal silent events: %w", err)
+	}
+
+	query := `UPDATE subscriptions SET silent = ? WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	result, err := s.db.Exec(query, string(silentJSON), chatID, repoOwner, repoName)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// SetSubscriptionSink routes the notifications of a subscription to a sink,
+// or back to its chat with an empty route.
+func (s *SubscriptionStore) SetSubscriptionSink(chatID int64, repoOwner, repoName, sink string) error {
+	query := `UPDATE subscriptions SET sink = ? WHERE chat_id = ? AND repo_owner = ? AND repo_name = ?`
+	result, err := s.db.Exec(query, sink, chatID, repoOwner, repoName)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// SetComplianceViolation records the current compliance drift of a subscription.
+// A changed violation must be acknowledged again.
+func (s *SubscriptionStore) SetComplianceViolation(id int64, violation string) error {
+	query := `UPDATE subscriptions SET compliance_violation = ?, compliance_acked = 0 WHERE id = ?`
+	_, err := s.db.Exec(query, violation, id)
+	return err
+}
+
+// AcknowledgeCompliance marks the compliance drift of a chat's subscription as seen.
+// It returns the acknowledged subscription, or nil if it does not belong to the chat.
+func (s *SubscriptionStore) AcknowledgeCompliance(chatID, id int64) (*Subscription, error) {
+	query := `UPDATE subscriptions SET compliance_acked = 1 WHERE id = ? AND chat_id = ?`
+	if _, err := s.db.Exec(query, id, chatID); err != nil {
+		return nil, err
+	}
+	return s.GetSubscriptionByID(chatID, id)
+}
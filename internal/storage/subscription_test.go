@@ -0,0 +1,81 @@
+package storage
+
+import "testing"
+
+// subscribe subscribes a chat, created if needed, to a repository and
+// returns the subscription.
+func subscribe(t *testing.T, store *SubscriptionStore, chatID int64, owner, repo string) *Subscription {
+	t.Helper()
+	if err := store.CreateOrUpdateChat(chatID, "private", ""); err != nil {
+		t.Fatalf("CreateOrUpdateChat() error = %v", err)
+	}
+	if err := store.Subscribe(chatID, owner, repo, []EventType{EventTypeRelease}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	sub, err := store.GetSubscription(chatID, owner, repo)
+	if err != nil || sub == nil {
+		t.Fatalf("GetSubscription() = %v, %v", sub, err)
+	}
+	return sub
+}
+
+func TestAcknowledgeCompliance(t *testing.T) {
+	store := newTestStore(t)
+	sub := subscribe(t, store, 1, "acme", "app")
+	other := subscribe(t, store, 2, "acme", "app")
+
+	state := func(chatID int64) (string, bool) {
+		t.Helper()
+		sub, err := store.GetSubscription(chatID, "acme", "app")
+		if err != nil || sub == nil {
+			t.Fatalf("GetSubscription() = %v, %v", sub, err)
+		}
+		return sub.ComplianceViolation, sub.ComplianceAcked
+	}
+
+	for _, id := range []int64{sub.ID, other.ID} {
+		if err := store.SetComplianceViolation(id, "license changed to GPL-3.0"); err != nil {
+			t.Fatalf("SetComplianceViolation() error = %v", err)
+		}
+	}
+
+	// Another chat cannot acknowledge the drift of this one
+	acked, err := store.AcknowledgeCompliance(2, sub.ID)
+	if err != nil || acked != nil {
+		t.Fatalf("AcknowledgeCompliance() = %v, %v for another chat's subscription, want nil", acked, err)
+	}
+	if _, ok := state(1); ok {
+		t.Fatal("drift acknowledged by another chat")
+	}
+
+	acked, err = store.AcknowledgeCompliance(1, sub.ID)
+	if err != nil || acked == nil || acked.ID != sub.ID || !acked.ComplianceAcked {
+		t.Fatalf("AcknowledgeCompliance() = %+v, %v", acked, err)
+	}
+	if violation, ok := state(1); !ok || violation != "license changed to GPL-3.0" {
+		t.Errorf("state = %q, %v, want the drift acknowledged and kept", violation, ok)
+	}
+	if _, ok := state(2); ok {
+		t.Error("acknowledging one subscription acknowledged another chat's")
+	}
+
+	// A different drift must be acknowledged again
+	if err := store.SetComplianceViolation(sub.ID, "license changed to GPL-3.0; repository was archived"); err != nil {
+		t.Fatalf("SetComplianceViolation() error = %v", err)
+	}
+	if _, ok := state(1); ok {
+		t.Error("changed drift still acknowledged")
+	}
+
+	// Back in compliance
+	if err := store.SetComplianceViolation(sub.ID, ""); err != nil {
+		t.Fatalf("SetComplianceViolation() error = %v", err)
+	}
+	if violation, ok := state(1); violation != "" || ok {
+		t.Errorf("state = %q, %v, want compliant", violation, ok)
+	}
+
+	if acked, err := store.AcknowledgeCompliance(1, 9999); err != nil || acked != nil {
+		t.Errorf("AcknowledgeCompliance() = %v, %v for a missing subscription, want nil", acked, err)
+	}
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// TopicSubscription announces new repositories tagged with a GitHub topic to
+// a chat.
+type TopicSubscription struct {
+	ID        int64     `db:"id"`
+	ChatID    int64     `db:"chat_id"`
+	Topic     string    `db:"topic"` // Lowercase, as GitHub normalizes topics
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// SubscribeTopic subscribes a chat to new repositories of a topic.
+func (s *SubscriptionStore) SubscribeTopic(chatID int64, topic string) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO topic_subscriptions (chat_id, topic) VALUES (?, ?)`, chatID, topic)
+	return err
+}
+
+// UnsubscribeTopic removes the subscription of a chat to a topic.
+func (s *SubscriptionStore) UnsubscribeTopic(chatID int64, topic string) error {
+	result, err := s.db.Exec(`DELETE FROM topic_subscriptions WHERE chat_id = ? AND topic = ?`, chatID, topic)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("subscription not found")
+	}
+	return nil
+}
+
+// GetTopicSubscriptionsByChat returns the topics a chat is subscribed to.
+func (s *SubscriptionStore) GetTopicSubscriptionsByChat(chatID int64) ([]TopicSubscription, error) {
+	var subs []TopicSubscription
+	err := s.db.Select(&subs, `SELECT * FROM topic_subscriptions WHERE chat_id = ? ORDER BY topic`, chatID)
+	return subs, err
+}
+
+// GetSubscribedTopics returns all topics at least one chat is subscribed to.
+func (s *SubscriptionStore) GetSubscribedTopics() ([]string, error) {
+	var topics []string
+	err := s.db.Select(&topics, `SELECT DISTINCT topic FROM topic_subscriptions ORDER BY topic`)
+	return topics, err
+}
+
+// GetActiveTopicSubscribers returns the chats subscribed to a topic that
+// currently accept notifications.
+func (s *SubscriptionStore) GetActiveTopicSubscribers(topic string) ([]int64, error) {
+	var chatIDs []int64
+	query := `
+		SELECT t.chat_id FROM topic_subscriptions t
+		LEFT JOIN chats c ON c.chat_id = t.chat_id
+		WHERE t.topic = ?
+			AND COALESCE(c.active, 1) = 1 AND COALESCE(c.paused, 0) = 0
+	`
+	err := s.db.Select(&chatIDs, query, topic)
+	return chatIDs, err
+}
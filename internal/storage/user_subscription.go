@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UserSubscription follows all public repositories of a GitHub user. The
+// repositories are subscribed individually, marked with Via so that they can
+// be removed together again.
+type UserSubscription struct {
+	ID        int64        `db:"id"`
+	ChatID    int64        `db:"chat_id"`
+	Login     string       `db:"login"`
+	Events    string       `db:"events"`    // JSON array of event types for the repositories
+	SyncedAt  sql.NullTime `db:"synced_at"` // When the repository list was last synchronized
+	CreatedAt time.Time    `db:"created_at"`
+}
+
+// UserVia returns the Via marker of subscriptions added for a GitHub user.
+func UserVia(login string) string {
+	return "user:" + login
+}
+
+// SubscribeUser creates or updates the subscription of a chat to a GitHub user.
+func (s *SubscriptionStore) SubscribeUser(chatID int64, login string, events []EventType) (*UserSubscription, error) {
+	eventsJSON, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal events: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_subscriptions (chat_id, login, events)
+		VALUES (?, ?, ?)
+		ON CONFLICT(chat_id, login) DO UPDATE SET
+			events = excluded.events
+	`
+	if _, err := s.db.Exec(query, chatID, login, string(eventsJSON)); err != nil {
+		return nil, err
+	}
+
+	var sub UserSubscription
+	err = s.db.Get(&sub, `SELECT * FROM user_subscriptions WHERE chat_id = ? AND login = ? COLLATE NOCASE`, chatID, login)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// UnsubscribeUser removes the subscription of a chat to a GitHub user and the
+// repository subscriptions it added. It returns how many repositories were
+// unsubscribed.
+func (s *SubscriptionStore) UnsubscribeUser(chatID int64, login string) (int64, error) {
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var sub UserSubscription
+	err = tx.Get(&sub, `SELECT * FROM user_subscriptions WHERE chat_id = ? AND login = ? COLLATE NOCASE`, chatID, login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errors.New("subscription not found")
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_subscriptions WHERE id = ?`, sub.ID); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(`DELETE FROM subscriptions WHERE chat_id = ? AND via = ?`, chatID, UserVia(sub.Login))
+	if err != nil {
+		return 0, err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return removed, tx.Commit()
+}
+
+// GetUserSubscriptions returns all subscriptions to GitHub users.
+func (s *SubscriptionStore) GetUserSubscriptions() ([]UserSubscription, error) {
+	var subs []UserSubscription
+	err := s.db.Select(&subs, `SELECT * FROM user_subscriptions ORDER BY id`)
+	return subs, err
+}
+
+// SubscribeVia subscribes a chat to a repository on behalf of a user
+// subscription. Existing subscriptions of the chat are left untouched. It
+// reports whether a subscription was added.
+func (s *SubscriptionStore) SubscribeVia(chatID int64, repoOwner, repoName, events, via string) (bool, error) {
+	var existing int
+	countQuery := `SELECT COUNT(*) FROM subscriptions WHERE repo_owner = ? AND repo_name = ?`
+	if err := s.db.Get(&existing, countQuery, repoOwner, repoName); err != nil {
+		return false, err
+	}
+	if err := s.checkQuotas(chatID, repoOwner, repoName, existing > 0); err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT OR IGNORE INTO subscriptions (chat_id, repo_owner, repo_name, events, via)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query, chatID, repoOwner, repoName, events, via)
+	if err != nil {
+		return false, err
+	}
+	added, err := result.RowsAffected()
+	if err != nil || added == 0 {
+		return false, err
+	}
+
+	// A repository nobody was watching starts over at the base poll interval
+	if existing == 0 {
+		return true, s.ResetRepoActivity(repoOwner, repoName)
+	}
+	return true, nil
+}
+
+// MarkUserSynced records when the repository list of a user subscription was
+// last synchronized.
+func (s *SubscriptionStore) MarkUserSynced(id int64, at time.Time) error {
+	_, err := s.db.Exec(`UPDATE user_subscriptions SET synced_at = ? WHERE id = ?`, at.UTC(), id)
+	return err
+}
@@ -0,0 +1,193 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Who may use the bot is decided per message. Administrators and the
+// configured users and chats always may. In approval mode, a chat using the
+// bot for the first time is held back until an administrator approves it
+// with the buttons sent to them privately.
+
+// Access modes of the bot.
+const (
+	AccessOpen      = "open"      // Everyone may use the bot
+	AccessAllowlist = "allowlist" // Only the allowed users and chats
+	AccessApproval  = "approval"  // New chats need an administrator's approval
+)
+
+// SetAccess sets who may use the bot.
+func (h *Handlers) SetAccess(mode string, users, chats []int64) {
+	switch mode {
+	case AccessOpen, AccessAllowlist, AccessApproval:
+	default:
+		logger.Warn().Str("access", mode).Msg("Unknown access mode, only allowing the listed users and chats")
+		mode = AccessAllowlist
+	}
+
+	allowedUsers := make(map[int64]bool, len(users))
+	for _, id := range users {
+		allowedUsers[id] = true
+	}
+	allowedChats := make(map[int64]bool, len(chats))
+	for _, id := range chats {
+		allowedChats[id] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.access = mode
+	h.allowedUsers = allowedUsers
+	h.allowedChats = allowedChats
+}
+
+// accessMode returns the access mode set with SetAccess.
+func (h *Handlers) accessMode() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.access
+}
+
+// isAllowed reports whether a user may use the bot in a chat regardless of
+// approvals.
+func (h *Handlers) isAllowed(chatID int64, user *tgbotapi.User) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.access == "" || h.access == AccessOpen || h.allowedChats[chatID] {
+		return true
+	}
+	return user != nil && (h.admins[user.ID] || h.allowedUsers[user.ID])
+}
+
+// hasAccess reports whether a user may use the bot in a chat.
+func (h *Handlers) hasAccess(chatID int64, user *tgbotapi.User) bool {
+	if h.isAllowed(chatID, user) {
+		return true
+	}
+	if h.accessMode() != AccessApproval {
+		return false
+	}
+	chat, err := h.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	return chat != nil && chat.Access == storage.ChatAccessApproved
+}
+
+// checkAccess reports whether a message may be handled, telling the chat why
+// not otherwise. In approval mode it asks the administrators to approve a
+// chat seen for the first time. The chat must already be tracked.
+func (h *Handlers) checkAccess(msg *tgbotapi.Message) bool {
+	if h.hasAccess(msg.Chat.ID, msg.From) {
+		return true
+	}
+
+	lang := h.lang(msg.Chat.ID)
+	if h.accessMode() != AccessApproval {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "access.denied"))
+		return false
+	}
+
+	chat, err := h.store.GetChat(msg.Chat.ID)
+	if err != nil || chat == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "access.denied"))
+		return false
+	}
+	switch chat.Access {
+	case storage.ChatAccessPending:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "access.pending"))
+	case storage.ChatAccessDenied:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "access.denied"))
+	default:
+		h.requestApproval(msg, chat)
+	}
+	return false
+}
+
+// requestApproval marks a chat as waiting for approval and sends every
+// administrator buttons to approve or deny it.
+func (h *Handlers) requestApproval(msg *tgbotapi.Message, chat *storage.Chat) {
+	lang := h.lang(chat.ChatID)
+	if err := h.store.SetChatAccess(chat.ChatID, storage.ChatAccessPending); err != nil {
+		h.sendReply(chat.ChatID, i18n.T(lang, "access.denied"))
+		logger.Error().Err(err).Int64("chat_id", chat.ChatID).Msg("Failed to request approval")
+		return
+	}
+	h.sendReply(chat.ChatID, i18n.T(lang, "access.requested"))
+
+	admins := h.adminIDs()
+	if len(admins) == 0 {
+		logger.Warn().Int64("chat_id", chat.ChatID).Msg("Chat awaits approval but no administrators are configured")
+		return
+	}
+
+	requester := "-"
+	if msg.From != nil {
+		requester = msg.From.String()
+	}
+	for _, adminID := range admins {
+		adminLang := h.lang(adminID)
+		request := tgbotapi.NewMessage(adminID, i18n.T(adminLang, "access.request",
+			chat.Title, chat.ChatType, chat.ChatID, requester))
+		request.ParseMode = tgbotapi.ModeMarkdownV2
+		request.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(adminLang, "access.approve_button"),
+				fmt.Sprintf("access:%s:%d", storage.ChatAccessApproved, chat.ChatID)),
+			tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(adminLang, "access.deny_button"),
+				fmt.Sprintf("access:%s:%d", storage.ChatAccessDenied, chat.ChatID)),
+		))
+		if _, err := h.api.Send(request); err != nil {
+			logger.Warn().Err(err).Int64("admin_id", adminID).Msg("Failed to send approval request")
+		}
+	}
+}
+
+// handleAccessCallback handles an administrator approving or denying a chat.
+func (h *Handlers) handleAccessCallback(callback *tgbotapi.CallbackQuery, access, idArg string) {
+	adminChatID := callback.Message.Chat.ID
+	adminLang := h.lang(adminChatID)
+	if !h.isAdmin(callback.From) {
+		h.sendReply(adminChatID, i18n.T(adminLang, "setting.admin_only"))
+		return
+	}
+	if access != storage.ChatAccessApproved && access != storage.ChatAccessDenied {
+		return
+	}
+	chatID, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return
+	}
+
+	chat, err := h.store.GetChat(chatID)
+	if err != nil || chat == nil {
+		h.sendReply(adminChatID, i18n.T(adminLang, "access.failed"))
+		return
+	}
+	if err := h.store.SetChatAccess(chatID, access); err != nil {
+		h.sendReply(adminChatID, i18n.T(adminLang, "access.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to set chat access")
+		return
+	}
+	logger.Info().Int64("chat_id", chatID).Int64("admin_id", callback.From.ID).Str("access", access).Msg("Chat access decided")
+
+	// Replace the buttons with the decision
+	edit := tgbotapi.NewEditMessageText(adminChatID, callback.Message.MessageID,
+		i18n.T(adminLang, "access.decided_"+access, chat.Title, chatID, callback.From.String()))
+	edit.ParseMode = tgbotapi.ModeMarkdownV2
+	if _, err := h.api.Send(edit); err != nil {
+		logger.Warn().Err(err).Msg("Failed to update approval request")
+	}
+
+	lang := h.lang(chatID)
+	if access == storage.ChatAccessApproved {
+		h.sendReply(chatID, i18n.T(lang, "access.granted"))
+	} else {
+		h.sendReply(chatID, i18n.T(lang, "access.denied"))
+	}
+}
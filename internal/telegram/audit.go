@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// /admin audit lists this many entries unless told otherwise, and at most
+// maxAuditCount.
+const (
+	defaultAuditCount = 20
+	maxAuditCount     = 50
+)
+
+// maxAuditArgs is how many characters of a command's arguments are listed.
+const maxAuditArgs = 40
+
+// secretCommands are the commands whose arguments are secrets, which are
+// neither logged nor kept in the audit log.
+var secretCommands = map[string]bool{"settoken": true}
+
+// loggedArgs returns the arguments of a command as they may be logged.
+func loggedArgs(command, args string) string {
+	if secretCommands[command] && args != "" {
+		return "[redacted]"
+	}
+	return args
+}
+
+// audit records a command or button press in the audit log.
+func (h *Handlers) audit(chatID int64, user *tgbotapi.User, command, args, outcome string) {
+	entry := &storage.AuditEntry{
+		ChatID:  chatID,
+		Command: command,
+		Args:    args,
+		Outcome: outcome,
+	}
+	if user != nil {
+		entry.UserID = user.ID
+		entry.Username = user.UserName
+	}
+	if err := h.store.RecordAudit(entry); err != nil {
+		logger.Warn().Err(err).Str("command", command).Int64("chat_id", chatID).Msg("Failed to record command in the audit log")
+	}
+}
+
+// handleAdminAudit lists the latest commands received, optionally only those
+// of a chat, a user or a command:
+// /admin audit [chat:<id>] [user:<id>] [cmd:<command>] [n]
+func (h *Handlers) handleAdminAudit(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	var filter storage.AuditFilter
+	count := defaultAuditCount
+	for _, arg := range strings.Fields(args) {
+		var err error
+		switch key, value, _ := strings.Cut(arg, ":"); key {
+		case "chat":
+			filter.ChatID, err = strconv.ParseInt(value, 10, 64)
+		case "user":
+			filter.UserID, err = strconv.ParseInt(value, 10, 64)
+		case "cmd":
+			filter.Command = strings.TrimPrefix(value, "/")
+		default:
+			count, err = strconv.Atoi(arg)
+			if err == nil && (count < 1 || count > maxAuditCount) {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "audit.invalid_count", maxAuditCount))
+				return
+			}
+		}
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "audit.usage"))
+			return
+		}
+	}
+
+	entries, err := h.store.GetAuditLog(filter, count)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "audit.failed"))
+		logger.Error().Err(err).Msg("Failed to get audit log")
+		return
+	}
+	if len(entries) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "audit.none"))
+		return
+	}
+
+	loc := h.chatLocation(msg.Chat.ID)
+	text := i18n.T(lang, "audit.title", len(entries))
+	for i, entry := range entries {
+		user := strconv.FormatInt(entry.UserID, 10)
+		if entry.Username != "" {
+			user = "@" + entry.Username
+		}
+		command := "/" + entry.Command
+		if strings.HasPrefix(entry.Command, "button:") {
+			command = entry.Command
+		}
+		if entry.Args != "" {
+			command += " " + truncateRunes(entry.Args, maxAuditArgs)
+		}
+		line := markdown.Sprintf("• `%s` `%d` %s `%s` %s\n", entry.CreatedAt.In(loc).Format("01-02 15:04"),
+			entry.ChatID, user, command, markdown.Raw(auditOutcome(entry.Outcome, lang)))
+		if len(text)+len(line) > maxMessageLength {
+			text += i18n.T(lang, "summary.more", len(entries)-i)
+			break
+		}
+		text += line
+	}
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// auditOutcome returns the label of an audit outcome.
+func auditOutcome(outcome string, lang i18n.Lang) string {
+	switch outcome {
+	case storage.AuditDenied:
+		return i18n.T(lang, "audit.denied")
+	case storage.AuditUnknown:
+		return i18n.T(lang, "audit.unknown")
+	default:
+		return i18n.T(lang, "audit.handled")
+	}
+}
@@ -23,7 +23,7 @@ type Bot struct {
 }
 
 // NewBot creates a new Telegram bot instance.
-func NewBot(token string, debug bool, store *storage.SubscriptionStore, ghClient *github.Client) (*Bot, error) {
+func NewBot(token string, debug bool, store storage.Store, ghClient *github.Client) (*Bot, error) {
 	api, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
@@ -59,6 +59,7 @@ func (b *Bot) Start() {
 	b.wg.Add(1)
 	go func() {
 		defer b.wg.Done()
+		defer logger.ReportPanic()
 		for {
 			select {
 			case <-b.ctx.Done():
@@ -68,6 +69,8 @@ func (b *Bot) Start() {
 					b.handleMessage(update.Message)
 				} else if update.CallbackQuery != nil {
 					b.handleCallback(update.CallbackQuery)
+				} else if update.MyChatMember != nil {
+					b.handlers.HandleMyChatMember(update.MyChatMember)
 				}
 			}
 		}
@@ -86,8 +89,12 @@ func (b *Bot) Stop() {
 
 // handleMessage processes incoming messages.
 func (b *Bot) handleMessage(msg *tgbotapi.Message) {
+	b.handlers.onboardUnknownChat(msg.Chat)
+
 	if msg.IsCommand() {
 		b.handlers.HandleCommand(msg)
+	} else {
+		b.handlers.HandleMessage(msg)
 	}
 }
 
@@ -112,9 +119,14 @@ func (b *Bot) SendMessage(chatID int64, text string, parseMode string) error {
 	return nil
 }
 
-// SendMarkdownMessage sends a markdown-formatted message.
+// SendMarkdownMessage sends a MarkdownV2-formatted message.
 func (b *Bot) SendMarkdownMessage(chatID int64, text string) error {
-	return b.SendMessage(chatID, text, tgbotapi.ModeMarkdown)
+	return b.SendMessage(chatID, text, tgbotapi.ModeMarkdownV2)
+}
+
+// Handlers returns the command handlers for additional configuration.
+func (b *Bot) Handlers() *Handlers {
+	return b.handlers
 }
 
 // GetAPI returns the underlying bot API for direct access.
@@ -0,0 +1,152 @@
+package telegram
+
+import (
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Channels cannot send commands, so a chat binds a channel it manages and its
+// notifications are posted there instead. Subscriptions and settings stay
+// with the chat that bound the channel.
+
+// handleBindChannel binds a channel given as @username or numeric ID, or
+// shows the current binding.
+func (h *Handlers) handleBindChannel(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	arg := strings.TrimSpace(args)
+	if arg == "" {
+		h.showChannel(msg.Chat.ID)
+		return
+	}
+
+	config := tgbotapi.ChatInfoConfig{}
+	if id, err := strconv.ParseInt(arg, 10, 64); err == nil {
+		config.ChatID = id
+	} else {
+		config.SuperGroupUsername = "@" + strings.TrimPrefix(arg, "@")
+	}
+
+	channel, err := h.api.GetChat(config)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.not_found", arg))
+		return
+	}
+	h.bindChannel(msg, &channel)
+}
+
+// handleUnbindChannel delivers a chat's notifications to the chat itself again.
+func (h *Handlers) handleUnbindChannel(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	if err := h.store.SetChatChannel(msg.Chat.ID, 0); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.failed"))
+		logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to unbind channel")
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.unbound"))
+}
+
+// handleForwardedChannelPost binds the channel a message in a private chat was
+// forwarded from. It reports whether the message was such a forward.
+func (h *Handlers) handleForwardedChannelPost(msg *tgbotapi.Message) bool {
+	if msg.ForwardFromChat == nil || !msg.ForwardFromChat.IsChannel() || !msg.Chat.IsPrivate() {
+		return false
+	}
+	h.trackChat(msg.Chat)
+	if h.checkAccess(msg) {
+		h.bindChannel(msg, msg.ForwardFromChat)
+	}
+	return true
+}
+
+// bindChannel checks that the bot can post to a channel and that the user is
+// one of its administrators, then posts the chat's notifications there.
+func (h *Handlers) bindChannel(msg *tgbotapi.Message, channel *tgbotapi.Chat) {
+	lang := h.lang(msg.Chat.ID)
+	if !channel.IsChannel() {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.not_channel"))
+		return
+	}
+
+	bot, err := h.channelMember(channel.ID, h.api.Self.ID)
+	if err != nil || !(bot.IsCreator() || bot.IsAdministrator() && bot.CanPostMessages) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.bot_not_admin", channel.Title))
+		return
+	}
+
+	if msg.From == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.user_not_admin", channel.Title))
+		return
+	}
+	user, err := h.channelMember(channel.ID, msg.From.ID)
+	if err != nil || !(user.IsCreator() || user.IsAdministrator()) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.user_not_admin", channel.Title))
+		return
+	}
+
+	if err := h.store.CreateOrUpdateChat(channel.ID, string(channel.Type), channel.Title); err != nil {
+		logger.Error().Err(err).Int64("chat_id", channel.ID).Msg("Failed to track channel")
+	}
+	if err := h.store.SetChatChannel(msg.Chat.ID, channel.ID); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.failed"))
+		logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to bind channel")
+		return
+	}
+
+	logger.Info().
+		Int64("chat_id", msg.Chat.ID).
+		Int64("channel_id", channel.ID).
+		Msg("Channel bound")
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "channel.bound", channel.Title))
+}
+
+// showChannel tells a chat where its notifications are posted.
+func (h *Handlers) showChannel(chatID int64) {
+	lang := h.lang(chatID)
+	chat, err := h.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	if chat == nil || chat.ChannelID == 0 {
+		h.sendReply(chatID, i18n.T(lang, "channel.none"))
+		return
+	}
+
+	title := strconv.FormatInt(chat.ChannelID, 10)
+	if channel, err := h.store.GetChat(chat.ChannelID); err == nil && channel != nil && channel.Title != "" {
+		title = channel.Title
+	}
+	h.sendReply(chatID, i18n.T(lang, "channel.current", title))
+}
+
+// channelMember looks up a user's membership in a channel.
+func (h *Handlers) channelMember(channelID, userID int64) (tgbotapi.ChatMember, error) {
+	return h.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: channelID, UserID: userID},
+	})
+}
+
+// handleChannelMembership unbinds a channel the bot was removed from or can
+// no longer post to, and tells the chats that posted there.
+func (h *Handlers) handleChannelMembership(update *tgbotapi.ChatMemberUpdated) {
+	member := update.NewChatMember
+	if member.IsCreator() || member.IsAdministrator() && member.CanPostMessages {
+		return
+	}
+
+	chatIDs, err := h.store.UnbindChannel(update.Chat.ID)
+	if err != nil {
+		logger.Error().Err(err).Int64("channel_id", update.Chat.ID).Msg("Failed to unbind channel")
+		return
+	}
+	for _, chatID := range chatIDs {
+		logger.Info().
+			Int64("chat_id", chatID).
+			Int64("channel_id", update.Chat.ID).
+			Msg("Channel unbound after losing post permission")
+		h.sendReply(chatID, i18n.T(h.lang(chatID), "channel.lost", update.Chat.Title))
+	}
+}
@@ -0,0 +1,159 @@
+package telegram
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// maxCustomHooks is how many custom hooks a chat may create.
+const maxCustomHooks = 10
+
+// hookNamePattern matches valid custom hook names.
+var hookNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// SetPublicURL sets the URL the bot's HTTP server is reachable at, which the
+// URLs of custom hooks are shown with.
+func (h *Handlers) SetPublicURL(url string) {
+	h.publicURL = strings.TrimSuffix(url, "/")
+}
+
+// hookURL returns the URL other services post to for a hook token.
+func (h *Handlers) hookURL(token string) string {
+	return h.publicURL + "/webhook/custom/" + token
+}
+
+// handleHook manages the custom hooks of a chat, which let other services
+// post messages to it:
+// /hook, /hook new <name>, /hook template <name> [template|off],
+// /hook delete <name>
+func (h *Handlers) handleHook(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	sub, rest := cutArg(args)
+	name, text := cutArg(rest)
+	if sub != "" && !hookNamePattern.MatchString(name) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "hook.usage"))
+		return
+	}
+
+	switch strings.ToLower(sub) {
+	case "":
+		h.listHooks(msg.Chat.ID, lang)
+	case "new":
+		h.createHook(msg.Chat.ID, name, lang)
+	case "template":
+		h.setHookTemplate(msg.Chat.ID, name, trimQuotes(text), lang)
+	case "delete":
+		deleted, err := h.store.DeleteCustomHook(msg.Chat.ID, name)
+		switch {
+		case err != nil:
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "hook.failed"))
+			logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to delete custom hook")
+		case !deleted:
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "hook.not_found", name))
+		default:
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "hook.deleted", name))
+		}
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "hook.usage"))
+	}
+}
+
+// listHooks lists the custom hooks of a chat with their URLs.
+func (h *Handlers) listHooks(chatID int64, lang i18n.Lang) {
+	hooks, err := h.store.GetCustomHooksByChat(chatID)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "hook.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to get custom hooks")
+		return
+	}
+	if len(hooks) == 0 {
+		h.sendReply(chatID, i18n.T(lang, "hook.none"))
+		return
+	}
+
+	reply := i18n.T(lang, "hook.list")
+	for i := range hooks {
+		token, err := h.store.GetCustomHookToken(&hooks[i])
+		switch {
+		case err != nil:
+			logger.Error().Err(err).Int64("hook_id", hooks[i].ID).Msg("Failed to decrypt custom hook token")
+			fallthrough
+		case token == "":
+			reply += markdown.Sprintf("• *%s*: %s\n", hooks[i].Name, i18n.Plain(lang, "hook.url_hidden"))
+		default:
+			reply += markdown.Sprintf("• *%s*: `%s`\n", hooks[i].Name, h.hookURL(token))
+		}
+	}
+	h.sendMarkdown(chatID, reply)
+}
+
+// createHook creates a custom hook and shows its URL.
+func (h *Handlers) createHook(chatID int64, name string, lang i18n.Lang) {
+	hooks, err := h.store.GetCustomHooksByChat(chatID)
+	if err == nil && len(hooks) >= maxCustomHooks {
+		h.sendReply(chatID, i18n.T(lang, "hook.limit", maxCustomHooks))
+		return
+	}
+
+	_, token, err := h.store.CreateCustomHook(chatID, name)
+	if errors.Is(err, storage.ErrCustomHookExists) {
+		h.sendReply(chatID, i18n.T(lang, "hook.exists", name))
+		return
+	}
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "hook.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to create custom hook")
+		return
+	}
+	h.sendMarkdown(chatID, i18n.T(lang, "hook.created", name, h.hookURL(token), name))
+}
+
+// setHookTemplate shows, sets or removes the template of a custom hook.
+func (h *Handlers) setHookTemplate(chatID int64, name, text string, lang i18n.Lang) {
+	hook, err := h.store.GetCustomHook(chatID, name)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "hook.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to get custom hook")
+		return
+	}
+	if hook == nil {
+		h.sendReply(chatID, i18n.T(lang, "hook.not_found", name))
+		return
+	}
+
+	switch {
+	case text == "":
+		if hook.Template == "" {
+			h.sendReply(chatID, i18n.T(lang, "hook.template_none", name))
+		} else {
+			h.sendReply(chatID, i18n.T(lang, "hook.template_current", name, hook.Template))
+		}
+		return
+	case strings.EqualFold(text, "off"):
+		text = ""
+	default:
+		if err := github.ValidatePayloadTemplate(text); err != nil {
+			h.sendReply(chatID, i18n.T(lang, "template.invalid", err.Error()))
+			return
+		}
+	}
+
+	if err := h.store.SetCustomHookTemplate(chatID, name, text); err != nil {
+		h.sendReply(chatID, i18n.T(lang, "hook.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to set custom hook template")
+		return
+	}
+	if text == "" {
+		h.sendReply(chatID, i18n.T(lang, "hook.template_reset", name))
+		return
+	}
+	h.sendReply(chatID, i18n.T(lang, "hook.template_set", name))
+}
@@ -0,0 +1,198 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Subscriptions are exported as a JSON document sent to the chat. Importing
+// takes such a document sent with /import as its caption, or a reply with
+// /import to it.
+
+// maxImportSize is the largest document /import accepts.
+const maxImportSize = 1 << 20
+
+// handleExport sends the chat's subscriptions as a JSON document.
+func (h *Handlers) handleExport(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	export, err := h.store.ExportChat(msg.Chat.ID)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "export.failed"))
+		logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to export subscriptions")
+		return
+	}
+	if len(export.Subscriptions) == 0 && len(export.Users) == 0 && len(export.Topics) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "list.empty"))
+		return
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "export.failed"))
+		logger.Error().Err(err).Msg("Failed to encode export")
+		return
+	}
+
+	document := tgbotapi.NewDocument(msg.Chat.ID, tgbotapi.FileBytes{
+		Name:  fmt.Sprintf("subscriptions-%s.json", time.Now().Format("2006-01-02")),
+		Bytes: data,
+	})
+	document.Caption = i18n.T(lang, "export.caption",
+		len(export.Subscriptions), len(export.Users), len(export.Topics))
+	document.ParseMode = tgbotapi.ModeMarkdownV2
+	if _, err := h.api.Send(document); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "export.failed"))
+		logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to send export")
+	}
+}
+
+// handleImport imports the document replied to with /import.
+func (h *Handlers) handleImport(msg *tgbotapi.Message) {
+	if msg.ReplyToMessage == nil || msg.ReplyToMessage.Document == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(h.lang(msg.Chat.ID), "import.usage"))
+		return
+	}
+	h.importDocument(msg.Chat.ID, senderID(msg.From), msg.ReplyToMessage.Document)
+}
+
+// handleImportCaption imports a document sent with /import as its caption.
+// It reports whether the message was such a document.
+func (h *Handlers) handleImportCaption(msg *tgbotapi.Message) bool {
+	if msg.Document == nil {
+		return false
+	}
+	fields := strings.Fields(msg.Caption)
+	if len(fields) == 0 {
+		return false
+	}
+	command, mention, _ := strings.Cut(fields[0], "@")
+	if command != "/import" || mention != "" && !strings.EqualFold(mention, h.api.Self.UserName) {
+		return false
+	}
+
+	h.trackChat(msg.Chat)
+	if h.checkAccess(msg) {
+		h.importDocument(msg.Chat.ID, senderID(msg.From), msg.Document)
+	}
+	return true
+}
+
+// importDocument downloads an export and recreates its subscriptions, on
+// behalf of the user who sent it.
+func (h *Handlers) importDocument(chatID, userID int64, document *tgbotapi.Document) {
+	lang := h.lang(chatID)
+	if document.FileSize > maxImportSize {
+		h.sendReply(chatID, i18n.T(lang, "import.invalid"))
+		return
+	}
+
+	data, err := h.downloadFile(document.FileID)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "import.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to download import")
+		return
+	}
+
+	var export storage.ChatExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		h.sendReply(chatID, i18n.T(lang, "import.invalid"))
+		return
+	}
+
+	denied, err := h.dropUnreadable(chatID, userID, &export)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "import.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to validate imported repositories")
+		return
+	}
+
+	result, err := h.store.ImportChat(chatID, &export, github.ValidateImport)
+	if errors.Is(err, storage.ErrUnsupportedExport) {
+		h.sendReply(chatID, i18n.T(lang, "import.invalid"))
+		return
+	}
+	if err != nil {
+		// Entries before the failure stay imported
+		h.sendReply(chatID, i18n.T(lang, "import.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to import subscriptions")
+		return
+	}
+
+	text := i18n.T(lang, "import.success", result.Subscriptions, result.Users, result.Topics)
+	if result.Skipped > 0 {
+		text += i18n.T(lang, "import.skipped", result.Skipped)
+	}
+	if len(denied) > 0 {
+		text += i18n.T(lang, "import.denied", strings.Join(denied, ", "))
+	}
+	if result.QuotaReached {
+		text += i18n.T(lang, "import.quota")
+	}
+	h.sendMarkdown(chatID, text)
+}
+
+// dropUnreadable removes the subscriptions of an export to repositories
+// the user may not subscribe the chat to, see canRead, and returns them.
+// Entries that are not repositories are left for ImportChat to skip.
+func (h *Handlers) dropUnreadable(chatID, userID int64, export *storage.ChatExport) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var denied []string
+	kept := export.Subscriptions[:0]
+	for _, sub := range export.Subscriptions {
+		owner, repo, err := parseRepoArg(sub.Repo)
+		if err != nil {
+			kept = append(kept, sub)
+			continue
+		}
+		readable, err := h.canRead(ctx, chatID, userID, owner, repo)
+		if err != nil {
+			return nil, err
+		}
+		if !readable {
+			denied = append(denied, sub.Repo)
+			continue
+		}
+		kept = append(kept, sub)
+	}
+	export.Subscriptions = kept
+	return denied, nil
+}
+
+// downloadFile fetches a file sent to the bot, up to maxImportSize bytes.
+func (h *Handlers) downloadFile(fileID string) ([]byte, error) {
+	url, err := h.api.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+}
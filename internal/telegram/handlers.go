@@ -2,12 +2,22 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/backup"
 	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/metrics"
 	"github.com/user/githubbot/internal/storage"
 	"github.com/user/githubbot/pkg/logger"
 )
@@ -15,16 +25,36 @@ import (
 // Handlers manages command handling for the bot.
 type Handlers struct {
 	api       *tgbotapi.BotAPI
-	store     *storage.SubscriptionStore
+	store     storage.Store
+	settings  *storage.SettingsStore
 	ghClient  *github.Client
 	startTime time.Time
+	backups   *backup.Backuper
+
+	// Settings a configuration reload replaces, guarded by mu
+	mu            sync.RWMutex
+	admins        map[int64]bool
+	defaultEvents []storage.EventType // Events of subscriptions made without a selection
+	access        string              // See Access constants
+	allowedUsers  map[int64]bool
+	allowedChats  map[int64]bool
+
+	publicURL string   // Where the HTTP server is reachable, for the URLs of custom hooks
+	routes    []string // Sink destinations subscriptions can be routed to, like "discord:releases"
+
+	deviceFlow *github.DeviceFlow // Links GitHub accounts for /login, nil if disabled
+	loginMu    sync.Mutex
+	logins     map[int64]context.CancelFunc // Logins waiting for their code, by user
+
+	previewer Previewer // Sends /test previews, nil if disabled
 }
 
 // NewHandlers creates a new handlers instance.
-func NewHandlers(api *tgbotapi.BotAPI, store *storage.SubscriptionStore) *Handlers {
+func NewHandlers(api *tgbotapi.BotAPI, store storage.Store) *Handlers {
 	return &Handlers{
-		api:   api,
-		store: store,
+		api:           api,
+		store:         store,
+		defaultEvents: storage.DefaultEvents(),
 	}
 }
 
@@ -33,6 +63,48 @@ func (h *Handlers) SetGitHubClient(client *github.Client) {
 	h.ghClient = client
 }
 
+// SetSettingsStore sets the store used by the /setting admin command.
+func (h *Handlers) SetSettingsStore(settings *storage.SettingsStore) {
+	h.settings = settings
+}
+
+// SetAdmins sets the Telegram user IDs allowed to run admin commands.
+func (h *Handlers) SetAdmins(ids []int64) {
+	admins := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		admins[id] = true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.admins = admins
+}
+
+// SetDefaultPreset sets the preset of subscriptions made without an event
+// selection.
+func (h *Handlers) SetDefaultPreset(name string) {
+	events, ok := storage.PresetEvents(name)
+	if !ok {
+		logger.Warn().Str("preset", name).Msg("Unknown default preset, using the default events")
+		events = storage.DefaultEvents()
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.defaultEvents = events
+}
+
+// defaultEventTypes returns the events of subscriptions made without a
+// selection.
+func (h *Handlers) defaultEventTypes() []storage.EventType {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.defaultEvents
+}
+
+// SetBackuper enables /admin backup.
+func (h *Handlers) SetBackuper(b *backup.Backuper) {
+	h.backups = b
+}
+
 // SetStartTime sets the bot start time for uptime calculation.
 func (h *Handlers) SetStartTime(t time.Time) {
 	h.startTime = t
@@ -45,13 +117,18 @@ func (h *Handlers) HandleCommand(msg *tgbotapi.Message) {
 
 	logger.Debug().
 		Str("command", command).
-		Str("args", args).
+		Str("args", loggedArgs(command, args)).
 		Int64("chat_id", msg.Chat.ID).
 		Msg("Received command")
 
 	// Track chat for future notifications
 	h.trackChat(msg.Chat)
+	if !h.checkAccess(msg) {
+		h.audit(msg.Chat.ID, msg.From, command, loggedArgs(command, args), storage.AuditDenied)
+		return
+	}
 
+	outcome := storage.AuditHandled
 	switch command {
 	case "start":
 		h.handleStart(msg)
@@ -63,11 +140,89 @@ func (h *Handlers) HandleCommand(msg *tgbotapi.Message) {
 		h.handleUnsubscribe(msg, args)
 	case "list":
 		h.handleList(msg)
+	case "info":
+		h.handleInfo(msg, args)
+	case "test":
+		h.handleTest(msg, args)
+	case "latest":
+		h.handleLatest(msg, args)
+	case "commits":
+		h.handleCommits(msg, args)
+	case "trending":
+		h.handleTrending(msg, args)
+	case "issues":
+		h.handleIssues(msg, args, false)
+	case "prs":
+		h.handleIssues(msg, args, true)
 	case "status":
 		h.handleStatus(msg)
+	case "stats":
+		h.handleStats(msg)
+	case "history":
+		h.handleHistory(msg, args)
+	case "export":
+		h.handleExport(msg)
+	case "import":
+		h.handleImport(msg)
+	case "filter":
+		h.handleFilter(msg, args)
+	case "setupcheck":
+		h.handleSetupCheck(msg)
+	case "diagnose":
+		h.handleDiagnose(msg, args)
+	case "pollinterval":
+		h.handlePollInterval(msg, args)
+	case "pause":
+		h.handlePause(msg, true)
+	case "resume":
+		h.handlePause(msg, false)
+	case "quiet":
+		h.handleQuiet(msg, args)
+	case "digest":
+		h.handleDigest(msg, args)
+	case "silent":
+		h.handleSilent(msg, args)
+	case "timezone", "tz":
+		h.handleTimezone(msg, args)
+	case "language", "lang":
+		h.handleLanguage(msg, args)
+	case "format":
+		h.handleFormat(msg, args)
+	case "dryrun":
+		h.handleDryRun(msg, args)
+	case "photos":
+		h.handlePhotos(msg, args)
+	case "template":
+		h.handleTemplate(msg, args)
+	case "hook":
+		h.handleHook(msg, args)
+	case "route":
+		h.handleRoute(msg, args)
+	case "login":
+		h.handleLogin(msg)
+	case "logout":
+		h.handleLogout(msg)
+	case "settoken":
+		h.handleSetToken(msg, args)
+	case "bindchannel":
+		h.handleBindChannel(msg, args)
+	case "unbindchannel":
+		h.handleUnbindChannel(msg)
+	case "mute":
+		h.handleMute(msg, args)
+	case "unmute":
+		h.handleUnmute(msg, args)
+	case "setting":
+		h.handleSetting(msg, args)
+	case "admin":
+		h.handleAdmin(msg, args)
+	case "settings":
+		h.handleEventSettings(msg, args)
 	default:
-		h.sendReply(msg.Chat.ID, "未知命令。使用 /help 查看可用命令。")
+		outcome = storage.AuditUnknown
+		h.sendReply(msg.Chat.ID, i18n.T(h.lang(msg.Chat.ID), "common.unknown_command"))
 	}
+	h.audit(msg.Chat.ID, msg.From, command, loggedArgs(command, args), outcome)
 }
 
 // HandleCallback handles inline keyboard callbacks.
@@ -82,10 +237,61 @@ func (h *Handlers) HandleCallback(callback *tgbotapi.CallbackQuery) {
 		return
 	}
 
+	var chatID int64
+	if callback.Message != nil {
+		chatID = callback.Message.Chat.ID
+	}
+	action, data := "button:"+parts[0], strings.Join(parts[1:], ":")
+
+	if parts[0] == "access" {
+		h.audit(chatID, callback.From, action, data, storage.AuditHandled)
+		if len(parts) == 3 {
+			h.handleAccessCallback(callback, parts[1], parts[2])
+		}
+		return
+	}
+	if callback.Message == nil || !h.hasAccess(chatID, callback.From) {
+		h.audit(chatID, callback.From, action, data, storage.AuditDenied)
+		return
+	}
+	h.audit(chatID, callback.From, action, data, storage.AuditHandled)
+
 	switch parts[0] {
+	case "sub":
+		if len(parts) == 3 {
+			h.handleSubscribeCallback(callback, parts[1], parts[2])
+		}
 	case "unsub":
 		if len(parts) == 3 {
 			h.handleUnsubscribeCallback(callback, parts[1], parts[2])
+		} else if len(parts) == 2 {
+			if sub := h.callbackSubscription(callback, parts[1]); sub != nil {
+				h.handleUnsubscribeCallback(callback, sub.RepoOwner, sub.RepoName)
+			}
+		}
+	case "mute":
+		if len(parts) == 2 {
+			h.handleMuteCallback(callback, parts[1])
+		}
+	case "ack":
+		if len(parts) == 2 {
+			h.handleAcknowledgeCallback(callback, parts[1])
+		}
+	case "list":
+		if len(parts) == 2 {
+			h.handleListPageCallback(callback, parts[1])
+		}
+	case "issues", "prs":
+		if len(parts) == 5 {
+			h.handleIssuesPageCallback(callback, parts[0] == "prs", parts[1], parts[2], parts[3], parts[4])
+		}
+	case "evt":
+		if len(parts) == 3 {
+			h.handleEventToggleCallback(callback, parts[1], storage.EventType(parts[2]))
+		}
+	case "wizard":
+		if len(parts) == 2 && parts[1] == "subscribe" {
+			h.handleWizardCallback(callback)
 		}
 	}
 }
@@ -108,271 +314,2402 @@ func (h *Handlers) trackChat(chat *tgbotapi.Chat) {
 
 // handleStart sends a welcome message.
 func (h *Handlers) handleStart(msg *tgbotapi.Message) {
-	text := `🤖 *欢迎使用 GitHub 监控机器人！*
+	h.sendMarkdown(msg.Chat.ID, i18n.T(h.lang(msg.Chat.ID), "start.text"))
+}
+
+// handleHelp sends help information.
+func (h *Handlers) handleHelp(msg *tgbotapi.Message) {
+	h.sendMarkdown(msg.Chat.ID, i18n.T(h.lang(msg.Chat.ID), "help.text"))
+}
+
+// handleSubscribe handles the subscribe command.
+func (h *Handlers) handleSubscribe(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.usage"))
+		return
+	}
 
-我可以帮助你监控 *任意 GitHub 公有仓库* 的变动，包括：
-• 📨 新的提交 (Push)
-• 🎉 版本发布 (Release)
-• 📝 Issue 变动
-• 🔀 Pull Request 变动
+	if topic, ok := strings.CutPrefix(fields[0], "topic:"); ok {
+		h.handleSubscribeTopic(msg, topic)
+		return
+	}
 
-*快速开始：*
-使用 ` + "`/subscribe owner/repo`" + ` 订阅仓库即可！
+	// Optional event selection, e.g. "releases,issues" or "preset=maintainer"
+	events := h.defaultEventTypes()
+	if len(fields) > 1 {
+		var err error
+		events, err = storage.ParseEventTypes(strings.Join(fields[1:], ","))
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.invalid_events"))
+			return
+		}
+	}
 
-*示例：*
-` + "`/subscribe torvalds/linux`" + `
-` + "`/subscribe microsoft/vscode`" + `
+	if login, ok := strings.CutPrefix(fields[0], "user:"); ok {
+		h.handleSubscribeUser(msg, login, events)
+		return
+	}
 
-使用 /help 查看所有命令。`
+	owner, repo, err := parseRepoArg(fields[0])
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
 
-	h.sendMarkdown(msg.Chat.ID, text)
+	h.subscribe(msg.Chat.ID, senderID(msg.From), owner, repo, events)
 }
 
-// handleHelp sends help information.
-func (h *Handlers) handleHelp(msg *tgbotapi.Message) {
-	text := `📚 *命令帮助*
+// senderID returns the ID of the user who sent a message or pressed a
+// button, 0 if unknown.
+func senderID(user *tgbotapi.User) int64 {
+	if user == nil {
+		return 0
+	}
+	return user.ID
+}
 
-*订阅管理：*
-• ` + "`/subscribe <owner/repo>`" + ` - 订阅仓库
-• ` + "`/unsubscribe <owner/repo>`" + ` - 取消订阅
-• ` + "`/list`" + ` - 查看当前订阅
+// subscribe subscribes a chat to a repository on behalf of a user and
+// confirms the subscription. The repository must be readable by the
+// configured tokens, the chat's token or the account the user linked, as its
+// events are delivered to every chat subscribed to it.
+func (h *Handlers) subscribe(chatID, userID int64, owner, repo string, events []storage.EventType) {
+	lang := h.lang(chatID)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-*快捷命令：*
-• ` + "`/sub`" + ` - 订阅仓库的简写
-• ` + "`/unsub`" + ` - 取消订阅的简写
+	readable, err := h.canRead(ctx, chatID, userID, owner, repo)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "subscribe.validate_error"))
+		logger.Error().Err(err).Str("repo", owner+"/"+repo).Msg("Failed to validate repository")
+		return
+	}
+	if !readable {
+		text := i18n.T(lang, "subscribe.repo_not_found", owner, repo)
+		if h.deviceFlow != nil {
+			text += i18n.T(lang, "subscribe.private_hint")
+		}
+		h.sendReply(chatID, text)
+		return
+	}
 
-*示例：*
-` + "```" + `
-/subscribe torvalds/linux
-/subscribe microsoft/vscode
-/sub golang/go
-/list
-/unsub torvalds/linux
-` + "```" + `
+	err = h.store.Subscribe(chatID, owner, repo, events)
+	if errors.Is(err, storage.ErrChatQuotaExceeded) {
+		perChat, _ := h.store.Quotas()
+		h.sendReply(chatID, i18n.T(lang, "subscribe.chat_quota", perChat))
+		return
+	}
+	if errors.Is(err, storage.ErrRepoQuotaExceeded) {
+		h.sendReply(chatID, i18n.T(lang, "subscribe.repo_quota"))
+		return
+	}
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "subscribe.failed"))
+		logger.Error().Err(err).Str("repo", owner+"/"+repo).Msg("Failed to subscribe")
+		return
+	}
 
-💡 订阅后，当仓库有新的 commit、release、issue 或 PR 时，你将自动收到通知。`
+	text := i18n.T(lang, "subscribe.success", owner, repo)
+	for _, event := range events {
+		text += markdown.Sprintf("• %s\n", eventLabel(event, lang))
+	}
+	text += i18n.T(lang, "subscribe.success_footer")
 
-	h.sendMarkdown(msg.Chat.ID, text)
+	h.sendMarkdown(chatID, text)
 }
 
-// handleSubscribe handles the subscribe command.
-func (h *Handlers) handleSubscribe(msg *tgbotapi.Message, args string) {
-	if args == "" {
-		h.sendReply(msg.Chat.ID, "❌ 请指定仓库，格式: `/subscribe owner/repo`")
+// handleSubscribeUser subscribes the chat to all public repositories of a
+// GitHub user, including ones the user creates later.
+func (h *Handlers) handleSubscribeUser(msg *tgbotapi.Message, login string, events []storage.EventType) {
+	lang := h.lang(msg.Chat.ID)
+	if !isValidLogin(login) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.user_usage"))
 		return
 	}
-
-	owner, repo, err := parseRepoArg(args)
-	if err != nil {
-		h.sendReply(msg.Chat.ID, "❌ 仓库格式错误，请使用: `owner/repo`")
+	if h.ghClient == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.failed"))
 		return
 	}
 
-	// Validate repository exists (if GitHub client is set)
-	if h.ghClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
 
-		exists, err := h.ghClient.ValidateRepository(ctx, owner, repo)
-		if err != nil {
-			h.sendReply(msg.Chat.ID, "⚠️ 验证仓库时出错，请稍后重试")
-			logger.Error().Err(err).Str("repo", args).Msg("Failed to validate repository")
-			return
-		}
-		if !exists {
-			h.sendReply(msg.Chat.ID, fmt.Sprintf("❌ 仓库 `%s/%s` 不存在或不可访问", owner, repo))
-			return
-		}
+	canonical, err := h.ghClient.GetUserLogin(ctx, login)
+	if errors.Is(err, github.ErrUserNotFound) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.user_not_found", login))
+		return
+	}
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.validate_error"))
+		logger.Error().Err(err).Str("user", login).Msg("Failed to validate user")
+		return
 	}
 
-	// Subscribe with default events
-	events := storage.DefaultEvents()
-	if err := h.store.Subscribe(msg.Chat.ID, owner, repo, events); err != nil {
-		h.sendReply(msg.Chat.ID, "❌ 订阅失败，请稍后重试")
-		logger.Error().Err(err).Str("repo", args).Msg("Failed to subscribe")
+	sub, err := h.store.SubscribeUser(msg.Chat.ID, canonical, events)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.failed"))
+		logger.Error().Err(err).Str("user", canonical).Msg("Failed to subscribe to user")
 		return
 	}
+	added, err := github.SyncUserRepos(ctx, h.ghClient, h.store, *sub)
+	if err != nil {
+		// The poller picks the synchronization up again later
+		logger.Warn().Err(err).Str("user", canonical).Msg("Failed to sync user repositories")
+	}
 
-	text := fmt.Sprintf(`✅ *成功订阅 %s/%s*
+	text := i18n.T(lang, "subscribe.user_success", canonical, added)
+	for _, event := range events {
+		text += markdown.Sprintf("• %s\n", eventLabel(event, lang))
+	}
+	text += i18n.T(lang, "subscribe.user_footer")
 
-监控事件：
-• 📨 Push (提交)
-• 🎉 Release (发布)
-• 📝 Issues
-• 🔀 Pull Requests
+	h.sendMarkdown(msg.Chat.ID, text)
+}
 
-当仓库有新动态时，你将自动收到通知！`, owner, repo)
+// handleSubscribeTopic subscribes the chat to new repositories tagged with a
+// GitHub topic.
+func (h *Handlers) handleSubscribeTopic(msg *tgbotapi.Message, topic string) {
+	lang := h.lang(msg.Chat.ID)
+	topic = strings.ToLower(topic)
+	if !isValidTopic(topic) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.topic_usage"))
+		return
+	}
 
-	h.sendMarkdown(msg.Chat.ID, text)
+	if err := h.store.SubscribeTopic(msg.Chat.ID, topic); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.failed"))
+		logger.Error().Err(err).Str("topic", topic).Msg("Failed to subscribe to topic")
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.topic_success", topic))
+}
+
+// eventLabel returns the display name of an event type.
+func eventLabel(event storage.EventType, lang i18n.Lang) string {
+	if label, ok := i18n.Lookup(lang, "event."+string(event)); ok {
+		return label
+	}
+	return string(event)
 }
 
 // handleUnsubscribe handles the unsubscribe command.
 func (h *Handlers) handleUnsubscribe(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
 	if args == "" {
-		h.sendReply(msg.Chat.ID, "❌ 请指定仓库，格式: `/unsubscribe owner/repo`")
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.usage"))
+		return
+	}
+
+	if topic, ok := strings.CutPrefix(args, "topic:"); ok {
+		topic = strings.ToLower(topic)
+		if err := h.store.UnsubscribeTopic(msg.Chat.ID, topic); err != nil {
+			if err.Error() == "subscription not found" {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.topic_not_found", topic))
+			} else {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.failed"))
+				logger.Error().Err(err).Str("topic", topic).Msg("Failed to unsubscribe from topic")
+			}
+			return
+		}
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.topic_success", topic))
+		return
+	}
+
+	if login, ok := strings.CutPrefix(args, "user:"); ok {
+		removed, err := h.store.UnsubscribeUser(msg.Chat.ID, login)
+		if err != nil {
+			if err.Error() == "subscription not found" {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.user_not_found", login))
+			} else {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.failed"))
+				logger.Error().Err(err).Str("user", login).Msg("Failed to unsubscribe from user")
+			}
+			return
+		}
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.user_success", login, removed))
 		return
 	}
 
 	owner, repo, err := parseRepoArg(args)
 	if err != nil {
-		h.sendReply(msg.Chat.ID, "❌ 仓库格式错误，请使用: `owner/repo`")
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
 		return
 	}
 
 	if err := h.store.Unsubscribe(msg.Chat.ID, owner, repo); err != nil {
 		if err.Error() == "subscription not found" {
-			h.sendReply(msg.Chat.ID, fmt.Sprintf("❌ 未找到 `%s/%s` 的订阅", owner, repo))
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
 		} else {
-			h.sendReply(msg.Chat.ID, "❌ 取消订阅失败，请稍后重试")
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.failed"))
 			logger.Error().Err(err).Str("repo", args).Msg("Failed to unsubscribe")
 		}
 		return
 	}
 
-	h.sendReply(msg.Chat.ID, fmt.Sprintf("✅ 已取消订阅 `%s/%s`", owner, repo))
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "unsubscribe.success", owner, repo))
 }
 
-// handleUnsubscribeCallback handles inline unsubscribe button.
-func (h *Handlers) handleUnsubscribeCallback(callback *tgbotapi.CallbackQuery, owner, repo string) {
-	chatID := callback.Message.Chat.ID
-
-	if err := h.store.Unsubscribe(chatID, owner, repo); err != nil {
-		h.sendReply(chatID, "❌ 取消订阅失败")
+// handlePause pauses or resumes all notifications to the chat.
+func (h *Handlers) handlePause(msg *tgbotapi.Message, paused bool) {
+	lang := h.lang(msg.Chat.ID)
+	if err := h.store.SetChatPaused(msg.Chat.ID, paused); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Bool("paused", paused).Msg("Failed to update chat pause state")
 		return
 	}
 
-	h.sendReply(chatID, fmt.Sprintf("✅ 已取消订阅 `%s/%s`", owner, repo))
+	if paused {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "pause.paused"))
+	} else {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "pause.resumed"))
+	}
 }
 
-// handleList shows all current subscriptions.
-func (h *Handlers) handleList(msg *tgbotapi.Message) {
-	subs, err := h.store.GetSubscriptionsByChat(msg.Chat.ID)
-	if err != nil {
-		h.sendReply(msg.Chat.ID, "❌ 获取订阅列表失败")
-		logger.Error().Err(err).Msg("Failed to get subscriptions")
+// handleQuiet shows, sets or clears the quiet hours of the chat.
+func (h *Handlers) handleQuiet(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	args = strings.TrimSpace(args)
+	if args == "" {
+		chat, err := h.store.GetChat(msg.Chat.ID)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.settings_failed"))
+			logger.Error().Err(err).Msg("Failed to get chat")
+			return
+		}
+		if chat == nil || chat.QuietHours == "" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "quiet.none"))
+			return
+		}
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "quiet.current", chat.QuietHours))
 		return
 	}
 
-	if len(subs) == 0 {
-		h.sendReply(msg.Chat.ID, "📭 当前没有任何订阅\n\n使用 `/subscribe owner/repo` 来订阅仓库")
-		return
+	value := ""
+	if args != "off" {
+		quiet, err := storage.ParseQuietHours(args)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "quiet.invalid"))
+			return
+		}
+		value = quiet.String()
 	}
 
-	text := fmt.Sprintf("📋 *当前订阅 (%d 个)*\n\n", len(subs))
-	for i, sub := range subs {
-		text += fmt.Sprintf("%d. [`%s/%s`](https://github.com/%s/%s)\n",
-			i+1, sub.RepoOwner, sub.RepoName, sub.RepoOwner, sub.RepoName)
+	if err := h.store.SetQuietHours(msg.Chat.ID, value); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set quiet hours")
+		return
 	}
 
-	text += "\n使用 `/unsubscribe owner/repo` 取消订阅"
-
-	h.sendMarkdown(msg.Chat.ID, text)
+	if value == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "quiet.disabled"))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "quiet.set", value, h.chatLocation(msg.Chat.ID)))
 }
 
-// handleStatus shows bot status information.
-func (h *Handlers) handleStatus(msg *tgbotapi.Message) {
-	// Calculate uptime
-	uptime := time.Since(h.startTime)
-	uptimeStr := formatDuration(uptime)
+// handleTimezone shows or sets the time zone used for the chat's times.
+func (h *Handlers) handleTimezone(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	args = strings.TrimSpace(args)
+	if args == "" {
+		loc := h.chatLocation(msg.Chat.ID)
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "timezone.current", loc, time.Now().In(loc).Format("2006-01-02 15:04")))
+		return
+	}
 
-	// Get subscription count
-	repos, err := h.store.GetAllSubscribedRepos()
-	repoCount := 0
-	if err == nil {
-		repoCount = len(repos)
+	value := ""
+	if args != "off" {
+		loc, err := time.LoadLocation(args)
+		if err != nil || args == "Local" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "timezone.invalid"))
+			return
+		}
+		value = loc.String()
 	}
 
-	// Get user's subscription count
-	userSubs, err := h.store.GetSubscriptionsByChat(msg.Chat.ID)
-	userSubCount := 0
-	if err == nil {
-		userSubCount = len(userSubs)
+	if err := h.store.SetChatTimezone(msg.Chat.ID, value); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set chat timezone")
+		return
 	}
 
-	// Get GitHub API rate limit
-	rateLimitInfo := "未知"
-	if h.ghClient != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	if value == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "timezone.reset", time.Local))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "timezone.set", value))
+}
 
-		limits, err := h.ghClient.GetRateLimit(ctx)
-		if err == nil && limits != nil && limits.Core != nil {
-			remaining := limits.Core.Remaining
-			limit := limits.Core.Limit
-			resetTime := limits.Core.Reset.Time
-			resetIn := time.Until(resetTime)
-			rateLimitInfo = fmt.Sprintf("%d/%d (重置于 %s)", remaining, limit, formatDuration(resetIn))
+// handleFormat shows or sets the parse mode of the chat's notifications.
+func (h *Handlers) handleFormat(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	mode := strings.ToLower(strings.TrimSpace(args))
+	if mode == "" {
+		chat, err := h.store.GetChat(msg.Chat.ID)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.settings_failed"))
+			logger.Error().Err(err).Msg("Failed to get chat")
+			return
+		}
+		current := i18n.Plain(lang, "format.default")
+		if chat != nil && chat.ParseMode != storage.ParseModeDefault {
+			current = chat.ParseMode
 		}
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "format.current", current))
+		return
 	}
 
-	text := fmt.Sprintf(`📊 *Bot 状态*
+	switch mode {
+	case "default":
+		mode = storage.ParseModeDefault
+	case storage.ParseModeMarkdown, storage.ParseModeHTML:
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "format.invalid"))
+		return
+	}
 
-⏱️ *运行时间:* %s
-📡 *监控模式:* Polling
+	if err := h.store.SetChatParseMode(msg.Chat.ID, mode); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set chat parse mode")
+		return
+	}
 
-📦 *全局统计:*
-• 监控仓库数: %d
+	if mode == storage.ParseModeDefault {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "format.reset"))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "format.set", mode))
+}
 
-👤 *你的订阅:*
-• 订阅数: %d
+// handlePhotos shows or sets whether the chat's notifications are sent as
+// preview images.
+func (h *Handlers) handlePhotos(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	var enabled bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		chat, err := h.store.GetChat(msg.Chat.ID)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.settings_failed"))
+			logger.Error().Err(err).Msg("Failed to get chat")
+			return
+		}
+		if chat != nil && chat.Photos {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "photos.on"))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "photos.off"))
+		}
+		return
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "photos.invalid"))
+		return
+	}
 
-🔗 *GitHub API:*
-• 配额: %s
-`, uptimeStr, repoCount, userSubCount, rateLimitInfo)
+	if err := h.store.SetChatPhotos(msg.Chat.ID, enabled); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set chat photos")
+		return
+	}
 
-	h.sendMarkdown(msg.Chat.ID, text)
+	if enabled {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "photos.on"))
+	} else {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "photos.off"))
+	}
 }
 
-// formatDuration formats a duration to a human-readable string.
-func formatDuration(d time.Duration) string {
-	days := int(d.Hours() / 24)
-	hours := int(d.Hours()) % 24
-	minutes := int(d.Minutes()) % 60
-	seconds := int(d.Seconds()) % 60
+// handleDryRun shows or sets whether the chat's notifications are only
+// logged, to try filters and templates on live events without sending them.
+func (h *Handlers) handleDryRun(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	var enabled bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "":
+		chat, err := h.store.GetChat(msg.Chat.ID)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.settings_failed"))
+			logger.Error().Err(err).Msg("Failed to get chat")
+			return
+		}
+		if chat != nil && chat.DryRun {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "dryrun.on"))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "dryrun.off"))
+		}
+		return
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "dryrun.invalid"))
+		return
+	}
 
-	if days > 0 {
-		return fmt.Sprintf("%d天 %d小时 %d分钟", days, hours, minutes)
-	} else if hours > 0 {
-		return fmt.Sprintf("%d小时 %d分钟", hours, minutes)
-	} else if minutes > 0 {
-		return fmt.Sprintf("%d分钟 %d秒", minutes, seconds)
+	if err := h.store.SetChatDryRun(msg.Chat.ID, enabled); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set chat dry run")
+		return
 	}
-	return fmt.Sprintf("%d秒", seconds)
-}
 
-// sendReply sends a simple text reply.
-func (h *Handlers) sendReply(chatID int64, text string) {
-	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	if _, err := h.api.Send(msg); err != nil {
-		logger.Error().Err(err).Msg("Failed to send reply")
+	if enabled {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "dryrun.on"))
+	} else {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "dryrun.off"))
 	}
 }
 
-// sendMarkdown sends a markdown-formatted message.
-func (h *Handlers) sendMarkdown(chatID int64, text string) {
-	msg := tgbotapi.NewMessage(chatID, text)
-	msg.ParseMode = tgbotapi.ModeMarkdown
-	msg.DisableWebPagePreview = true
-	if _, err := h.api.Send(msg); err != nil {
-		logger.Error().Err(err).Msg("Failed to send markdown message")
+// handleLanguage shows or sets the language of the chat.
+func (h *Handlers) handleLanguage(msg *tgbotapi.Message, args string) {
+	args = strings.TrimSpace(args)
+	if args == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(h.lang(msg.Chat.ID), "language.current"))
+		return
 	}
-}
 
-// parseRepoArg parses "owner/repo" format.
-func parseRepoArg(arg string) (owner, repo string, err error) {
-	arg = strings.TrimSpace(arg)
-	parts := strings.Split(arg, "/")
-	if len(parts) != 2 {
-		return "", "", fmt.Errorf("invalid format")
+	lang, ok := i18n.Parse(args)
+	if !ok {
+		h.sendReply(msg.Chat.ID, i18n.T(h.lang(msg.Chat.ID), "language.invalid"))
+		return
 	}
 
-	owner = strings.TrimSpace(parts[0])
-	repo = strings.TrimSpace(parts[1])
+	if err := h.store.SetChatLanguage(msg.Chat.ID, string(lang)); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set chat language")
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "language.set"))
+}
 
-	if owner == "" || repo == "" {
-		return "", "", fmt.Errorf("empty owner or repo")
+// lang returns the language configured for a chat.
+func (h *Handlers) lang(chatID int64) i18n.Lang {
+	chat, err := h.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
 	}
+	if chat == nil {
+		return i18n.Default()
+	}
+	return i18n.Resolve(chat.Language)
+}
 
-	return owner, repo, nil
+// chatLocation returns the time zone configured for a chat.
+func (h *Handlers) chatLocation(chatID int64) *time.Location {
+	chat, err := h.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat")
+	}
+	return chat.Location()
+}
+
+const (
+	// defaultMuteDuration is how long /mute and the notification button
+	// silence a subscription when no duration is given.
+	defaultMuteDuration = time.Hour
+
+	// maxMuteDuration caps how long a subscription can be muted.
+	maxMuteDuration = 30 * 24 * time.Hour
+)
+
+// handleMute silences a subscription for a while.
+func (h *Handlers) handleMute(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "mute.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(fields[0])
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	duration := defaultMuteDuration
+	if len(fields) > 1 {
+		duration, err = parseMuteDuration(fields[1])
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "mute.invalid_duration"))
+			return
+		}
+	}
+
+	until := time.Now().Add(duration)
+	if err := h.store.MuteSubscription(msg.Chat.ID, owner, repo, until); err != nil {
+		if err.Error() == "subscription not found" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "mute.failed"))
+			logger.Error().Err(err).Str("repo", fields[0]).Msg("Failed to mute subscription")
+		}
+		return
+	}
+
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "mute.success", owner, repo, formatDuration(duration, lang), owner, repo))
+}
+
+// handleUnmute resumes notifications of a muted subscription.
+func (h *Handlers) handleUnmute(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if args == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "unmute.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(args)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	if err := h.store.MuteSubscription(msg.Chat.ID, owner, repo, time.Time{}); err != nil {
+		if err.Error() == "subscription not found" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "unmute.failed"))
+			logger.Error().Err(err).Str("repo", args).Msg("Failed to unmute subscription")
+		}
+		return
+	}
+
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "unmute.success", owner, repo))
+}
+
+// handleDigest switches a subscription between real-time delivery and digests.
+func (h *Handlers) handleDigest(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "digest.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(fields[0])
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	var digest, reply string
+	switch strings.ToLower(fields[1]) {
+	case "daily":
+		digest = storage.DigestDaily
+		reply = "digest.daily"
+	case "weekly":
+		digest = storage.DigestWeekly
+		reply = "digest.weekly"
+	case "off":
+		digest = storage.DigestOff
+		reply = "digest.off"
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "digest.invalid_mode"))
+		return
+	}
+
+	if err := h.store.SetSubscriptionDigest(msg.Chat.ID, owner, repo, digest); err != nil {
+		if err.Error() == "subscription not found" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "digest.failed"))
+			logger.Error().Err(err).Str("repo", fields[0]).Msg("Failed to set digest mode")
+		}
+		return
+	}
+
+	h.sendReply(msg.Chat.ID, i18n.T(lang, reply, owner, repo))
+}
+
+// handleSilent shows or sets the event types of a subscription that are
+// delivered without a notification sound.
+func (h *Handlers) handleSilent(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(fields[0])
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	if len(fields) == 1 {
+		sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.failed"))
+			logger.Error().Err(err).Str("repo", fields[0]).Msg("Failed to get subscription")
+			return
+		}
+		if sub == nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+			return
+		}
+		events, _ := storage.ParseEvents(sub.Silent)
+		if len(events) == 0 {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.none", owner, repo))
+			return
+		}
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.current", owner, repo, eventLabels(events, lang)))
+		return
+	}
+
+	var events []storage.EventType
+	if !strings.EqualFold(fields[1], "off") {
+		events, err = storage.ParseEventTypes(strings.Join(fields[1:], ","))
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.invalid_events"))
+			return
+		}
+	}
+
+	if err := h.store.SetSubscriptionSilent(msg.Chat.ID, owner, repo, events); err != nil {
+		if err.Error() == "subscription not found" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.failed"))
+			logger.Error().Err(err).Str("repo", fields[0]).Msg("Failed to set silent events")
+		}
+		return
+	}
+
+	if len(events) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.off", owner, repo))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "silent.set", owner, repo, eventLabels(events, lang)))
+}
+
+// eventLabels joins the display names of event types.
+func eventLabels(events []storage.EventType, lang i18n.Lang) string {
+	labels := make([]string, len(events))
+	for i, e := range events {
+		labels[i] = eventLabel(e, lang)
+	}
+	return strings.Join(labels, ", ")
+}
+
+// handleTemplate shows or sets the custom message templates of a subscription.
+func (h *Handlers) handleTemplate(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	repoArg, rest := cutArg(args)
+	if repoArg == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "template.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "template.failed"))
+		logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to get subscription")
+		return
+	}
+	if sub == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		return
+	}
+	templates, err := storage.ParseTemplates(sub.Templates)
+	if err != nil {
+		logger.Warn().Err(err).Str("repo", repoArg).Msg("Failed to parse message templates")
+	}
+
+	eventArg, text := cutArg(rest)
+	if eventArg == "" {
+		h.sendReply(msg.Chat.ID, formatTemplates(owner, repo, templates, lang))
+		return
+	}
+
+	event, err := storage.ParseEventType(eventArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "template.invalid_event", eventArg))
+		return
+	}
+
+	text = trimQuotes(text)
+	switch {
+	case text == "":
+		reply := i18n.T(lang, "template.builtin", owner, repo, string(event))
+		if current, ok := templates[event]; ok {
+			reply = i18n.T(lang, "template.current", string(event), owner, repo, current)
+		}
+		fields := make([]string, 0, len(github.TemplateFields(event)))
+		for _, field := range github.TemplateFields(event) {
+			fields = append(fields, "{{."+field+"}}")
+		}
+		reply += i18n.T(lang, "template.fields", strings.Join(fields, " "))
+		h.sendReply(msg.Chat.ID, reply)
+		return
+	case strings.EqualFold(text, "off"):
+		text = ""
+	default:
+		if err := github.ValidateTemplate(event, text); err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "template.invalid", err.Error()))
+			return
+		}
+	}
+
+	if err := h.store.SetSubscriptionTemplate(msg.Chat.ID, owner, repo, event, text); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "template.failed"))
+		logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to set message template")
+		return
+	}
+
+	if text == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "template.reset", owner, repo, string(event)))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "template.set", string(event), owner, repo))
+}
+
+// formatTemplates lists the custom message templates of a subscription.
+func formatTemplates(owner, repo string, templates map[storage.EventType]string, lang i18n.Lang) string {
+	if len(templates) == 0 {
+		return i18n.T(lang, "template.none", owner, repo, owner, repo)
+	}
+	reply := i18n.T(lang, "template.list", owner, repo)
+	for _, event := range storage.AllEventTypes() {
+		if text, ok := templates[event]; ok {
+			reply += markdown.Sprintf("• %s: `%s`\n", string(event), text)
+		}
+	}
+	return reply
+}
+
+// cutArg splits off the first whitespace-separated argument, keeping the
+// remaining text, including its line breaks, intact.
+func cutArg(s string) (arg, rest string) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexFunc(s, unicode.IsSpace); i >= 0 {
+		return s[:i], strings.TrimSpace(s[i:])
+	}
+	return s, ""
+}
+
+// trimQuotes removes quotes around an argument, including the curly quotes
+// some Telegram clients substitute for straight ones.
+func trimQuotes(s string) string {
+	for _, q := range [][2]string{{`"`, `"`}, {"“", "”"}} {
+		if len(s) >= len(q[0])+len(q[1]) && strings.HasPrefix(s, q[0]) && strings.HasSuffix(s, q[1]) {
+			return strings.TrimSpace(s[len(q[0]) : len(s)-len(q[1])])
+		}
+	}
+	return s
+}
+
+// parseMuteDuration parses a mute duration such as "30m", "2h" or "1d".
+func parseMuteDuration(s string) (time.Duration, error) {
+	d, err := parseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 || d > maxMuteDuration {
+		return 0, fmt.Errorf("duration out of range")
+	}
+	return d, nil
+}
+
+// parseDuration parses a Go duration, also accepting whole days like "2d".
+func parseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// handleSubscribeCallback handles the subscribe button of /info.
+func (h *Handlers) handleSubscribeCallback(callback *tgbotapi.CallbackQuery, owner, repo string) {
+	h.subscribe(callback.Message.Chat.ID, senderID(callback.From), owner, repo, h.defaultEventTypes())
+}
+
+// handleUnsubscribeCallback handles inline unsubscribe button.
+func (h *Handlers) handleUnsubscribeCallback(callback *tgbotapi.CallbackQuery, owner, repo string) {
+	chatID := callback.Message.Chat.ID
+	lang := h.lang(chatID)
+
+	if err := h.store.Unsubscribe(chatID, owner, repo); err != nil {
+		h.sendReply(chatID, i18n.T(lang, "unsubscribe.failed_short"))
+		return
+	}
+
+	h.sendReply(chatID, i18n.T(lang, "unsubscribe.success", owner, repo))
+}
+
+// handleMuteCallback handles the mute button on notifications.
+func (h *Handlers) handleMuteCallback(callback *tgbotapi.CallbackQuery, idArg string) {
+	chatID := callback.Message.Chat.ID
+	sub := h.callbackSubscription(callback, idArg)
+	if sub == nil {
+		return
+	}
+
+	lang := h.lang(chatID)
+	until := time.Now().Add(defaultMuteDuration)
+	if err := h.store.MuteSubscription(chatID, sub.RepoOwner, sub.RepoName, until); err != nil {
+		h.sendReply(chatID, i18n.T(lang, "mute.failed"))
+		logger.Error().Err(err).Int64("subscription_id", sub.ID).Msg("Failed to mute subscription")
+		return
+	}
+
+	h.sendReply(chatID, i18n.T(lang, "mute.success", sub.RepoOwner, sub.RepoName,
+		formatDuration(defaultMuteDuration, lang), sub.RepoOwner, sub.RepoName))
+}
+
+// callbackSubscription looks up the subscription a callback button refers
+// to by ID, replying when it no longer exists.
+func (h *Handlers) callbackSubscription(callback *tgbotapi.CallbackQuery, idArg string) *storage.Subscription {
+	chatID := callback.Message.Chat.ID
+
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	sub, err := h.store.GetSubscriptionByID(chatID, id)
+	if err != nil {
+		logger.Error().Err(err).Int64("subscription_id", id).Msg("Failed to get subscription")
+		return nil
+	}
+	if sub == nil {
+		h.sendReply(chatID, i18n.T(h.lang(chatID), "common.sub_not_found_short"))
+	}
+	return sub
+}
+
+// handleAcknowledgeCallback handles the inline button acknowledging a compliance drift.
+func (h *Handlers) handleAcknowledgeCallback(callback *tgbotapi.CallbackQuery, idArg string) {
+	chatID := callback.Message.Chat.ID
+
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return
+	}
+
+	lang := h.lang(chatID)
+	sub, err := h.store.AcknowledgeCompliance(chatID, id)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "ack.failed"))
+		logger.Error().Err(err).Int64("subscription_id", id).Msg("Failed to acknowledge compliance")
+		return
+	}
+	if sub == nil {
+		h.sendReply(chatID, i18n.T(lang, "common.sub_not_found_short"))
+		return
+	}
+
+	h.sendReply(chatID, i18n.T(lang, "ack.success", sub.RepoOwner, sub.RepoName))
+}
+
+// handleList shows all current subscriptions.
+func (h *Handlers) handleList(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	text, markup, err := h.listPage(msg.Chat.ID, 0)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "list.failed"))
+		logger.Error().Err(err).Msg("Failed to get subscriptions")
+		return
+	}
+	if markup == nil {
+		h.sendReply(msg.Chat.ID, text)
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeMarkdownV2
+	reply.DisableWebPagePreview = true
+	reply.ReplyMarkup = *markup
+	if _, err := h.api.Send(reply); err != nil {
+		logger.Error().Err(err).Msg("Failed to send subscription list")
+	}
+}
+
+// listPageSize is how many subscriptions one page of /list shows.
+const listPageSize = 10
+
+// listPage renders a page of the chat's subscriptions with an unsubscribe
+// button per subscription and buttons to turn the page. Pages past the end
+// show the last page. Without subscriptions the markup is nil.
+func (h *Handlers) listPage(chatID int64, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	chat, err := h.store.GetChat(chatID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to get chat")
+	}
+	lang := h.lang(chatID)
+	loc := chat.Location()
+
+	subs, err := h.store.GetSubscriptionsByChat(chatID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(subs) == 0 {
+		return i18n.T(lang, "list.empty"), nil, nil
+	}
+
+	pages := (len(subs) + listPageSize - 1) / listPageSize
+	page = max(0, min(page, pages-1))
+	offset := page * listPageSize
+
+	text := i18n.T(lang, "list.title", len(subs))
+	if chat != nil && chat.Paused {
+		text += i18n.T(lang, "list.paused")
+	}
+	if chat != nil && chat.DryRun {
+		text += i18n.T(lang, "list.dry_run")
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, sub := range subs[offset:min(offset+listPageSize, len(subs))] {
+		repoURL := "https://github.com/" + url.PathEscape(sub.RepoOwner) + "/" + url.PathEscape(sub.RepoName)
+		text += markdown.Sprintf("%d. %s\n", offset+i+1, markdown.Raw(github.MarkdownLink(markdown.Code(sub.RepoOwner+"/"+sub.RepoName), repoURL)))
+		text += markdown.Sprintf("   %s\n", subscriptionSummary(sub))
+		if sub.IsMuted(time.Now()) {
+			text += i18n.T(lang, "list.muted_until", sub.MutedUntil.Time.In(loc).Format("2006-01-02 15:04"))
+		}
+		switch sub.Digest {
+		case storage.DigestDaily:
+			text += i18n.T(lang, "list.digest_daily")
+		case storage.DigestWeekly:
+			text += i18n.T(lang, "list.digest_weekly")
+		}
+		if sub.Sink != "" {
+			text += i18n.T(lang, "list.routed", sub.Sink)
+		}
+
+		row := tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			i18n.Plain(lang, "list.unsub_button", sub.RepoOwner, sub.RepoName),
+			fmt.Sprintf("unsub:%d", sub.ID),
+		))
+		if sub.ComplianceViolation != "" && !sub.ComplianceAcked {
+			text += i18n.T(lang, "list.compliance", sub.ComplianceViolation)
+			row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+				i18n.Plain(lang, "list.ack_button", sub.RepoOwner, sub.RepoName),
+				fmt.Sprintf("ack:%d", sub.ID),
+			))
+		}
+		rows = append(rows, row)
+	}
+
+	if pages > 1 {
+		text += i18n.T(lang, "list.page", page+1, pages)
+		var nav []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "list.prev"), fmt.Sprintf("list:%d", page-1)))
+		}
+		if page < pages-1 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "list.next"), fmt.Sprintf("list:%d", page+1)))
+		}
+		rows = append(rows, nav)
+	}
+	text += i18n.T(lang, "list.footer")
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return text, &markup, nil
+}
+
+// eventShortNames are the names /subscribe accepts for each event type, used
+// for compact listings.
+var eventShortNames = map[storage.EventType]string{
+	storage.EventTypePush:                     "push",
+	storage.EventTypeRelease:                  "releases",
+	storage.EventTypeTag:                      "tags",
+	storage.EventTypePackage:                  "packages",
+	storage.EventTypeIssue:                    "issues",
+	storage.EventTypePullRequest:              "prs",
+	storage.EventTypeStar:                     "stars",
+	storage.EventTypeWorkflowRun:              "ci",
+	storage.EventTypeDeployment:               "deployments",
+	storage.EventTypeWiki:                     "wiki",
+	storage.EventTypePullRequestReview:        "reviews",
+	storage.EventTypePullRequestReviewComment: "review_comments",
+}
+
+// subscriptionSummary describes a subscription in one line: its enabled
+// event types, which of the default ones are off, and its filters, e.g.
+// "push✅ releases✅ issues❌ prs✅ · branch=main".
+func subscriptionSummary(sub storage.Subscription) string {
+	events, err := storage.ParseEvents(sub.Events)
+	if err != nil {
+		events = storage.DefaultEvents()
+	}
+	enabled := make(map[storage.EventType]bool, len(events))
+	for _, e := range events {
+		enabled[e] = true
+	}
+	defaults := make(map[storage.EventType]bool)
+	for _, e := range storage.DefaultEvents() {
+		defaults[e] = true
+	}
+
+	var flags []string
+	for _, e := range storage.AllEventTypes() {
+		switch {
+		case enabled[e]:
+			flags = append(flags, eventShortNames[e]+"✅")
+		case defaults[e]:
+			flags = append(flags, eventShortNames[e]+"❌")
+		}
+	}
+	parts := []string{strings.Join(flags, " ")}
+
+	filters, _ := storage.ParseFilters(sub.Filters)
+	list := func(key string, values []string) {
+		if len(values) > 0 {
+			parts = append(parts, key+"="+strings.Join(values, ","))
+		}
+	}
+	list("branch", filters.Branches)
+	list("labels", filters.Labels)
+	list("-labels", filters.ExcludeLabels)
+	list("ignore", filters.IgnoreAuthors)
+	if filters.Include != "" {
+		parts = append(parts, "include="+filters.Include)
+	}
+	if filters.Exclude != "" {
+		parts = append(parts, "exclude="+filters.Exclude)
+	}
+	if filters.CI != storage.CIFilterAll {
+		parts = append(parts, "ci="+filters.CI)
+	}
+	if filters.Assets != "" {
+		parts = append(parts, "assets="+filters.Assets)
+	}
+	list("platforms", filters.Platforms)
+	list("licenses", filters.Licenses)
+
+	if silent, _ := storage.ParseEvents(sub.Silent); len(silent) > 0 {
+		names := make([]string, len(silent))
+		for i, e := range silent {
+			names[i] = eventShortNames[e]
+		}
+		list("silent", names)
+	}
+	if sub.Via != "" {
+		parts = append(parts, "via "+sub.Via)
+	}
+	return strings.Join(parts, " · ")
+}
+
+// handleListPageCallback turns the page of a /list message.
+func (h *Handlers) handleListPageCallback(callback *tgbotapi.CallbackQuery, pageArg string) {
+	page, err := strconv.Atoi(pageArg)
+	if err != nil {
+		return
+	}
+	chatID := callback.Message.Chat.ID
+	text, markup, err := h.listPage(chatID, page)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get subscriptions")
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, callback.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeMarkdownV2
+	edit.DisableWebPagePreview = true
+	edit.ReplyMarkup = markup
+	if _, err := h.api.Send(edit); err != nil {
+		logger.Error().Err(err).Msg("Failed to update subscription list")
+	}
+}
+
+// handleInfo shows the details of a repository with a button to subscribe.
+func (h *Handlers) handleInfo(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	owner, repo, err := parseRepoArg(args)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "info.usage"))
+		return
+	}
+	if h.ghClient == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "info.failed"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	info, err := h.ghClient.GetRepository(ctx, owner, repo)
+	if errors.Is(err, github.ErrRepoNotFound) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.repo_not_found", owner, repo))
+		return
+	}
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "info.failed"))
+		logger.Error().Err(err).Str("repo", args).Msg("Failed to get repository")
+		return
+	}
+
+	text := markdown.Sprintf("📦 *%s*\n", info.FullName)
+	if info.Description != "" {
+		text += markdown.Sprintf("\n%s\n", info.Description)
+	}
+	text += "\n" + i18n.T(lang, "info.stars", info.Stars, info.Forks)
+	text += i18n.T(lang, "info.open_issues", info.OpenIssues)
+	if info.Language != "" {
+		text += i18n.T(lang, "info.language", info.Language)
+	}
+	if info.License != "" {
+		text += i18n.T(lang, "info.license", info.License)
+	}
+	if !info.PushedAt.IsZero() {
+		text += i18n.T(lang, "info.pushed_at", info.PushedAt.In(h.chatLocation(msg.Chat.ID)).Format("2006-01-02 15:04"))
+	}
+	if info.Archived {
+		text += i18n.T(lang, "info.archived")
+	}
+	text += "\n" + github.MarkdownLink(i18n.T(lang, "repository.view"), info.URL)
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeMarkdownV2
+	reply.DisableWebPagePreview = true
+
+	// Callback data is limited to 64 bytes, long names go without the button
+	sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+	if err != nil {
+		logger.Warn().Err(err).Str("repo", args).Msg("Failed to get subscription")
+	}
+	data := fmt.Sprintf("sub:%s:%s", owner, repo)
+	if sub == nil && len(data) <= 64 {
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "info.subscribe_button"), data),
+		))
+	}
+	if _, err := h.api.Send(reply); err != nil {
+		logger.Error().Err(err).Msg("Failed to send repository info")
+	}
+}
+
+// handleLatest shows the most recent release of a repository.
+func (h *Handlers) handleLatest(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	owner, repo, err := parseRepoArg(args)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "latest.usage"))
+		return
+	}
+	if h.ghClient == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "latest.failed"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	release, err := h.ghClient.GetLatestRelease(ctx, owner, repo)
+	if errors.Is(err, github.ErrReleaseNotFound) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "latest.none", owner, repo))
+		return
+	}
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "latest.failed"))
+		logger.Error().Err(err).Str("repo", args).Msg("Failed to get latest release")
+		return
+	}
+
+	text := markdown.Sprintf("📦 *%s/%s*\n\n", owner, repo)
+	if !release.PublishedAt.IsZero() {
+		text += i18n.T(lang, "latest.published", release.PublishedAt.In(h.chatLocation(msg.Chat.ID)).Format("2006-01-02 15:04"))
+	}
+	text += release.FormatMessage(github.RepoInfo{Owner: owner, Name: repo}, lang)
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// Number of commits /commits lists by default and at most.
+const (
+	defaultCommitCount = 10
+	maxCommitCount     = 30
+)
+
+// handleCommits lists the latest commits on the default branch of a
+// repository.
+func (h *Handlers) handleCommits(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	repoArg, countArg := cutArg(args)
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "commits.usage"))
+		return
+	}
+	count := defaultCommitCount
+	if countArg != "" {
+		count, err = strconv.Atoi(countArg)
+		if err != nil || count < 1 || count > maxCommitCount {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "commits.invalid_count", maxCommitCount))
+			return
+		}
+	}
+	if h.ghClient == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "commits.failed"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	commits, err := h.ghClient.ListRecentCommits(ctx, owner, repo, count)
+	if errors.Is(err, github.ErrRepoNotFound) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.repo_not_found", owner, repo))
+		return
+	}
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "commits.failed"))
+		logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to list commits")
+		return
+	}
+	if len(commits) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "commits.none", owner, repo))
+		return
+	}
+
+	h.sendMarkdown(msg.Chat.ID, github.FormatCommitHistory(github.RepoInfo{Owner: owner, Name: repo}, commits, lang))
+}
+
+// defaultHistoryCount and maxHistoryCount bound how many events /history
+// lists.
+const (
+	defaultHistoryCount = 10
+	maxHistoryCount     = 50
+)
+
+// handleHistory lists the latest events notified for a repository. Chats only
+// see the history of repositories they are subscribed to.
+func (h *Handlers) handleHistory(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	repoArg, countArg := cutArg(args)
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "history.usage"))
+		return
+	}
+	count := defaultHistoryCount
+	if countArg != "" {
+		count, err = strconv.Atoi(countArg)
+		if err != nil || count < 1 || count > maxHistoryCount {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "history.invalid_count", maxHistoryCount))
+			return
+		}
+	}
+
+	if !h.isAdmin(msg.From) {
+		sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "history.failed"))
+			logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to get subscription")
+			return
+		}
+		if sub == nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "history.not_subscribed", owner, repo))
+			return
+		}
+	}
+
+	entries, err := h.store.GetHistory(owner, repo, count)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "history.failed"))
+		logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to get event history")
+		return
+	}
+	if len(entries) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "history.none", owner, repo))
+		return
+	}
+
+	loc := h.chatLocation(msg.Chat.ID)
+	text := i18n.T(lang, "history.title", owner, repo, len(entries))
+	for _, entry := range entries {
+		summary := i18n.T(lang, "summary.event")
+		if payload, err := github.DecodePayload(entry.EventType, []byte(entry.Payload)); err == nil {
+			summary = github.Summarize(payload, lang)
+		}
+		text += markdown.Sprintf("• `%s` %s %s\n", entry.Time().In(loc).Format("01-02 15:04"),
+			eventLabel(storage.EventType(entry.EventType), lang), markdown.Raw(summary))
+	}
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// issuesPageSize is how many issues or pull requests one page of /issues
+// and /prs shows. The search API returns at most maxSearchResults matches.
+const (
+	issuesPageSize   = 10
+	maxSearchResults = 1000
+)
+
+// handleIssues lists the issues or pull requests of a repository.
+func (h *Handlers) handleIssues(msg *tgbotapi.Message, args string, pulls bool) {
+	lang := h.lang(msg.Chat.ID)
+	usage := "issues.usage"
+	if pulls {
+		usage = "prs.usage"
+	}
+
+	repoArg, state := cutArg(args)
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, usage))
+		return
+	}
+	state = strings.ToLower(state)
+	switch state {
+	case "":
+		state = "open"
+	case "open", "closed":
+	case "merged":
+		if !pulls {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, usage))
+			return
+		}
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, usage))
+		return
+	}
+	if h.ghClient == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "issues.failed"))
+		return
+	}
+
+	text, markup, err := h.issuesPage(msg.Chat.ID, pulls, state, owner, repo, 0)
+	if errors.Is(err, github.ErrRepoNotFound) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "subscribe.repo_not_found", owner, repo))
+		return
+	}
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "issues.failed"))
+		logger.Error().Err(err).Str("repo", repoArg).Bool("pulls", pulls).Msg("Failed to search issues")
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeMarkdownV2
+	reply.DisableWebPagePreview = true
+	if markup != nil {
+		reply.ReplyMarkup = *markup
+	}
+	if _, err := h.api.Send(reply); err != nil {
+		logger.Error().Err(err).Msg("Failed to send issue list")
+	}
+}
+
+// issuesPage renders a page of issues or pull requests as one link button
+// each, with buttons to turn the page. Without matches the markup is nil.
+func (h *Handlers) issuesPage(chatID int64, pulls bool, state, owner, repo string, page int) (string, *tgbotapi.InlineKeyboardMarkup, error) {
+	lang := h.lang(chatID)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	items, total, err := h.ghClient.SearchIssues(ctx, owner, repo, pulls, state, page+1, issuesPageSize)
+	if err != nil {
+		return "", nil, err
+	}
+
+	kind := "issues"
+	if pulls {
+		kind = "prs"
+	}
+	title := i18n.T(lang, kind+".title_"+state, owner, repo, total)
+	if len(items) == 0 {
+		return title + i18n.T(lang, "issues.none"), nil, nil
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, item := range items {
+		label := fmt.Sprintf("#%d %s", item.Number, truncateRunes(item.Title, 50))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonURL(label, item.URL)))
+	}
+
+	// Callback data is limited to 64 bytes, long names go without paging
+	pages := (min(total, maxSearchResults) + issuesPageSize - 1) / issuesPageSize
+	if pages > 1 {
+		title += i18n.T(lang, "list.page", page+1, pages)
+		var nav []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "list.prev"), fmt.Sprintf("%s:%s:%d:%s:%s", kind, state, page-1, owner, repo)))
+		}
+		if page < pages-1 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "list.next"), fmt.Sprintf("%s:%s:%d:%s:%s", kind, state, page+1, owner, repo)))
+		}
+		if len(fmt.Sprintf("%s:%s:%d:%s:%s", kind, state, pages, owner, repo)) <= 64 {
+			rows = append(rows, nav)
+		}
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return title, &markup, nil
+}
+
+// handleIssuesPageCallback turns the page of an /issues or /prs message.
+func (h *Handlers) handleIssuesPageCallback(callback *tgbotapi.CallbackQuery, pulls bool, state, pageArg, owner, repo string) {
+	page, err := strconv.Atoi(pageArg)
+	if err != nil || page < 0 || h.ghClient == nil {
+		return
+	}
+	chatID := callback.Message.Chat.ID
+	text, markup, err := h.issuesPage(chatID, pulls, state, owner, repo, page)
+	if err != nil {
+		logger.Error().Err(err).Str("repo", owner+"/"+repo).Bool("pulls", pulls).Msg("Failed to search issues")
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, callback.Message.MessageID, text)
+	edit.ParseMode = tgbotapi.ModeMarkdownV2
+	edit.DisableWebPagePreview = true
+	edit.ReplyMarkup = markup
+	if _, err := h.api.Send(edit); err != nil {
+		logger.Error().Err(err).Msg("Failed to update issue list")
+	}
+}
+
+// trendingCount is how many repositories /trending shows.
+const trendingCount = 10
+
+// handleTrending lists the repositories gaining the most stars lately, each
+// with a button to subscribe.
+func (h *Handlers) handleTrending(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	language, period := "", "daily"
+	for _, field := range strings.Fields(args) {
+		switch strings.ToLower(field) {
+		case "daily", "weekly":
+			period = strings.ToLower(field)
+		default:
+			language = field
+		}
+	}
+	if h.ghClient == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "trending.failed"))
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -1)
+	if period == "weekly" {
+		since = time.Now().AddDate(0, 0, -7)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	repos, err := h.ghClient.TrendingRepos(ctx, language, since, trendingCount)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "trending.failed"))
+		logger.Error().Err(err).Str("language", language).Msg("Failed to get trending repositories")
+		return
+	}
+	if len(repos) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "trending.none"))
+		return
+	}
+
+	text := i18n.T(lang, "trending.title_"+period)
+	if language != "" {
+		text += i18n.T(lang, "trending.language", language)
+	}
+	text += "\n"
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, r := range repos {
+		text += markdown.Sprintf("%d. %s ⭐ %d\n", i+1, markdown.Raw(github.MarkdownLink(markdown.Escape(r.FullName), r.URL)), r.Stars)
+		if r.Description != "" {
+			text += markdown.Sprintf("   %s\n", truncateRunes(r.Description, 120))
+		}
+
+		// Callback data is limited to 64 bytes, long names go without the button
+		data := fmt.Sprintf("sub:%s:%s", r.Owner, r.Name)
+		if len(data) <= 64 {
+			rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "trending.subscribe_button", r.FullName), data),
+			))
+		}
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	reply.ParseMode = tgbotapi.ModeMarkdownV2
+	reply.DisableWebPagePreview = true
+	if len(rows) > 0 {
+		reply.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	}
+	if _, err := h.api.Send(reply); err != nil {
+		logger.Error().Err(err).Msg("Failed to send trending repositories")
+	}
+}
+
+// statsTopRepos is how many of the noisiest repositories /stats lists.
+const statsTopRepos = 5
+
+// handleStats shows how many notifications the chat got in the last week, per
+// event type and for its noisiest repositories.
+func (h *Handlers) handleStats(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	counts, err := h.store.GetNotificationCounts(msg.Chat.ID, time.Now().AddDate(0, 0, -6))
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "stats.failed"))
+		logger.Error().Err(err).Msg("Failed to get notification stats")
+		return
+	}
+	if len(counts) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "stats.none"))
+		return
+	}
+
+	total := 0
+	byEvent := make(map[string]int)
+	byRepo := make(map[string]int)
+	for _, c := range counts {
+		total += c.Count
+		byEvent[c.EventType] += c.Count
+		byRepo[c.RepoOwner+"/"+c.RepoName] += c.Count
+	}
+
+	text := i18n.T(lang, "stats.title")
+	text += i18n.T(lang, "stats.total", total, len(byRepo))
+	text += i18n.T(lang, "stats.by_event")
+	for _, e := range sortedByCount(byEvent) {
+		text += markdown.Sprintf("• %s: %d\n", eventLabel(storage.EventType(e), lang), byEvent[e])
+	}
+	text += i18n.T(lang, "stats.by_repo")
+	for i, repo := range sortedByCount(byRepo) {
+		if i == statsTopRepos {
+			break
+		}
+		text += markdown.Sprintf("%d. `%s`: %d\n", i+1, repo, byRepo[repo])
+	}
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// sortedByCount returns the keys of counts, the largest count first.
+func sortedByCount(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// handleStatus shows bot status information.
+func (h *Handlers) handleStatus(msg *tgbotapi.Message) {
+	loc := h.chatLocation(msg.Chat.ID)
+	lang := h.lang(msg.Chat.ID)
+
+	// Calculate uptime
+	uptime := time.Since(h.startTime)
+	uptimeStr := formatDuration(uptime, lang)
+
+	// Get subscription count
+	repos, err := h.store.GetAllSubscribedRepos()
+	repoCount := 0
+	if err == nil {
+		repoCount = len(repos)
+	}
+
+	// Get the number of chats the bot can no longer deliver to
+	inactiveChats, err := h.store.CountInactiveChats()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to count inactive chats")
+	}
+
+	// Get user's subscription count
+	userSubs, err := h.store.GetSubscriptionsByChat(msg.Chat.ID)
+	userSubCount := 0
+	if err == nil {
+		userSubCount = len(userSubs)
+	}
+
+	// Get GitHub API rate limit
+	rateLimitInfo := i18n.Plain(lang, "common.unknown")
+	if h.ghClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		limits, err := h.ghClient.GetRateLimit(ctx)
+		if err == nil && limits != nil && limits.Core != nil {
+			remaining := limits.Core.Remaining
+			limit := limits.Core.Limit
+			resetTime := limits.Core.Reset.Time
+			resetIn := time.Until(resetTime)
+			rateLimitInfo = i18n.Plain(lang, "status.rate_limit", remaining, limit, formatDuration(resetIn, lang),
+				resetTime.In(loc).Format("15:04"))
+		}
+	}
+
+	text := i18n.T(lang, "status.text",
+		uptimeStr, time.Now().In(loc).Format("2006-01-02 15:04"), loc, repoCount, inactiveChats, userSubCount, rateLimitInfo)
+
+	if lag := formatLagSummary(lang); lag != "" {
+		text += i18n.T(lang, "status.lag_title") + lag
+	}
+
+	if len(userSubs) > 0 {
+		text += i18n.T(lang, "status.poll_title")
+		for i, sub := range userSubs {
+			if i >= maxStatusRepos {
+				text += i18n.T(lang, "status.more_repos", len(userSubs)-maxStatusRepos)
+				break
+			}
+			state, err := h.store.GetRepoState(sub.RepoOwner, sub.RepoName)
+			if err != nil {
+				logger.Warn().Err(err).Msg("Failed to get repo state")
+			}
+			text += markdown.Sprintf("• `%s/%s`: %s\n", sub.RepoOwner, sub.RepoName, describePollSchedule(state, lang))
+		}
+	}
+
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// handleSetting lets administrators read and change allow-listed settings.
+func (h *Handlers) handleSetting(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if !h.isAdmin(msg.From) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.admin_only"))
+		return
+	}
+	if h.settings == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.unavailable"))
+		return
+	}
+
+	fields := strings.Fields(args)
+	if len(fields) < 2 || (fields[0] != "get" && fields[0] != "set") {
+		text := i18n.T(lang, "setting.usage")
+		for _, key := range storage.EditableSettingKeys() {
+			text += markdown.Sprintf("• `%s`\n", key)
+		}
+		h.sendReply(msg.Chat.ID, text)
+		return
+	}
+
+	key := fields[1]
+	if !storage.IsEditableSetting(key) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.forbidden", key))
+		return
+	}
+
+	switch fields[0] {
+	case "get":
+		value, err := h.settings.GetString(key, "")
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.read_failed"))
+			logger.Error().Err(err).Str("key", key).Msg("Failed to get setting")
+			return
+		}
+		if value == "" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.unset", key))
+			return
+		}
+		h.sendReply(msg.Chat.ID, markdown.Sprintf("⚙️ `%s` = `%s`", key, value))
+
+	case "set":
+		if len(fields) < 3 {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.set_usage"))
+			return
+		}
+		value := strings.Join(fields[2:], " ")
+		if err := h.settings.SetEditable(key, value); err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.invalid_value", err.Error()))
+			return
+		}
+		logger.Info().Str("key", key).Str("value", value).Int64("user_id", msg.From.ID).Msg("Setting changed")
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.saved", key, value))
+	}
+}
+
+// handleAdmin runs the administrator subcommands.
+func (h *Handlers) handleAdmin(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if !h.isAdmin(msg.From) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.admin_only"))
+		return
+	}
+
+	sub, rest := cutArg(args)
+	switch sub {
+	case "stats":
+		h.handleAdminStats(msg)
+	case "backup":
+		h.handleAdminBackup(msg)
+	case "audit":
+		h.handleAdminAudit(msg, rest)
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "admin.usage"))
+	}
+}
+
+// handleAdminStats reports totals over all chats and the GitHub quota.
+func (h *Handlers) handleAdminStats(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	stats, err := h.store.GetBotStats(time.Now().Add(-24 * time.Hour))
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "stats.failed"))
+		logger.Error().Err(err).Msg("Failed to get bot stats")
+		return
+	}
+
+	quota := i18n.Plain(lang, "common.unknown")
+	if h.ghClient != nil {
+		if rate := h.ghClient.RateStatus(); rate.Limit > 0 {
+			quota = i18n.Plain(lang, "admin.quota", rate.Remaining, rate.Limit,
+				rate.Reset.In(h.chatLocation(msg.Chat.ID)).Format("15:04"))
+		}
+	}
+
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "admin.stats",
+		stats.Chats, stats.InactiveChats, stats.Subscriptions, stats.Repos,
+		stats.UserFollows, stats.TopicFollows, stats.EventsRecent,
+		stats.PendingOutbox, stats.QueuedEvents, quota))
+}
+
+// handleAdminBackup takes a database snapshot right away.
+func (h *Handlers) handleAdminBackup(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	if h.backups == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "admin.backup_disabled"))
+		return
+	}
+
+	result, err := h.backups.Run(context.Background())
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "admin.backup_failed"))
+		logger.Error().Err(err).Msg("Failed to back up database")
+		return
+	}
+	key := "admin.backup_done"
+	if result.Uploaded {
+		key = "admin.backup_uploaded"
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, key, result.Name, float64(result.Size)/(1<<20)))
+}
+
+// isAdmin checks whether a user is a configured administrator.
+func (h *Handlers) isAdmin(user *tgbotapi.User) bool {
+	if user == nil {
+		return false
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.admins[user.ID]
+}
+
+// NotifyAdmins sends every administrator the message key of the catalog in
+// their language.
+func (h *Handlers) NotifyAdmins(key string, args ...any) {
+	for _, adminID := range h.adminIDs() {
+		h.sendReply(adminID, i18n.T(h.lang(adminID), key, args...))
+	}
+}
+
+// adminIDs returns the configured administrators.
+func (h *Handlers) adminIDs() []int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]int64, 0, len(h.admins))
+	for id := range h.admins {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// handleEventSettings shows toggle buttons for the event types of a subscription.
+func (h *Handlers) handleEventSettings(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if args == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "settings.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(args)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "settings.load_failed"))
+		logger.Error().Err(err).Str("repo", args).Msg("Failed to get subscription")
+		return
+	}
+	if sub == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		return
+	}
+
+	reply := tgbotapi.NewMessage(msg.Chat.ID, i18n.T(lang, "settings.title", owner, repo))
+	reply.ParseMode = tgbotapi.ModeMarkdownV2
+	reply.ReplyMarkup = eventToggleKeyboard(sub, lang)
+	if _, err := h.api.Send(reply); err != nil {
+		logger.Error().Err(err).Msg("Failed to send event settings")
+	}
+}
+
+// handleEventToggleCallback turns an event type of a subscription on or off
+// and refreshes the toggle buttons.
+func (h *Handlers) handleEventToggleCallback(callback *tgbotapi.CallbackQuery, idArg string, event storage.EventType) {
+	chatID := callback.Message.Chat.ID
+
+	id, err := strconv.ParseInt(idArg, 10, 64)
+	if err != nil {
+		return
+	}
+
+	lang := h.lang(chatID)
+	sub, err := h.store.GetSubscriptionByID(chatID, id)
+	if err != nil {
+		logger.Error().Err(err).Int64("subscription_id", id).Msg("Failed to get subscription")
+		return
+	}
+	if sub == nil {
+		h.sendReply(chatID, i18n.T(lang, "common.sub_not_found_short"))
+		return
+	}
+
+	current, err := storage.ParseEvents(sub.Events)
+	if err != nil {
+		current = storage.DefaultEvents()
+	}
+
+	var events []storage.EventType
+	enabled := false
+	for _, e := range current {
+		if e == event {
+			enabled = true
+			continue
+		}
+		events = append(events, e)
+	}
+	if !enabled {
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		h.sendReply(chatID, i18n.T(lang, "settings.keep_one"))
+		return
+	}
+
+	if err := h.store.SetSubscribedEvents(sub.ID, events); err != nil {
+		h.sendReply(chatID, i18n.T(lang, "settings.update_failed"))
+		logger.Error().Err(err).Int64("subscription_id", id).Msg("Failed to update events")
+		return
+	}
+
+	sub, err = h.store.GetSubscriptionByID(chatID, id)
+	if err != nil || sub == nil {
+		return
+	}
+	edit := tgbotapi.NewEditMessageReplyMarkup(chatID, callback.Message.MessageID, eventToggleKeyboard(sub, lang))
+	if _, err := h.api.Send(edit); err != nil {
+		logger.Error().Err(err).Msg("Failed to update event settings")
+	}
+}
+
+// eventToggleKeyboard builds one toggle button per supported event type.
+func eventToggleKeyboard(sub *storage.Subscription, lang i18n.Lang) tgbotapi.InlineKeyboardMarkup {
+	events, err := storage.ParseEvents(sub.Events)
+	if err != nil {
+		events = storage.DefaultEvents()
+	}
+	enabled := make(map[storage.EventType]bool, len(events))
+	for _, e := range events {
+		enabled[e] = true
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, e := range storage.AllEventTypes() {
+		mark := "⬜"
+		if enabled[e] {
+			mark = "✅"
+		}
+		data := fmt.Sprintf("evt:%d:%s", sub.ID, e)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(mark+" "+eventLabel(e, lang), data),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// handleFilter shows or changes the filters of a subscription.
+func (h *Handlers) handleFilter(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(fields[0])
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	filters, err := h.store.GetSubscriptionFilters(msg.Chat.ID, owner, repo)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.load_failed"))
+		logger.Error().Err(err).Str("repo", fields[0]).Msg("Failed to get filters")
+		return
+	}
+	if filters == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		return
+	}
+
+	if len(fields) == 1 {
+		h.sendMarkdown(msg.Chat.ID, formatFilters(owner, repo, *filters, lang))
+		return
+	}
+
+	for _, f := range fields[1:] {
+		name, value, ok := strings.Cut(f, ":")
+		if !ok {
+			name, value, ok = strings.Cut(f, "=")
+		}
+		if !ok {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid", f))
+			return
+		}
+
+		switch name {
+		case "assets":
+			if strings.ContainsAny(value, "`") || github.ValidateAssetPattern(value) != nil {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_assets"))
+				return
+			}
+			filters.Assets = value
+		case "platforms", "platform":
+			if value == "" {
+				filters.Platforms = nil
+				break
+			}
+			platforms, err := github.ParsePlatformList(value)
+			if err != nil {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_platforms"))
+				return
+			}
+			filters.Platforms = platforms
+		case "compliance":
+			if value == "" {
+				filters.Licenses = nil
+				break
+			}
+			list, ok := strings.CutPrefix(value, "license=")
+			if !ok {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.compliance_format"))
+				return
+			}
+			licenses, err := github.ParseLicenseList(list)
+			if err != nil {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_licenses", err.Error()))
+				return
+			}
+			filters.Licenses = licenses
+		case "branch", "branches":
+			if value == "" {
+				filters.Branches = nil
+				break
+			}
+			branches, err := github.ParseBranchList(value)
+			if err != nil {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_branches"))
+				return
+			}
+			filters.Branches = branches
+		case "labels", "label":
+			if value == "" {
+				filters.Labels, filters.ExcludeLabels = nil, nil
+				break
+			}
+			required, excluded, err := github.ParseLabelList(value)
+			if err != nil {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_labels"))
+				return
+			}
+			filters.Labels, filters.ExcludeLabels = required, excluded
+		case "ignore":
+			if value == "" {
+				filters.IgnoreAuthors = nil
+				break
+			}
+			authors, err := github.ParseAuthorList(value)
+			if err != nil {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_ignore"))
+				return
+			}
+			filters.IgnoreAuthors = authors
+		case "include", "exclude":
+			if strings.ContainsAny(value, "`") || (value != "" && github.ValidateTextPattern(value) != nil) {
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_regex"))
+				return
+			}
+			if name == "include" {
+				filters.Include = value
+			} else {
+				filters.Exclude = value
+			}
+		case "prereleases", "prerelease":
+			switch {
+			case value == "":
+				filters.SkipPrereleases, filters.PrereleaseTags = false, ""
+			case value == "skip":
+				filters.SkipPrereleases, filters.PrereleaseTags = true, ""
+			case strings.ContainsAny(value, "`") || github.ValidateTextPattern(value) != nil:
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.invalid_regex"))
+				return
+			default:
+				filters.SkipPrereleases, filters.PrereleaseTags = true, value
+			}
+		case "ci":
+			switch value {
+			case storage.CIFilterAll, "all":
+				filters.CI = storage.CIFilterAll
+			case storage.CIFilterFailures, storage.CIFilterFailuresRecovery:
+				filters.CI = value
+			default:
+				h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.ci_format"))
+				return
+			}
+		default:
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.unknown", name))
+			return
+		}
+	}
+
+	if err := h.store.SetSubscriptionFilters(msg.Chat.ID, owner, repo, *filters); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "filter.save_failed"))
+		logger.Error().Err(err).Str("repo", fields[0]).Msg("Failed to set filters")
+		return
+	}
+
+	// Without a compliance watch there is nothing left to flag
+	if len(filters.Licenses) == 0 {
+		if sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo); err == nil && sub != nil && sub.ComplianceViolation != "" {
+			if err := h.store.SetComplianceViolation(sub.ID, ""); err != nil {
+				logger.Warn().Err(err).Msg("Failed to clear compliance state")
+			}
+		}
+	}
+
+	h.sendMarkdown(msg.Chat.ID, i18n.T(lang, "filter.updated")+formatFilters(owner, repo, *filters, lang))
+}
+
+// formatFilters describes the filters of a subscription.
+func formatFilters(owner, repo string, filters storage.SubscriptionFilters, lang i18n.Lang) string {
+	none, all := i18n.Plain(lang, "filters.none"), i18n.Plain(lang, "filters.all")
+	code := func(values ...string) markdown.Raw {
+		return markdown.Raw(markdown.Code(strings.Join(values, ", ")))
+	}
+
+	text := i18n.T(lang, "filters.title", owner, repo)
+	if filters.Assets == "" {
+		text += i18n.T(lang, "filters.assets", none)
+	} else {
+		text += i18n.T(lang, "filters.assets", code(filters.Assets))
+	}
+	if len(filters.Platforms) == 0 {
+		text += i18n.T(lang, "filters.platforms", all)
+	} else {
+		text += i18n.T(lang, "filters.platforms", code(filters.Platforms...))
+	}
+	if len(filters.Licenses) == 0 {
+		text += i18n.T(lang, "filters.licenses", none)
+	} else {
+		text += i18n.T(lang, "filters.licenses", code(filters.Licenses...))
+	}
+	if len(filters.Branches) == 0 {
+		text += i18n.T(lang, "filters.branches", all)
+	} else {
+		text += i18n.T(lang, "filters.branches", code(filters.Branches...))
+	}
+	if len(filters.Labels) == 0 && len(filters.ExcludeLabels) == 0 {
+		text += i18n.T(lang, "filters.labels", all)
+	} else {
+		if len(filters.Labels) > 0 {
+			text += i18n.T(lang, "filters.required_labels", code(filters.Labels...))
+		}
+		if len(filters.ExcludeLabels) > 0 {
+			text += i18n.T(lang, "filters.excluded_labels", code(filters.ExcludeLabels...))
+		}
+	}
+	if len(filters.IgnoreAuthors) == 0 {
+		text += i18n.T(lang, "filters.ignore", none)
+	} else {
+		text += i18n.T(lang, "filters.ignore", code(filters.IgnoreAuthors...))
+	}
+	if filters.Include != "" {
+		text += i18n.T(lang, "filters.include", code(filters.Include))
+	}
+	if filters.Exclude != "" {
+		text += i18n.T(lang, "filters.exclude", code(filters.Exclude))
+	}
+	switch {
+	case !filters.SkipPrereleases:
+		text += i18n.T(lang, "filters.prereleases", all)
+	case filters.PrereleaseTags != "":
+		text += i18n.T(lang, "filters.prereleases_skipped", code(filters.PrereleaseTags))
+	default:
+		text += i18n.T(lang, "filters.prereleases_skipped", i18n.Plain(lang, "filters.prerelease_default"))
+	}
+	switch filters.CI {
+	case storage.CIFilterFailures:
+		text += i18n.T(lang, "filters.ci", i18n.Plain(lang, "filters.ci_failures"))
+	case storage.CIFilterFailuresRecovery:
+		text += i18n.T(lang, "filters.ci", i18n.Plain(lang, "filters.ci_recovery"))
+	default:
+		text += i18n.T(lang, "filters.ci", all)
+	}
+	text += i18n.T(lang, "filters.help", strings.Join(github.BotAuthors, ", "))
+	return text
+}
+
+// formatLagSummary describes the median delivery lag per event source.
+func formatLagSummary(lang i18n.Lang) string {
+	var text string
+	if lag, ok := metrics.MedianLag(metrics.SourceWebhook, metrics.StageTotal); ok {
+		text += markdown.Sprintf("• Webhook: %s\n", formatDuration(lag, lang))
+	}
+	if lag, ok := metrics.MedianLag(metrics.SourcePoller, metrics.StageTotal); ok {
+		text += markdown.Sprintf("• Polling: %s", formatDuration(lag, lang))
+		detection, _ := metrics.MedianLag(metrics.SourcePoller, metrics.StageDetection)
+		delivery, _ := metrics.MedianLag(metrics.SourcePoller, metrics.StageDelivery)
+		text += i18n.T(lang, "status.lag_detail", formatDuration(detection, lang), formatDuration(delivery, lang))
+	}
+	return text
+}
+
+// maxStatusRepos limits how many repositories /status lists.
+const maxStatusRepos = 10
+
+// handleDiagnose shows polling diagnostics for a subscribed repository.
+func (h *Handlers) handleDiagnose(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if args == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "diagnose.usage"))
+		return
+	}
+
+	owner, repo, err := parseRepoArg(args)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "diagnose.load_failed"))
+		logger.Error().Err(err).Str("repo", args).Msg("Failed to get subscription")
+		return
+	}
+	if sub == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		return
+	}
+
+	state, err := h.store.GetRepoState(owner, repo)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "diagnose.state_failed"))
+		logger.Error().Err(err).Str("repo", args).Msg("Failed to get repo state")
+		return
+	}
+
+	text := i18n.T(lang, "diagnose.title", owner, repo)
+	text += i18n.T(lang, "diagnose.schedule", describePollSchedule(state, lang))
+	if state != nil {
+		if state.ActivityBand != github.ActivityBandNew {
+			text += i18n.T(lang, "diagnose.activity", state.ActivityScore)
+		}
+		if state.LastPolledAt.Valid {
+			text += i18n.T(lang, "diagnose.last_polled", formatDuration(time.Since(state.LastPolledAt.Time), lang))
+		}
+	}
+
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// maxPollOverride is the longest poll interval that can be set manually.
+const maxPollOverride = 24 * time.Hour
+
+// handlePollInterval shows or overrides how often a repository is polled.
+// The interval applies to every subscriber, so only administrators may set it.
+func (h *Handlers) handlePollInterval(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if !h.isAdmin(msg.From) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "setting.admin_only"))
+		return
+	}
+
+	repoArg, value := cutArg(args)
+	if repoArg == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "pollinterval.usage"))
+		return
+	}
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	if value == "" {
+		state, err := h.store.GetRepoState(owner, repo)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "diagnose.state_failed"))
+			logger.Error().Err(err).Msg("Failed to get repo state")
+			return
+		}
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "pollinterval.current", owner, repo, describePollSchedule(state, lang)))
+		return
+	}
+
+	seconds := 0
+	if !strings.EqualFold(value, "auto") {
+		d, err := parseDuration(value)
+		if err != nil || d < github.MinPollInterval*time.Second || d > maxPollOverride {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "pollinterval.invalid"))
+			return
+		}
+		seconds = int(d.Seconds())
+	}
+
+	if err := h.store.SetRepoPollOverride(owner, repo, seconds); err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.failed"))
+		logger.Error().Err(err).Msg("Failed to set poll interval override")
+		return
+	}
+
+	logger.Info().
+		Str("repo", owner+"/"+repo).
+		Int("seconds", seconds).
+		Int64("user_id", msg.From.ID).
+		Msg("Poll interval override changed")
+	if seconds == 0 {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "pollinterval.reset", owner, repo))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "pollinterval.set", owner, repo, formatDuration(time.Duration(seconds)*time.Second, lang)))
+}
+
+// describePollSchedule explains how often a repository is checked and why.
+func describePollSchedule(state *storage.RepoState, lang i18n.Lang) string {
+	if state == nil || state.PollInterval == 0 {
+		return i18n.Plain(lang, "poll.never")
+	}
+
+	var reason string
+	switch {
+	case state.PollIntervalOverride > 0:
+		reason = "poll.reason_override"
+	case state.ActivityBand == github.ActivityBandActive:
+		reason = "poll.reason_active"
+	case state.ActivityBand == github.ActivityBandModerate:
+		reason = "poll.reason_moderate"
+	case state.ActivityBand == github.ActivityBandDormant:
+		reason = "poll.reason_dormant"
+	default:
+		reason = "poll.reason_new"
+	}
+
+	interval := formatDuration(time.Duration(state.PollInterval)*time.Second, lang)
+	return i18n.Plain(lang, "poll.schedule", interval, i18n.Plain(lang, reason))
+}
+
+// formatDuration formats a duration to a human-readable string.
+func formatDuration(d time.Duration, lang i18n.Lang) string {
+	days := int(d.Hours() / 24)
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+
+	if days > 0 {
+		return i18n.Plain(lang, "duration.days", days, hours, minutes)
+	} else if hours > 0 {
+		return i18n.Plain(lang, "duration.hours", hours, minutes)
+	} else if minutes > 0 {
+		return i18n.Plain(lang, "duration.minutes", minutes, seconds)
+	}
+	return i18n.Plain(lang, "duration.seconds", seconds)
+}
+
+// sendReply sends a simple text reply.
+func (h *Handlers) sendReply(chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	if _, err := h.api.Send(msg); err != nil {
+		logger.Error().Err(err).Msg("Failed to send reply")
+	}
+}
+
+// sendMarkdown sends a markdown-formatted message.
+func (h *Handlers) sendMarkdown(chatID int64, text string) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.DisableWebPagePreview = true
+	if _, err := h.api.Send(msg); err != nil {
+		logger.Error().Err(err).Msg("Failed to send markdown message")
+	}
+}
+
+// parseRepoArg parses "owner/repo" format.
+func parseRepoArg(arg string) (owner, repo string, err error) {
+	arg = strings.TrimSpace(arg)
+	parts := strings.Split(arg, "/")
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid format")
+	}
+
+	owner = strings.TrimSpace(parts[0])
+	repo = strings.TrimSpace(parts[1])
+
+	if owner == "" || repo == "" {
+		return "", "", fmt.Errorf("empty owner or repo")
+	}
+
+	return owner, repo, nil
+}
+
+// truncateRunes shortens s to at most n characters, marking a cut with an
+// ellipsis.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// isValidTopic reports whether s is a valid GitHub topic: lowercase letters,
+// digits and hyphens, not starting with a hyphen.
+func isValidTopic(s string) bool {
+	if s == "" || len(s) > 50 || strings.HasPrefix(s, "-") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// isValidLogin reports whether s is a syntactically valid GitHub login.
+func isValidLogin(s string) bool {
+	if s == "" || len(s) > 39 || strings.HasPrefix(s, "-") {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
 }
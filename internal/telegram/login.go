@@ -0,0 +1,311 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// SetDeviceFlow enables /login, which links GitHub accounts with the given
+// OAuth device flow.
+func (h *Handlers) SetDeviceFlow(flow *github.DeviceFlow) {
+	h.deviceFlow = flow
+}
+
+// handleLogin links the sender's GitHub account, so that they can subscribe
+// to private repositories it can read. The user enters a code on GitHub
+// while the bot waits for the token in the background.
+func (h *Handlers) handleLogin(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	switch {
+	case h.deviceFlow == nil || h.ghClient == nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "login.disabled"))
+		return
+	case !msg.Chat.IsPrivate() || msg.From == nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "login.private_only"))
+		return
+	}
+
+	account, err := h.store.GetGitHubAccount(msg.From.ID)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "login.failed"))
+		logger.Error().Err(err).Int64("user_id", msg.From.ID).Msg("Failed to get GitHub account")
+		return
+	}
+	if account != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "login.linked", account.Login))
+		return
+	}
+
+	// A device code is valid for 15 minutes
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
+	h.loginMu.Lock()
+	_, pending := h.logins[msg.From.ID]
+	if !pending {
+		if h.logins == nil {
+			h.logins = make(map[int64]context.CancelFunc)
+		}
+		h.logins[msg.From.ID] = cancel
+	}
+	h.loginMu.Unlock()
+	if pending {
+		cancel()
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "login.pending"))
+		return
+	}
+
+	startCtx, startCancel := context.WithTimeout(ctx, 15*time.Second)
+	code, err := h.deviceFlow.Start(startCtx)
+	startCancel()
+	if err != nil {
+		h.endLogin(msg.From.ID, cancel)
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "login.failed"))
+		logger.Error().Err(err).Msg("Failed to start GitHub login")
+		return
+	}
+
+	h.sendMarkdown(msg.Chat.ID, i18n.T(lang, "login.code", code.VerificationURI, code.UserCode,
+		int(time.Until(code.ExpiresAt).Round(time.Minute).Minutes())))
+	go func() {
+		defer logger.ReportPanic()
+		defer h.endLogin(msg.From.ID, cancel)
+		h.waitForLogin(ctx, msg.Chat.ID, msg.From.ID, code)
+	}()
+}
+
+// endLogin forgets the pending login of a user.
+func (h *Handlers) endLogin(userID int64, cancel context.CancelFunc) {
+	cancel()
+	h.loginMu.Lock()
+	delete(h.logins, userID)
+	h.loginMu.Unlock()
+}
+
+// waitForLogin waits until the user entered the code of a login, and links
+// the account it was entered for.
+func (h *Handlers) waitForLogin(ctx context.Context, chatID, userID int64, code *github.DeviceCode) {
+	lang := h.lang(chatID)
+	token, err := h.deviceFlow.Wait(ctx, code)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return // Logged out meanwhile
+	case errors.Is(err, github.ErrLoginDenied):
+		h.sendReply(chatID, i18n.T(lang, "login.denied"))
+		return
+	case errors.Is(err, github.ErrLoginExpired), errors.Is(err, context.DeadlineExceeded):
+		h.sendReply(chatID, i18n.T(lang, "login.expired"))
+		return
+	case err != nil:
+		h.sendReply(chatID, i18n.T(lang, "login.failed"))
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to complete GitHub login")
+		return
+	}
+
+	login, err := h.ghClient.GetAuthenticatedLogin(ctx, token.AccessToken)
+	if err == nil {
+		err = h.store.SaveGitHubAccount(userID, login, token.AccessToken, token.Scopes)
+	}
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "login.failed"))
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to link GitHub account")
+		return
+	}
+
+	logger.Info().Int64("user_id", userID).Str("login", login).Msg("GitHub account linked")
+	h.sendReply(chatID, i18n.T(lang, "login.success", login))
+}
+
+// handleLogout unlinks the sender's GitHub account, cancelling a login
+// still waiting for its code.
+func (h *Handlers) handleLogout(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	if msg.From == nil {
+		return
+	}
+
+	h.loginMu.Lock()
+	if cancel, ok := h.logins[msg.From.ID]; ok {
+		cancel()
+	}
+	h.loginMu.Unlock()
+
+	account, err := h.store.GetGitHubAccount(msg.From.ID)
+	if err == nil && account != nil {
+		_, err = h.store.DeleteGitHubAccount(msg.From.ID)
+	}
+	switch {
+	case err != nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "logout.failed"))
+		logger.Error().Err(err).Int64("user_id", msg.From.ID).Msg("Failed to unlink GitHub account")
+	case account == nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "logout.none"))
+	default:
+		logger.Info().Int64("user_id", msg.From.ID).Str("login", account.Login).Msg("GitHub account unlinked")
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "logout.done", account.Login))
+	}
+}
+
+// canRead reports whether a user may subscribe a chat to a repository: the
+// configured tokens can read it, or else the token the chat set or the
+// account the user linked can. Without a GitHub client repositories are not
+// checked.
+func (h *Handlers) canRead(ctx context.Context, chatID, userID int64, owner, repo string) (bool, error) {
+	if h.ghClient == nil {
+		return true, nil
+	}
+	exists, err := h.ghClient.ValidateRepository(ctx, owner, repo)
+	if err == nil && !exists {
+		// Private repositories are read with the chat's token
+		exists, err = h.validateWithChatToken(ctx, chatID, owner, repo)
+	}
+	if err == nil && !exists && userID != 0 {
+		// or the subscriber's linked account
+		exists, err = h.validateWithAccount(ctx, userID, owner, repo)
+	}
+	return exists, err
+}
+
+// validateWithChatToken checks whether the token a chat set can read a
+// repository the configured tokens cannot, and if so records the chat as
+// the one whose token the repository is read with.
+func (h *Handlers) validateWithChatToken(ctx context.Context, chatID int64, owner, repo string) (bool, error) {
+	token, err := h.store.GetChatGitHubToken(chatID)
+	if err != nil || token == "" {
+		return false, err
+	}
+	exists, err := h.ghClient.ValidateRepository(github.WithToken(ctx, token), owner, repo)
+	if err != nil || !exists {
+		return false, err
+	}
+	if err := h.store.SetRepoChatCredential(owner, repo, chatID); err != nil {
+		return false, err
+	}
+	logger.Info().Int64("chat_id", chatID).Str("repo", owner+"/"+repo).Msg("Repository read with chat token")
+	return true, nil
+}
+
+// validateWithAccount checks whether the GitHub account a user linked can
+// read a repository the configured tokens cannot, and if so records it as
+// the account the repository is read with.
+func (h *Handlers) validateWithAccount(ctx context.Context, userID int64, owner, repo string) (bool, error) {
+	token, err := h.store.GetGitHubToken(userID)
+	if err != nil || token == "" {
+		return false, err
+	}
+	exists, err := h.ghClient.ValidateRepository(github.WithToken(ctx, token), owner, repo)
+	if err != nil || !exists {
+		return false, err
+	}
+	if err := h.store.SetRepoCredential(owner, repo, userID); err != nil {
+		return false, err
+	}
+	logger.Info().Int64("user_id", userID).Str("repo", owner+"/"+repo).Msg("Repository read with linked GitHub account")
+	return true, nil
+}
+
+// handleSetToken sets the GitHub token of a private chat, for users who'd
+// rather not authorize an OAuth app. The chat's subscriptions to private
+// repositories the token can read are checked and polled with it, and its
+// quota is tracked apart: /settoken <token>, /settoken to show the account
+// of the token and the quota left, /settoken off to remove it. The message
+// with the token is deleted.
+func (h *Handlers) handleSetToken(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	token := strings.TrimSpace(args)
+	if token != "" && !strings.EqualFold(token, "off") {
+		// The token should not stay in the chat, whatever happens next
+		if _, err := h.api.Request(tgbotapi.NewDeleteMessage(msg.Chat.ID, msg.MessageID)); err != nil {
+			logger.Warn().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to delete message with GitHub token")
+			defer h.sendReply(msg.Chat.ID, i18n.T(lang, "settoken.delete_failed"))
+		}
+	}
+
+	switch {
+	case h.ghClient == nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "settoken.disabled"))
+	case !msg.Chat.IsPrivate():
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "settoken.private_only"))
+	case token == "":
+		h.showChatToken(msg.Chat.ID, lang)
+	case strings.EqualFold(token, "off"):
+		h.removeChatToken(msg.Chat.ID, lang)
+	default:
+		h.saveChatToken(msg.Chat.ID, token, lang)
+	}
+}
+
+// showChatToken shows the GitHub account of the token a chat set and how
+// much of its quota is left.
+func (h *Handlers) showChatToken(chatID int64, lang i18n.Lang) {
+	chatToken, err := h.store.GetChatToken(chatID)
+	if err != nil {
+		h.sendReply(chatID, i18n.T(lang, "settoken.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to get chat token")
+		return
+	}
+	if chatToken == nil {
+		h.sendReply(chatID, i18n.T(lang, "settoken.none"))
+		return
+	}
+
+	quota := i18n.Plain(lang, "common.unknown")
+	if token, err := h.store.GetChatGitHubToken(chatID); err == nil {
+		if rate := h.ghClient.UserRateStatus(token); rate.Limit > 0 {
+			quota = i18n.Plain(lang, "admin.quota", rate.Remaining, rate.Limit,
+				rate.Reset.In(h.chatLocation(chatID)).Format("15:04"))
+		}
+	}
+	h.sendReply(chatID, i18n.T(lang, "settoken.current", chatToken.Login, quota))
+}
+
+// saveChatToken checks a personal access token with GitHub and sets it as
+// the token of a chat.
+func (h *Handlers) saveChatToken(chatID int64, token string, lang i18n.Lang) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	login, err := h.ghClient.GetAuthenticatedLogin(ctx, token)
+	if errors.Is(err, github.ErrInvalidToken) {
+		h.sendReply(chatID, i18n.T(lang, "settoken.invalid"))
+		return
+	}
+	if err == nil {
+		err = h.store.SaveChatToken(chatID, login, token)
+	}
+	switch {
+	case errors.Is(err, storage.ErrNoEncryptionKey):
+		h.sendReply(chatID, i18n.T(lang, "settoken.disabled"))
+	case err != nil:
+		h.sendReply(chatID, i18n.T(lang, "settoken.failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to save chat token")
+	default:
+		logger.Info().Int64("chat_id", chatID).Str("login", login).Msg("Chat token saved")
+		h.sendReply(chatID, i18n.T(lang, "settoken.saved", login))
+	}
+}
+
+// removeChatToken removes the token of a chat. Repositories read with it
+// fall back to the configured tokens.
+func (h *Handlers) removeChatToken(chatID int64, lang i18n.Lang) {
+	chatToken, err := h.store.GetChatToken(chatID)
+	if err == nil && chatToken != nil {
+		_, err = h.store.DeleteChatToken(chatID)
+	}
+	switch {
+	case err != nil:
+		h.sendReply(chatID, i18n.T(lang, "settoken.remove_failed"))
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to remove chat token")
+	case chatToken == nil:
+		h.sendReply(chatID, i18n.T(lang, "settoken.none"))
+	default:
+		logger.Info().Int64("chat_id", chatID).Str("login", chatToken.Login).Msg("Chat token removed")
+		h.sendReply(chatID, i18n.T(lang, "settoken.removed", chatToken.Login))
+	}
+}
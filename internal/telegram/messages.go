@@ -1,9 +1,15 @@
 package telegram
 
 import (
-	"fmt"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/markdown"
+	"github.com/user/githubbot/internal/storage"
 )
 
 // MessageBuilder helps construct formatted notification messages.
@@ -15,35 +21,221 @@ func NewMessageBuilder() *MessageBuilder {
 }
 
 // BuildPushMessage creates a notification message for push events.
-func (m *MessageBuilder) BuildPushMessage(repoOwner, repoName string, event *github.PushEvent) string {
-	header := fmt.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
-	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName})
+func (m *MessageBuilder) BuildPushMessage(repoOwner, repoName string, event *github.PushEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
 }
 
 // BuildReleaseMessage creates a notification message for release events.
-func (m *MessageBuilder) BuildReleaseMessage(repoOwner, repoName string, event *github.ReleaseEvent) string {
-	header := fmt.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
-	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName})
+func (m *MessageBuilder) BuildReleaseMessage(repoOwner, repoName string, event *github.ReleaseEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildTagMessage creates a notification message for tag events.
+func (m *MessageBuilder) BuildTagMessage(repoOwner, repoName string, event *github.TagEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildPackageMessage creates a notification message for package events.
+func (m *MessageBuilder) BuildPackageMessage(repoOwner, repoName string, event *github.PackageEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
 }
 
 // BuildIssueMessage creates a notification message for issue events.
-func (m *MessageBuilder) BuildIssueMessage(repoOwner, repoName string, event *github.IssueEvent) string {
-	header := fmt.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
-	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName})
+func (m *MessageBuilder) BuildIssueMessage(repoOwner, repoName string, event *github.IssueEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
 }
 
 // BuildPRMessage creates a notification message for pull request events.
-func (m *MessageBuilder) BuildPRMessage(repoOwner, repoName string, event *github.PullRequestEvent) string {
-	header := fmt.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
-	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName})
+func (m *MessageBuilder) BuildPRMessage(repoOwner, repoName string, event *github.PullRequestEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildStarMessage creates a notification message for star events.
+func (m *MessageBuilder) BuildStarMessage(repoOwner, repoName string, event *github.StarEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildReviewMessage creates a notification message for pull request review events.
+func (m *MessageBuilder) BuildReviewMessage(repoOwner, repoName string, event *github.PullRequestReviewEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildReviewCommentMessage creates a notification message for review comment events.
+func (m *MessageBuilder) BuildReviewCommentMessage(repoOwner, repoName string, event *github.PullRequestReviewCommentEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
 }
 
-// FormatRepoLink creates a markdown link to a repository.
+// BuildWorkflowRunMessage creates a notification message for workflow run events.
+func (m *MessageBuilder) BuildWorkflowRunMessage(repoOwner, repoName string, event *github.WorkflowRunEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildDeploymentMessage creates a notification message for deployment events.
+func (m *MessageBuilder) BuildDeploymentMessage(repoOwner, repoName string, event *github.DeploymentEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildWikiMessage creates a notification message for wiki events.
+func (m *MessageBuilder) BuildWikiMessage(repoOwner, repoName string, event *github.WikiEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildRepositoryMessage creates a notification message for repository lifecycle events.
+func (m *MessageBuilder) BuildRepositoryMessage(repoOwner, repoName string, event *github.RepositoryEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildTopicRepoMessage creates a notification message for a new repository
+// under a followed topic.
+func (m *MessageBuilder) BuildTopicRepoMessage(repoOwner, repoName string, event *github.TopicRepoEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🆕 *%s/%s*\n\n", repoOwner, repoName)
+	return header + event.FormatMessage(github.RepoInfo{Owner: repoOwner, Name: repoName}, lang)
+}
+
+// BuildCustomMessage creates a notification message from a chat's custom
+// template. The rendered text is shown literally below the usual header.
+func (m *MessageBuilder) BuildCustomMessage(repoOwner, repoName string, event storage.EventType, tmpl string, payload interface{}) (string, error) {
+	text, err := github.RenderTemplate(event, tmpl, repoOwner, repoName, payload)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		return "", errors.New("template rendered an empty message")
+	}
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	return header + markdown.Escape(text), nil
+}
+
+// BuildAssetSection lists release assets matching a subscription's asset filter,
+// with their SHA-256 checksums when known.
+func (m *MessageBuilder) BuildAssetSection(assets []github.AssetInfo, checksums map[string]string, lang i18n.Lang) string {
+	section := i18n.T(lang, "assets.title")
+	for _, a := range assets {
+		link := github.MarkdownLink(markdown.Escape(a.Name), a.DownloadURL)
+		section += markdown.Sprintf("• %s (%s)\n", markdown.Raw(link), github.FormatAssetSize(a.Size))
+		if sum, ok := checksums[a.Name]; ok {
+			section += markdown.Sprintf("  `sha256:%s`\n", sum)
+		}
+	}
+	return section
+}
+
+// BuildMissingAssetMessage creates a notice for a release that never produced
+// an asset matching the subscription's asset filter.
+func (m *MessageBuilder) BuildMissingAssetMessage(repoOwner, repoName string, event *github.ReleaseEvent, pattern string, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	msg := i18n.T(lang, "assets.missing_title")
+	msg += i18n.T(lang, "field.tag", event.TagName)
+	msg += i18n.T(lang, "field.filter", pattern)
+	msg += i18n.T(lang, "assets.missing_note")
+	msg += "\n" + github.MarkdownLink(i18n.T(lang, "release.view"), event.URL)
+	return header + msg
+}
+
+// BuildComplianceAlert creates a warning about a repository drifting out of compliance.
+func (m *MessageBuilder) BuildComplianceAlert(repoOwner, repoName string, event *github.ComplianceEvent, violations, allowed []string, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	msg := i18n.T(lang, "compliance.alert")
+	for _, v := range violations {
+		msg += markdown.Sprintf("• %s\n", v)
+	}
+	msg += i18n.T(lang, "compliance.allowed", strings.Join(allowed, ", "))
+	msg += i18n.T(lang, "compliance.note")
+	msg += "\n" + github.MarkdownLink(i18n.T(lang, "repository.view"), event.URL)
+	return header + msg
+}
+
+// BuildComplianceResolved creates a notice that a repository is compliant again.
+func (m *MessageBuilder) BuildComplianceResolved(repoOwner, repoName string, event *github.ComplianceEvent, lang i18n.Lang) string {
+	header := markdown.Sprintf("🔔 *%s/%s*\n\n", repoOwner, repoName)
+	msg := i18n.T(lang, "compliance.resolved")
+	msg += i18n.T(lang, "field.license", event.License)
+	msg += "\n" + github.MarkdownLink(i18n.T(lang, "repository.view"), event.URL)
+	return header + msg
+}
+
+// FormatRepoLink creates a MarkdownV2 link to a repository.
 func FormatRepoLink(owner, name string) string {
-	return fmt.Sprintf("[%s/%s](https://github.com/%s/%s)", owner, name, owner, name)
+	return github.MarkdownLink(markdown.Escape(owner+"/"+name), "https://github.com/"+url.PathEscape(owner)+"/"+url.PathEscape(name))
 }
 
-// FormatUserLink creates a markdown link to a user profile.
+// FormatUserLink creates a MarkdownV2 link to a user profile.
 func FormatUserLink(username string) string {
-	return fmt.Sprintf("[@%s](https://github.com/%s)", username, username)
+	return github.MarkdownLink(markdown.Escape("@"+username), "https://github.com/"+url.PathEscape(username))
+}
+
+// maxSummaryItems caps how many events of one type and repository are listed
+// in a summary message.
+const maxSummaryItems = 10
+
+// maxMessageLength keeps summary messages below Telegram's 4096 character limit.
+const maxMessageLength = 4000
+
+// BuildQueuedSummary groups queued events by repository and event type into
+// one or more messages, each starting with the title. Event times are shown in
+// loc using the given layout.
+func (m *MessageBuilder) BuildQueuedSummary(title string, events []storage.QueuedEvent, loc *time.Location, layout string, lang i18n.Lang) []string {
+	type group struct {
+		repo   string
+		types  []string
+		byType map[string][]string
+	}
+
+	var groups []*group
+	byRepo := make(map[string]*group)
+	for _, e := range events {
+		repo := e.RepoOwner + "/" + e.RepoName
+		g := byRepo[repo]
+		if g == nil {
+			g = &group{repo: repo, byType: make(map[string][]string)}
+			byRepo[repo] = g
+			groups = append(groups, g)
+		}
+		if _, ok := g.byType[e.EventType]; !ok {
+			g.types = append(g.types, e.EventType)
+		}
+		item := markdown.Sprintf("`%s` %s", e.CreatedAt.In(loc).Format(layout), markdown.Raw(e.Summary))
+		g.byType[e.EventType] = append(g.byType[e.EventType], item)
+	}
+
+	var sections []string
+	for _, g := range groups {
+		section := markdown.Sprintf("*%s*\n", g.repo)
+		for _, t := range g.types {
+			items := g.byType[t]
+			section += markdown.Sprintf("%s (%d)\n", eventLabel(storage.EventType(t), lang), len(items))
+			for i, item := range items {
+				if i == maxSummaryItems {
+					section += i18n.T(lang, "summary.more", len(items)-maxSummaryItems)
+					break
+				}
+				section += "  • " + item + "\n"
+			}
+		}
+		sections = append(sections, section)
+	}
+
+	var messages []string
+	current := title + "\n\n"
+	for _, section := range sections {
+		if len(current)+len(section) > maxMessageLength && current != title+"\n\n" {
+			messages = append(messages, strings.TrimSpace(current))
+			current = title + "\n\n"
+		}
+		current += section + "\n"
+	}
+	return append(messages, strings.TrimSpace(current))
 }
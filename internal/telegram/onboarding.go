@@ -0,0 +1,226 @@
+package telegram
+
+import (
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// HandleMyChatMember reacts to the bot being added to, removed from or having
+// its permissions changed in a group or channel, or being blocked by a user.
+func (h *Handlers) HandleMyChatMember(update *tgbotapi.ChatMemberUpdated) {
+	chat := update.Chat
+	if chat.IsChannel() {
+		h.handleChannelMembership(update)
+		return
+	}
+	if chat.IsPrivate() {
+		h.handlePrivateMembership(update)
+		return
+	}
+	if !chat.IsGroup() && !chat.IsSuperGroup() {
+		return
+	}
+
+	wasIn := isInChat(update.OldChatMember)
+	isIn := isInChat(update.NewChatMember)
+
+	switch {
+	case !isIn:
+		// Keep subscriptions so they resume if the bot is added back
+		if err := h.store.SetChatActive(chat.ID, false); err != nil {
+			logger.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to deactivate chat")
+		}
+		logger.Info().Int64("chat_id", chat.ID).Msg("Bot removed from group")
+	case !wasIn:
+		logger.Info().Int64("chat_id", chat.ID).Msg("Bot added to group")
+		h.onboardChat(&chat)
+	default:
+		h.retryOnboarding(chat.ID)
+	}
+}
+
+// handlePrivateMembership stops delivery to a user who blocked the bot and
+// resumes it once they unblock it.
+func (h *Handlers) handlePrivateMembership(update *tgbotapi.ChatMemberUpdated) {
+	active := isInChat(update.NewChatMember)
+	if err := h.store.SetChatActive(update.Chat.ID, active); err != nil {
+		logger.Error().Err(err).Int64("chat_id", update.Chat.ID).Msg("Failed to update chat")
+		return
+	}
+	if active {
+		logger.Info().Int64("chat_id", update.Chat.ID).Msg("Bot unblocked by user")
+	} else {
+		logger.Info().Int64("chat_id", update.Chat.ID).Msg("Bot blocked by user")
+	}
+}
+
+// HandleMessage processes a non-command message.
+func (h *Handlers) HandleMessage(msg *tgbotapi.Message) {
+	if h.handleForwardedChannelPost(msg) || h.handleImportCaption(msg) {
+		return
+	}
+	if msg.ReplyToMessage != nil && msg.ReplyToMessage.From != nil &&
+		msg.ReplyToMessage.From.ID == h.api.Self.ID &&
+		isWizardPrompt(msg.ReplyToMessage.Text) {
+		h.trackChat(msg.Chat)
+		if h.checkAccess(msg) {
+			h.handleSubscribe(msg, strings.TrimSpace(msg.Text))
+		}
+	}
+}
+
+// isWizardPrompt reports whether text is the subscribe wizard's prompt in any
+// language, since the chat's language may have changed since it was sent.
+func isWizardPrompt(text string) bool {
+	for _, prompt := range i18n.All("wizard.prompt") {
+		if strings.HasPrefix(text, prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+// onboardUnknownChat sends the onboarding message to a group seen for the first time.
+// It must be called before the chat is tracked.
+func (h *Handlers) onboardUnknownChat(chat *tgbotapi.Chat) {
+	if !chat.IsGroup() && !chat.IsSuperGroup() {
+		return
+	}
+	known, err := h.store.GetChat(chat.ID)
+	if err != nil || known != nil {
+		return
+	}
+	h.onboardChat(chat)
+}
+
+// onboardChat greets a group the bot joined. Groups that still have
+// subscriptions get a welcome-back notice instead of the onboarding message,
+// which is sent at most once per chat.
+func (h *Handlers) onboardChat(chat *tgbotapi.Chat) {
+	known, err := h.store.GetChat(chat.ID)
+	if err != nil {
+		logger.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get chat")
+		return
+	}
+
+	h.trackChat(chat)
+	if err := h.store.SetChatActive(chat.ID, true); err != nil {
+		logger.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to activate chat")
+	}
+
+	subs, err := h.store.GetSubscriptionsByChat(chat.ID)
+	if err != nil {
+		logger.Error().Err(err).Int64("chat_id", chat.ID).Msg("Failed to get subscriptions")
+		return
+	}
+	if len(subs) > 0 {
+		h.sendReply(chat.ID, i18n.T(h.lang(chat.ID), "onboarding.welcome_back", len(subs)))
+		return
+	}
+
+	if known != nil && known.OnboardingState != storage.OnboardingNone {
+		return
+	}
+	h.sendOnboarding(chat.ID, storage.OnboardingPending)
+}
+
+// retryOnboarding resends the onboarding message once after a permission
+// change if the first attempt failed.
+func (h *Handlers) retryOnboarding(chatID int64) {
+	chat, err := h.store.GetChat(chatID)
+	if err != nil || chat == nil || chat.OnboardingState != storage.OnboardingPending {
+		return
+	}
+	h.sendOnboarding(chatID, storage.OnboardingFailed)
+}
+
+// sendOnboarding sends the group onboarding message and records the outcome,
+// using failedState if it could not be delivered.
+func (h *Handlers) sendOnboarding(chatID int64, failedState string) {
+	lang := h.lang(chatID)
+	text := i18n.T(lang, "onboarding.text")
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = tgbotapi.ModeMarkdownV2
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(i18n.Plain(lang, "onboarding.button"), "wizard:subscribe"),
+	))
+
+	state := storage.OnboardingSent
+	if _, err := h.api.Send(msg); err != nil {
+		logger.Warn().Err(err).Int64("chat_id", chatID).Msg("Failed to send onboarding message")
+		state = failedState
+	}
+	if err := h.store.SetOnboardingState(chatID, state); err != nil {
+		logger.Error().Err(err).Int64("chat_id", chatID).Msg("Failed to record onboarding state")
+	}
+}
+
+// handleWizardCallback starts the subscribe wizard by asking for a repository.
+func (h *Handlers) handleWizardCallback(callback *tgbotapi.CallbackQuery) {
+	lang := h.lang(callback.Message.Chat.ID)
+	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, i18n.Plain(lang, "wizard.prompt")+i18n.Plain(lang, "wizard.format"))
+	msg.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply:            true,
+		InputFieldPlaceholder: "owner/repo",
+		Selective:             true,
+	}
+	if _, err := h.api.Send(msg); err != nil {
+		logger.Error().Err(err).Msg("Failed to send wizard prompt")
+	}
+}
+
+// handleSetupCheck reports whether the bot is set up correctly in this chat.
+func (h *Handlers) handleSetupCheck(msg *tgbotapi.Message) {
+	lang := h.lang(msg.Chat.ID)
+	text := i18n.T(lang, "setupcheck.title")
+
+	if msg.Chat.IsGroup() || msg.Chat.IsSuperGroup() {
+		member, err := h.api.GetChatMember(tgbotapi.GetChatMemberConfig{
+			ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: msg.Chat.ID, UserID: h.api.Self.ID},
+		})
+		switch {
+		case err != nil:
+			text += i18n.T(lang, "setupcheck.no_permissions")
+		case member.Status == "restricted" && !member.CanSendMessages:
+			text += i18n.T(lang, "setupcheck.muted")
+		default:
+			text += i18n.T(lang, "setupcheck.group_ok", member.Status)
+		}
+	} else {
+		text += i18n.T(lang, "setupcheck.private_ok")
+	}
+
+	subs, err := h.store.GetSubscriptionsByChat(msg.Chat.ID)
+	if err == nil {
+		if len(subs) == 0 {
+			text += i18n.T(lang, "setupcheck.no_subs")
+		} else {
+			text += i18n.T(lang, "setupcheck.subs", len(subs))
+		}
+	}
+
+	if h.ghClient == nil {
+		text += i18n.T(lang, "setupcheck.no_client")
+	} else {
+		text += i18n.T(lang, "setupcheck.client")
+	}
+
+	h.sendMarkdown(msg.Chat.ID, text)
+}
+
+// isInChat reports whether a chat member status means the bot is in the chat.
+func isInChat(member tgbotapi.ChatMember) bool {
+	switch member.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return member.IsMember
+	default:
+		return false
+	}
+}
@@ -0,0 +1,144 @@
+package telegram
+
+import (
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/internal/telegramtest"
+)
+
+// newTestHandlers returns handlers sending to a fake Telegram server, with
+// their store in a temporary database.
+func newTestHandlers(t *testing.T) (*Handlers, *storage.SubscriptionStore, *telegramtest.Server) {
+	t.Helper()
+	store := telegramtest.NewStore(t)
+	telegram := telegramtest.NewServer(t)
+	return NewHandlers(telegram.Bot(t), store), store, telegram
+}
+
+// testGroup is the group the bot is added to in the tests.
+var testGroup = tgbotapi.Chat{ID: -100, Type: "supergroup", Title: "Acme developers"}
+
+// membershipChange returns the update sent when the bot's status in the test
+// group changes.
+func membershipChange(from, to tgbotapi.ChatMember) *tgbotapi.ChatMemberUpdated {
+	return &tgbotapi.ChatMemberUpdated{Chat: testGroup, OldChatMember: from, NewChatMember: to}
+}
+
+var (
+	left       = tgbotapi.ChatMember{Status: "left"}
+	member     = tgbotapi.ChatMember{Status: "member"}
+	admin      = tgbotapi.ChatMember{Status: "administrator", CanPostMessages: true}
+	restricted = tgbotapi.ChatMember{Status: "restricted", IsMember: true}
+)
+
+// chatState returns whether the test group is active and its onboarding state.
+func chatState(t *testing.T, store *storage.SubscriptionStore) (bool, string) {
+	t.Helper()
+	chat, err := store.GetChat(testGroup.ID)
+	if err != nil || chat == nil {
+		t.Fatalf("GetChat() = %v, %v", chat, err)
+	}
+	return chat.Active, chat.OnboardingState
+}
+
+func TestOnboardingFirstAdd(t *testing.T) {
+	h, store, telegram := newTestHandlers(t)
+	onboarding := i18n.T(i18n.Default(), "onboarding.text")
+
+	h.HandleMyChatMember(membershipChange(left, member))
+	if sent := telegram.Messages(); len(sent) != 1 || sent[0] != onboarding {
+		t.Fatalf("sent %q, want the onboarding message", sent)
+	}
+	if active, state := chatState(t, store); !active || state != storage.OnboardingSent {
+		t.Errorf("chat active = %v, onboarding = %q, want active and %q", active, state, storage.OnboardingSent)
+	}
+
+	// Promotion to admin is not another add
+	h.HandleMyChatMember(membershipChange(member, admin))
+	// Nor is being removed and added back to a group without subscriptions
+	h.HandleMyChatMember(membershipChange(admin, left))
+	h.HandleMyChatMember(membershipChange(left, member))
+	if sent := telegram.Messages(); len(sent) != 1 {
+		t.Errorf("sent %q, want the onboarding message once", sent)
+	}
+}
+
+func TestOnboardingReAddWithSubscriptions(t *testing.T) {
+	h, store, telegram := newTestHandlers(t)
+
+	h.HandleMyChatMember(membershipChange(left, member))
+	for _, repo := range []string{"app", "lib"} {
+		if err := store.Subscribe(testGroup.ID, "acme", repo, storage.DefaultEvents()); err != nil {
+			t.Fatalf("Subscribe() error = %v", err)
+		}
+	}
+
+	// Removal pauses delivery but keeps the subscriptions
+	h.HandleMyChatMember(membershipChange(member, left))
+	if active, _ := chatState(t, store); active {
+		t.Error("chat still active after the bot was removed")
+	}
+	if subs, err := store.GetSubscriptionsByChat(testGroup.ID); err != nil || len(subs) != 2 {
+		t.Fatalf("GetSubscriptionsByChat() = %d subscriptions, %v, want 2 kept", len(subs), err)
+	}
+	if sent := telegram.Messages(); len(sent) != 1 {
+		t.Fatalf("sent %q, want the onboarding message only", sent)
+	}
+
+	// Coming back reactivates the chat and greets it without onboarding it again
+	h.HandleMyChatMember(membershipChange(left, member))
+	if active, _ := chatState(t, store); !active {
+		t.Error("chat not reactivated when the bot was added back")
+	}
+	sent := telegram.Messages()
+	if want := i18n.T(i18n.Default(), "onboarding.welcome_back", 2); len(sent) != 2 || sent[1] != want {
+		t.Errorf("sent %q, want the welcome back notice %q", sent, want)
+	}
+}
+
+func TestOnboardingRestrictedRetry(t *testing.T) {
+	h, store, telegram := newTestHandlers(t)
+	onboarding := i18n.T(i18n.Default(), "onboarding.text")
+
+	// Added without the right to send messages
+	telegram.SetMuted(true)
+	h.HandleMyChatMember(membershipChange(left, restricted))
+	if _, state := chatState(t, store); state != storage.OnboardingPending {
+		t.Fatalf("onboarding = %q after a failed send, want %q", state, storage.OnboardingPending)
+	}
+
+	// Granting the rights sends it
+	telegram.SetMuted(false)
+	h.HandleMyChatMember(membershipChange(restricted, admin))
+	if sent := telegram.Messages(); len(sent) != 1 || sent[0] != onboarding {
+		t.Fatalf("sent %q after the permission change, want the onboarding message", sent)
+	}
+	if _, state := chatState(t, store); state != storage.OnboardingSent {
+		t.Errorf("onboarding = %q, want %q", state, storage.OnboardingSent)
+	}
+
+	// Later permission changes don't send it again
+	h.HandleMyChatMember(membershipChange(admin, member))
+	if sent := telegram.Messages(); len(sent) != 1 {
+		t.Errorf("sent %q, want the onboarding message once", sent)
+	}
+}
+
+func TestOnboardingRetriedOnce(t *testing.T) {
+	h, store, telegram := newTestHandlers(t)
+
+	telegram.SetMuted(true)
+	h.HandleMyChatMember(membershipChange(left, restricted))
+	h.HandleMyChatMember(membershipChange(restricted, member))
+	if _, state := chatState(t, store); state != storage.OnboardingFailed {
+		t.Fatalf("onboarding = %q after the retry failed, want %q", state, storage.OnboardingFailed)
+	}
+
+	h.HandleMyChatMember(membershipChange(member, admin))
+	if attempts := telegram.Attempts(); attempts != 2 {
+		t.Errorf("tried to send %d messages, want the first attempt and one retry", attempts)
+	}
+}
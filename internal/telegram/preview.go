@@ -0,0 +1,107 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/github"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/internal/storage"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// Previewer sends a chat the notification of an event as the chat's
+// subscription would, reporting whether the subscription would have notified
+// it. The notifier implements it.
+type Previewer interface {
+	Preview(ctx context.Context, chatID int64, event *github.WebhookEvent) (bool, error)
+}
+
+// SetPreviewer enables /test, which previews notifications with it.
+func (h *Handlers) SetPreviewer(p Previewer) {
+	h.previewer = p
+}
+
+// testEvents are the event types /test can preview.
+var testEvents = map[storage.EventType]bool{
+	storage.EventTypePush:    true,
+	storage.EventTypeRelease: true,
+	storage.EventTypeIssue:   true,
+}
+
+// handleTest sends the chat the notification of the latest commit, release
+// or issue of a repository, so that the format, template and filters of a
+// subscription can be checked before anything happens:
+// /test owner/repo [push|release|issue], release by default.
+func (h *Handlers) handleTest(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	repoArg, eventArg := cutArg(args)
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.usage"))
+		return
+	}
+	eventType := storage.EventTypeRelease
+	if eventArg != "" {
+		eventType, err = storage.ParseEventType(eventArg)
+		if err != nil || !testEvents[eventType] {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "test.invalid_event"))
+			return
+		}
+	}
+	if h.ghClient == nil || h.previewer == nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.failed"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	event, err := h.ghClient.LatestEvent(ctx, owner, repo, eventType)
+	if errors.Is(err, github.ErrRepoNotFound) {
+		// A private repository the chat's token can read
+		if token, tokenErr := h.store.GetChatGitHubToken(msg.Chat.ID); tokenErr == nil && token != "" {
+			event, err = h.ghClient.LatestEvent(github.WithToken(ctx, token), owner, repo, eventType)
+		}
+	}
+	if errors.Is(err, github.ErrRepoNotFound) && msg.From != nil {
+		// or the sender's linked account can
+		if token, tokenErr := h.store.GetGitHubToken(msg.From.ID); tokenErr == nil && token != "" {
+			event, err = h.ghClient.LatestEvent(github.WithToken(ctx, token), owner, repo, eventType)
+		}
+	}
+	switch {
+	case errors.Is(err, github.ErrRepoNotFound):
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.not_found", owner, repo))
+		return
+	case errors.Is(err, github.ErrNoEvent):
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.none", owner, repo, string(eventType)))
+		return
+	case err != nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.failed"))
+		logger.Error().Err(err).Str("repo", owner+"/"+repo).Str("event", string(eventType)).Msg("Failed to get latest event")
+		return
+	}
+
+	sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.failed"))
+		logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to get subscription")
+		return
+	}
+
+	wanted, err := h.previewer.Preview(ctx, msg.Chat.ID, event)
+	switch {
+	case err != nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.failed"))
+		logger.Error().Err(err).Int64("chat_id", msg.Chat.ID).Msg("Failed to send notification preview")
+	case sub == nil:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.not_subscribed", owner, repo))
+	case !wanted:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.filtered", owner, repo))
+	default:
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "test.delivered"))
+	}
+}
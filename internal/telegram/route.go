@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"slices"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/i18n"
+	"github.com/user/githubbot/pkg/logger"
+)
+
+// SetRoutes sets the sink destinations subscriptions can be routed to, like
+// "discord:releases".
+func (h *Handlers) SetRoutes(routes []string) {
+	h.routes = slices.Sorted(slices.Values(routes))
+}
+
+// handleRoute lets administrators send the notifications of a subscription
+// to a sink, such as a Discord or Slack channel or a Matrix room, instead of
+// the chat:
+// /route owner/repo [destination|chat]
+func (h *Handlers) handleRoute(msg *tgbotapi.Message, args string) {
+	lang := h.lang(msg.Chat.ID)
+	if !h.isAdmin(msg.From) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "route.admin_only"))
+		return
+	}
+	repoArg, route := cutArg(args)
+	if repoArg == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "route.usage"))
+		return
+	}
+	owner, repo, err := parseRepoArg(repoArg)
+	if err != nil {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "common.repo_format"))
+		return
+	}
+
+	if route == "" {
+		sub, err := h.store.GetSubscription(msg.Chat.ID, owner, repo)
+		if err != nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "route.failed"))
+			logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to get subscription")
+			return
+		}
+		if sub == nil {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+			return
+		}
+		reply := i18n.T(lang, "route.chat", owner, repo)
+		if sub.Sink != "" {
+			reply = i18n.T(lang, "route.current", owner, repo, sub.Sink)
+		}
+		h.sendReply(msg.Chat.ID, reply+h.availableRoutes(lang))
+		return
+	}
+
+	route = strings.ToLower(route)
+	if route == "chat" {
+		route = ""
+	} else if !slices.Contains(h.routes, route) {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "route.unknown", route)+h.availableRoutes(lang))
+		return
+	}
+
+	if err := h.store.SetSubscriptionSink(msg.Chat.ID, owner, repo, route); err != nil {
+		if err.Error() == "subscription not found" {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "common.sub_not_found", owner, repo))
+		} else {
+			h.sendReply(msg.Chat.ID, i18n.T(lang, "route.failed"))
+			logger.Error().Err(err).Str("repo", repoArg).Msg("Failed to route subscription")
+		}
+		return
+	}
+	if route == "" {
+		h.sendReply(msg.Chat.ID, i18n.T(lang, "route.reset", owner, repo))
+		return
+	}
+	h.sendReply(msg.Chat.ID, i18n.T(lang, "route.set", owner, repo, route))
+}
+
+// availableRoutes lists the destinations subscriptions can be routed to.
+func (h *Handlers) availableRoutes(lang i18n.Lang) string {
+	if len(h.routes) == 0 {
+		return i18n.T(lang, "route.none_configured")
+	}
+	return i18n.T(lang, "route.available", strings.Join(h.routes, ", "))
+}
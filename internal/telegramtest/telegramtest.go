@@ -0,0 +1,127 @@
+// Package telegramtest provides the fixtures of tests sending messages
+// through the Bot API: a fake Telegram server recording the messages, and a
+// store in a temporary database.
+package telegramtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/user/githubbot/internal/storage"
+)
+
+// Server is a Bot API server recording the messages sent through it.
+type Server struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	sent   []string // Texts of the messages delivered
+	muted  bool     // Reject messages as if the bot may not send them
+	failed int      // Messages rejected
+}
+
+// NewServer starts a fake Bot API server, closed when the test ends.
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{}
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result interface{}
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/getMe"):
+			result = map[string]interface{}{"id": 1, "is_bot": true, "username": "test_bot"}
+		case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+			s.mu.Lock()
+			if s.muted {
+				s.failed++
+				s.mu.Unlock()
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"ok":          false,
+					"error_code":  400,
+					"description": "Bad Request: not enough rights to send text messages to the chat",
+				})
+				return
+			}
+			s.sent = append(s.sent, r.FormValue("text"))
+			id := len(s.sent)
+			s.mu.Unlock()
+
+			// Groups have negative IDs
+			chatType := "private"
+			if chatID, _ := strconv.ParseInt(r.FormValue("chat_id"), 10, 64); chatID < 0 {
+				chatType = "group"
+			}
+			result = map[string]interface{}{
+				"message_id": id,
+				"date":       time.Now().Unix(),
+				"chat":       map[string]interface{}{"id": json.Number(r.FormValue("chat_id")), "type": chatType},
+			}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+// Bot returns a bot sending through the server.
+func (s *Server) Bot(t testing.TB) *tgbotapi.BotAPI {
+	t.Helper()
+	bot, err := tgbotapi.NewBotAPIWithClient("token", s.server.URL+"/bot%s/%s", s.server.Client())
+	if err != nil {
+		t.Fatalf("NewBotAPIWithClient() error = %v", err)
+	}
+	return bot
+}
+
+// SetMuted sets whether messages are rejected.
+func (s *Server) SetMuted(muted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.muted = muted
+}
+
+// Messages returns the texts delivered so far.
+func (s *Server) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent...)
+}
+
+// WaitForMessages waits up to five seconds until count messages were
+// delivered, and returns the texts delivered.
+func (s *Server) WaitForMessages(count int) []string {
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.Messages()) < count && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return s.Messages()
+}
+
+// Attempts returns how many messages were sent, delivered or not.
+func (s *Server) Attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent) + s.failed
+}
+
+// NewStore returns a store in a temporary database, closed when the test
+// ends.
+func NewStore(t testing.TB) *storage.SubscriptionStore {
+	t.Helper()
+	db, err := storage.NewDatabase(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatalf("NewDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return storage.NewSubscriptionStore(db)
+}
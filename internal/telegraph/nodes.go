@@ -0,0 +1,125 @@
+package telegraph
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Node is a piece of page content, either a string or an *Element.
+type Node interface{}
+
+// Element is a tag in page content.
+type Element struct {
+	Tag      string            `json:"tag"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []Node            `json:"children,omitempty"`
+}
+
+// supportedTags lists the tags of Telegram HTML kept in page content.
+var supportedTags = map[string]bool{
+	"a": true, "b": true, "i": true, "s": true, "u": true, "code": true, "pre": true,
+}
+
+// hrefAttr extracts the target of a link tag.
+var hrefAttr = regexp.MustCompile(`href="([^"]*)"`)
+
+// FromHTML converts Telegram HTML, as produced by markdown.ToHTML, to page
+// content. Blank lines separate paragraphs and single line breaks are kept.
+func FromHTML(s string) []Node {
+	return paragraphs(parseHTML(s))
+}
+
+// parseHTML parses Telegram HTML into a tree of nodes. Unknown tags are
+// dropped while their text is kept.
+func parseHTML(s string) []Node {
+	root := &Element{}
+	stack := []*Element{root}
+	for len(s) > 0 {
+		if s[0] == '<' {
+			if end := strings.IndexByte(s, '>'); end > 0 {
+				tag := s[1:end]
+				s = s[end+1:]
+				if name, ok := strings.CutPrefix(tag, "/"); ok {
+					for i := len(stack) - 1; i > 0; i-- {
+						if stack[i].Tag == name {
+							stack = stack[:i]
+							break
+						}
+					}
+					continue
+				}
+
+				name, attrs, _ := strings.Cut(tag, " ")
+				parent := stack[len(stack)-1]
+				if !supportedTags[name] || name == "code" && parent.Tag == "pre" {
+					continue
+				}
+				el := &Element{Tag: name}
+				if name == "a" {
+					if m := hrefAttr.FindStringSubmatch(attrs); m != nil {
+						el.Attrs = map[string]string{"href": html.UnescapeString(m[1])}
+					}
+				}
+				parent.Children = append(parent.Children, el)
+				stack = append(stack, el)
+				continue
+			}
+		}
+
+		end := strings.IndexByte(s[1:], '<') + 1
+		if end == 0 {
+			end = len(s)
+		}
+		top := stack[len(stack)-1]
+		top.Children = append(top.Children, html.UnescapeString(s[:end]))
+		s = s[end:]
+	}
+	return root.Children
+}
+
+// paragraphs groups top-level nodes into paragraphs. Code blocks stand on
+// their own.
+func paragraphs(nodes []Node) []Node {
+	var out, para []Node
+	newlines := 0
+	flush := func() {
+		if len(para) > 0 {
+			out = append(out, &Element{Tag: "p", Children: para})
+			para = nil
+		}
+	}
+	add := func(n Node) {
+		if newlines >= 2 {
+			flush()
+		} else if newlines == 1 && len(para) > 0 {
+			para = append(para, &Element{Tag: "br"})
+		}
+		newlines = 0
+		para = append(para, n)
+	}
+
+	for _, n := range nodes {
+		switch v := n.(type) {
+		case string:
+			for i, part := range strings.Split(v, "\n") {
+				if i > 0 {
+					newlines++
+				}
+				if part != "" {
+					add(part)
+				}
+			}
+		case *Element:
+			if v.Tag == "pre" {
+				flush()
+				out = append(out, v)
+				newlines = 0
+				continue
+			}
+			add(v)
+		}
+	}
+	flush()
+	return out
+}
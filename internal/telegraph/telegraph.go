@@ -0,0 +1,117 @@
+// Package telegraph publishes long texts as telegra.ph pages, so that
+// notifications can link to content that does not fit in a Telegram message.
+package telegraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/user/githubbot/internal/markdown"
+)
+
+// apiURL is the base URL of the Telegraph API.
+const apiURL = "https://api.telegra.ph"
+
+// MaxContentSize is the largest page content Telegraph accepts, in bytes of
+// its JSON encoding.
+const MaxContentSize = 64 * 1024
+
+// Client publishes pages with a Telegraph account.
+type Client struct {
+	token      string
+	authorName string
+	http       *http.Client
+}
+
+// New creates a client for the account with the given access token.
+func New(token, authorName string) *Client {
+	return &Client{
+		token:      token,
+		authorName: authorName,
+		http:       &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// CreateAccount creates a Telegraph account and returns a client for it.
+func CreateAccount(ctx context.Context, shortName, authorName string) (*Client, error) {
+	c := New("", authorName)
+	var account struct {
+		AccessToken string `json:"access_token"`
+	}
+	err := c.call(ctx, "createAccount", url.Values{
+		"short_name":  {shortName},
+		"author_name": {authorName},
+	}, &account)
+	if err != nil {
+		return nil, err
+	}
+	c.token = account.AccessToken
+	return c, nil
+}
+
+// CreatePage publishes a page and returns its URL. The author links to
+// authorURL when it is set.
+func (c *Client) CreatePage(ctx context.Context, title, authorURL string, content []Node) (string, error) {
+	body, err := json.Marshal(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page content: %w", err)
+	}
+	if len(body) > MaxContentSize {
+		return "", fmt.Errorf("page content is %d bytes, at most %d are allowed", len(body), MaxContentSize)
+	}
+
+	params := url.Values{
+		"access_token": {c.token},
+		"title":        {markdown.Truncate(title, 256)},
+		"author_name":  {c.authorName},
+		"content":      {string(body)},
+	}
+	if authorURL != "" {
+		params.Set("author_url", authorURL)
+	}
+
+	var page struct {
+		URL string `json:"url"`
+	}
+	if err := c.call(ctx, "createPage", params, &page); err != nil {
+		return "", err
+	}
+	return page.URL, nil
+}
+
+// call invokes an API method and decodes its result.
+func (c *Client) call(ctx context.Context, method string, params url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL+"/"+method, strings.NewReader(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("telegraph %s failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		OK     bool            `json:"ok"`
+		Error  string          `json:"error"`
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return fmt.Errorf("telegraph %s: invalid response: %w", method, err)
+	}
+	if !reply.OK {
+		if reply.Error == "" {
+			return errors.New("telegraph " + method + " failed")
+		}
+		return fmt.Errorf("telegraph %s failed: %s", method, reply.Error)
+	}
+	return json.Unmarshal(reply.Result, result)
+}
@@ -0,0 +1,64 @@
+// Package tracing sets up OpenTelemetry tracing of the event pipeline. Spans
+// are exported over OTLP/HTTP to a collector such as the OpenTelemetry
+// Collector, Jaeger or Tempo.
+//
+// A webhook delivery is traced from its receipt through parsing and the
+// notifier to each Telegram send; the trace travels with the event through
+// the events channel. Without tracing set up, the spans cost next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// ServiceName identifies the bot in traces.
+const ServiceName = "github-telegram-bot"
+
+// Config holds where spans are exported to.
+type Config struct {
+	Endpoint    string  // OTLP/HTTP endpoint URL, empty for OTEL_EXPORTER_OTLP_* environment variables
+	Insecure    bool    // Export over plain HTTP
+	SampleRatio float64 // Fraction of traces recorded, from 0 to 1
+}
+
+// Setup installs the tracer provider and the W3C trace context propagator.
+// The returned function flushes the spans not exported yet and shuts the
+// exporter down.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	var opts []otlptracehttp.Option
+	if config.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(config.Endpoint))
+	}
+	if config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe service: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
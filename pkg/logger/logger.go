@@ -2,54 +2,99 @@
 package logger
 
 import (
+	"fmt"
 	"io"
 	"os"
 	"time"
 
 	"github.com/rs/zerolog"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 var log zerolog.Logger
 
+// Console output formats. The log file is always written as JSON.
+const (
+	FormatConsole = "console" // Human-readable, colored
+	FormatJSON    = "json"    // One JSON object per line, for log shippers
+)
+
+// Options configures the logger.
+type Options struct {
+	Debug  bool   // Log debug messages as well
+	Format string // Console output format, FormatConsole if empty
+	File   string // Log file path, empty to log to the console only
+
+	// The file is rotated once it reaches MaxSize megabytes. Rotated files
+	// are kept up to MaxBackups files and MaxAge days, 0 for no limit.
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+	Compress   bool // Gzip rotated files
+}
+
 // Init initializes the global logger with the specified configuration.
-func Init(debug bool, logFile string) error {
+func Init(opts Options) error {
 	var writers []io.Writer
 
-	// Console writer with pretty formatting
-	consoleWriter := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
+	switch opts.Format {
+	case FormatConsole, "":
+		// Console writer with pretty formatting
+		writers = append(writers, zerolog.ConsoleWriter{
+			Out:        os.Stdout,
+			TimeFormat: time.RFC3339,
+		})
+	case FormatJSON:
+		writers = append(writers, os.Stdout)
+	default:
+		return fmt.Errorf("unknown log format %q, expected %s or %s", opts.Format, FormatConsole, FormatJSON)
 	}
-	writers = append(writers, consoleWriter)
 
-	// File writer if specified
-	if logFile != "" {
-		file, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	// Rotated file writer if specified
+	if opts.File != "" {
+		// Fail early if the file cannot be written, rather than on the first entry
+		file, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 		if err != nil {
 			return err
 		}
-		writers = append(writers, file)
+		file.Close()
+
+		writers = append(writers, &lumberjack.Logger{
+			Filename:   opts.File,
+			MaxSize:    opts.MaxSize,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAge,
+			Compress:   opts.Compress,
+			LocalTime:  true,
+		})
 	}
 
+	// Errors also go to the reporter, once one is set
+	writers = append(writers, reportWriter{})
+
 	// Create multi-writer
 	multi := zerolog.MultiLevelWriter(writers...)
 
-	// Set log level
-	level := zerolog.InfoLevel
-	if debug {
-		level = zerolog.DebugLevel
-	}
-
 	log = zerolog.New(multi).
-		Level(level).
 		With().
 		Timestamp().
 		Caller().
 		Logger()
+	SetDebug(opts.Debug)
 
 	return nil
 }
 
+// SetDebug switches logging debug messages on or off, e.g. when the
+// configuration is reloaded.
+func SetDebug(debug bool) {
+	level := zerolog.InfoLevel
+	if debug {
+		level = zerolog.DebugLevel
+	}
+	zerolog.SetGlobalLevel(level)
+}
+
 // Debug logs a debug message.
 func Debug() *zerolog.Event {
 	return log.Debug()
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// flushTimeout bounds how long the reports are flushed for before the
+// program exits.
+const flushTimeout = 2 * time.Second
+
+// Entry is an error logged, as given to a Reporter.
+type Entry struct {
+	Time    time.Time
+	Level   zerolog.Level
+	Message string
+	Error   string         // The error logged with Err, if any
+	Caller  string         // file:line that logged the entry
+	Fields  map[string]any // The other fields
+	Stack   []runtime.Frame
+}
+
+// Reporter aggregates the errors logged, e.g. in an error tracker such as
+// Sentry.
+type Reporter interface {
+	Report(entry Entry)
+	// Flush waits up to timeout for the reports to be sent.
+	Flush(timeout time.Duration)
+}
+
+var (
+	reporterMu sync.RWMutex
+	reporter   Reporter
+)
+
+// SetReporter reports every entry logged at error level or above to r, with
+// the stack where it was logged.
+func SetReporter(r Reporter) {
+	reporterMu.Lock()
+	defer reporterMu.Unlock()
+	reporter = r
+}
+
+// Flush waits for the reported entries to be sent, before the program exits.
+func Flush() {
+	reporterMu.RLock()
+	r := reporter
+	reporterMu.RUnlock()
+	if r != nil {
+		r.Flush(flushTimeout)
+	}
+}
+
+// reportWriter passes the entries of the logger to the reporter.
+type reportWriter struct{}
+
+func (reportWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (reportWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.ErrorLevel || level == zerolog.NoLevel {
+		return len(p), nil
+	}
+	reporterMu.RLock()
+	r := reporter
+	reporterMu.RUnlock()
+	if r == nil {
+		return len(p), nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(p, &fields); err != nil {
+		return len(p), nil
+	}
+	entry := Entry{Time: time.Now(), Level: level, Stack: callers()}
+	entry.Message, _ = fields[zerolog.MessageFieldName].(string)
+	entry.Error, _ = fields[zerolog.ErrorFieldName].(string)
+	entry.Caller, _ = fields[zerolog.CallerFieldName].(string)
+	for _, key := range []string{zerolog.MessageFieldName, zerolog.ErrorFieldName, zerolog.CallerFieldName,
+		zerolog.LevelFieldName, zerolog.TimestampFieldName} {
+		delete(fields, key)
+	}
+	entry.Fields = fields
+	r.Report(entry)
+
+	// Fatal exits right after writing
+	if level == zerolog.FatalLevel {
+		r.Flush(flushTimeout)
+	}
+	return len(p), nil
+}
+
+// callers returns the stack of the code that logged, leaving out the frames
+// of the logging itself.
+func callers() []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack []runtime.Frame
+	for {
+		frame, more := frames.Next()
+		if !isLoggingFrame(frame.Function) {
+			stack = append(stack, frame)
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+// isLoggingFrame reports whether a function belongs to the logger, zerolog
+// or the runtime.
+func isLoggingFrame(function string) bool {
+	return strings.HasPrefix(function, "github.com/rs/zerolog") ||
+		strings.HasPrefix(function, "github.com/user/githubbot/pkg/logger.") ||
+		strings.HasPrefix(function, "runtime.")
+}
+
+// ReportPanic logs and reports a panic of the calling goroutine before it
+// goes on, crashing the program. Defer it at the start of a goroutine.
+func ReportPanic() {
+	if recovered := recover(); recovered != nil {
+		log.Error().
+			Str("panic", fmt.Sprint(recovered)).
+			Str("stack", string(debug.Stack())).
+			Msg("Panic")
+		Flush()
+		panic(recovered)
+	}
+}
+
+// ReportPanics is HTTP middleware reporting the panics of handlers. The
+// panic goes on to an outer recoverer, such as chi's middleware.Recoverer.
+func ReportPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if recovered != http.ErrAbortHandler {
+					log.Error().
+						Str("panic", fmt.Sprint(recovered)).
+						Str("path", r.URL.Path).
+						Msg("Panic in HTTP handler")
+				}
+				panic(recovered)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}